@@ -1,11 +1,13 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // RAGmeSpec defines the desired state of RAGme
+// +kubebuilder:validation:XValidation:rule="!has(self.replicas) || !has(self.replicas.agent) || self.replicas.agent <= 1 || (has(self.agent) && has(self.agent.coordination) && has(self.agent.coordination.enabled) && self.agent.coordination.enabled)",message="replicas.agent: more than 1 agent requires agent.coordination.enabled"
 type RAGmeSpec struct {
 	// Version specifies the RAGme version to deploy
 	Version string `json:"version,omitempty"`
@@ -16,6 +18,9 @@ type RAGmeSpec struct {
 	// Replicas configuration for each service
 	Replicas RAGmeReplicas `json:"replicas,omitempty"`
 
+	// ReadReplicas splits the api into a query path and an ingestion path
+	ReadReplicas RAGmeReadReplicas `json:"readReplicas,omitempty"`
+
 	// Storage configuration
 	Storage RAGmeStorage `json:"storage,omitempty"`
 
@@ -30,6 +35,139 @@ type RAGmeSpec struct {
 
 	// Authentication configuration
 	Authentication RAGmeAuthentication `json:"authentication,omitempty"`
+
+	// Authorization maps users to application roles
+	Authorization RAGmeAuthorization `json:"authorization,omitempty"`
+
+	// Per-component customization
+	Components RAGmeComponents `json:"components,omitempty"`
+
+	// Frontend delivery configuration
+	Frontend RAGmeFrontend `json:"frontend,omitempty"`
+
+	// Agent ingestion throughput configuration
+	Agent RAGmeAgent `json:"agent,omitempty"`
+
+	// Security controls of the ingestion path
+	Security RAGmeSecurity `json:"security,omitempty"`
+
+	// Processing configures the document processing pipeline
+	Processing RAGmeProcessing `json:"processing,omitempty"`
+
+	// Egress configures the outbound calls of the services
+	Egress RAGmeEgress `json:"egress,omitempty"`
+
+	// LLM configures the use of the language models by the api
+	LLM RAGmeLLM `json:"llm,omitempty"`
+
+	// SecretRefs are exposed as environment variables to the api, mcp and
+	// agent, e.g. the API keys of the LLM providers. A Secret of another
+	// namespace is copied into the namespace of the instance and kept in sync
+	SecretRefs []RAGmeSecretRef `json:"secretRefs,omitempty"`
+
+	// Embeddings configures the embedding calls of the services
+	Embeddings RAGmeEmbeddings `json:"embeddings,omitempty"`
+
+	// API configures the query serving of the api
+	API RAGmeAPI `json:"api,omitempty"`
+
+	// Retrieval tunes how documents are retrieved for a query
+	Retrieval RAGmeRetrieval `json:"retrieval,omitempty"`
+
+	// Evaluation periodically scores the retrieval and answer quality of the instance
+	Evaluation RAGmeEvaluation `json:"evaluation,omitempty"`
+
+	// SLO probes the api and frontend and reports the availability against a target
+	SLO RAGmeSLO `json:"slo,omitempty"`
+
+	// Resilience periodically verifies that the services heal after losing a pod
+	Resilience RAGmeResilience `json:"resilience,omitempty"`
+
+	// NodeRecovery reschedules MinIO and Weaviate off a failed node
+	NodeRecovery RAGmeNodeRecovery `json:"nodeRecovery,omitempty"`
+
+	// Topology places MinIO and the Weaviate shards across availability zones
+	Topology RAGmeTopology `json:"topology,omitempty"`
+
+	// AdminPort serves the health and metrics endpoints of the services on a
+	// separate port and Service, never exposed through an Ingress
+	AdminPort RAGmeAdminPort `json:"adminPort,omitempty"`
+
+	// Monitoring selects how Prometheus discovers the metrics endpoints
+	Monitoring RAGmeMonitoring `json:"monitoring,omitempty"`
+
+	// Tracing exports the spans of the services to an OpenTelemetry collector
+	Tracing RAGmeTracing `json:"tracing,omitempty"`
+
+	// Observability runs the in-cluster telemetry pipeline of the instance
+	Observability RAGmeObservability `json:"observability,omitempty"`
+
+	// Hibernation scales the instance to zero outside business hours
+	Hibernation RAGmeHibernation `json:"hibernation,omitempty"`
+
+	// CloneFrom boots the instance with a clone of the data volumes of an
+	// existing instance in the same namespace
+	CloneFrom *RAGmeCloneFrom `json:"cloneFrom,omitempty"`
+
+	// PreviousInstanceName renames an instance: the volumes and credentials
+	// retained by the deleted instance of that name are re-bound to this one
+	PreviousInstanceName string `json:"previousInstanceName,omitempty"`
+
+	// SeedData is ingested once the instance is ready for the first time
+	SeedData RAGmeSeedData `json:"seedData,omitempty"`
+
+	// TTL deletes the instance once it is older than this duration, e.g. 72h.
+	// Meant for ephemeral instances such as pull request previews
+	TTL string `json:"ttl,omitempty"`
+
+	// CleanupPolicy decides what happens to the data volumes when the instance
+	// is deleted: Delete (default) removes them, Retain keeps them
+	CleanupPolicy string `json:"cleanupPolicy,omitempty"`
+
+	// DriftPolicy decides what happens to managed resources edited outside of
+	// the operator: Correct (default) reverts them, Warn only reports them
+	DriftPolicy string `json:"driftPolicy,omitempty"`
+
+	// Termination controls how the instance is torn down when deleted
+	Termination RAGmeTermination `json:"termination,omitempty"`
+
+	// MaintenanceMode freezes the instance: readOnly stops ingestion and rejects
+	// writes while search stays available, full also stops the api and mcp
+	MaintenanceMode string `json:"maintenanceMode,omitempty"`
+
+	// MaintenanceMessage is shown in the frontend banner during maintenance
+	MaintenanceMessage string `json:"maintenanceMessage,omitempty"`
+
+	// FeatureFlags toggle application features at runtime. They are rendered
+	// into a ConfigMap mounted by the api, mcp, agent and frontend
+	FeatureFlags map[string]bool `json:"featureFlags,omitempty"`
+
+	// FeatureFlagsReload is how running pods pick up changed feature flags:
+	// HotReload (default) refreshes the mounted flags in place, Rollout restarts the pods
+	FeatureFlagsReload string `json:"featureFlagsReload,omitempty"`
+
+	// PodAnnotations are added to the pod template of every generated
+	// workload, the Jobs and CronJobs included
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// DeploymentAnnotations are added to every generated Deployment
+	DeploymentAnnotations map[string]string `json:"deploymentAnnotations,omitempty"`
+
+	// Metadata attributes the instance to an owner for cost allocation
+	Metadata RAGmeMetadata `json:"metadata,omitempty"`
+
+	// StatusEndpoint publishes a summary of the instance on the operator status server
+	StatusEndpoint RAGmeStatusEndpoint `json:"statusEndpoint,omitempty"`
+
+	// Cluster autoscaler integration of the generated pods
+	ClusterAutoscaler RAGmeClusterAutoscaler `json:"clusterAutoscaler,omitempty"`
+
+	// Jobs configures the cleanup of the Jobs created by the operator
+	Jobs RAGmeJobs `json:"jobs,omitempty"`
+
+	// RecreateOnImmutableChange deletes and recreates resources whose update is
+	// rejected because it changes an immutable field. PersistentVolumeClaims are never recreated
+	RecreateOnImmutableChange bool `json:"recreateOnImmutableChange,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeSpec
@@ -37,11 +175,65 @@ func (r *RAGmeSpec) DeepCopyInto(out *RAGmeSpec) {
 	*out = *r
 	r.Images.DeepCopyInto(&out.Images)
 	r.Replicas.DeepCopyInto(&out.Replicas)
+	r.ReadReplicas.DeepCopyInto(&out.ReadReplicas)
 	r.Storage.DeepCopyInto(&out.Storage)
 	r.VectorDB.DeepCopyInto(&out.VectorDB)
 	r.Resources.DeepCopyInto(&out.Resources)
 	r.ExternalAccess.DeepCopyInto(&out.ExternalAccess)
 	r.Authentication.DeepCopyInto(&out.Authentication)
+	r.Authorization.DeepCopyInto(&out.Authorization)
+	r.Components.DeepCopyInto(&out.Components)
+	r.Frontend.DeepCopyInto(&out.Frontend)
+	r.Agent.DeepCopyInto(&out.Agent)
+	r.Security.DeepCopyInto(&out.Security)
+	r.Processing.DeepCopyInto(&out.Processing)
+	r.Egress.DeepCopyInto(&out.Egress)
+	r.LLM.DeepCopyInto(&out.LLM)
+	if r.SecretRefs != nil {
+		out.SecretRefs = make([]RAGmeSecretRef, len(r.SecretRefs))
+		copy(out.SecretRefs, r.SecretRefs)
+	}
+	r.Embeddings.DeepCopyInto(&out.Embeddings)
+	r.API.DeepCopyInto(&out.API)
+	r.Retrieval.DeepCopyInto(&out.Retrieval)
+	r.Evaluation.DeepCopyInto(&out.Evaluation)
+	r.SLO.DeepCopyInto(&out.SLO)
+	r.Resilience.DeepCopyInto(&out.Resilience)
+	r.NodeRecovery.DeepCopyInto(&out.NodeRecovery)
+	r.Topology.DeepCopyInto(&out.Topology)
+	r.AdminPort.DeepCopyInto(&out.AdminPort)
+	r.Monitoring.DeepCopyInto(&out.Monitoring)
+	r.Tracing.DeepCopyInto(&out.Tracing)
+	r.Observability.DeepCopyInto(&out.Observability)
+	r.Hibernation.DeepCopyInto(&out.Hibernation)
+	if r.CloneFrom != nil {
+		out.CloneFrom = new(RAGmeCloneFrom)
+		r.CloneFrom.DeepCopyInto(out.CloneFrom)
+	}
+	r.SeedData.DeepCopyInto(&out.SeedData)
+	r.Termination.DeepCopyInto(&out.Termination)
+	r.Metadata.DeepCopyInto(&out.Metadata)
+	r.StatusEndpoint.DeepCopyInto(&out.StatusEndpoint)
+	r.ClusterAutoscaler.DeepCopyInto(&out.ClusterAutoscaler)
+	r.Jobs.DeepCopyInto(&out.Jobs)
+	if r.FeatureFlags != nil {
+		out.FeatureFlags = make(map[string]bool)
+		for k, v := range r.FeatureFlags {
+			out.FeatureFlags[k] = v
+		}
+	}
+	if r.PodAnnotations != nil {
+		out.PodAnnotations = make(map[string]string)
+		for k, v := range r.PodAnnotations {
+			out.PodAnnotations[k] = v
+		}
+	}
+	if r.DeploymentAnnotations != nil {
+		out.DeploymentAnnotations = make(map[string]string)
+		for k, v := range r.DeploymentAnnotations {
+			out.DeploymentAnnotations[k] = v
+		}
+	}
 }
 
 // DeepCopy returns a deep copy of RAGmeSpec
@@ -100,6 +292,31 @@ func (r *RAGmeReplicas) DeepCopy() *RAGmeReplicas {
 	return out
 }
 
+// RAGmeReadReplicas defines the api replicas serving the read path
+type RAGmeReadReplicas struct {
+	// Enabled runs the read replicas next to the api and generates the
+	// api-read and api-write Services
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Replicas of the read path. Defaults to 2
+	Replicas int32 `json:"replicas,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeReadReplicas
+func (r *RAGmeReadReplicas) DeepCopyInto(out *RAGmeReadReplicas) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeReadReplicas
+func (r *RAGmeReadReplicas) DeepCopy() *RAGmeReadReplicas {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeReadReplicas)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeStorage defines storage configuration
 type RAGmeStorage struct {
 	// MinIO configuration
@@ -107,6 +324,9 @@ type RAGmeStorage struct {
 
 	// Shared storage for watch directory
 	SharedVolume RAGmeSharedVolume `json:"sharedVolume,omitempty"`
+
+	// ObjectStorage selects the provider of the object storage
+	ObjectStorage RAGmeObjectStorage `json:"objectStorage,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeStorage
@@ -114,6 +334,7 @@ func (r *RAGmeStorage) DeepCopyInto(out *RAGmeStorage) {
 	*out = *r
 	r.MinIO.DeepCopyInto(&out.MinIO)
 	r.SharedVolume.DeepCopyInto(&out.SharedVolume)
+	r.ObjectStorage.DeepCopyInto(&out.ObjectStorage)
 }
 
 // DeepCopy returns a deep copy of RAGmeStorage
@@ -126,17 +347,95 @@ func (r *RAGmeStorage) DeepCopy() *RAGmeStorage {
 	return out
 }
 
+// RAGmeObjectStorage defines the object storage holding the documents. The
+// services reach every provider through the S3 API.
+type RAGmeObjectStorage struct {
+	// Provider of the object storage: minio (default, the MinIO of
+	// storage.minio), s3, gcs (through its S3 interoperability API) or azure
+	// (through an S3 gateway run in the namespace)
+	Provider string `json:"provider,omitempty"`
+
+	// Endpoint of the S3 API. Defaults to https://s3.<region>.amazonaws.com
+	// for s3, not used by the other providers
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region of the bucket. Defaults to us-east-1
+	Region string `json:"region,omitempty"`
+
+	// Bucket holding the documents. Defaults to ragme-storage
+	Bucket string `json:"bucket,omitempty"`
+
+	// CredentialsSecretRef references a Secret with accessKey and secretKey:
+	// the access keys of s3, the HMAC keys of gcs, or the account name and
+	// key of azure. Required unless the provider is minio
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+
+	// ExpirationDays removes the documents older than this many days. 0 keeps them
+	ExpirationDays int32 `json:"expirationDays,omitempty"`
+
+	// GatewayImage of the S3 gateway of the azure provider. Defaults to andrewgaul/s3proxy
+	GatewayImage string `json:"gatewayImage,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeObjectStorage
+func (r *RAGmeObjectStorage) DeepCopyInto(out *RAGmeObjectStorage) {
+	*out = *r
+	if r.CredentialsSecretRef != nil {
+		out.CredentialsSecretRef = new(corev1.LocalObjectReference)
+		*out.CredentialsSecretRef = *r.CredentialsSecretRef
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeObjectStorage
+func (r *RAGmeObjectStorage) DeepCopy() *RAGmeObjectStorage {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeObjectStorage)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeMinIOStorage defines MinIO storage settings
 type RAGmeMinIOStorage struct {
-	Enabled     bool   `json:"enabled,omitempty"`
+	Enabled bool `json:"enabled,omitempty"`
+
+	// StorageSize of the MinIO volume, e.g. 10Gi
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.matches('^([0-9]+([.][0-9]*)?|[.][0-9]+)([eE][-+]?[0-9]+|[KMGTPE]i|[numkMGTPE])?$')",message="must be a quantity, e.g. 10Gi"
 	StorageSize string `json:"storageSize,omitempty"`
-	AccessKey   string `json:"accessKey,omitempty"`
-	SecretKey   string `json:"secretKey,omitempty"`
+
+	// Deprecated: AccessKey of the root user in plaintext, use existingSecretRef
+	AccessKey string `json:"accessKey,omitempty"`
+
+	// Deprecated: SecretKey of the root user in plaintext, use existingSecretRef
+	SecretKey string `json:"secretKey,omitempty"`
+
+	// ExistingSecretRef references a Secret with the accessKey and secretKey
+	// of the root user. Without it nor secretKeyRefs, the operator generates
+	// the <name>-minio-root Secret
+	ExistingSecretRef *corev1.LocalObjectReference `json:"existingSecretRef,omitempty"`
+
+	// SecretKeyRefs select the keys of the root user in Secrets, when they
+	// are not stored under accessKey and secretKey of a single Secret
+	SecretKeyRefs RAGmeMinIOSecretKeyRefs `json:"secretKeyRefs,omitempty"`
+
+	// Prometheus metrics and capacity monitoring
+	Metrics RAGmeMinIOMetrics `json:"metrics,omitempty"`
+
+	// Credentials of the services accessing the object storage
+	Credentials RAGmeStorageCredentials `json:"credentials,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeMinIOStorage
 func (r *RAGmeMinIOStorage) DeepCopyInto(out *RAGmeMinIOStorage) {
 	*out = *r
+	if r.ExistingSecretRef != nil {
+		out.ExistingSecretRef = new(corev1.LocalObjectReference)
+		*out.ExistingSecretRef = *r.ExistingSecretRef
+	}
+	r.SecretKeyRefs.DeepCopyInto(&out.SecretKeyRefs)
+	r.Metrics.DeepCopyInto(&out.Metrics)
+	r.Credentials.DeepCopyInto(&out.Credentials)
 }
 
 // DeepCopy returns a deep copy of RAGmeMinIOStorage
@@ -149,15 +448,150 @@ func (r *RAGmeMinIOStorage) DeepCopy() *RAGmeMinIOStorage {
 	return out
 }
 
+// RAGmeMinIOSecretKeyRefs select the keys holding the MinIO root user
+type RAGmeMinIOSecretKeyRefs struct {
+	AccessKey *corev1.SecretKeySelector `json:"accessKey,omitempty"`
+	SecretKey *corev1.SecretKeySelector `json:"secretKey,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMinIOSecretKeyRefs
+func (r *RAGmeMinIOSecretKeyRefs) DeepCopyInto(out *RAGmeMinIOSecretKeyRefs) {
+	*out = *r
+	if r.AccessKey != nil {
+		out.AccessKey = r.AccessKey.DeepCopy()
+	}
+	if r.SecretKey != nil {
+		out.SecretKey = r.SecretKey.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeMinIOSecretKeyRefs
+func (r *RAGmeMinIOSecretKeyRefs) DeepCopy() *RAGmeMinIOSecretKeyRefs {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMinIOSecretKeyRefs)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeStorageCredentials defines the object storage credentials of the
+// services. The operator can create a scoped MinIO user per service, or the
+// credentials can be brought in Secrets, e.g. for external object storage.
+type RAGmeStorageCredentials struct {
+	// PerService creates distinct MinIO users for the api (read-write), the
+	// agent (read-only) and backup jobs (read-only)
+	PerService bool `json:"perService,omitempty"`
+
+	// Buckets the service users are scoped to, wildcards allowed. Defaults to ragme-*
+	Buckets []string `json:"buckets,omitempty"`
+
+	// RotationPeriod of the generated secret keys (e.g. 720h). Empty disables rotation
+	RotationPeriod string `json:"rotationPeriod,omitempty"`
+
+	// SecretRefs replace the generated users. Each Secret holds accessKey and secretKey
+	SecretRefs RAGmeStorageSecretRefs `json:"secretRefs,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeStorageCredentials
+func (r *RAGmeStorageCredentials) DeepCopyInto(out *RAGmeStorageCredentials) {
+	*out = *r
+	if r.Buckets != nil {
+		out.Buckets = make([]string, len(r.Buckets))
+		copy(out.Buckets, r.Buckets)
+	}
+	r.SecretRefs.DeepCopyInto(&out.SecretRefs)
+}
+
+// DeepCopy returns a deep copy of RAGmeStorageCredentials
+func (r *RAGmeStorageCredentials) DeepCopy() *RAGmeStorageCredentials {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeStorageCredentials)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeStorageSecretRefs references the credential Secrets of each service
+type RAGmeStorageSecretRefs struct {
+	API    *corev1.LocalObjectReference `json:"api,omitempty"`
+	Agent  *corev1.LocalObjectReference `json:"agent,omitempty"`
+	Backup *corev1.LocalObjectReference `json:"backup,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeStorageSecretRefs
+func (r *RAGmeStorageSecretRefs) DeepCopyInto(out *RAGmeStorageSecretRefs) {
+	*out = *r
+	if r.API != nil {
+		out.API = new(corev1.LocalObjectReference)
+		*out.API = *r.API
+	}
+	if r.Agent != nil {
+		out.Agent = new(corev1.LocalObjectReference)
+		*out.Agent = *r.Agent
+	}
+	if r.Backup != nil {
+		out.Backup = new(corev1.LocalObjectReference)
+		*out.Backup = *r.Backup
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeStorageSecretRefs
+func (r *RAGmeStorageSecretRefs) DeepCopy() *RAGmeStorageSecretRefs {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeStorageSecretRefs)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMinIOMetrics defines MinIO Prometheus metrics settings
+type RAGmeMinIOMetrics struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// AuthType of the metrics endpoint: jwt (bearer token Secret) or public
+	AuthType string `json:"authType,omitempty"`
+
+	// ServiceMonitor creates a Prometheus Operator ServiceMonitor for MinIO
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+
+	// CapacityThresholdPercent of used capacity above which the
+	// StorageAlmostFull condition is raised
+	CapacityThresholdPercent int32 `json:"capacityThresholdPercent,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMinIOMetrics
+func (r *RAGmeMinIOMetrics) DeepCopyInto(out *RAGmeMinIOMetrics) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeMinIOMetrics
+func (r *RAGmeMinIOMetrics) DeepCopy() *RAGmeMinIOMetrics {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMinIOMetrics)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeSharedVolume defines shared volume settings
 type RAGmeSharedVolume struct {
+	// Size of the shared volume, e.g. 5Gi
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.matches('^([0-9]+([.][0-9]*)?|[.][0-9]+)([eE][-+]?[0-9]+|[KMGTPE]i|[numkMGTPE])?$')",message="must be a quantity, e.g. 10Gi"
 	Size         string `json:"size,omitempty"`
 	StorageClass string `json:"storageClass,omitempty"`
+
+	// Monitoring of the watch-directory utilization
+	Monitoring RAGmeVolumeMonitoring `json:"monitoring,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeSharedVolume
 func (r *RAGmeSharedVolume) DeepCopyInto(out *RAGmeSharedVolume) {
 	*out = *r
+	r.Monitoring.DeepCopyInto(&out.Monitoring)
 }
 
 // DeepCopy returns a deep copy of RAGmeSharedVolume
@@ -170,11 +604,51 @@ func (r *RAGmeSharedVolume) DeepCopy() *RAGmeSharedVolume {
 	return out
 }
 
+// RAGmeVolumeMonitoring defines the shared volume usage monitor. A sidecar of
+// the api reports the volume usage, which the operator records in status
+type RAGmeVolumeMonitoring struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image of the monitor sidecar. Defaults to busybox
+	Image string `json:"image,omitempty"`
+
+	// ThresholdPercent of used capacity that raises SharedVolumeAlmostFull. Defaults to 85
+	ThresholdPercent int32 `json:"thresholdPercent,omitempty"`
+
+	// AutoExpand grows the PVC when the threshold is crossed. Requires a
+	// StorageClass with allowVolumeExpansion
+	AutoExpand bool `json:"autoExpand,omitempty"`
+
+	// ExpandStepPercent is the growth applied on each expansion. Defaults to 50
+	ExpandStepPercent int32 `json:"expandStepPercent,omitempty"`
+
+	// MaxSize caps automatic expansion (e.g. 100Gi)
+	MaxSize string `json:"maxSize,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeVolumeMonitoring
+func (r *RAGmeVolumeMonitoring) DeepCopyInto(out *RAGmeVolumeMonitoring) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeVolumeMonitoring
+func (r *RAGmeVolumeMonitoring) DeepCopy() *RAGmeVolumeMonitoring {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeVolumeMonitoring)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeVectorDB defines vector database configuration
 type RAGmeVectorDB struct {
 	Type     string          `json:"type,omitempty"`
 	Weaviate RAGmeWeaviateDB `json:"weaviate,omitempty"`
 	Milvus   RAGmeMilvusDB   `json:"milvus,omitempty"`
+
+	// Sharding spreads the vector index across several shards
+	Sharding RAGmeSharding `json:"sharding,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeVectorDB
@@ -182,6 +656,7 @@ func (r *RAGmeVectorDB) DeepCopyInto(out *RAGmeVectorDB) {
 	*out = *r
 	r.Weaviate.DeepCopyInto(&out.Weaviate)
 	r.Milvus.DeepCopyInto(&out.Milvus)
+	r.Sharding.DeepCopyInto(&out.Sharding)
 }
 
 // DeepCopy returns a deep copy of RAGmeVectorDB
@@ -194,10 +669,42 @@ func (r *RAGmeVectorDB) DeepCopy() *RAGmeVectorDB {
 	return out
 }
 
+// RAGmeSharding defines how the vector index is split across shards. Managed
+// Weaviate runs one instance per shard, Milvus uses one collection per shard.
+type RAGmeSharding struct {
+	// Shards is the number of index shards. 0 or 1 disables sharding
+	Shards int32 `json:"shards,omitempty"`
+
+	// RoutingKey is the document attribute hashed to select a shard:
+	// document (default), collection or tenant
+	RoutingKey string `json:"routingKey,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSharding
+func (r *RAGmeSharding) DeepCopyInto(out *RAGmeSharding) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeSharding
+func (r *RAGmeSharding) DeepCopy() *RAGmeSharding {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSharding)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeWeaviateDB defines Weaviate configuration
 type RAGmeWeaviateDB struct {
-	Enabled     bool   `json:"enabled,omitempty"`
+	Enabled bool `json:"enabled,omitempty"`
+
+	// StorageSize of the Weaviate volume, e.g. 2Gi
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.matches('^([0-9]+([.][0-9]*)?|[.][0-9]+)([eE][-+]?[0-9]+|[KMGTPE]i|[numkMGTPE])?$')",message="must be a quantity, e.g. 10Gi"
 	StorageSize string `json:"storageSize,omitempty"`
+
+	// Version of Weaviate (e.g. 1.25.0). Changing it runs a checked upgrade
+	Version string `json:"version,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeWeaviateDB
@@ -217,9 +724,18 @@ func (r *RAGmeWeaviateDB) DeepCopy() *RAGmeWeaviateDB {
 
 // RAGmeMilvusDB defines Milvus configuration
 type RAGmeMilvusDB struct {
+	// Enabled deploys a standalone Milvus in the namespace, unless uri
+	// points the services at an existing cluster
 	Enabled bool   `json:"enabled,omitempty"`
 	URI     string `json:"uri,omitempty"`
 	Token   string `json:"token,omitempty"`
+
+	// Version of the standalone Milvus image. Defaults to v2.4.15
+	Version string `json:"version,omitempty"`
+
+	// StorageSize of the standalone Milvus volume, e.g. 10Gi
+	// +kubebuilder:validation:XValidation:rule="size(self) == 0 || self.matches('^([0-9]+([.][0-9]*)?|[.][0-9]+)([eE][-+]?[0-9]+|[KMGTPE]i|[numkMGTPE])?$')",message="must be a quantity, e.g. 10Gi"
+	StorageSize string `json:"storageSize,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeMilvusDB
@@ -335,6 +851,8 @@ func (r *RAGmeResourceLimits) DeepCopy() *RAGmeResourceLimits {
 
 // RAGmeExternalAccess defines external access configuration
 type RAGmeExternalAccess struct {
+	// Type of the external access. Ingress routes the host of the ingress
+	// configuration to the frontend, api and mcp services
 	Type    string             `json:"type,omitempty"` // NodePort, LoadBalancer, Ingress
 	Ingress RAGmeIngressConfig `json:"ingress,omitempty"`
 }
@@ -356,11 +874,24 @@ func (r *RAGmeExternalAccess) DeepCopy() *RAGmeExternalAccess {
 }
 
 // RAGmeIngressConfig defines ingress configuration
+// +kubebuilder:validation:XValidation:rule="!has(self.enabled) || !self.enabled || (has(self.host) && size(self.host) > 0)",message="host is required when the ingress is enabled"
 type RAGmeIngressConfig struct {
 	Enabled     bool              `json:"enabled,omitempty"`
 	Host        string            `json:"host,omitempty"`
 	TLSEnabled  bool              `json:"tlsEnabled,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TLSSecretName is the Secret holding the certificate of the host.
+	// Defaults to <name>-tls, e.g. issued by cert-manager
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+
+	// IngressClassName of the generated Ingresses. Defaults to the cluster
+	// default IngressClass, or the only one installed
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// Auth exposes operational routes, which have no authentication of their
+	// own, behind an oauth2-proxy managed by the operator
+	Auth RAGmeIngressAuth `json:"auth,omitempty"`
 }
 
 // RAGmeAuthentication defines authentication configuration
@@ -370,6 +901,19 @@ type RAGmeAuthentication struct {
 
 	// Session configuration
 	Session RAGmeSessionConfig `json:"session,omitempty"`
+
+	// Machine-to-machine authentication for headless clients
+	ServiceAuth RAGmeServiceAuth `json:"serviceAuth,omitempty"`
+
+	// LDAP/Active Directory authentication
+	LDAP RAGmeLDAPConfig `json:"ldap,omitempty"`
+
+	// Anonymous access for public, query-only knowledge bases
+	Anonymous RAGmeAnonymousAccess `json:"anonymous,omitempty"`
+
+	// BootstrapAdmins are granted admin rights on their first login. Entries
+	// are emails or OAuth subjects written as <provider>:<subject>
+	BootstrapAdmins []string `json:"bootstrapAdmins,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *RAGmeAuthentication
@@ -377,6 +921,13 @@ func (r *RAGmeAuthentication) DeepCopyInto(out *RAGmeAuthentication) {
 	*out = *r
 	r.OAuth.DeepCopyInto(&out.OAuth)
 	r.Session.DeepCopyInto(&out.Session)
+	r.ServiceAuth.DeepCopyInto(&out.ServiceAuth)
+	r.LDAP.DeepCopyInto(&out.LDAP)
+	r.Anonymous.DeepCopyInto(&out.Anonymous)
+	if r.BootstrapAdmins != nil {
+		out.BootstrapAdmins = make([]string, len(r.BootstrapAdmins))
+		copy(out.BootstrapAdmins, r.BootstrapAdmins)
+	}
 }
 
 // DeepCopy returns a deep copy of RAGmeAuthentication
@@ -389,6 +940,72 @@ func (r *RAGmeAuthentication) DeepCopy() *RAGmeAuthentication {
 	return out
 }
 
+// RAGmeAuthorization defines the roles of the application users
+type RAGmeAuthorization struct {
+	// Roles grant a role to the users matching a group or email domain. A user
+	// matching several mappings gets the highest role
+	Roles []RAGmeRoleMapping `json:"roles,omitempty"`
+
+	// DefaultRole of authenticated users matching no mapping. Defaults to viewer
+	DefaultRole string `json:"defaultRole,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAuthorization
+func (r *RAGmeAuthorization) DeepCopyInto(out *RAGmeAuthorization) {
+	*out = *r
+	if r.Roles != nil {
+		out.Roles = make([]RAGmeRoleMapping, len(r.Roles))
+		for i := range r.Roles {
+			r.Roles[i].DeepCopyInto(&out.Roles[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeAuthorization
+func (r *RAGmeAuthorization) DeepCopy() *RAGmeAuthorization {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAuthorization)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeRoleMapping grants a role to OAuth or LDAP groups and email domains
+type RAGmeRoleMapping struct {
+	// Role granted: admin, editor or viewer
+	Role string `json:"role"`
+
+	// Groups reported by the identity provider
+	Groups []string `json:"groups,omitempty"`
+
+	// EmailDomains of the users, e.g. example.com
+	EmailDomains []string `json:"emailDomains,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeRoleMapping
+func (r *RAGmeRoleMapping) DeepCopyInto(out *RAGmeRoleMapping) {
+	*out = *r
+	if r.Groups != nil {
+		out.Groups = make([]string, len(r.Groups))
+		copy(out.Groups, r.Groups)
+	}
+	if r.EmailDomains != nil {
+		out.EmailDomains = make([]string, len(r.EmailDomains))
+		copy(out.EmailDomains, r.EmailDomains)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeRoleMapping
+func (r *RAGmeRoleMapping) DeepCopy() *RAGmeRoleMapping {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeRoleMapping)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // RAGmeOAuthConfig defines OAuth configuration
 type RAGmeOAuthConfig struct {
 	// Google OAuth configuration
@@ -443,79 +1060,3589 @@ func (r *RAGmeOAuthProvider) DeepCopy() *RAGmeOAuthProvider {
 	return out
 }
 
-// RAGmeSessionConfig defines session configuration
-type RAGmeSessionConfig struct {
-	SecretKey     string `json:"secretKey,omitempty"`
-	MaxAgeSeconds int32  `json:"maxAgeSeconds,omitempty"`
-	Secure        bool   `json:"secure,omitempty"`
-	HttpOnly      bool   `json:"httpOnly,omitempty"`
-	SameSite      string `json:"sameSite,omitempty"`
+// RAGmeServiceAuth defines API-key and JWT authentication for headless clients
+type RAGmeServiceAuth struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Keys are provisioned by the operator as Secrets named <instance>-apikey-<name>
+	Keys []RAGmeAPIKey `json:"keys,omitempty"`
+
+	// JWT configures validation of externally issued bearer tokens
+	JWT RAGmeJWTConfig `json:"jwt,omitempty"`
 }
 
-// DeepCopyInto copies the receiver into the given *RAGmeSessionConfig
-func (r *RAGmeSessionConfig) DeepCopyInto(out *RAGmeSessionConfig) {
+// DeepCopyInto copies the receiver into the given *RAGmeServiceAuth
+func (r *RAGmeServiceAuth) DeepCopyInto(out *RAGmeServiceAuth) {
 	*out = *r
+	if r.Keys != nil {
+		out.Keys = make([]RAGmeAPIKey, len(r.Keys))
+		for i := range r.Keys {
+			r.Keys[i].DeepCopyInto(&out.Keys[i])
+		}
+	}
+	r.JWT.DeepCopyInto(&out.JWT)
 }
 
-// DeepCopy returns a deep copy of RAGmeSessionConfig
-func (r *RAGmeSessionConfig) DeepCopy() *RAGmeSessionConfig {
+// DeepCopy returns a deep copy of RAGmeServiceAuth
+func (r *RAGmeServiceAuth) DeepCopy() *RAGmeServiceAuth {
 	if r == nil {
 		return nil
 	}
-	out := new(RAGmeSessionConfig)
+	out := new(RAGmeServiceAuth)
 	r.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyInto copies the receiver into the given *RAGmeIngressConfig
-func (r *RAGmeIngressConfig) DeepCopyInto(out *RAGmeIngressConfig) {
+// RAGmeAPIKey defines an API key provisioned for a headless client
+type RAGmeAPIKey struct {
+	Name string `json:"name"`
+
+	// Scopes granted to the key (e.g. query, ingest, admin)
+	Scopes []string `json:"scopes,omitempty"`
+
+	// RotationPeriod after which the key is regenerated (e.g. 720h).
+	// The previous key stays valid until the next rotation
+	RotationPeriod string `json:"rotationPeriod,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAPIKey
+func (r *RAGmeAPIKey) DeepCopyInto(out *RAGmeAPIKey) {
 	*out = *r
-	if r.Annotations != nil {
-		out.Annotations = make(map[string]string)
-		for k, v := range r.Annotations {
+	if r.Scopes != nil {
+		out.Scopes = make([]string, len(r.Scopes))
+		copy(out.Scopes, r.Scopes)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeAPIKey
+func (r *RAGmeAPIKey) DeepCopy() *RAGmeAPIKey {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAPIKey)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeJWTConfig defines validation of JWT bearer tokens
+type RAGmeJWTConfig struct {
+	Issuer   string `json:"issuer,omitempty"`
+	Audience string `json:"audience,omitempty"`
+	JWKSURI  string `json:"jwksUri,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeJWTConfig
+func (r *RAGmeJWTConfig) DeepCopyInto(out *RAGmeJWTConfig) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeJWTConfig
+func (r *RAGmeJWTConfig) DeepCopy() *RAGmeJWTConfig {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeJWTConfig)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLDAPConfig defines LDAP/Active Directory authentication
+type RAGmeLDAPConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// URL of the directory server (ldap:// or ldaps://)
+	URL string `json:"url,omitempty"`
+
+	// BindDN used to search the directory
+	BindDN string `json:"bindDN,omitempty"`
+
+	// BindPasswordSecretRef selects the Secret key holding the bind password
+	BindPasswordSecretRef *corev1.SecretKeySelector `json:"bindPasswordSecretRef,omitempty"`
+
+	// UserSearch locates the user entry to authenticate
+	UserSearch RAGmeLDAPSearch `json:"userSearch,omitempty"`
+
+	// GroupSearch resolves the groups of an authenticated user
+	GroupSearch RAGmeLDAPSearch `json:"groupSearch,omitempty"`
+
+	// TLS configuration of the connection
+	TLS RAGmeLDAPTLS `json:"tls,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLDAPConfig
+func (r *RAGmeLDAPConfig) DeepCopyInto(out *RAGmeLDAPConfig) {
+	*out = *r
+	if r.BindPasswordSecretRef != nil {
+		out.BindPasswordSecretRef = r.BindPasswordSecretRef.DeepCopy()
+	}
+	r.TLS.DeepCopyInto(&out.TLS)
+}
+
+// DeepCopy returns a deep copy of RAGmeLDAPConfig
+func (r *RAGmeLDAPConfig) DeepCopy() *RAGmeLDAPConfig {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLDAPConfig)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLDAPSearch defines an LDAP search
+type RAGmeLDAPSearch struct {
+	// BaseDN to search from
+	BaseDN string `json:"baseDN,omitempty"`
+
+	// Filter applied to the search, e.g. (sAMAccountName={username})
+	Filter string `json:"filter,omitempty"`
+
+	// Attribute holding the user name or group name
+	Attribute string `json:"attribute,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLDAPSearch
+func (r *RAGmeLDAPSearch) DeepCopyInto(out *RAGmeLDAPSearch) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLDAPSearch
+func (r *RAGmeLDAPSearch) DeepCopy() *RAGmeLDAPSearch {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLDAPSearch)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLDAPTLS defines TLS settings of the LDAP connection
+type RAGmeLDAPTLS struct {
+	// StartTLS upgrades a plain ldap:// connection
+	StartTLS bool `json:"startTLS,omitempty"`
+
+	// InsecureSkipVerify disables server certificate verification
+	InsecureSkipVerify bool `json:"insecureSkipVerify,omitempty"`
+
+	// CASecretRef selects the Secret key holding the CA bundle
+	CASecretRef *corev1.SecretKeySelector `json:"caSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLDAPTLS
+func (r *RAGmeLDAPTLS) DeepCopyInto(out *RAGmeLDAPTLS) {
+	*out = *r
+	if r.CASecretRef != nil {
+		out.CASecretRef = r.CASecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeLDAPTLS
+func (r *RAGmeLDAPTLS) DeepCopy() *RAGmeLDAPTLS {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLDAPTLS)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAnonymousAccess defines unauthenticated access to an instance
+type RAGmeAnonymousAccess struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Role granted to anonymous users. Only read-only is supported
+	Role string `json:"role,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAnonymousAccess
+func (r *RAGmeAnonymousAccess) DeepCopyInto(out *RAGmeAnonymousAccess) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeAnonymousAccess
+func (r *RAGmeAnonymousAccess) DeepCopy() *RAGmeAnonymousAccess {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAnonymousAccess)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSessionConfig defines session configuration
+type RAGmeSessionConfig struct {
+	SecretKey     string `json:"secretKey,omitempty"`
+	MaxAgeSeconds int32  `json:"maxAgeSeconds,omitempty"`
+	Secure        bool   `json:"secure,omitempty"`
+	HttpOnly      bool   `json:"httpOnly,omitempty"`
+	SameSite      string `json:"sameSite,omitempty"`
+
+	// Store holding the sessions. Must be shared when the api runs more than one replica
+	Store RAGmeSessionStore `json:"store,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSessionConfig
+func (r *RAGmeSessionConfig) DeepCopyInto(out *RAGmeSessionConfig) {
+	*out = *r
+	r.Store.DeepCopyInto(&out.Store)
+}
+
+// DeepCopy returns a deep copy of RAGmeSessionConfig
+func (r *RAGmeSessionConfig) DeepCopy() *RAGmeSessionConfig {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSessionConfig)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSessionStore defines where sessions are stored
+type RAGmeSessionStore struct {
+	// Type of the store: memory, redis or postgres. Defaults to memory
+	Type string `json:"type,omitempty"`
+
+	// Redis store. A Redis instance is managed by the operator unless a URL is given
+	Redis RAGmeRedisSessionStore `json:"redis,omitempty"`
+
+	// Postgres store
+	Postgres RAGmePostgresSessionStore `json:"postgres,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSessionStore
+func (r *RAGmeSessionStore) DeepCopyInto(out *RAGmeSessionStore) {
+	*out = *r
+	r.Redis.DeepCopyInto(&out.Redis)
+	r.Postgres.DeepCopyInto(&out.Postgres)
+}
+
+// DeepCopy returns a deep copy of RAGmeSessionStore
+func (r *RAGmeSessionStore) DeepCopy() *RAGmeSessionStore {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSessionStore)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeRedisSessionStore defines the Redis session store
+type RAGmeRedisSessionStore struct {
+	// URL of an external Redis (redis://...)
+	URL string `json:"url,omitempty"`
+
+	// URLSecretRef selects the Secret key holding the URL of an external Redis
+	URLSecretRef *corev1.SecretKeySelector `json:"urlSecretRef,omitempty"`
+
+	// Image of the managed Redis
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeRedisSessionStore
+func (r *RAGmeRedisSessionStore) DeepCopyInto(out *RAGmeRedisSessionStore) {
+	*out = *r
+	if r.URLSecretRef != nil {
+		out.URLSecretRef = r.URLSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeRedisSessionStore
+func (r *RAGmeRedisSessionStore) DeepCopy() *RAGmeRedisSessionStore {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeRedisSessionStore)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmePostgresSessionStore defines the Postgres session store
+type RAGmePostgresSessionStore struct {
+	// URLSecretRef selects the Secret key holding the Postgres connection URL
+	URLSecretRef *corev1.SecretKeySelector `json:"urlSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmePostgresSessionStore
+func (r *RAGmePostgresSessionStore) DeepCopyInto(out *RAGmePostgresSessionStore) {
+	*out = *r
+	if r.URLSecretRef != nil {
+		out.URLSecretRef = r.URLSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmePostgresSessionStore
+func (r *RAGmePostgresSessionStore) DeepCopy() *RAGmePostgresSessionStore {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmePostgresSessionStore)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeIngressConfig
+func (r *RAGmeIngressConfig) DeepCopyInto(out *RAGmeIngressConfig) {
+	*out = *r
+	if r.Annotations != nil {
+		out.Annotations = make(map[string]string)
+		for k, v := range r.Annotations {
 			out.Annotations[k] = v
 		}
 	}
+	r.Auth.DeepCopyInto(&out.Auth)
+}
+
+// DeepCopy returns a deep copy of RAGmeIngressConfig
+func (r *RAGmeIngressConfig) DeepCopy() *RAGmeIngressConfig {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeIngressConfig)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeIngressAuth defines the oauth2-proxy authenticating the operational
+// routes. It signs users in with one of the configured OAuth providers.
+type RAGmeIngressAuth struct {
+	// Enabled deploys oauth2-proxy and the Ingress of the routes
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Provider signing users in: google or github. Defaults to the first enabled one
+	Provider string `json:"provider,omitempty"`
+
+	// Routes exposed behind oauth2-proxy
+	Routes []RAGmeProtectedRoute `json:"routes,omitempty"`
+
+	// EmailDomains allowed to sign in. Defaults to any domain
+	EmailDomains []string `json:"emailDomains,omitempty"`
+
+	// Image of oauth2-proxy
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeIngressAuth
+func (r *RAGmeIngressAuth) DeepCopyInto(out *RAGmeIngressAuth) {
+	*out = *r
+	if r.Routes != nil {
+		out.Routes = make([]RAGmeProtectedRoute, len(r.Routes))
+		copy(out.Routes, r.Routes)
+	}
+	if r.EmailDomains != nil {
+		out.EmailDomains = make([]string, len(r.EmailDomains))
+		copy(out.EmailDomains, r.EmailDomains)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeIngressAuth
+func (r *RAGmeIngressAuth) DeepCopy() *RAGmeIngressAuth {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeIngressAuth)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeProtectedRoute exposes an operational endpoint on its own host
+type RAGmeProtectedRoute struct {
+	// Name of the endpoint: minio-console, weaviate or api-docs
+	Name string `json:"name"`
+
+	// Host serving the endpoint
+	Host string `json:"host"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeProtectedRoute
+func (r *RAGmeProtectedRoute) DeepCopyInto(out *RAGmeProtectedRoute) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeProtectedRoute
+func (r *RAGmeProtectedRoute) DeepCopy() *RAGmeProtectedRoute {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeProtectedRoute)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeComponents defines per-component customization
+type RAGmeComponents struct {
+	API      RAGmeComponentSpec `json:"api,omitempty"`
+	MCP      RAGmeComponentSpec `json:"mcp,omitempty"`
+	Agent    RAGmeComponentSpec `json:"agent,omitempty"`
+	Frontend RAGmeComponentSpec `json:"frontend,omitempty"`
+	MinIO    RAGmeComponentSpec `json:"minio,omitempty"`
+	Weaviate RAGmeComponentSpec `json:"weaviate,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeComponents
+func (r *RAGmeComponents) DeepCopyInto(out *RAGmeComponents) {
+	*out = *r
+	r.API.DeepCopyInto(&out.API)
+	r.MCP.DeepCopyInto(&out.MCP)
+	r.Agent.DeepCopyInto(&out.Agent)
+	r.Frontend.DeepCopyInto(&out.Frontend)
+	r.MinIO.DeepCopyInto(&out.MinIO)
+	r.Weaviate.DeepCopyInto(&out.Weaviate)
+}
+
+// DeepCopy returns a deep copy of RAGmeComponents
+func (r *RAGmeComponents) DeepCopy() *RAGmeComponents {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeComponents)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeComponentSpec defines customization for a single component
+type RAGmeComponentSpec struct {
+	// Sidecars are additional containers added to the component pods.
+	// Names must not collide with the containers managed by the operator
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// TempStorage backs the temporary directory of the managed container
+	TempStorage RAGmeTempStorage `json:"tempStorage,omitempty"`
+
+	// SafeToEvict tells the cluster autoscaler whether it may evict the
+	// component pods to scale a node down. Overrides the clusterAutoscaler defaults
+	SafeToEvict *bool `json:"safeToEvict,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeComponentSpec
+func (r *RAGmeComponentSpec) DeepCopyInto(out *RAGmeComponentSpec) {
+	*out = *r
+	if r.Sidecars != nil {
+		out.Sidecars = make([]corev1.Container, len(r.Sidecars))
+		for i := range r.Sidecars {
+			r.Sidecars[i].DeepCopyInto(&out.Sidecars[i])
+		}
+	}
+	r.TempStorage.DeepCopyInto(&out.TempStorage)
+	if r.SafeToEvict != nil {
+		out.SafeToEvict = new(bool)
+		*out.SafeToEvict = *r.SafeToEvict
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeComponentSpec
+func (r *RAGmeComponentSpec) DeepCopy() *RAGmeComponentSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeComponentSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeFrontend defines how the frontend is delivered to browsers
+type RAGmeFrontend struct {
+	// Assets configures the delivery of the static UI bundles
+	Assets RAGmeFrontendAssets `json:"assets,omitempty"`
+
+	// Streaming tunes the proxied connections carrying chat responses
+	Streaming RAGmeFrontendStreaming `json:"streaming,omitempty"`
+
+	// Notice is an operational notice shown in the frontend banner
+	Notice *RAGmeFrontendNotice `json:"notice,omitempty"`
+
+	// AutomaticNotices shows a notice in the frontend banner during maintenance,
+	// Weaviate upgrades and restores. Defaults to true
+	AutomaticNotices *bool `json:"automaticNotices,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeFrontend
+func (r *RAGmeFrontend) DeepCopyInto(out *RAGmeFrontend) {
+	*out = *r
+	r.Assets.DeepCopyInto(&out.Assets)
+	r.Streaming.DeepCopyInto(&out.Streaming)
+	if r.Notice != nil {
+		out.Notice = new(RAGmeFrontendNotice)
+		r.Notice.DeepCopyInto(out.Notice)
+	}
+	if r.AutomaticNotices != nil {
+		out.AutomaticNotices = new(bool)
+		*out.AutomaticNotices = *r.AutomaticNotices
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeFrontend
+func (r *RAGmeFrontend) DeepCopy() *RAGmeFrontend {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeFrontend)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeFrontendNotice defines a notice shown to the end users, e.g. an
+// announced downtime
+type RAGmeFrontendNotice struct {
+	// Message shown in the banner
+	Message string `json:"message"`
+
+	// Severity of the notice: info, warning or critical. Defaults to info
+	Severity string `json:"severity,omitempty"`
+
+	// Schedule limits the notice to a time window. Shown until removed when unset
+	Schedule *RAGmeNoticeSchedule `json:"schedule,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeFrontendNotice
+func (r *RAGmeFrontendNotice) DeepCopyInto(out *RAGmeFrontendNotice) {
+	*out = *r
+	if r.Schedule != nil {
+		out.Schedule = new(RAGmeNoticeSchedule)
+		r.Schedule.DeepCopyInto(out.Schedule)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeFrontendNotice
+func (r *RAGmeFrontendNotice) DeepCopy() *RAGmeFrontendNotice {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeFrontendNotice)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeNoticeSchedule defines the time window a notice is shown in
+type RAGmeNoticeSchedule struct {
+	// Start of the window. Shown right away when unset
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// End of the window. Shown until removed when unset
+	End *metav1.Time `json:"end,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeNoticeSchedule
+func (r *RAGmeNoticeSchedule) DeepCopyInto(out *RAGmeNoticeSchedule) {
+	*out = *r
+	if r.Start != nil {
+		out.Start = r.Start.DeepCopy()
+	}
+	if r.End != nil {
+		out.End = r.End.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeNoticeSchedule
+func (r *RAGmeNoticeSchedule) DeepCopy() *RAGmeNoticeSchedule {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeNoticeSchedule)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeFrontendAssets defines static asset delivery. The assets can be
+// offloaded to a CDN, with the frontend Service acting as its origin
+type RAGmeFrontendAssets struct {
+	// BaseURL the UI loads its static assets from, e.g. https://cdn.example.com/ragme.
+	// Empty serves the assets from the frontend itself
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// CacheControl header returned with static assets, e.g. public, max-age=31536000, immutable
+	CacheControl string `json:"cacheControl,omitempty"`
+
+	// Compression encodings enabled on the Ingress: gzip and/or brotli
+	Compression []string `json:"compression,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeFrontendAssets
+func (r *RAGmeFrontendAssets) DeepCopyInto(out *RAGmeFrontendAssets) {
+	*out = *r
+	if r.Compression != nil {
+		out.Compression = make([]string, len(r.Compression))
+		copy(out.Compression, r.Compression)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeFrontendAssets
+func (r *RAGmeFrontendAssets) DeepCopy() *RAGmeFrontendAssets {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeFrontendAssets)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeFrontendStreaming defines timeouts and buffers of the WebSocket and
+// streaming connections between browsers, the Ingress, the frontend and the api
+type RAGmeFrontendStreaming struct {
+	// IdleTimeoutSeconds after which an idle streaming connection is closed. Defaults to 3600
+	IdleTimeoutSeconds int32 `json:"idleTimeoutSeconds,omitempty"`
+
+	// ProxyBufferSize of the Ingress for response headers, e.g. 16k
+	ProxyBufferSize string `json:"proxyBufferSize,omitempty"`
+
+	// ProxyBuffering keeps the Ingress buffering responses. Disabled by default
+	// so streamed tokens reach the browser as they are generated
+	ProxyBuffering bool `json:"proxyBuffering,omitempty"`
+
+	// MaxMessageSize of a WebSocket message accepted by the frontend and api, e.g. 1Mi
+	MaxMessageSize string `json:"maxMessageSize,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeFrontendStreaming
+func (r *RAGmeFrontendStreaming) DeepCopyInto(out *RAGmeFrontendStreaming) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeFrontendStreaming
+func (r *RAGmeFrontendStreaming) DeepCopy() *RAGmeFrontendStreaming {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeFrontendStreaming)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTempStorage defines the emptyDir volume mounted at /tmp. Bounding it
+// keeps large processing batches from filling the node disk
+type RAGmeTempStorage struct {
+	// SizeLimit of the volume (e.g. 2Gi). The pod is evicted when it is exceeded
+	SizeLimit string `json:"sizeLimit,omitempty"`
+
+	// Medium backing the volume: Disk (default) or Memory. Memory-backed
+	// volumes count against the container memory limit
+	Medium string `json:"medium,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTempStorage
+func (r *RAGmeTempStorage) DeepCopyInto(out *RAGmeTempStorage) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeTempStorage
+func (r *RAGmeTempStorage) DeepCopy() *RAGmeTempStorage {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTempStorage)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAgent defines how the agent processes the files of the watch directory
+type RAGmeAgent struct {
+	// Concurrency is the number of files processed in parallel
+	Concurrency int32 `json:"concurrency,omitempty"`
+
+	// BatchSize is the number of chunks sent to the vector database per write
+	BatchSize int32 `json:"batchSize,omitempty"`
+
+	// PollInterval is how often the watch directory is scanned for new files, e.g. 30s
+	PollInterval string `json:"pollInterval,omitempty"`
+
+	// FileTypes restricts ingestion to the given file extensions, e.g. pdf, docx.
+	// All supported types are ingested when empty
+	FileTypes []string `json:"fileTypes,omitempty"`
+
+	// ExcludePatterns are glob patterns of files the agent ignores, e.g. *.tmp
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+
+	// Backpressure bounds the files the agent accepts from the watch directory
+	Backpressure RAGmeAgentBackpressure `json:"backpressure,omitempty"`
+
+	// Coordination lets several agents share the watch directory
+	Coordination RAGmeAgentCoordination `json:"coordination,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAgent
+func (r *RAGmeAgent) DeepCopyInto(out *RAGmeAgent) {
+	*out = *r
+	if r.FileTypes != nil {
+		out.FileTypes = make([]string, len(r.FileTypes))
+		copy(out.FileTypes, r.FileTypes)
+	}
+	if r.ExcludePatterns != nil {
+		out.ExcludePatterns = make([]string, len(r.ExcludePatterns))
+		copy(out.ExcludePatterns, r.ExcludePatterns)
+	}
+	r.Backpressure.DeepCopyInto(&out.Backpressure)
+}
+
+// DeepCopy returns a deep copy of RAGmeAgent
+func (r *RAGmeAgent) DeepCopy() *RAGmeAgent {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAgent)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAgentBackpressure defines how the agent handles more files than it can
+// ingest, so a single large dump cannot take down ingestion
+type RAGmeAgentBackpressure struct {
+	// MaxQueuedFiles is the number of files waiting for ingestion above which
+	// new files are turned away. Unbounded when 0
+	MaxQueuedFiles int32 `json:"maxQueuedFiles,omitempty"`
+
+	// MaxFileSize is the size above which a file is turned away, e.g. 500Mi.
+	// Unbounded when empty
+	MaxFileSize string `json:"maxFileSize,omitempty"`
+
+	// Behavior is reject (default), moving the files turned away to the
+	// rejected directory of the watch directory, or park, moving them to the
+	// parked directory to be ingested once the queue drains
+	Behavior string `json:"behavior,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAgentBackpressure
+func (r *RAGmeAgentBackpressure) DeepCopyInto(out *RAGmeAgentBackpressure) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeAgentBackpressure
+func (r *RAGmeAgentBackpressure) DeepCopy() *RAGmeAgentBackpressure {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAgentBackpressure)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAgentCoordination defines how several agents share the watch
+// directory. Each file is claimed by a single agent before it is ingested,
+// so the agent can be scaled beyond one replica
+type RAGmeAgentCoordination struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAgentCoordination
+func (r *RAGmeAgentCoordination) DeepCopyInto(out *RAGmeAgentCoordination) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeAgentCoordination
+func (r *RAGmeAgentCoordination) DeepCopy() *RAGmeAgentCoordination {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAgentCoordination)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSecurity defines the security controls of the ingestion path
+type RAGmeSecurity struct {
+	// Scanning scans uploads for malware before they are ingested
+	Scanning RAGmeScanning `json:"scanning,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSecurity
+func (r *RAGmeSecurity) DeepCopyInto(out *RAGmeSecurity) {
+	*out = *r
+	r.Scanning.DeepCopyInto(&out.Scanning)
+}
+
+// DeepCopy returns a deep copy of RAGmeSecurity
+func (r *RAGmeSecurity) DeepCopy() *RAGmeSecurity {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSecurity)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeScanning defines the malware scanning of uploads. Uploads land in a
+// quarantine directory of the shared volume and are only moved into the watch
+// directory once the scanner cleared them
+type RAGmeScanning struct {
+	// Enabled routes uploads through the quarantine directory
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Engine is clamav (default), scanning with a ClamAV sidecar, or webhook,
+	// posting each file to an external scanner
+	Engine string `json:"engine,omitempty"`
+
+	// Image of the ClamAV scanner
+	Image string `json:"image,omitempty"`
+
+	// WebhookURL receives each file as the body of a POST request with the
+	// webhook engine. A 2xx response clears the file, any other outcome quarantines it
+	WebhookURL string `json:"webhookURL,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeScanning
+func (r *RAGmeScanning) DeepCopyInto(out *RAGmeScanning) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeScanning
+func (r *RAGmeScanning) DeepCopy() *RAGmeScanning {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeScanning)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeProcessing defines the document processing pipeline, rendered into the
+// processing configuration of the agent and api
+type RAGmeProcessing struct {
+	// PIIRedaction detects and redacts personal data before it is indexed
+	PIIRedaction RAGmePIIRedaction `json:"piiRedaction,omitempty"`
+
+	// AllowedTypes restricts ingestion to the matching files. Each entry is a
+	// file extension (pdf), a MIME type (application/pdf), a MIME type
+	// wildcard (text/*) or a group (@executables, @archives). Every type is
+	// allowed when empty
+	AllowedTypes []string `json:"allowedTypes,omitempty"`
+
+	// BlockedTypes are never ingested, even when allowed. Same patterns as
+	// AllowedTypes
+	BlockedTypes []string `json:"blockedTypes,omitempty"`
+
+	// OCR extracts the text of scanned documents and images
+	OCR RAGmeOCR `json:"ocr,omitempty"`
+
+	// Images routes the image files to a dedicated worker pool
+	Images RAGmeImageProcessing `json:"images,omitempty"`
+
+	// Media transcribes the audio and video files so they can be searched
+	Media RAGmeMediaProcessing `json:"media,omitempty"`
+
+	// Dedup skips the documents already ingested, so re-dropped files and
+	// recrawled pages do not multiply vectors
+	Dedup RAGmeDedup `json:"dedup,omitempty"`
+
+	// Versioning replaces the previous versions of an updated document
+	// rather than answering from every version
+	Versioning RAGmeVersioning `json:"versioning,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeProcessing
+func (r *RAGmeProcessing) DeepCopyInto(out *RAGmeProcessing) {
+	*out = *r
+	r.PIIRedaction.DeepCopyInto(&out.PIIRedaction)
+	if r.AllowedTypes != nil {
+		out.AllowedTypes = make([]string, len(r.AllowedTypes))
+		copy(out.AllowedTypes, r.AllowedTypes)
+	}
+	if r.BlockedTypes != nil {
+		out.BlockedTypes = make([]string, len(r.BlockedTypes))
+		copy(out.BlockedTypes, r.BlockedTypes)
+	}
+	r.OCR.DeepCopyInto(&out.OCR)
+	r.Images.DeepCopyInto(&out.Images)
+	r.Media.DeepCopyInto(&out.Media)
+	r.Dedup.DeepCopyInto(&out.Dedup)
+	r.Versioning.DeepCopyInto(&out.Versioning)
+}
+
+// DeepCopy returns a deep copy of RAGmeProcessing
+func (r *RAGmeProcessing) DeepCopy() *RAGmeProcessing {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeProcessing)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeImageProcessing defines the worker pool understanding images, which
+// is much heavier than text and would otherwise delay text ingestion
+type RAGmeImageProcessing struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Workers is the number of image-processor replicas. Defaults to 1
+	Workers int32 `json:"workers,omitempty"`
+
+	// Concurrency is the number of images each worker processes in parallel
+	Concurrency int32 `json:"concurrency,omitempty"`
+
+	// FileTypes are the extensions routed to the workers. Defaults to png,
+	// jpg, jpeg, tif, tiff, bmp, gif and webp
+	FileTypes []string `json:"fileTypes,omitempty"`
+
+	// GPU is the number of GPUs of each worker
+	GPU int32 `json:"gpu,omitempty"`
+
+	// GPUResource is the extended resource of the GPUs. Defaults to nvidia.com/gpu
+	GPUResource string `json:"gpuResource,omitempty"`
+
+	// NodeSelector places the workers on the accelerated nodes
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the taints of the accelerated nodes
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeImageProcessing
+func (r *RAGmeImageProcessing) DeepCopyInto(out *RAGmeImageProcessing) {
+	*out = *r
+	if r.FileTypes != nil {
+		out.FileTypes = make([]string, len(r.FileTypes))
+		copy(out.FileTypes, r.FileTypes)
+	}
+	if r.NodeSelector != nil {
+		out.NodeSelector = make(map[string]string, len(r.NodeSelector))
+		for k, v := range r.NodeSelector {
+			out.NodeSelector[k] = v
+		}
+	}
+	if r.Tolerations != nil {
+		out.Tolerations = make([]corev1.Toleration, len(r.Tolerations))
+		for i := range r.Tolerations {
+			r.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeImageProcessing
+func (r *RAGmeImageProcessing) DeepCopy() *RAGmeImageProcessing {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeImageProcessing)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMediaProcessing defines the transcription of audio and video files,
+// such as meeting recordings and podcasts, into searchable documents
+type RAGmeMediaProcessing struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Model is the Whisper model size: tiny, base, small, medium or
+	// large-v3. Defaults to base
+	Model string `json:"model,omitempty"`
+
+	// Language of the recordings as an ISO 639-1 code, e.g. en. Detected
+	// per file when empty
+	Language string `json:"language,omitempty"`
+
+	// Mode is deployment, a pool of long-running transcribers, or job, a Job
+	// per file that releases its GPU when done. Defaults to deployment
+	Mode string `json:"mode,omitempty"`
+
+	// Workers is the number of transcriber replicas in deployment mode. Defaults to 1
+	Workers int32 `json:"workers,omitempty"`
+
+	// MaxParallelJobs bounds the transcription Jobs running at once in job
+	// mode. Defaults to 2
+	MaxParallelJobs int32 `json:"maxParallelJobs,omitempty"`
+
+	// FileTypes are the extensions transcribed. Defaults to mp3, wav, m4a,
+	// flac, ogg, mp4, mov, mkv and webm
+	FileTypes []string `json:"fileTypes,omitempty"`
+
+	// GPU is the number of GPUs of each transcriber
+	GPU int32 `json:"gpu,omitempty"`
+
+	// GPUResource is the extended resource of the GPUs. Defaults to nvidia.com/gpu
+	GPUResource string `json:"gpuResource,omitempty"`
+
+	// NodeSelector places the transcribers on the accelerated nodes
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the taints of the accelerated nodes
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMediaProcessing
+func (r *RAGmeMediaProcessing) DeepCopyInto(out *RAGmeMediaProcessing) {
+	*out = *r
+	if r.FileTypes != nil {
+		out.FileTypes = make([]string, len(r.FileTypes))
+		copy(out.FileTypes, r.FileTypes)
+	}
+	if r.NodeSelector != nil {
+		out.NodeSelector = make(map[string]string, len(r.NodeSelector))
+		for k, v := range r.NodeSelector {
+			out.NodeSelector[k] = v
+		}
+	}
+	if r.Tolerations != nil {
+		out.Tolerations = make([]corev1.Toleration, len(r.Tolerations))
+		for i := range r.Tolerations {
+			r.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeMediaProcessing
+func (r *RAGmeMediaProcessing) DeepCopy() *RAGmeMediaProcessing {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMediaProcessing)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeDedup defines how the ingestion path recognizes the documents already ingested
+type RAGmeDedup struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Strategy recognizing duplicates: hash skips documents with the same
+	// normalized content, semantic also skips documents whose embedding is
+	// closer than SimilarityThreshold to an ingested one. Defaults to hash
+	Strategy string `json:"strategy,omitempty"`
+
+	// SimilarityThreshold is the cosine similarity, between 0 and 1, above
+	// which the semantic strategy considers two documents duplicates.
+	// Defaults to 0.95
+	SimilarityThreshold string `json:"similarityThreshold,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeDedup
+func (r *RAGmeDedup) DeepCopyInto(out *RAGmeDedup) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeDedup
+func (r *RAGmeDedup) DeepCopy() *RAGmeDedup {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDedup)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeVersioning defines how the versions of a document updated in place are kept
+type RAGmeVersioning struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// KeepVersions is the number of versions kept per document, the latest
+	// included. Only the latest is searched, the others are kept for
+	// rollbacks. Defaults to 3
+	KeepVersions int32 `json:"keepVersions,omitempty"`
+
+	// SupersedeOnSamePath makes a document ingested from the source path or
+	// URL of an existing one its new version. Defaults to true
+	SupersedeOnSamePath *bool `json:"supersedeOnSamePath,omitempty"`
+
+	// CleanupSchedule of the Job pruning the vectors and objects of the
+	// versions beyond KeepVersions, in cron format. Defaults to daily at 03:30
+	CleanupSchedule string `json:"cleanupSchedule,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeVersioning
+func (r *RAGmeVersioning) DeepCopyInto(out *RAGmeVersioning) {
+	*out = *r
+	if r.SupersedeOnSamePath != nil {
+		out.SupersedeOnSamePath = new(bool)
+		*out.SupersedeOnSamePath = *r.SupersedeOnSamePath
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeVersioning
+func (r *RAGmeVersioning) DeepCopy() *RAGmeVersioning {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeVersioning)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeOCR defines the text recognition of scanned documents
+type RAGmeOCR struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Languages are the Tesseract language packs to recognize, e.g. eng, deu,
+	// chi_sim. Defaults to eng
+	Languages []string `json:"languages,omitempty"`
+
+	// Locale of the documents, as a BCP 47 tag such as de-CH, used to parse
+	// numbers and dates
+	Locale string `json:"locale,omitempty"`
+
+	// DateOrder is the order of ambiguous dates, DMY, MDY or YMD. Derived from
+	// the locale when empty
+	DateOrder string `json:"dateOrder,omitempty"`
+
+	// LanguageData is where the language packs come from
+	LanguageData RAGmeOCRLanguageData `json:"languageData,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeOCR
+func (r *RAGmeOCR) DeepCopyInto(out *RAGmeOCR) {
+	*out = *r
+	if r.Languages != nil {
+		out.Languages = make([]string, len(r.Languages))
+		copy(out.Languages, r.Languages)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeOCR
+func (r *RAGmeOCR) DeepCopy() *RAGmeOCR {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeOCR)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeOCRLanguageData defines how the language packs are provided to the pods
+type RAGmeOCRLanguageData struct {
+	// Source is download (default), fetching the packs from URL when the pods
+	// start, or image, copying them from an image bundling them
+	Source string `json:"source,omitempty"`
+
+	// URL the packs are downloaded from as <URL>/<language>.traineddata.
+	// Defaults to the tessdata_fast repository
+	URL string `json:"url,omitempty"`
+
+	// Image bundling the packs, required with the image source
+	Image string `json:"image,omitempty"`
+
+	// Path of the packs in the image. Defaults to /usr/share/tesseract-ocr/5/tessdata
+	Path string `json:"path,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeOCRLanguageData
+func (r *RAGmeOCRLanguageData) DeepCopyInto(out *RAGmeOCRLanguageData) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeOCRLanguageData
+func (r *RAGmeOCRLanguageData) DeepCopy() *RAGmeOCRLanguageData {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeOCRLanguageData)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmePIIRedaction defines the detection and redaction of personal data
+type RAGmePIIRedaction struct {
+	Enabled bool `json:"enabled,omitempty"`
+
+	// EntityTypes are the kinds of personal data to detect, e.g. EMAIL, PHONE,
+	// CREDIT_CARD. Defaults to EMAIL, PHONE, CREDIT_CARD and SSN
+	EntityTypes []string `json:"entityTypes,omitempty"`
+
+	// Action is mask (default), replacing the detected entities with their
+	// type, or drop, removing the chunks containing them
+	Action string `json:"action,omitempty"`
+
+	// Service is an external redaction service replacing the built-in detector
+	Service RAGmePIIRedactionService `json:"service,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmePIIRedaction
+func (r *RAGmePIIRedaction) DeepCopyInto(out *RAGmePIIRedaction) {
+	*out = *r
+	if r.EntityTypes != nil {
+		out.EntityTypes = make([]string, len(r.EntityTypes))
+		copy(out.EntityTypes, r.EntityTypes)
+	}
+	r.Service.DeepCopyInto(&out.Service)
+}
+
+// DeepCopy returns a deep copy of RAGmePIIRedaction
+func (r *RAGmePIIRedaction) DeepCopy() *RAGmePIIRedaction {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmePIIRedaction)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmePIIRedactionService defines an external redaction service
+type RAGmePIIRedactionService struct {
+	// URL of the redaction endpoint
+	URL string `json:"url,omitempty"`
+
+	// TokenSecretRef selects the Secret key holding the bearer token of the service
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmePIIRedactionService
+func (r *RAGmePIIRedactionService) DeepCopyInto(out *RAGmePIIRedactionService) {
+	*out = *r
+	if r.TokenSecretRef != nil {
+		out.TokenSecretRef = r.TokenSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmePIIRedactionService
+func (r *RAGmePIIRedactionService) DeepCopy() *RAGmePIIRedactionService {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmePIIRedactionService)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCloneFrom defines the live instance a new instance is cloned from. The
+// data volumes of the source are CSI-cloned when the new volumes are created
+// and its collections are imported. The volumes are cloned one by one while
+// the source keeps running, so the copy is not point-in-time consistent.
+type RAGmeCloneFrom struct {
+	// InstanceRef is the name of the RAGme instance, in the same namespace,
+	// whose volumes are cloned. Requires a CSI driver supporting volume cloning
+	InstanceRef string `json:"instanceRef"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCloneFrom
+func (r *RAGmeCloneFrom) DeepCopyInto(out *RAGmeCloneFrom) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeCloneFrom
+func (r *RAGmeCloneFrom) DeepCopy() *RAGmeCloneFrom {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCloneFrom)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSeedData defines the content a bootstrap Job ingests into a new instance
+type RAGmeSeedData struct {
+	// URLs are ingested as web pages
+	URLs []string `json:"urls,omitempty"`
+
+	// Git copies the files of a repository into the watch directory
+	Git *RAGmeSeedGit `json:"git,omitempty"`
+
+	// S3 copies the objects under a bucket prefix into the watch directory
+	S3 *RAGmeSeedS3 `json:"s3,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSeedData
+func (r *RAGmeSeedData) DeepCopyInto(out *RAGmeSeedData) {
+	*out = *r
+	if r.URLs != nil {
+		out.URLs = make([]string, len(r.URLs))
+		copy(out.URLs, r.URLs)
+	}
+	if r.Git != nil {
+		out.Git = new(RAGmeSeedGit)
+		r.Git.DeepCopyInto(out.Git)
+	}
+	if r.S3 != nil {
+		out.S3 = new(RAGmeSeedS3)
+		r.S3.DeepCopyInto(out.S3)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeSeedData
+func (r *RAGmeSeedData) DeepCopy() *RAGmeSeedData {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSeedData)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSeedGit defines a Git repository to seed the instance from
+type RAGmeSeedGit struct {
+	// URL of the repository, cloned over HTTP(S)
+	URL string `json:"url"`
+
+	// Ref is the branch or tag to clone. Defaults to the default branch
+	Ref string `json:"ref,omitempty"`
+
+	// Path restricts the copied files to a directory of the repository
+	Path string `json:"path,omitempty"`
+
+	// TokenSecretRef holds a token for private repositories
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSeedGit
+func (r *RAGmeSeedGit) DeepCopyInto(out *RAGmeSeedGit) {
+	*out = *r
+	if r.TokenSecretRef != nil {
+		out.TokenSecretRef = r.TokenSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeSeedGit
+func (r *RAGmeSeedGit) DeepCopy() *RAGmeSeedGit {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSeedGit)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSeedS3 defines an S3 bucket prefix to seed the instance from
+type RAGmeSeedS3 struct {
+	// Endpoint of the S3 service, e.g. https://s3.amazonaws.com
+	Endpoint string `json:"endpoint"`
+
+	// Bucket holding the objects
+	Bucket string `json:"bucket"`
+
+	// Prefix of the copied objects
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef names a Secret with accessKey and secretKey entries.
+	// Public buckets are read anonymously
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSeedS3
+func (r *RAGmeSeedS3) DeepCopyInto(out *RAGmeSeedS3) {
+	*out = *r
+	if r.CredentialsSecretRef != nil {
+		out.CredentialsSecretRef = new(corev1.LocalObjectReference)
+		*out.CredentialsSecretRef = *r.CredentialsSecretRef
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeSeedS3
+func (r *RAGmeSeedS3) DeepCopy() *RAGmeSeedS3 {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSeedS3)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLLM configures the use of the language models by the api
+type RAGmeLLM struct {
+	// Budget caps the tokens consumed per day
+	Budget RAGmeLLMBudget `json:"budget,omitempty"`
+
+	// Routing lists the models chat falls back through when a provider fails
+	Routing RAGmeLLMRouting `json:"routing,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLLM
+func (r *RAGmeLLM) DeepCopyInto(out *RAGmeLLM) {
+	*out = *r
+	r.Budget.DeepCopyInto(&out.Budget)
+	r.Routing.DeepCopyInto(&out.Routing)
+}
+
+// DeepCopy returns a deep copy of RAGmeLLM
+func (r *RAGmeLLM) DeepCopy() *RAGmeLLM {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLLM)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSecretRef references a Secret whose keys are exposed as environment variables
+type RAGmeSecretRef struct {
+	// Name of the Secret
+	Name string `json:"name"`
+
+	// Namespace of the Secret, defaults to the namespace of the instance. The
+	// Secret must list the namespace of the instance in its ragme.io/share-with
+	// annotation to be copied
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// RAGmeLLMBudget defines the daily token caps enforced by the api. Days start
+// at midnight UTC, a cap of 0 is unlimited
+type RAGmeLLMBudget struct {
+	// DailyTokens caps the tokens consumed by the instance per day
+	DailyTokens int64 `json:"dailyTokens,omitempty"`
+
+	// PerUserDailyTokens caps the tokens consumed by each user per day
+	PerUserDailyTokens int64 `json:"perUserDailyTokens,omitempty"`
+
+	// AlertThresholdPercent of the daily cap above which the consumption is
+	// reported on the BudgetExceeded condition. Defaults to 80
+	AlertThresholdPercent int32 `json:"alertThresholdPercent,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLLMBudget
+func (r *RAGmeLLMBudget) DeepCopyInto(out *RAGmeLLMBudget) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLLMBudget
+func (r *RAGmeLLMBudget) DeepCopy() *RAGmeLLMBudget {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLLMBudget)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLLMRouting defines the provider fallback of the language model calls
+type RAGmeLLMRouting struct {
+	// Routes are tried in order: a call that fails or times out is retried on
+	// the next route. The services use their built-in model when empty
+	Routes []RAGmeLLMRoute `json:"routes,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLLMRouting
+func (r *RAGmeLLMRouting) DeepCopyInto(out *RAGmeLLMRouting) {
+	*out = *r
+	if r.Routes != nil {
+		out.Routes = make([]RAGmeLLMRoute, len(r.Routes))
+		copy(out.Routes, r.Routes)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeLLMRouting
+func (r *RAGmeLLMRouting) DeepCopy() *RAGmeLLMRouting {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLLMRouting)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLLMRoute is a provider and model of the fallback list
+type RAGmeLLMRoute struct {
+	// Provider of the model, e.g. openai or friendli
+	Provider string `json:"provider"`
+
+	// Model name at the provider, e.g. gpt-4o-mini
+	Model string `json:"model"`
+
+	// Timeout of a call before falling back to the next route, e.g. 30s. Defaults to 60s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+// RAGmeEmbeddings configures the embedding calls of the services
+type RAGmeEmbeddings struct {
+	// Cache keeps the embeddings of identical chunks and queries
+	Cache RAGmeEmbeddingsCache `json:"cache,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEmbeddings
+func (r *RAGmeEmbeddings) DeepCopyInto(out *RAGmeEmbeddings) {
+	*out = *r
+	r.Cache.DeepCopyInto(&out.Cache)
+}
+
+// DeepCopy returns a deep copy of RAGmeEmbeddings
+func (r *RAGmeEmbeddings) DeepCopy() *RAGmeEmbeddings {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEmbeddings)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEmbeddingsCache defines the cache of the embeddings, keyed by the
+// model and the text, so repeated chunks and queries skip the provider
+type RAGmeEmbeddingsCache struct {
+	// Enabled turns the cache on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Backend of the cache. Only redis is supported
+	Backend string `json:"backend,omitempty"`
+
+	// TTL of the cached embeddings, e.g. 720h. Defaults to 168h
+	TTL string `json:"ttl,omitempty"`
+
+	// MaxSize of the cache, e.g. 1Gi. The least recently used embeddings are
+	// evicted beyond it. Defaults to 512Mi
+	MaxSize string `json:"maxSize,omitempty"`
+
+	// Redis backend. A Redis instance is managed by the operator unless a URL is given
+	Redis RAGmeCacheRedis `json:"redis,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEmbeddingsCache
+func (r *RAGmeEmbeddingsCache) DeepCopyInto(out *RAGmeEmbeddingsCache) {
+	*out = *r
+	r.Redis.DeepCopyInto(&out.Redis)
+}
+
+// DeepCopy returns a deep copy of RAGmeEmbeddingsCache
+func (r *RAGmeEmbeddingsCache) DeepCopy() *RAGmeEmbeddingsCache {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEmbeddingsCache)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAPI configures the query serving of the api
+type RAGmeAPI struct {
+	// QueryCache serves repeated questions from a cache of the answers
+	QueryCache RAGmeQueryCache `json:"queryCache,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAPI
+func (r *RAGmeAPI) DeepCopyInto(out *RAGmeAPI) {
+	*out = *r
+	r.QueryCache.DeepCopyInto(&out.QueryCache)
+}
+
+// DeepCopy returns a deep copy of RAGmeAPI
+func (r *RAGmeAPI) DeepCopy() *RAGmeAPI {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAPI)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeQueryCache defines the cache of the query answers of the api
+type RAGmeQueryCache struct {
+	// Enabled turns the cache on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Backend of the cache: memory (default) keeps a cache per api replica,
+	// redis shares it between the replicas
+	Backend string `json:"backend,omitempty"`
+
+	// TTL of the cached answers, e.g. 30m. Defaults to 1h
+	TTL string `json:"ttl,omitempty"`
+
+	// MaxEntries kept in the cache, the least recently used are evicted beyond it. Defaults to 10000
+	MaxEntries int32 `json:"maxEntries,omitempty"`
+
+	// KeyStrategy decides which questions share an answer: exact matches the
+	// query text, normalized (default) ignores case, whitespace and punctuation,
+	// perUser is normalized scoped to the asking user
+	KeyStrategy string `json:"keyStrategy,omitempty"`
+
+	// Redis backend. A Redis instance is managed by the operator unless a URL is given
+	Redis RAGmeCacheRedis `json:"redis,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeQueryCache
+func (r *RAGmeQueryCache) DeepCopyInto(out *RAGmeQueryCache) {
+	*out = *r
+	r.Redis.DeepCopyInto(&out.Redis)
+}
+
+// DeepCopy returns a deep copy of RAGmeQueryCache
+func (r *RAGmeQueryCache) DeepCopy() *RAGmeQueryCache {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeQueryCache)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeRetrieval tunes the retrieval of the api and mcp, and the keyword index
+// built when the collections are created
+type RAGmeRetrieval struct {
+	// HybridAlpha weighs the vector score against the keyword score of the
+	// hybrid search, from 0 (keyword only) to 1 (vector only). Defaults to 0.75
+	HybridAlpha string `json:"hybridAlpha,omitempty"`
+
+	// KeywordIndex builds the BM25 index of the text properties used by the
+	// keyword part of the hybrid search. Defaults to true. Only applies to
+	// collections created after the change
+	KeywordIndex *bool `json:"keywordIndex,omitempty"`
+
+	// TopK is the number of chunks retrieved per query. Defaults to 5
+	TopK int32 `json:"topK,omitempty"`
+
+	// Filters restrict every query to the objects whose property equals the
+	// given value, e.g. language: en
+	Filters map[string]string `json:"filters,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeRetrieval
+func (r *RAGmeRetrieval) DeepCopyInto(out *RAGmeRetrieval) {
+	*out = *r
+	if r.KeywordIndex != nil {
+		out.KeywordIndex = new(bool)
+		*out.KeywordIndex = *r.KeywordIndex
+	}
+	if r.Filters != nil {
+		out.Filters = make(map[string]string)
+		for k, v := range r.Filters {
+			out.Filters[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeRetrieval
+func (r *RAGmeRetrieval) DeepCopy() *RAGmeRetrieval {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeRetrieval)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEvaluation defines the scheduled quality evaluation of the instance
+type RAGmeEvaluation struct {
+	// Enabled turns the evaluation on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Schedule of the evaluation runs in cron format. Defaults to "0 4 * * *"
+	Schedule string `json:"schedule,omitempty"`
+
+	// Dataset of the questions asked to the instance, with their expected
+	// sources and answers
+	Dataset RAGmeEvaluationDataset `json:"dataset,omitempty"`
+
+	// Thresholds are the minimum scores per metric, e.g. recall: "0.8".
+	// QualityRegressed is flagged when a score drops below its threshold
+	Thresholds map[string]string `json:"thresholds,omitempty"`
+
+	// Image running the evaluation. Defaults to the api image, which ships the
+	// evaluation harness
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEvaluation
+func (r *RAGmeEvaluation) DeepCopyInto(out *RAGmeEvaluation) {
+	*out = *r
+	r.Dataset.DeepCopyInto(&out.Dataset)
+	if r.Thresholds != nil {
+		out.Thresholds = make(map[string]string)
+		for k, v := range r.Thresholds {
+			out.Thresholds[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeEvaluation
+func (r *RAGmeEvaluation) DeepCopy() *RAGmeEvaluation {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEvaluation)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEvaluationDataset locates the evaluation dataset, a JSON Lines file.
+// Exactly one source is set
+type RAGmeEvaluationDataset struct {
+	// ConfigMapRef selects the ConfigMap entry holding the dataset
+	ConfigMapRef *corev1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+
+	// S3 object holding the dataset
+	S3 *RAGmeEvaluationS3 `json:"s3,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEvaluationDataset
+func (r *RAGmeEvaluationDataset) DeepCopyInto(out *RAGmeEvaluationDataset) {
+	*out = *r
+	if r.ConfigMapRef != nil {
+		out.ConfigMapRef = new(corev1.ConfigMapKeySelector)
+		r.ConfigMapRef.DeepCopyInto(out.ConfigMapRef)
+	}
+	if r.S3 != nil {
+		out.S3 = new(RAGmeEvaluationS3)
+		r.S3.DeepCopyInto(out.S3)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeEvaluationDataset
+func (r *RAGmeEvaluationDataset) DeepCopy() *RAGmeEvaluationDataset {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEvaluationDataset)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEvaluationS3 defines an evaluation dataset stored in a bucket
+type RAGmeEvaluationS3 struct {
+	// Endpoint of the S3 service, e.g. https://s3.amazonaws.com
+	Endpoint string `json:"endpoint"`
+
+	// Bucket holding the dataset
+	Bucket string `json:"bucket"`
+
+	// Key of the dataset object
+	Key string `json:"key"`
+
+	// CredentialsSecretRef names a Secret with accessKey and secretKey entries.
+	// Public buckets are read anonymously
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEvaluationS3
+func (r *RAGmeEvaluationS3) DeepCopyInto(out *RAGmeEvaluationS3) {
+	*out = *r
+	if r.CredentialsSecretRef != nil {
+		out.CredentialsSecretRef = new(corev1.LocalObjectReference)
+		*out.CredentialsSecretRef = *r.CredentialsSecretRef
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeEvaluationS3
+func (r *RAGmeEvaluationS3) DeepCopy() *RAGmeEvaluationS3 {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEvaluationS3)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSLO defines the synthetic probes and the service level objective of the instance
+type RAGmeSLO struct {
+	// Enabled turns the probes on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// AvailabilityTarget is the objective in percent of successful probes, e.g. 99.9. Defaults to 99.5
+	AvailabilityTarget string `json:"availabilityTarget,omitempty"`
+
+	// LatencyTarget is the duration above which a probe counts as failed. Defaults to 2s
+	LatencyTarget string `json:"latencyTarget,omitempty"`
+
+	// ProbeInterval between two probes, e.g. 30s. Defaults to 1m
+	ProbeInterval string `json:"probeInterval,omitempty"`
+
+	// Window over which the availability is computed, e.g. 6h. Defaults to 24h
+	Window string `json:"window,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSLO
+func (r *RAGmeSLO) DeepCopyInto(out *RAGmeSLO) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeSLO
+func (r *RAGmeSLO) DeepCopy() *RAGmeSLO {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSLO)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeResilience defines the self-healing verification of the instance.
+// In its window, the operator deletes a pod of a service and checks that the
+// service is fully available again within the recovery timeout
+type RAGmeResilience struct {
+	// Enabled turns the verification on
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Schedule opening the verification window in cron format. Defaults to
+	// "0 10 * * 2", Tuesdays at 10:00
+	Schedule string `json:"schedule,omitempty"`
+
+	// TimeZone of the schedule, e.g. Europe/Paris. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Components verified in turn, among api, mcp, agent and frontend.
+	// Defaults to mcp and frontend
+	Components []string `json:"components,omitempty"`
+
+	// RecoveryTimeout within which the component must be available again, e.g. 3m. Defaults to 5m
+	RecoveryTimeout string `json:"recoveryTimeout,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeResilience
+func (r *RAGmeResilience) DeepCopyInto(out *RAGmeResilience) {
+	*out = *r
+	if r.Components != nil {
+		out.Components = make([]string, len(r.Components))
+		copy(out.Components, r.Components)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeResilience
+func (r *RAGmeResilience) DeepCopy() *RAGmeResilience {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeResilience)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeNodeRecovery defines the recovery of the stateful components when the
+// node they run on fails. Their ReadWriteOnce volumes stay attached to the
+// lost node, which keeps the replacement pods from starting.
+type RAGmeNodeRecovery struct {
+	// Enabled reports the MinIO and Weaviate pods stranded on an unreachable
+	// node in the NodeFailure condition
+	Enabled bool `json:"enabled,omitempty"`
+
+	// UnreachableAfter is how long a node must be not ready before it is
+	// considered lost, e.g. 10m. Defaults to 5m
+	UnreachableAfter string `json:"unreachableAfter,omitempty"`
+
+	// Force deletes the stranded pods and the VolumeAttachments of their
+	// volumes so the pods are rescheduled. A node that is only partitioned
+	// may still be writing to the volumes, so this is opt-in
+	Force bool `json:"force,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeNodeRecovery
+func (r *RAGmeNodeRecovery) DeepCopyInto(out *RAGmeNodeRecovery) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeNodeRecovery
+func (r *RAGmeNodeRecovery) DeepCopy() *RAGmeNodeRecovery {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeNodeRecovery)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTopology defines the availability zones of the data services
+type RAGmeTopology struct {
+	// Zones the data services run in
+	Zones []string `json:"zones,omitempty"`
+
+	// Mode is spread (default), placing each Weaviate shard and MinIO in
+	// their own zone in turn so a zone outage takes down as little data as
+	// possible, or pin, allowing every data service in any of the zones
+	Mode string `json:"mode,omitempty"`
+
+	// ZoneLabel is the node label holding the zone. Defaults to
+	// topology.kubernetes.io/zone
+	ZoneLabel string `json:"zoneLabel,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTopology
+func (r *RAGmeTopology) DeepCopyInto(out *RAGmeTopology) {
+	*out = *r
+	if r.Zones != nil {
+		out.Zones = make([]string, len(r.Zones))
+		copy(out.Zones, r.Zones)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTopology
+func (r *RAGmeTopology) DeepCopy() *RAGmeTopology {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTopology)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAdminPort defines the port serving the operational endpoints of the
+// api, mcp and frontend: /health, /ready and /metrics
+type RAGmeAdminPort struct {
+	// Enabled moves the operational endpoints to the admin port
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Port of the operational endpoints. Defaults to 9090
+	Port int32 `json:"port,omitempty"`
+
+	// ServiceMonitor creates a Prometheus Operator ServiceMonitor scraping the
+	// metrics of the services on their admin Service
+	ServiceMonitor bool `json:"serviceMonitor,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAdminPort
+func (r *RAGmeAdminPort) DeepCopyInto(out *RAGmeAdminPort) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeAdminPort
+func (r *RAGmeAdminPort) DeepCopy() *RAGmeAdminPort {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAdminPort)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMonitoring defines how the metrics endpoints are scraped
+type RAGmeMonitoring struct {
+	// Mode is serviceMonitor (default) to create Prometheus Operator
+	// ServiceMonitors, or annotations to stamp prometheus.io/scrape, port and
+	// path annotations for clusters without the Prometheus Operator
+	Mode string `json:"mode,omitempty"`
+
+	// AnnotationTarget is pods (default) or services, matching the scrape job
+	// of the Prometheus configuration. Only one is annotated so that each
+	// endpoint is scraped once.
+	AnnotationTarget string `json:"annotationTarget,omitempty"`
+
+	// RemoteWrite ships the metrics to a central store through a bundled
+	// Prometheus agent, without a Prometheus install in the cluster
+	RemoteWrite RAGmeRemoteWrite `json:"remoteWrite,omitempty"`
+
+	// AlertRouting routes the alerts of the namespace through an
+	// AlertmanagerConfig and mutes them during planned upgrades
+	AlertRouting RAGmeAlertRouting `json:"alertRouting,omitempty"`
+}
+
+// RAGmeTracing defines the OpenTelemetry tracing of the services. The api,
+// mcp, agent and frontend get the standard OTEL_* variables, so a chat request
+// is traced from the frontend through the api and mcp down to the vector
+// database calls
+type RAGmeTracing struct {
+	// Enabled exports the spans of the services
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Endpoint of the OTLP receiver, e.g. http://otel-collector.observability:4317.
+	// Defaults to the in-cluster collector when it is enabled
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Protocol of the OTLP exporter: grpc or http/protobuf. Defaults to grpc
+	Protocol string `json:"protocol,omitempty"`
+
+	// SampleRate is the ratio of the traces started by the services that are
+	// recorded, between 0 and 1. Sampling decisions of the caller are honored.
+	// Defaults to 0.1
+	SampleRate string `json:"sampleRate,omitempty"`
+
+	// Propagators of the trace context across services: tracecontext, baggage,
+	// b3, b3multi or jaeger. Defaults to tracecontext and baggage
+	Propagators []string `json:"propagators,omitempty"`
+
+	// HeadersSecretRef references a Secret key holding the OTLP headers, e.g.
+	// the API key of a hosted tracing backend, as comma-separated key=value pairs
+	HeadersSecretRef *corev1.SecretKeySelector `json:"headersSecretRef,omitempty"`
+
+	// ResourceAttributes are added to the spans of all services
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTracing
+func (r *RAGmeTracing) DeepCopyInto(out *RAGmeTracing) {
+	*out = *r
+	if r.Propagators != nil {
+		out.Propagators = make([]string, len(r.Propagators))
+		copy(out.Propagators, r.Propagators)
+	}
+	if r.HeadersSecretRef != nil {
+		out.HeadersSecretRef = r.HeadersSecretRef.DeepCopy()
+	}
+	if r.ResourceAttributes != nil {
+		out.ResourceAttributes = make(map[string]string, len(r.ResourceAttributes))
+		for k, v := range r.ResourceAttributes {
+			out.ResourceAttributes[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTracing
+func (r *RAGmeTracing) DeepCopy() *RAGmeTracing {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTracing)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeObservability defines the telemetry pipeline deployed with the instance
+type RAGmeObservability struct {
+	// Collector runs an OpenTelemetry Collector receiving the telemetry of the
+	// services, for clusters without a collector of their own
+	Collector RAGmeCollector `json:"collector,omitempty"`
+
+	// Profiling serves profiling endpoints in the services and scrapes them
+	// into a continuous profiling backend
+	Profiling RAGmeProfiling `json:"profiling,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeObservability
+func (r *RAGmeObservability) DeepCopyInto(out *RAGmeObservability) {
+	*out = *r
+	r.Collector.DeepCopyInto(&out.Collector)
+	r.Profiling.DeepCopyInto(&out.Profiling)
+}
+
+// DeepCopy returns a deep copy of RAGmeObservability
+func (r *RAGmeObservability) DeepCopy() *RAGmeObservability {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeObservability)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeProfiling defines the continuous profiling of the api, mcp and agent.
+// The services serve pprof-compatible CPU and heap profiles on a dedicated
+// port, and a scraper deployed with the instance ships them to Pyroscope or
+// Parca
+type RAGmeProfiling struct {
+	// Enabled serves the profiling endpoints and runs the scraper
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Backend is pyroscope (default) or parca
+	Backend string `json:"backend,omitempty"`
+
+	// Endpoint of the backend: the URL of Pyroscope, e.g.
+	// http://pyroscope.observability:4040, or the host:port of the Parca gRPC
+	// API, e.g. parca.observability:7070
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Insecure connects to Parca without TLS
+	Insecure bool `json:"insecure,omitempty"`
+
+	// TokenSecretRef references a Secret key holding the bearer token of the
+	// backend
+	TokenSecretRef *corev1.SecretKeySelector `json:"tokenSecretRef,omitempty"`
+
+	// Port of the profiling endpoints in the services. Defaults to 6060
+	Port int32 `json:"port,omitempty"`
+
+	// ScrapeInterval of the profiles. Defaults to 15s
+	ScrapeInterval string `json:"scrapeInterval,omitempty"`
+
+	// Image of the scraper. Defaults to Grafana Alloy for pyroscope and Parca
+	// for parca
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeProfiling
+func (r *RAGmeProfiling) DeepCopyInto(out *RAGmeProfiling) {
+	*out = *r
+	if r.TokenSecretRef != nil {
+		out.TokenSecretRef = r.TokenSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeProfiling
+func (r *RAGmeProfiling) DeepCopy() *RAGmeProfiling {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeProfiling)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCollector defines the in-cluster OpenTelemetry Collector. It receives
+// the spans of the services over OTLP, scrapes their metrics endpoints and
+// forwards both to the exporters. The tracing endpoint defaults to the
+// collector when it is enabled.
+type RAGmeCollector struct {
+	// Enabled deploys the collector
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Image of the collector. The contrib distribution is required by the
+	// prometheus receiver and the prometheusremotewrite exporter
+	Image string `json:"image,omitempty"`
+
+	// Replicas of the collector. Defaults to 1
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ScrapeInterval of the metrics endpoints of the services. Defaults to 30s
+	ScrapeInterval string `json:"scrapeInterval,omitempty"`
+
+	// Exporters are the backends the telemetry is forwarded to
+	Exporters []RAGmeCollectorExporter `json:"exporters,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCollector
+func (r *RAGmeCollector) DeepCopyInto(out *RAGmeCollector) {
+	*out = *r
+	if r.Exporters != nil {
+		out.Exporters = make([]RAGmeCollectorExporter, len(r.Exporters))
+		for i := range r.Exporters {
+			r.Exporters[i].DeepCopyInto(&out.Exporters[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCollector
+func (r *RAGmeCollector) DeepCopy() *RAGmeCollector {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollector)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCollectorExporter defines a backend of the collector
+type RAGmeCollectorExporter struct {
+	// Name of the exporter, unique within the collector
+	Name string `json:"name"`
+
+	// Type is otlp (gRPC), otlphttp, prometheusremotewrite or debug
+	Type string `json:"type"`
+
+	// Endpoint of the backend. Not used by the debug exporter
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Insecure disables TLS towards an otlp endpoint
+	Insecure bool `json:"insecure,omitempty"`
+
+	// Signals forwarded to the exporter: traces and/or metrics. Defaults to
+	// the signals supported by the type
+	Signals []string `json:"signals,omitempty"`
+
+	// HeadersFrom sets request headers, e.g. the API key of a hosted backend,
+	// from Secret keys
+	HeadersFrom map[string]corev1.SecretKeySelector `json:"headersFrom,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCollectorExporter
+func (r *RAGmeCollectorExporter) DeepCopyInto(out *RAGmeCollectorExporter) {
+	*out = *r
+	if r.Signals != nil {
+		out.Signals = make([]string, len(r.Signals))
+		copy(out.Signals, r.Signals)
+	}
+	if r.HeadersFrom != nil {
+		out.HeadersFrom = make(map[string]corev1.SecretKeySelector, len(r.HeadersFrom))
+		for k, v := range r.HeadersFrom {
+			out.HeadersFrom[k] = *v.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCollectorExporter
+func (r *RAGmeCollectorExporter) DeepCopy() *RAGmeCollectorExporter {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollectorExporter)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMonitoring
+func (r *RAGmeMonitoring) DeepCopyInto(out *RAGmeMonitoring) {
+	*out = *r
+	r.RemoteWrite.DeepCopyInto(&out.RemoteWrite)
+	r.AlertRouting.DeepCopyInto(&out.AlertRouting)
+}
+
+// DeepCopy returns a deep copy of RAGmeMonitoring
+func (r *RAGmeMonitoring) DeepCopy() *RAGmeMonitoring {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMonitoring)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeRemoteWrite defines the Prometheus agent scraping the instance and
+// forwarding the samples to a remote-write endpoint (Thanos, Mimir, ...)
+type RAGmeRemoteWrite struct {
+	// Enabled runs the metrics agent
+	Enabled bool `json:"enabled,omitempty"`
+
+	// URL of the remote-write endpoint
+	URL string `json:"url,omitempty"`
+
+	// AuthType is bearer (token key) or basic (username and password keys)
+	AuthType string `json:"authType,omitempty"`
+
+	// AuthSecretRef references the Secret holding the credentials of the
+	// endpoint. The endpoint is called without authentication when unset.
+	AuthSecretRef *corev1.LocalObjectReference `json:"authSecretRef,omitempty"`
+
+	// ScrapeInterval of the services. Defaults to 30s
+	ScrapeInterval string `json:"scrapeInterval,omitempty"`
+
+	// ExternalLabels added to every series, e.g. the cluster name
+	ExternalLabels map[string]string `json:"externalLabels,omitempty"`
+
+	// Image of the Prometheus agent
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeRemoteWrite
+func (r *RAGmeRemoteWrite) DeepCopyInto(out *RAGmeRemoteWrite) {
+	*out = *r
+	if r.AuthSecretRef != nil {
+		out.AuthSecretRef = new(corev1.LocalObjectReference)
+		*out.AuthSecretRef = *r.AuthSecretRef
+	}
+	if r.ExternalLabels != nil {
+		out.ExternalLabels = make(map[string]string)
+		for k, v := range r.ExternalLabels {
+			out.ExternalLabels[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeRemoteWrite
+func (r *RAGmeRemoteWrite) DeepCopy() *RAGmeRemoteWrite {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeRemoteWrite)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAlertRouting defines the Alertmanager routing of the alerts of the
+// instance and their muting during upgrades
+type RAGmeAlertRouting struct {
+	// Enabled creates the AlertmanagerConfig
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Receivers the alerts are routed to
+	Receivers []RAGmeAlertReceiver `json:"receivers,omitempty"`
+
+	// Receiver of the alerts without a severity mapping. Defaults to the
+	// first receiver.
+	Receiver string `json:"receiver,omitempty"`
+
+	// SeverityReceivers routes the alerts by their severity label, e.g.
+	// critical: pagerduty
+	SeverityReceivers map[string]string `json:"severityReceivers,omitempty"`
+
+	// GroupBy labels of the notifications. Defaults to alertname.
+	GroupBy []string `json:"groupBy,omitempty"`
+
+	// AlertmanagerURL is the Alertmanager API the operator signals upgrades
+	// to, e.g. http://alertmanager-operated.monitoring.svc:9093
+	AlertmanagerURL string `json:"alertmanagerURL,omitempty"`
+
+	// InhibitDuringUpgrades fires a RAGmeUpgradeInProgress alert while an
+	// upgrade rolls out, inhibiting the other alerts of the namespace
+	InhibitDuringUpgrades bool `json:"inhibitDuringUpgrades,omitempty"`
+
+	// SilenceUpgrades creates a temporary silence for the alerts of the
+	// namespace while an upgrade rolls out
+	SilenceUpgrades bool `json:"silenceUpgrades,omitempty"`
+
+	// SilenceDuration bounds the silence should the operator not expire it.
+	// Defaults to 2h
+	SilenceDuration string `json:"silenceDuration,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAlertRouting
+func (r *RAGmeAlertRouting) DeepCopyInto(out *RAGmeAlertRouting) {
+	*out = *r
+	if r.Receivers != nil {
+		out.Receivers = make([]RAGmeAlertReceiver, len(r.Receivers))
+		for i := range r.Receivers {
+			r.Receivers[i].DeepCopyInto(&out.Receivers[i])
+		}
+	}
+	if r.SeverityReceivers != nil {
+		out.SeverityReceivers = make(map[string]string)
+		for k, v := range r.SeverityReceivers {
+			out.SeverityReceivers[k] = v
+		}
+	}
+	if r.GroupBy != nil {
+		out.GroupBy = make([]string, len(r.GroupBy))
+		copy(out.GroupBy, r.GroupBy)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeAlertRouting
+func (r *RAGmeAlertRouting) DeepCopy() *RAGmeAlertRouting {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAlertRouting)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAlertReceiver defines an Alertmanager receiver. Exactly one of the
+// webhook, Slack or PagerDuty settings is required.
+type RAGmeAlertReceiver struct {
+	// Name of the receiver
+	Name string `json:"name"`
+
+	// WebhookURLSecretRef references the URL of a webhook receiver
+	WebhookURLSecretRef *corev1.SecretKeySelector `json:"webhookURLSecretRef,omitempty"`
+
+	// SlackAPIURLSecretRef references the incoming webhook URL of a Slack receiver
+	SlackAPIURLSecretRef *corev1.SecretKeySelector `json:"slackAPIURLSecretRef,omitempty"`
+
+	// SlackChannel of a Slack receiver
+	SlackChannel string `json:"slackChannel,omitempty"`
+
+	// PagerDutyRoutingKeySecretRef references the Events API v2 routing key
+	// of a PagerDuty receiver
+	PagerDutyRoutingKeySecretRef *corev1.SecretKeySelector `json:"pagerDutyRoutingKeySecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAlertReceiver
+func (r *RAGmeAlertReceiver) DeepCopyInto(out *RAGmeAlertReceiver) {
+	*out = *r
+	if r.WebhookURLSecretRef != nil {
+		out.WebhookURLSecretRef = r.WebhookURLSecretRef.DeepCopy()
+	}
+	if r.SlackAPIURLSecretRef != nil {
+		out.SlackAPIURLSecretRef = r.SlackAPIURLSecretRef.DeepCopy()
+	}
+	if r.PagerDutyRoutingKeySecretRef != nil {
+		out.PagerDutyRoutingKeySecretRef = r.PagerDutyRoutingKeySecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeAlertReceiver
+func (r *RAGmeAlertReceiver) DeepCopy() *RAGmeAlertReceiver {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAlertReceiver)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTermination defines how the instance is torn down when deleted: the
+// order the components are stopped in, so in-flight ingestion never leaves
+// partial state behind, and when a pending deletion is reported as blocked
+type RAGmeTermination struct {
+	// Ordered deletes the components stage by stage, waiting for the pods of a
+	// stage to be gone before the next one. Defaults to frontend, api and mcp,
+	// agent, vector database, MinIO
+	Ordered bool `json:"ordered,omitempty"`
+
+	// Stages overrides the default order
+	Stages []RAGmeTerminationStage `json:"stages,omitempty"`
+
+	// TimeoutSeconds bounds the wait of a stage without a timeout of its own.
+	// Defaults to 120
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+
+	// StuckThresholdSeconds is how long a deletion may take before the
+	// DeletionBlocked condition names what holds it. Defaults to 900
+	StuckThresholdSeconds int32 `json:"stuckThresholdSeconds,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTermination
+func (r *RAGmeTermination) DeepCopyInto(out *RAGmeTermination) {
+	*out = *r
+	if r.Stages != nil {
+		out.Stages = make([]RAGmeTerminationStage, len(r.Stages))
+		for i := range r.Stages {
+			r.Stages[i].DeepCopyInto(&out.Stages[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTermination
+func (r *RAGmeTermination) DeepCopy() *RAGmeTermination {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTermination)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTerminationStage lists components stopped together
+type RAGmeTerminationStage struct {
+	// Components of the stage, by their component label
+	Components []string `json:"components"`
+
+	// TimeoutSeconds bounds the wait for the pods of the stage to terminate,
+	// after which the next stage starts regardless
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTerminationStage
+func (r *RAGmeTerminationStage) DeepCopyInto(out *RAGmeTerminationStage) {
+	*out = *r
+	if r.Components != nil {
+		out.Components = make([]string, len(r.Components))
+		copy(out.Components, r.Components)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTerminationStage
+func (r *RAGmeTerminationStage) DeepCopy() *RAGmeTerminationStage {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTerminationStage)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCacheRedis defines the Redis backing a cache
+type RAGmeCacheRedis struct {
+	// URL of an external Redis (redis://...)
+	URL string `json:"url,omitempty"`
+
+	// URLSecretRef selects the Secret key holding the URL of an external Redis
+	URLSecretRef *corev1.SecretKeySelector `json:"urlSecretRef,omitempty"`
+
+	// Image of the managed Redis
+	Image string `json:"image,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCacheRedis
+func (r *RAGmeCacheRedis) DeepCopyInto(out *RAGmeCacheRedis) {
+	*out = *r
+	if r.URLSecretRef != nil {
+		out.URLSecretRef = r.URLSecretRef.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCacheRedis
+func (r *RAGmeCacheRedis) DeepCopy() *RAGmeCacheRedis {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCacheRedis)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEgress configures the outbound calls of the services
+type RAGmeEgress struct {
+	// Proxy routes the LLM provider calls through an Envoy egress proxy
+	Proxy RAGmeEgressProxy `json:"proxy,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEgress
+func (r *RAGmeEgress) DeepCopyInto(out *RAGmeEgress) {
+	*out = *r
+	r.Proxy.DeepCopyInto(&out.Proxy)
+}
+
+// DeepCopy returns a deep copy of RAGmeEgress
+func (r *RAGmeEgress) DeepCopy() *RAGmeEgress {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEgress)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEgressProxy defines the Envoy proxy the api, mcp and agent reach the
+// LLM providers through, for clusters that forbid direct internet egress
+type RAGmeEgressProxy struct {
+	// Enabled routes the provider calls through the proxy
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Mode of the proxy: Sidecar (default) runs Envoy in every pod, Shared runs
+	// a single Deployment used by all the services
+	Mode string `json:"mode,omitempty"`
+
+	// Image of Envoy, defaults to envoyproxy/envoy
+	Image string `json:"image,omitempty"`
+
+	// Replicas of the Shared proxy. Defaults to 1
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Upstreams are the allowlisted provider domains. Defaults to OpenAI and
+	// FriendliAI, requests to any other path are rejected by the proxy
+	Upstreams []RAGmeEgressUpstream `json:"upstreams,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEgressProxy
+func (r *RAGmeEgressProxy) DeepCopyInto(out *RAGmeEgressProxy) {
+	*out = *r
+	if r.Upstreams != nil {
+		out.Upstreams = make([]RAGmeEgressUpstream, len(r.Upstreams))
+		copy(out.Upstreams, r.Upstreams)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeEgressProxy
+func (r *RAGmeEgressProxy) DeepCopy() *RAGmeEgressProxy {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEgressProxy)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEgressUpstream is an allowlisted domain of the egress proxy. The proxy
+// originates TLS to the upstream, so services call it over plain HTTP
+type RAGmeEgressUpstream struct {
+	// Name of the upstream, the proxy serves it under /<name>/
+	Name string `json:"name"`
+
+	// Host is the allowlisted domain, e.g. api.openai.com
+	Host string `json:"host"`
+
+	// Port of the upstream. Defaults to 443
+	Port int32 `json:"port,omitempty"`
+
+	// BaseURLEnv is set on the services to the proxy URL of the upstream, e.g. OPENAI_BASE_URL
+	BaseURLEnv string `json:"baseURLEnv,omitempty"`
+
+	// BasePath is appended to the proxy URL in BaseURLEnv, e.g. /v1
+	BasePath string `json:"basePath,omitempty"`
+}
+
+// RAGmeHibernation defines when the stateless components are scaled to zero.
+// The configured replicas are restored when the instance wakes up
+type RAGmeHibernation struct {
+	// Enabled hibernates the instance until it is unset
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Windows hibernate the instance on a schedule
+	Windows []RAGmeHibernationWindow `json:"windows,omitempty"`
+
+	// TimeZone of the window schedules, e.g. Europe/Paris. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// StopVectorDB also scales the in-cluster vector database to zero
+	StopVectorDB bool `json:"stopVectorDB,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeHibernation
+func (r *RAGmeHibernation) DeepCopyInto(out *RAGmeHibernation) {
+	*out = *r
+	if r.Windows != nil {
+		out.Windows = make([]RAGmeHibernationWindow, len(r.Windows))
+		copy(out.Windows, r.Windows)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeHibernation
+func (r *RAGmeHibernation) DeepCopy() *RAGmeHibernation {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeHibernation)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeHibernationWindow defines a recurring hibernation window with two cron
+// schedules, e.g. sleep "0 19 * * 1-5" and wake "0 7 * * 1-5"
+type RAGmeHibernationWindow struct {
+	// Sleep is the cron schedule starting the window
+	Sleep string `json:"sleep"`
+
+	// Wake is the cron schedule ending the window
+	Wake string `json:"wake"`
+}
+
+// RAGmeMetadata defines the ownership of an instance, stamped as labels on
+// every generated object so chargeback tools can attribute its spend
+type RAGmeMetadata struct {
+	// CostCenter is set as the ragme.io/cost-center label
+	CostCenter string `json:"costCenter,omitempty"`
+
+	// Team is set as the ragme.io/team label
+	Team string `json:"team,omitempty"`
+
+	// Environment is set as the ragme.io/environment label
+	Environment string `json:"environment,omitempty"`
+
+	// InfoMetric exports the ownership as the ragme_instance_info metric of the operator
+	InfoMetric bool `json:"infoMetric,omitempty"`
+
+	// Catalog publishes the instance as Backstage catalog entities
+	Catalog RAGmeCatalog `json:"catalog,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMetadata
+func (r *RAGmeMetadata) DeepCopyInto(out *RAGmeMetadata) {
+	*out = *r
+	r.Catalog.DeepCopyInto(&out.Catalog)
+}
+
+// DeepCopy returns a deep copy of RAGmeMetadata
+func (r *RAGmeMetadata) DeepCopy() *RAGmeMetadata {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMetadata)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCatalog defines the catalog-info entities generated for the instance,
+// so platform teams can register it in Backstage without hand-written YAML
+type RAGmeCatalog struct {
+	// Enabled renders the entities in the <name>-catalog-info ConfigMap
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Owner is the entity reference of the owner. Defaults to metadata.team
+	Owner string `json:"owner,omitempty"`
+
+	// System is the entity reference of the system the instance belongs to
+	System string `json:"system,omitempty"`
+
+	// Lifecycle of the instance. Defaults to metadata.environment, or production
+	Lifecycle string `json:"lifecycle,omitempty"`
+
+	// Description of the instance
+	Description string `json:"description,omitempty"`
+
+	// Tags of the instance entity
+	Tags []string `json:"tags,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCatalog
+func (r *RAGmeCatalog) DeepCopyInto(out *RAGmeCatalog) {
+	*out = *r
+	if r.Tags != nil {
+		out.Tags = make([]string, len(r.Tags))
+		copy(out.Tags, r.Tags)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCatalog
+func (r *RAGmeCatalog) DeepCopy() *RAGmeCatalog {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCatalog)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeStatusEndpoint defines the summary of the instance served by the
+// operator for embedding into internal portals
+type RAGmeStatusEndpoint struct {
+	// Enabled serves the summary at /instances/<namespace>/<name> on the
+	// status server of the operator, authenticated with a generated token
+	Enabled bool `json:"enabled,omitempty"`
+
+	// CollectionCounts queries the vector database for the object count of
+	// each collection on every request
+	CollectionCounts bool `json:"collectionCounts,omitempty"`
+
+	// TopologyConfigMap publishes the component graph of the instance in the
+	// <name>-topology ConfigMap as JSON and DOT, for developer portals that
+	// cannot call the status server
+	TopologyConfigMap bool `json:"topologyConfigMap,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeStatusEndpoint
+func (r *RAGmeStatusEndpoint) DeepCopyInto(out *RAGmeStatusEndpoint) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeStatusEndpoint
+func (r *RAGmeStatusEndpoint) DeepCopy() *RAGmeStatusEndpoint {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeStatusEndpoint)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeClusterAutoscaler defines the cluster autoscaler annotations of the generated pods
+type RAGmeClusterAutoscaler struct {
+	// Enabled marks the agent, MinIO and Weaviate pods as not safe to evict, so
+	// scale-downs do not interrupt ingestion, and the api, mcp and frontend pods
+	// as safe to evict
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeClusterAutoscaler
+func (r *RAGmeClusterAutoscaler) DeepCopyInto(out *RAGmeClusterAutoscaler) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeClusterAutoscaler
+func (r *RAGmeClusterAutoscaler) DeepCopy() *RAGmeClusterAutoscaler {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeClusterAutoscaler)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeJobs defines how long the Jobs created by the operator (LDAP checks,
+// storage provisioning, shard rebalances, tenant quotas, ...) are kept
+type RAGmeJobs struct {
+	// TTLSecondsAfterFinished after which finished Jobs are deleted. Defaults to 3600
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// SuccessfulJobsHistoryLimit is the number of succeeded Jobs kept per kind. Defaults to 3
+	SuccessfulJobsHistoryLimit *int32 `json:"successfulJobsHistoryLimit,omitempty"`
+
+	// FailedJobsHistoryLimit is the number of failed Jobs kept per kind. Defaults to 1
+	FailedJobsHistoryLimit *int32 `json:"failedJobsHistoryLimit,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeJobs
+func (r *RAGmeJobs) DeepCopyInto(out *RAGmeJobs) {
+	*out = *r
+	if r.TTLSecondsAfterFinished != nil {
+		out.TTLSecondsAfterFinished = new(int32)
+		*out.TTLSecondsAfterFinished = *r.TTLSecondsAfterFinished
+	}
+	if r.SuccessfulJobsHistoryLimit != nil {
+		out.SuccessfulJobsHistoryLimit = new(int32)
+		*out.SuccessfulJobsHistoryLimit = *r.SuccessfulJobsHistoryLimit
+	}
+	if r.FailedJobsHistoryLimit != nil {
+		out.FailedJobsHistoryLimit = new(int32)
+		*out.FailedJobsHistoryLimit = *r.FailedJobsHistoryLimit
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeJobs
+func (r *RAGmeJobs) DeepCopy() *RAGmeJobs {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeJobs)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeStatus defines the observed state of RAGme
+type RAGmeStatus struct {
+	// Phase represents the current deployment phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Service status for each component
+	Services RAGmeServiceStatus `json:"services,omitempty"`
+
+	// Readiness summarizes the readiness of the generated workloads
+	Readiness RAGmeReadinessStatus `json:"readiness,omitempty"`
+
+	// Storage usage observed by the operator
+	Storage RAGmeStorageStatus `json:"storage,omitempty"`
+
+	// Sharding reports the shard layout serving traffic
+	Sharding RAGmeShardingStatus `json:"sharding,omitempty"`
+
+	// Weaviate reports the running version and upgrade progress
+	Weaviate RAGmeWeaviateStatus `json:"weaviate,omitempty"`
+
+	// Classes reports the IngressClass and StorageClasses used by the generated resources
+	Classes RAGmeClassesStatus `json:"classes,omitempty"`
+
+	// Scanning reports the outcome of the upload malware scanning
+	Scanning RAGmeScanningStatus `json:"scanning,omitempty"`
+
+	// Hibernation reports whether the instance is scaled to zero
+	Hibernation RAGmeHibernationStatus `json:"hibernation,omitempty"`
+
+	// Restore reports the cloning of the data of spec.cloneFrom
+	Restore RAGmeRestoreStatus `json:"restore,omitempty"`
+
+	// Migration reports the re-binding of the data of spec.previousInstanceName
+	Migration RAGmeMigrationStatus `json:"migration,omitempty"`
+
+	// SeedData reports the bootstrap ingestion of spec.seedData
+	SeedData RAGmeSeedDataStatus `json:"seedData,omitempty"`
+
+	// ExpiresAt is when the instance is deleted because of its TTL
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// LLM reports the token consumption of the day, as reported by the api
+	LLM RAGmeLLMStatus `json:"llm,omitempty"`
+
+	// SecretRefs reports the Secrets exposed to the services
+	SecretRefs RAGmeSecretRefsStatus `json:"secretRefs,omitempty"`
+
+	// Evaluation reports the scores of the last quality evaluation
+	Evaluation RAGmeEvaluationStatus `json:"evaluation,omitempty"`
+
+	// SLO reports the availability measured by the synthetic probes
+	SLO RAGmeSLOStatus `json:"slo,omitempty"`
+
+	// Resilience reports the self-healing verifications
+	Resilience RAGmeResilienceStatus `json:"resilience,omitempty"`
+
+	// Alerting reports the muting of the alerts during an upgrade
+	Alerting RAGmeAlertingStatus `json:"alerting,omitempty"`
+
+	// Termination reports the ordered termination of a deleted instance
+	Termination RAGmeTerminationStatus `json:"termination,omitempty"`
+
+	// Agent reports the ingestion queue of the agent
+	Agent RAGmeAgentStatus `json:"agent,omitempty"`
+
+	// Media reports the transcription of the audio and video files
+	Media RAGmeMediaStatus `json:"media,omitempty"`
+
+	// Ingestion reports the documents the ingestion path skipped as duplicates
+	Ingestion RAGmeIngestionStatus `json:"ingestion,omitempty"`
+
+	// Versioning reports the last pruning of the superseded versions
+	Versioning RAGmeVersioningStatus `json:"versioning,omitempty"`
+
+	// Notices are the notices shown in the frontend banner
+	Notices []RAGmeNoticeStatus `json:"notices,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeStatus
+func (r *RAGmeStatus) DeepCopyInto(out *RAGmeStatus) {
+	*out = *r
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+	r.Services.DeepCopyInto(&out.Services)
+	r.Readiness.DeepCopyInto(&out.Readiness)
+	r.Storage.DeepCopyInto(&out.Storage)
+	r.Sharding.DeepCopyInto(&out.Sharding)
+	r.Weaviate.DeepCopyInto(&out.Weaviate)
+	r.Classes.DeepCopyInto(&out.Classes)
+	r.Scanning.DeepCopyInto(&out.Scanning)
+	r.Hibernation.DeepCopyInto(&out.Hibernation)
+	r.Restore.DeepCopyInto(&out.Restore)
+	r.Migration.DeepCopyInto(&out.Migration)
+	r.SeedData.DeepCopyInto(&out.SeedData)
+	if r.ExpiresAt != nil {
+		out.ExpiresAt = r.ExpiresAt.DeepCopy()
+	}
+	r.LLM.DeepCopyInto(&out.LLM)
+	r.SecretRefs.DeepCopyInto(&out.SecretRefs)
+	r.Evaluation.DeepCopyInto(&out.Evaluation)
+	r.SLO.DeepCopyInto(&out.SLO)
+	r.Resilience.DeepCopyInto(&out.Resilience)
+	r.Alerting.DeepCopyInto(&out.Alerting)
+	r.Termination.DeepCopyInto(&out.Termination)
+	r.Agent.DeepCopyInto(&out.Agent)
+	r.Media.DeepCopyInto(&out.Media)
+	r.Ingestion.DeepCopyInto(&out.Ingestion)
+	r.Versioning.DeepCopyInto(&out.Versioning)
+	if r.Notices != nil {
+		out.Notices = make([]RAGmeNoticeStatus, len(r.Notices))
+		copy(out.Notices, r.Notices)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeStatus
+func (r *RAGmeStatus) DeepCopy() *RAGmeStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeSecretRefsStatus reports the Secrets exposed to the services
+type RAGmeSecretRefsStatus struct {
+	// Revision identifies the content of the referenced Secrets, a new
+	// revision rolls the services
+	Revision string `json:"revision,omitempty"`
+
+	// Copies are the copies of the Secrets of other namespaces, as namespace/name
+	Copies []string `json:"copies,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSecretRefsStatus
+func (r *RAGmeSecretRefsStatus) DeepCopyInto(out *RAGmeSecretRefsStatus) {
+	*out = *r
+	if r.Copies != nil {
+		out.Copies = make([]string, len(r.Copies))
+		copy(out.Copies, r.Copies)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeSecretRefsStatus
+func (r *RAGmeSecretRefsStatus) DeepCopy() *RAGmeSecretRefsStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSecretRefsStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeNoticeStatus reports a notice shown in the frontend banner
+type RAGmeNoticeStatus struct {
+	// Source of the notice: spec, maintenance, upgrade or restore
+	Source string `json:"source"`
+
+	// Severity of the notice
+	Severity string `json:"severity"`
+
+	// Message shown in the banner
+	Message string `json:"message"`
+}
+
+// RAGmeSeedDataStatus defines the observed state of the bootstrap ingestion
+type RAGmeSeedDataStatus struct {
+	// Revision identifies the seeded content
+	Revision string `json:"revision,omitempty"`
+
+	// Job is the name of the bootstrap Job
+	Job string `json:"job,omitempty"`
+
+	// Completed is true once the content of Revision has been ingested
+	Completed bool `json:"completed,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeSeedDataStatus
+func (r *RAGmeSeedDataStatus) DeepCopyInto(out *RAGmeSeedDataStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeSeedDataStatus
+func (r *RAGmeSeedDataStatus) DeepCopy() *RAGmeSeedDataStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeSeedDataStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeRestoreStatus defines the observed state of the provisioning from another instance
+type RAGmeRestoreStatus struct {
+	// Source is the instance the data is restored from
+	Source string `json:"source,omitempty"`
+
+	// Volumes lists the PVCs of the source being cloned
+	Volumes []string `json:"volumes,omitempty"`
+
+	// Completed is true once the volumes are restored and the collections imported
+	Completed bool `json:"completed,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeRestoreStatus
+func (r *RAGmeRestoreStatus) DeepCopyInto(out *RAGmeRestoreStatus) {
+	*out = *r
+	if r.Volumes != nil {
+		out.Volumes = make([]string, len(r.Volumes))
+		copy(out.Volumes, r.Volumes)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeRestoreStatus
+func (r *RAGmeRestoreStatus) DeepCopy() *RAGmeRestoreStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeRestoreStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMigrationStatus defines the observed re-binding of the data of a renamed instance
+type RAGmeMigrationStatus struct {
+	// From is the previous name of the instance
+	From string `json:"from,omitempty"`
+
+	// Volumes lists the claims of the instance and the volumes they are re-bound to
+	Volumes []RAGmeMigratedVolume `json:"volumes,omitempty"`
+
+	// Completed is true once every claim is bound to its volume
+	Completed bool `json:"completed,omitempty"`
+}
+
+// RAGmeMigratedVolume is a claim of the instance taking over a retained volume
+type RAGmeMigratedVolume struct {
+	// Claim is the PVC of the instance
+	Claim string `json:"claim"`
+
+	// VolumeName is the PersistentVolume of the previous instance
+	VolumeName string `json:"volumeName"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMigrationStatus
+func (r *RAGmeMigrationStatus) DeepCopyInto(out *RAGmeMigrationStatus) {
+	*out = *r
+	if r.Volumes != nil {
+		out.Volumes = make([]RAGmeMigratedVolume, len(r.Volumes))
+		copy(out.Volumes, r.Volumes)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeMigrationStatus
+func (r *RAGmeMigrationStatus) DeepCopy() *RAGmeMigrationStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMigrationStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeHibernationStatus defines the observed hibernation state
+type RAGmeHibernationStatus struct {
+	// Hibernated is true while the stateless components are scaled to zero
+	Hibernated bool `json:"hibernated,omitempty"`
+
+	// NextTransition is when the next hibernation window starts or ends
+	NextTransition *metav1.Time `json:"nextTransition,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeHibernationStatus
+func (r *RAGmeHibernationStatus) DeepCopyInto(out *RAGmeHibernationStatus) {
+	*out = *r
+	if r.NextTransition != nil {
+		out.NextTransition = r.NextTransition.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeHibernationStatus
+func (r *RAGmeHibernationStatus) DeepCopy() *RAGmeHibernationStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeHibernationStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAgentStatus defines the observed ingestion queue, as reported by the
+// agent since it started
+type RAGmeAgentStatus struct {
+	// FilesQueued is the number of files waiting for ingestion
+	FilesQueued int64 `json:"filesQueued,omitempty"`
+
+	// FilesRejected is the number of files turned away by the backpressure
+	FilesRejected int64 `json:"filesRejected,omitempty"`
+
+	// FilesParked is the number of files parked until the queue drains
+	FilesParked int64 `json:"filesParked,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAgentStatus
+func (r *RAGmeAgentStatus) DeepCopyInto(out *RAGmeAgentStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeAgentStatus
+func (r *RAGmeAgentStatus) DeepCopy() *RAGmeAgentStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAgentStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeIngestionStatus defines the observed ingestion path
+type RAGmeIngestionStatus struct {
+	// Dedup reports the duplicates recognized since the api started
+	Dedup RAGmeDedupStatus `json:"dedup,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeIngestionStatus
+func (r *RAGmeIngestionStatus) DeepCopyInto(out *RAGmeIngestionStatus) {
+	*out = *r
+	r.Dedup.DeepCopyInto(&out.Dedup)
+}
+
+// DeepCopy returns a deep copy of RAGmeIngestionStatus
+func (r *RAGmeIngestionStatus) DeepCopy() *RAGmeIngestionStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeIngestionStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeDedupStatus defines the observed de-duplication hits
+type RAGmeDedupStatus struct {
+	// DocumentsChecked is the number of documents checked for duplicates
+	DocumentsChecked int64 `json:"documentsChecked,omitempty"`
+
+	// Hits is the number of documents skipped as duplicates
+	Hits int64 `json:"hits,omitempty"`
+
+	// HashHits is the number of documents skipped with the same content
+	HashHits int64 `json:"hashHits,omitempty"`
+
+	// SemanticHits is the number of documents skipped as near duplicates
+	SemanticHits int64 `json:"semanticHits,omitempty"`
+
+	// HitRatePercent is the share of the checked documents skipped
+	HitRatePercent int32 `json:"hitRatePercent,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeDedupStatus
+func (r *RAGmeDedupStatus) DeepCopyInto(out *RAGmeDedupStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeDedupStatus
+func (r *RAGmeDedupStatus) DeepCopy() *RAGmeDedupStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDedupStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeVersioningStatus defines the observed pruning of the superseded versions
+type RAGmeVersioningStatus struct {
+	// Job is the name of the last finished cleanup Job
+	Job string `json:"job,omitempty"`
+
+	// Result of the last cleanup: Succeeded or Failed
+	Result string `json:"result,omitempty"`
+
+	// CompletedAt is when the last cleanup finished
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// VectorsPruned is the number of vectors the last cleanup deleted
+	VectorsPruned int64 `json:"vectorsPruned,omitempty"`
+
+	// ObjectsPruned is the number of stored objects the last cleanup deleted
+	ObjectsPruned int64 `json:"objectsPruned,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeVersioningStatus
+func (r *RAGmeVersioningStatus) DeepCopyInto(out *RAGmeVersioningStatus) {
+	*out = *r
+	if r.CompletedAt != nil {
+		out.CompletedAt = r.CompletedAt.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeVersioningStatus
+func (r *RAGmeVersioningStatus) DeepCopy() *RAGmeVersioningStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeVersioningStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMediaStatus defines the observed transcriptions
+type RAGmeMediaStatus struct {
+	// Pending is the number of files waiting for a transcriber
+	Pending int32 `json:"pending,omitempty"`
+
+	// Transcribed is the number of files transcribed and ingested
+	Transcribed int64 `json:"transcribed,omitempty"`
+
+	// Failed is the number of files that could not be transcribed
+	Failed int64 `json:"failed,omitempty"`
+
+	// Files lists the files being transcribed or waiting, and the failed
+	// transcription Jobs still kept, at most 20
+	Files []RAGmeMediaFileStatus `json:"files,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeMediaStatus
+func (r *RAGmeMediaStatus) DeepCopyInto(out *RAGmeMediaStatus) {
+	*out = *r
+	if r.Files != nil {
+		out.Files = make([]RAGmeMediaFileStatus, len(r.Files))
+		copy(out.Files, r.Files)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeMediaStatus
+func (r *RAGmeMediaStatus) DeepCopy() *RAGmeMediaStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeMediaStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeMediaFileStatus defines the progress of the transcription of a file
+type RAGmeMediaFileStatus struct {
+	// Name of the file, relative to the watch directory
+	Name string `json:"name"`
+
+	// Phase is Pending, Transcribing or Failed
+	Phase string `json:"phase"`
+
+	// Progress is the transcribed share of the recording, in percent
+	Progress int32 `json:"progress,omitempty"`
+
+	// Job running the transcription in job mode
+	Job string `json:"job,omitempty"`
+}
+
+// RAGmeScanningStatus defines the observed upload scanning counters, as
+// reported by the scanner since it started
+type RAGmeScanningStatus struct {
+	// ScannedFiles is the number of uploads cleared into the watch directory
+	ScannedFiles int64 `json:"scannedFiles,omitempty"`
+
+	// InfectedFiles is the number of uploads kept in quarantine
+	InfectedFiles int64 `json:"infectedFiles,omitempty"`
+
+	// PendingFiles is the number of uploads waiting to be scanned
+	PendingFiles int64 `json:"pendingFiles,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeScanningStatus
+func (r *RAGmeScanningStatus) DeepCopyInto(out *RAGmeScanningStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeScanningStatus
+func (r *RAGmeScanningStatus) DeepCopy() *RAGmeScanningStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeScanningStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLLMStatus defines the observed use of the language models
+type RAGmeLLMStatus struct {
+	// Budget reports the consumption against spec.llm.budget
+	Budget RAGmeLLMBudgetStatus `json:"budget,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLLMStatus
+func (r *RAGmeLLMStatus) DeepCopyInto(out *RAGmeLLMStatus) {
+	*out = *r
+	r.Budget.DeepCopyInto(&out.Budget)
+}
+
+// DeepCopy returns a deep copy of RAGmeLLMStatus
+func (r *RAGmeLLMStatus) DeepCopy() *RAGmeLLMStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLLMStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEvaluationStatus defines the outcome of the last quality evaluation
+type RAGmeEvaluationStatus struct {
+	// Job is the name of the last completed evaluation Job
+	Job string `json:"job,omitempty"`
+
+	// CompletedAt is when the last evaluation completed
+	CompletedAt *metav1.Time `json:"completedAt,omitempty"`
+
+	// Version is the image tag of the evaluated services
+	Version string `json:"version,omitempty"`
+
+	// Scores per metric of the last evaluation
+	Scores map[string]string `json:"scores,omitempty"`
+
+	// LastPassedVersion is the last image tag meeting all the thresholds
+	LastPassedVersion string `json:"lastPassedVersion,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEvaluationStatus
+func (r *RAGmeEvaluationStatus) DeepCopyInto(out *RAGmeEvaluationStatus) {
+	*out = *r
+	if r.CompletedAt != nil {
+		out.CompletedAt = r.CompletedAt.DeepCopy()
+	}
+	if r.Scores != nil {
+		out.Scores = make(map[string]string)
+		for k, v := range r.Scores {
+			out.Scores[k] = v
+		}
+	}
 }
 
-// DeepCopy returns a deep copy of RAGmeIngressConfig
-func (r *RAGmeIngressConfig) DeepCopy() *RAGmeIngressConfig {
+// DeepCopy returns a deep copy of RAGmeEvaluationStatus
+func (r *RAGmeEvaluationStatus) DeepCopy() *RAGmeEvaluationStatus {
 	if r == nil {
 		return nil
 	}
-	out := new(RAGmeIngressConfig)
+	out := new(RAGmeEvaluationStatus)
 	r.DeepCopyInto(out)
 	return out
 }
 
-// RAGmeStatus defines the observed state of RAGme
-type RAGmeStatus struct {
-	// Phase represents the current deployment phase
-	Phase string `json:"phase,omitempty"`
+// RAGmeSLOStatus defines the availability measured over the SLO window.
+// The probe history is kept in memory and restarts with the operator
+type RAGmeSLOStatus struct {
+	// Probes run in the window
+	Probes int32 `json:"probes,omitempty"`
 
-	// Conditions represent the latest available observations
-	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Availability in percent of successful probes, e.g. 99.82
+	Availability string `json:"availability,omitempty"`
 
-	// Service status for each component
-	Services RAGmeServiceStatus `json:"services,omitempty"`
+	// LatencyP95 of the probes, e.g. 180ms
+	LatencyP95 string `json:"latencyP95,omitempty"`
+
+	// BurnRate is the pace the error budget is consumed at, 1 spending exactly
+	// the budget over the window
+	BurnRate string `json:"burnRate,omitempty"`
+
+	// ErrorBudgetRemaining in percent of the budget of the window
+	ErrorBudgetRemaining string `json:"errorBudgetRemaining,omitempty"`
+
+	// LastProbeTime is when the services were last probed
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
 }
 
-// DeepCopyInto copies the receiver into the given *RAGmeStatus
-func (r *RAGmeStatus) DeepCopyInto(out *RAGmeStatus) {
+// DeepCopyInto copies the receiver into the given *RAGmeSLOStatus
+func (r *RAGmeSLOStatus) DeepCopyInto(out *RAGmeSLOStatus) {
 	*out = *r
-	r.Conditions = make([]metav1.Condition, len(r.Conditions))
-	for i := range r.Conditions {
-		r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+	if r.LastProbeTime != nil {
+		out.LastProbeTime = r.LastProbeTime.DeepCopy()
 	}
-	r.Services.DeepCopyInto(&out.Services)
 }
 
-// DeepCopy returns a deep copy of RAGmeStatus
-func (r *RAGmeStatus) DeepCopy() *RAGmeStatus {
+// DeepCopy returns a deep copy of RAGmeSLOStatus
+func (r *RAGmeSLOStatus) DeepCopy() *RAGmeSLOStatus {
 	if r == nil {
 		return nil
 	}
-	out := new(RAGmeStatus)
+	out := new(RAGmeSLOStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeResilienceStatus defines the outcome of the self-healing verifications
+type RAGmeResilienceStatus struct {
+	// Current is the verification in progress
+	Current *RAGmeResilienceCheck `json:"current,omitempty"`
+
+	// Checks are the last completed verifications, most recent first
+	Checks []RAGmeResilienceCheck `json:"checks,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeResilienceStatus
+func (r *RAGmeResilienceStatus) DeepCopyInto(out *RAGmeResilienceStatus) {
+	*out = *r
+	if r.Current != nil {
+		out.Current = new(RAGmeResilienceCheck)
+		r.Current.DeepCopyInto(out.Current)
+	}
+	if r.Checks != nil {
+		out.Checks = make([]RAGmeResilienceCheck, len(r.Checks))
+		for i := range r.Checks {
+			r.Checks[i].DeepCopyInto(&out.Checks[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeResilienceStatus
+func (r *RAGmeResilienceStatus) DeepCopy() *RAGmeResilienceStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeResilienceStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeResilienceCheck records one self-healing verification
+type RAGmeResilienceCheck struct {
+	// Component whose pod was deleted
+	Component string `json:"component"`
+
+	// Pod that was deleted
+	Pod string `json:"pod"`
+
+	// StartedAt is when the pod was deleted
+	StartedAt metav1.Time `json:"startedAt"`
+
+	// RecoveredAt is when the component was fully available again
+	RecoveredAt *metav1.Time `json:"recoveredAt,omitempty"`
+
+	// Result of the verification: Passed or Failed
+	Result string `json:"result,omitempty"`
+
+	// Message details the result
+	Message string `json:"message,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeResilienceCheck
+func (r *RAGmeResilienceCheck) DeepCopyInto(out *RAGmeResilienceCheck) {
+	*out = *r
+	r.StartedAt.DeepCopyInto(&out.StartedAt)
+	if r.RecoveredAt != nil {
+		out.RecoveredAt = r.RecoveredAt.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeResilienceCheck
+func (r *RAGmeResilienceCheck) DeepCopy() *RAGmeResilienceCheck {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeResilienceCheck)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLLMBudgetStatus defines the token consumption counters of the current day
+type RAGmeLLMBudgetStatus struct {
+	// TokensToday is the number of tokens consumed by the instance today
+	TokensToday int64 `json:"tokensToday,omitempty"`
+
+	// UsedPercent of the daily cap
+	UsedPercent int32 `json:"usedPercent,omitempty"`
+
+	// UsersOverBudget is the number of users who reached their daily cap
+	UsersOverBudget int32 `json:"usersOverBudget,omitempty"`
+
+	// ObservedAt is when the counters were read from the api
+	ObservedAt *metav1.Time `json:"observedAt,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLLMBudgetStatus
+func (r *RAGmeLLMBudgetStatus) DeepCopyInto(out *RAGmeLLMBudgetStatus) {
+	*out = *r
+	if r.ObservedAt != nil {
+		out.ObservedAt = r.ObservedAt.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeLLMBudgetStatus
+func (r *RAGmeLLMBudgetStatus) DeepCopy() *RAGmeLLMBudgetStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLLMBudgetStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeShardingStatus defines the observed sharding state
+type RAGmeShardingStatus struct {
+	// Shards is the number of shards serving reads and writes
+	Shards int32 `json:"shards,omitempty"`
+
+	// RebalanceJob is the name of the Job moving the index to the desired shard count
+	RebalanceJob string `json:"rebalanceJob,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeShardingStatus
+func (r *RAGmeShardingStatus) DeepCopyInto(out *RAGmeShardingStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeShardingStatus
+func (r *RAGmeShardingStatus) DeepCopy() *RAGmeShardingStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeShardingStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeAlertingStatus reports the alert muting of an upgrade
+type RAGmeAlertingStatus struct {
+	// SilenceID of the silence created for the running upgrade
+	SilenceID string `json:"silenceID,omitempty"`
+
+	// SilencedUntil is the end of the silence
+	SilencedUntil *metav1.Time `json:"silencedUntil,omitempty"`
+
+	// UpgradeAlertFiring is set while the RAGmeUpgradeInProgress alert is
+	// sent to Alertmanager
+	UpgradeAlertFiring bool `json:"upgradeAlertFiring,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeAlertingStatus
+func (r *RAGmeAlertingStatus) DeepCopyInto(out *RAGmeAlertingStatus) {
+	*out = *r
+	if r.SilencedUntil != nil {
+		out.SilencedUntil = r.SilencedUntil.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeAlertingStatus
+func (r *RAGmeAlertingStatus) DeepCopy() *RAGmeAlertingStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeAlertingStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTerminationStatus reports the progress of the ordered termination
+type RAGmeTerminationStatus struct {
+	// Stage is the index of the stage being terminated
+	Stage int32 `json:"stage,omitempty"`
+
+	// StageStartedAt is when the components of the stage were deleted
+	StageStartedAt *metav1.Time `json:"stageStartedAt,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTerminationStatus
+func (r *RAGmeTerminationStatus) DeepCopyInto(out *RAGmeTerminationStatus) {
+	*out = *r
+	if r.StageStartedAt != nil {
+		out.StageStartedAt = r.StageStartedAt.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTerminationStatus
+func (r *RAGmeTerminationStatus) DeepCopy() *RAGmeTerminationStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTerminationStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeWeaviateStatus defines the observed Weaviate version state
+type RAGmeWeaviateStatus struct {
+	// Version is the last verified running version
+	Version string `json:"version,omitempty"`
+
+	// UpgradeTo is the version being rolled out
+	UpgradeTo string `json:"upgradeTo,omitempty"`
+
+	// UpgradePhase is Snapshotting, Rolling or Verifying while upgrading
+	UpgradePhase string `json:"upgradePhase,omitempty"`
+
+	// BackupID of the snapshot taken before the upgrade
+	BackupID string `json:"backupID,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeWeaviateStatus
+func (r *RAGmeWeaviateStatus) DeepCopyInto(out *RAGmeWeaviateStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeWeaviateStatus
+func (r *RAGmeWeaviateStatus) DeepCopy() *RAGmeWeaviateStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeWeaviateStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeClassesStatus defines the classes chosen for the generated resources,
+// either pinned in the spec or discovered from the cluster
+type RAGmeClassesStatus struct {
+	// IngressClassName of the generated Ingresses
+	IngressClassName string `json:"ingressClassName,omitempty"`
+
+	// SharedVolumeStorageClass of the shared watch-directory volume
+	SharedVolumeStorageClass string `json:"sharedVolumeStorageClass,omitempty"`
+
+	// StorageClass of the MinIO and Weaviate volumes
+	StorageClass string `json:"storageClass,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeClassesStatus
+func (r *RAGmeClassesStatus) DeepCopyInto(out *RAGmeClassesStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeClassesStatus
+func (r *RAGmeClassesStatus) DeepCopy() *RAGmeClassesStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeClassesStatus)
 	r.DeepCopyInto(out)
 	return out
 }
@@ -551,11 +4678,66 @@ func (r *RAGmeServiceStatus) DeepCopy() *RAGmeServiceStatus {
 	return out
 }
 
+// RAGmeStorageStatus defines observed storage usage
+type RAGmeStorageStatus struct {
+	MinIO StorageUsageStatus `json:"minio,omitempty"`
+
+	// SharedVolume is the watch-directory utilization
+	SharedVolume StorageUsageStatus `json:"sharedVolume,omitempty"`
+
+	// CredentialsRevision identifies the service credentials provisioned in MinIO
+	CredentialsRevision string `json:"credentialsRevision,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeStorageStatus
+func (r *RAGmeStorageStatus) DeepCopyInto(out *RAGmeStorageStatus) {
+	*out = *r
+	r.MinIO.DeepCopyInto(&out.MinIO)
+	r.SharedVolume.DeepCopyInto(&out.SharedVolume)
+}
+
+// DeepCopy returns a deep copy of RAGmeStorageStatus
+func (r *RAGmeStorageStatus) DeepCopy() *RAGmeStorageStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeStorageStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// StorageUsageStatus defines usage of a single storage backend
+type StorageUsageStatus struct {
+	UsedBytes     int64 `json:"usedBytes,omitempty"`
+	CapacityBytes int64 `json:"capacityBytes,omitempty"`
+	UsedPercent   int32 `json:"usedPercent,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *StorageUsageStatus
+func (r *StorageUsageStatus) DeepCopyInto(out *StorageUsageStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of StorageUsageStatus
+func (r *StorageUsageStatus) DeepCopy() *StorageUsageStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(StorageUsageStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // ServiceComponentStatus defines status for a single service component
 type ServiceComponentStatus struct {
-	Ready    bool   `json:"ready,omitempty"`
-	Replicas int32  `json:"replicas,omitempty"`
-	URL      string `json:"url,omitempty"`
+	// Ready is true when all replicas of the component are ready
+	Ready bool `json:"ready,omitempty"`
+
+	// Replicas is the number of ready replicas
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// URL is the in-cluster URL of the Service of the component
+	URL string `json:"url,omitempty"`
 }
 
 // DeepCopyInto copies the receiver into the given *ServiceComponentStatus
@@ -573,9 +4755,44 @@ func (r *ServiceComponentStatus) DeepCopy() *ServiceComponentStatus {
 	return out
 }
 
+// RAGmeReadinessStatus summarizes the readiness of the generated workloads,
+// recomputed on every event of a workload
+type RAGmeReadinessStatus struct {
+	// Summary is the ready components out of the deployed ones, e.g. 5/6
+	Summary string `json:"summary,omitempty"`
+
+	// ReadyComponents is the number of components with all replicas ready
+	ReadyComponents int32 `json:"readyComponents,omitempty"`
+
+	// Components is the number of deployed components
+	Components int32 `json:"components,omitempty"`
+
+	// NotReady lists the components with replicas not ready
+	NotReady []string `json:"notReady,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeReadinessStatus
+func (r *RAGmeReadinessStatus) DeepCopyInto(out *RAGmeReadinessStatus) {
+	*out = *r
+	if r.NotReady != nil {
+		out.NotReady = make([]string, len(r.NotReady))
+		copy(out.NotReady, r.NotReady)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeReadinessStatus
+func (r *RAGmeReadinessStatus) DeepCopy() *RAGmeReadinessStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeReadinessStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
-// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:resource:scope=Namespaced,shortName={rm,rgm},categories=ragme
 
 // RAGme is the Schema for the ragmes API
 type RAGme struct {
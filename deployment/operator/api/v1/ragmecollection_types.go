@@ -0,0 +1,204 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeCollectionSpec defines the desired state of RAGmeCollection
+type RAGmeCollectionSpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace)
+	// whose vector database hosts this collection
+	InstanceRef string `json:"instanceRef"`
+
+	// CollectionName is the name of the collection in the vector database.
+	// Defaults to the name of the RAGmeCollection resource
+	CollectionName string `json:"collectionName,omitempty"`
+
+	// Description of the collection
+	Description string `json:"description,omitempty"`
+
+	// Fields defines the schema properties of the collection
+	Fields []RAGmeCollectionField `json:"fields,omitempty"`
+
+	// Vectorizer module used by the vector database (e.g. none, text2vec-openai)
+	Vectorizer string `json:"vectorizer,omitempty"`
+
+	// Dimension of the embedding vectors (required by Milvus)
+	Dimension int32 `json:"dimension,omitempty"`
+
+	// IndexParams are passed through to the vector index configuration
+	IndexParams map[string]string `json:"indexParams,omitempty"`
+
+	// Tenant owning the collection. When set, the collection is created with
+	// multi-tenancy enabled and the tenant is registered on it
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCollectionSpec
+func (r *RAGmeCollectionSpec) DeepCopyInto(out *RAGmeCollectionSpec) {
+	*out = *r
+	if r.Fields != nil {
+		out.Fields = make([]RAGmeCollectionField, len(r.Fields))
+		copy(out.Fields, r.Fields)
+	}
+	if r.IndexParams != nil {
+		out.IndexParams = make(map[string]string)
+		for k, v := range r.IndexParams {
+			out.IndexParams[k] = v
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCollectionSpec
+func (r *RAGmeCollectionSpec) DeepCopy() *RAGmeCollectionSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollectionSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCollectionField defines a single schema field of a collection
+type RAGmeCollectionField struct {
+	Name        string `json:"name"`
+	DataType    string `json:"dataType"`
+	Description string `json:"description,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCollectionField
+func (r *RAGmeCollectionField) DeepCopyInto(out *RAGmeCollectionField) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeCollectionField
+func (r *RAGmeCollectionField) DeepCopy() *RAGmeCollectionField {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollectionField)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCollectionStatus defines the observed state of RAGmeCollection
+type RAGmeCollectionStatus struct {
+	// Phase represents the current collection phase
+	Phase string `json:"phase,omitempty"`
+
+	// Backend is the vector database type hosting the collection
+	Backend string `json:"backend,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCollectionStatus
+func (r *RAGmeCollectionStatus) DeepCopyInto(out *RAGmeCollectionStatus) {
+	*out = *r
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCollectionStatus
+func (r *RAGmeCollectionStatus) DeepCopy() *RAGmeCollectionStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollectionStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmc,rgmcollection},categories=ragme
+
+// RAGmeCollection is the Schema for the ragmecollections API
+type RAGmeCollection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeCollectionSpec   `json:"spec,omitempty"`
+	Status RAGmeCollectionStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeCollection) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeCollection) DeepCopy() *RAGmeCollection {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollection)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeCollection) DeepCopyInto(out *RAGmeCollection) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeCollectionList contains a list of RAGmeCollection
+type RAGmeCollectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeCollection `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeCollectionList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeCollectionList) DeepCopy() *RAGmeCollectionList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCollectionList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeCollectionList) DeepCopyInto(out *RAGmeCollectionList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeCollection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeCollection{}, &RAGmeCollectionList{})
+}
@@ -0,0 +1,604 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeDataSourceSpec defines the desired state of RAGmeDataSource, an
+// external source the operator syncs into a RAGme instance on a schedule
+type RAGmeDataSourceSpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace)
+	// the documents are ingested into
+	InstanceRef string `json:"instanceRef"`
+
+	// Type of the source: crawl, email, git, or a connector to an enterprise
+	// document system: confluence, sharepoint or gdrive
+	Type string `json:"type"`
+
+	// Schedule of the syncs, in cron format. Defaults to every 6 hours
+	Schedule string `json:"schedule,omitempty"`
+
+	// Suspend pauses the syncs without deleting the source
+	Suspend bool `json:"suspend,omitempty"`
+
+	// Crawl configures the web crawler of the crawl type
+	Crawl *RAGmeCrawlSource `json:"crawl,omitempty"`
+
+	// Connector configures the document system of the connector types
+	Connector *RAGmeConnectorSource `json:"connector,omitempty"`
+
+	// Email configures the mailbox of the email type
+	Email *RAGmeEmailSource `json:"email,omitempty"`
+
+	// Git configures the repository of the git type
+	Git *RAGmeGitSource `json:"git,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeDataSourceSpec
+func (r *RAGmeDataSourceSpec) DeepCopyInto(out *RAGmeDataSourceSpec) {
+	*out = *r
+	if r.Crawl != nil {
+		out.Crawl = r.Crawl.DeepCopy()
+	}
+	if r.Connector != nil {
+		out.Connector = r.Connector.DeepCopy()
+	}
+	if r.Email != nil {
+		out.Email = r.Email.DeepCopy()
+	}
+	if r.Git != nil {
+		out.Git = r.Git.DeepCopy()
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeDataSourceSpec
+func (r *RAGmeDataSourceSpec) DeepCopy() *RAGmeDataSourceSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDataSourceSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCrawlSource defines the pages crawled from the seed URLs and how
+// politely the sites are crawled
+type RAGmeCrawlSource struct {
+	// SeedURLs the crawl starts from
+	SeedURLs []string `json:"seedURLs"`
+
+	// MaxDepth is the number of links followed from a seed URL. Defaults to 2
+	MaxDepth int32 `json:"maxDepth,omitempty"`
+
+	// MaxPages bounds the pages fetched by a crawl. Defaults to 1000
+	MaxPages int32 `json:"maxPages,omitempty"`
+
+	// AllowedDomains are the domains links are followed to. Defaults to the
+	// domains of the seed URLs
+	AllowedDomains []string `json:"allowedDomains,omitempty"`
+
+	// IncludePatterns are regular expressions a URL must match to be crawled.
+	// Every URL of the allowed domains is crawled when empty
+	IncludePatterns []string `json:"includePatterns,omitempty"`
+
+	// ExcludePatterns are regular expressions of the URLs never crawled
+	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+
+	// RequestsPerSecond bounds the requests sent to a single domain. Defaults to 1
+	RequestsPerSecond string `json:"requestsPerSecond,omitempty"`
+
+	// RespectRobotsTxt skips the pages disallowed by the robots.txt of a site
+	// and honors its crawl delay. Defaults to true
+	RespectRobotsTxt *bool `json:"respectRobotsTxt,omitempty"`
+
+	// UserAgent the crawler identifies itself with. Defaults to RAGmeCrawler/1.0
+	UserAgent string `json:"userAgent,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCrawlSource
+func (r *RAGmeCrawlSource) DeepCopyInto(out *RAGmeCrawlSource) {
+	*out = *r
+	if r.SeedURLs != nil {
+		out.SeedURLs = make([]string, len(r.SeedURLs))
+		copy(out.SeedURLs, r.SeedURLs)
+	}
+	if r.AllowedDomains != nil {
+		out.AllowedDomains = make([]string, len(r.AllowedDomains))
+		copy(out.AllowedDomains, r.AllowedDomains)
+	}
+	if r.IncludePatterns != nil {
+		out.IncludePatterns = make([]string, len(r.IncludePatterns))
+		copy(out.IncludePatterns, r.IncludePatterns)
+	}
+	if r.ExcludePatterns != nil {
+		out.ExcludePatterns = make([]string, len(r.ExcludePatterns))
+		copy(out.ExcludePatterns, r.ExcludePatterns)
+	}
+	if r.RespectRobotsTxt != nil {
+		out.RespectRobotsTxt = new(bool)
+		*out.RespectRobotsTxt = *r.RespectRobotsTxt
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeCrawlSource
+func (r *RAGmeCrawlSource) DeepCopy() *RAGmeCrawlSource {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCrawlSource)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeConnectorSource defines the documents synced from an enterprise
+// document system. Each sync only fetches the documents changed since the
+// previous one.
+type RAGmeConnectorSource struct {
+	// URL of the system: the Confluence base URL or the SharePoint site URL.
+	// Not used by gdrive
+	URL string `json:"url,omitempty"`
+
+	// CredentialsSecretRef names the Secret holding the credentials:
+	// username and apiToken for confluence, tenantId, clientId and
+	// clientSecret for sharepoint, credentials.json of a service account for
+	// gdrive
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+
+	// Spaces are the keys of the Confluence spaces synced
+	Spaces []string `json:"spaces,omitempty"`
+
+	// Folders are the SharePoint library folders or the Google Drive folder
+	// IDs synced, with their subfolders. Everything accessible is synced when empty
+	Folders []string `json:"folders,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeConnectorSource
+func (r *RAGmeConnectorSource) DeepCopyInto(out *RAGmeConnectorSource) {
+	*out = *r
+	if r.Spaces != nil {
+		out.Spaces = make([]string, len(r.Spaces))
+		copy(out.Spaces, r.Spaces)
+	}
+	if r.Folders != nil {
+		out.Folders = make([]string, len(r.Folders))
+		copy(out.Folders, r.Folders)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeConnectorSource
+func (r *RAGmeConnectorSource) DeepCopy() *RAGmeConnectorSource {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeConnectorSource)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEmailSource defines the mailbox ingested by the email type, a shared
+// drop mailbox documents are sent to. Each sync ingests the messages received
+// since the previous one.
+type RAGmeEmailSource struct {
+	// Protocol the mailbox is read with: imap, or graph for the Microsoft
+	// Graph API of Exchange Online. Defaults to imap
+	Protocol string `json:"protocol,omitempty"`
+
+	// Host of the IMAP server. Not used by graph
+	Host string `json:"host,omitempty"`
+
+	// Port of the IMAP server, over TLS. Defaults to 993
+	Port int32 `json:"port,omitempty"`
+
+	// Mailbox is the address of the mailbox read
+	Mailbox string `json:"mailbox"`
+
+	// Folders read. Defaults to INBOX
+	Folders []string `json:"folders,omitempty"`
+
+	// ExcludeFolders are never read, with their subfolders
+	ExcludeFolders []string `json:"excludeFolders,omitempty"`
+
+	// FromAddresses ingests the messages of these senders only, all the
+	// messages are ingested when empty
+	FromAddresses []string `json:"fromAddresses,omitempty"`
+
+	// IngestBodies ingests the message bodies as documents. Defaults to true
+	IngestBodies *bool `json:"ingestBodies,omitempty"`
+
+	// IngestAttachments ingests the attachments as documents. Defaults to true
+	IngestAttachments *bool `json:"ingestAttachments,omitempty"`
+
+	// CredentialsSecretRef names the Secret holding the credentials: username
+	// and password for imap, tenantId, clientId and clientSecret for graph
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEmailSource
+func (r *RAGmeEmailSource) DeepCopyInto(out *RAGmeEmailSource) {
+	*out = *r
+	if r.Folders != nil {
+		out.Folders = make([]string, len(r.Folders))
+		copy(out.Folders, r.Folders)
+	}
+	if r.ExcludeFolders != nil {
+		out.ExcludeFolders = make([]string, len(r.ExcludeFolders))
+		copy(out.ExcludeFolders, r.ExcludeFolders)
+	}
+	if r.FromAddresses != nil {
+		out.FromAddresses = make([]string, len(r.FromAddresses))
+		copy(out.FromAddresses, r.FromAddresses)
+	}
+	if r.IngestBodies != nil {
+		out.IngestBodies = new(bool)
+		*out.IngestBodies = *r.IngestBodies
+	}
+	if r.IngestAttachments != nil {
+		out.IngestAttachments = new(bool)
+		*out.IngestAttachments = *r.IngestAttachments
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeEmailSource
+func (r *RAGmeEmailSource) DeepCopy() *RAGmeEmailSource {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEmailSource)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeGitSource defines the repository whose source code and docs the git
+// type ingests. Each sync only ingests the files changed since the commit of
+// the previous one.
+type RAGmeGitSource struct {
+	// URL of the repository, over https or ssh (ssh://host/repo or git@host:repo)
+	URL string `json:"url"`
+
+	// Branch synced. Defaults to main
+	Branch string `json:"branch,omitempty"`
+
+	// Paths are the globs of the files ingested, relative to the repository
+	// root (e.g. docs/**, **/*.go). Every file is ingested when empty
+	Paths []string `json:"paths,omitempty"`
+
+	// ExcludePaths are the globs of the files never ingested
+	ExcludePaths []string `json:"excludePaths,omitempty"`
+
+	// Collection the files are ingested into, keeping code apart from the
+	// documents. Defaults to the name of the data source
+	Collection string `json:"collection,omitempty"`
+
+	// CredentialsSecretRef names the Secret holding the credentials of a
+	// private repository: ssh-privatekey (and optionally known_hosts) for
+	// ssh, token for https
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeGitSource
+func (r *RAGmeGitSource) DeepCopyInto(out *RAGmeGitSource) {
+	*out = *r
+	if r.Paths != nil {
+		out.Paths = make([]string, len(r.Paths))
+		copy(out.Paths, r.Paths)
+	}
+	if r.ExcludePaths != nil {
+		out.ExcludePaths = make([]string, len(r.ExcludePaths))
+		copy(out.ExcludePaths, r.ExcludePaths)
+	}
+	if r.CredentialsSecretRef != nil {
+		out.CredentialsSecretRef = new(corev1.LocalObjectReference)
+		*out.CredentialsSecretRef = *r.CredentialsSecretRef
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeGitSource
+func (r *RAGmeGitSource) DeepCopy() *RAGmeGitSource {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeGitSource)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeDataSourceStatus defines the observed state of RAGmeDataSource
+type RAGmeDataSourceStatus struct {
+	// Phase represents the current data source phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// CronJob running the syncs
+	CronJob string `json:"cronJob,omitempty"`
+
+	// LastJob is the last finished sync Job
+	LastJob string `json:"lastJob,omitempty"`
+
+	// LastSyncTime is when the last sync finished
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// LastSuccessfulSyncTime is when the last successful sync finished
+	LastSuccessfulSyncTime *metav1.Time `json:"lastSuccessfulSyncTime,omitempty"`
+
+	// Crawl reports the statistics of the last crawl
+	Crawl RAGmeCrawlStats `json:"crawl,omitempty"`
+
+	// Connector reports the statistics of the last connector sync
+	Connector RAGmeConnectorStats `json:"connector,omitempty"`
+
+	// Email reports the statistics of the last mailbox sync
+	Email RAGmeEmailStats `json:"email,omitempty"`
+
+	// Git reports the statistics of the last repository sync
+	Git RAGmeGitStats `json:"git,omitempty"`
+
+	// Cursor is where the last successful sync stopped, the next sync only
+	// fetches the changes after it. The commit SHA of a git source
+	Cursor string `json:"cursor,omitempty"`
+
+	// CursorSettingsHash is the hash of the source settings the cursor was
+	// recorded with. The cursor is discarded when they change, so that the
+	// next sync fetches everything the new settings select
+	CursorSettingsHash string `json:"cursorSettingsHash,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeDataSourceStatus
+func (r *RAGmeDataSourceStatus) DeepCopyInto(out *RAGmeDataSourceStatus) {
+	*out = *r
+	if r.LastSyncTime != nil {
+		out.LastSyncTime = r.LastSyncTime.DeepCopy()
+	}
+	if r.LastSuccessfulSyncTime != nil {
+		out.LastSuccessfulSyncTime = r.LastSuccessfulSyncTime.DeepCopy()
+	}
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeDataSourceStatus
+func (r *RAGmeDataSourceStatus) DeepCopy() *RAGmeDataSourceStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDataSourceStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeCrawlStats defines the statistics a crawl reports when it finishes
+type RAGmeCrawlStats struct {
+	// PagesFetched is the number of pages downloaded
+	PagesFetched int64 `json:"pagesFetched,omitempty"`
+
+	// PagesIngested is the number of pages submitted to the api
+	PagesIngested int64 `json:"pagesIngested,omitempty"`
+
+	// PagesSkipped is the number of links not followed, excluded by the
+	// patterns, the domains or the page limit
+	PagesSkipped int64 `json:"pagesSkipped,omitempty"`
+
+	// RobotsDisallowed is the number of pages disallowed by robots.txt
+	RobotsDisallowed int64 `json:"robotsDisallowed,omitempty"`
+
+	// Errors is the number of pages that could not be fetched or ingested
+	Errors int64 `json:"errors,omitempty"`
+
+	// Duration of the crawl
+	Duration string `json:"duration,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeCrawlStats
+func (r *RAGmeCrawlStats) DeepCopyInto(out *RAGmeCrawlStats) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeCrawlStats
+func (r *RAGmeCrawlStats) DeepCopy() *RAGmeCrawlStats {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeCrawlStats)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeConnectorStats defines the statistics a connector sync reports when it finishes
+type RAGmeConnectorStats struct {
+	// DocumentsAdded is the number of new documents ingested
+	DocumentsAdded int64 `json:"documentsAdded,omitempty"`
+
+	// DocumentsUpdated is the number of changed documents ingested again
+	DocumentsUpdated int64 `json:"documentsUpdated,omitempty"`
+
+	// DocumentsDeleted is the number of documents removed from the instance
+	// since they were deleted from the system
+	DocumentsDeleted int64 `json:"documentsDeleted,omitempty"`
+
+	// Errors is the number of documents that could not be synced
+	Errors int64 `json:"errors,omitempty"`
+
+	// Duration of the sync
+	Duration string `json:"duration,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeConnectorStats
+func (r *RAGmeConnectorStats) DeepCopyInto(out *RAGmeConnectorStats) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeConnectorStats
+func (r *RAGmeConnectorStats) DeepCopy() *RAGmeConnectorStats {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeConnectorStats)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeEmailStats defines the statistics a mailbox sync reports when it finishes
+type RAGmeEmailStats struct {
+	// MessagesProcessed is the number of new messages read
+	MessagesProcessed int64 `json:"messagesProcessed,omitempty"`
+
+	// BodiesIngested is the number of message bodies ingested
+	BodiesIngested int64 `json:"bodiesIngested,omitempty"`
+
+	// AttachmentsIngested is the number of attachments ingested
+	AttachmentsIngested int64 `json:"attachmentsIngested,omitempty"`
+
+	// Errors is the number of messages or attachments that could not be ingested
+	Errors int64 `json:"errors,omitempty"`
+
+	// Duration of the sync
+	Duration string `json:"duration,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeEmailStats
+func (r *RAGmeEmailStats) DeepCopyInto(out *RAGmeEmailStats) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeEmailStats
+func (r *RAGmeEmailStats) DeepCopy() *RAGmeEmailStats {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeEmailStats)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeGitStats defines the statistics a repository sync reports when it finishes
+type RAGmeGitStats struct {
+	// Commit is the SHA of the commit synced
+	Commit string `json:"commit,omitempty"`
+
+	// PreviousCommit is the SHA of the commit the sync started from, empty
+	// for the first sync ingesting the whole repository
+	PreviousCommit string `json:"previousCommit,omitempty"`
+
+	// FilesIngested is the number of files added or changed and ingested
+	FilesIngested int64 `json:"filesIngested,omitempty"`
+
+	// FilesDeleted is the number of files removed from the collection since
+	// they were deleted from the repository
+	FilesDeleted int64 `json:"filesDeleted,omitempty"`
+
+	// Errors is the number of files that could not be ingested
+	Errors int64 `json:"errors,omitempty"`
+
+	// Duration of the sync
+	Duration string `json:"duration,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeGitStats
+func (r *RAGmeGitStats) DeepCopyInto(out *RAGmeGitStats) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeGitStats
+func (r *RAGmeGitStats) DeepCopy() *RAGmeGitStats {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeGitStats)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmds,rgmdatasource},categories=ragme
+
+// RAGmeDataSource is the Schema for the ragmedatasources API
+type RAGmeDataSource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeDataSourceSpec   `json:"spec,omitempty"`
+	Status RAGmeDataSourceStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeDataSource) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeDataSource) DeepCopy() *RAGmeDataSource {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDataSource)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeDataSource) DeepCopyInto(out *RAGmeDataSource) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeDataSourceList contains a list of RAGmeDataSource
+type RAGmeDataSourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeDataSource `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeDataSourceList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeDataSourceList) DeepCopy() *RAGmeDataSourceList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeDataSourceList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeDataSourceList) DeepCopyInto(out *RAGmeDataSourceList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeDataSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeDataSource{}, &RAGmeDataSourceList{})
+}
@@ -0,0 +1,250 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeExportSpec defines the desired state of RAGmeExport, a one-off dump of
+// collections of a RAGme instance into a portable archive in object storage
+type RAGmeExportSpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace) exported
+	InstanceRef string `json:"instanceRef"`
+
+	// Collections are the vector database collections exported. All the
+	// collections of the instance are exported when empty
+	Collections []string `json:"collections,omitempty"`
+
+	// Format of the archive: jsonl or parquet. Defaults to jsonl
+	Format string `json:"format,omitempty"`
+
+	// IncludeEmbeddings exports the vectors next to the documents and their
+	// metadata. Defaults to true
+	IncludeEmbeddings *bool `json:"includeEmbeddings,omitempty"`
+
+	// Destination of the archive. Defaults to the MinIO of the instance
+	Destination RAGmeExportDestination `json:"destination,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeExportSpec
+func (r *RAGmeExportSpec) DeepCopyInto(out *RAGmeExportSpec) {
+	*out = *r
+	if r.Collections != nil {
+		out.Collections = make([]string, len(r.Collections))
+		copy(out.Collections, r.Collections)
+	}
+	if r.IncludeEmbeddings != nil {
+		out.IncludeEmbeddings = new(bool)
+		*out.IncludeEmbeddings = *r.IncludeEmbeddings
+	}
+	r.Destination.DeepCopyInto(&out.Destination)
+}
+
+// DeepCopy returns a deep copy of RAGmeExportSpec
+func (r *RAGmeExportSpec) DeepCopy() *RAGmeExportSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeExportSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeExportDestination defines the S3-compatible bucket the archive is written to
+type RAGmeExportDestination struct {
+	// Endpoint of an external S3-compatible storage, e.g. https://s3.eu-west-1.amazonaws.com.
+	// The MinIO of the instance is used when empty
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region of the external storage
+	Region string `json:"region,omitempty"`
+
+	// Bucket the archive is written to, created when missing. Defaults to ragme-exports
+	Bucket string `json:"bucket,omitempty"`
+
+	// Prefix of the archive objects. Defaults to <export name>/
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef references a Secret with the accessKey and secretKey
+	// keys. Required with an external endpoint, the credentials of the api are
+	// used for the MinIO of the instance
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeExportDestination
+func (r *RAGmeExportDestination) DeepCopyInto(out *RAGmeExportDestination) {
+	*out = *r
+	if r.CredentialsSecretRef != nil {
+		out.CredentialsSecretRef = new(corev1.LocalObjectReference)
+		*out.CredentialsSecretRef = *r.CredentialsSecretRef
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeExportDestination
+func (r *RAGmeExportDestination) DeepCopy() *RAGmeExportDestination {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeExportDestination)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeExportStatus defines the observed state of RAGmeExport
+type RAGmeExportStatus struct {
+	// Phase represents the current export phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Job running the export of the observed generation
+	Job string `json:"job,omitempty"`
+
+	// StartTime is when the Job was created
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the Job finished
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Archive is the location of the archive, e.g. s3://ragme-exports/docs/
+	Archive string `json:"archive,omitempty"`
+
+	// Collections reports the documents exported per collection
+	Collections []RAGmeExportedCollection `json:"collections,omitempty"`
+
+	// Documents is the number of documents exported
+	Documents int64 `json:"documents,omitempty"`
+
+	// SizeBytes is the size of the archive
+	SizeBytes int64 `json:"sizeBytes,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeExportStatus
+func (r *RAGmeExportStatus) DeepCopyInto(out *RAGmeExportStatus) {
+	*out = *r
+	if r.StartTime != nil {
+		out.StartTime = r.StartTime.DeepCopy()
+	}
+	if r.CompletionTime != nil {
+		out.CompletionTime = r.CompletionTime.DeepCopy()
+	}
+	if r.Collections != nil {
+		out.Collections = make([]RAGmeExportedCollection, len(r.Collections))
+		copy(out.Collections, r.Collections)
+	}
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeExportStatus
+func (r *RAGmeExportStatus) DeepCopy() *RAGmeExportStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeExportStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeExportedCollection reports the export of a collection
+type RAGmeExportedCollection struct {
+	// Name of the collection
+	Name string `json:"name"`
+
+	// Documents is the number of documents exported
+	Documents int64 `json:"documents"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmex,rgmexport},categories=ragme
+
+// RAGmeExport is the Schema for the ragmeexports API
+type RAGmeExport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeExportSpec   `json:"spec,omitempty"`
+	Status RAGmeExportStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeExport) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeExport) DeepCopy() *RAGmeExport {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeExport)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeExport) DeepCopyInto(out *RAGmeExport) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeExportList contains a list of RAGmeExport
+type RAGmeExportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeExport `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeExportList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeExportList) DeepCopy() *RAGmeExportList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeExportList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeExportList) DeepCopyInto(out *RAGmeExportList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeExport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeExport{}, &RAGmeExportList{})
+}
@@ -0,0 +1,381 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeLoadTestSpec defines the desired state of RAGmeLoadTest. The load test
+// runs once per spec generation.
+type RAGmeLoadTestSpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace) under test
+	InstanceRef string `json:"instanceRef"`
+
+	// Tool generating the load: k6 (default) or vegeta
+	Tool string `json:"tool,omitempty"`
+
+	// Image of the tool. Defaults to grafana/k6 or peterevans/vegeta
+	Image string `json:"image,omitempty"`
+
+	// Scenarios run one after the other against the query endpoint of the api
+	Scenarios []RAGmeLoadTestScenario `json:"scenarios"`
+
+	// Thresholds a scenario must meet for its throughput to count as capacity
+	Thresholds RAGmeLoadTestThresholds `json:"thresholds,omitempty"`
+
+	// Autoscaling adjusts a HorizontalPodAutoscaler of the api from the measured capacity
+	Autoscaling RAGmeLoadTestAutoscaling `json:"autoscaling,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestSpec
+func (r *RAGmeLoadTestSpec) DeepCopyInto(out *RAGmeLoadTestSpec) {
+	*out = *r
+	if r.Scenarios != nil {
+		out.Scenarios = make([]RAGmeLoadTestScenario, len(r.Scenarios))
+		for i := range r.Scenarios {
+			r.Scenarios[i].DeepCopyInto(&out.Scenarios[i])
+		}
+	}
+	r.Thresholds.DeepCopyInto(&out.Thresholds)
+	r.Autoscaling.DeepCopyInto(&out.Autoscaling)
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestSpec
+func (r *RAGmeLoadTestSpec) DeepCopy() *RAGmeLoadTestSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestScenario defines a constant query rate held for a duration
+type RAGmeLoadTestScenario struct {
+	// Name of the scenario, a DNS label
+	Name string `json:"name"`
+
+	// QPS is the query rate sent to the api
+	QPS int32 `json:"qps"`
+
+	// Duration of the scenario. Defaults to 1m
+	Duration string `json:"duration,omitempty"`
+
+	// Queries sent, picked according to their weight
+	Queries []RAGmeLoadTestQuery `json:"queries"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestScenario
+func (r *RAGmeLoadTestScenario) DeepCopyInto(out *RAGmeLoadTestScenario) {
+	*out = *r
+	if r.Queries != nil {
+		out.Queries = make([]RAGmeLoadTestQuery, len(r.Queries))
+		copy(out.Queries, r.Queries)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestScenario
+func (r *RAGmeLoadTestScenario) DeepCopy() *RAGmeLoadTestScenario {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestScenario)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestQuery defines a query of the mix of a scenario
+type RAGmeLoadTestQuery struct {
+	// Query sent to the api
+	Query string `json:"query"`
+
+	// Weight of the query in the mix. Defaults to 1
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestQuery
+func (r *RAGmeLoadTestQuery) DeepCopyInto(out *RAGmeLoadTestQuery) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestQuery
+func (r *RAGmeLoadTestQuery) DeepCopy() *RAGmeLoadTestQuery {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestQuery)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestThresholds defines when a scenario is sustained by the instance
+type RAGmeLoadTestThresholds struct {
+	// LatencyP95 is the highest 95th percentile latency of a sustained scenario. Defaults to 2s
+	LatencyP95 string `json:"latencyP95,omitempty"`
+
+	// SuccessRatio is the lowest ratio of successful queries of a sustained scenario. Defaults to 0.99
+	SuccessRatio string `json:"successRatio,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestThresholds
+func (r *RAGmeLoadTestThresholds) DeepCopyInto(out *RAGmeLoadTestThresholds) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestThresholds
+func (r *RAGmeLoadTestThresholds) DeepCopy() *RAGmeLoadTestThresholds {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestThresholds)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestAutoscaling defines how the measured capacity adjusts an
+// existing HorizontalPodAutoscaler of the api. The operator does not create it.
+type RAGmeLoadTestAutoscaling struct {
+	// Enabled adjusts the HorizontalPodAutoscaler once the load test succeeds
+	Enabled bool `json:"enabled,omitempty"`
+
+	// HPAName is the name of the HorizontalPodAutoscaler. Defaults to <instance>-api
+	HPAName string `json:"hpaName,omitempty"`
+
+	// Utilization is the percentage of the capacity of a replica the
+	// HorizontalPodAutoscaler aims at. Defaults to 70
+	Utilization int32 `json:"utilization,omitempty"`
+
+	// PeakQPS is the expected peak query rate. When set, maxReplicas is raised
+	// to the replicas serving it at the target utilization
+	PeakQPS int32 `json:"peakQPS,omitempty"`
+
+	// MetricName is a Pods metric of the HorizontalPodAutoscaler counting the
+	// queries per second of a replica. When set, its average value target is
+	// set to the capacity of a replica at the target utilization
+	MetricName string `json:"metricName,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestAutoscaling
+func (r *RAGmeLoadTestAutoscaling) DeepCopyInto(out *RAGmeLoadTestAutoscaling) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestAutoscaling
+func (r *RAGmeLoadTestAutoscaling) DeepCopy() *RAGmeLoadTestAutoscaling {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestAutoscaling)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestStatus defines the observed state of RAGmeLoadTest
+type RAGmeLoadTestStatus struct {
+	// Phase represents the current load test phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Job running the load test of the observed generation
+	Job string `json:"job,omitempty"`
+
+	// StartTime is when the Job was created
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the results were collected
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// APIReplicas is the number of ready api replicas when the load test started
+	APIReplicas int32 `json:"apiReplicas,omitempty"`
+
+	// Results of the scenarios that ran
+	Results []RAGmeLoadTestResult `json:"results,omitempty"`
+
+	// CapacityQPS is the highest throughput of a sustained scenario
+	CapacityQPS string `json:"capacityQPS,omitempty"`
+
+	// ResultsConfigMap is the ConfigMap holding the reports of the scenarios
+	ResultsConfigMap string `json:"resultsConfigMap,omitempty"`
+
+	// Autoscaling reports the adjustment of the HorizontalPodAutoscaler
+	Autoscaling RAGmeLoadTestAutoscalingStatus `json:"autoscaling,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestStatus
+func (r *RAGmeLoadTestStatus) DeepCopyInto(out *RAGmeLoadTestStatus) {
+	*out = *r
+	if r.StartTime != nil {
+		out.StartTime = r.StartTime.DeepCopy()
+	}
+	if r.CompletionTime != nil {
+		out.CompletionTime = r.CompletionTime.DeepCopy()
+	}
+	if r.Results != nil {
+		out.Results = make([]RAGmeLoadTestResult, len(r.Results))
+		for i := range r.Results {
+			r.Results[i].DeepCopyInto(&out.Results[i])
+		}
+	}
+	r.Autoscaling.DeepCopyInto(&out.Autoscaling)
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestStatus
+func (r *RAGmeLoadTestStatus) DeepCopy() *RAGmeLoadTestStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestResult defines the measurements of a scenario
+type RAGmeLoadTestResult struct {
+	Scenario string `json:"scenario"`
+	// QPS is the query rate of the scenario
+	QPS      int32 `json:"qps,omitempty"`
+	Requests int64 `json:"requests,omitempty"`
+	// Throughput is the rate of successful queries per second
+	Throughput   string `json:"throughput,omitempty"`
+	SuccessRatio string `json:"successRatio,omitempty"`
+	LatencyP50   string `json:"latencyP50,omitempty"`
+	LatencyP95   string `json:"latencyP95,omitempty"`
+	LatencyP99   string `json:"latencyP99,omitempty"`
+	// Sustained reports whether the scenario met the thresholds
+	Sustained bool `json:"sustained,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestResult
+func (r *RAGmeLoadTestResult) DeepCopyInto(out *RAGmeLoadTestResult) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestResult
+func (r *RAGmeLoadTestResult) DeepCopy() *RAGmeLoadTestResult {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestResult)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeLoadTestAutoscalingStatus defines the adjustment applied to the HorizontalPodAutoscaler
+type RAGmeLoadTestAutoscalingStatus struct {
+	HPA string `json:"hpa,omitempty"`
+	// MaxReplicas set from the peak query rate
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+	// TargetAverageValue set on the queries per second metric
+	TargetAverageValue string `json:"targetAverageValue,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeLoadTestAutoscalingStatus
+func (r *RAGmeLoadTestAutoscalingStatus) DeepCopyInto(out *RAGmeLoadTestAutoscalingStatus) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeLoadTestAutoscalingStatus
+func (r *RAGmeLoadTestAutoscalingStatus) DeepCopy() *RAGmeLoadTestAutoscalingStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestAutoscalingStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmlt,rgmloadtest},categories=ragme
+
+// RAGmeLoadTest is the Schema for the ragmeloadtests API
+type RAGmeLoadTest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeLoadTestSpec   `json:"spec,omitempty"`
+	Status RAGmeLoadTestStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeLoadTest) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeLoadTest) DeepCopy() *RAGmeLoadTest {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTest)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeLoadTest) DeepCopyInto(out *RAGmeLoadTest) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeLoadTestList contains a list of RAGmeLoadTest
+type RAGmeLoadTestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeLoadTest `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeLoadTestList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeLoadTestList) DeepCopy() *RAGmeLoadTestList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeLoadTestList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeLoadTestList) DeepCopyInto(out *RAGmeLoadTestList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeLoadTest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeLoadTest{}, &RAGmeLoadTestList{})
+}
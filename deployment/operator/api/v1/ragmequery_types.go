@@ -0,0 +1,207 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeQuerySpec defines the desired state of RAGmeQuery, a saved query the
+// operator asks a RAGme instance on a schedule, checking the answer still
+// mentions the expected keywords
+type RAGmeQuerySpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace) queried
+	InstanceRef string `json:"instanceRef"`
+
+	// Query asked to the query endpoint of the api
+	Query string `json:"query"`
+
+	// ExpectedKeywords the answer must contain, case-insensitively. Only
+	// the answer being returned is checked when empty
+	ExpectedKeywords []string `json:"expectedKeywords,omitempty"`
+
+	// Match requires all the expected keywords, or any of them. Defaults to all
+	Match string `json:"match,omitempty"`
+
+	// Schedule of the runs, in cron format. Defaults to every 5 minutes
+	Schedule string `json:"schedule,omitempty"`
+
+	// Timeout of a run. Defaults to 30s
+	Timeout string `json:"timeout,omitempty"`
+
+	// MaxLatency fails a run answering slower, even with the expected keywords
+	MaxLatency string `json:"maxLatency,omitempty"`
+
+	// Suspend pauses the runs without deleting the query
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeQuerySpec
+func (r *RAGmeQuerySpec) DeepCopyInto(out *RAGmeQuerySpec) {
+	*out = *r
+	if r.ExpectedKeywords != nil {
+		out.ExpectedKeywords = make([]string, len(r.ExpectedKeywords))
+		copy(out.ExpectedKeywords, r.ExpectedKeywords)
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeQuerySpec
+func (r *RAGmeQuerySpec) DeepCopy() *RAGmeQuerySpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeQuerySpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeQueryStatus defines the observed state of RAGmeQuery
+type RAGmeQueryStatus struct {
+	// Phase represents the current query phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastRunTime is when the query was last asked
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// NextRunTime is when the query is asked next
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+
+	// Result of the last run: Passed or Failed
+	Result string `json:"result,omitempty"`
+
+	// Latency of the last run
+	Latency string `json:"latency,omitempty"`
+
+	// MissingKeywords are the expected keywords the last answer lacked
+	MissingKeywords []string `json:"missingKeywords,omitempty"`
+
+	// Runs is the number of runs since the query was created
+	Runs int64 `json:"runs,omitempty"`
+
+	// PassedRuns is the number of passed runs since the query was created
+	PassedRuns int64 `json:"passedRuns,omitempty"`
+
+	// ConsecutiveFailures is the number of failed runs since the last passed one
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeQueryStatus
+func (r *RAGmeQueryStatus) DeepCopyInto(out *RAGmeQueryStatus) {
+	*out = *r
+	if r.LastRunTime != nil {
+		out.LastRunTime = r.LastRunTime.DeepCopy()
+	}
+	if r.NextRunTime != nil {
+		out.NextRunTime = r.NextRunTime.DeepCopy()
+	}
+	if r.MissingKeywords != nil {
+		out.MissingKeywords = make([]string, len(r.MissingKeywords))
+		copy(out.MissingKeywords, r.MissingKeywords)
+	}
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeQueryStatus
+func (r *RAGmeQueryStatus) DeepCopy() *RAGmeQueryStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeQueryStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmq,rgmquery},categories=ragme
+
+// RAGmeQuery is the Schema for the ragmequeries API
+type RAGmeQuery struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeQuerySpec   `json:"spec,omitempty"`
+	Status RAGmeQueryStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeQuery) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeQuery) DeepCopy() *RAGmeQuery {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeQuery)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeQuery) DeepCopyInto(out *RAGmeQuery) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeQueryList contains a list of RAGmeQuery
+type RAGmeQueryList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeQuery `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeQueryList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeQueryList) DeepCopy() *RAGmeQueryList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeQueryList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeQueryList) DeepCopyInto(out *RAGmeQueryList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeQuery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeQuery{}, &RAGmeQueryList{})
+}
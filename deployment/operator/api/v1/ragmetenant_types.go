@@ -0,0 +1,209 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// RAGmeTenantSpec defines the desired state of RAGmeTenant
+type RAGmeTenantSpec struct {
+	// InstanceRef is the name of the RAGme instance (in the same namespace) serving the tenant
+	InstanceRef string `json:"instanceRef"`
+
+	// DisplayName of the tenant
+	DisplayName string `json:"displayName,omitempty"`
+
+	// Organization is the OAuth organization mapped to this tenant
+	Organization string `json:"organization,omitempty"`
+
+	// Bucket holding the tenant documents. Defaults to ragme-<tenant name>
+	Bucket string `json:"bucket,omitempty"`
+
+	// Quotas applied to the tenant
+	Quotas RAGmeTenantQuotas `json:"quotas,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTenantSpec
+func (r *RAGmeTenantSpec) DeepCopyInto(out *RAGmeTenantSpec) {
+	*out = *r
+	r.Quotas.DeepCopyInto(&out.Quotas)
+}
+
+// DeepCopy returns a deep copy of RAGmeTenantSpec
+func (r *RAGmeTenantSpec) DeepCopy() *RAGmeTenantSpec {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenantSpec)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTenantQuotas defines tenant quotas. Zero values mean unlimited
+type RAGmeTenantQuotas struct {
+	MaxDocuments     int64  `json:"maxDocuments,omitempty"`
+	MaxStorage       string `json:"maxStorage,omitempty"`
+	MaxQueriesPerDay int64  `json:"maxQueriesPerDay,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTenantQuotas
+func (r *RAGmeTenantQuotas) DeepCopyInto(out *RAGmeTenantQuotas) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeTenantQuotas
+func (r *RAGmeTenantQuotas) DeepCopy() *RAGmeTenantQuotas {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenantQuotas)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTenantStatus defines the observed state of RAGmeTenant
+type RAGmeTenantStatus struct {
+	// Phase represents the current tenant phase
+	Phase string `json:"phase,omitempty"`
+
+	// ObservedGeneration is the last spec generation the operator acted on
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Usage observed for the tenant
+	Usage RAGmeTenantUsage `json:"usage,omitempty"`
+
+	// AppliedQuota identifies the bucket and quota last applied by a succeeded
+	// Job, so the Job is not run again once removed after its TTL
+	AppliedQuota string `json:"appliedQuota,omitempty"`
+
+	// Conditions represent the latest available observations
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTenantStatus
+func (r *RAGmeTenantStatus) DeepCopyInto(out *RAGmeTenantStatus) {
+	*out = *r
+	r.Usage.DeepCopyInto(&out.Usage)
+	if r.Conditions != nil {
+		out.Conditions = make([]metav1.Condition, len(r.Conditions))
+		for i := range r.Conditions {
+			r.Conditions[i].DeepCopyInto(&out.Conditions[i])
+		}
+	}
+}
+
+// DeepCopy returns a deep copy of RAGmeTenantStatus
+func (r *RAGmeTenantStatus) DeepCopy() *RAGmeTenantStatus {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenantStatus)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// RAGmeTenantUsage defines resource usage of a tenant
+type RAGmeTenantUsage struct {
+	Documents    int64 `json:"documents,omitempty"`
+	StorageBytes int64 `json:"storageBytes,omitempty"`
+}
+
+// DeepCopyInto copies the receiver into the given *RAGmeTenantUsage
+func (r *RAGmeTenantUsage) DeepCopyInto(out *RAGmeTenantUsage) {
+	*out = *r
+}
+
+// DeepCopy returns a deep copy of RAGmeTenantUsage
+func (r *RAGmeTenantUsage) DeepCopy() *RAGmeTenantUsage {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenantUsage)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName={rmt,rgmtenant},categories=ragme
+
+// RAGmeTenant is the Schema for the ragmetenants API
+type RAGmeTenant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RAGmeTenantSpec   `json:"spec,omitempty"`
+	Status RAGmeTenantStatus `json:"status,omitempty"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeTenant) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeTenant) DeepCopy() *RAGmeTenant {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenant)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeTenant) DeepCopyInto(out *RAGmeTenant) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	r.Spec.DeepCopyInto(&out.Spec)
+	r.Status.DeepCopyInto(&out.Status)
+}
+
+// +kubebuilder:object:root=true
+
+// RAGmeTenantList contains a list of RAGmeTenant
+type RAGmeTenantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RAGmeTenant `json:"items"`
+}
+
+// DeepCopyObject implements runtime.Object
+func (r *RAGmeTenantList) DeepCopyObject() runtime.Object {
+	if c := r.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopy implements runtime.Object
+func (r *RAGmeTenantList) DeepCopy() *RAGmeTenantList {
+	if r == nil {
+		return nil
+	}
+	out := new(RAGmeTenantList)
+	r.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto implements runtime.Object
+func (r *RAGmeTenantList) DeepCopyInto(out *RAGmeTenantList) {
+	*out = *r
+	out.TypeMeta = r.TypeMeta
+	r.ListMeta.DeepCopyInto(&out.ListMeta)
+	if r.Items != nil {
+		in, out := &r.Items, &out.Items
+		*out = make([]RAGmeTenant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+func init() {
+	SchemeBuilder.Register(&RAGmeTenant{}, &RAGmeTenantList{})
+}
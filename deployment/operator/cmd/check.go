@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/controller"
+)
+
+// runCheck implements the check subcommand, which runs the preflight checks
+// against the current cluster and returns the process exit code
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	var manifest string
+	flags.StringVar(&manifest, "f", "", "RAGme manifest to check the prerequisites of. Defaults to an instance with the default spec")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if manifest != "" {
+		file, err := os.Open(manifest)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to read manifest: %v\n", err)
+			return 2
+		}
+		defer file.Close()
+		if err := yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(ragme); err != nil {
+			fmt.Fprintf(os.Stderr, "unable to decode manifest: %v\n", err)
+			return 2
+		}
+	}
+
+	config, err := ctrl.GetConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to load kubeconfig: %v\n", err)
+		return 2
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to create client: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	results, err := controller.RunPreflight(ctx, c, ragme)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "preflight checks failed to run: %v\n", err)
+		return 2
+	}
+
+	code := 0
+	for _, result := range results {
+		status := "PASS"
+		switch {
+		case !result.Passed && result.Required:
+			status = "FAIL"
+			code = 1
+		case !result.Passed:
+			status = "WARN"
+		}
+		fmt.Printf("[%s] %s\n", status, result)
+	}
+	return code
+}
@@ -2,13 +2,17 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -34,11 +38,26 @@ func init() {
 }
 
 func main() {
+	// ragme-operator check runs the preflight checks and exits
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var resync controller.ResyncConfig
+	var skipPreflight bool
+	var statusAddr string
+	var enableWebhooks bool
+	var protectManagedResources bool
+	var operatorUsername string
+	var orphanScanInterval time.Duration
+	var deleteOrphans bool
+	var operatorConfig string
+	var parallelism int
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -49,6 +68,32 @@ func main() {
 		"If set the metrics endpoint is served securely")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&resync.Period, "resync-period", controller.DefaultResyncPeriod,
+		"How often unchanged resources are reconciled to detect drift. "+
+			"Can be overridden per resource with the "+controller.ResyncPeriodAnnotation+" annotation.")
+	flag.BoolVar(&resync.Disabled, "disable-resync", false,
+		"If set, resources are only reconciled on watch events and never resynced periodically")
+	flag.BoolVar(&skipPreflight, "skip-preflight", false,
+		"If set, cluster prerequisites are not checked before the first deploy of an instance")
+	flag.StringVar(&statusAddr, "status-bind-address", "0",
+		"The address the instance status endpoint binds to. Set it to e.g. :8082 to serve the summaries, \"0\" disables the endpoint")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", false,
+		"If set, RAGme specs are defaulted and validated at admission. Requires the serving certificate of config/webhook/")
+	flag.BoolVar(&protectManagedResources, "protect-managed-resources", false,
+		"If set, manual edits of the generated Deployments and Services are rejected at admission. "+
+			"Requires --enable-webhooks and config/webhook/protection/")
+	flag.StringVar(&operatorUsername, "operator-username", controller.DefaultOperatorUsername,
+		"The user the operator runs as, whose updates of the generated resources are always admitted")
+	flag.DurationVar(&orphanScanInterval, "orphan-scan-interval", controller.DefaultOrphanScanInterval,
+		"How often the cluster is swept for ragme-labeled resources whose instance is gone, \"0\" disables the sweep")
+	flag.BoolVar(&deleteOrphans, "delete-orphans", false,
+		"If set, the orphaned resources found by the sweep are deleted instead of only reported. Data volumes and retained credentials are never deleted")
+	flag.StringVar(&operatorConfig, "operator-config", "",
+		"The <namespace>/<name> of the ConfigMap holding the organization-wide defaults and policies in its "+
+			controller.OperatorConfigKey+" key. Empty applies none")
+	flag.IntVar(&parallelism, "reconcile-parallelism", controller.DefaultParallelism,
+		"How many independent areas of an instance, e.g. the object store and the vector database, are reconciled at the same time. "+
+			"1 reconciles them one after the other")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -103,15 +148,113 @@ func main() {
 		os.Exit(1)
 	}
 
+	var config *controller.OperatorConfigSource
+	if operatorConfig != "" {
+		namespace, name, found := strings.Cut(operatorConfig, "/")
+		if !found || namespace == "" || name == "" {
+			setupLog.Error(fmt.Errorf("expected <namespace>/<name>, got %q", operatorConfig), "invalid --operator-config")
+			os.Exit(1)
+		}
+		config = &controller.OperatorConfigSource{
+			Reader:    mgr.GetClient(),
+			ConfigMap: types.NamespacedName{Namespace: namespace, Name: name},
+		}
+	}
+
 	if err = (&controller.RAGmeReconciler{
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		Resync:        resync,
+		SkipPreflight: skipPreflight,
+		Config:        config,
+		Parallelism:   parallelism,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGme")
+		os.Exit(1)
+	}
+	if err = (&controller.RAGmeCollectionReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Resync: resync,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "RAGme")
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeCollection")
+		os.Exit(1)
+	}
+	if err = (&controller.RAGmeTenantReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Resync: resync,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeTenant")
+		os.Exit(1)
+	}
+	if err = (&controller.RAGmeLoadTestReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeLoadTest")
 		os.Exit(1)
 	}
+	if err = (&controller.RAGmeDataSourceReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Resync: resync,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeDataSource")
+		os.Exit(1)
+	}
+	if err = (&controller.RAGmeQueryReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Resync: resync,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeQuery")
+		os.Exit(1)
+	}
+	if err = (&controller.RAGmeExportReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "RAGmeExport")
+		os.Exit(1)
+	}
+	if enableWebhooks {
+		if err = (&controller.RAGmeDefaulter{Config: config}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RAGmeDefaults")
+			os.Exit(1)
+		}
+		if err = (&controller.RAGmeValidator{Config: config}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RAGme")
+			os.Exit(1)
+		}
+		if protectManagedResources {
+			if err = (&controller.ManagedResourceGuard{OperatorUsername: operatorUsername}).SetupWebhookWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create webhook", "webhook", "ManagedResources")
+				os.Exit(1)
+			}
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
+	if statusAddr != "0" {
+		if err := mgr.Add(&controller.StatusServer{Client: mgr.GetClient(), Addr: statusAddr}); err != nil {
+			setupLog.Error(err, "unable to set up status server")
+			os.Exit(1)
+		}
+	}
+
+	if orphanScanInterval > 0 {
+		if err := mgr.Add(&controller.OrphanJanitor{
+			Reader:   mgr.GetAPIReader(),
+			Client:   mgr.GetClient(),
+			Interval: orphanScanInterval,
+			Delete:   deleteOrphans,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up orphan janitor")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -0,0 +1,261 @@
+// Package conditions provides helpers to maintain the status conditions of the
+// RAGme resources and defines the condition types and reasons they use.
+//
+// Every resource carries the following summary conditions, following the
+// conventions understood by kstatus and GitOps health checks:
+//
+//   - Ready: True once the resource is fully reconciled.
+//   - Reconciling: True while the operator is converging the resource. Removed once Ready.
+//   - Stalled: True when the operator cannot make progress without a spec change
+//     (e.g. an invalid spec). Removed once the resource reconciles again.
+//   - Degraded: True when the last reconciliation failed and is being retried.
+//
+// Informational conditions (StorageAlmostFull, QuotaExceeded, ...) describe
+// a single aspect of the resource and do not affect the summary conditions.
+// Use the Set helpers for them, and the Mark helpers for the summary conditions.
+package conditions
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Summary condition types
+const (
+	TypeReady       = "Ready"
+	TypeReconciling = "Reconciling"
+	TypeStalled     = "Stalled"
+	TypeDegraded    = "Degraded"
+)
+
+// Informational condition types
+const (
+	TypeSpecValid                = "SpecValid"
+	TypeStorageAlmostFull        = "StorageAlmostFull"
+	TypeOAuthRedirectURIConflict = "OAuthRedirectURIConflict"
+	TypeLDAPConnectionVerified   = "LDAPConnectionVerified"
+	TypeSessionStoreShared       = "SessionStoreShared"
+	TypeDryRun                   = "DryRun"
+	TypeQuotaExceeded            = "QuotaExceeded"
+	TypeBlockedByImmutableField  = "BlockedByImmutableField"
+	TypeSharedVolumeAlmostFull   = "SharedVolumeAlmostFull"
+	TypeShardsBalanced           = "ShardsBalanced"
+	TypeWeaviateUpgraded         = "WeaviateUpgraded"
+	TypePreflightPassed          = "PreflightPassed"
+	TypeMaintenance              = "Maintenance"
+	TypeHibernated               = "Hibernated"
+	TypeRestored                 = "Restored"
+	TypeDataSeeded               = "DataSeeded"
+	TypeBudgetExceeded           = "BudgetExceeded"
+	TypeQualityRegressed         = "QualityRegressed"
+	TypeSelfHealingVerified      = "SelfHealingVerified"
+	TypeAutoscalingAdjusted      = "AutoscalingAdjusted"
+	TypeDeletionBlocked          = "DeletionBlocked"
+	TypeNodeFailure              = "NodeFailure"
+	TypeIngestionThrottled       = "IngestionThrottled"
+	TypeCredentialsValid         = "CredentialsValid"
+	TypeDrifted                  = "Drifted"
+	TypeMigrated                 = "Migrated"
+	TypePolicyViolation          = "PolicyViolation"
+)
+
+// Reasons of the summary conditions
+const (
+	// ReasonReconciled: all resources match the spec
+	ReasonReconciled = "Reconciled"
+	// ReasonProgressing: the operator is applying the spec
+	ReasonProgressing = "Progressing"
+	// ReasonReconcileFailed: applying the spec failed and will be retried
+	ReasonReconcileFailed = "ReconcileFailed"
+	// ReasonValidationFailed: the spec is invalid and will not be applied
+	ReasonValidationFailed = "ValidationFailed"
+	// ReasonPolicyViolated: the spec matches a rule of the deny-list enforced by the operator
+	ReasonPolicyViolated = "PolicyViolated"
+	// ReasonInstanceNotFound: the referenced RAGme instance does not exist
+	ReasonInstanceNotFound = "InstanceNotFound"
+	// ReasonSynced: the collection matches the vector database, or the data source was synced
+	ReasonSynced = "Synced"
+	// ReasonSyncFailed: syncing the collection with the vector database, or the data source, failed and will be retried
+	ReasonSyncFailed = "SyncFailed"
+	// ReasonPreflightFailed: a cluster prerequisite is missing, checked again periodically
+	ReasonPreflightFailed = "PreflightFailed"
+	// ReasonQueryPassed: the last run of the saved query passed its checks
+	ReasonQueryPassed = "QueryPassed"
+	// ReasonQueryFailed: the last run of the saved query failed its checks, run again on schedule
+	ReasonQueryFailed = "QueryFailed"
+)
+
+// Reasons of the informational conditions
+const (
+	ReasonValidationSucceeded       = "ValidationSucceeded"
+	ReasonCapacityWithinThreshold   = "CapacityWithinThreshold"
+	ReasonCapacityThresholdExceeded = "CapacityThresholdExceeded"
+	ReasonRedirectURIOverridden     = "RedirectURIOverridden"
+	ReasonChecking                  = "Checking"
+	ReasonConnectionSucceeded       = "ConnectionSucceeded"
+	ReasonConnectionFailed          = "ConnectionFailed"
+	ReasonInMemorySessionStore      = "InMemorySessionStore"
+	ReasonNoChanges                 = "NoChanges"
+	ReasonChangesPending            = "ChangesPending"
+	ReasonDryRunFailed              = "DryRunFailed"
+	ReasonWithinQuota               = "WithinQuota"
+	ReasonDocumentQuotaExceeded     = "DocumentQuotaExceeded"
+	ReasonStorageQuotaExceeded      = "StorageQuotaExceeded"
+	ReasonImmutableFieldChanged     = "ImmutableFieldChanged"
+	ReasonVolumeExpanded            = "VolumeExpanded"
+	ReasonVolumeAtMaxSize           = "VolumeAtMaxSize"
+	ReasonRebalancing               = "Rebalancing"
+	ReasonRebalanceSucceeded        = "RebalanceSucceeded"
+	ReasonRebalanceFailed           = "RebalanceFailed"
+	ReasonUpgrading                 = "Upgrading"
+	ReasonUpgradeSucceeded          = "UpgradeSucceeded"
+	ReasonIncompatibleVersion       = "IncompatibleVersion"
+	ReasonBackupFailed              = "BackupFailed"
+	ReasonVerificationFailed        = "VerificationFailed"
+	ReasonPreflightSucceeded        = "PreflightSucceeded"
+	ReasonReadOnlyMaintenance       = "ReadOnlyMaintenance"
+	ReasonFullMaintenance           = "FullMaintenance"
+	ReasonHibernationRequested      = "HibernationRequested"
+	ReasonHibernationWindow         = "HibernationWindow"
+	ReasonAwake                     = "Awake"
+	ReasonRestoring                 = "Restoring"
+	ReasonRestoreSucceeded          = "RestoreSucceeded"
+	ReasonRestoreSourceNotFound     = "RestoreSourceNotFound"
+	ReasonRestoreSkipped            = "RestoreSkipped"
+	ReasonWaitingForReady           = "WaitingForReady"
+	ReasonSeeding                   = "Seeding"
+	ReasonSeedSucceeded             = "SeedSucceeded"
+	ReasonSeedFailed                = "SeedFailed"
+	ReasonWithinBudget              = "WithinBudget"
+	ReasonBudgetThresholdReached    = "BudgetThresholdReached"
+	ReasonDailyBudgetExceeded       = "DailyBudgetExceeded"
+	ReasonUserBudgetExceeded        = "UserBudgetExceeded"
+	ReasonQualityWithinThresholds   = "QualityWithinThresholds"
+	ReasonScoreBelowThreshold       = "ScoreBelowThreshold"
+	ReasonEvaluationFailed          = "EvaluationFailed"
+	ReasonRecoveredWithinTimeout    = "RecoveredWithinTimeout"
+	ReasonRecoveryTimedOut          = "RecoveryTimedOut"
+	ReasonLoadTestSucceeded         = "LoadTestSucceeded"
+	ReasonLoadTestFailed            = "LoadTestFailed"
+	ReasonExportSucceeded           = "ExportSucceeded"
+	ReasonExportFailed              = "ExportFailed"
+	ReasonHPAAdjusted               = "HPAAdjusted"
+	ReasonHPANotFound               = "HPANotFound"
+	ReasonCapacityNotMeasured       = "CapacityNotMeasured"
+	ReasonDeletionStuck             = "DeletionStuck"
+	ReasonNodesReachable            = "NodesReachable"
+	ReasonNodeNotReady              = "NodeNotReady"
+	ReasonNodeUnreachable           = "NodeUnreachable"
+	ReasonPodsRescheduled           = "PodsRescheduled"
+	ReasonQueueAvailable            = "QueueAvailable"
+	ReasonQueueFull                 = "QueueFull"
+	ReasonCredentialsFound          = "CredentialsFound"
+	ReasonCredentialsMissing        = "CredentialsMissing"
+	ReasonNoDrift                   = "NoDrift"
+	ReasonDriftDetected             = "DriftDetected"
+	ReasonDriftCorrected            = "DriftCorrected"
+	ReasonMigrating                 = "Migrating"
+	ReasonMigrationSucceeded        = "MigrationSucceeded"
+	ReasonMigrationSkipped          = "MigrationSkipped"
+	ReasonPreviousInstanceExists    = "PreviousInstanceExists"
+	ReasonPreviousVolumesNotFound   = "PreviousVolumesNotFound"
+	ReasonPoliciesSatisfied         = "PoliciesSatisfied"
+	ReasonApplyingQuota             = "ApplyingQuota"
+	ReasonQuotaFailed               = "QuotaFailed"
+)
+
+// Phases derived from the summary conditions
+const (
+	PhasePending     = "Pending"
+	PhaseReconciling = "Reconciling"
+	PhaseReady       = "Ready"
+	PhaseHibernated  = "Hibernated"
+	PhaseDegraded    = "Degraded"
+	PhaseFailed      = "Failed"
+)
+
+// Set sets a condition, observed at the given generation
+func Set(conditions *[]metav1.Condition, generation int64, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	})
+}
+
+// SetTrue sets a condition to True
+func SetTrue(conditions *[]metav1.Condition, generation int64, conditionType, reason, message string) {
+	Set(conditions, generation, conditionType, metav1.ConditionTrue, reason, message)
+}
+
+// SetFalse sets a condition to False
+func SetFalse(conditions *[]metav1.Condition, generation int64, conditionType, reason, message string) {
+	Set(conditions, generation, conditionType, metav1.ConditionFalse, reason, message)
+}
+
+// SetUnknown sets a condition to Unknown
+func SetUnknown(conditions *[]metav1.Condition, generation int64, conditionType, reason, message string) {
+	Set(conditions, generation, conditionType, metav1.ConditionUnknown, reason, message)
+}
+
+// Remove removes a condition
+func Remove(conditions *[]metav1.Condition, conditionType string) {
+	meta.RemoveStatusCondition(conditions, conditionType)
+}
+
+// IsTrue reports whether a condition is present and True
+func IsTrue(conditions []metav1.Condition, conditionType string) bool {
+	return meta.IsStatusConditionTrue(conditions, conditionType)
+}
+
+// MarkReconciling records that the operator is converging the resource
+func MarkReconciling(conditions *[]metav1.Condition, generation int64, reason, message string) {
+	SetTrue(conditions, generation, TypeReconciling, reason, message)
+	// Keep a previous Ready=True until the reconciliation finishes, but record the new generation
+	if ready := meta.FindStatusCondition(*conditions, TypeReady); ready == nil || ready.Status != metav1.ConditionTrue {
+		SetFalse(conditions, generation, TypeReady, reason, message)
+	}
+}
+
+// MarkReady records that the resource is fully reconciled
+func MarkReady(conditions *[]metav1.Condition, generation int64, reason, message string) {
+	SetTrue(conditions, generation, TypeReady, reason, message)
+	Remove(conditions, TypeReconciling)
+	Remove(conditions, TypeStalled)
+	Remove(conditions, TypeDegraded)
+}
+
+// MarkDegraded records a failed reconciliation that will be retried
+func MarkDegraded(conditions *[]metav1.Condition, generation int64, reason, message string) {
+	SetTrue(conditions, generation, TypeDegraded, reason, message)
+	SetFalse(conditions, generation, TypeReady, reason, message)
+	Remove(conditions, TypeReconciling)
+}
+
+// MarkStalled records that the resource cannot progress without a spec change
+func MarkStalled(conditions *[]metav1.Condition, generation int64, reason, message string) {
+	SetTrue(conditions, generation, TypeStalled, reason, message)
+	SetFalse(conditions, generation, TypeReady, reason, message)
+	Remove(conditions, TypeReconciling)
+}
+
+// Phase summarizes the summary conditions into a phase for display. A Ready
+// resource scaled to zero by its hibernation schedule is Hibernated.
+func Phase(conditions []metav1.Condition) string {
+	switch {
+	case IsTrue(conditions, TypeStalled):
+		return PhaseFailed
+	case IsTrue(conditions, TypeDegraded):
+		return PhaseDegraded
+	case IsTrue(conditions, TypeReconciling):
+		return PhaseReconciling
+	case IsTrue(conditions, TypeReady) && IsTrue(conditions, TypeHibernated):
+		return PhaseHibernated
+	case IsTrue(conditions, TypeReady):
+		return PhaseReady
+	default:
+		return PhasePending
+	}
+}
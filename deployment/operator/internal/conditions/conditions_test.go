@@ -0,0 +1,58 @@
+package conditions
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLifecycle(t *testing.T) {
+	var conds []metav1.Condition
+
+	if got := Phase(conds); got != PhasePending {
+		t.Errorf("Phase() = %s, want %s", got, PhasePending)
+	}
+
+	MarkReconciling(&conds, 1, ReasonProgressing, "applying")
+	if got := Phase(conds); got != PhaseReconciling {
+		t.Errorf("Phase() = %s, want %s", got, PhaseReconciling)
+	}
+	if IsTrue(conds, TypeReady) {
+		t.Error("expected Ready to be False while reconciling for the first time")
+	}
+
+	MarkDegraded(&conds, 1, ReasonReconcileFailed, "boom")
+	if got := Phase(conds); got != PhaseDegraded {
+		t.Errorf("Phase() = %s, want %s", got, PhaseDegraded)
+	}
+
+	MarkReady(&conds, 1, ReasonReconciled, "done")
+	if got := Phase(conds); got != PhaseReady {
+		t.Errorf("Phase() = %s, want %s", got, PhaseReady)
+	}
+	SetTrue(&conds, 1, TypeHibernated, ReasonHibernationRequested, "hibernating")
+	if got := Phase(conds); got != PhaseHibernated {
+		t.Errorf("Phase() = %s, want %s", got, PhaseHibernated)
+	}
+	Remove(&conds, TypeHibernated)
+	for _, removed := range []string{TypeReconciling, TypeDegraded, TypeStalled} {
+		if meta.FindStatusCondition(conds, removed) != nil {
+			t.Errorf("expected %s to be removed once ready", removed)
+		}
+	}
+
+	// A new generation keeps Ready while reconciling
+	MarkReconciling(&conds, 2, ReasonProgressing, "applying")
+	if !IsTrue(conds, TypeReady) {
+		t.Error("expected Ready to stay True while reconciling a new generation")
+	}
+
+	MarkStalled(&conds, 2, ReasonValidationFailed, "invalid")
+	if got := Phase(conds); got != PhaseFailed {
+		t.Errorf("Phase() = %s, want %s", got, PhaseFailed)
+	}
+	if ready := meta.FindStatusCondition(conds, TypeReady); ready.Status != metav1.ConditionFalse || ready.ObservedGeneration != 2 {
+		t.Errorf("expected Ready=False at generation 2, got %+v", ready)
+	}
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultAdminPort = 9090
+	adminPortName    = "admin"
+	// adminEndpointLabel marks the Services exposing the admin port, so the
+	// ServiceMonitor never selects the public Services
+	adminEndpointLabel = "ragme.io/endpoint"
+)
+
+// adminServices are the services serving operational endpoints
+var adminServices = []string{"api", "mcp", "frontend"}
+
+// adminPort returns the port of the operational endpoints, or 0 when they
+// are served on the service port
+func adminPort(ragme *ragmev1.RAGme) int32 {
+	if !ragme.Spec.AdminPort.Enabled {
+		return 0
+	}
+	if ragme.Spec.AdminPort.Port != 0 {
+		return ragme.Spec.AdminPort.Port
+	}
+	return defaultAdminPort
+}
+
+func adminServiceName(ragme *ragmev1.RAGme, serviceName string) string {
+	return fmt.Sprintf("%s-%s-admin", ragme.Name, serviceName)
+}
+
+// operationalURL returns the in-cluster URL of an operational endpoint of a
+// service, on its admin Service when the admin port is enabled
+func operationalURL(ragme *ragmev1.RAGme, serviceName string, servicePort int32, path string) string {
+	return fmt.Sprintf("http://%s%s", operationalTarget(ragme, serviceName, servicePort), path)
+}
+
+// operationalTarget returns the host:port serving the operational endpoints of a service
+func operationalTarget(ragme *ragmev1.RAGme, serviceName string, servicePort int32) string {
+	if port := adminPort(ragme); port != 0 {
+		return fmt.Sprintf("%s.%s.svc:%d", adminServiceName(ragme, serviceName), ragme.Namespace, port)
+	}
+	return fmt.Sprintf("%s-%s.%s.svc:%d", ragme.Name, serviceName, ragme.Namespace, servicePort)
+}
+
+// applyAdminPort moves the health and metrics endpoints of a service to the
+// admin port and repoints its probes there
+func applyAdminPort(ragme *ragmev1.RAGme, serviceName string, template *corev1.PodTemplateSpec) {
+	port := adminPort(ragme)
+	container := &template.Spec.Containers[0]
+	if port == 0 || len(container.Ports) == 0 {
+		return
+	}
+
+	container.Ports = append(container.Ports, corev1.ContainerPort{Name: adminPortName, ContainerPort: port})
+	container.Env = append(container.Env, corev1.EnvVar{Name: "RAGME_ADMIN_PORT", Value: fmt.Sprint(port)})
+	for _, probe := range []*corev1.Probe{container.LivenessProbe, container.ReadinessProbe} {
+		if probe != nil && probe.HTTPGet != nil {
+			probe.HTTPGet.Port = intstr.FromString(adminPortName)
+		}
+	}
+	if scrapeAdminPort(ragme) {
+		template.Annotations = mergeStringMaps(template.Annotations, podScrapeAnnotations(ragme, port, "/metrics"))
+	}
+}
+
+// scrapeAdminPort reports whether the admin port is scraped through
+// prometheus.io annotations
+func scrapeAdminPort(ragme *ragmev1.RAGme) bool {
+	return adminPort(ragme) != 0 && ragme.Spec.AdminPort.ServiceMonitor && annotationScraping(ragme)
+}
+
+// createAdminService returns the ClusterIP Service exposing the admin port of
+// a service. It is never referenced by an Ingress.
+func createAdminService(ragme *ragmev1.RAGme, serviceName string) *corev1.Service {
+	selector := map[string]string{
+		"app":       "ragme",
+		"component": serviceName,
+		"instance":  ragme.Name,
+	}
+	labels := mergeStringMaps(map[string]string{adminEndpointLabel: adminPortName}, selector)
+	port := adminPort(ragme)
+	var annotations map[string]string
+	if scrapeAdminPort(ragme) {
+		annotations = serviceScrapeAnnotations(ragme, port, "/metrics")
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        adminServiceName(ragme, serviceName),
+			Namespace:   ragme.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{Name: adminPortName, Port: port, TargetPort: intstr.FromString(adminPortName)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
+
+// reconcileAdminServices creates the admin Services and their ServiceMonitor,
+// deleting them when the admin port is disabled
+func (r *RAGmeReconciler) reconcileAdminServices(ctx context.Context, ragme *ragmev1.RAGme) error {
+	for _, serviceName := range adminServices {
+		if adminPort(ragme) == 0 {
+			service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: adminServiceName(ragme, serviceName), Namespace: ragme.Namespace}}
+			if err := r.Delete(ctx, service); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			continue
+		}
+
+		service := createAdminService(ragme, serviceName)
+		if err := r.setOwner(ragme, service); err != nil {
+			return err
+		}
+		found := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, found)
+		if err != nil && errors.IsNotFound(err) {
+			if err := r.Create(ctx, service); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		annotationsChanged := syncScrapeAnnotations(found, service)
+		if annotationsChanged || !reflect.DeepEqual(found.Spec.Ports, service.Spec.Ports) {
+			found.Spec.Ports = service.Spec.Ports
+			if err := r.Update(ctx, found); err != nil {
+				return err
+			}
+		}
+	}
+
+	if adminPort(ragme) != 0 && ragme.Spec.AdminPort.ServiceMonitor && !annotationScraping(ragme) {
+		return r.applyServiceMonitor(ctx, ragme, createAdminServiceMonitor(ragme))
+	}
+	return r.deleteServiceMonitor(ctx, ragme, fmt.Sprintf("%s-services", ragme.Name))
+}
+
+// createAdminServiceMonitor returns the ServiceMonitor scraping the metrics of
+// the services on their admin Services
+func createAdminServiceMonitor(ragme *ragmev1.RAGme) *unstructured.Unstructured {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetAPIVersion("monitoring.coreos.com/v1")
+	serviceMonitor.SetKind("ServiceMonitor")
+	serviceMonitor.SetName(fmt.Sprintf("%s-services", ragme.Name))
+	serviceMonitor.SetNamespace(ragme.Namespace)
+	serviceMonitor.SetLabels(map[string]string{
+		"app":      "ragme",
+		"instance": ragme.Name,
+	})
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{"matchLabels": map[string]interface{}{
+			"app":              "ragme",
+			"instance":         ragme.Name,
+			adminEndpointLabel: adminPortName,
+		}},
+		"endpoints": []interface{}{map[string]interface{}{
+			"port": adminPortName,
+			"path": "/metrics",
+		}},
+	}
+	return serviceMonitor
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyAdminPort(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.AdminPort.Enabled = true
+
+	container := r.createRAGmeServiceDeployment(ragme, "api").Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 2 || container.Ports[1].Name != "admin" || container.Ports[1].ContainerPort != 9090 {
+		t.Fatalf("ports = %+v, want the admin port 9090", container.Ports)
+	}
+	if envValue(container.Env, "RAGME_ADMIN_PORT") != "9090" {
+		t.Errorf("RAGME_ADMIN_PORT = %q, want 9090", envValue(container.Env, "RAGME_ADMIN_PORT"))
+	}
+	if got := container.LivenessProbe.HTTPGet.Port.String(); got != "admin" {
+		t.Errorf("liveness probe port = %s, want admin", got)
+	}
+	if got := container.ReadinessProbe.HTTPGet.Port.String(); got != "admin" {
+		t.Errorf("readiness probe port = %s, want admin", got)
+	}
+
+	agent := r.createRAGmeServiceDeployment(ragme, "agent").Spec.Template.Spec.Containers[0]
+	if len(agent.Ports) != 0 || envValue(agent.Env, "RAGME_ADMIN_PORT") != "" {
+		t.Errorf("agent ports = %+v, want no admin port", agent.Ports)
+	}
+
+	ragme.Spec.AdminPort.Enabled = false
+	container = r.createRAGmeServiceDeployment(ragme, "api").Spec.Template.Spec.Containers[0]
+	if len(container.Ports) != 1 || container.LivenessProbe.HTTPGet.Port.IntValue() != 8021 {
+		t.Errorf("disabled admin port changed the container: %+v", container.Ports)
+	}
+}
+
+func TestCreateAdminService(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.AdminPort = ragmev1.RAGmeAdminPort{Enabled: true, Port: 9500}
+
+	service := createAdminService(ragme, "frontend")
+	if service.Name != "test-frontend-admin" || service.Labels["ragme.io/endpoint"] != "admin" {
+		t.Errorf("service = %s %v", service.Name, service.Labels)
+	}
+	if _, ok := service.Spec.Selector["ragme.io/endpoint"]; ok || service.Spec.Selector["component"] != "frontend" {
+		t.Errorf("selector = %v, want the frontend pods", service.Spec.Selector)
+	}
+	if port := service.Spec.Ports[0]; port.Port != 9500 || port.TargetPort.String() != "admin" {
+		t.Errorf("port = %+v, want 9500 to the admin port", port)
+	}
+
+	if got := operationalURL(ragme, "api", 8021, "/metrics"); got != "http://test-api-admin.ragme.svc:9500/metrics" {
+		t.Errorf("operationalURL() = %s", got)
+	}
+	ragme.Spec.AdminPort.Enabled = false
+	if got := operationalURL(ragme, "api", 8021, "/metrics"); got != "http://test-api.ragme.svc:8021/metrics" {
+		t.Errorf("operationalURL() without admin port = %s", got)
+	}
+}
+
+func TestValidateSpecAdminPort(t *testing.T) {
+	for port, wantErr := range map[int32]bool{0: false, 9500: false, 8021: true, 9102: true, 70000: true} {
+		ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+		ragme.Spec.AdminPort = ragmev1.RAGmeAdminPort{Enabled: true, Port: port}
+		if err := validateSpec(ragme); (err != nil) != wantErr {
+			t.Errorf("validateSpec() with port %d error = %v, wantErr %v", port, err, wantErr)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	// minAgentPollInterval keeps the agent from rescanning the shared volume in a busy loop
+	minAgentPollInterval = time.Second
+
+	backpressureReject = "reject"
+	backpressurePark   = "park"
+
+	// agentMetricsPort serves the ingestion queue counters and the pending
+	// recordings of the agent
+	agentMetricsPort = 9104
+
+	agentFilesQueuedMetric   = "ragme_agent_files_queued"
+	agentFilesRejectedMetric = "ragme_agent_files_rejected_total"
+	agentFilesParkedMetric   = "ragme_agent_files_parked_total"
+)
+
+// applyAgentProcessing renders the ingestion throughput settings into the agent environment
+func applyAgentProcessing(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "agent" {
+		return
+	}
+
+	agent := ragme.Spec.Agent
+	var env []corev1.EnvVar
+	if agent.Concurrency > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_CONCURRENCY", Value: strconv.Itoa(int(agent.Concurrency))})
+	}
+	if agent.BatchSize > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_BATCH_SIZE", Value: strconv.Itoa(int(agent.BatchSize))})
+	}
+	if interval, err := time.ParseDuration(agent.PollInterval); err == nil && agent.PollInterval != "" {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_POLL_INTERVAL_SECONDS", Value: strconv.Itoa(int(interval.Seconds()))})
+	}
+	if len(agent.FileTypes) > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_FILE_TYPES", Value: strings.Join(normalizeFileTypes(agent.FileTypes), ",")})
+	}
+	if len(agent.ExcludePatterns) > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_EXCLUDE_PATTERNS", Value: strings.Join(agent.ExcludePatterns, ",")})
+	}
+	if skipped := routedFileTypes(ragme); len(skipped) > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_SKIP_FILE_TYPES", Value: strings.Join(skipped, ",")})
+	}
+	if agent.Coordination.Enabled {
+		// The agents claim the files under their pod name
+		env = append(env,
+			corev1.EnvVar{Name: "RAGME_AGENT_COORDINATION_ENABLED", Value: "true"},
+			corev1.EnvVar{Name: "RAGME_AGENT_ID", ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			}},
+		)
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+	applyAgentBackpressure(ragme, podSpec)
+	if agentMetricsEnabled(ragme) {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_AGENT_METRICS_PORT", Value: strconv.Itoa(agentMetricsPort),
+		})
+		podSpec.Containers[0].Ports = append(podSpec.Containers[0].Ports, corev1.ContainerPort{
+			Name: "agent-metrics", ContainerPort: agentMetricsPort,
+		})
+	}
+}
+
+// routedFileTypes returns the extensions the agent leaves to the image and
+// media workers
+func routedFileTypes(ragme *ragmev1.RAGme) []string {
+	var routed []string
+	if ragme.Spec.Processing.Images.Enabled {
+		routed = append(routed, imageFileTypes(ragme)...)
+	}
+	if ragme.Spec.Processing.Media.Enabled {
+		routed = append(routed, mediaFileTypes(ragme)...)
+	}
+	return routed
+}
+
+// agentMetricsEnabled reports whether the agent serves its metrics port,
+// read by the operator for the queue counters and the pending recordings
+func agentMetricsEnabled(ragme *ragmev1.RAGme) bool {
+	return backpressureEnabled(ragme) || ragme.Spec.Processing.Media.Enabled
+}
+
+// backpressureEnabled reports whether the agent bounds its ingestion queue
+func backpressureEnabled(ragme *ragmev1.RAGme) bool {
+	backpressure := ragme.Spec.Agent.Backpressure
+	return backpressure.MaxQueuedFiles > 0 || backpressure.MaxFileSize != ""
+}
+
+// backpressureBehavior returns what the agent does with the files it turns away
+func backpressureBehavior(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.Agent.Backpressure.Behavior != "" {
+		return ragme.Spec.Agent.Backpressure.Behavior
+	}
+	return backpressureReject
+}
+
+// applyAgentBackpressure renders the queue bounds into the agent environment.
+// The files turned away are moved out of the watch directory, into .rejected
+// or .parked, and the queue counters are served on the agent metrics port.
+func applyAgentBackpressure(ragme *ragmev1.RAGme, podSpec *corev1.PodSpec) {
+	if !backpressureEnabled(ragme) {
+		return
+	}
+	backpressure := ragme.Spec.Agent.Backpressure
+	behavior := backpressureBehavior(ragme)
+	overflowDir := "/app/watch_directory/.rejected"
+	if behavior == backpressurePark {
+		overflowDir = "/app/watch_directory/.parked"
+	}
+	env := []corev1.EnvVar{
+		{Name: "RAGME_AGENT_OVERFLOW_BEHAVIOR", Value: behavior},
+		{Name: "RAGME_AGENT_OVERFLOW_DIR", Value: overflowDir},
+	}
+	if backpressure.MaxQueuedFiles > 0 {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_MAX_QUEUED_FILES", Value: strconv.Itoa(int(backpressure.MaxQueuedFiles))})
+	}
+	if size, err := resource.ParseQuantity(backpressure.MaxFileSize); err == nil {
+		env = append(env, corev1.EnvVar{Name: "RAGME_AGENT_MAX_FILE_SIZE_BYTES", Value: strconv.FormatInt(size.Value(), 10)})
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+}
+
+// checkAgentQueue reads the ingestion queue counters from the agent and
+// records them in status, flipping IngestionThrottled while the queue is full
+func (r *RAGmeReconciler) checkAgentQueue(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !backpressureEnabled(ragme) {
+		ragme.Status.Agent = ragmev1.RAGmeAgentStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeIngestionThrottled)
+		return nil
+	}
+	// The agent is stopped while the instance hibernates or is under maintenance
+	if maintenanceReplicas(ragme, "agent", 1) == 0 || ragme.Status.Hibernation.Hibernated {
+		return nil
+	}
+
+	samples, err := r.scrapeAgentPod(ctx, ragme, agentMetricsPort,
+		agentFilesQueuedMetric, agentFilesRejectedMetric, agentFilesParkedMetric)
+	if err != nil {
+		return fmt.Errorf("agent queue counters: %w", err)
+	}
+	setAgentQueueStatus(ragme, samples)
+	return nil
+}
+
+// setAgentQueueStatus records the queue counters reported by the agent and
+// sets the IngestionThrottled condition
+func setAgentQueueStatus(ragme *ragmev1.RAGme, samples []promSample) {
+	status := ragmev1.RAGmeAgentStatus{
+		FilesQueued:   int64(sumSamples(samples, agentFilesQueuedMetric, nil)),
+		FilesRejected: int64(sumSamples(samples, agentFilesRejectedMetric, nil)),
+		FilesParked:   int64(sumSamples(samples, agentFilesParkedMetric, nil)),
+	}
+	ragme.Status.Agent = status
+
+	limit := int64(ragme.Spec.Agent.Backpressure.MaxQueuedFiles)
+	if limit > 0 && status.FilesQueued >= limit {
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeIngestionThrottled, conditions.ReasonQueueFull,
+			fmt.Sprintf("%d files queued, new files are %sed until the queue drains below %d", status.FilesQueued, backpressureBehavior(ragme), limit))
+		return
+	}
+	conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeIngestionThrottled, conditions.ReasonQueueAvailable,
+		fmt.Sprintf("%d files queued, %d rejected, %d parked", status.FilesQueued, status.FilesRejected, status.FilesParked))
+}
+
+// normalizeFileTypes lower-cases the file extensions and strips their leading dot
+func normalizeFileTypes(fileTypes []string) []string {
+	normalized := make([]string, 0, len(fileTypes))
+	for _, fileType := range fileTypes {
+		normalized = append(normalized, strings.ToLower(strings.TrimPrefix(fileType, ".")))
+	}
+	return normalized
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestApplyAgentBackpressure(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	applyAgentProcessing(ragme, "agent", podSpec)
+	if len(podSpec.Containers[0].Env) != 0 || len(podSpec.Containers[0].Ports) != 0 {
+		t.Errorf("agent = %+v, want it untouched without backpressure", podSpec.Containers[0])
+	}
+
+	ragme.Spec.Agent.Backpressure = ragmev1.RAGmeAgentBackpressure{MaxQueuedFiles: 500, MaxFileSize: "1Gi", Behavior: "park"}
+	applyAgentProcessing(ragme, "agent", podSpec)
+	env := podSpec.Containers[0].Env
+	for name, want := range map[string]string{
+		"RAGME_AGENT_MAX_QUEUED_FILES":    "500",
+		"RAGME_AGENT_MAX_FILE_SIZE_BYTES": "1073741824",
+		"RAGME_AGENT_OVERFLOW_BEHAVIOR":   "park",
+		"RAGME_AGENT_OVERFLOW_DIR":        "/app/watch_directory/.parked",
+		"RAGME_AGENT_METRICS_PORT":        "9104",
+	} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if ports := podSpec.Containers[0].Ports; len(ports) != 1 || ports[0].ContainerPort != agentMetricsPort {
+		t.Errorf("ports = %+v, want the agent metrics port", ports)
+	}
+}
+
+func TestApplyAgentCoordination(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Agent.Coordination.Enabled = true
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	applyAgentProcessing(ragme, "agent", podSpec)
+
+	env := podSpec.Containers[0].Env
+	if envValue(env, "RAGME_AGENT_COORDINATION_ENABLED") != "true" {
+		t.Errorf("env = %+v, want coordination enabled", env)
+	}
+	for _, e := range env {
+		if e.Name == "RAGME_AGENT_ID" && (e.ValueFrom == nil || e.ValueFrom.FieldRef.FieldPath != "metadata.name") {
+			t.Errorf("RAGME_AGENT_ID = %+v, want the pod name", e)
+		}
+	}
+}
+
+func TestSetAgentQueueStatus(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Agent.Backpressure.MaxQueuedFiles = 100
+	samples := []promSample{
+		{Name: agentFilesQueuedMetric, Value: 100},
+		{Name: agentFilesRejectedMetric, Value: 7},
+	}
+	setAgentQueueStatus(ragme, samples)
+	if status := ragme.Status.Agent; status.FilesQueued != 100 || status.FilesRejected != 7 {
+		t.Errorf("status = %+v, want the reported counters", status)
+	}
+	if !conditions.IsTrue(ragme.Status.Conditions, conditions.TypeIngestionThrottled) {
+		t.Error("IngestionThrottled not set with a full queue")
+	}
+	if message := ragme.Status.Conditions[0].Message; !strings.Contains(message, "rejected until") {
+		t.Errorf("message = %q, want the files rejected", message)
+	}
+
+	samples[0].Value = 10
+	setAgentQueueStatus(ragme, samples)
+	if conditions.IsTrue(ragme.Status.Conditions, conditions.TypeIngestionThrottled) {
+		t.Error("IngestionThrottled still set once the queue drained")
+	}
+}
+
+func TestValidateSpecBackpressure(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Agent.Backpressure = ragmev1.RAGmeAgentBackpressure{MaxQueuedFiles: -1, MaxFileSize: "big", Behavior: "drop"}
+	err := validateSpec(ragme)
+	if err == nil {
+		t.Fatal("validateSpec() accepted an invalid backpressure")
+	}
+	for _, field := range []string{"maxQueuedFiles", "maxFileSize", "behavior"} {
+		if !strings.Contains(err.Error(), "agent.backpressure."+field) {
+			t.Errorf("validateSpec() error = %v, want %s reported", err, field)
+		}
+	}
+	ragme.Spec.Agent.Backpressure = ragmev1.RAGmeAgentBackpressure{MaxQueuedFiles: 1000, MaxFileSize: "500Mi", Behavior: "reject"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=alertmanagerconfigs,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	upgradeAlertName = "RAGmeUpgradeInProgress"
+	// nullReceiver swallows the upgrade alert, which only inhibits
+	nullReceiver = "null"
+
+	defaultSilenceDuration = 2 * time.Hour
+	// upgradeAlertLifetime is refreshed on every reconcile while upgrading
+	upgradeAlertLifetime = 15 * time.Minute
+)
+
+// alertRoutingName returns the name of the AlertmanagerConfig of an instance
+func alertRoutingName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-alerts", ragme.Name)
+}
+
+// alertReceiver returns the receiver of the alerts without a severity mapping
+func alertReceiver(routing ragmev1.RAGmeAlertRouting) string {
+	if routing.Receiver != "" {
+		return routing.Receiver
+	}
+	if len(routing.Receivers) > 0 {
+		return routing.Receivers[0].Name
+	}
+	return ""
+}
+
+// createAlertmanagerConfig returns the AlertmanagerConfig routing the alerts
+// of the namespace. The Prometheus Operator restricts its routes and inhibit
+// rules to the namespace of the resource.
+func createAlertmanagerConfig(ragme *ragmev1.RAGme) *unstructured.Unstructured {
+	routing := ragme.Spec.Monitoring.AlertRouting
+
+	var receivers []interface{}
+	for _, receiver := range routing.Receivers {
+		rendered := map[string]interface{}{"name": receiver.Name}
+		if ref := receiver.WebhookURLSecretRef; ref != nil {
+			rendered["webhookConfigs"] = []interface{}{map[string]interface{}{
+				"urlSecret": map[string]interface{}{"name": ref.Name, "key": ref.Key},
+			}}
+		}
+		if ref := receiver.SlackAPIURLSecretRef; ref != nil {
+			slack := map[string]interface{}{
+				"apiURL": map[string]interface{}{"name": ref.Name, "key": ref.Key},
+			}
+			if receiver.SlackChannel != "" {
+				slack["channel"] = receiver.SlackChannel
+			}
+			rendered["slackConfigs"] = []interface{}{slack}
+		}
+		if ref := receiver.PagerDutyRoutingKeySecretRef; ref != nil {
+			rendered["pagerdutyConfigs"] = []interface{}{map[string]interface{}{
+				"routingKey": map[string]interface{}{"name": ref.Name, "key": ref.Key},
+			}}
+		}
+		receivers = append(receivers, rendered)
+	}
+
+	var routes []interface{}
+	if routing.InhibitDuringUpgrades {
+		receivers = append(receivers, map[string]interface{}{"name": nullReceiver})
+		routes = append(routes, map[string]interface{}{
+			"receiver": nullReceiver,
+			"matchers": []interface{}{matcher("alertname", "=", upgradeAlertName)},
+		})
+	}
+	severities := make([]string, 0, len(routing.SeverityReceivers))
+	for severity := range routing.SeverityReceivers {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	for _, severity := range severities {
+		routes = append(routes, map[string]interface{}{
+			"receiver": routing.SeverityReceivers[severity],
+			"matchers": []interface{}{matcher("severity", "=", severity)},
+		})
+	}
+
+	groupBy := routing.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = []string{"alertname"}
+	}
+	route := map[string]interface{}{
+		"receiver": alertReceiver(routing),
+		"groupBy":  toInterfaceSlice(groupBy),
+	}
+	if len(routes) > 0 {
+		route["routes"] = routes
+	}
+	spec := map[string]interface{}{
+		"route":     route,
+		"receivers": receivers,
+	}
+	if routing.InhibitDuringUpgrades {
+		spec["inhibitRules"] = []interface{}{map[string]interface{}{
+			"sourceMatch": []interface{}{matcher("alertname", "=", upgradeAlertName)},
+			"targetMatch": []interface{}{matcher("alertname", "!=", upgradeAlertName)},
+			"equal":       []interface{}{"namespace"},
+		}}
+	}
+
+	config := &unstructured.Unstructured{}
+	config.SetAPIVersion("monitoring.coreos.com/v1alpha1")
+	config.SetKind("AlertmanagerConfig")
+	config.SetName(alertRoutingName(ragme))
+	config.SetNamespace(ragme.Namespace)
+	config.SetLabels(map[string]string{
+		"app":      "ragme",
+		"instance": ragme.Name,
+	})
+	config.Object["spec"] = spec
+	return config
+}
+
+// matcher renders an AlertmanagerConfig matcher
+func matcher(name, matchType, value string) map[string]interface{} {
+	return map[string]interface{}{"name": name, "matchType": matchType, "value": value}
+}
+
+func toInterfaceSlice(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, value := range values {
+		out[i] = value
+	}
+	return out
+}
+
+// reconcileAlertRouting creates or updates the AlertmanagerConfig, deleting it
+// when alert routing is disabled. It is skipped when the AlertmanagerConfig
+// CRD is not installed.
+func (r *RAGmeReconciler) reconcileAlertRouting(ctx context.Context, ragme *ragmev1.RAGme) error {
+	config := createAlertmanagerConfig(ragme)
+	if !ragme.Spec.Monitoring.AlertRouting.Enabled {
+		if err := r.Delete(ctx, config); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+		return nil
+	}
+	if err := r.setOwner(ragme, config); err != nil {
+		return err
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(config.GroupVersionKind())
+	err := r.Get(ctx, types.NamespacedName{Name: config.GetName(), Namespace: config.GetNamespace()}, found)
+	if meta.IsNoMatchError(err) {
+		log.FromContext(ctx).Info("AlertmanagerConfig CRD not installed, skipping alert routing")
+		return nil
+	}
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, config)
+	} else if err != nil {
+		return err
+	}
+
+	found.Object["spec"] = config.Object["spec"]
+	return r.Update(ctx, found)
+}
+
+// reconcileUpgradeAlerting mutes the alerts of the namespace while an upgrade
+// rolls out: the RAGmeUpgradeInProgress alert inhibits them and a silence
+// covers the receivers outside the AlertmanagerConfig. Both are lifted once
+// the upgrade completes. Failures never hold back the upgrade.
+func (r *RAGmeReconciler) reconcileUpgradeAlerting(ctx context.Context, ragme *ragmev1.RAGme) {
+	routing := ragme.Spec.Monitoring.AlertRouting
+	status := &ragme.Status.Alerting
+	logger := log.FromContext(ctx)
+	_, upgrading := weaviateUpgradeInProgress(ragme)
+	upgrading = upgrading && routing.Enabled && routing.AlertmanagerURL != ""
+	httpClient := defaultHTTPClient(r.HTTPClient)
+	baseURL := strings.TrimSuffix(routing.AlertmanagerURL, "/")
+	now := r.now()
+
+	switch {
+	case upgrading && routing.InhibitDuringUpgrades:
+		if err := postUpgradeAlert(ctx, httpClient, baseURL, ragme, now.Add(upgradeAlertLifetime)); err != nil {
+			logger.Error(err, "Failed to send the upgrade alert")
+		} else {
+			status.UpgradeAlertFiring = true
+		}
+	case status.UpgradeAlertFiring:
+		// Resolve the alert rather than waiting for it to expire
+		if baseURL == "" {
+			status.UpgradeAlertFiring = false
+		} else if err := postUpgradeAlert(ctx, httpClient, baseURL, ragme, now); err != nil {
+			logger.Error(err, "Failed to resolve the upgrade alert")
+		} else {
+			status.UpgradeAlertFiring = false
+		}
+	}
+
+	switch {
+	case upgrading && routing.SilenceUpgrades && status.SilenceID == "":
+		duration := defaultSilenceDuration
+		if d, err := time.ParseDuration(routing.SilenceDuration); err == nil {
+			duration = d
+		}
+		id, err := createUpgradeSilence(ctx, httpClient, baseURL, ragme, now, now.Add(duration))
+		if err != nil {
+			logger.Error(err, "Failed to silence the alerts for the upgrade")
+			return
+		}
+		status.SilenceID = id
+		status.SilencedUntil = &metav1.Time{Time: now.Add(duration)}
+	case !upgrading && status.SilenceID != "":
+		if baseURL != "" {
+			url := fmt.Sprintf("%s/api/v2/silence/%s", baseURL, status.SilenceID)
+			// An expired or unknown silence needs no cleanup
+			if code, err := doJSON(ctx, httpClient, http.MethodDelete, url, "", nil, nil); err != nil && code != http.StatusNotFound {
+				logger.Error(err, "Failed to expire the upgrade silence", "silence", status.SilenceID)
+				return
+			}
+		}
+		status.SilenceID = ""
+		status.SilencedUntil = nil
+	}
+}
+
+// postUpgradeAlert sends the RAGmeUpgradeInProgress alert, resolved when
+// endsAt is not in the future
+func postUpgradeAlert(ctx context.Context, httpClient *http.Client, baseURL string, ragme *ragmev1.RAGme, endsAt time.Time) error {
+	alert := map[string]interface{}{
+		"labels": map[string]string{
+			"alertname": upgradeAlertName,
+			"namespace": ragme.Namespace,
+			"instance":  ragme.Name,
+			"severity":  "none",
+		},
+		"annotations": map[string]string{
+			"summary": fmt.Sprintf("RAGme %s/%s is being upgraded", ragme.Namespace, ragme.Name),
+		},
+		"endsAt": endsAt.UTC().Format(time.RFC3339),
+	}
+	_, err := doJSON(ctx, httpClient, http.MethodPost, baseURL+"/api/v2/alerts", "", []interface{}{alert}, nil)
+	return err
+}
+
+// createUpgradeSilence silences the alerts of the namespace and returns the
+// silence ID
+func createUpgradeSilence(ctx context.Context, httpClient *http.Client, baseURL string, ragme *ragmev1.RAGme, startsAt, endsAt time.Time) (string, error) {
+	silence := map[string]interface{}{
+		"matchers": []interface{}{map[string]interface{}{
+			"name": "namespace", "value": ragme.Namespace, "isRegex": false, "isEqual": true,
+		}},
+		"startsAt":  startsAt.UTC().Format(time.RFC3339),
+		"endsAt":    endsAt.UTC().Format(time.RFC3339),
+		"createdBy": "ragme-operator",
+		"comment":   fmt.Sprintf("Planned upgrade of RAGme %s/%s", ragme.Namespace, ragme.Name),
+	}
+	created := struct {
+		SilenceID string `json:"silenceID"`
+	}{}
+	if _, err := doJSON(ctx, httpClient, http.MethodPost, baseURL+"/api/v2/silences", "", silence, &created); err != nil {
+		return "", err
+	}
+	return created.SilenceID, nil
+}
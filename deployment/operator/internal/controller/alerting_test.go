@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func alertRoutingRAGme(alertmanagerURL string) *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Monitoring.AlertRouting = ragmev1.RAGmeAlertRouting{
+		Enabled: true,
+		Receivers: []ragmev1.RAGmeAlertReceiver{
+			{Name: "slack", SlackAPIURLSecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "alerting"}, Key: "slack-url"}, SlackChannel: "#ragme"},
+			{Name: "pagerduty", PagerDutyRoutingKeySecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "alerting"}, Key: "routing-key"}},
+		},
+		SeverityReceivers:     map[string]string{"critical": "pagerduty"},
+		AlertmanagerURL:       alertmanagerURL,
+		InhibitDuringUpgrades: true,
+		SilenceUpgrades:       true,
+	}
+	return ragme
+}
+
+func TestCreateAlertmanagerConfig(t *testing.T) {
+	config := createAlertmanagerConfig(alertRoutingRAGme("http://alertmanager:9093"))
+
+	if receiver, _, _ := unstructured.NestedString(config.Object, "spec", "route", "receiver"); receiver != "slack" {
+		t.Errorf("default receiver = %q, want the first receiver", receiver)
+	}
+	routes, _, _ := unstructured.NestedSlice(config.Object, "spec", "route", "routes")
+	if len(routes) != 2 {
+		t.Fatalf("routes = %+v, want the upgrade alert and the critical route", routes)
+	}
+	if receiver := routes[0].(map[string]interface{})["receiver"]; receiver != nullReceiver {
+		t.Errorf("upgrade alert routed to %v, want the null receiver", receiver)
+	}
+	if receiver := routes[1].(map[string]interface{})["receiver"]; receiver != "pagerduty" {
+		t.Errorf("critical alerts routed to %v, want pagerduty", receiver)
+	}
+	receivers, _, _ := unstructured.NestedSlice(config.Object, "spec", "receivers")
+	if len(receivers) != 3 {
+		t.Errorf("receivers = %+v, want slack, pagerduty and null", receivers)
+	}
+	inhibitRules, _, _ := unstructured.NestedSlice(config.Object, "spec", "inhibitRules")
+	if len(inhibitRules) != 1 {
+		t.Errorf("inhibit rules = %+v, want the upgrade inhibition", inhibitRules)
+	}
+
+	ragme := alertRoutingRAGme("")
+	ragme.Spec.Monitoring.AlertRouting.InhibitDuringUpgrades = false
+	if rules, found, _ := unstructured.NestedSlice(createAlertmanagerConfig(ragme).Object, "spec", "inhibitRules"); found {
+		t.Errorf("inhibit rules = %+v without inhibitDuringUpgrades", rules)
+	}
+}
+
+func TestReconcileUpgradeAlerting(t *testing.T) {
+	var alerts []map[string]interface{}
+	silences, expired := 0, ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/api/v2/alerts":
+			posted := []map[string]interface{}{}
+			_ = json.NewDecoder(req.Body).Decode(&posted)
+			alerts = append(alerts, posted...)
+		case req.Method == http.MethodPost && req.URL.Path == "/api/v2/silences":
+			silences++
+			_, _ = w.Write([]byte(`{"silenceID":"abc"}`))
+		case req.Method == http.MethodDelete:
+			expired = req.URL.Path
+		}
+	}))
+	defer server.Close()
+
+	r := &RAGmeReconciler{HTTPClient: server.Client()}
+	ragme := alertRoutingRAGme(server.URL)
+	ragme.Status.Weaviate = ragmev1.RAGmeWeaviateStatus{Version: "1.24.0", UpgradeTo: "1.25.0", UpgradePhase: weaviatePhaseRolling}
+
+	r.reconcileUpgradeAlerting(context.Background(), ragme)
+	r.reconcileUpgradeAlerting(context.Background(), ragme)
+	if silences != 1 || ragme.Status.Alerting.SilenceID != "abc" || ragme.Status.Alerting.SilencedUntil == nil {
+		t.Errorf("silences = %d, status = %+v, want one silence recorded", silences, ragme.Status.Alerting)
+	}
+	if len(alerts) != 2 || !ragme.Status.Alerting.UpgradeAlertFiring {
+		t.Errorf("alerts = %d, want the upgrade alert refreshed on every reconcile", len(alerts))
+	}
+
+	// The upgrade completed
+	ragme.Status.Weaviate = ragmev1.RAGmeWeaviateStatus{Version: "1.25.0"}
+	r.reconcileUpgradeAlerting(context.Background(), ragme)
+	if expired != "/api/v2/silence/abc" || ragme.Status.Alerting.SilenceID != "" {
+		t.Errorf("expired = %q, status = %+v, want the silence expired", expired, ragme.Status.Alerting)
+	}
+	if len(alerts) != 3 || ragme.Status.Alerting.UpgradeAlertFiring || alerts[2]["labels"].(map[string]interface{})["alertname"] != upgradeAlertName {
+		t.Errorf("alerts = %+v, want the upgrade alert resolved", alerts)
+	}
+}
+
+func TestValidateSpecAlertRouting(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*ragmev1.RAGme)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(*ragmev1.RAGme) {}},
+		{name: "no receivers", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.AlertRouting.Receivers = nil }, wantErr: true},
+		{name: "reserved name", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.AlertRouting.Receivers[0].Name = "null" }, wantErr: true},
+		{name: "two integrations", mutate: func(r *ragmev1.RAGme) {
+			r.Spec.Monitoring.AlertRouting.Receivers[0].WebhookURLSecretRef = &corev1.SecretKeySelector{Key: "url"}
+		}, wantErr: true},
+		{name: "unknown severity receiver", mutate: func(r *ragmev1.RAGme) {
+			r.Spec.Monitoring.AlertRouting.SeverityReceivers["warning"] = "email"
+		}, wantErr: true},
+		{name: "muting without Alertmanager", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.AlertRouting.AlertmanagerURL = "" }, wantErr: true},
+		{name: "bad silence duration", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.AlertRouting.SilenceDuration = "1 hour" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := alertRoutingRAGme("http://alertmanager-operated.monitoring.svc:9093")
+			tt.mutate(ragme)
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
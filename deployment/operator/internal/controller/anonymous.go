@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// anonymousAPIPaths are the read-only api endpoints reachable without authentication
+var anonymousAPIPaths = []string{
+	"/query",
+	"/list-documents",
+	"/list-content",
+	"/count-documents",
+	"/document",
+	"/config",
+	"/auth/providers",
+	"/auth/status",
+}
+
+// applyAnonymousAccess configures the api and frontend to serve anonymous users
+func applyAnonymousAccess(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	anonymous := ragme.Spec.Authentication.Anonymous
+	if !anonymous.Enabled || (serviceName != "api" && serviceName != "frontend") {
+		return
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, []corev1.EnvVar{
+		{Name: "RAGME_ANONYMOUS_ACCESS", Value: "true"},
+		{Name: "RAGME_ANONYMOUS_ROLE", Value: anonymous.Role},
+	}...)
+}
+
+// reconcilePublicIngress exposes the frontend and the query-only api endpoints
+// on the Ingress host when anonymous access is enabled, and removes the public
+// Ingress otherwise. Upload and admin endpoints are never routed.
+func (r *RAGmeReconciler) reconcilePublicIngress(ctx context.Context, ragme *ragmev1.RAGme) error {
+	name := fmt.Sprintf("%s-public", ragme.Name)
+	ingressConfig := ragme.Spec.ExternalAccess.Ingress
+
+	found := &networkingv1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !ragme.Spec.Authentication.Anonymous.Enabled || !ingressConfig.Enabled || ingressConfig.Host == "" {
+		if exists {
+			return r.Delete(ctx, found)
+		}
+		return nil
+	}
+
+	ingress := createPublicIngress(ragme, name)
+	if err := r.setOwner(ragme, ingress); err != nil {
+		return err
+	}
+	if !exists {
+		return r.Create(ctx, ingress)
+	}
+
+	if !reflect.DeepEqual(found.Spec, ingress.Spec) || !reflect.DeepEqual(found.Annotations, ingress.Annotations) {
+		found.Spec = ingress.Spec
+		found.Annotations = ingress.Annotations
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+func createPublicIngress(ragme *ragmev1.RAGme, name string) *networkingv1.Ingress {
+	ingressConfig := ragme.Spec.ExternalAccess.Ingress
+	prefix := networkingv1.PathTypePrefix
+	backend := func(serviceName string, port int32) networkingv1.IngressBackend {
+		return networkingv1.IngressBackend{
+			Service: &networkingv1.IngressServiceBackend{
+				Name: fmt.Sprintf("%s-%s", ragme.Name, serviceName),
+				Port: networkingv1.ServiceBackendPort{Number: port},
+			},
+		}
+	}
+
+	paths := []networkingv1.HTTPIngressPath{}
+	for _, path := range anonymousAPIPaths {
+		paths = append(paths, networkingv1.HTTPIngressPath{
+			Path:     "/api" + path,
+			PathType: &prefix,
+			Backend:  backend(apiQueryService(ragme), 8021),
+		})
+	}
+	paths = append(paths, networkingv1.HTTPIngressPath{
+		Path:     "/",
+		PathType: &prefix,
+		Backend:  backend("frontend", 8020),
+	})
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+			Annotations: ingressAnnotations(ragme),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: className(ragme.Status.Classes.IngressClassName),
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: ingressConfig.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+					},
+				},
+			},
+		},
+	}
+	if ingressConfig.TLSEnabled {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{Hosts: []string{ingressConfig.Host}, SecretName: ingressTLSSecretName(ragme)},
+		}
+	}
+	return ingress
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	roleAdmin  = "admin"
+	roleEditor = "editor"
+	roleViewer = "viewer"
+
+	authorizationConfigKey       = "roles.json"
+	authorizationConfigMountPath = "/app/config/authorization"
+)
+
+// applicationRoles are the roles of the application users, highest first
+var applicationRoles = []string{roleAdmin, roleEditor, roleViewer}
+
+// authorizationConfig is the role mapping rendered for the api
+type authorizationConfig struct {
+	DefaultRole string                     `json:"defaultRole"`
+	Roles       []ragmev1.RAGmeRoleMapping `json:"roles"`
+}
+
+// emailPattern matches the email addresses accepted in the authorization settings
+var emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+
+// validBootstrapAdmin reports whether an entry is an email or an OAuth subject
+// of a supported provider written as <provider>:<subject>
+func validBootstrapAdmin(entry string) bool {
+	if emailPattern.MatchString(entry) {
+		return true
+	}
+	provider, subject, found := strings.Cut(entry, ":")
+	if !found || subject == "" || strings.ContainsAny(subject, " ,") {
+		return false
+	}
+	_, known := oauthProviders(&ragmev1.RAGme{})[provider]
+	return known
+}
+
+// applyBootstrapAdmins passes the users granted admin rights on their first
+// login to the api, which owns the role assignments
+func applyBootstrapAdmins(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	admins := ragme.Spec.Authentication.BootstrapAdmins
+	if len(admins) == 0 || serviceName != "api" {
+		return
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_BOOTSTRAP_ADMINS", Value: strings.Join(admins, ","),
+	})
+}
+
+// validApplicationRole reports whether role is one of the application roles
+func validApplicationRole(role string) bool {
+	for _, known := range applicationRoles {
+		if role == known {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizationConfigMapName returns the name of the ConfigMap holding the role mapping
+func authorizationConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-authorization", ragme.Name)
+}
+
+// renderAuthorizationConfig returns the role mapping of the instance. Email
+// domains are lowercased since the api compares them with the user emails.
+func renderAuthorizationConfig(ragme *ragmev1.RAGme) authorizationConfig {
+	config := authorizationConfig{
+		DefaultRole: ragme.Spec.Authorization.DefaultRole,
+		Roles:       []ragmev1.RAGmeRoleMapping{},
+	}
+	for _, mapping := range ragme.Spec.Authorization.Roles {
+		mapping = *mapping.DeepCopy()
+		for i, domain := range mapping.EmailDomains {
+			mapping.EmailDomains[i] = strings.ToLower(domain)
+		}
+		config.Roles = append(config.Roles, mapping)
+	}
+	return config
+}
+
+// reconcileAuthorizationConfig renders the role mapping into the ConfigMap
+// mounted by the api
+func (r *RAGmeReconciler) reconcileAuthorizationConfig(ctx context.Context, ragme *ragmev1.RAGme) error {
+	data, err := json.MarshalIndent(renderAuthorizationConfig(ragme), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      authorizationConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{authorizationConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[authorizationConfigKey] != configMap.Data[authorizationConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyAuthorization mounts the role mapping into the api, which resolves the
+// role of each user at login
+func applyAuthorization(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "api" {
+		return
+	}
+
+	mountVolume(podSpec, corev1.Volume{
+		Name: "authorization",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: authorizationConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, authorizationConfigMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_AUTHORIZATION_CONFIG", Value: authorizationConfigMountPath + "/" + authorizationConfigKey,
+	})
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestValidBootstrapAdmin(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  bool
+	}{
+		{entry: "alice@example.com", want: true},
+		{entry: "github:1234567", want: true},
+		{entry: "google:108234", want: true},
+		{entry: "gitlab:42", want: false},
+		{entry: "github:", want: false},
+		{entry: "alice", want: false},
+		{entry: "alice@example", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			if got := validBootstrapAdmin(tt.entry); got != tt.want {
+				t.Errorf("validBootstrapAdmin(%q) = %v, want %v", tt.entry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateRoleMappings(t *testing.T) {
+	tests := []struct {
+		name    string
+		roles   []ragmev1.RAGmeRoleMapping
+		wantErr bool
+	}{
+		{name: "no mappings"},
+		{name: "valid mappings", roles: []ragmev1.RAGmeRoleMapping{
+			{Role: "admin", Groups: []string{"ragme-admins"}},
+			{Role: "editor", EmailDomains: []string{"Example.com"}},
+		}},
+		{name: "unknown role", roles: []ragmev1.RAGmeRoleMapping{{Role: "owner", Groups: []string{"owners"}}}, wantErr: true},
+		{name: "no group or domain", roles: []ragmev1.RAGmeRoleMapping{{Role: "viewer"}}, wantErr: true},
+		{name: "group with comma", roles: []ragmev1.RAGmeRoleMapping{{Role: "viewer", Groups: []string{"a,b"}}}, wantErr: true},
+		{name: "invalid domain", roles: []ragmev1.RAGmeRoleMapping{{Role: "viewer", EmailDomains: []string{"@example.com"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Authorization.Roles = tt.roles
+			_, err := (&RAGmeValidator{}).ValidateCreate(context.Background(), ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyAuthorization(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}
+	ragme.Spec.Authorization.Roles = []ragmev1.RAGmeRoleMapping{{Role: "editor", EmailDomains: []string{"Example.com"}}}
+	(&RAGmeReconciler{}).setDefaults(ragme)
+
+	config := renderAuthorizationConfig(ragme)
+	if config.DefaultRole != roleViewer || config.Roles[0].EmailDomains[0] != "example.com" {
+		t.Errorf("renderAuthorizationConfig() = %+v, want the viewer default and lowercased domains", config)
+	}
+	if ragme.Spec.Authorization.Roles[0].EmailDomains[0] != "Example.com" {
+		t.Error("expected rendering not to modify the spec")
+	}
+
+	for _, service := range []string{"api", "agent"} {
+		podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: service}}}
+		applyAuthorization(ragme, service, podSpec)
+		got := envValue(podSpec.Containers[0].Env, "RAGME_AUTHORIZATION_CONFIG")
+		if want := map[string]string{"api": "/app/config/authorization/roles.json"}[service]; got != want {
+			t.Errorf("%s: RAGME_AUTHORIZATION_CONFIG = %q, want %q", service, got, want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// BenchmarkReconcileSteadyState measures a resync of instances whose
+// resources are all in place, the bulk of the work of a large cluster
+func BenchmarkReconcileSteadyState(b *testing.B) {
+	for _, count := range []int{100, 1000, 2000} {
+		b.Run(fmt.Sprintf("instances=%d", count), func(b *testing.B) {
+			names := make([]string, count)
+			instances := make([]*ragmev1.RAGme, count)
+			for i := range instances {
+				names[i] = fmt.Sprintf("ragme-%d", i)
+				instances[i] = harnessRAGme(names[i])
+			}
+			h := newReconcilerHarness(b, instances...)
+			for _, name := range names {
+				h.reconcile(b, name)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				h.reconcile(b, names[i%count])
+			}
+			b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "reconciles/s")
+		})
+	}
+}
+
+// BenchmarkReconcileCreate measures the first reconciliation of an instance,
+// creating all its resources
+func BenchmarkReconcileCreate(b *testing.B) {
+	instances := make([]*ragmev1.RAGme, b.N)
+	for i := range instances {
+		instances[i] = harnessRAGme(fmt.Sprintf("ragme-%d", i))
+	}
+	h := newReconcilerHarness(b, instances...)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.reconcile(b, instances[i].Name)
+	}
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "reconciles/s")
+}
@@ -0,0 +1,276 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	cacheBackendMemory = "memory"
+	cacheBackendRedis  = "redis"
+
+	defaultEmbeddingsCacheTTL     = 168 * time.Hour
+	defaultEmbeddingsCacheMaxSize = "512Mi"
+
+	defaultQueryCacheTTL        = time.Hour
+	defaultQueryCacheMaxEntries = 10000
+	// queryCacheRedisMemory bounds the managed Redis, the api bounds the entries
+	queryCacheRedisMemory = "256Mi"
+
+	queryCacheKeyExact      = "exact"
+	queryCacheKeyNormalized = "normalized"
+	queryCacheKeyPerUser    = "perUser"
+)
+
+// managedCacheRedis reports whether the operator runs the Redis of an enabled cache
+func managedCacheRedis(enabled bool, redis ragmev1.RAGmeCacheRedis) bool {
+	return enabled && redis.URL == "" && redis.URLSecretRef == nil
+}
+
+// cacheRedisURLEnv returns the variable passing the Redis URL of a cache to the
+// services, pointing at the managed Redis unless an external one is configured
+func cacheRedisURLEnv(name string, redis ragmev1.RAGmeCacheRedis, managedHost string) corev1.EnvVar {
+	switch {
+	case redis.URLSecretRef != nil:
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: redis.URLSecretRef}}
+	case redis.URL != "":
+		return corev1.EnvVar{Name: name, Value: redis.URL}
+	default:
+		return corev1.EnvVar{Name: name, Value: fmt.Sprintf("redis://%s:6379/0", managedHost)}
+	}
+}
+
+// durationOrDefault returns the parsed duration, or the default when unset or invalid
+func durationOrDefault(value string, defaultDuration time.Duration) time.Duration {
+	if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+		return parsed
+	}
+	return defaultDuration
+}
+
+// reconcileCacheRedis runs the managed Redis of a cache, or removes it when
+// the cache is disabled or backed by an external Redis
+func (r *RAGmeReconciler) reconcileCacheRedis(ctx context.Context, ragme *ragmev1.RAGme, managed bool, deployment *appsv1.Deployment, service *corev1.Service) error {
+	if !managed {
+		for _, obj := range []client.Object{deployment, service} {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// createCacheRedisDeployment returns the Deployment of a managed cache Redis.
+// Redis evicts the least recently used keys beyond maxSize, and the container
+// is given some headroom above it for the Redis overhead.
+func createCacheRedisDeployment(ragme *ragmev1.RAGme, component, image, maxSize string) *appsv1.Deployment {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}
+
+	if image == "" {
+		image = defaultRedisImage
+	}
+	memory, err := resource.ParseQuantity(maxSize)
+	if err != nil {
+		memory = resource.MustParse(defaultEmbeddingsCacheMaxSize)
+	}
+	limit := resource.NewQuantity(memory.Value()*5/4, resource.BinarySI)
+
+	replicas := hibernationReplicas(ragme, 1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ragme.Name, component),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "redis",
+							Image: image,
+							// A cache is rebuilt on demand, persistence is not needed
+							Args: []string{
+								"--save", "", "--appendonly", "no",
+								"--maxmemory", strconv.FormatInt(memory.Value(), 10),
+								"--maxmemory-policy", "allkeys-lru",
+							},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 6379, Name: "redis"},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{corev1.ResourceMemory: memory},
+								Limits:   corev1.ResourceList{corev1.ResourceMemory: *limit},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(6379)},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createCacheRedisService returns the Service of a managed cache Redis
+func createCacheRedisService(ragme *ragmev1.RAGme, component string) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ragme.Name, component),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "redis", Port: 6379, TargetPort: intstr.FromInt(6379)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
+
+// reconcileEmbeddingsCache runs the managed Redis of the embeddings cache
+func (r *RAGmeReconciler) reconcileEmbeddingsCache(ctx context.Context, ragme *ragmev1.RAGme) error {
+	cache := ragme.Spec.Embeddings.Cache
+	return r.reconcileCacheRedis(ctx, ragme, managedCacheRedis(cache.Enabled, cache.Redis),
+		createCacheRedisDeployment(ragme, "embeddings-cache", cache.Redis.Image, cache.MaxSize),
+		createCacheRedisService(ragme, "embeddings-cache"))
+}
+
+// applyEmbeddingsCache wires the embeddings cache into the services calling
+// the embedding provider: the agent at ingestion, the api and mcp at query time
+func applyEmbeddingsCache(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	cache := ragme.Spec.Embeddings.Cache
+	if !cache.Enabled || serviceName == "frontend" {
+		return
+	}
+
+	maxSize := cache.MaxSize
+	if maxSize == "" {
+		maxSize = defaultEmbeddingsCacheMaxSize
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env,
+		corev1.EnvVar{Name: "EMBEDDINGS_CACHE_ENABLED", Value: "true"},
+		cacheRedisURLEnv("EMBEDDINGS_CACHE_REDIS_URL", cache.Redis, ragme.Name+"-embeddings-cache"),
+		corev1.EnvVar{
+			Name:  "EMBEDDINGS_CACHE_TTL_SECONDS",
+			Value: strconv.Itoa(int(durationOrDefault(cache.TTL, defaultEmbeddingsCacheTTL).Seconds())),
+		},
+		corev1.EnvVar{Name: "EMBEDDINGS_CACHE_MAX_SIZE", Value: maxSize},
+	)
+}
+
+// queryCacheBackend returns the effective backend of the query cache
+func queryCacheBackend(ragme *ragmev1.RAGme) string {
+	if backend := ragme.Spec.API.QueryCache.Backend; backend != "" {
+		return backend
+	}
+	return cacheBackendMemory
+}
+
+// reconcileQueryCache runs the managed Redis of the query cache
+func (r *RAGmeReconciler) reconcileQueryCache(ctx context.Context, ragme *ragmev1.RAGme) error {
+	cache := ragme.Spec.API.QueryCache
+	managed := queryCacheBackend(ragme) == cacheBackendRedis && managedCacheRedis(cache.Enabled, cache.Redis)
+	return r.reconcileCacheRedis(ctx, ragme, managed,
+		createCacheRedisDeployment(ragme, "query-cache", cache.Redis.Image, queryCacheRedisMemory),
+		createCacheRedisService(ragme, "query-cache"))
+}
+
+// applyQueryCache configures the query cache of the api
+func applyQueryCache(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	cache := ragme.Spec.API.QueryCache
+	if !cache.Enabled || serviceName != "api" {
+		return
+	}
+
+	maxEntries := cache.MaxEntries
+	if maxEntries == 0 {
+		maxEntries = defaultQueryCacheMaxEntries
+	}
+	keyStrategy := cache.KeyStrategy
+	if keyStrategy == "" {
+		keyStrategy = queryCacheKeyNormalized
+	}
+	env := []corev1.EnvVar{
+		{Name: "QUERY_CACHE_ENABLED", Value: "true"},
+		{Name: "QUERY_CACHE_BACKEND", Value: queryCacheBackend(ragme)},
+		{Name: "QUERY_CACHE_TTL_SECONDS", Value: strconv.Itoa(int(durationOrDefault(cache.TTL, defaultQueryCacheTTL).Seconds()))},
+		{Name: "QUERY_CACHE_MAX_ENTRIES", Value: strconv.Itoa(int(maxEntries))},
+		{Name: "QUERY_CACHE_KEY_STRATEGY", Value: keyStrategy},
+	}
+	if queryCacheBackend(ragme) == cacheBackendRedis {
+		env = append(env, cacheRedisURLEnv("QUERY_CACHE_REDIS_URL", cache.Redis, ragme.Name+"-query-cache"))
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+}
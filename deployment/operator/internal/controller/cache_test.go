@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyEmbeddingsCache(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}
+	ragme.Spec.Embeddings.Cache = ragmev1.RAGmeEmbeddingsCache{Enabled: true, TTL: "24h"}
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	applyEmbeddingsCache(ragme, "agent", podSpec)
+	env := podSpec.Containers[0].Env
+	if got := envValue(env, "EMBEDDINGS_CACHE_REDIS_URL"); got != "redis://demo-embeddings-cache:6379/0" {
+		t.Errorf("EMBEDDINGS_CACHE_REDIS_URL = %q, want the managed Redis", got)
+	}
+	if got := envValue(env, "EMBEDDINGS_CACHE_TTL_SECONDS"); got != "86400" {
+		t.Errorf("EMBEDDINGS_CACHE_TTL_SECONDS = %q, want 86400", got)
+	}
+
+	ragme.Spec.Embeddings.Cache.Redis.URL = "redis://cache.example.com:6379/2"
+	if managedCacheRedis(true, ragme.Spec.Embeddings.Cache.Redis) {
+		t.Error("expected no managed Redis with an external URL")
+	}
+}
+
+func TestCreateCacheRedisDeployment(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}
+	deployment := createCacheRedisDeployment(ragme, "embeddings-cache", "", "1Gi")
+
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if deployment.Name != "demo-embeddings-cache" || container.Image != defaultRedisImage {
+		t.Errorf("got %s running %s, want demo-embeddings-cache running %s", deployment.Name, container.Image, defaultRedisImage)
+	}
+	wantArgs := map[string]string{"--maxmemory": "1073741824", "--maxmemory-policy": "allkeys-lru"}
+	for i := 0; i+1 < len(container.Args); i++ {
+		if want, ok := wantArgs[container.Args[i]]; ok {
+			if container.Args[i+1] != want {
+				t.Errorf("%s = %s, want %s", container.Args[i], container.Args[i+1], want)
+			}
+			delete(wantArgs, container.Args[i])
+		}
+	}
+	if len(wantArgs) > 0 {
+		t.Errorf("missing Redis arguments %v", wantArgs)
+	}
+	if limit := container.Resources.Limits[corev1.ResourceMemory]; limit.Value() != 1342177280 {
+		t.Errorf("memory limit = %s, want 1.25Gi", limit.String())
+	}
+}
+
+func TestApplyQueryCache(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}
+	ragme.Spec.API.QueryCache.Enabled = true
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}
+	applyQueryCache(ragme, "api", podSpec)
+	env := podSpec.Containers[0].Env
+	for name, want := range map[string]string{
+		"QUERY_CACHE_BACKEND":      "memory",
+		"QUERY_CACHE_TTL_SECONDS":  "3600",
+		"QUERY_CACHE_MAX_ENTRIES":  "10000",
+		"QUERY_CACHE_KEY_STRATEGY": "normalized",
+		"QUERY_CACHE_REDIS_URL":    "",
+	} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	ragme.Spec.API.QueryCache.Backend = cacheBackendRedis
+	podSpec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}
+	applyQueryCache(ragme, "api", podSpec)
+	if got := envValue(podSpec.Containers[0].Env, "QUERY_CACHE_REDIS_URL"); got != "redis://demo-query-cache:6379/0" {
+		t.Errorf("QUERY_CACHE_REDIS_URL = %q, want the managed Redis", got)
+	}
+
+	podSpec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "mcp"}}}
+	applyQueryCache(ragme, "mcp", podSpec)
+	if len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("expected only the api to use the query cache, got %v", podSpec.Containers[0].Env)
+	}
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	catalogInfoKey = "catalog-info.yaml"
+	// catalogLabel lets catalog providers discover the ConfigMaps
+	catalogLabel = "backstage.io/catalog-info"
+)
+
+// catalogResourceTypes maps the datastores of an instance to the type of
+// their Resource entity. The other workloads are subcomponents.
+var catalogResourceTypes = map[string]string{
+	"weaviate":         "database",
+	"weaviate-shard":   "database",
+	"minio":            "storage",
+	"redis":            "cache",
+	"query-cache":      "cache",
+	"embeddings-cache": "cache",
+}
+
+// catalogConfigMapName returns the name of the ConfigMap holding the entities
+func catalogConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-catalog-info", ragme.Name)
+}
+
+// catalogOwner returns the owner of the entities
+func catalogOwner(ragme *ragmev1.RAGme) string {
+	if owner := ragme.Spec.Metadata.Catalog.Owner; owner != "" {
+		return owner
+	}
+	return ragme.Spec.Metadata.Team
+}
+
+// catalogLifecycle returns the lifecycle of the entities
+func catalogLifecycle(ragme *ragmev1.RAGme) string {
+	if lifecycle := ragme.Spec.Metadata.Catalog.Lifecycle; lifecycle != "" {
+		return lifecycle
+	}
+	if environment := ragme.Spec.Metadata.Environment; environment != "" {
+		return environment
+	}
+	return "production"
+}
+
+// renderCatalogInfo renders the catalog-info entities of an instance: a
+// Component for the instance, a subcomponent for each workload and a Resource
+// for each datastore, as a multi-document YAML file
+func renderCatalogInfo(ragme *ragmev1.RAGme, components []string) (string, error) {
+	catalog := ragme.Spec.Metadata.Catalog
+	owner := catalogOwner(ragme)
+	lifecycle := catalogLifecycle(ragme)
+	instanceRef := "component:" + ragme.Name
+
+	annotations := map[string]string{
+		"backstage.io/kubernetes-id":             ragme.Name,
+		"backstage.io/kubernetes-namespace":      ragme.Namespace,
+		"backstage.io/kubernetes-label-selector": fmt.Sprintf("app=ragme,instance=%s", ragme.Name),
+	}
+	if ragme.Spec.Version != "" {
+		annotations["ragme.io/version"] = ragme.Spec.Version
+	}
+	metadata := map[string]interface{}{
+		"name":        ragme.Name,
+		"title":       fmt.Sprintf("RAGme %s/%s", ragme.Namespace, ragme.Name),
+		"annotations": annotations,
+	}
+	if catalog.Description != "" {
+		metadata["description"] = catalog.Description
+	}
+	if len(catalog.Tags) > 0 {
+		metadata["tags"] = catalog.Tags
+	}
+	if ingress := ragme.Spec.ExternalAccess.Ingress; ingress.Enabled && ingress.Host != "" {
+		scheme := "http"
+		if ingress.TLSEnabled {
+			scheme = "https"
+		}
+		metadata["links"] = []map[string]string{{"url": fmt.Sprintf("%s://%s", scheme, ingress.Host), "title": "RAGme"}}
+	}
+
+	var dependsOn []string
+	var entities []map[string]interface{}
+	for _, component := range components {
+		name := fmt.Sprintf("%s-%s", ragme.Name, component)
+		spec := map[string]interface{}{"owner": owner}
+		if catalog.System != "" {
+			spec["system"] = catalog.System
+		}
+		entity := map[string]interface{}{
+			"apiVersion": "backstage.io/v1alpha1",
+			"metadata":   map[string]interface{}{"name": name},
+			"spec":       spec,
+		}
+		if resourceType, ok := catalogResourceTypes[component]; ok {
+			entity["kind"] = "Resource"
+			spec["type"] = resourceType
+			spec["dependencyOf"] = []string{instanceRef}
+			dependsOn = append(dependsOn, "resource:"+name)
+		} else {
+			entity["kind"] = "Component"
+			spec["type"] = "service"
+			spec["lifecycle"] = lifecycle
+			spec["subcomponentOf"] = instanceRef
+		}
+		entities = append(entities, entity)
+	}
+
+	spec := map[string]interface{}{
+		"type":      "service",
+		"lifecycle": lifecycle,
+		"owner":     owner,
+	}
+	if catalog.System != "" {
+		spec["system"] = catalog.System
+	}
+	if len(dependsOn) > 0 {
+		spec["dependsOn"] = dependsOn
+	}
+	entities = append([]map[string]interface{}{{
+		"apiVersion": "backstage.io/v1alpha1",
+		"kind":       "Component",
+		"metadata":   metadata,
+		"spec":       spec,
+	}}, entities...)
+
+	documents := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		data, err := yaml.Marshal(entity)
+		if err != nil {
+			return "", err
+		}
+		documents = append(documents, string(data))
+	}
+	return strings.Join(documents, "---\n"), nil
+}
+
+// reconcileCatalogInfo renders the catalog-info entities of the instance in a
+// ConfigMap, deleting it when disabled
+func (r *RAGmeReconciler) reconcileCatalogInfo(ctx context.Context, ragme *ragmev1.RAGme) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      catalogConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":        "ragme",
+				"instance":   ragme.Name,
+				catalogLabel: "true",
+			},
+		},
+	}
+	if !ragme.Spec.Metadata.Catalog.Enabled {
+		if err := r.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": ragme.Name}); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	var components []string
+	for _, deployment := range deployments.Items {
+		if component := deployment.Labels["component"]; component != "" && !seen[component] {
+			seen[component] = true
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	data, err := renderCatalogInfo(ragme, components)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{catalogInfoKey: data}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	if found.Data[catalogInfoKey] != data {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderCatalogInfo(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Version = "1.4.0"
+	ragme.Spec.Metadata = ragmev1.RAGmeMetadata{
+		Team:        "search",
+		Environment: "staging",
+		Catalog:     ragmev1.RAGmeCatalog{Enabled: true, System: "knowledge", Tags: []string{"rag"}},
+	}
+	ragme.Spec.ExternalAccess.Ingress = ragmev1.RAGmeIngressConfig{Enabled: true, Host: "ragme.example.com", TLSEnabled: true}
+
+	data, err := renderCatalogInfo(ragme, []string{"api", "minio", "weaviate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents := strings.Split(data, "---\n")
+	if len(documents) != 4 {
+		t.Fatalf("documents = %d, want the instance, api, minio and weaviate:\n%s", len(documents), data)
+	}
+
+	type entity struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name        string              `json:"name"`
+			Annotations map[string]string   `json:"annotations"`
+			Links       []map[string]string `json:"links"`
+		} `json:"metadata"`
+		Spec struct {
+			Type           string   `json:"type"`
+			Owner          string   `json:"owner"`
+			Lifecycle      string   `json:"lifecycle"`
+			System         string   `json:"system"`
+			DependsOn      []string `json:"dependsOn"`
+			SubcomponentOf string   `json:"subcomponentOf"`
+		} `json:"spec"`
+	}
+	entities := make([]entity, len(documents))
+	for i, document := range documents {
+		if err := yaml.Unmarshal([]byte(document), &entities[i]); err != nil {
+			t.Fatalf("document %d is not valid YAML: %v", i, err)
+		}
+	}
+
+	instance := entities[0]
+	if instance.Kind != "Component" || instance.Spec.Owner != "search" || instance.Spec.Lifecycle != "staging" || instance.Spec.System != "knowledge" {
+		t.Errorf("instance = %+v, want the owner and lifecycle from the metadata", instance)
+	}
+	if instance.Metadata.Annotations["backstage.io/kubernetes-id"] != "test" || instance.Metadata.Annotations["ragme.io/version"] != "1.4.0" {
+		t.Errorf("annotations = %v", instance.Metadata.Annotations)
+	}
+	if len(instance.Metadata.Links) != 1 || instance.Metadata.Links[0]["url"] != "https://ragme.example.com" {
+		t.Errorf("links = %v, want the public URL", instance.Metadata.Links)
+	}
+	if strings.Join(instance.Spec.DependsOn, ",") != "resource:test-minio,resource:test-weaviate" {
+		t.Errorf("dependsOn = %v, want the datastores", instance.Spec.DependsOn)
+	}
+	if api := entities[1]; api.Kind != "Component" || api.Spec.SubcomponentOf != "component:test" {
+		t.Errorf("api = %+v, want a subcomponent of the instance", api)
+	}
+	if weaviate := entities[3]; weaviate.Kind != "Resource" || weaviate.Spec.Type != "database" {
+		t.Errorf("weaviate = %+v, want a database Resource", weaviate)
+	}
+}
+
+func TestValidateSpecCatalog(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Metadata.Catalog.Enabled = true
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a catalog without owner")
+	}
+	ragme.Spec.Metadata.Team = "search"
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v, want the team as owner", err)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// defaultIngressClassAnnotation marks the default IngressClass of a cluster
+const defaultIngressClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+// reconcileClasses records in status the IngressClass and StorageClasses of
+// the generated resources. Classes the spec does not pin are discovered from
+// the cluster, so resources are not left Pending on clusters without defaults.
+func (r *RAGmeReconciler) reconcileClasses(ctx context.Context, ragme *ragmev1.RAGme) error {
+	logger := log.FromContext(ctx)
+
+	storageClasses := &storagev1.StorageClassList{}
+	if err := r.List(ctx, storageClasses); err != nil {
+		return err
+	}
+	ingressClasses := &networkingv1.IngressClassList{}
+	if err := r.List(ctx, ingressClasses); err != nil {
+		return err
+	}
+
+	config, err := r.Config.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	classes := ragmev1.RAGmeClassesStatus{
+		IngressClassName:         ragme.Spec.ExternalAccess.Ingress.IngressClassName,
+		SharedVolumeStorageClass: ragme.Spec.Storage.SharedVolume.StorageClass,
+		StorageClass:             selectStorageClass(storageClasses.Items, false),
+	}
+	if config.StorageClass != "" {
+		classes.StorageClass = config.StorageClass
+	}
+	if classes.IngressClassName == "" {
+		classes.IngressClassName = selectIngressClass(ingressClasses.Items)
+	}
+	if classes.SharedVolumeStorageClass == "" {
+		classes.SharedVolumeStorageClass = selectStorageClass(storageClasses.Items, true)
+	}
+
+	if classes != ragme.Status.Classes {
+		logger.Info("Selected classes", "ingressClass", classes.IngressClassName,
+			"sharedVolumeStorageClass", classes.SharedVolumeStorageClass, "storageClass", classes.StorageClass)
+	}
+	ragme.Status.Classes = classes
+	return nil
+}
+
+// selectIngressClass returns the default IngressClass, or the first one when
+// none is marked default. It returns "" when no IngressClass is installed.
+func selectIngressClass(classes []networkingv1.IngressClass) string {
+	for _, class := range classes {
+		if class.Annotations[defaultIngressClassAnnotation] == "true" {
+			return class.Name
+		}
+	}
+	if len(classes) > 0 {
+		return classes[0].Name
+	}
+	return ""
+}
+
+// selectStorageClass returns the default StorageClass, or the first one when
+// none is marked default. With preferRWX, classes whose provisioner supports
+// ReadWriteMany are preferred over the default. It returns "" when no
+// StorageClass is installed.
+func selectStorageClass(classes []storagev1.StorageClass, preferRWX bool) string {
+	var defaultClass, rwxClass string
+	for _, class := range classes {
+		isDefault := class.Annotations[defaultStorageClassAnnotation] == "true"
+		isRWX := supportsRWX(class.Provisioner)
+		if isDefault && (!preferRWX || isRWX) {
+			return class.Name
+		}
+		if isDefault && defaultClass == "" {
+			defaultClass = class.Name
+		}
+		if isRWX && rwxClass == "" {
+			rwxClass = class.Name
+		}
+	}
+
+	switch {
+	case preferRWX && rwxClass != "":
+		return rwxClass
+	case defaultClass != "":
+		return defaultClass
+	case len(classes) > 0:
+		return classes[0].Name
+	}
+	return ""
+}
+
+// supportsRWX reports whether the provisioner is known to support ReadWriteMany volumes
+func supportsRWX(provisioner string) bool {
+	for _, rwx := range rwxProvisioners {
+		if strings.Contains(provisioner, rwx) {
+			return true
+		}
+	}
+	return false
+}
+
+// className returns a pointer to the class name of a generated resource, or
+// nil to leave the choice to the cluster when no class was selected
+func className(class string) *string {
+	if class == "" {
+		return nil
+	}
+	return &class
+}
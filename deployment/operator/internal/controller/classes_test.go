@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectStorageClass(t *testing.T) {
+	storageClass := func(name, provisioner string, isDefault bool) storagev1.StorageClass {
+		class := storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Provisioner: provisioner}
+		if isDefault {
+			class.Annotations = map[string]string{defaultStorageClassAnnotation: "true"}
+		}
+		return class
+	}
+
+	tests := []struct {
+		name      string
+		classes   []storagev1.StorageClass
+		preferRWX bool
+		want      string
+	}{
+		{name: "no classes"},
+		{
+			name:    "default class",
+			classes: []storagev1.StorageClass{storageClass("fast", "ebs.csi.aws.com", false), storageClass("standard", "ebs.csi.aws.com", true)},
+			want:    "standard",
+		},
+		{
+			name:    "first class without default",
+			classes: []storagev1.StorageClass{storageClass("fast", "ebs.csi.aws.com", false), storageClass("slow", "ebs.csi.aws.com", false)},
+			want:    "fast",
+		},
+		{
+			name:      "rwx class preferred over default",
+			classes:   []storagev1.StorageClass{storageClass("efs", "efs.csi.aws.com", false), storageClass("gp3", "ebs.csi.aws.com", true)},
+			preferRWX: true,
+			want:      "efs",
+		},
+		{
+			name:      "rwx default",
+			classes:   []storagev1.StorageClass{storageClass("cephfs", "rook-ceph.cephfs.csi.ceph.com", false), storageClass("nfs", "nfs.csi.k8s.io", true)},
+			preferRWX: true,
+			want:      "nfs",
+		},
+		{
+			name:      "default without rwx class",
+			classes:   []storagev1.StorageClass{storageClass("fast", "ebs.csi.aws.com", false), storageClass("gp3", "ebs.csi.aws.com", true)},
+			preferRWX: true,
+			want:      "gp3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectStorageClass(tt.classes, tt.preferRWX); got != tt.want {
+				t.Errorf("selectStorageClass() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectIngressClass(t *testing.T) {
+	classes := []networkingv1.IngressClass{
+		{ObjectMeta: metav1.ObjectMeta{Name: "haproxy"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Annotations: map[string]string{defaultIngressClassAnnotation: "true"}}},
+	}
+	if got := selectIngressClass(classes); got != "nginx" {
+		t.Errorf("selectIngressClass() = %q, want the default nginx", got)
+	}
+	if got := selectIngressClass(classes[:1]); got != "haproxy" {
+		t.Errorf("selectIngressClass() = %q, want the only haproxy", got)
+	}
+	if got := selectIngressClass(nil); got != "" {
+		t.Errorf("selectIngressClass() = %q, want none", got)
+	}
+}
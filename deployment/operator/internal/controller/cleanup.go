@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	ragmeFinalizer = "ragme.io/cleanup"
+
+	// ForceCleanupAnnotation makes the operator release a deleted instance
+	// without waiting for the termination order
+	ForceCleanupAnnotation = "ragme.io/force-cleanup"
+
+	defaultDeletionStuckThreshold = 15 * time.Minute
+
+	cleanupPolicyDelete = "Delete"
+	cleanupPolicyRetain = "Retain"
+)
+
+// reconcileDelete applies the cleanup policy and the termination order of a
+// deleted instance before releasing it to the garbage collector, which removes
+// everything it still owns. A deletion that does not complete within the stuck
+// threshold raises the DeletionBlocked condition.
+func (r *RAGmeReconciler) reconcileDelete(ctx context.Context, ragme *ragmev1.RAGme) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	now := r.now()
+	stored := ragme.DeepCopy()
+	if !controllerutil.ContainsFinalizer(ragme, ragmeFinalizer) {
+		// Only finalizers of others hold the instance
+		return r.reconcileDeletionBlocked(ctx, ragme, stored, nil, now)
+	}
+
+	if ragme.Spec.CleanupPolicy == cleanupPolicyRetain {
+		// Forcing the cleanup never gives up the data the policy keeps
+		if err := r.retainVolumes(ctx, ragme); err != nil {
+			logger.Error(err, "Failed to retain the data volumes")
+			return r.reconcileDeletionBlocked(ctx, ragme, stored, fmt.Errorf("retaining the data volumes: %w", err), now)
+		}
+	}
+
+	if forceCleanupRequested(ragme) {
+		logger.Info("Forced cleanup requested, skipping the termination order")
+	} else if ragme.Spec.Termination.Ordered {
+		done, err := r.reconcileTermination(ctx, ragme, now)
+		if err != nil {
+			logger.Error(err, "Failed to terminate the components")
+			return r.reconcileDeletionBlocked(ctx, ragme, stored, fmt.Errorf("terminating the components: %w", err), now)
+		}
+		if !done {
+			return r.reconcileDeletionBlocked(ctx, ragme, stored, nil, now)
+		}
+	}
+
+	forgetInstanceInfo(ragme.Namespace, ragme.Name)
+	patch := client.MergeFrom(ragme.DeepCopy())
+	controllerutil.RemoveFinalizer(ragme, ragmeFinalizer)
+	return ctrl.Result{}, client.IgnoreNotFound(r.Patch(ctx, ragme, patch))
+}
+
+// forceCleanupRequested reports whether the operator must release the instance
+// without waiting for the termination order
+func forceCleanupRequested(ragme *ragmev1.RAGme) bool {
+	return ragme.Annotations[ForceCleanupAnnotation] == "true"
+}
+
+// deletionStuckThreshold returns how long a deletion may take before it is
+// reported as blocked
+func deletionStuckThreshold(ragme *ragmev1.RAGme) time.Duration {
+	if seconds := ragme.Spec.Termination.StuckThresholdSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return defaultDeletionStuckThreshold
+}
+
+// reconcileDeletionBlocked records the progress of a pending deletion, raising
+// the DeletionBlocked condition with the blocking resource once the deletion
+// has been pending beyond the threshold. stepErr is the failure of the cleanup
+// of the operator, if any, and is returned for a retry.
+func (r *RAGmeReconciler) reconcileDeletionBlocked(ctx context.Context, ragme, stored *ragmev1.RAGme, stepErr error, now time.Time) (ctrl.Result, error) {
+	ours := controllerutil.ContainsFinalizer(ragme, ragmeFinalizer)
+	remaining := ragme.DeletionTimestamp.Add(deletionStuckThreshold(ragme)).Sub(now)
+	if remaining <= 0 {
+		blocker, err := r.deletionBlocker(ctx, ragme, stepErr)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		message := fmt.Sprintf("Deletion pending since %s, blocked by %s", ragme.DeletionTimestamp.UTC().Format(time.RFC3339), blocker)
+		if ours {
+			message += fmt.Sprintf(". Set the %s=true annotation to release the instance", ForceCleanupAnnotation)
+		}
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDeletionBlocked, conditions.ReasonDeletionStuck, message)
+		log.FromContext(ctx).Info("Deletion blocked", "blocker", blocker)
+	}
+	if remaining <= 0 || ours {
+		if err := r.patchStatus(ctx, ragme, stored); err != nil {
+			return ctrl.Result{}, client.IgnoreNotFound(err)
+		}
+	}
+
+	switch {
+	case stepErr != nil:
+		return ctrl.Result{}, stepErr
+	case ours:
+		return ctrl.Result{RequeueAfter: terminationPollInterval}, nil
+	case remaining > 0:
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+	return ctrl.Result{RequeueAfter: time.Minute}, nil
+}
+
+// deletionBlocker describes what holds a deleted instance: the failing cleanup
+// step of the operator, a terminating object of the instance, or the
+// finalizers of others
+func (r *RAGmeReconciler) deletionBlocker(ctx context.Context, ragme *ragmev1.RAGme, stepErr error) (string, error) {
+	if stepErr != nil {
+		return fmt.Sprintf("finalizer %s: %v", ragmeFinalizer, stepErr), nil
+	}
+
+	selector := client.MatchingLabels{"app": "ragme", "instance": ragme.Name}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), selector); err != nil {
+		return "", err
+	}
+	for i := range pods.Items {
+		if blocker := terminatingObject("Pod", &pods.Items[i]); blocker != "" {
+			return blocker, nil
+		}
+	}
+	// The volumes carry no labels, only the ownership of the instance
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs, client.InNamespace(ragme.Namespace)); err != nil {
+		return "", err
+	}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		if len(withoutOwner(pvc.OwnerReferences, ragme)) == len(pvc.OwnerReferences) {
+			continue
+		}
+		if blocker := terminatingObject("PersistentVolumeClaim", pvc); blocker != "" {
+			return blocker, nil
+		}
+	}
+
+	if controllerutil.ContainsFinalizer(ragme, ragmeFinalizer) {
+		return fmt.Sprintf("finalizer %s: components still terminating", ragmeFinalizer), nil
+	}
+	return fmt.Sprintf("finalizers %s", strings.Join(ragme.Finalizers, ", ")), nil
+}
+
+// terminatingObject describes an object stuck terminating, or returns "" when
+// it is not being deleted
+func terminatingObject(kind string, obj client.Object) string {
+	if obj.GetDeletionTimestamp() == nil {
+		return ""
+	}
+	if finalizers := obj.GetFinalizers(); len(finalizers) > 0 {
+		return fmt.Sprintf("%s %s (finalizers %s)", kind, obj.GetName(), strings.Join(finalizers, ", "))
+	}
+	return fmt.Sprintf("%s %s (terminating)", kind, obj.GetName())
+}
+
+// retainVolumes releases the PVCs of the instance from its ownership, so the
+// garbage collector keeps the data when the instance is gone. The Secrets of
+// the storage users and API keys are released with them, the data stays
+// reachable with the same credentials.
+func (r *RAGmeReconciler) retainVolumes(ctx context.Context, ragme *ragmev1.RAGme) error {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs, client.InNamespace(ragme.Namespace)); err != nil {
+		return err
+	}
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": ragme.Name}); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		owners := withoutOwner(secret.OwnerReferences, ragme)
+		if !retainedCredentials(secret) || len(owners) == len(secret.OwnerReferences) {
+			continue
+		}
+		patch := client.MergeFrom(secret.DeepCopy())
+		secret.OwnerReferences = owners
+		if err := r.Patch(ctx, secret, patch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		owners := withoutOwner(pvc.OwnerReferences, ragme)
+		if len(owners) == len(pvc.OwnerReferences) {
+			continue
+		}
+		patch := client.MergeFrom(pvc.DeepCopy())
+		pvc.OwnerReferences = owners
+		if err := r.Patch(ctx, pvc, patch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		log.FromContext(ctx).Info("Retained data volume", "pvc", pvc.Name)
+	}
+	return nil
+}
+
+// retainedCredentials reports whether a Secret holds generated credentials
+// kept with the data volumes
+func retainedCredentials(secret *corev1.Secret) bool {
+	return secret.Labels[storageUserLabel] == "true" || secret.Labels[serviceAuthSecretLabel] == "true" ||
+		secret.Labels[minioRootSecretLabel] == "true"
+}
+
+// withoutOwner returns the owner references without the ones pointing at owner
+func withoutOwner(refs []metav1.OwnerReference, owner metav1.Object) []metav1.OwnerReference {
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.UID != owner.GetUID() {
+			kept = append(kept, ref)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,417 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultCollectorImage = "otel/opentelemetry-collector-contrib:0.102.1"
+
+	collectorConfigKey      = "collector.json"
+	collectorConfigPath     = "/etc/otelcol"
+	collectorHashAnnotation = "ragme.io/collector-hash"
+
+	collectorOTLPGRPCPort = 4317
+	collectorOTLPHTTPPort = 4318
+	collectorHealthPort   = 13133
+
+	collectorExporterOTLP        = "otlp"
+	collectorExporterOTLPHTTP    = "otlphttp"
+	collectorExporterRemoteWrite = "prometheusremotewrite"
+	collectorExporterDebug       = "debug"
+
+	collectorSignalTraces  = "traces"
+	collectorSignalMetrics = "metrics"
+)
+
+// collectorExporterSignals are the signals supported by each exporter type
+var collectorExporterSignals = map[string][]string{
+	collectorExporterOTLP:        {collectorSignalTraces, collectorSignalMetrics},
+	collectorExporterOTLPHTTP:    {collectorSignalTraces, collectorSignalMetrics},
+	collectorExporterRemoteWrite: {collectorSignalMetrics},
+	collectorExporterDebug:       {collectorSignalTraces, collectorSignalMetrics},
+}
+
+// collectorName returns the name of the ConfigMap, Deployment and Service of the collector
+func collectorName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-otel-collector", ragme.Name)
+}
+
+// collectorEndpoint returns the OTLP endpoint of the collector for a protocol
+func collectorEndpoint(ragme *ragmev1.RAGme, protocol string) string {
+	port := collectorOTLPGRPCPort
+	if protocol == tracingProtocolHTTP {
+		port = collectorOTLPHTTPPort
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", collectorName(ragme), ragme.Namespace, port)
+}
+
+// collectorExporterSignalsOf returns the signals forwarded to an exporter
+func collectorExporterSignalsOf(exporter ragmev1.RAGmeCollectorExporter) []string {
+	if len(exporter.Signals) > 0 {
+		return exporter.Signals
+	}
+	return collectorExporterSignals[exporter.Type]
+}
+
+// collectorHeaderEnv returns the environment variable holding a header of an
+// exporter, indexed so that the names are valid whatever the header names
+func collectorHeaderEnv(exporter, header int) string {
+	return fmt.Sprintf("COLLECTOR_EXPORTER_%d_HEADER_%d", exporter, header)
+}
+
+// sortedHeaders returns the header names of an exporter in a stable order
+func sortedHeaders(exporter ragmev1.RAGmeCollectorExporter) []string {
+	headers := make([]string, 0, len(exporter.HeadersFrom))
+	for header := range exporter.HeadersFrom {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// renderCollectorConfig returns the collector configuration and its hash.
+// The spans are received over OTLP; the metrics are received over OTLP and
+// scraped from the services. A pipeline is only defined when an exporter
+// takes its signal. The header values are expanded from the environment.
+func renderCollectorConfig(ragme *ragmev1.RAGme) (string, string, error) {
+	collector := ragme.Spec.Observability.Collector
+
+	exporters := map[string]interface{}{}
+	pipelineExporters := map[string][]string{}
+	for i, exporter := range collector.Exporters {
+		id := exporter.Type + "/" + exporter.Name
+		config := map[string]interface{}{}
+		switch exporter.Type {
+		case collectorExporterDebug:
+			config["verbosity"] = "basic"
+		default:
+			config["endpoint"] = exporter.Endpoint
+			if exporter.Insecure && exporter.Type == collectorExporterOTLP {
+				config["tls"] = map[string]interface{}{"insecure": true}
+			}
+			if len(exporter.HeadersFrom) > 0 {
+				headers := map[string]string{}
+				for j, header := range sortedHeaders(exporter) {
+					headers[header] = fmt.Sprintf("${env:%s}", collectorHeaderEnv(i, j))
+				}
+				config["headers"] = headers
+			}
+		}
+		exporters[id] = config
+		for _, signal := range collectorExporterSignalsOf(exporter) {
+			pipelineExporters[signal] = append(pipelineExporters[signal], id)
+		}
+	}
+
+	receivers := map[string]interface{}{
+		"otlp": map[string]interface{}{
+			"protocols": map[string]interface{}{
+				"grpc": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", collectorOTLPGRPCPort)},
+				"http": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", collectorOTLPHTTPPort)},
+			},
+		},
+	}
+	processors := []string{"memory_limiter", "batch"}
+	pipelines := map[string]interface{}{}
+	if ids := pipelineExporters[collectorSignalTraces]; len(ids) > 0 {
+		pipelines[collectorSignalTraces] = map[string]interface{}{
+			"receivers":  []string{"otlp"},
+			"processors": processors,
+			"exporters":  ids,
+		}
+	}
+	if ids := pipelineExporters[collectorSignalMetrics]; len(ids) > 0 {
+		interval := collector.ScrapeInterval
+		if interval == "" {
+			interval = defaultRemoteWriteScrapeInterval
+		}
+		var scrapeConfigs []interface{}
+		for _, service := range scrapedServices {
+			scrapeConfigs = append(scrapeConfigs, map[string]interface{}{
+				"job_name":        "ragme-" + service.name,
+				"metrics_path":    "/metrics",
+				"scrape_interval": interval,
+				"static_configs": []interface{}{map[string]interface{}{
+					"targets": []string{operationalTarget(ragme, service.name, service.port)},
+					"labels": map[string]string{
+						"component":      service.name,
+						"namespace":      ragme.Namespace,
+						"ragme_instance": ragme.Name,
+					},
+				}},
+			})
+		}
+		receivers["prometheus"] = map[string]interface{}{
+			"config": map[string]interface{}{"scrape_configs": scrapeConfigs},
+		}
+		pipelines[collectorSignalMetrics] = map[string]interface{}{
+			"receivers":  []string{"otlp", "prometheus"},
+			"processors": processors,
+			"exporters":  ids,
+		}
+	}
+
+	config := map[string]interface{}{
+		"extensions": map[string]interface{}{
+			"health_check": map[string]interface{}{"endpoint": fmt.Sprintf("0.0.0.0:%d", collectorHealthPort)},
+		},
+		"receivers": receivers,
+		"processors": map[string]interface{}{
+			"memory_limiter": map[string]interface{}{
+				"check_interval":         "1s",
+				"limit_percentage":       80,
+				"spike_limit_percentage": 25,
+			},
+			"batch": map[string]interface{}{},
+		},
+		"exporters": exporters,
+		"service": map[string]interface{}{
+			"extensions": []string{"health_check"},
+			"pipelines":  pipelines,
+		},
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:])[:8], nil
+}
+
+// reconcileCollector renders the collector configuration and runs the
+// collector behind its Service. Its resources are removed when the collector
+// is disabled.
+func (r *RAGmeReconciler) reconcileCollector(ctx context.Context, ragme *ragmev1.RAGme) error {
+	deployment := createCollectorDeployment(ragme)
+	service := createCollectorService(ragme)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      collectorName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":       "ragme",
+				"component": "otel-collector",
+				"instance":  ragme.Name,
+			},
+		},
+	}
+
+	if !ragme.Spec.Observability.Collector.Enabled {
+		for _, obj := range []client.Object{deployment, service, configMap} {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	config, _, err := renderCollectorConfig(ragme)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{collectorConfigKey: config}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if found.Data[collectorConfigKey] != config {
+		found.Data = configMap.Data
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// createCollectorDeployment returns the Deployment of the collector. The
+// configuration hash on the pod template rolls it when the exporters change.
+func createCollectorDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	collector := ragme.Spec.Observability.Collector
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "otel-collector",
+		"instance":  ragme.Name,
+	}
+
+	image := collector.Image
+	if image == "" {
+		image = defaultCollectorImage
+	}
+	replicas := collector.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+	// Nothing is sent nor scraped while the services are scaled to zero
+	replicas = hibernationReplicas(ragme, replicas)
+
+	annotations := map[string]string{}
+	if _, hash, err := renderCollectorConfig(ragme); err == nil {
+		annotations[collectorHashAnnotation] = hash
+	}
+
+	var env []corev1.EnvVar
+	for i, exporter := range collector.Exporters {
+		for j, header := range sortedHeaders(exporter) {
+			ref := exporter.HeadersFrom[header]
+			env = append(env, corev1.EnvVar{
+				Name:      collectorHeaderEnv(i, j),
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref.DeepCopy()},
+			})
+		}
+	}
+
+	container := corev1.Container{
+		Name:  "otel-collector",
+		Image: image,
+		Args:  []string{"--config=" + collectorConfigPath + "/" + collectorConfigKey},
+		Env:   env,
+		Ports: []corev1.ContainerPort{
+			{ContainerPort: collectorOTLPGRPCPort, Name: "otlp-grpc"},
+			{ContainerPort: collectorOTLPHTTPPort, Name: "otlp-http"},
+			{ContainerPort: collectorHealthPort, Name: "health"},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromString("health")},
+			},
+			PeriodSeconds: 10,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: collectorConfigPath, ReadOnly: true},
+		},
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      collectorName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: collectorName(ragme)},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createCollectorService returns the Service the services send their telemetry to
+func createCollectorService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "otel-collector",
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      collectorName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "otlp-grpc", Port: collectorOTLPGRPCPort, TargetPort: intstr.FromString("otlp-grpc")},
+				{Name: "otlp-http", Port: collectorOTLPHTTPPort, TargetPort: intstr.FromString("otlp-http")},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
+
+// validCollectorSignal reports whether an exporter type supports a signal
+func validCollectorSignal(exporterType, signal string) bool {
+	for _, s := range collectorExporterSignals[exporterType] {
+		if s == signal {
+			return true
+		}
+	}
+	return false
+}
+
+// collectorExporterTypes lists the supported exporter types for error messages
+func collectorExporterTypes() string {
+	types := make([]string, 0, len(collectorExporterSignals))
+	for t := range collectorExporterSignals {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return strings.Join(types, ", ")
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func collectorRAGme() *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Observability.Collector = ragmev1.RAGmeCollector{
+		Enabled: true,
+		Exporters: []ragmev1.RAGmeCollectorExporter{
+			{Name: "tempo", Type: collectorExporterOTLP, Endpoint: "tempo.observability:4317", Insecure: true, Signals: []string{collectorSignalTraces}},
+			{
+				Name:     "mimir",
+				Type:     collectorExporterRemoteWrite,
+				Endpoint: "https://mimir.example.com/api/v1/push",
+				HeadersFrom: map[string]corev1.SecretKeySelector{
+					"X-Scope-OrgID": {LocalObjectReference: corev1.LocalObjectReference{Name: "mimir"}, Key: "tenant"},
+				},
+			},
+		},
+	}
+	return ragme
+}
+
+func TestRenderCollectorConfig(t *testing.T) {
+	ragme := collectorRAGme()
+
+	data, hash, err := renderCollectorConfig(ragme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		Receivers map[string]json.RawMessage `json:"receivers"`
+		Exporters map[string]struct {
+			Endpoint string            `json:"endpoint"`
+			TLS      map[string]bool   `json:"tls"`
+			Headers  map[string]string `json:"headers"`
+		} `json:"exporters"`
+		Service struct {
+			Pipelines map[string]struct {
+				Receivers []string `json:"receivers"`
+				Exporters []string `json:"exporters"`
+			} `json:"pipelines"`
+		} `json:"service"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("config is not valid JSON: %v", err)
+	}
+
+	if _, ok := config.Receivers["prometheus"]; !ok {
+		t.Error("receivers do not scrape the services although metrics are exported")
+	}
+	if tempo := config.Exporters["otlp/tempo"]; tempo.Endpoint != "tempo.observability:4317" || !tempo.TLS["insecure"] {
+		t.Errorf("otlp/tempo = %+v", tempo)
+	}
+	if header := config.Exporters["prometheusremotewrite/mimir"].Headers["X-Scope-OrgID"]; header != "${env:COLLECTOR_EXPORTER_1_HEADER_0}" {
+		t.Errorf("X-Scope-OrgID = %q, want the environment variable", header)
+	}
+	if traces := config.Service.Pipelines[collectorSignalTraces]; len(traces.Exporters) != 1 || traces.Exporters[0] != "otlp/tempo" {
+		t.Errorf("traces pipeline = %+v, want only tempo", traces)
+	}
+	if metrics := config.Service.Pipelines[collectorSignalMetrics]; len(metrics.Exporters) != 1 || metrics.Exporters[0] != "prometheusremotewrite/mimir" {
+		t.Errorf("metrics pipeline = %+v, want only mimir", metrics)
+	}
+
+	ragme.Spec.Observability.Collector.Exporters = ragme.Spec.Observability.Collector.Exporters[:1]
+	data, other, err := renderCollectorConfig(ragme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == hash {
+		t.Error("hash did not change with the exporters")
+	}
+	config.Receivers = nil
+	config.Service.Pipelines = nil
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := config.Receivers["prometheus"]; ok {
+		t.Error("receivers scrape the services although no exporter takes metrics")
+	}
+	if _, ok := config.Service.Pipelines[collectorSignalMetrics]; ok {
+		t.Error("metrics pipeline defined without exporter")
+	}
+}
+
+func TestCreateCollectorDeployment(t *testing.T) {
+	ragme := collectorRAGme()
+	deployment := createCollectorDeployment(ragme)
+	if deployment.Name != "test-otel-collector" || *deployment.Spec.Replicas != 1 {
+		t.Errorf("Deployment %s with %d replicas, want test-otel-collector with 1", deployment.Name, *deployment.Spec.Replicas)
+	}
+	if deployment.Spec.Template.Annotations[collectorHashAnnotation] == "" {
+		t.Error("pod template is not annotated with the configuration hash")
+	}
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != "COLLECTOR_EXPORTER_1_HEADER_0" || env[0].ValueFrom.SecretKeyRef.Name != "mimir" {
+		t.Errorf("env = %+v, want the mimir header from its Secret", env)
+	}
+
+	ragme.Status.Hibernation.Hibernated = true
+	if replicas := *createCollectorDeployment(ragme).Spec.Replicas; replicas != 0 {
+		t.Errorf("replicas = %d while hibernated, want 0", replicas)
+	}
+}
+
+func TestTracingEndpointDefaultsToCollector(t *testing.T) {
+	ragme := collectorRAGme()
+	ragme.Spec.Tracing = ragmev1.RAGmeTracing{Enabled: true, Protocol: tracingProtocolHTTP}
+	if err := validateSpec(ragme); err != nil {
+		t.Fatalf("validateSpec() error = %v", err)
+	}
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}
+	applyTracing(ragme, "api", podSpec)
+	if got, want := envValue(podSpec.Containers[0].Env, "OTEL_EXPORTER_OTLP_ENDPOINT"), "http://test-otel-collector.ragme.svc:4318"; got != want {
+		t.Errorf("OTEL_EXPORTER_OTLP_ENDPOINT = %q, want %q", got, want)
+	}
+
+	ragme.Spec.Observability.Collector.Enabled = false
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted tracing without endpoint nor collector")
+	}
+}
+
+func TestValidateSpecCollector(t *testing.T) {
+	ragme := collectorRAGme()
+	ragme.Spec.Observability.Collector.Exporters = append(ragme.Spec.Observability.Collector.Exporters,
+		ragmev1.RAGmeCollectorExporter{Name: "tempo", Type: "zipkin"},
+		ragmev1.RAGmeCollectorExporter{Name: "thanos", Type: collectorExporterRemoteWrite, Signals: []string{collectorSignalTraces}},
+	)
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a duplicate exporter, an unsupported type and traces sent to remote write without endpoint")
+	}
+
+	ragme.Spec.Observability.Collector.Exporters = nil
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a collector without exporter")
+	}
+	ragme.Spec.Observability.Collector.Exporters = []ragmev1.RAGmeCollectorExporter{{Name: "logs", Type: collectorExporterDebug}}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// managedComponents lists every component the operator can deploy. The
+// managed container of each component is named after the component.
+var managedComponents = []string{"api", "mcp", "agent", "frontend", "minio", "weaviate"}
+
+// componentSpec returns the customization for the given component
+func componentSpec(ragme *ragmev1.RAGme, component string) ragmev1.RAGmeComponentSpec {
+	switch component {
+	case "api":
+		return ragme.Spec.Components.API
+	case "mcp":
+		return ragme.Spec.Components.MCP
+	case "agent":
+		return ragme.Spec.Components.Agent
+	case "frontend":
+		return ragme.Spec.Components.Frontend
+	case "minio":
+		return ragme.Spec.Components.MinIO
+	case "weaviate":
+		return ragme.Spec.Components.Weaviate
+	}
+	return ragmev1.RAGmeComponentSpec{}
+}
+
+// applySidecars appends the sidecars configured for the component to the pod spec
+func applySidecars(podSpec *corev1.PodSpec, spec ragmev1.RAGmeComponentSpec) {
+	for i := range spec.Sidecars {
+		podSpec.Containers = append(podSpec.Containers, *spec.Sidecars[i].DeepCopy())
+	}
+}
+
+// applyTempStorage mounts an emptyDir volume sized and backed as configured for
+// the component at /tmp in the managed container. The container keeps its
+// image-provided /tmp when no temp storage is configured.
+func applyTempStorage(podSpec *corev1.PodSpec, spec ragmev1.RAGmeComponentSpec) {
+	tempStorage := spec.TempStorage
+	if tempStorage.SizeLimit == "" && tempStorage.Medium == "" {
+		return
+	}
+
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+	if tempStorage.Medium == "Memory" {
+		emptyDir.Medium = corev1.StorageMediumMemory
+	}
+	if tempStorage.SizeLimit != "" {
+		sizeLimit := resource.MustParse(tempStorage.SizeLimit)
+		emptyDir.SizeLimit = &sizeLimit
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         "tmp",
+		VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+	})
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name: "tmp", MountPath: "/tmp",
+	})
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{Name: "TMPDIR", Value: "/tmp"})
+}
+
+// mountVolume adds the volume to the pod and mounts it read-only at mountPath
+// in the managed container, which is always the first container of the pod
+func mountVolume(podSpec *corev1.PodSpec, volume corev1.Volume, mountPath string) {
+	podSpec.Volumes = append(podSpec.Volumes, volume)
+	podSpec.Containers[0].VolumeMounts = append(podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{
+		Name: volume.Name, MountPath: mountPath, ReadOnly: true,
+	})
+}
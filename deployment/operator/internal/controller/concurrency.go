@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// DefaultParallelism is the number of independent areas of an instance
+// reconciled at the same time
+const DefaultParallelism = 4
+
+// reconcileTask reconciles a part of an instance
+type reconcileTask func(ctx context.Context, ragme *ragmev1.RAGme) error
+
+// parallelism returns the number of tasks of an instance run at the same time
+func (r *RAGmeReconciler) parallelism() int {
+	if r.Parallelism > 0 {
+		return r.Parallelism
+	}
+	return DefaultParallelism
+}
+
+// reconcileConcurrently runs independent tasks, at most limit at a time, and
+// returns the error of the first failed task in order. As with an errgroup,
+// the tasks not started yet are skipped after a failure while the running
+// ones complete. Every task works on its own copy of the instance, and the
+// status changes of the copies are merged back in order once all are done.
+// A single task, or a limit of 1, runs the tasks one after the other on the
+// instance itself.
+func reconcileConcurrently(ctx context.Context, ragme *ragmev1.RAGme, limit int, tasks ...reconcileTask) error {
+	if len(tasks) == 1 || limit <= 1 {
+		for _, task := range tasks {
+			if err := task(ctx, ragme); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	base := ragme.Status.DeepCopy()
+	copies := make([]*ragmev1.RAGme, len(tasks))
+	errs := make([]error, len(tasks))
+	for i := range tasks {
+		copies[i] = ragme.DeepCopy()
+	}
+
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, limit)
+	for i, task := range tasks {
+		slots <- struct{}{}
+		if failed.Load() {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(i int, task reconcileTask) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if errs[i] = task(ctx, copies[i]); errs[i] != nil {
+				failed.Store(true)
+			}
+		}(i, task)
+	}
+	wg.Wait()
+
+	for _, copied := range copies {
+		mergeStatus(&ragme.Status, base, &copied.Status)
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeStatus applies to status the changes a task made from base to changed.
+// Conditions are merged by type and the other fields one by one, so tasks
+// writing different parts of the status do not overwrite each other.
+func mergeStatus(status, base, changed *ragmev1.RAGmeStatus) {
+	for _, condition := range changed.Conditions {
+		if previous := meta.FindStatusCondition(base.Conditions, condition.Type); previous == nil || !equality.Semantic.DeepEqual(*previous, condition) {
+			meta.SetStatusCondition(&status.Conditions, condition)
+		}
+	}
+	for _, condition := range base.Conditions {
+		if meta.FindStatusCondition(changed.Conditions, condition.Type) == nil {
+			meta.RemoveStatusCondition(&status.Conditions, condition.Type)
+		}
+	}
+
+	out := reflect.ValueOf(status).Elem()
+	mergeFields(out, reflect.ValueOf(base).Elem(), reflect.ValueOf(changed).Elem(), map[string]bool{"Conditions": true})
+}
+
+// mergeFields copies the fields changed from base into out, descending into
+// the status structs of the API
+func mergeFields(out, base, changed reflect.Value, skip map[string]bool) {
+	for i := 0; i < out.NumField(); i++ {
+		if skip[out.Type().Field(i).Name] {
+			continue
+		}
+		field := out.Field(i)
+		if field.Kind() == reflect.Struct && field.Type().PkgPath() == out.Type().PkgPath() {
+			mergeFields(field, base.Field(i), changed.Field(i), nil)
+			continue
+		}
+		if !equality.Semantic.DeepEqual(base.Field(i).Interface(), changed.Field(i).Interface()) {
+			field.Set(changed.Field(i))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestReconcileConcurrentlyBounded(t *testing.T) {
+	var running, peak atomic.Int32
+	task := func(context.Context, *ragmev1.RAGme) error {
+		n := running.Add(1)
+		for {
+			current := peak.Load()
+			if n <= current || peak.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+
+	tasks := []reconcileTask{task, task, task, task, task}
+	if err := reconcileConcurrently(context.Background(), harnessRAGme("bounded"), 2, tasks...); err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() != 2 {
+		t.Errorf("%d tasks ran at the same time, want the limit of 2", peak.Load())
+	}
+}
+
+func TestReconcileConcurrentlyMergesStatus(t *testing.T) {
+	ragme := harnessRAGme("merged")
+	conditions.SetTrue(&ragme.Status.Conditions, 1, conditions.TypeBlockedByImmutableField, conditions.ReasonImmutableFieldChanged, "blocked")
+	ragme.Status.Services.API.Ready = true
+
+	err := reconcileConcurrently(context.Background(), ragme, 2,
+		func(_ context.Context, ragme *ragmev1.RAGme) error {
+			ragme.Status.Storage.MinIO.UsedPercent = 90
+			conditions.SetTrue(&ragme.Status.Conditions, 1, conditions.TypeStorageAlmostFull, "Threshold", "85% used")
+			return nil
+		},
+		func(_ context.Context, ragme *ragmev1.RAGme) error {
+			ragme.Status.Storage.SharedVolume.UsedPercent = 20
+			conditions.Remove(&ragme.Status.Conditions, conditions.TypeBlockedByImmutableField)
+			return errors.New("weaviate unreachable")
+		},
+	)
+	if err == nil || err.Error() != "weaviate unreachable" {
+		t.Errorf("reconcileConcurrently() error = %v, want the failed task", err)
+	}
+
+	status := ragme.Status
+	if status.Storage.MinIO.UsedPercent != 90 || status.Storage.SharedVolume.UsedPercent != 20 || !status.Services.API.Ready {
+		t.Errorf("status %+v, want the changes of both tasks merged", status)
+	}
+	if meta.FindStatusCondition(status.Conditions, conditions.TypeStorageAlmostFull) == nil {
+		t.Error("condition set by a task lost")
+	}
+	if meta.FindStatusCondition(status.Conditions, conditions.TypeBlockedByImmutableField) != nil {
+		t.Error("condition removed by a task kept")
+	}
+}
+
+func TestReconcileConcurrentlySkipsAfterFailure(t *testing.T) {
+	var ran atomic.Int32
+	tasks := []reconcileTask{
+		func(context.Context, *ragmev1.RAGme) error {
+			ran.Add(1)
+			return errors.New("conflict")
+		},
+		func(context.Context, *ragmev1.RAGme) error {
+			ran.Add(1)
+			return nil
+		},
+	}
+	if err := reconcileConcurrently(context.Background(), harnessRAGme("serial"), 1, tasks...); err == nil {
+		t.Error("reconcileConcurrently() succeeded, want the failed task")
+	}
+	if ran.Load() != 1 {
+		t.Errorf("%d tasks ran, want the pass stopped at the failure", ran.Load())
+	}
+}
+
+func TestSubreconcilerStages(t *testing.T) {
+	var names [][]string
+	for _, stage := range subreconcilerStages((&RAGmeReconciler{}).subreconcilers()) {
+		var stageNames []string
+		for _, sub := range stage {
+			stageNames = append(stageNames, sub.Name())
+		}
+		names = append(names, stageNames)
+	}
+	// The object store and the vector database run once the volumes are in place
+	if len(names) < 3 || len(names[1]) != 1 || names[1][0] != "Storage" || len(names[2]) != 3 || names[2][1] != "VectorDB" {
+		t.Errorf("stages = %v, want the backends together after the storage", names)
+	}
+}
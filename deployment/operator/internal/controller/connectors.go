@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	dataSourceTypeConfluence = "confluence"
+	dataSourceTypeSharePoint = "sharepoint"
+	dataSourceTypeGDrive     = "gdrive"
+
+	// credentialsDir is where the credentials Secret is mounted in the sync worker
+	credentialsDir = "/credentials"
+)
+
+// connectorCredentialKeys are the keys the credentials Secret of each connector type must hold
+var connectorCredentialKeys = map[string][]string{
+	dataSourceTypeConfluence: {"username", "apiToken"},
+	dataSourceTypeSharePoint: {"tenantId", "clientId", "clientSecret"},
+	dataSourceTypeGDrive:     {"credentials.json"},
+}
+
+// isConnectorType reports whether a data source type syncs an enterprise document system
+func isConnectorType(sourceType string) bool {
+	_, ok := connectorCredentialKeys[sourceType]
+	return ok
+}
+
+// validateConnector checks the connector settings of a connector data source
+func validateConnector(sourceType string, connector *ragmev1.RAGmeConnectorSource) []error {
+	var errs []error
+	if connector == nil {
+		return []error{fmt.Errorf("connector: required with the %s type", sourceType)}
+	}
+	if connector.CredentialsSecretRef.Name == "" {
+		errs = append(errs, fmt.Errorf("connector.credentialsSecretRef.name: required"))
+	}
+	switch sourceType {
+	case dataSourceTypeConfluence, dataSourceTypeSharePoint:
+		if u, err := url.Parse(connector.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("connector.url: %q must be an https URL with the %s type", connector.URL, sourceType))
+		}
+	case dataSourceTypeGDrive:
+		if connector.URL != "" {
+			errs = append(errs, fmt.Errorf("connector.url: not used with the %s type", sourceType))
+		}
+	}
+	if len(connector.Spaces) > 0 && sourceType != dataSourceTypeConfluence {
+		errs = append(errs, fmt.Errorf("connector.spaces: only used with the %s type", dataSourceTypeConfluence))
+	}
+	if len(connector.Folders) > 0 && sourceType == dataSourceTypeConfluence {
+		errs = append(errs, fmt.Errorf("connector.folders: not used with the %s type, select spaces instead", sourceType))
+	}
+	for i, space := range connector.Spaces {
+		if space == "" || strings.ContainsAny(space, ", ") {
+			errs = append(errs, fmt.Errorf("connector.spaces[%d]: %q must be a space key", i, space))
+		}
+	}
+	for i, folder := range connector.Folders {
+		if folder == "" || strings.Contains(folder, ",") {
+			errs = append(errs, fmt.Errorf("connector.folders[%d]: %q must be a folder without commas", i, folder))
+		}
+	}
+	return errs
+}
+
+// connectorContainer returns the sync worker of a connector source. The
+// worker resumes from the cursor of the last successful sync, so only the
+// documents changed since are fetched, and writes its stats with the new
+// cursor as a JSON object to its termination message.
+func connectorContainer(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) corev1.Container {
+	connector := source.Spec.Connector
+	return corev1.Container{
+		Name:            "connector",
+		Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+		ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+		Command:         []string{"python", "-m", "src.ragme.connectors"},
+		Env: []corev1.EnvVar{
+			{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+			{Name: "CONNECTOR_TYPE", Value: source.Spec.Type},
+			{Name: "CONNECTOR_URL", Value: connector.URL},
+			{Name: "CONNECTOR_SPACES", Value: strings.Join(connector.Spaces, ",")},
+			{Name: "CONNECTOR_FOLDERS", Value: strings.Join(connector.Folders, ",")},
+			{Name: "CONNECTOR_CREDENTIALS_DIR", Value: credentialsDir},
+			{Name: "CONNECTOR_CURSOR", Value: source.Status.Cursor},
+			{Name: "SYNC_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "credentials", MountPath: credentialsDir, ReadOnly: true},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+}
+
+// credentialsVolumes returns the volume of the credentials Secret of a sync worker
+func credentialsVolumes(secretName string) []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: "credentials",
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretName,
+				},
+			},
+		},
+	}
+}
+
+// sourceCredentials returns the credentials Secret of a data source and the
+// keys it must hold, or no name when the source needs no credentials
+func sourceCredentials(source *ragmev1.RAGmeDataSource) (string, []string) {
+	switch {
+	case isConnectorType(source.Spec.Type) && source.Spec.Connector != nil:
+		return source.Spec.Connector.CredentialsSecretRef.Name, connectorCredentialKeys[source.Spec.Type]
+	case source.Spec.Type == dataSourceTypeEmail && source.Spec.Email != nil:
+		return source.Spec.Email.CredentialsSecretRef.Name, emailCredentialKeys[emailProtocol(source.Spec.Email)]
+	case source.Spec.Type == dataSourceTypeGit && source.Spec.Git != nil && source.Spec.Git.CredentialsSecretRef != nil:
+		return source.Spec.Git.CredentialsSecretRef.Name, gitCredentialKeys(source.Spec.Git)
+	}
+	return "", nil
+}
+
+// checkSourceCredentials checks the credentials Secret of a connector, email
+// or private git source holds the keys of its type, and reports it in the
+// CredentialsValid condition. The syncs are not scheduled until it does.
+func (r *RAGmeDataSourceReconciler) checkSourceCredentials(ctx context.Context, source *ragmev1.RAGmeDataSource) (bool, error) {
+	name, keys := sourceCredentials(source)
+	if name == "" {
+		conditions.Remove(&source.Status.Conditions, conditions.TypeCredentialsValid)
+		return true, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: source.Namespace}, secret); err != nil {
+		if !errors.IsNotFound(err) {
+			return false, err
+		}
+		conditions.SetFalse(&source.Status.Conditions, source.Generation, conditions.TypeCredentialsValid,
+			conditions.ReasonCredentialsMissing, fmt.Sprintf("Secret %s not found", name))
+		return false, nil
+	}
+	if missing := missingCredentialKeys(keys, secret); len(missing) > 0 {
+		conditions.SetFalse(&source.Status.Conditions, source.Generation, conditions.TypeCredentialsValid,
+			conditions.ReasonCredentialsMissing, fmt.Sprintf("Secret %s is missing the keys %s", name, strings.Join(missing, ", ")))
+		return false, nil
+	}
+	conditions.SetTrue(&source.Status.Conditions, source.Generation, conditions.TypeCredentialsValid,
+		conditions.ReasonCredentialsFound, fmt.Sprintf("Secret %s holds the %s credentials", name, source.Spec.Type))
+	return true, nil
+}
+
+// missingCredentialKeys returns the keys the Secret does not hold, or holds empty
+func missingCredentialKeys(keys []string, secret *corev1.Secret) []string {
+	var missing []string
+	for _, key := range keys {
+		if len(secret.Data[key]) == 0 && secret.StringData[key] == "" {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// connectorReport is the JSON object of the stats a connector writes when it finishes
+type connectorReport struct {
+	DocumentsAdded   int64   `json:"documentsAdded"`
+	DocumentsUpdated int64   `json:"documentsUpdated"`
+	DocumentsDeleted int64   `json:"documentsDeleted"`
+	Errors           int64   `json:"errors"`
+	DurationSeconds  float64 `json:"durationSeconds"`
+	Cursor           string  `json:"cursor"`
+}
+
+// parseConnectorStats parses the stats of a connector sync and the cursor it stopped at
+func parseConnectorStats(message string) (ragmev1.RAGmeConnectorStats, string, error) {
+	report := connectorReport{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return ragmev1.RAGmeConnectorStats{}, "", fmt.Errorf("invalid connector stats %q: %w", message, err)
+	}
+	return ragmev1.RAGmeConnectorStats{
+		DocumentsAdded:   report.DocumentsAdded,
+		DocumentsUpdated: report.DocumentsUpdated,
+		DocumentsDeleted: report.DocumentsDeleted,
+		Errors:           report.Errors,
+		Duration:         (time.Duration(report.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+	}, report.Cursor, nil
+}
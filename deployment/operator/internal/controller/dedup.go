@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	dedupStrategyHash     = "hash"
+	dedupStrategySemantic = "semantic"
+
+	defaultDedupSimilarityThreshold = 0.95
+
+	dedupDocumentsCheckedMetric = "ragme_dedup_documents_checked_total"
+	dedupHitsMetric             = "ragme_dedup_hits_total"
+)
+
+// dedupConfig is the de-duplication stage of the processing pipeline. The
+// api checks every document it ingests, from the agent, the connectors and
+// the crawler alike.
+type dedupConfig struct {
+	Strategy            string  `json:"strategy"`
+	SimilarityThreshold float64 `json:"similarityThreshold,omitempty"`
+}
+
+// dedupStrategy returns the strategy recognizing duplicates
+func dedupStrategy(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.Processing.Dedup.Strategy != "" {
+		return ragme.Spec.Processing.Dedup.Strategy
+	}
+	return dedupStrategyHash
+}
+
+// renderDedupConfig returns the de-duplication stage of the processing
+// configuration, or nil when de-duplication is disabled
+func renderDedupConfig(ragme *ragmev1.RAGme) *dedupConfig {
+	dedup := ragme.Spec.Processing.Dedup
+	if !dedup.Enabled {
+		return nil
+	}
+	config := &dedupConfig{Strategy: dedupStrategy(ragme)}
+	if config.Strategy == dedupStrategySemantic {
+		config.SimilarityThreshold = defaultDedupSimilarityThreshold
+		if threshold, err := strconv.ParseFloat(dedup.SimilarityThreshold, 64); err == nil && threshold > 0 {
+			config.SimilarityThreshold = threshold
+		}
+	}
+	return config
+}
+
+// checkDedup reads the de-duplication counters from the api and records them in status
+func (r *RAGmeReconciler) checkDedup(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Processing.Dedup.Enabled {
+		ragme.Status.Ingestion.Dedup = ragmev1.RAGmeDedupStatus{}
+		return nil
+	}
+	// Nothing is ingested while the api is scaled to zero
+	if ragme.Status.Hibernation.Hibernated {
+		return nil
+	}
+
+	samples, err := r.scrapeURL(ctx, operationalURL(ragme, "api", 8021, "/metrics"),
+		dedupDocumentsCheckedMetric, dedupHitsMetric)
+	if err != nil {
+		return fmt.Errorf("dedup counters: %w", err)
+	}
+	ragme.Status.Ingestion.Dedup = dedupStatus(samples)
+	return nil
+}
+
+// dedupStatus returns the de-duplication counters reported by the api, the
+// hits split by the strategy that recognized them
+func dedupStatus(samples []promSample) ragmev1.RAGmeDedupStatus {
+	status := ragmev1.RAGmeDedupStatus{
+		DocumentsChecked: int64(sumSamples(samples, dedupDocumentsCheckedMetric, nil)),
+		Hits:             int64(sumSamples(samples, dedupHitsMetric, nil)),
+		HashHits:         int64(sumSamples(samples, dedupHitsMetric, map[string]string{"strategy": dedupStrategyHash})),
+		SemanticHits:     int64(sumSamples(samples, dedupHitsMetric, map[string]string{"strategy": dedupStrategySemantic})),
+	}
+	if status.DocumentsChecked > 0 {
+		status.HitRatePercent = int32(status.Hits * 100 / status.DocumentsChecked)
+	}
+	return status
+}
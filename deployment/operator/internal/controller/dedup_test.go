@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderDedupConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if config := renderProcessingConfig(ragme); config.Dedup != nil {
+		t.Errorf("dedup = %+v, want none while disabled", config.Dedup)
+	}
+
+	ragme.Spec.Processing.Dedup = ragmev1.RAGmeDedup{Enabled: true}
+	if config := renderDedupConfig(ragme); config.Strategy != dedupStrategyHash || config.SimilarityThreshold != 0 {
+		t.Errorf("dedup = %+v, want the hash strategy", config)
+	}
+
+	ragme.Spec.Processing.Dedup.Strategy = dedupStrategySemantic
+	if config := renderDedupConfig(ragme); config.SimilarityThreshold != defaultDedupSimilarityThreshold {
+		t.Errorf("dedup = %+v, want the default threshold", config)
+	}
+	ragme.Spec.Processing.Dedup.SimilarityThreshold = "0.9"
+	if config := renderDedupConfig(ragme); config.SimilarityThreshold != 0.9 {
+		t.Errorf("dedup = %+v, want the configured threshold", config)
+	}
+}
+
+func TestValidateSpecDedup(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.Dedup = ragmev1.RAGmeDedup{Enabled: true, Strategy: "fuzzy"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an unsupported strategy")
+	}
+	ragme.Spec.Processing.Dedup = ragmev1.RAGmeDedup{Enabled: true, SimilarityThreshold: "0.9"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a threshold with the hash strategy")
+	}
+	ragme.Spec.Processing.Dedup = ragmev1.RAGmeDedup{Enabled: true, Strategy: dedupStrategySemantic, SimilarityThreshold: "1.5"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a threshold above 1")
+	}
+	ragme.Spec.Processing.Dedup.SimilarityThreshold = "0.97"
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
+
+func TestDedupStatus(t *testing.T) {
+	samples := []promSample{
+		{Name: dedupDocumentsCheckedMetric, Value: 200},
+		{Name: dedupHitsMetric, Labels: map[string]string{"strategy": dedupStrategyHash}, Value: 40},
+		{Name: dedupHitsMetric, Labels: map[string]string{"strategy": dedupStrategySemantic}, Value: 10},
+	}
+	want := ragmev1.RAGmeDedupStatus{DocumentsChecked: 200, Hits: 50, HashHits: 40, SemanticHits: 10, HitRatePercent: 25}
+	if status := dedupStatus(samples); status != want {
+		t.Errorf("dedupStatus() = %+v, want %+v", status, want)
+	}
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	driftPolicyCorrect = "Correct"
+	driftPolicyWarn    = "Warn"
+)
+
+// driftDetected counts the out-of-band edits the drift audit found
+var driftDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "ragme_drift_detected_total",
+	Help: "Managed resources of a RAGme instance found edited outside of the operator",
+}, []string{"namespace", "name", "kind"})
+
+func init() {
+	metrics.Registry.MustRegister(driftDetected)
+}
+
+// forgetDrift withdraws the drift series of an instance
+func forgetDrift(namespace, name string) {
+	driftDetected.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
+
+// auditedServices returns the Services the instance can generate. The
+// Services are only created by their areas, so an edit of a live Service
+// persists until the audit reverts it. Deployments and ConfigMaps are
+// rewritten on every pass and are not audited.
+func (r *RAGmeReconciler) auditedServices(ragme *ragmev1.RAGme) []*corev1.Service {
+	services := []*corev1.Service{
+		r.createMinIOService(ragme),
+		r.createRedisService(ragme),
+		createCacheRedisService(ragme, "embeddings-cache"),
+		createCacheRedisService(ragme, "query-cache"),
+		createAPIPathService(ragme, apiReadComponent, componentLabels(ragme, apiReadComponent)),
+		createAPIPathService(ragme, "api-write", componentLabels(ragme, "api")),
+		createCollectorService(ragme),
+		createEgressProxyService(ragme),
+		createOAuth2ProxyService(ragme),
+		createS3GatewayService(ragme),
+	}
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		services = append(services, r.createWeaviateService(ragme, shard))
+	}
+	for _, serviceName := range []string{"api", "mcp", "frontend"} {
+		services = append(services, r.createRAGmeService(ragme, serviceName))
+	}
+	for _, component := range profiledComponents {
+		services = append(services, createProfilingService(ragme, component))
+	}
+	if adminPort(ragme) != 0 {
+		for _, serviceName := range adminServices {
+			services = append(services, createAdminService(ragme, serviceName))
+		}
+	}
+	return services
+}
+
+// serviceDrift returns the significant fields of a live Service that differ
+// from the desired one. Fields the API server defaults count as set to their
+// default.
+func serviceDrift(found, desired *corev1.Service) []string {
+	var fields []string
+	if serviceType(found) != serviceType(desired) {
+		fields = append(fields, "spec.type")
+	}
+	if !reflect.DeepEqual(normalizedPorts(found.Spec.Ports), normalizedPorts(desired.Spec.Ports)) {
+		fields = append(fields, "spec.ports")
+	}
+	if !reflect.DeepEqual(found.Spec.Selector, desired.Spec.Selector) {
+		fields = append(fields, "spec.selector")
+	}
+	for key, value := range desired.Labels {
+		if found.Labels[key] != value {
+			fields = append(fields, "metadata.labels")
+			break
+		}
+	}
+	return fields
+}
+
+func serviceType(service *corev1.Service) corev1.ServiceType {
+	if service.Spec.Type == "" {
+		return corev1.ServiceTypeClusterIP
+	}
+	return service.Spec.Type
+}
+
+// normalizedPorts returns the ports with the defaults of the API server
+// applied and the allocated node ports cleared
+func normalizedPorts(ports []corev1.ServicePort) []corev1.ServicePort {
+	normalized := make([]corev1.ServicePort, len(ports))
+	for i, port := range ports {
+		port.NodePort = 0
+		if port.Protocol == "" {
+			port.Protocol = corev1.ProtocolTCP
+		}
+		if port.TargetPort.Type == intstr.Int && port.TargetPort.IntVal == 0 {
+			port.TargetPort = intstr.FromInt(int(port.Port))
+		}
+		normalized[i] = port
+	}
+	return normalized
+}
+
+// auditDrift compares the live Services of the instance with the desired ones.
+// Drifted Services are reverted with the Correct policy and only reported
+// with Warn. Either way the Drifted condition and the drift metric record the
+// edits. Services released with the break-glass annotation are skipped.
+func (r *RAGmeReconciler) auditDrift(ctx context.Context, ragme *ragmev1.RAGme) error {
+	logger := log.FromContext(ctx)
+	warnOnly := ragme.Spec.DriftPolicy == driftPolicyWarn
+
+	var drifted []string
+	for _, desired := range r.auditedServices(ragme) {
+		found := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, found)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(found, ragme) || breakGlass(found) {
+			continue
+		}
+
+		fields := serviceDrift(found, desired)
+		if len(fields) == 0 {
+			continue
+		}
+		driftDetected.WithLabelValues(ragme.Namespace, ragme.Name, "Service").Inc()
+		drifted = append(drifted, fmt.Sprintf("Service %s (%s)", found.Name, strings.Join(fields, ", ")))
+		logger.Info("Managed resource drifted from the desired state", "kind", "Service", "resource", found.Name,
+			"fields", fields, "policy", ragme.Spec.DriftPolicy)
+		if warnOnly {
+			continue
+		}
+
+		found.Spec.Type = desired.Spec.Type
+		found.Spec.Ports = desired.Spec.Ports
+		found.Spec.Selector = desired.Spec.Selector
+		found.Labels = mergeStringMaps(found.Labels, desired.Labels)
+		if err := r.Update(ctx, found); err != nil {
+			return fmt.Errorf("failed to correct the drift of Service %s: %w", found.Name, err)
+		}
+	}
+
+	sort.Strings(drifted)
+	switch {
+	case len(drifted) == 0:
+		// Keep the report of the last correction
+		if condition := meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeDrifted); condition != nil && condition.Status == metav1.ConditionFalse {
+			return nil
+		}
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDrifted,
+			conditions.ReasonNoDrift, "Managed resources match the desired state")
+	case warnOnly:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDrifted,
+			conditions.ReasonDriftDetected, "Edited outside of the operator, set spec.driftPolicy to Correct to revert: "+strings.Join(drifted, "; "))
+	default:
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDrifted,
+			conditions.ReasonDriftCorrected, "Reverted edits made outside of the operator: "+strings.Join(drifted, "; "))
+	}
+	return nil
+}
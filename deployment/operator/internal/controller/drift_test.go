@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// editService changes the port of the api Service the way kubectl edit would
+func editService(t *testing.T, h *reconcilerHarness, name string) {
+	t.Helper()
+	service := &corev1.Service{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: name}, service); err != nil {
+		t.Fatal(err)
+	}
+	service.Spec.Type = corev1.ServiceTypeNodePort
+	service.Spec.Ports[0].TargetPort = intstr.FromInt(9999)
+	if err := h.client.Update(h.ctx, service); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func driftCount(t *testing.T, name string) float64 {
+	t.Helper()
+	metric := &dto.Metric{}
+	if err := driftDetected.WithLabelValues("bench", name, "Service").Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestServiceDriftIgnoresDefaults(t *testing.T) {
+	desired := (&RAGmeReconciler{}).createRAGmeService(harnessRAGme("test"), "api")
+	live := desired.DeepCopy()
+	live.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	live.Spec.ClusterIP = "10.0.0.12"
+	live.Labels = mergeStringMaps(live.Labels, map[string]string{teamLabel: "search"})
+	if fields := serviceDrift(live, desired); len(fields) != 0 {
+		t.Errorf("serviceDrift() = %v, want no drift for the defaulted fields", fields)
+	}
+
+	live.Spec.Selector = map[string]string{"app": "other"}
+	if fields := serviceDrift(live, desired); len(fields) != 1 || fields[0] != "spec.selector" {
+		t.Errorf("serviceDrift() = %v, want spec.selector", fields)
+	}
+}
+
+func TestAuditDriftCorrects(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("drift"))
+	h.reconcile(t, "drift")
+	before := driftCount(t, "drift")
+
+	editService(t, h, "drift-api")
+	h.reconcile(t, "drift")
+
+	service := &corev1.Service{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "drift-api"}, service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Type != corev1.ServiceTypeClusterIP || service.Spec.Ports[0].TargetPort.IntValue() != 8021 {
+		t.Errorf("Service = %+v, want the edit reverted", service.Spec)
+	}
+	if got := driftCount(t, "drift"); got != before+1 {
+		t.Errorf("ragme_drift_detected_total = %v, want %v", got, before+1)
+	}
+	condition := meta.FindStatusCondition(h.get(t, "drift").Status.Conditions, conditions.TypeDrifted)
+	if condition == nil || condition.Reason != conditions.ReasonDriftCorrected || !strings.Contains(condition.Message, "drift-api") {
+		t.Errorf("Drifted condition = %+v, want the correction of drift-api", condition)
+	}
+}
+
+func TestAuditDriftWarns(t *testing.T) {
+	ragme := harnessRAGme("warn")
+	ragme.Spec.DriftPolicy = driftPolicyWarn
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "warn")
+
+	editService(t, h, "warn-api")
+	h.reconcile(t, "warn")
+
+	service := &corev1.Service{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "warn-api"}, service); err != nil {
+		t.Fatal(err)
+	}
+	if service.Spec.Type != corev1.ServiceTypeNodePort {
+		t.Errorf("Service type = %s, want the edit kept with the Warn policy", service.Spec.Type)
+	}
+	if !conditions.IsTrue(h.get(t, "warn").Status.Conditions, conditions.TypeDrifted) {
+		t.Error("Drifted condition not set with the Warn policy")
+	}
+}
@@ -0,0 +1,279 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	// DryRunAnnotation makes the reconciler publish the changes it would
+	// apply instead of applying them
+	DryRunAnnotation = "ragme.io/dry-run"
+
+	dryRunChangesKey = "changes.json"
+)
+
+// plannedChange is a write the reconciler would have issued
+type plannedChange struct {
+	Action string   `json:"action"`
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// recordingClient sends all writes as server-side dry runs and records the
+// ones that would change the cluster
+type recordingClient struct {
+	client.Client
+	live    client.Client
+	changes []plannedChange
+}
+
+func newRecordingClient(c client.Client) *recordingClient {
+	return &recordingClient{Client: client.NewDryRunClient(c), live: c}
+}
+
+func (c *recordingClient) record(action string, obj client.Object, fields []string) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+	c.changes = append(c.changes, plannedChange{Action: action, Kind: kind, Name: obj.GetName(), Fields: fields})
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	if err := c.Client.Create(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.record("create", obj, nil)
+	return nil
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	live := obj.DeepCopyObject().(client.Object)
+	if err := c.live.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		return err
+	}
+	if err := c.Client.Update(ctx, obj, opts...); err != nil {
+		return err
+	}
+	fields, err := changedFields(live, obj)
+	if err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		c.record("update", obj, fields)
+	}
+	return nil
+}
+
+func (c *recordingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	c.record("patch", obj, nil)
+	return nil
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	c.record("delete", obj, nil)
+	return nil
+}
+
+// dryRunTransport answers mutating HTTP requests locally and records them, so
+// dry runs leave external services such as the vector database untouched
+type dryRunTransport struct {
+	base     http.RoundTripper
+	recorder *recordingClient
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+	t.recorder.changes = append(t.recorder.changes, plannedChange{
+		Action: strings.ToLower(req.Method), Kind: "HTTP", Name: req.URL.String(),
+	})
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+// dryRunHTTPClient returns a copy of c that only sends read requests
+func dryRunHTTPClient(c *http.Client, recorder *recordingClient) *http.Client {
+	dryRun := *defaultHTTPClient(c)
+	base := dryRun.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	dryRun.Transport = &dryRunTransport{base: base, recorder: recorder}
+	return &dryRun
+}
+
+// changedFields returns the metadata and spec fields that differ between the
+// live object and the dry-run result, ignoring server-managed metadata and status
+func changedFields(live, updated runtime.Object) ([]string, error) {
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(live)
+	if err != nil {
+		return nil, err
+	}
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(updated)
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range []map[string]interface{}{before, after} {
+		delete(obj, "status")
+		if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+			for _, field := range []string{"resourceVersion", "generation", "managedFields", "creationTimestamp", "uid"} {
+				delete(metadata, field)
+			}
+		}
+	}
+	return diffKeys("", before, after, 2), nil
+}
+
+// diffKeys lists the keys whose values differ, descending into nested maps up to depth
+func diffKeys(prefix string, a, b map[string]interface{}, depth int) []string {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	var diff []string
+	for k := range keys {
+		if reflect.DeepEqual(a[k], b[k]) {
+			continue
+		}
+		path := prefix + k
+		nestedA, okA := a[k].(map[string]interface{})
+		nestedB, okB := b[k].(map[string]interface{})
+		if depth > 1 && okA && okB {
+			diff = append(diff, diffKeys(path+".", nestedA, nestedB, depth-1)...)
+			continue
+		}
+		diff = append(diff, path)
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+// dryRunRequested reports whether the instance asks for a dry run
+func dryRunRequested(ragme *ragmev1.RAGme) bool {
+	return ragme.Annotations[DryRunAnnotation] == "true"
+}
+
+// reconcileDryRun computes the changes a reconciliation would apply and
+// publishes them in the <instance>-dry-run ConfigMap and the DryRun condition
+func (r *RAGmeReconciler) reconcileDryRun(ctx context.Context, ragme, stored *ragmev1.RAGme) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	recorder := newRecordingClient(r.Client)
+	dryRun := *r
+	dryRun.Client = recorder
+	dryRun.HTTPClient = dryRunHTTPClient(r.HTTPClient, recorder)
+	// Record the changes in the order of the areas
+	dryRun.Parallelism = 1
+	if err := dryRun.reconcileComponents(ctx, ragme.DeepCopy()); err != nil {
+		logger.Error(err, "Dry run failed")
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDryRun, conditions.ReasonDryRunFailed, err.Error())
+		if statusErr := r.patchStatus(ctx, ragme, stored); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGme status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, err
+	}
+
+	data, err := json.MarshalIndent(recorder.changes, "", "  ")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-dry-run", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{dryRunChangesKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		err = r.Create(ctx, configMap)
+	} else if err == nil && found.Data[dryRunChangesKey] != configMap.Data[dryRunChangesKey] {
+		found.Data = configMap.Data
+		err = r.Update(ctx, found)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if n := len(recorder.changes); n > 0 {
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDryRun, conditions.ReasonChangesPending,
+			fmt.Sprintf("%d changes would be applied, see ConfigMap %s", n, configMap.Name))
+	} else {
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDryRun, conditions.ReasonNoChanges,
+			"The cluster matches the spec")
+	}
+	if err := r.patchStatus(ctx, ragme, stored); err != nil {
+		logger.Error(err, "Failed to update RAGme status")
+		return ctrl.Result{}, err
+	}
+
+	logger.Info("Dry run completed", "changes", len(recorder.changes))
+	return r.Resync.Result(ragme), nil
+}
+
+// cleanupDryRun removes the dry-run results once the annotation is removed
+func (r *RAGmeReconciler) cleanupDryRun(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeDryRun) == nil {
+		return nil
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-dry-run", ragme.Name),
+			Namespace: ragme.Namespace,
+		},
+	}
+	if err := r.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	conditions.Remove(&ragme.Status.Conditions, conditions.TypeDryRun)
+	return nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestChangedFields(t *testing.T) {
+	live := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "ragme-api",
+			ResourceVersion: "1",
+			Generation:      1,
+			Annotations:     map[string]string{"team": "search"},
+		},
+		Spec: appsv1.DeploymentSpec{Replicas: &[]int32{2}[0]},
+	}
+
+	unchanged := live.DeepCopy()
+	unchanged.ResourceVersion = "2"
+	unchanged.Status.ReadyReplicas = 2
+	if fields, err := changedFields(live, unchanged); err != nil || len(fields) != 0 {
+		t.Errorf("expected no changes, got %v (err %v)", fields, err)
+	}
+
+	updated := live.DeepCopy()
+	updated.Generation = 2
+	updated.Spec.Replicas = &[]int32{3}[0]
+	updated.Annotations["owner"] = "platform"
+	fields, err := changedFields(live, updated)
+	if err != nil {
+		t.Fatalf("changedFields failed: %v", err)
+	}
+	want := []string{"metadata.annotations", "spec.replicas"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("changedFields() = %v, want %v", fields, want)
+	}
+}
+
+func TestDryRunHTTPClient(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recorder := &recordingClient{}
+	httpClient := dryRunHTTPClient(server.Client(), recorder)
+
+	resp, err := httpClient.Get(server.URL + "/v1/meta")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = httpClient.Post(server.URL+"/v1/backups/filesystem", "application/json", strings.NewReader(`{"id":"b"}`))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if !reflect.DeepEqual(methods, []string{http.MethodGet}) {
+		t.Errorf("server received %v, want only the GET", methods)
+	}
+	want := []plannedChange{{Action: "post", Kind: "HTTP", Name: server.URL + "/v1/backups/filesystem"}}
+	if !reflect.DeepEqual(recorder.changes, want) {
+		t.Errorf("recorded %v, want %v", recorder.changes, want)
+	}
+}
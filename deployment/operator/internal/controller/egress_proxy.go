@@ -0,0 +1,425 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultEgressProxyImage = "envoyproxy/envoy:v1.30.2"
+
+	egressProxyModeSidecar = "Sidecar"
+	egressProxyModeShared  = "Shared"
+
+	egressProxyPort           = 10000
+	egressProxyConfigKey      = "envoy.json"
+	egressProxyConfigPath     = "/etc/envoy"
+	egressProxyHashAnnotation = "ragme.io/egress-proxy-hash"
+
+	// egressProxyCABundle is the system CA bundle of the Envoy image, used to
+	// verify the upstream certificates
+	egressProxyCABundle = "/etc/ssl/certs/ca-certificates.crt"
+)
+
+// defaultEgressUpstreams are the LLM providers allowlisted when spec.egress.proxy lists no upstreams
+var defaultEgressUpstreams = []ragmev1.RAGmeEgressUpstream{
+	{Name: "openai", Host: "api.openai.com", BaseURLEnv: "OPENAI_BASE_URL", BasePath: "/v1"},
+	{Name: "friendli", Host: "api.friendli.ai", BaseURLEnv: "FRIENDLI_BASE_URL", BasePath: "/serverless/v1"},
+}
+
+// egressProxyMode returns the mode of the egress proxy, or "" when it is disabled
+func egressProxyMode(ragme *ragmev1.RAGme) string {
+	proxy := ragme.Spec.Egress.Proxy
+	if !proxy.Enabled {
+		return ""
+	}
+	if proxy.Mode == "" {
+		return egressProxyModeSidecar
+	}
+	return proxy.Mode
+}
+
+// egressUpstreams returns the allowlisted upstreams with their default port
+func egressUpstreams(ragme *ragmev1.RAGme) []ragmev1.RAGmeEgressUpstream {
+	upstreams := ragme.Spec.Egress.Proxy.Upstreams
+	if len(upstreams) == 0 {
+		upstreams = defaultEgressUpstreams
+	}
+
+	resolved := make([]ragmev1.RAGmeEgressUpstream, len(upstreams))
+	copy(resolved, upstreams)
+	for i := range resolved {
+		if resolved[i].Port == 0 {
+			resolved[i].Port = 443
+		}
+	}
+	return resolved
+}
+
+// egressProxyName returns the name of the ConfigMap, Deployment and Service of the egress proxy
+func egressProxyName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-egress-proxy", ragme.Name)
+}
+
+// renderEgressProxyConfig returns the Envoy bootstrap configuration and its
+// hash. Each upstream is served under /<name>/ and reached over TLS verified
+// against its host name; any other request is rejected.
+func renderEgressProxyConfig(ragme *ragmev1.RAGme) (string, string, error) {
+	// A sidecar is only reachable from its own pod
+	address := "127.0.0.1"
+	if egressProxyMode(ragme) == egressProxyModeShared {
+		address = "0.0.0.0"
+	}
+
+	var routes, clusters []interface{}
+	for _, upstream := range egressUpstreams(ragme) {
+		routes = append(routes, map[string]interface{}{
+			"match": map[string]interface{}{"prefix": "/" + upstream.Name + "/"},
+			"route": map[string]interface{}{
+				"cluster":              upstream.Name,
+				"prefix_rewrite":       "/",
+				"host_rewrite_literal": upstream.Host,
+				// Completions are streamed for minutes
+				"timeout": "300s",
+			},
+		})
+		clusters = append(clusters, map[string]interface{}{
+			"name":              upstream.Name,
+			"type":              "LOGICAL_DNS",
+			"dns_lookup_family": "V4_PREFERRED",
+			"connect_timeout":   "10s",
+			"load_assignment": map[string]interface{}{
+				"cluster_name": upstream.Name,
+				"endpoints": []interface{}{map[string]interface{}{
+					"lb_endpoints": []interface{}{map[string]interface{}{
+						"endpoint": map[string]interface{}{"address": socketAddress(upstream.Host, upstream.Port)},
+					}},
+				}},
+			},
+			"transport_socket": map[string]interface{}{
+				"name": "envoy.transport_sockets.tls",
+				"typed_config": map[string]interface{}{
+					"@type": "type.googleapis.com/envoy.extensions.transport_sockets.tls.v3.UpstreamTlsContext",
+					"sni":   upstream.Host,
+					"common_tls_context": map[string]interface{}{
+						"validation_context": map[string]interface{}{
+							"trusted_ca": map[string]interface{}{"filename": egressProxyCABundle},
+							"match_typed_subject_alt_names": []interface{}{map[string]interface{}{
+								"san_type": "DNS",
+								"matcher":  map[string]interface{}{"exact": upstream.Host},
+							}},
+						},
+					},
+				},
+			},
+		})
+	}
+	routes = append(routes, map[string]interface{}{
+		"match": map[string]interface{}{"prefix": "/"},
+		"direct_response": map[string]interface{}{
+			"status": 403,
+			"body":   map[string]interface{}{"inline_string": "destination is not allowlisted by the egress proxy\n"},
+		},
+	})
+
+	config := map[string]interface{}{
+		"static_resources": map[string]interface{}{
+			"listeners": []interface{}{map[string]interface{}{
+				"name":    "egress",
+				"address": socketAddress(address, egressProxyPort),
+				"filter_chains": []interface{}{map[string]interface{}{
+					"filters": []interface{}{map[string]interface{}{
+						"name": "envoy.filters.network.http_connection_manager",
+						"typed_config": map[string]interface{}{
+							"@type":       "type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager",
+							"stat_prefix": "egress",
+							"route_config": map[string]interface{}{
+								"virtual_hosts": []interface{}{map[string]interface{}{
+									"name":    "upstreams",
+									"domains": []string{"*"},
+									"routes":  routes,
+								}},
+							},
+							"http_filters": []interface{}{map[string]interface{}{
+								"name": "envoy.filters.http.router",
+								"typed_config": map[string]interface{}{
+									"@type": "type.googleapis.com/envoy.extensions.filters.http.router.v3.Router",
+								},
+							}},
+						},
+					}},
+				}},
+			}},
+			"clusters": clusters,
+		},
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:])[:8], nil
+}
+
+// socketAddress returns an Envoy socket address
+func socketAddress(address string, port int32) map[string]interface{} {
+	return map[string]interface{}{
+		"socket_address": map[string]interface{}{"address": address, "port_value": port},
+	}
+}
+
+// egressProxyURL returns the URL the services reach the proxy at
+func egressProxyURL(ragme *ragmev1.RAGme) string {
+	if egressProxyMode(ragme) == egressProxyModeShared {
+		return fmt.Sprintf("http://%s:%d", egressProxyName(ragme), egressProxyPort)
+	}
+	return fmt.Sprintf("http://127.0.0.1:%d", egressProxyPort)
+}
+
+// reconcileEgressProxy renders the Envoy configuration and runs the Shared
+// proxy. Resources of a disabled proxy, or of another mode, are removed.
+func (r *RAGmeReconciler) reconcileEgressProxy(ctx context.Context, ragme *ragmev1.RAGme) error {
+	mode := egressProxyMode(ragme)
+	deployment := createEgressProxyDeployment(ragme)
+	service := createEgressProxyService(ragme)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      egressProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+	}
+
+	var stale []client.Object
+	switch mode {
+	case "":
+		stale = []client.Object{deployment, service, configMap}
+	case egressProxyModeSidecar:
+		stale = []client.Object{deployment, service}
+	}
+	for _, obj := range stale {
+		if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	if mode == "" {
+		return nil
+	}
+
+	config, _, err := renderEgressProxyConfig(ragme)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{egressProxyConfigKey: config}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if found.Data[egressProxyConfigKey] != config {
+		found.Data = configMap.Data
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	if mode != egressProxyModeShared {
+		return nil
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// egressProxyContainer returns the Envoy container serving the rendered configuration
+func egressProxyContainer(ragme *ragmev1.RAGme) corev1.Container {
+	image := ragme.Spec.Egress.Proxy.Image
+	if image == "" {
+		image = defaultEgressProxyImage
+	}
+
+	return corev1.Container{
+		Name:  "egress-proxy",
+		Image: image,
+		Args:  []string{"-c", egressProxyConfigPath + "/" + egressProxyConfigKey, "--log-level", "warn"},
+		Ports: []corev1.ContainerPort{
+			{ContainerPort: egressProxyPort, Name: "egress"},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(egressProxyPort)},
+			},
+			PeriodSeconds: 5,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "egress-proxy", MountPath: egressProxyConfigPath, ReadOnly: true},
+		},
+	}
+}
+
+// egressProxyVolume returns the volume of the rendered Envoy configuration
+func egressProxyVolume(ragme *ragmev1.RAGme) corev1.Volume {
+	return corev1.Volume{
+		Name: "egress-proxy",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: egressProxyName(ragme)},
+			},
+		},
+	}
+}
+
+// applyEgressProxy points the provider base URLs of the api, mcp and agent at
+// the egress proxy, adding the Envoy sidecar in Sidecar mode. Envoy does not
+// reload a static configuration, so its hash on the pod template rolls the
+// pods when the upstreams change.
+func applyEgressProxy(ragme *ragmev1.RAGme, serviceName string, template *corev1.PodTemplateSpec) {
+	mode := egressProxyMode(ragme)
+	if mode == "" || serviceName == "frontend" {
+		return
+	}
+
+	podSpec := &template.Spec
+	proxyURL := egressProxyURL(ragme)
+	for _, upstream := range egressUpstreams(ragme) {
+		if upstream.BaseURLEnv == "" {
+			continue
+		}
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: upstream.BaseURLEnv, Value: fmt.Sprintf("%s/%s%s", proxyURL, upstream.Name, upstream.BasePath),
+		})
+	}
+
+	if mode != egressProxyModeSidecar {
+		return
+	}
+	podSpec.Containers = append(podSpec.Containers, egressProxyContainer(ragme))
+	podSpec.Volumes = append(podSpec.Volumes, egressProxyVolume(ragme))
+	if _, hash, err := renderEgressProxyConfig(ragme); err == nil {
+		template.Annotations = mergeStringMaps(template.Annotations, map[string]string{
+			egressProxyHashAnnotation: hash,
+		})
+	}
+}
+
+// createEgressProxyDeployment returns the Deployment of the Shared egress proxy
+func createEgressProxyDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "egress-proxy",
+		"instance":  ragme.Name,
+	}
+
+	replicas := ragme.Spec.Egress.Proxy.Replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+	replicas = hibernationReplicas(ragme, replicas)
+
+	annotations := map[string]string{}
+	if _, hash, err := renderEgressProxyConfig(ragme); err == nil {
+		annotations[egressProxyHashAnnotation] = hash
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      egressProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{egressProxyContainer(ragme)},
+					Volumes:    []corev1.Volume{egressProxyVolume(ragme)},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createEgressProxyService returns the Service of the Shared egress proxy
+func createEgressProxyService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "egress-proxy",
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      egressProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "egress", Port: egressProxyPort, TargetPort: intstr.FromInt(egressProxyPort)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
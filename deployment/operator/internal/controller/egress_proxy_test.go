@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyEgressProxy(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo"}}
+	ragme.Spec.Egress.Proxy.Enabled = true
+
+	template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}}
+	applyEgressProxy(ragme, "api", template)
+	if got := envValue(template.Spec.Containers[0].Env, "OPENAI_BASE_URL"); got != "http://127.0.0.1:10000/openai/v1" {
+		t.Errorf("OPENAI_BASE_URL = %q, want the sidecar URL", got)
+	}
+	if len(template.Spec.Containers) != 2 || template.Spec.Containers[1].Name != "egress-proxy" {
+		t.Errorf("expected the Envoy sidecar, got %d containers", len(template.Spec.Containers))
+	}
+	if template.Annotations[egressProxyHashAnnotation] == "" {
+		t.Error("expected the configuration hash on the pod template")
+	}
+
+	ragme.Spec.Egress.Proxy.Mode = egressProxyModeShared
+	template = &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}}
+	applyEgressProxy(ragme, "agent", template)
+	if got := envValue(template.Spec.Containers[0].Env, "FRIENDLI_BASE_URL"); got != "http://demo-egress-proxy:10000/friendli/serverless/v1" {
+		t.Errorf("FRIENDLI_BASE_URL = %q, want the shared proxy URL", got)
+	}
+	if len(template.Spec.Containers) != 1 {
+		t.Errorf("expected no sidecar in Shared mode, got %d containers", len(template.Spec.Containers))
+	}
+}
+
+func TestRenderEgressProxyConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Egress.Proxy = ragmev1.RAGmeEgressProxy{
+		Enabled:   true,
+		Upstreams: []ragmev1.RAGmeEgressUpstream{{Name: "azure", Host: "example.openai.azure.com"}},
+	}
+
+	config, hash, err := renderEgressProxyConfig(ragme)
+	if err != nil {
+		t.Fatalf("renderEgressProxyConfig() error = %v", err)
+	}
+	for _, want := range []string{`"prefix": "/azure/"`, `"sni": "example.openai.azure.com"`, `"port_value": 443`, `"status": 403`} {
+		if !strings.Contains(config, want) {
+			t.Errorf("expected the configuration to contain %s", want)
+		}
+	}
+	if strings.Contains(config, "api.openai.com") {
+		t.Error("expected the default upstreams to be replaced")
+	}
+
+	ragme.Spec.Egress.Proxy.Upstreams[0].Port = 8443
+	if _, changed, _ := renderEgressProxyConfig(ragme); changed == hash {
+		t.Error("expected a changed upstream to change the hash")
+	}
+}
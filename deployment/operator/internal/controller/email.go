@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	dataSourceTypeEmail = "email"
+
+	emailProtocolIMAP  = "imap"
+	emailProtocolGraph = "graph"
+
+	defaultIMAPPort    = 993
+	defaultEmailFolder = "INBOX"
+)
+
+// emailCredentialKeys are the keys the credentials Secret of each mail protocol must hold
+var emailCredentialKeys = map[string][]string{
+	emailProtocolIMAP:  {"username", "password"},
+	emailProtocolGraph: {"tenantId", "clientId", "clientSecret"},
+}
+
+// emailProtocol returns the protocol the mailbox is read with
+func emailProtocol(email *ragmev1.RAGmeEmailSource) string {
+	if email.Protocol == "" {
+		return emailProtocolIMAP
+	}
+	return email.Protocol
+}
+
+// validateEmail checks the mailbox settings of an email data source
+func validateEmail(email *ragmev1.RAGmeEmailSource) []error {
+	var errs []error
+	if email == nil {
+		return []error{fmt.Errorf("email: required with the %s type", dataSourceTypeEmail)}
+	}
+	switch emailProtocol(email) {
+	case emailProtocolIMAP:
+		if email.Host == "" {
+			errs = append(errs, fmt.Errorf("email.host: required with the %s protocol", emailProtocolIMAP))
+		}
+		if email.Port < 0 || email.Port > 65535 {
+			errs = append(errs, fmt.Errorf("email.port: %d is not a valid port", email.Port))
+		}
+	case emailProtocolGraph:
+		if email.Host != "" || email.Port != 0 {
+			errs = append(errs, fmt.Errorf("email.host: not used with the %s protocol", emailProtocolGraph))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("email.protocol: unsupported protocol %q, use %s or %s",
+			email.Protocol, emailProtocolIMAP, emailProtocolGraph))
+	}
+	if _, err := mail.ParseAddress(email.Mailbox); err != nil {
+		errs = append(errs, fmt.Errorf("email.mailbox: %q must be an email address", email.Mailbox))
+	}
+	if email.CredentialsSecretRef.Name == "" {
+		errs = append(errs, fmt.Errorf("email.credentialsSecretRef.name: required"))
+	}
+
+	excluded := map[string]bool{}
+	for i, folder := range email.ExcludeFolders {
+		if folder == "" || strings.Contains(folder, ",") {
+			errs = append(errs, fmt.Errorf("email.excludeFolders[%d]: %q must be a folder without commas", i, folder))
+		}
+		excluded[folder] = true
+	}
+	for i, folder := range email.Folders {
+		if folder == "" || strings.Contains(folder, ",") {
+			errs = append(errs, fmt.Errorf("email.folders[%d]: %q must be a folder without commas", i, folder))
+		}
+		if excluded[folder] {
+			errs = append(errs, fmt.Errorf("email.folders[%d]: %q is also excluded", i, folder))
+		}
+	}
+	for i, from := range email.FromAddresses {
+		if _, err := mail.ParseAddress(from); err != nil {
+			errs = append(errs, fmt.Errorf("email.fromAddresses[%d]: %q must be an email address", i, from))
+		}
+	}
+	if !enabledOrDefault(email.IngestBodies) && !enabledOrDefault(email.IngestAttachments) {
+		errs = append(errs, fmt.Errorf("email: ingestBodies and ingestAttachments are both disabled, nothing would be ingested"))
+	}
+	return errs
+}
+
+// enabledOrDefault returns the value of an optional setting enabled by default
+func enabledOrDefault(enabled *bool) bool {
+	return enabled == nil || *enabled
+}
+
+// mailContainer returns the sync worker of an email source. The worker reads
+// the messages received since the cursor of the last successful sync, submits
+// their bodies and attachments to the api, and writes its stats with the new
+// cursor as a JSON object to its termination message.
+func mailContainer(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) corev1.Container {
+	email := source.Spec.Email
+	folders := email.Folders
+	if len(folders) == 0 {
+		folders = []string{defaultEmailFolder}
+	}
+	env := []corev1.EnvVar{
+		{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+		{Name: "EMAIL_PROTOCOL", Value: emailProtocol(email)},
+		{Name: "EMAIL_MAILBOX", Value: email.Mailbox},
+		{Name: "EMAIL_FOLDERS", Value: strings.Join(folders, ",")},
+		{Name: "EMAIL_EXCLUDE_FOLDERS", Value: strings.Join(email.ExcludeFolders, ",")},
+		{Name: "EMAIL_FROM_ADDRESSES", Value: strings.Join(email.FromAddresses, ",")},
+		{Name: "EMAIL_INGEST_BODIES", Value: strconv.FormatBool(enabledOrDefault(email.IngestBodies))},
+		{Name: "EMAIL_INGEST_ATTACHMENTS", Value: strconv.FormatBool(enabledOrDefault(email.IngestAttachments))},
+		{Name: "EMAIL_CREDENTIALS_DIR", Value: credentialsDir},
+		{Name: "EMAIL_CURSOR", Value: source.Status.Cursor},
+		{Name: "SYNC_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+	}
+	if emailProtocol(email) == emailProtocolIMAP {
+		port := email.Port
+		if port == 0 {
+			port = defaultIMAPPort
+		}
+		env = append(env,
+			corev1.EnvVar{Name: "EMAIL_HOST", Value: email.Host},
+			corev1.EnvVar{Name: "EMAIL_PORT", Value: strconv.Itoa(int(port))},
+		)
+	}
+
+	return corev1.Container{
+		Name:            "mail",
+		Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+		ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+		Command:         []string{"python", "-m", "src.ragme.mail"},
+		Env:             env,
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "credentials", MountPath: credentialsDir, ReadOnly: true},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+}
+
+// emailReport is the JSON object of the stats the mail worker writes when it finishes
+type emailReport struct {
+	MessagesProcessed   int64   `json:"messagesProcessed"`
+	BodiesIngested      int64   `json:"bodiesIngested"`
+	AttachmentsIngested int64   `json:"attachmentsIngested"`
+	Errors              int64   `json:"errors"`
+	DurationSeconds     float64 `json:"durationSeconds"`
+	Cursor              string  `json:"cursor"`
+}
+
+// parseEmailStats parses the stats of a mailbox sync and the cursor it stopped at
+func parseEmailStats(message string) (ragmev1.RAGmeEmailStats, string, error) {
+	report := emailReport{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return ragmev1.RAGmeEmailStats{}, "", fmt.Errorf("invalid email stats %q: %w", message, err)
+	}
+	return ragmev1.RAGmeEmailStats{
+		MessagesProcessed:   report.MessagesProcessed,
+		BodiesIngested:      report.BodiesIngested,
+		AttachmentsIngested: report.AttachmentsIngested,
+		Errors:              report.Errors,
+		Duration:            (time.Duration(report.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+	}, report.Cursor, nil
+}
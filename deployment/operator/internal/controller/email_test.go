@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func emailDataSource() *ragmev1.RAGmeDataSource {
+	return &ragmev1.RAGmeDataSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "dropbox", Namespace: "ragme"},
+		Spec: ragmev1.RAGmeDataSourceSpec{
+			InstanceRef: "test",
+			Type:        dataSourceTypeEmail,
+			Email: &ragmev1.RAGmeEmailSource{
+				Host:                 "imap.example.com",
+				Mailbox:              "docs-drop@example.com",
+				CredentialsSecretRef: corev1.LocalObjectReference{Name: "mail-credentials"},
+			},
+		},
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	source := emailDataSource()
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+
+	disabled := false
+	source.Spec.Email.Mailbox = "docs-drop"
+	source.Spec.Email.Folders = []string{"INBOX", "Archive"}
+	source.Spec.Email.ExcludeFolders = []string{"Archive"}
+	source.Spec.Email.IngestBodies = &disabled
+	source.Spec.Email.IngestAttachments = &disabled
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an invalid mailbox, an excluded folder and nothing ingested")
+	}
+
+	source = emailDataSource()
+	source.Spec.Email.Protocol = emailProtocolGraph
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an IMAP host with the graph protocol")
+	}
+	source.Spec.Email.Host = ""
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+}
+
+func TestCreateMailCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	attachmentsOnly := false
+	source := emailDataSource()
+	source.Spec.Email.IngestBodies = &attachmentsOnly
+
+	cronJob := createSyncCronJob(ragme, source)
+	if cronJob.Name != "dropbox-email" {
+		t.Errorf("CronJob %s, want dropbox-email", cronJob.Name)
+	}
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	for name, want := range map[string]string{
+		"EMAIL_PROTOCOL":           emailProtocolIMAP,
+		"EMAIL_HOST":               "imap.example.com",
+		"EMAIL_PORT":               "993",
+		"EMAIL_FOLDERS":            defaultEmailFolder,
+		"EMAIL_INGEST_BODIES":      "false",
+		"EMAIL_INGEST_ATTACHMENTS": "true",
+	} {
+		if got := envValue(container.Env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Secret.SecretName != "mail-credentials" {
+		t.Errorf("volumes = %+v, want the credentials Secret", podSpec.Volumes)
+	}
+
+	name, keys := sourceCredentials(source)
+	if name != "mail-credentials" || len(keys) != 2 {
+		t.Errorf("sourceCredentials() = %s %v, want the IMAP username and password", name, keys)
+	}
+}
+
+func TestRecordEmailStats(t *testing.T) {
+	source := emailDataSource()
+	err := recordSyncStats(source, "dropbox-email-1", `{"messagesProcessed":4,"bodiesIngested":4,"attachmentsIngested":7,"durationSeconds":3.2,"cursor":"INBOX:1042"}`)
+	if err != nil {
+		t.Fatalf("recordSyncStats() error = %v", err)
+	}
+	want := ragmev1.RAGmeEmailStats{MessagesProcessed: 4, BodiesIngested: 4, AttachmentsIngested: 7, Duration: "3s"}
+	if source.Status.Email != want || source.Status.Cursor != "INBOX:1042" {
+		t.Errorf("status = %+v, want %+v at cursor INBOX:1042", source.Status, want)
+	}
+}
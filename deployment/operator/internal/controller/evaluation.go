@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	defaultEvaluationSchedule = "0 4 * * *"
+
+	evaluationDatasetDir = "/dataset"
+	evaluationDatasetKey = "dataset.jsonl"
+
+	// evaluationVersionAnnotation records the image tag evaluated by a Job
+	evaluationVersionAnnotation = "ragme.io/evaluated-version"
+)
+
+// evaluationS3Script downloads the dataset object into the dataset directory
+const evaluationS3Script = `set -e
+mc alias set eval "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY"
+mc cp "eval/$S3_BUCKET/$S3_KEY" "$EVAL_DATASET"`
+
+// evaluationCronJobName returns the name of the evaluation CronJob
+func evaluationCronJobName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-evaluation", ragme.Name)
+}
+
+// reconcileEvaluation runs the evaluation CronJob, suspended while the
+// instance hibernates
+func (r *RAGmeReconciler) reconcileEvaluation(ctx context.Context, ragme *ragmev1.RAGme) error {
+	cronJob := createEvaluationCronJob(ragme)
+	if !ragme.Spec.Evaluation.Enabled {
+		ragme.Status.Evaluation = ragmev1.RAGmeEvaluationStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeQualityRegressed)
+		if err := r.Delete(ctx, cronJob, client.PropagationPolicy("Background")); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := r.setOwner(ragme, cronJob); err != nil {
+		return err
+	}
+	found := &batchv1.CronJob{}
+	err := r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, cronJob)
+	} else if err != nil {
+		return err
+	}
+	found.Spec = cronJob.Spec
+	found.Labels = mergeStringMaps(found.Labels, cronJob.Labels)
+	return r.Update(ctx, found)
+}
+
+// checkEvaluation records the scores of the last completed evaluation in
+// status and flips QualityRegressed when a score drops below its threshold
+func (r *RAGmeReconciler) checkEvaluation(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Evaluation.Enabled {
+		return nil
+	}
+
+	job, err := r.lastFinishedJob(ctx, ragme, "evaluation")
+	if err != nil || job == nil || job.Name == ragme.Status.Evaluation.Job {
+		return err
+	}
+	if jobFailed(job) {
+		ragme.Status.Evaluation.Job = job.Name
+		conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeQualityRegressed,
+			metav1.ConditionUnknown, conditions.ReasonEvaluationFailed,
+			fmt.Sprintf("Evaluation failed, see the logs of job %s", job.Name))
+		return nil
+	}
+
+	scores, err := r.evaluationScores(ctx, job)
+	if err != nil {
+		// The scores are lost with the pod, wait for the next run
+		ragme.Status.Evaluation.Job = job.Name
+		return fmt.Errorf("failed to read the scores of job %s: %w", job.Name, err)
+	}
+	completedAt := r.now()
+	if job.Status.CompletionTime != nil {
+		completedAt = job.Status.CompletionTime.Time
+	}
+	setEvaluationStatus(ragme, job.Name, job.Annotations[evaluationVersionAnnotation], scores, completedAt)
+	return nil
+}
+
+// evaluationScores reads the scores the evaluation wrote to the termination
+// message of its container
+func (r *RAGmeReconciler) evaluationScores(ctx context.Context, job *batchv1.Job) (map[string]float64, error) {
+	message, err := jobTerminationMessage(ctx, r, job, "evaluation")
+	if err != nil {
+		return nil, err
+	}
+	return parseEvaluationScores(message)
+}
+
+// parseEvaluationScores parses the JSON object of the scores per metric
+func parseEvaluationScores(message string) (map[string]float64, error) {
+	scores := map[string]float64{}
+	if err := json.Unmarshal([]byte(message), &scores); err != nil {
+		return nil, fmt.Errorf("invalid scores %q: %w", message, err)
+	}
+	return scores, nil
+}
+
+// setEvaluationStatus records the scores of an evaluation Job and sets the
+// QualityRegressed condition from the thresholds
+func setEvaluationStatus(ragme *ragmev1.RAGme, jobName, version string, scores map[string]float64, completedAt time.Time) {
+	status := &ragme.Status.Evaluation
+	status.Job = jobName
+	status.CompletedAt = &metav1.Time{Time: completedAt}
+	status.Version = version
+	status.Scores = map[string]string{}
+	for metric, score := range scores {
+		status.Scores[metric] = strconv.FormatFloat(score, 'f', 3, 64)
+	}
+
+	var regressions []string
+	for metric, threshold := range ragme.Spec.Evaluation.Thresholds {
+		minimum, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			continue
+		}
+		score, ok := scores[metric]
+		switch {
+		case !ok:
+			regressions = append(regressions, fmt.Sprintf("%s not reported", metric))
+		case score < minimum:
+			regressions = append(regressions, fmt.Sprintf("%s %.3f < %s", metric, score, threshold))
+		}
+	}
+	sort.Strings(regressions)
+
+	if len(regressions) == 0 {
+		status.LastPassedVersion = version
+		conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeQualityRegressed,
+			metav1.ConditionFalse, conditions.ReasonQualityWithinThresholds,
+			fmt.Sprintf("All scores of version %s meet their thresholds", version))
+		return
+	}
+
+	message := fmt.Sprintf("Version %s scores below thresholds: %s", version, strings.Join(regressions, ", "))
+	if status.LastPassedVersion != "" && status.LastPassedVersion != version {
+		message += fmt.Sprintf(" (last passed by version %s)", status.LastPassedVersion)
+	}
+	conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeQualityRegressed,
+		metav1.ConditionTrue, conditions.ReasonScoreBelowThreshold, message)
+}
+
+// createEvaluationCronJob returns the CronJob evaluating the instance. The
+// evaluation asks the dataset questions to the api and writes the scores per
+// metric as a JSON object to its termination message.
+func createEvaluationCronJob(ragme *ragmev1.RAGme) *batchv1.CronJob {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "evaluation",
+		"instance":  ragme.Name,
+	}
+
+	evaluation := ragme.Spec.Evaluation
+	schedule := evaluation.Schedule
+	if schedule == "" {
+		schedule = defaultEvaluationSchedule
+	}
+	image := evaluation.Image
+	if image == "" {
+		image = fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag)
+	}
+	datasetPath := evaluationDatasetDir + "/" + evaluationDatasetKey
+	mounts := []corev1.VolumeMount{{Name: "dataset", MountPath: evaluationDatasetDir}}
+
+	dataset := corev1.Volume{Name: "dataset"}
+	var initContainers []corev1.Container
+	switch {
+	case evaluation.Dataset.ConfigMapRef != nil:
+		ref := evaluation.Dataset.ConfigMapRef
+		dataset.VolumeSource = corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: ref.LocalObjectReference,
+				Items:                []corev1.KeyToPath{{Key: ref.Key, Path: evaluationDatasetKey}},
+				Optional:             ref.Optional,
+			},
+		}
+	case evaluation.Dataset.S3 != nil:
+		s3 := evaluation.Dataset.S3
+		dataset.VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+		env := []corev1.EnvVar{
+			{Name: "EVAL_DATASET", Value: datasetPath},
+			{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+			{Name: "S3_BUCKET", Value: s3.Bucket},
+			{Name: "S3_KEY", Value: s3.Key},
+		}
+		if ref := s3.CredentialsSecretRef; ref != nil {
+			env = append(env,
+				corev1.EnvVar{Name: "S3_ACCESS_KEY", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageAccessKeyKey},
+				}},
+				corev1.EnvVar{Name: "S3_SECRET_KEY", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageSecretKeyKey},
+				}},
+			)
+		}
+		initContainers = append(initContainers, corev1.Container{
+			Name:         "fetch-dataset",
+			Image:        seedS3Image,
+			Command:      []string{"sh", "-c", evaluationS3Script},
+			Env:          env,
+			VolumeMounts: mounts,
+		})
+	default:
+		dataset.VolumeSource = corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}
+	}
+
+	succeeded, failed := jobHistoryLimits(ragme)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      evaluationCronJobName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			Suspend:                    &[]bool{ragme.Status.Hibernation.Hibernated}[0],
+			SuccessfulJobsHistoryLimit: &[]int32{int32(succeeded)}[0],
+			FailedJobsHistoryLimit:     &[]int32{int32(failed)}[0],
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{evaluationVersionAnnotation: ragme.Spec.Images.Tag},
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:            &[]int32{1}[0],
+					TTLSecondsAfterFinished: jobTTL(ragme),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy:  corev1.RestartPolicyNever,
+							InitContainers: initContainers,
+							Containers: []corev1.Container{
+								{
+									Name:            "evaluation",
+									Image:           image,
+									ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+									Command:         []string{"python", "-m", "src.ragme.evaluation"},
+									Env: []corev1.EnvVar{
+										{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+										{Name: "EVAL_DATASET", Value: datasetPath},
+										{Name: "EVAL_SCORES_FILE", Value: corev1.TerminationMessagePathDefault},
+									},
+									VolumeMounts:             mounts,
+									TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+								},
+							},
+							Volumes: []corev1.Volume{dataset},
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &cronJob.Spec.JobTemplate.Spec.Template)
+	return cronJob
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestSetEvaluationStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 4, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		scores      map[string]float64
+		wantStatus  metav1.ConditionStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name:       "within thresholds",
+			scores:     map[string]float64{"recall": 0.9, "faithfulness": 0.75},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: conditions.ReasonQualityWithinThresholds,
+		},
+		{
+			name:        "score below threshold",
+			scores:      map[string]float64{"recall": 0.6, "faithfulness": 0.75},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  conditions.ReasonScoreBelowThreshold,
+			wantMessage: "recall 0.600 < 0.8 (last passed by version v1.1.0)",
+		},
+		{
+			name:        "metric not reported",
+			scores:      map[string]float64{"recall": 0.9},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  conditions.ReasonScoreBelowThreshold,
+			wantMessage: "faithfulness not reported",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Evaluation.Thresholds = map[string]string{"recall": "0.8", "faithfulness": "0.7"}
+			ragme.Status.Evaluation.LastPassedVersion = "v1.1.0"
+			setEvaluationStatus(ragme, "ragme-evaluation-1", "v1.2.0", tt.scores, now)
+
+			condition := meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeQualityRegressed)
+			if condition == nil || condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Fatalf("QualityRegressed = %+v, want %s/%s", condition, tt.wantStatus, tt.wantReason)
+			}
+			if !strings.Contains(condition.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", condition.Message, tt.wantMessage)
+			}
+			if got := ragme.Status.Evaluation.Scores["recall"]; got == "" {
+				t.Errorf("recall score not recorded, scores = %v", ragme.Status.Evaluation.Scores)
+			}
+			wantPassed := "v1.1.0"
+			if tt.wantStatus == metav1.ConditionFalse {
+				wantPassed = "v1.2.0"
+			}
+			if got := ragme.Status.Evaluation.LastPassedVersion; got != wantPassed {
+				t.Errorf("lastPassedVersion = %q, want %q", got, wantPassed)
+			}
+		})
+	}
+}
+
+func TestParseEvaluationScores(t *testing.T) {
+	scores, err := parseEvaluationScores(`{"recall": 0.84, "faithfulness": 0.7}`)
+	if err != nil || scores["recall"] != 0.84 || scores["faithfulness"] != 0.7 {
+		t.Errorf("parseEvaluationScores() = %v, %v", scores, err)
+	}
+	if _, err := parseEvaluationScores("Traceback (most recent call last)"); err == nil {
+		t.Error("expected an error for a message that is not JSON")
+	}
+}
+
+func TestCreateEvaluationCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Name = "ragme"
+	ragme.Spec.Images = ragmev1.RAGmeImages{Registry: "ghcr.io/maximilien", Tag: "v1.2.0"}
+	ragme.Spec.Evaluation = ragmev1.RAGmeEvaluation{
+		Enabled: true,
+		Dataset: ragmev1.RAGmeEvaluationDataset{S3: &ragmev1.RAGmeEvaluationS3{
+			Endpoint: "https://s3.amazonaws.com", Bucket: "evals", Key: "ragme/questions.jsonl",
+			CredentialsSecretRef: &corev1.LocalObjectReference{Name: "eval-s3"},
+		}},
+	}
+	ragme.Status.Hibernation.Hibernated = true
+
+	cronJob := createEvaluationCronJob(ragme)
+	if cronJob.Spec.Schedule != defaultEvaluationSchedule {
+		t.Errorf("schedule = %q, want the default", cronJob.Spec.Schedule)
+	}
+	if cronJob.Spec.Suspend == nil || !*cronJob.Spec.Suspend {
+		t.Error("expected the CronJob to be suspended while hibernated")
+	}
+	if got := cronJob.Spec.JobTemplate.Annotations[evaluationVersionAnnotation]; got != "v1.2.0" {
+		t.Errorf("evaluated version = %q, want v1.2.0", got)
+	}
+
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 1 || envValue(podSpec.InitContainers[0].Env, "S3_KEY") != "ragme/questions.jsonl" {
+		t.Errorf("init containers = %+v, want the dataset download", podSpec.InitContainers)
+	}
+	container := podSpec.Containers[0]
+	if container.Image != "ghcr.io/maximilien/ragme-api:v1.2.0" {
+		t.Errorf("image = %q, want the api image", container.Image)
+	}
+	if envValue(container.Env, "EVAL_SCORES_FILE") != corev1.TerminationMessagePathDefault {
+		t.Errorf("scores file = %q, want the termination message", envValue(container.Env, "EVAL_SCORES_FILE"))
+	}
+}
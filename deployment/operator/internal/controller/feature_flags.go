@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+
+const (
+	featureFlagsKey       = "flags.json"
+	featureFlagsMountPath = "/app/config/features"
+
+	// featureFlagsHashAnnotation records the feature flags a pod runs with
+	featureFlagsHashAnnotation = "ragme.io/feature-flags-hash"
+
+	featureFlagsHotReload = "HotReload"
+	featureFlagsRollout   = "Rollout"
+)
+
+// featureFlagComponents lists the components consuming the feature flags
+var featureFlagComponents = []string{"api", "mcp", "agent", "frontend"}
+
+// featureFlagsConfigMapName returns the name of the ConfigMap holding the feature flags
+func featureFlagsConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-feature-flags", ragme.Name)
+}
+
+// renderFeatureFlags returns the feature flags document mounted by the components
+// and its hash. Map keys are sorted by encoding/json, so the hash is stable.
+func renderFeatureFlags(ragme *ragmev1.RAGme) (string, string, error) {
+	flags := ragme.Spec.FeatureFlags
+	if flags == nil {
+		flags = map[string]bool{}
+	}
+	data, err := json.MarshalIndent(flags, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:])[:16], nil
+}
+
+// reconcileFeatureFlags renders the feature flags into the ConfigMap mounted by the components
+func (r *RAGmeReconciler) reconcileFeatureFlags(ctx context.Context, ragme *ragmev1.RAGme) error {
+	data, _, err := renderFeatureFlags(ragme)
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      featureFlagsConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{featureFlagsKey: data},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[featureFlagsKey] != configMap.Data[featureFlagsKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyFeatureFlags mounts the feature flags into a component pod. With the
+// Rollout reload policy, the flags hash is set on the pod template so changed
+// flags roll the Deployment.
+func applyFeatureFlags(ragme *ragmev1.RAGme, serviceName string, template *corev1.PodTemplateSpec) {
+	mountVolume(&template.Spec, corev1.Volume{
+		Name: "feature-flags",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: featureFlagsConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, featureFlagsMountPath)
+	template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_FEATURE_FLAGS", Value: featureFlagsMountPath + "/" + featureFlagsKey,
+	})
+
+	if ragme.Spec.FeatureFlagsReload != featureFlagsRollout {
+		return
+	}
+	if _, hash, err := renderFeatureFlags(ragme); err == nil {
+		template.Annotations = mergeStringMaps(template.Annotations, map[string]string{
+			featureFlagsHashAnnotation: hash,
+		})
+	}
+}
+
+// reloadFeatureFlags annotates the running component pods with the flags hash.
+// Updating a pod makes the kubelet refresh its ConfigMap volumes right away
+// instead of on its next periodic sync, and the components reload the mounted
+// flags file when it changes.
+func (r *RAGmeReconciler) reloadFeatureFlags(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if ragme.Spec.FeatureFlagsReload == featureFlagsRollout {
+		return nil
+	}
+	_, hash, err := renderFeatureFlags(ragme)
+	if err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":      "ragme",
+		"instance": ragme.Name,
+	}); err != nil {
+		return err
+	}
+
+	reloaded := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !consumesFeatureFlags(pod.Labels["component"]) || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		if pod.Annotations[featureFlagsHashAnnotation] == hash {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Annotations = mergeStringMaps(pod.Annotations, map[string]string{featureFlagsHashAnnotation: hash})
+		if err := r.Patch(ctx, pod, patch); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		reloaded++
+	}
+	if reloaded > 0 {
+		log.FromContext(ctx).Info("Triggered feature flags reload", "pods", reloaded, "hash", hash)
+	}
+	return nil
+}
+
+// consumesFeatureFlags reports whether the component mounts the feature flags
+func consumesFeatureFlags(component string) bool {
+	for _, c := range featureFlagComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderFeatureFlagsHash(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.FeatureFlags = map[string]bool{"aiAcceleration": true, "betaSearch": false}
+	_, first, err := renderFeatureFlags(ragme)
+	if err != nil {
+		t.Fatalf("renderFeatureFlags() error = %v", err)
+	}
+	_, again, _ := renderFeatureFlags(ragme)
+	if first != again {
+		t.Errorf("hash is not stable: %s != %s", first, again)
+	}
+
+	ragme.Spec.FeatureFlags["betaSearch"] = true
+	if _, changed, _ := renderFeatureFlags(ragme); changed == first {
+		t.Errorf("hash did not change with the flags")
+	}
+}
+
+func TestApplyFeatureFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		reload   string
+		wantHash bool
+	}{
+		{name: "hot reload", reload: featureFlagsHotReload},
+		{name: "rollout", reload: featureFlagsRollout, wantHash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.FeatureFlagsReload = tt.reload
+			template := &corev1.PodTemplateSpec{}
+			template.Spec.Containers = []corev1.Container{{Name: "api"}}
+
+			applyFeatureFlags(ragme, "api", template)
+			if len(template.Spec.Volumes) != 1 || template.Spec.Volumes[0].ConfigMap.Name != featureFlagsConfigMapName(ragme) {
+				t.Errorf("volumes = %v, want the feature flags ConfigMap", template.Spec.Volumes)
+			}
+			if _, ok := template.Annotations[featureFlagsHashAnnotation]; ok != tt.wantHash {
+				t.Errorf("hash annotation present = %v, want %v", ok, tt.wantHash)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	// ingressAnnotationPrefix is the prefix of the ingress-nginx annotations
+	ingressAnnotationPrefix = "nginx.ingress.kubernetes.io/"
+
+	// ingressSnippetAnnotation carries the nginx directives rendered from the spec
+	ingressSnippetAnnotation = ingressAnnotationPrefix + "configuration-snippet"
+
+	// defaultStreamIdleTimeoutSeconds keeps long chat responses from being cut by the
+	// 60s proxy timeouts of most Ingress controllers
+	defaultStreamIdleTimeoutSeconds = 3600
+)
+
+// proxyBufferSizePattern matches nginx sizes such as 8k or 1m
+var proxyBufferSizePattern = regexp.MustCompile(`^[0-9]+[kKmM]?$`)
+
+// staticAssetTypes are the content types of the UI bundles that are cached and compressed
+var staticAssetTypes = []string{
+	"text/css",
+	"application/javascript",
+	"application/json",
+	"image/svg+xml",
+	"font/woff2",
+}
+
+// applyFrontendAssets points the frontend at the asset base URL and cache policy
+func applyFrontendAssets(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "frontend" {
+		return
+	}
+	assets := ragme.Spec.Frontend.Assets
+	if assets.BaseURL != "" {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_ASSETS_BASE_URL", Value: strings.TrimSuffix(assets.BaseURL, "/"),
+		})
+	}
+	if assets.CacheControl != "" {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_ASSETS_CACHE_CONTROL", Value: assets.CacheControl,
+		})
+	}
+}
+
+// applyStreaming passes the streaming limits to the frontend and the api, which
+// both terminate the chat WebSocket
+func applyStreaming(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "frontend" && serviceName != "api" {
+		return
+	}
+	streaming := ragme.Spec.Frontend.Streaming
+	if streaming.IdleTimeoutSeconds > 0 {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_STREAM_IDLE_TIMEOUT_SECONDS", Value: strconv.Itoa(int(streaming.IdleTimeoutSeconds)),
+		})
+	}
+	if streaming.MaxMessageSize != "" {
+		size := resource.MustParse(streaming.MaxMessageSize)
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_WS_MAX_MESSAGE_BYTES", Value: strconv.FormatInt(size.Value(), 10),
+		})
+	}
+}
+
+// streamingAnnotations returns the ingress-nginx proxy settings keeping
+// streaming connections open for the idle timeout, or nil when no timeout is set
+func streamingAnnotations(ragme *ragmev1.RAGme) map[string]string {
+	streaming := ragme.Spec.Frontend.Streaming
+	if streaming.IdleTimeoutSeconds <= 0 {
+		return nil
+	}
+	timeout := strconv.Itoa(int(streaming.IdleTimeoutSeconds))
+	annotations := map[string]string{
+		ingressAnnotationPrefix + "proxy-read-timeout": timeout,
+		ingressAnnotationPrefix + "proxy-send-timeout": timeout,
+		ingressAnnotationPrefix + "proxy-buffering":    "off",
+	}
+	if streaming.ProxyBuffering {
+		annotations[ingressAnnotationPrefix+"proxy-buffering"] = "on"
+	}
+	if streaming.ProxyBufferSize != "" {
+		annotations[ingressAnnotationPrefix+"proxy-buffer-size"] = streaming.ProxyBufferSize
+	}
+	return annotations
+}
+
+// frontendSnippet returns the nginx directives setting the asset cache headers
+// and compression, or "" when nothing is configured. Directives only apply to
+// static asset content types so api responses are left untouched.
+func frontendSnippet(ragme *ragmev1.RAGme) string {
+	assets := ragme.Spec.Frontend.Assets
+	types := strings.Join(staticAssetTypes, " ")
+
+	var lines []string
+	if assets.CacheControl != "" {
+		lines = append(lines, fmt.Sprintf("more_set_headers -t '%s' 'Cache-Control: %s';", types, assets.CacheControl))
+	}
+	for _, encoding := range assets.Compression {
+		switch encoding {
+		case "gzip":
+			lines = append(lines, "gzip on;", fmt.Sprintf("gzip_types %s;", types))
+		case "brotli":
+			lines = append(lines, "brotli on;", fmt.Sprintf("brotli_types %s;", types))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// ingressAnnotations returns the annotations of the generated Ingresses: the
+// directives rendered from the spec, overridden by the user-provided annotations
+func ingressAnnotations(ragme *ragmev1.RAGme) map[string]string {
+	annotations := streamingAnnotations(ragme)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	if snippet := frontendSnippet(ragme); snippet != "" {
+		annotations[ingressSnippetAnnotation] = snippet
+	}
+	annotations = mergeStringMaps(annotations, ragme.Spec.ExternalAccess.Ingress.Annotations)
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
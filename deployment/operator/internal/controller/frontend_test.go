@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestIngressAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		assets      ragmev1.RAGmeFrontendAssets
+		annotations map[string]string
+		wantSnippet []string
+		wantNil     bool
+	}{
+		{name: "nothing configured", wantNil: true},
+		{name: "user annotations only", annotations: map[string]string{"a": "b"}},
+		{
+			name:        "cache control",
+			assets:      ragmev1.RAGmeFrontendAssets{CacheControl: "public, max-age=600"},
+			wantSnippet: []string{"'Cache-Control: public, max-age=600'"},
+		},
+		{
+			name:        "compression",
+			assets:      ragmev1.RAGmeFrontendAssets{Compression: []string{"gzip", "brotli"}},
+			wantSnippet: []string{"gzip on;", "brotli on;"},
+		},
+		{
+			name:        "user snippet wins",
+			assets:      ragmev1.RAGmeFrontendAssets{Compression: []string{"gzip"}},
+			annotations: map[string]string{ingressSnippetAnnotation: "custom;"},
+			wantSnippet: []string{"custom;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Frontend.Assets = tt.assets
+			ragme.Spec.ExternalAccess.Ingress.Annotations = tt.annotations
+			got := ingressAnnotations(ragme)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("ingressAnnotations() = %v, want nil", got)
+				}
+				return
+			}
+			for k, v := range tt.annotations {
+				if got[k] != v {
+					t.Errorf("annotation %s = %q, want %q", k, got[k], v)
+				}
+			}
+			for _, want := range tt.wantSnippet {
+				if !strings.Contains(got[ingressSnippetAnnotation], want) {
+					t.Errorf("snippet %q does not contain %q", got[ingressSnippetAnnotation], want)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamingAnnotations(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if got := streamingAnnotations(ragme); got != nil {
+		t.Fatalf("streamingAnnotations() = %v, want nil without idle timeout", got)
+	}
+
+	ragme.Spec.Frontend.Streaming = ragmev1.RAGmeFrontendStreaming{IdleTimeoutSeconds: 600, ProxyBufferSize: "16k"}
+	got := streamingAnnotations(ragme)
+	want := map[string]string{
+		ingressAnnotationPrefix + "proxy-read-timeout": "600",
+		ingressAnnotationPrefix + "proxy-send-timeout": "600",
+		ingressAnnotationPrefix + "proxy-buffering":    "off",
+		ingressAnnotationPrefix + "proxy-buffer-size":  "16k",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("annotation %s = %q, want %q", k, got[k], v)
+		}
+	}
+}
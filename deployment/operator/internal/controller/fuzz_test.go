@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// fuzzSeeds are specs the fuzzer starts mutating from
+var fuzzSeeds = []string{
+	`{}`,
+	`{"storage":{"minio":{"enabled":true,"storageSize":"10Gi"},"sharedVolume":{"size":"5Gi"}},"vectorDB":{"type":"weaviate","weaviate":{"enabled":true}}}`,
+	`{"storage":{"minio":{"enabled":true,"storageSize":"ten gigs"}}}`,
+	`{"vectorDB":{"type":"weaviate","weaviate":{"enabled":true,"storageSize":"-1Gi"},"sharding":{"shards":3}}}`,
+	`{"components":{"api":{"tempStorage":{"sizeLimit":"1Gi","medium":"Memory"}}},"frontend":{"streaming":{"maxMessageSize":"1Mi"}}}`,
+	`{"replicas":{"api":-1},"readReplicas":{"enabled":true,"replicas":3}}`,
+	`{"embeddings":{"cache":{"enabled":true,"maxSize":"256Mi"}},"egress":{"proxy":{"enabled":true,"mode":"Shared"}}}`,
+	`{"observability":{"collector":{"enabled":true},"profiling":{"enabled":true,"endpoint":"http://pyroscope:4040"}}}`,
+	`{"storage":{"minio":{"enabled":false},"objectStorage":{"provider":"azure","credentialsSecretRef":{"name":"azure"}}}}`,
+}
+
+// FuzzSpecBuilders feeds arbitrary specs through the defaulting, the
+// validation and the Deployment builders. Specs passing validation must
+// never panic the builders and must produce valid Deployments.
+//
+//	go test ./internal/controller/ -run '^$' -fuzz FuzzSpecBuilders -fuzztime 5m
+func FuzzSpecBuilders(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "fuzz", Namespace: "ragme"}}
+		if err := json.Unmarshal(data, &ragme.Spec); err != nil {
+			return
+		}
+
+		r := &RAGmeReconciler{}
+		r.setDefaults(ragme)
+		if err := validateSpec(ragme); err != nil {
+			return
+		}
+
+		for _, deployment := range generatedDeployments(r, ragme) {
+			if errs := validateDeployment(deployment); len(errs) > 0 {
+				t.Errorf("Deployment %s is invalid: %v\nspec: %s", deployment.Name, errs, data)
+			}
+		}
+	})
+}
+
+// generatedDeployments builds every Deployment the reconciler can generate for the instance
+func generatedDeployments(r *RAGmeReconciler, ragme *ragmev1.RAGme) []*appsv1.Deployment {
+	deployments := []*appsv1.Deployment{
+		r.createMinIODeployment(ragme),
+		r.createReadAPIDeployment(ragme),
+		r.createRedisDeployment(ragme),
+		createCacheRedisDeployment(ragme, "embeddings-cache", ragme.Spec.Embeddings.Cache.Redis.Image, ragme.Spec.Embeddings.Cache.MaxSize),
+		createCollectorDeployment(ragme),
+		createEgressProxyDeployment(ragme),
+		createMetricsAgentDeployment(ragme),
+		createProfilerDeployment(ragme),
+		createS3GatewayDeployment(ragme),
+	}
+	for _, service := range []string{"api", "mcp", "agent", "frontend"} {
+		deployments = append(deployments, r.createRAGmeServiceDeployment(ragme, service))
+	}
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		deployments = append(deployments, r.createWeaviateDeployment(ragme, shard))
+	}
+	return deployments
+}
+
+// validateDeployment applies the checks of the API server that apimachinery
+// exposes: names, labels, selector, containers, ports, env and volumes
+func validateDeployment(deployment *appsv1.Deployment) []string {
+	var errs []string
+	check := func(path string, msgs []string) {
+		for _, msg := range msgs {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, msg))
+		}
+	}
+
+	check("metadata.name", validation.IsDNS1123Subdomain(deployment.Name))
+	for _, meta := range []metav1.ObjectMeta{deployment.ObjectMeta, deployment.Spec.Template.ObjectMeta} {
+		for key, value := range meta.Labels {
+			check("labels "+key, validation.IsQualifiedName(key))
+			check("labels "+key, validation.IsValidLabelValue(value))
+		}
+		for key := range meta.Annotations {
+			check("annotations "+key, validation.IsQualifiedName(key))
+		}
+	}
+	if deployment.Spec.Replicas != nil && *deployment.Spec.Replicas < 0 {
+		errs = append(errs, fmt.Sprintf("spec.replicas: %d is negative", *deployment.Spec.Replicas))
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil || selector.Empty() {
+		errs = append(errs, fmt.Sprintf("spec.selector: invalid or empty: %v", err))
+	} else if !selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+		errs = append(errs, "spec.selector: does not match the template labels")
+	}
+
+	podSpec := deployment.Spec.Template.Spec
+	volumes := map[string]bool{}
+	for _, volume := range podSpec.Volumes {
+		check("volumes "+volume.Name, validation.IsDNS1123Label(volume.Name))
+		if volumes[volume.Name] {
+			errs = append(errs, fmt.Sprintf("volumes %s: duplicate", volume.Name))
+		}
+		volumes[volume.Name] = true
+	}
+
+	if len(podSpec.Containers) == 0 {
+		errs = append(errs, "spec.template.spec.containers: required")
+	}
+	names := map[string]bool{}
+	for _, containers := range [][]corev1.Container{podSpec.InitContainers, podSpec.Containers} {
+		for _, container := range containers {
+			path := "containers " + container.Name
+			check(path, validation.IsDNS1123Label(container.Name))
+			if names[container.Name] {
+				errs = append(errs, path+": duplicate name")
+			}
+			names[container.Name] = true
+			if container.Image == "" {
+				errs = append(errs, path+": image required")
+			}
+			for _, env := range container.Env {
+				check(path+" env "+env.Name, validation.IsEnvVarName(env.Name))
+			}
+			for _, port := range container.Ports {
+				check(path+" ports", validation.IsValidPortNum(int(port.ContainerPort)))
+				if port.Name != "" {
+					check(path+" ports "+port.Name, validation.IsValidPortName(port.Name))
+				}
+			}
+			for _, mount := range container.VolumeMounts {
+				if !volumes[mount.Name] {
+					errs = append(errs, fmt.Sprintf("%s volumeMounts %s: no such volume", path, mount.Name))
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func TestFuzzSeedsGenerateValidDeployments(t *testing.T) {
+	for _, seed := range fuzzSeeds {
+		ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "fuzz", Namespace: "ragme"}}
+		if err := json.Unmarshal([]byte(seed), &ragme.Spec); err != nil {
+			t.Fatalf("seed %s: %v", seed, err)
+		}
+		r := &RAGmeReconciler{}
+		r.setDefaults(ragme)
+		if err := validateSpec(ragme); err != nil {
+			continue
+		}
+		for _, deployment := range generatedDeployments(r, ragme) {
+			if errs := validateDeployment(deployment); len(errs) > 0 {
+				t.Errorf("seed %s: Deployment %s is invalid: %v", seed, deployment.Name, errs)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	dataSourceTypeGit = "git"
+
+	defaultGitBranch = "main"
+
+	// gitWorkspaceDir is where the sync worker clones the repository
+	gitWorkspaceDir = "/workspace"
+)
+
+// scpLikeGitURL matches the scp-like ssh URLs of git, e.g. git@github.com:org/repo.git
+var scpLikeGitURL = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^/].*$`)
+
+// gitURLIsSSH reports whether a repository is cloned over ssh
+func gitURLIsSSH(repoURL string) bool {
+	return strings.HasPrefix(repoURL, "ssh://") || scpLikeGitURL.MatchString(repoURL)
+}
+
+// gitCredentialKeys returns the keys the credentials Secret of a repository must hold
+func gitCredentialKeys(git *ragmev1.RAGmeGitSource) []string {
+	if gitURLIsSSH(git.URL) {
+		return []string{"ssh-privatekey"}
+	}
+	return []string{"token"}
+}
+
+// gitCollection returns the collection the files of a git source are ingested into
+func gitCollection(source *ragmev1.RAGmeDataSource) string {
+	if source.Spec.Git.Collection != "" {
+		return source.Spec.Git.Collection
+	}
+	return source.Name
+}
+
+// validateGit checks the repository settings of a git data source
+func validateGit(git *ragmev1.RAGmeGitSource) []error {
+	var errs []error
+	if git == nil {
+		return []error{fmt.Errorf("git: required with the %s type", dataSourceTypeGit)}
+	}
+	if gitURLIsSSH(git.URL) {
+		if git.CredentialsSecretRef == nil || git.CredentialsSecretRef.Name == "" {
+			errs = append(errs, fmt.Errorf("git.credentialsSecretRef: required to clone %q over ssh", git.URL))
+		}
+	} else if u, err := url.Parse(git.URL); err != nil || u.Scheme != "https" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("git.url: %q must be an https or ssh URL", git.URL))
+	}
+	if ref := git.CredentialsSecretRef; ref != nil && ref.Name == "" {
+		errs = append(errs, fmt.Errorf("git.credentialsSecretRef.name: required"))
+	}
+	if branch := git.Branch; branch != "" &&
+		(strings.HasPrefix(branch, "-") || strings.Contains(branch, "..") || strings.ContainsAny(branch, " ~^:?*[\\")) {
+		errs = append(errs, fmt.Errorf("git.branch: %q is not a valid branch name", branch))
+	}
+	for i, glob := range git.Paths {
+		if _, err := path.Match(glob, ""); err != nil || strings.Contains(glob, ",") {
+			errs = append(errs, fmt.Errorf("git.paths[%d]: %q must be a glob without commas", i, glob))
+		}
+	}
+	for i, glob := range git.ExcludePaths {
+		if _, err := path.Match(glob, ""); err != nil || strings.Contains(glob, ",") {
+			errs = append(errs, fmt.Errorf("git.excludePaths[%d]: %q must be a glob without commas", i, glob))
+		}
+	}
+	return errs
+}
+
+// gitContainer returns the sync worker of a git source. The worker clones the
+// branch, ingests the files changed since the commit of the last successful
+// sync into the collection of the source (the whole tree on the first sync,
+// or when that commit is no longer an ancestor of the branch), and writes its
+// stats with the commit synced as a JSON object to its termination message.
+func gitContainer(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) corev1.Container {
+	git := source.Spec.Git
+	branch := git.Branch
+	if branch == "" {
+		branch = defaultGitBranch
+	}
+	container := corev1.Container{
+		Name:            "git",
+		Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+		ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+		Command:         []string{"python", "-m", "src.ragme.git"},
+		Env: []corev1.EnvVar{
+			{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+			{Name: "GIT_URL", Value: git.URL},
+			{Name: "GIT_BRANCH", Value: branch},
+			{Name: "GIT_PATHS", Value: strings.Join(git.Paths, ",")},
+			{Name: "GIT_EXCLUDE_PATHS", Value: strings.Join(git.ExcludePaths, ",")},
+			{Name: "GIT_COLLECTION", Value: gitCollection(source)},
+			{Name: "GIT_LAST_COMMIT", Value: source.Status.Cursor},
+			{Name: "GIT_WORKSPACE", Value: gitWorkspaceDir},
+			{Name: "SYNC_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "workspace", MountPath: gitWorkspaceDir},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+	if git.CredentialsSecretRef != nil {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "GIT_CREDENTIALS_DIR", Value: credentialsDir})
+		container.VolumeMounts = append(container.VolumeMounts,
+			corev1.VolumeMount{Name: "credentials", MountPath: credentialsDir, ReadOnly: true})
+	}
+	return container
+}
+
+// gitVolumes returns the scratch volume the repository is cloned into
+func gitVolumes() []corev1.Volume {
+	return []corev1.Volume{
+		{Name: "workspace", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+}
+
+// gitReport is the JSON object of the stats the git worker writes when it finishes
+type gitReport struct {
+	Commit          string  `json:"commit"`
+	PreviousCommit  string  `json:"previousCommit"`
+	FilesIngested   int64   `json:"filesIngested"`
+	FilesDeleted    int64   `json:"filesDeleted"`
+	Errors          int64   `json:"errors"`
+	DurationSeconds float64 `json:"durationSeconds"`
+}
+
+// parseGitStats parses the stats of a repository sync
+func parseGitStats(message string) (ragmev1.RAGmeGitStats, error) {
+	report := gitReport{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return ragmev1.RAGmeGitStats{}, fmt.Errorf("invalid git stats %q: %w", message, err)
+	}
+	return ragmev1.RAGmeGitStats{
+		Commit:         report.Commit,
+		PreviousCommit: report.PreviousCommit,
+		FilesIngested:  report.FilesIngested,
+		FilesDeleted:   report.FilesDeleted,
+		Errors:         report.Errors,
+		Duration:       (time.Duration(report.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+	}, nil
+}
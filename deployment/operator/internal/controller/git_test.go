@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func gitDataSource() *ragmev1.RAGmeDataSource {
+	return &ragmev1.RAGmeDataSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-code", Namespace: "ragme"},
+		Spec: ragmev1.RAGmeDataSourceSpec{
+			InstanceRef: "test",
+			Type:        dataSourceTypeGit,
+			Git: &ragmev1.RAGmeGitSource{
+				URL:   "https://github.com/example/operator.git",
+				Paths: []string{"docs/**", "**/*.go"},
+			},
+		},
+	}
+}
+
+func TestValidateGit(t *testing.T) {
+	source := gitDataSource()
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+
+	source.Spec.Git.URL = "git@github.com:example/operator.git"
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an ssh repository without credentials")
+	}
+	source.Spec.Git.CredentialsSecretRef = &corev1.LocalObjectReference{Name: "deploy-key"}
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+	if name, keys := sourceCredentials(source); name != "deploy-key" || len(keys) != 1 || keys[0] != "ssh-privatekey" {
+		t.Errorf("sourceCredentials() = %s %v, want the ssh private key of deploy-key", name, keys)
+	}
+
+	source.Spec.Git.URL = "ftp://example.com/repo"
+	source.Spec.Git.Branch = "feature..x"
+	source.Spec.Git.ExcludePaths = []string{"[vendor"}
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an invalid URL, branch and glob")
+	}
+}
+
+func TestCreateGitCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	source := gitDataSource()
+	source.Status.Cursor = "3f2a9c1"
+
+	cronJob := createSyncCronJob(ragme, source)
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	for name, want := range map[string]string{
+		"GIT_BRANCH":      defaultGitBranch,
+		"GIT_PATHS":       "docs/**,**/*.go",
+		"GIT_COLLECTION":  "operator-code",
+		"GIT_LAST_COMMIT": "3f2a9c1",
+	} {
+		if got := envValue(container.Env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].EmptyDir == nil {
+		t.Errorf("volumes = %+v, want the workspace of a public repository", podSpec.Volumes)
+	}
+	if hash := cronJob.Spec.JobTemplate.Annotations[syncSettingsHashAnnotation]; hash != syncSettingsHash(source) {
+		t.Errorf("Job settings hash = %q, want %q", hash, syncSettingsHash(source))
+	}
+
+	// Only what a sync selects changes the hash
+	hash := syncSettingsHash(source)
+	source.Spec.Schedule = "0 * * * *"
+	if syncSettingsHash(source) != hash {
+		t.Error("expected the schedule to keep the settings hash")
+	}
+	source.Spec.Git.Branch = "release"
+	if syncSettingsHash(source) == hash {
+		t.Error("expected the branch to change the settings hash")
+	}
+}
+
+func TestRecordGitStats(t *testing.T) {
+	source := gitDataSource()
+	err := recordSyncStats(source, "operator-code-git-1", `{"commit":"9b1e0d4","previousCommit":"3f2a9c1","filesIngested":14,"filesDeleted":2,"durationSeconds":41}`)
+	if err != nil {
+		t.Fatalf("recordSyncStats() error = %v", err)
+	}
+	want := ragmev1.RAGmeGitStats{Commit: "9b1e0d4", PreviousCommit: "3f2a9c1", FilesIngested: 14, FilesDeleted: 2, Duration: "41s"}
+	if source.Status.Git != want || source.Status.Cursor != "9b1e0d4" {
+		t.Errorf("status = %+v, want %+v at commit 9b1e0d4", source.Status, want)
+	}
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden manifests in testdata/golden")
+
+// goldenKinds are the generated resources compared against the golden files.
+// Secrets are left out as they hold generated keys.
+var goldenKinds = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Version: "v1", Kind: "Service"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Version: "v1", Kind: "ConfigMap"},
+}
+
+// goldenCases is the matrix of specs rendered into golden files
+var goldenCases = map[string]func(ragme *ragmev1.RAGme){
+	"minimal": func(*ragmev1.RAGme) {},
+	"sharded": func(ragme *ragmev1.RAGme) {
+		ragme.Spec.VectorDB.Sharding.Shards = 3
+	},
+	"milvus": func(ragme *ragmev1.RAGme) {
+		ragme.Spec.VectorDB = ragmev1.RAGmeVectorDB{
+			Type:   "milvus",
+			Milvus: ragmev1.RAGmeMilvusDB{Enabled: true, URI: "http://milvus.vectors:19530"},
+		}
+	},
+	"s3": func(ragme *ragmev1.RAGme) {
+		ragme.Spec.Storage.MinIO.Enabled = false
+		ragme.Spec.Storage.ObjectStorage = ragmev1.RAGmeObjectStorage{
+			Provider:             objectStorageS3,
+			Region:               "eu-west-1",
+			CredentialsSecretRef: &corev1.LocalObjectReference{Name: "ragme-s3"},
+		}
+	},
+	"observability": func(ragme *ragmev1.RAGme) {
+		ragme.Spec.Observability.Collector = ragmev1.RAGmeCollector{
+			Enabled: true,
+			Exporters: []ragmev1.RAGmeCollectorExporter{
+				{Name: "tempo", Type: "otlp", Endpoint: "tempo.observability:4317", Insecure: true},
+			},
+		}
+		ragme.Spec.Observability.Profiling = ragmev1.RAGmeProfiling{
+			Enabled:  true,
+			Endpoint: "http://pyroscope.observability:4040",
+		}
+	},
+	"egress": func(ragme *ragmev1.RAGme) {
+		ragme.Spec.Egress.Proxy = ragmev1.RAGmeEgressProxy{Enabled: true, Mode: egressProxyModeShared}
+	},
+}
+
+// renderManifests reconciles the instance twice, so resources depending on an
+// earlier pass are in place, and returns the generated resources as YAML
+func renderManifests(t *testing.T, ragme *ragmev1.RAGme) []byte {
+	t.Helper()
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, ragme.Name)
+	h.reconcile(t, ragme.Name)
+
+	var out bytes.Buffer
+	for _, gvk := range goldenKinds {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := h.client.List(h.ctx, list, client.InNamespace(ragme.Namespace)); err != nil {
+			t.Fatalf("List(%s) error = %v", gvk.Kind, err)
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+		for _, item := range list.Items {
+			// Drop the fields the API server assigns
+			item.SetResourceVersion("")
+			unstructured.RemoveNestedField(item.Object, "metadata", "creationTimestamp")
+			unstructured.RemoveNestedField(item.Object, "status")
+			item.SetAPIVersion(gvk.GroupVersion().String())
+			item.SetKind(gvk.Kind)
+
+			manifest, err := yaml.Marshal(item.Object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out.WriteString("---\n")
+			out.Write(manifest)
+		}
+	}
+	return out.Bytes()
+}
+
+// TestGoldenManifests compares the resources generated for each spec of the
+// matrix with testdata/golden. Run go test -run TestGoldenManifests -update
+// to accept intended changes, then review the diff of the golden files.
+func TestGoldenManifests(t *testing.T) {
+	names := make([]string, 0, len(goldenCases))
+	for name := range goldenCases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			ragme := harnessRAGme("golden")
+			goldenCases[name](ragme)
+			got := renderManifests(t, ragme)
+
+			path := filepath.Join("testdata", "golden", name+".yaml")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v, run with -update to create it", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("generated resources differ from %s, run with -update to accept the change:\n%s", path, firstDifference(string(want), string(got)))
+			}
+		})
+	}
+}
+
+// firstDifference describes the first line where the manifests differ
+func firstDifference(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, w, g)
+		}
+	}
+	return ""
+}
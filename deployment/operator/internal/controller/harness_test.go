@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// offlineTransport fails every request at once, standing in for the component
+// endpoints the reconciler probes
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+// harnessRAGme returns an instance with the fields the API server would default
+func harnessRAGme(name string) *ragmev1.RAGme {
+	return &ragmev1.RAGme{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "bench"},
+		Spec: ragmev1.RAGmeSpec{
+			Version:  "latest",
+			Images:   ragmev1.RAGmeImages{Registry: "localhost:5001", Repository: "ragme", Tag: "latest", PullPolicy: "IfNotPresent"},
+			Replicas: ragmev1.RAGmeReplicas{API: 2, MCP: 2, Agent: 1, Frontend: 2},
+			Storage: ragmev1.RAGmeStorage{
+				MinIO:        ragmev1.RAGmeMinIOStorage{Enabled: true, StorageSize: "10Gi", AccessKey: "minioadmin", SecretKey: "minioadmin"},
+				SharedVolume: ragmev1.RAGmeSharedVolume{Size: "5Gi"},
+			},
+			VectorDB: ragmev1.RAGmeVectorDB{
+				Type:     "weaviate",
+				Weaviate: ragmev1.RAGmeWeaviateDB{Enabled: true, StorageSize: "2Gi"},
+			},
+		},
+	}
+}
+
+// reconcilerHarness drives the RAGme reconciler against a fake client, a
+// fake clock and offline component endpoints
+type reconcilerHarness struct {
+	client     client.Client
+	clock      *clocktesting.FakePassiveClock
+	reconciler *RAGmeReconciler
+	ctx        context.Context
+}
+
+func newReconcilerHarness(tb testing.TB, instances ...*ragmev1.RAGme) *reconcilerHarness {
+	tb.Helper()
+	harnessScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(harnessScheme))
+	utilruntime.Must(ragmev1.AddToScheme(harnessScheme))
+
+	objects := make([]client.Object, len(instances))
+	for i, ragme := range instances {
+		objects[i] = ragme
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(harnessScheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&ragmev1.RAGme{}).
+		Build()
+	clock := clocktesting.NewFakePassiveClock(time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC))
+
+	return &reconcilerHarness{
+		client: c,
+		clock:  clock,
+		reconciler: &RAGmeReconciler{
+			Client:        c,
+			Scheme:        harnessScheme,
+			HTTPClient:    &http.Client{Transport: offlineTransport{}},
+			SkipPreflight: true,
+			Clock:         clock,
+		},
+		ctx: log.IntoContext(context.Background(), logr.Discard()),
+	}
+}
+
+// reconcile runs one reconciliation of an instance
+func (h *reconcilerHarness) reconcile(tb testing.TB, name string) ctrl.Result {
+	tb.Helper()
+	result, err := h.reconciler.Reconcile(h.ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "bench", Name: name}})
+	if err != nil {
+		tb.Fatalf("Reconcile(%s) error = %v", name, err)
+	}
+	return result
+}
+
+// get returns the stored instance
+func (h *reconcilerHarness) get(tb testing.TB, name string) *ragmev1.RAGme {
+	tb.Helper()
+	ragme := &ragmev1.RAGme{}
+	if err := h.client.Get(h.ctx, types.NamespacedName{Namespace: "bench", Name: name}, ragme); err != nil {
+		tb.Fatal(err)
+	}
+	return ragme
+}
+
+func TestReconcilerHarnessFakeClock(t *testing.T) {
+	ragme := harnessRAGme("notice")
+	start := metav1.NewTime(time.Date(2024, 6, 7, 12, 2, 0, 0, time.UTC))
+	ragme.Spec.Frontend.Notice = &ragmev1.RAGmeFrontendNotice{
+		Message:  "Downtime at 13:00",
+		Schedule: &ragmev1.RAGmeNoticeSchedule{Start: &start},
+	}
+	h := newReconcilerHarness(t, ragme)
+
+	if result := h.reconcile(t, "notice"); result.RequeueAfter != 2*time.Minute {
+		t.Errorf("RequeueAfter = %v, want the start of the notice", result.RequeueAfter)
+	}
+	if got := h.get(t, "notice"); got.Status.Phase != "Ready" || len(got.Status.Notices) != 0 {
+		t.Errorf("phase %s with notices %+v, want Ready without notice", got.Status.Phase, got.Status.Notices)
+	}
+
+	h.clock.SetTime(start.Add(time.Minute))
+	h.reconcile(t, "notice")
+	if notices := h.get(t, "notice").Status.Notices; len(notices) != 1 || notices[0].Source != noticeSourceSpec {
+		t.Errorf("notices = %+v, want the spec notice once the clock passed its start", notices)
+	}
+}
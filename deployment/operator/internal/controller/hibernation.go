@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	// hibernationLookback bounds the search for the previous and next firing
+	// of a schedule, weekly windows are the longest supported
+	hibernationLookback = 8 * 24 * time.Hour
+
+	// hibernationRequeueSlack delays the requeue past a transition so the
+	// schedule has fired when the instance is reconciled
+	hibernationRequeueSlack = 5 * time.Second
+)
+
+// cronFields lists the bounds of the five fields of a cron expression
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// cronSchedule is a parsed five-field cron expression. Each field is a bitset
+// of the values it matches.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// When both day fields are restricted, a day matching either one matches
+	domAny, dowAny bool
+}
+
+// parseCron parses a standard five-field cron expression supporting values,
+// ranges, lists and steps, e.g. "0 19 * * 1-5" or "*/30 8-18 * * *"
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(cronFields), len(fields))
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseCronField(field, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cronFields[i].name, err)
+		}
+		bits[i] = b
+	}
+	// Both 0 and 7 are Sunday
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &cronSchedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField returns the bitset of the values matched by a cron field
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		span, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			s, err := strconv.Atoi(part[i+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			span, step = part[:i], s
+		}
+
+		lo, hi := min, max
+		if span != "*" {
+			bounds := strings.SplitN(span, "-", 2)
+			var err error
+			if lo, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			switch {
+			case len(bounds) == 2:
+				if hi, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+			case step == 1:
+				hi = lo
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// matches reports whether the schedule fires at the minute of t
+func (s *cronSchedule) matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 || s.hour&(1<<uint(t.Hour())) == 0 || s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// prev returns the last time the schedule fired at or before t, or the zero
+// time when it did not fire within the lookback
+func (s *cronSchedule) prev(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for end := t.Add(-hibernationLookback); t.After(end); t = t.Add(-time.Minute) {
+		if s.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// next returns the next time the schedule fires after t, or the zero time
+// when it does not fire within the lookback
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	for end := t.Add(hibernationLookback); t.Before(end); t = t.Add(time.Minute) {
+		if s.matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// evaluateHibernation reports whether the instance hibernates at now, whether
+// a schedule window is the cause, and when the next window starts or ends
+func evaluateHibernation(hibernation ragmev1.RAGmeHibernation, now time.Time) (bool, bool, time.Time, error) {
+	location := time.UTC
+	if hibernation.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(hibernation.TimeZone); err != nil {
+			return false, false, time.Time{}, fmt.Errorf("timeZone: %w", err)
+		}
+	}
+	now = now.In(location)
+
+	inWindow := false
+	var next time.Time
+	for i, window := range hibernation.Windows {
+		sleep, err := parseCron(window.Sleep)
+		if err != nil {
+			return false, false, time.Time{}, fmt.Errorf("windows[%d].sleep: %w", i, err)
+		}
+		wake, err := parseCron(window.Wake)
+		if err != nil {
+			return false, false, time.Time{}, fmt.Errorf("windows[%d].wake: %w", i, err)
+		}
+
+		// The window is open when its sleep schedule fired after its wake schedule
+		if lastSleep := sleep.prev(now); !lastSleep.IsZero() && lastSleep.After(wake.prev(now)) {
+			inWindow = true
+		}
+		for _, t := range []time.Time{sleep.next(now), wake.next(now)} {
+			if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return hibernation.Enabled || inWindow, inWindow, next, nil
+}
+
+// setHibernationStatus evaluates the hibernation schedule at now and records
+// the outcome in status, where the service builders read it
+func setHibernationStatus(ragme *ragmev1.RAGme, now time.Time) error {
+	hibernation := ragme.Spec.Hibernation
+	if !hibernation.Enabled && len(hibernation.Windows) == 0 {
+		ragme.Status.Hibernation = ragmev1.RAGmeHibernationStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeHibernated)
+		return nil
+	}
+
+	hibernated, inWindow, next, err := evaluateHibernation(hibernation, now)
+	if err != nil {
+		return err
+	}
+
+	status := ragmev1.RAGmeHibernationStatus{Hibernated: hibernated}
+	if !next.IsZero() {
+		status.NextTransition = &metav1.Time{Time: next}
+	}
+	ragme.Status.Hibernation = status
+
+	switch {
+	case hibernation.Enabled:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeHibernated,
+			conditions.ReasonHibernationRequested, "Hibernation is enabled, the stateless components are scaled to zero")
+	case inWindow:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeHibernated,
+			conditions.ReasonHibernationWindow, "A hibernation window is open, the stateless components are scaled to zero")
+	default:
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeHibernated,
+			conditions.ReasonAwake, "No hibernation window is open")
+	}
+	return nil
+}
+
+// hibernationReplicas returns the replicas of a stateless service, which are
+// all scaled to zero while the instance hibernates
+func hibernationReplicas(ragme *ragmev1.RAGme, replicas int32) int32 {
+	if ragme.Status.Hibernation.Hibernated {
+		return 0
+	}
+	return replicas
+}
+
+// weaviateReplicas returns the replicas of a Weaviate shard, stopped while the
+// instance hibernates when stopVectorDB is set
+func weaviateReplicas(ragme *ragmev1.RAGme) int32 {
+	if ragme.Status.Hibernation.Hibernated && ragme.Spec.Hibernation.StopVectorDB {
+		return 0
+	}
+	return 1
+}
+
+// hibernationResult shortens the requeue of result so the instance is
+// reconciled when the next hibernation window starts or ends
+func hibernationResult(ragme *ragmev1.RAGme, result ctrl.Result, now time.Time) ctrl.Result {
+	next := ragme.Status.Hibernation.NextTransition
+	if next == nil {
+		return result
+	}
+	return requeueBefore(result, next.Sub(now)+hibernationRequeueSlack)
+}
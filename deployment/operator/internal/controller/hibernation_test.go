@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestParseCron(t *testing.T) {
+	// 2024-01-01 is a Monday
+	monday := time.Date(2024, 1, 1, 19, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr    string
+		at      time.Time
+		want    bool
+		wantErr bool
+	}{
+		{expr: "0 19 * * 1-5", at: monday, want: true},
+		{expr: "0 19 * * 1-5", at: monday.AddDate(0, 0, 5), want: false},
+		{expr: "*/15 * * * *", at: monday.Add(45 * time.Minute), want: true},
+		{expr: "*/15 * * * *", at: monday.Add(50 * time.Minute), want: false},
+		{expr: "0 8,19 * * *", at: monday, want: true},
+		{expr: "0 19 * * 7", at: monday.AddDate(0, 0, 6), want: true},
+		{expr: "0 19 15 * 1", at: monday, want: true},
+		{expr: "0 19 15 * 2", at: monday, want: false},
+		{expr: "0 19 * *", wantErr: true},
+		{expr: "60 * * * *", wantErr: true},
+		{expr: "*/0 * * * *", wantErr: true},
+		{expr: "0 20-8 * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCron() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := schedule.matches(tt.at); got != tt.want {
+				t.Errorf("matches(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateHibernation(t *testing.T) {
+	hibernation := ragmev1.RAGmeHibernation{
+		Windows: []ragmev1.RAGmeHibernationWindow{
+			{Sleep: "0 20 * * 1-4", Wake: "0 7 * * 2-5"},
+			{Sleep: "0 20 * * 5", Wake: "0 7 * * 1"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		now      time.Time
+		want     bool
+		wantNext time.Time
+	}{
+		{
+			name:     "weekday business hours",
+			now:      time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC),
+			want:     false,
+			wantNext: time.Date(2024, 1, 3, 20, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday night",
+			now:      time.Date(2024, 1, 3, 23, 30, 0, 0, time.UTC),
+			want:     true,
+			wantNext: time.Date(2024, 1, 4, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekend",
+			now:      time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC),
+			want:     true,
+			wantNext: time.Date(2024, 1, 8, 7, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "wake time",
+			now:      time.Date(2024, 1, 8, 7, 0, 0, 0, time.UTC),
+			want:     false,
+			wantNext: time.Date(2024, 1, 8, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, inWindow, next, err := evaluateHibernation(hibernation, tt.now)
+			if err != nil {
+				t.Fatalf("evaluateHibernation() error = %v", err)
+			}
+			if got != tt.want || inWindow != tt.want {
+				t.Errorf("evaluateHibernation() = %v (in window %v), want %v", got, inWindow, tt.want)
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("next transition = %s, want %s", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestEvaluateHibernationTimeZone(t *testing.T) {
+	hibernation := ragmev1.RAGmeHibernation{
+		TimeZone: "America/New_York",
+		Windows:  []ragmev1.RAGmeHibernationWindow{{Sleep: "0 19 * * *", Wake: "0 7 * * *"}},
+	}
+
+	// 23:00 UTC is 18:00 in New York in January
+	hibernated, _, _, err := evaluateHibernation(hibernation, time.Date(2024, 1, 3, 23, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("evaluateHibernation() error = %v", err)
+	}
+	if hibernated {
+		t.Error("expected the instance to be awake before 19:00 in New York")
+	}
+}
+
+func TestHibernationReplicas(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if got := hibernationReplicas(ragme, 2); got != 2 {
+		t.Errorf("hibernationReplicas() = %d, want 2 while awake", got)
+	}
+
+	ragme.Status.Hibernation.Hibernated = true
+	if got := hibernationReplicas(ragme, 2); got != 0 {
+		t.Errorf("hibernationReplicas() = %d, want 0 while hibernated", got)
+	}
+	if got := weaviateReplicas(ragme); got != 1 {
+		t.Errorf("weaviateReplicas() = %d, want 1 without stopVectorDB", got)
+	}
+	ragme.Spec.Hibernation.StopVectorDB = true
+	if got := weaviateReplicas(ragme); got != 0 {
+		t.Errorf("weaviateReplicas() = %d, want 0 with stopVectorDB", got)
+	}
+}
+
+func TestHibernationResult(t *testing.T) {
+	now := time.Date(2024, 1, 3, 19, 58, 0, 0, time.UTC)
+	ragme := &ragmev1.RAGme{}
+
+	resync := ctrl.Result{RequeueAfter: 5 * time.Minute}
+	if got := hibernationResult(ragme, resync, now); got != resync {
+		t.Errorf("hibernationResult() = %v, want %v without a transition", got, resync)
+	}
+
+	ragme.Spec.Hibernation.Windows = []ragmev1.RAGmeHibernationWindow{{Sleep: "0 20 * * *", Wake: "0 7 * * *"}}
+	if err := setHibernationStatus(ragme, now); err != nil {
+		t.Fatalf("setHibernationStatus() error = %v", err)
+	}
+	want := 2*time.Minute + hibernationRequeueSlack
+	if got := hibernationResult(ragme, resync, now); got.RequeueAfter != want {
+		t.Errorf("hibernationResult() requeues after %s, want %s", got.RequeueAfter, want)
+	}
+}
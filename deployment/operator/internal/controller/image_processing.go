@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	// imageProcessorComponent runs the agent in its image mode
+	imageProcessorComponent = "image-processor"
+
+	defaultGPUResource = "nvidia.com/gpu"
+)
+
+// defaultImageFileTypes are routed to the image workers when none are configured
+var defaultImageFileTypes = []string{"png", "jpg", "jpeg", "tif", "tiff", "bmp", "gif", "webp"}
+
+// imageFileTypes returns the extensions routed to the image workers
+func imageFileTypes(ragme *ragmev1.RAGme) []string {
+	if len(ragme.Spec.Processing.Images.FileTypes) > 0 {
+		return normalizeFileTypes(ragme.Spec.Processing.Images.FileTypes)
+	}
+	return defaultImageFileTypes
+}
+
+// imageWorkers returns the replicas of the image-processor
+func imageWorkers(ragme *ragmev1.RAGme) int32 {
+	if ragme.Spec.Processing.Images.Workers > 0 {
+		return ragme.Spec.Processing.Images.Workers
+	}
+	return 1
+}
+
+// applyImageProcessing configures the image workers and their accelerators.
+// The agent leaves the image files to them, see routedFileTypes.
+func applyImageProcessing(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	images := ragme.Spec.Processing.Images
+	if !images.Enabled || serviceName != imageProcessorComponent {
+		return
+	}
+
+	container := &podSpec.Containers[0]
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "RAGME_AGENT_MODE", Value: "images"},
+		corev1.EnvVar{Name: "RAGME_AGENT_FILE_TYPES", Value: strings.Join(imageFileTypes(ragme), ",")},
+	)
+	if images.Concurrency > 0 {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: "RAGME_AGENT_CONCURRENCY", Value: strconv.Itoa(int(images.Concurrency)),
+		})
+	}
+	applyAccelerator(podSpec, images.GPU, images.GPUResource, images.NodeSelector, images.Tolerations)
+}
+
+// applyAccelerator requests the GPUs of a worker and places it on the
+// accelerated nodes
+func applyAccelerator(podSpec *corev1.PodSpec, gpu int32, gpuResource string, nodeSelector map[string]string, tolerations []corev1.Toleration) {
+	if gpu > 0 {
+		if gpuResource == "" {
+			gpuResource = defaultGPUResource
+		}
+		container := &podSpec.Containers[0]
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceName(gpuResource)] = *resource.NewQuantity(int64(gpu), resource.DecimalSI)
+	}
+	podSpec.NodeSelector = mergeStringMaps(podSpec.NodeSelector, nodeSelector)
+	podSpec.Tolerations = append(podSpec.Tolerations, tolerations...)
+}
+
+// reconcileImageProcessing runs the image-processor Deployment, or removes it
+// when image processing is disabled. It shares the watch directory of the
+// agent and needs no Service.
+func (r *RAGmeReconciler) reconcileImageProcessing(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Processing.Images.Enabled {
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ragme.Name, imageProcessorComponent),
+			Namespace: ragme.Namespace,
+		}}
+		if err := r.Delete(ctx, deployment); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	deployment := r.createRAGmeServiceDeployment(ragme, imageProcessorComponent)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+	found.Spec = deployment.Spec
+	found.Labels = mergeStringMaps(found.Labels, deployment.Labels)
+	found.Annotations = mergeStringMaps(found.Annotations, deployment.Annotations)
+	return r.updateOrRecreate(ctx, ragme, found, deployment)
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestImageProcessorDeployment(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Images = ragmev1.RAGmeImages{Registry: "localhost:5001", Tag: "latest"}
+	ragme.Spec.Processing.Images = ragmev1.RAGmeImageProcessing{
+		Enabled:      true,
+		Workers:      3,
+		Concurrency:  2,
+		GPU:          1,
+		NodeSelector: map[string]string{"accelerator": "nvidia-l4"},
+		Tolerations:  []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}},
+	}
+
+	deployment := r.createRAGmeServiceDeployment(ragme, imageProcessorComponent)
+	if deployment.Name != "test-image-processor" || *deployment.Spec.Replicas != 3 {
+		t.Errorf("Deployment %s has %d replicas, want test-image-processor with 3", deployment.Name, *deployment.Spec.Replicas)
+	}
+	podSpec := deployment.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	if container.Image != "localhost:5001/ragme-agent:latest" || envValue(container.Env, "RAGME_AGENT_MODE") != "images" {
+		t.Errorf("container = %s %+v, want the agent image in its image mode", container.Image, container.Env)
+	}
+	if envValue(container.Env, "RAGME_AGENT_CONCURRENCY") != "2" || !strings.Contains(envValue(container.Env, "RAGME_AGENT_FILE_TYPES"), "png") {
+		t.Errorf("env = %+v, want the concurrency and the image types", container.Env)
+	}
+	if gpus := container.Resources.Limits[defaultGPUResource]; gpus.Value() != 1 {
+		t.Errorf("GPU limit = %s, want 1", gpus.String())
+	}
+	if podSpec.NodeSelector["accelerator"] != "nvidia-l4" || len(podSpec.Tolerations) != 1 {
+		t.Errorf("placement = %v %v, want the accelerated nodes", podSpec.NodeSelector, podSpec.Tolerations)
+	}
+
+	agent := r.createRAGmeServiceDeployment(ragme, "agent").Spec.Template.Spec.Containers[0]
+	if skipped := envValue(agent.Env, "RAGME_AGENT_SKIP_FILE_TYPES"); skipped != strings.Join(defaultImageFileTypes, ",") {
+		t.Errorf("agent skipped types = %q, want the images routed away", skipped)
+	}
+}
+
+func TestValidateSpecImageProcessing(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.Images = ragmev1.RAGmeImageProcessing{Enabled: true, FileTypes: []string{"png", "a/b"}, GPUResource: "gpu!"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an invalid file type and GPU resource")
+	}
+	ragme.Spec.Processing.Images = ragmev1.RAGmeImageProcessing{Enabled: true, FileTypes: []string{".PNG", "heic"}, GPUResource: "amd.com/gpu"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// ingressRoute is a path of the Ingress host and the Service it is routed to
+type ingressRoute struct {
+	Path    string
+	Service string
+	Port    int32
+}
+
+// ingressRoutes are the routes of the Ingress host, the api also serving the
+// OAuth callbacks under /auth
+var ingressRoutes = []ingressRoute{
+	{Path: "/api", Service: "api", Port: 8021},
+	{Path: "/auth", Service: "api", Port: 8021},
+	{Path: "/mcp", Service: "mcp", Port: 8022},
+	{Path: "/", Service: "frontend", Port: 8020},
+}
+
+// ingressEnabled reports whether the instance is exposed through an Ingress.
+// With anonymous access the public Ingress routes the host instead, so that
+// uploads and admin endpoints stay unreachable from outside the cluster.
+func ingressEnabled(ragme *ragmev1.RAGme) bool {
+	access := ragme.Spec.ExternalAccess
+	return access.Type == "Ingress" && access.Ingress.Enabled && access.Ingress.Host != "" &&
+		!ragme.Spec.Authentication.Anonymous.Enabled
+}
+
+// ingressTLSSecretName returns the Secret holding the certificate of the Ingress host
+func ingressTLSSecretName(ragme *ragmev1.RAGme) string {
+	if name := ragme.Spec.ExternalAccess.Ingress.TLSSecretName; name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s-tls", ragme.Name)
+}
+
+// reconcileIngress routes the Ingress host to the frontend, api and mcp
+// services with externalAccess.type Ingress, and removes the Ingress otherwise
+func (r *RAGmeReconciler) reconcileIngress(ctx context.Context, ragme *ragmev1.RAGme) error {
+	found := &networkingv1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Name: ragme.Name, Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !ingressEnabled(ragme) {
+		if exists && metav1.IsControlledBy(found, ragme) {
+			return r.Delete(ctx, found)
+		}
+		return nil
+	}
+
+	ingress := createIngress(ragme)
+	if err := r.setOwner(ragme, ingress); err != nil {
+		return err
+	}
+	if !exists {
+		return r.Create(ctx, ingress)
+	}
+
+	if !reflect.DeepEqual(found.Spec, ingress.Spec) || !reflect.DeepEqual(found.Annotations, ingress.Annotations) {
+		found.Spec = ingress.Spec
+		found.Annotations = ingress.Annotations
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+func createIngress(ragme *ragmev1.RAGme) *networkingv1.Ingress {
+	ingressConfig := ragme.Spec.ExternalAccess.Ingress
+	prefix := networkingv1.PathTypePrefix
+
+	paths := []networkingv1.HTTPIngressPath{}
+	for _, route := range ingressRoutes {
+		paths = append(paths, networkingv1.HTTPIngressPath{
+			Path:     route.Path,
+			PathType: &prefix,
+			Backend: networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: fmt.Sprintf("%s-%s", ragme.Name, route.Service),
+					Port: networkingv1.ServiceBackendPort{Number: route.Port},
+				},
+			},
+		})
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ragme.Name,
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+			Annotations: ingressAnnotations(ragme),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: className(ragme.Status.Classes.IngressClassName),
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: ingressConfig.Host,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+					},
+				},
+			},
+		},
+	}
+	if ingressConfig.TLSEnabled {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{Hosts: []string{ingressConfig.Host}, SecretName: ingressTLSSecretName(ragme)},
+		}
+	}
+	return ingress
+}
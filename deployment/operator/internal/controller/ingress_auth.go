@@ -0,0 +1,431 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultOAuth2ProxyImage = "quay.io/oauth2-proxy/oauth2-proxy:v7.6.0"
+	oauth2ProxyPort         = 4180
+
+	oauth2ProxyCookieSecretKey = "cookie-secret"
+	oauth2ProxyClientSecretKey = "client-secret"
+)
+
+// protectedBackend is the Service and paths an operational route exposes
+type protectedBackend struct {
+	Service string
+	Port    int32
+	Paths   []string
+}
+
+// protectedBackends are the operational endpoints that can be exposed behind
+// oauth2-proxy, none of them authenticates users on its own
+var protectedBackends = map[string]protectedBackend{
+	"minio-console": {Service: "minio", Port: 9001, Paths: []string{"/"}},
+	"weaviate":      {Service: "weaviate", Port: 8080, Paths: []string{"/"}},
+	"api-docs":      {Service: "api", Port: 8021, Paths: []string{"/docs", "/openapi.json"}},
+}
+
+// oauth2ProxyProviders are the configured OAuth providers oauth2-proxy supports, in order of preference
+var oauth2ProxyProviders = []string{"google", "github"}
+
+// ingressAuthEnabled reports whether the operational routes are exposed behind oauth2-proxy
+func ingressAuthEnabled(ragme *ragmev1.RAGme) bool {
+	ingress := ragme.Spec.ExternalAccess.Ingress
+	return ingress.Enabled && ingress.Auth.Enabled
+}
+
+// ingressAuthProvider returns the OAuth provider signing users in to
+// oauth2-proxy, or "" when no supported provider is enabled
+func ingressAuthProvider(ragme *ragmev1.RAGme) string {
+	if provider := ragme.Spec.ExternalAccess.Ingress.Auth.Provider; provider != "" {
+		return provider
+	}
+	providers := oauthProviders(ragme)
+	for _, name := range oauth2ProxyProviders {
+		if providers[name].Enabled {
+			return name
+		}
+	}
+	return ""
+}
+
+// oauth2ProxyName returns the name of the oauth2-proxy Deployment, Service,
+// Secret and sign-in Ingress
+func oauth2ProxyName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-oauth2-proxy", ragme.Name)
+}
+
+// reconcileIngressAuth runs oauth2-proxy and the Ingresses of the operational
+// routes, or removes them when disabled. The routes Ingress asks oauth2-proxy
+// to authenticate every request through the nginx external authentication,
+// and the sign-in Ingress serves the /oauth2 endpoints of the proxy on the
+// route hosts.
+func (r *RAGmeReconciler) reconcileIngressAuth(ctx context.Context, ragme *ragmev1.RAGme) error {
+	name := oauth2ProxyName(ragme)
+	objects := []client.Object{
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: ragme.Name + "-protected", Namespace: ragme.Namespace}},
+		&networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ragme.Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ragme.Namespace}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ragme.Namespace}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ragme.Namespace}},
+	}
+	if !ingressAuthEnabled(ragme) {
+		for _, obj := range objects {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := r.reconcileOAuth2ProxySecret(ctx, ragme); err != nil {
+		return err
+	}
+
+	deployment := createOAuth2ProxyDeployment(ragme)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	service := createOAuth2ProxyService(ragme)
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, service); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	for _, ingress := range []*networkingv1.Ingress{createProtectedIngress(ragme), createOAuth2ProxyIngress(ragme)} {
+		if err := r.reconcileAuthIngress(ctx, ragme, ingress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileOAuth2ProxySecret keeps the client secret of the provider and a
+// cookie secret, generated once, in the Secret read by oauth2-proxy
+func (r *RAGmeReconciler) reconcileOAuth2ProxySecret(ctx context.Context, ragme *ragmev1.RAGme) error {
+	clientSecret := []byte(oauthProviders(ragme)[ingressAuthProvider(ragme)].ClientSecret)
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: oauth2ProxyName(ragme), Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		if string(found.Data[oauth2ProxyClientSecretKey]) == string(clientSecret) {
+			return nil
+		}
+		if found.Data == nil {
+			found.Data = map[string][]byte{}
+		}
+		found.Data[oauth2ProxyClientSecretKey] = clientSecret
+		return r.Update(ctx, found)
+	}
+
+	// A 32 characters secret selects AES-256 for the cookies
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauth2ProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			oauth2ProxyCookieSecretKey: []byte(hex.EncodeToString(buf)),
+			oauth2ProxyClientSecretKey: clientSecret,
+		},
+	}
+	if err := r.setOwner(ragme, secret); err != nil {
+		return err
+	}
+	return r.Create(ctx, secret)
+}
+
+// reconcileAuthIngress creates or updates an Ingress of the operational routes
+func (r *RAGmeReconciler) reconcileAuthIngress(ctx context.Context, ragme *ragmev1.RAGme, ingress *networkingv1.Ingress) error {
+	if err := r.setOwner(ragme, ingress); err != nil {
+		return err
+	}
+	found := &networkingv1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, ingress)
+	} else if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(found.Spec, ingress.Spec) || !reflect.DeepEqual(found.Annotations, ingress.Annotations) {
+		found.Spec = ingress.Spec
+		found.Annotations = ingress.Annotations
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// createOAuth2ProxyDeployment returns the Deployment of oauth2-proxy. It only
+// answers the authentication subrequests of the Ingress controller, the
+// routes are proxied by the Ingress controller itself.
+func createOAuth2ProxyDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "oauth2-proxy",
+		"instance":  ragme.Name,
+	}
+
+	auth := ragme.Spec.ExternalAccess.Ingress.Auth
+	image := auth.Image
+	if image == "" {
+		image = defaultOAuth2ProxyImage
+	}
+	provider := ingressAuthProvider(ragme)
+	emailDomains := auth.EmailDomains
+	if len(emailDomains) == 0 {
+		emailDomains = []string{"*"}
+	}
+
+	args := []string{
+		"--provider=" + provider,
+		"--client-id=" + oauthProviders(ragme)[provider].ClientID,
+		fmt.Sprintf("--http-address=0.0.0.0:%d", oauth2ProxyPort),
+		"--upstream=static://202",
+		"--reverse-proxy=true",
+		"--set-xauthrequest=true",
+		"--skip-provider-button=true",
+		fmt.Sprintf("--cookie-secure=%t", ragme.Spec.ExternalAccess.Ingress.TLSEnabled),
+	}
+	for _, domain := range emailDomains {
+		args = append(args, "--email-domain="+domain)
+	}
+	for _, route := range auth.Routes {
+		args = append(args, "--whitelist-domain="+route.Host)
+	}
+
+	secretEnv := func(name, key string) corev1.EnvVar {
+		return corev1.EnvVar{Name: name, ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: oauth2ProxyName(ragme)},
+			Key:                  key,
+		}}}
+	}
+
+	// The data stores stay up when the instance hibernates, so does their access
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauth2ProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "oauth2-proxy",
+							Image: image,
+							Args:  args,
+							Env: []corev1.EnvVar{
+								secretEnv("OAUTH2_PROXY_CLIENT_SECRET", oauth2ProxyClientSecretKey),
+								secretEnv("OAUTH2_PROXY_COOKIE_SECRET", oauth2ProxyCookieSecretKey),
+							},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: oauth2ProxyPort, Name: "http"},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{Path: "/ping", Port: intstr.FromInt(oauth2ProxyPort)},
+								},
+								PeriodSeconds: 10,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createOAuth2ProxyService returns the Service of oauth2-proxy
+func createOAuth2ProxyService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "oauth2-proxy",
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oauth2ProxyName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: oauth2ProxyPort, TargetPort: intstr.FromInt(oauth2ProxyPort)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
+
+// protectedRouteHosts returns the sorted hosts of the operational routes
+func protectedRouteHosts(ragme *ragmev1.RAGme) []string {
+	hosts := []string{}
+	for _, route := range ragme.Spec.ExternalAccess.Ingress.Auth.Routes {
+		hosts = append(hosts, route.Host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// createAuthIngress returns an Ingress of the route hosts with the given rules
+func createAuthIngress(ragme *ragmev1.RAGme, name string, annotations map[string]string, rules []networkingv1.IngressRule) *networkingv1.Ingress {
+	ingressConfig := ragme.Spec.ExternalAccess.Ingress
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+			Annotations: mergeStringMaps(mergeStringMaps(nil, ingressConfig.Annotations), annotations),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: className(ragme.Status.Classes.IngressClassName),
+			Rules:            rules,
+		},
+	}
+	if ingressConfig.TLSEnabled && len(rules) > 0 {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{Hosts: protectedRouteHosts(ragme), SecretName: fmt.Sprintf("%s-protected-tls", ragme.Name)},
+		}
+	}
+	return ingress
+}
+
+// createProtectedIngress returns the Ingress of the operational routes,
+// authenticated by oauth2-proxy through the nginx external authentication
+func createProtectedIngress(ragme *ragmev1.RAGme) *networkingv1.Ingress {
+	prefix := networkingv1.PathTypePrefix
+	rules := []networkingv1.IngressRule{}
+	for _, route := range ragme.Spec.ExternalAccess.Ingress.Auth.Routes {
+		backend := protectedBackends[route.Name]
+		paths := []networkingv1.HTTPIngressPath{}
+		for _, path := range backend.Paths {
+			paths = append(paths, networkingv1.HTTPIngressPath{
+				Path:     path,
+				PathType: &prefix,
+				Backend: networkingv1.IngressBackend{
+					Service: &networkingv1.IngressServiceBackend{
+						Name: fmt.Sprintf("%s-%s", ragme.Name, backend.Service),
+						Port: networkingv1.ServiceBackendPort{Number: backend.Port},
+					},
+				},
+			})
+		}
+		rules = append(rules, networkingv1.IngressRule{
+			Host:             route.Host,
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths}},
+		})
+	}
+
+	scheme := "http"
+	if ragme.Spec.ExternalAccess.Ingress.TLSEnabled {
+		scheme = "https"
+	}
+	return createAuthIngress(ragme, ragme.Name+"-protected", map[string]string{
+		"nginx.ingress.kubernetes.io/auth-url": fmt.Sprintf("http://%s.%s.svc.cluster.local:%d/oauth2/auth",
+			oauth2ProxyName(ragme), ragme.Namespace, oauth2ProxyPort),
+		"nginx.ingress.kubernetes.io/auth-signin":           scheme + "://$host/oauth2/start?rd=$escaped_request_uri",
+		"nginx.ingress.kubernetes.io/auth-response-headers": strings.Join([]string{"X-Auth-Request-User", "X-Auth-Request-Email"}, ","),
+	}, rules)
+}
+
+// createOAuth2ProxyIngress returns the Ingress serving the sign-in and
+// callback endpoints of oauth2-proxy on the route hosts
+func createOAuth2ProxyIngress(ragme *ragmev1.RAGme) *networkingv1.Ingress {
+	prefix := networkingv1.PathTypePrefix
+	rules := []networkingv1.IngressRule{}
+	for _, host := range protectedRouteHosts(ragme) {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+				Paths: []networkingv1.HTTPIngressPath{
+					{
+						Path:     "/oauth2",
+						PathType: &prefix,
+						Backend: networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: oauth2ProxyName(ragme),
+								Port: networkingv1.ServiceBackendPort{Number: oauth2ProxyPort},
+							},
+						},
+					},
+				},
+			}},
+		})
+	}
+	return createAuthIngress(ragme, oauth2ProxyName(ragme), nil, rules)
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func ingressAuthRAGme() *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.ExternalAccess.Ingress = ragmev1.RAGmeIngressConfig{
+		Enabled:    true,
+		Host:       "ragme.example.com",
+		TLSEnabled: true,
+		Auth: ragmev1.RAGmeIngressAuth{
+			Enabled: true,
+			Routes: []ragmev1.RAGmeProtectedRoute{
+				{Name: "minio-console", Host: "console.example.com"},
+				{Name: "api-docs", Host: "docs.example.com"},
+			},
+		},
+	}
+	ragme.Spec.Authentication.OAuth.GitHub = ragmev1.RAGmeOAuthProvider{Enabled: true, ClientID: "gh-client", ClientSecret: "gh-secret"}
+	ragme.Spec.Storage.MinIO.Enabled = true
+	return ragme
+}
+
+func TestCreateProtectedIngress(t *testing.T) {
+	ragme := ingressAuthRAGme()
+	ingress := createProtectedIngress(ragme)
+
+	if got := ingress.Annotations["nginx.ingress.kubernetes.io/auth-url"]; got != "http://test-oauth2-proxy.ragme.svc.cluster.local:4180/oauth2/auth" {
+		t.Errorf("auth-url = %q", got)
+	}
+	if got := ingress.Annotations["nginx.ingress.kubernetes.io/auth-signin"]; !strings.HasPrefix(got, "https://$host/oauth2/start") {
+		t.Errorf("auth-signin = %q, want an https sign-in on the route host", got)
+	}
+	if len(ingress.Spec.Rules) != 2 {
+		t.Fatalf("rules = %+v, want one per route", ingress.Spec.Rules)
+	}
+	console := ingress.Spec.Rules[0]
+	if console.Host != "console.example.com" || console.HTTP.Paths[0].Backend.Service.Name != "test-minio" ||
+		console.HTTP.Paths[0].Backend.Service.Port.Number != 9001 {
+		t.Errorf("console rule = %+v, want the MinIO console", console)
+	}
+	if paths := ingress.Spec.Rules[1].HTTP.Paths; len(paths) != 2 || paths[0].Path != "/docs" {
+		t.Errorf("api-docs paths = %+v, want only the documentation", paths)
+	}
+	if tls := ingress.Spec.TLS; len(tls) != 1 || len(tls[0].Hosts) != 2 {
+		t.Errorf("tls = %+v, want the route hosts", tls)
+	}
+
+	signin := createOAuth2ProxyIngress(ragme)
+	if _, ok := signin.Annotations["nginx.ingress.kubernetes.io/auth-url"]; ok {
+		t.Error("sign-in Ingress must not require authentication")
+	}
+	for _, rule := range signin.Spec.Rules {
+		if path := rule.HTTP.Paths[0]; path.Path != "/oauth2" || path.Backend.Service.Name != "test-oauth2-proxy" {
+			t.Errorf("sign-in rule of %s = %+v", rule.Host, path)
+		}
+	}
+}
+
+func TestCreateOAuth2ProxyDeployment(t *testing.T) {
+	ragme := ingressAuthRAGme()
+	container := createOAuth2ProxyDeployment(ragme).Spec.Template.Spec.Containers[0]
+
+	args := strings.Join(container.Args, " ")
+	for _, want := range []string{"--provider=github", "--client-id=gh-client", "--email-domain=*", "--cookie-secure=true",
+		"--whitelist-domain=console.example.com"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args = %s, want %s", args, want)
+		}
+	}
+	if strings.Contains(args, "gh-secret") {
+		t.Error("client secret passed as an argument")
+	}
+	for _, env := range container.Env {
+		if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef.Name != "test-oauth2-proxy" {
+			t.Errorf("env %s not read from the oauth2-proxy Secret", env.Name)
+		}
+	}
+}
+
+func TestValidateSpecIngressAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*ragmev1.RAGme)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(*ragmev1.RAGme) {}},
+		{name: "no provider", mutate: func(r *ragmev1.RAGme) { r.Spec.Authentication.OAuth.GitHub.Enabled = false }, wantErr: true},
+		{name: "provider not enabled", mutate: func(r *ragmev1.RAGme) { r.Spec.ExternalAccess.Ingress.Auth.Provider = "google" }, wantErr: true},
+		{name: "no routes", mutate: func(r *ragmev1.RAGme) { r.Spec.ExternalAccess.Ingress.Auth.Routes = nil }, wantErr: true},
+		{name: "unknown route", mutate: func(r *ragmev1.RAGme) { r.Spec.ExternalAccess.Ingress.Auth.Routes[0].Name = "grafana" }, wantErr: true},
+		{name: "console without MinIO", mutate: func(r *ragmev1.RAGme) { r.Spec.Storage.MinIO.Enabled = false }, wantErr: true},
+		{name: "public host reused", mutate: func(r *ragmev1.RAGme) { r.Spec.ExternalAccess.Ingress.Auth.Routes[1].Host = "ragme.example.com" }, wantErr: true},
+		{name: "disabled", mutate: func(r *ragmev1.RAGme) {
+			r.Spec.ExternalAccess.Ingress.Auth.Enabled = false
+			r.Spec.ExternalAccess.Ingress.Auth.Routes = nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := ingressAuthRAGme()
+			tt.mutate(ragme)
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestCreateIngress(t *testing.T) {
+	ragme := harnessRAGme("web")
+	ragme.Spec.ExternalAccess.Type = "Ingress"
+	ragme.Spec.ExternalAccess.Ingress.Enabled = true
+	ragme.Spec.ExternalAccess.Ingress.Host = "ragme.example.com"
+	ragme.Spec.ExternalAccess.Ingress.TLSEnabled = true
+	ragme.Spec.ExternalAccess.Ingress.Annotations = map[string]string{"cert-manager.io/cluster-issuer": "letsencrypt"}
+
+	ingress := createIngress(ragme)
+	if ingress.Name != "web" || ingress.Annotations["cert-manager.io/cluster-issuer"] != "letsencrypt" {
+		t.Errorf("Ingress %s annotations = %v, want the configured annotations", ingress.Name, ingress.Annotations)
+	}
+	rule := ingress.Spec.Rules[0]
+	if rule.Host != "ragme.example.com" {
+		t.Errorf("host = %q, want the configured host", rule.Host)
+	}
+	backends := map[string]string{}
+	for _, path := range rule.HTTP.Paths {
+		backends[path.Path] = path.Backend.Service.Name
+	}
+	for path, service := range map[string]string{"/": "web-frontend", "/api": "web-api", "/auth": "web-api", "/mcp": "web-mcp"} {
+		if backends[path] != service {
+			t.Errorf("path %s routed to %q, want %s", path, backends[path], service)
+		}
+	}
+	if tls := ingress.Spec.TLS; len(tls) != 1 || tls[0].SecretName != "web-tls" || tls[0].Hosts[0] != "ragme.example.com" {
+		t.Errorf("tls = %+v, want the default Secret of the host", tls)
+	}
+
+	ragme.Spec.ExternalAccess.Ingress.TLSSecretName = "wildcard-cert"
+	if tls := createIngress(ragme).Spec.TLS; tls[0].SecretName != "wildcard-cert" {
+		t.Errorf("tls Secret = %s, want the configured Secret", tls[0].SecretName)
+	}
+}
+
+func TestReconcileIngress(t *testing.T) {
+	ragme := harnessRAGme("web")
+	ragme.Spec.ExternalAccess.Type = "Ingress"
+	ragme.Spec.ExternalAccess.Ingress.Enabled = true
+	ragme.Spec.ExternalAccess.Ingress.Host = "ragme.example.com"
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "web")
+
+	key := client.ObjectKey{Namespace: "bench", Name: "web"}
+	if err := h.client.Get(h.ctx, key, &networkingv1.Ingress{}); err != nil {
+		t.Fatalf("Ingress not created: %v", err)
+	}
+
+	// Anonymous access only routes the query endpoints of the host
+	current := h.get(t, "web")
+	current.Spec.Authentication.Anonymous.Enabled = true
+	current.Spec.Authentication.Anonymous.Role = "read-only"
+	if err := h.client.Update(h.ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "web")
+	if err := h.client.Get(h.ctx, key, &networkingv1.Ingress{}); err == nil {
+		t.Error("Ingress routing uploads kept with anonymous access")
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "web-public"}, &networkingv1.Ingress{}); err != nil {
+		t.Errorf("public Ingress not created: %v", err)
+	}
+
+	current = h.get(t, "web")
+	current.Spec.Authentication.Anonymous.Enabled = false
+	current.Spec.ExternalAccess.Type = "NodePort"
+	if err := h.client.Update(h.ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "web")
+	if err := h.client.Get(h.ctx, key, &networkingv1.Ingress{}); err == nil {
+		t.Error("Ingress kept after switching to NodePort")
+	}
+}
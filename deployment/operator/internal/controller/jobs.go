@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultJobTTLSeconds              = 3600
+	defaultSuccessfulJobsHistoryLimit = 3
+	defaultFailedJobsHistoryLimit     = 1
+
+	// minJobTTLSeconds leaves the operator time to read the outcome of a Job
+	minJobTTLSeconds = 60
+)
+
+// jobTTL returns the TTLSecondsAfterFinished of the Jobs created for the instance
+func jobTTL(ragme *ragmev1.RAGme) *int32 {
+	ttl := int32(defaultJobTTLSeconds)
+	if ragme.Spec.Jobs.TTLSecondsAfterFinished != nil {
+		ttl = *ragme.Spec.Jobs.TTLSecondsAfterFinished
+	}
+	return &ttl
+}
+
+// jobHistoryLimits returns the number of succeeded and failed Jobs kept per kind
+func jobHistoryLimits(ragme *ragmev1.RAGme) (succeeded, failed int) {
+	succeeded, failed = defaultSuccessfulJobsHistoryLimit, defaultFailedJobsHistoryLimit
+	if limit := ragme.Spec.Jobs.SuccessfulJobsHistoryLimit; limit != nil {
+		succeeded = int(*limit)
+	}
+	if limit := ragme.Spec.Jobs.FailedJobsHistoryLimit; limit != nil {
+		failed = int(*limit)
+	}
+	return succeeded, failed
+}
+
+// pruneJobs deletes the finished Jobs of the instance exceeding the history
+// limits. Jobs are grouped by component, tenant, load test, data source and
+// export, and the most recent Job of each group is always kept since the operator
+// reads its outcome from it.
+func (r *RAGmeReconciler) pruneJobs(ctx context.Context, ragme *ragmev1.RAGme) error {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":      "ragme",
+		"instance": ragme.Name,
+	}); err != nil {
+		return err
+	}
+
+	groups := map[string][]*batchv1.Job{}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		key := job.Labels["component"] + "/" + job.Labels["tenant"] + "/" + job.Labels["loadtest"] + "/" + job.Labels[dataSourceLabel] + "/" + job.Labels[exportLabel]
+		groups[key] = append(groups[key], job)
+	}
+
+	succeededLimit, failedLimit := jobHistoryLimits(ragme)
+	for _, group := range groups {
+		for _, job := range expiredJobs(group, succeededLimit, failedLimit) {
+			if err := r.Delete(ctx, job, client.PropagationPolicy("Background")); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expiredJobs returns the finished Jobs of a group exceeding the history
+// limits. The newest Job of the group is never returned.
+func expiredJobs(group []*batchv1.Job, succeededLimit, failedLimit int) []*batchv1.Job {
+	sort.Slice(group, func(i, j int) bool {
+		return group[j].CreationTimestamp.Before(&group[i].CreationTimestamp)
+	})
+
+	var expired []*batchv1.Job
+	succeeded, failed := 0, 0
+	for i, job := range group {
+		switch {
+		case job.Status.Succeeded > 0:
+			succeeded++
+			if i > 0 && succeeded > succeededLimit {
+				expired = append(expired, job)
+			}
+		case jobFailed(job):
+			failed++
+			if i > 0 && failed > failedLimit {
+				expired = append(expired, job)
+			}
+		}
+	}
+	return expired
+}
+
+// lastFinishedJob returns the most recent finished Job of a component of the instance, or nil
+func (r *RAGmeReconciler) lastFinishedJob(ctx context.Context, ragme *ragmev1.RAGme, component string) (*batchv1.Job, error) {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}); err != nil {
+		return nil, err
+	}
+
+	var last *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Status.Succeeded == 0 && !jobFailed(job) {
+			continue
+		}
+		if last == nil || last.CreationTimestamp.Before(&job.CreationTimestamp) {
+			last = job
+		}
+	}
+	return last, nil
+}
+
+// jobTerminationMessage reads the termination message a container of a
+// succeeded Job wrote when it finished
+func jobTerminationMessage(ctx context.Context, c client.Reader, job *batchv1.Job, container string) (string, error) {
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name == container && status.State.Terminated != nil {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no succeeded pod found")
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestExpiredJobs(t *testing.T) {
+	now := time.Now()
+	job := func(name string, age time.Duration, outcome string) *batchv1.Job {
+		job := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+		}}
+		switch outcome {
+		case "succeeded":
+			job.Status.Succeeded = 1
+		case "failed":
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		}
+		return job
+	}
+
+	tests := []struct {
+		name           string
+		group          []*batchv1.Job
+		succeededLimit int
+		failedLimit    int
+		want           []string
+	}{
+		{
+			name:           "within limits",
+			group:          []*batchv1.Job{job("a", time.Hour, "succeeded"), job("b", time.Minute, "failed")},
+			succeededLimit: 3,
+			failedLimit:    1,
+		},
+		{
+			name: "oldest succeeded jobs expire",
+			group: []*batchv1.Job{
+				job("old", 3*time.Hour, "succeeded"),
+				job("new", time.Minute, "succeeded"),
+				job("mid", time.Hour, "succeeded"),
+			},
+			succeededLimit: 1,
+			failedLimit:    1,
+			want:           []string{"mid", "old"},
+		},
+		{
+			name:           "newest job is kept with a zero limit",
+			group:          []*batchv1.Job{job("a", time.Hour, "failed"), job("b", time.Minute, "failed")},
+			succeededLimit: 3,
+			failedLimit:    0,
+			want:           []string{"a"},
+		},
+		{
+			name:           "running jobs are kept",
+			group:          []*batchv1.Job{job("a", time.Hour, "succeeded"), job("b", time.Minute, "")},
+			succeededLimit: 0,
+			failedLimit:    0,
+			want:           []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := expiredJobs(tt.group, tt.succeededLimit, tt.failedLimit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expiredJobs() returned %d jobs, want %v", len(got), tt.want)
+			}
+			for i := range got {
+				if got[i].Name != tt.want[i] {
+					t.Errorf("expiredJobs()[%d] = %s, want %s", i, got[i].Name, tt.want[i])
+				}
+			}
+		})
+	}
+}
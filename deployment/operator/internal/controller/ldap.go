@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	ldapCAMountPath = "/app/config/ldap-ca"
+	ldapCAFile      = "ca.crt"
+	ldapCheckImage  = "bitnami/openldap:latest"
+)
+
+// ldapEnv returns the LDAP configuration passed to the api and frontend
+func ldapEnv(ragme *ragmev1.RAGme) []corev1.EnvVar {
+	ldap := ragme.Spec.Authentication.LDAP
+	env := []corev1.EnvVar{
+		{Name: "LDAP_ENABLED", Value: "true"},
+		{Name: "LDAP_URL", Value: ldap.URL},
+		{Name: "LDAP_BIND_DN", Value: ldap.BindDN},
+		{Name: "LDAP_USER_SEARCH_BASE", Value: ldap.UserSearch.BaseDN},
+		{Name: "LDAP_USER_SEARCH_FILTER", Value: ldap.UserSearch.Filter},
+		{Name: "LDAP_USER_ATTRIBUTE", Value: ldap.UserSearch.Attribute},
+		{Name: "LDAP_GROUP_SEARCH_BASE", Value: ldap.GroupSearch.BaseDN},
+		{Name: "LDAP_GROUP_SEARCH_FILTER", Value: ldap.GroupSearch.Filter},
+		{Name: "LDAP_GROUP_ATTRIBUTE", Value: ldap.GroupSearch.Attribute},
+		{Name: "LDAP_START_TLS", Value: strconv.FormatBool(ldap.TLS.StartTLS)},
+		{Name: "LDAP_TLS_INSECURE_SKIP_VERIFY", Value: strconv.FormatBool(ldap.TLS.InsecureSkipVerify)},
+	}
+	if ldap.BindPasswordSecretRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name:      "LDAP_BIND_PASSWORD",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ldap.BindPasswordSecretRef},
+		})
+	}
+	if ldap.TLS.CASecretRef != nil {
+		env = append(env, corev1.EnvVar{Name: "LDAP_TLS_CA_FILE", Value: ldapCAMountPath + "/" + ldapCAFile})
+	}
+	return env
+}
+
+// ldapCAVolume returns the volume projecting the LDAP CA bundle, or nil when
+// no CA is configured
+func ldapCAVolume(ragme *ragmev1.RAGme) *corev1.Volume {
+	ref := ragme.Spec.Authentication.LDAP.TLS.CASecretRef
+	if ref == nil {
+		return nil
+	}
+	return &corev1.Volume{
+		Name: "ldap-ca",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: ref.Name,
+				Items:      []corev1.KeyToPath{{Key: ref.Key, Path: ldapCAFile}},
+			},
+		},
+	}
+}
+
+// applyLDAP configures LDAP authentication on the api and frontend pods
+func applyLDAP(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if !ragme.Spec.Authentication.LDAP.Enabled || (serviceName != "api" && serviceName != "frontend") {
+		return
+	}
+	if volume := ldapCAVolume(ragme); volume != nil {
+		mountVolume(podSpec, *volume, ldapCAMountPath)
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, ldapEnv(ragme)...)
+}
+
+// reconcileLDAPCheck runs a connection-test Job whenever the LDAP connection
+// settings change and reports its outcome in the LDAPConnectionVerified condition
+func (r *RAGmeReconciler) reconcileLDAPCheck(ctx context.Context, ragme *ragmev1.RAGme) error {
+	ldap := ragme.Spec.Authentication.LDAP
+	if !ldap.Enabled {
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeLDAPConnectionVerified)
+		return nil
+	}
+
+	// Only the settings used to bind are part of the hash, search changes do not need a new test
+	settings := fmt.Sprintf("%s|%s|%v|%v|%v|%v", ldap.URL, ldap.BindDN, ldap.BindPasswordSecretRef,
+		ldap.TLS.StartTLS, ldap.TLS.InsecureSkipVerify, ldap.TLS.CASecretRef)
+	sum := sha256.Sum256([]byte(settings))
+	job := createLDAPCheckJob(ragme, hex.EncodeToString(sum[:])[:8])
+	if err := r.setOwner(ragme, job); err != nil {
+		return err
+	}
+
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		setLDAPCondition(ragme, metav1.ConditionUnknown, conditions.ReasonChecking, "LDAP connection test is running")
+		return r.Create(ctx, job)
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case found.Status.Succeeded > 0:
+		setLDAPCondition(ragme, metav1.ConditionTrue, conditions.ReasonConnectionSucceeded,
+			fmt.Sprintf("Bound to %s as %s", ldap.URL, ldap.BindDN))
+	case jobFailed(found):
+		setLDAPCondition(ragme, metav1.ConditionFalse, conditions.ReasonConnectionFailed,
+			fmt.Sprintf("LDAP connection test failed, see the logs of job %s", found.Name))
+	default:
+		setLDAPCondition(ragme, metav1.ConditionUnknown, conditions.ReasonChecking, "LDAP connection test is running")
+	}
+	return nil
+}
+
+// setLDAPCondition sets the LDAPConnectionVerified condition
+func setLDAPCondition(ragme *ragmev1.RAGme, status metav1.ConditionStatus, reason, message string) {
+	conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeLDAPConnectionVerified, status, reason, message)
+}
+
+// jobFailed reports whether the Job has permanently failed
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func createLDAPCheckJob(ragme *ragmev1.RAGme, hash string) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "ldap-check",
+		"instance":  ragme.Name,
+	}
+
+	ldap := ragme.Spec.Authentication.LDAP
+	script := `if [ "${LDAP_START_TLS}" = "true" ]; then STARTTLS=-ZZ; fi; ` +
+		`ldapwhoami -x ${STARTTLS} -H "${LDAP_URL}" -D "${LDAP_BIND_DN}" -w "${LDAP_BIND_PASSWORD}"`
+
+	env := ldapEnv(ragme)
+	if ldap.TLS.InsecureSkipVerify {
+		env = append(env, corev1.EnvVar{Name: "LDAPTLS_REQCERT", Value: "never"})
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:    "ldap-check",
+				Image:   ldapCheckImage,
+				Command: []string{"/bin/sh", "-c", script},
+				Env:     env,
+			},
+		},
+	}
+	if volume := ldapCAVolume(ragme); volume != nil {
+		mountVolume(&podSpec, *volume, ldapCAMountPath)
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "LDAPTLS_CACERT", Value: ldapCAMountPath + "/" + ldapCAFile,
+		})
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-ldap-check-%s", ragme.Name, hash),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{2}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
@@ -0,0 +1,210 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	llmConfigKey       = "llm.json"
+	llmConfigMountPath = "/app/config/llm"
+
+	defaultLLMRouteTimeout = 60 * time.Second
+
+	// Consumption counters of the current UTC day reported by the api
+	llmTokensTodayMetric     = "ragme_llm_tokens_today"
+	llmUsersOverBudgetMetric = "ragme_llm_users_over_budget"
+)
+
+// llmConfig is the language model configuration rendered for the services
+type llmConfig struct {
+	Budget *llmBudgetConfig `json:"budget,omitempty"`
+	Routes []llmRouteConfig `json:"routes,omitempty"`
+}
+
+// llmBudgetConfig is the token budget enforced by the api
+type llmBudgetConfig struct {
+	DailyTokens           int64 `json:"dailyTokens,omitempty"`
+	PerUserDailyTokens    int64 `json:"perUserDailyTokens,omitempty"`
+	AlertThresholdPercent int32 `json:"alertThresholdPercent"`
+}
+
+// llmRouteConfig is a route of the model fallback list
+type llmRouteConfig struct {
+	Provider       string  `json:"provider"`
+	Model          string  `json:"model"`
+	TimeoutSeconds float64 `json:"timeoutSeconds"`
+}
+
+// budgetConfigured reports whether spec.llm.budget sets any cap
+func budgetConfigured(ragme *ragmev1.RAGme) bool {
+	budget := ragme.Spec.LLM.Budget
+	return budget.DailyTokens > 0 || budget.PerUserDailyTokens > 0
+}
+
+// llmConfigMapName returns the name of the ConfigMap holding the language model configuration
+func llmConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-llm", ragme.Name)
+}
+
+// renderLLMConfig returns the language model configuration of the instance
+func renderLLMConfig(ragme *ragmev1.RAGme) llmConfig {
+	config := llmConfig{}
+	if budget := ragme.Spec.LLM.Budget; budgetConfigured(ragme) {
+		config.Budget = &llmBudgetConfig{
+			DailyTokens:           budget.DailyTokens,
+			PerUserDailyTokens:    budget.PerUserDailyTokens,
+			AlertThresholdPercent: budget.AlertThresholdPercent,
+		}
+	}
+	for _, route := range ragme.Spec.LLM.Routing.Routes {
+		timeout := defaultLLMRouteTimeout
+		if parsed, err := time.ParseDuration(route.Timeout); err == nil {
+			timeout = parsed
+		}
+		config.Routes = append(config.Routes, llmRouteConfig{
+			Provider:       route.Provider,
+			Model:          route.Model,
+			TimeoutSeconds: timeout.Seconds(),
+		})
+	}
+	return config
+}
+
+// reconcileLLMConfig renders the language model configuration into the
+// ConfigMap mounted by the api, mcp and agent
+func (r *RAGmeReconciler) reconcileLLMConfig(ctx context.Context, ragme *ragmev1.RAGme) error {
+	data, err := json.MarshalIndent(renderLLMConfig(ragme), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      llmConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{llmConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[llmConfigKey] != configMap.Data[llmConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyLLM mounts the language model configuration into the services calling
+// the models. The budget is only enforced by the api, all of them follow the routes
+func applyLLM(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName == "frontend" {
+		return
+	}
+
+	mountVolume(podSpec, corev1.Volume{
+		Name: "llm",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: llmConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, llmConfigMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_LLM_CONFIG", Value: llmConfigMountPath + "/" + llmConfigKey,
+	})
+}
+
+// checkBudget reads the token consumption counters from the api and records
+// them in status, flipping BudgetExceeded when a cap is reached
+func (r *RAGmeReconciler) checkBudget(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !budgetConfigured(ragme) {
+		ragme.Status.LLM.Budget = ragmev1.RAGmeLLMBudgetStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeBudgetExceeded)
+		return nil
+	}
+	// Nothing is consumed while the api is scaled to zero
+	if ragme.Status.Hibernation.Hibernated {
+		return nil
+	}
+
+	url := operationalURL(ragme, "api", 8021, "/metrics")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := defaultHTTPClient(r.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	samples, err := parsePrometheusText(resp.Body, llmTokensTodayMetric, llmUsersOverBudgetMetric)
+	if err != nil {
+		return err
+	}
+	setBudgetStatus(ragme, samples, r.now())
+	return nil
+}
+
+// setBudgetStatus records the consumption counters reported by the api and
+// sets the BudgetExceeded condition
+func setBudgetStatus(ragme *ragmev1.RAGme, samples []promSample, now time.Time) {
+	budget := ragme.Spec.LLM.Budget
+	status := ragmev1.RAGmeLLMBudgetStatus{
+		TokensToday:     int64(sumSamples(samples, llmTokensTodayMetric, nil)),
+		UsersOverBudget: int32(sumSamples(samples, llmUsersOverBudgetMetric, nil)),
+		ObservedAt:      &metav1.Time{Time: now},
+	}
+	if budget.DailyTokens > 0 {
+		status.UsedPercent = int32(status.TokensToday * 100 / budget.DailyTokens)
+	}
+	ragme.Status.LLM.Budget = status
+
+	set := func(state metav1.ConditionStatus, reason, message string) {
+		conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeBudgetExceeded, state, reason, message)
+	}
+	switch {
+	case budget.DailyTokens > 0 && status.TokensToday >= budget.DailyTokens:
+		set(metav1.ConditionTrue, conditions.ReasonDailyBudgetExceeded,
+			fmt.Sprintf("%d tokens consumed today, the daily cap of %d is reached", status.TokensToday, budget.DailyTokens))
+	case status.UsersOverBudget > 0:
+		set(metav1.ConditionTrue, conditions.ReasonUserBudgetExceeded,
+			fmt.Sprintf("%d users reached their daily cap of %d tokens", status.UsersOverBudget, budget.PerUserDailyTokens))
+	case budget.DailyTokens > 0 && status.UsedPercent >= budget.AlertThresholdPercent:
+		set(metav1.ConditionFalse, conditions.ReasonBudgetThresholdReached,
+			fmt.Sprintf("%d%% of the daily cap consumed (alert threshold %d%%)", status.UsedPercent, budget.AlertThresholdPercent))
+	default:
+		set(metav1.ConditionFalse, conditions.ReasonWithinBudget,
+			fmt.Sprintf("%d tokens consumed today", status.TokensToday))
+	}
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestSetBudgetStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		tokens     float64
+		usersOver  float64
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{name: "within budget", tokens: 1000, wantStatus: metav1.ConditionFalse, wantReason: conditions.ReasonWithinBudget},
+		{name: "alert threshold", tokens: 8500, wantStatus: metav1.ConditionFalse, wantReason: conditions.ReasonBudgetThresholdReached},
+		{name: "daily cap", tokens: 10000, wantStatus: metav1.ConditionTrue, wantReason: conditions.ReasonDailyBudgetExceeded},
+		{name: "user cap", tokens: 1000, usersOver: 2, wantStatus: metav1.ConditionTrue, wantReason: conditions.ReasonUserBudgetExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.LLM.Budget = ragmev1.RAGmeLLMBudget{DailyTokens: 10000, PerUserDailyTokens: 500, AlertThresholdPercent: 80}
+			setBudgetStatus(ragme, []promSample{
+				{Name: llmTokensTodayMetric, Value: tt.tokens},
+				{Name: llmUsersOverBudgetMetric, Value: tt.usersOver},
+			}, now)
+
+			if got := ragme.Status.LLM.Budget.TokensToday; got != int64(tt.tokens) {
+				t.Errorf("tokensToday = %d, want %d", got, int64(tt.tokens))
+			}
+			condition := meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeBudgetExceeded)
+			if condition == nil || condition.Status != tt.wantStatus || condition.Reason != tt.wantReason {
+				t.Errorf("BudgetExceeded = %+v, want %s/%s", condition, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestRenderLLMConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if config := renderLLMConfig(ragme); config.Budget != nil {
+		t.Errorf("budget = %+v, want nil without caps", config.Budget)
+	}
+
+	ragme.Spec.LLM.Budget.PerUserDailyTokens = 500
+	(&RAGmeReconciler{}).setDefaults(ragme)
+	config := renderLLMConfig(ragme)
+	if config.Budget == nil || config.Budget.PerUserDailyTokens != 500 || config.Budget.AlertThresholdPercent != 80 {
+		t.Errorf("budget = %+v, want the per-user cap and the default threshold", config.Budget)
+	}
+}
+
+func TestRenderLLMRoutes(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.LLM.Routing.Routes = []ragmev1.RAGmeLLMRoute{
+		{Provider: "openai", Model: "gpt-4o-mini", Timeout: "30s"},
+		{Provider: "friendli", Model: "meta-llama-3.1-8b-instruct"},
+	}
+
+	routes := renderLLMConfig(ragme).Routes
+	if len(routes) != 2 || routes[0].Provider != "openai" || routes[1].Provider != "friendli" {
+		t.Fatalf("routes = %+v, want the spec order", routes)
+	}
+	if routes[0].TimeoutSeconds != 30 || routes[1].TimeoutSeconds != 60 {
+		t.Errorf("timeouts = %v and %v, want 30 and the 60 default", routes[0].TimeoutSeconds, routes[1].TimeoutSeconds)
+	}
+}
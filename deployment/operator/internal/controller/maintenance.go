@@ -0,0 +1,75 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	maintenanceReadOnly = "readOnly"
+	maintenanceFull     = "full"
+)
+
+// defaultMaintenanceMessages are shown in the frontend banner when the spec sets no message
+var defaultMaintenanceMessages = map[string]string{
+	maintenanceReadOnly: "Maintenance in progress: search is available, uploads are paused.",
+	maintenanceFull:     "Maintenance in progress: RAGme will be back shortly.",
+}
+
+// maintenanceReplicas returns the replicas of a service during maintenance.
+// The agent is stopped so nothing is ingested, and full maintenance also
+// stops the api and mcp.
+func maintenanceReplicas(ragme *ragmev1.RAGme, serviceName string, replicas int32) int32 {
+	switch ragme.Spec.MaintenanceMode {
+	case maintenanceReadOnly:
+		if serviceName == "agent" || serviceName == imageProcessorComponent || serviceName == transcriberComponent {
+			return 0
+		}
+	case maintenanceFull:
+		if serviceName != "frontend" {
+			return 0
+		}
+	}
+	return replicas
+}
+
+// applyMaintenance flips the api and mcp into read-only mode and configures
+// the frontend maintenance banner
+func applyMaintenance(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	mode := ragme.Spec.MaintenanceMode
+	if mode == "" {
+		return
+	}
+
+	switch serviceName {
+	case "api", "mcp":
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_READ_ONLY", Value: "true",
+		})
+	case "frontend":
+		message := ragme.Spec.MaintenanceMessage
+		if message == "" {
+			message = defaultMaintenanceMessages[mode]
+		}
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, []corev1.EnvVar{
+			{Name: "RAGME_MAINTENANCE_MODE", Value: mode},
+			{Name: "RAGME_MAINTENANCE_BANNER", Value: message},
+		}...)
+	}
+}
+
+// setMaintenanceCondition reports the maintenance mode of the instance
+func setMaintenanceCondition(ragme *ragmev1.RAGme) {
+	switch ragme.Spec.MaintenanceMode {
+	case maintenanceReadOnly:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMaintenance,
+			conditions.ReasonReadOnlyMaintenance, "Ingestion is stopped and writes are rejected, search is available")
+	case maintenanceFull:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMaintenance,
+			conditions.ReasonFullMaintenance, "Only the frontend runs, showing the maintenance banner")
+	default:
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeMaintenance)
+	}
+}
@@ -0,0 +1,32 @@
+package controller
+
+import (
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestMaintenanceReplicas(t *testing.T) {
+	tests := []struct {
+		mode    string
+		service string
+		want    int32
+	}{
+		{mode: "", service: "agent", want: 2},
+		{mode: maintenanceReadOnly, service: "agent", want: 0},
+		{mode: maintenanceReadOnly, service: "api", want: 2},
+		{mode: maintenanceFull, service: "api", want: 0},
+		{mode: maintenanceFull, service: "mcp", want: 0},
+		{mode: maintenanceFull, service: "frontend", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.service, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.MaintenanceMode = tt.mode
+			if got := maintenanceReplicas(ragme, tt.service, 2); got != tt.want {
+				t.Errorf("maintenanceReplicas() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	// BreakGlassAnnotation set to "true" on a generated Deployment or Service
+	// lets it be edited by hand. The operator leaves the resource alone until
+	// the annotation is removed.
+	BreakGlassAnnotation = "ragme.io/break-glass"
+
+	// ManagedResourcesWebhookPath is where the guard of the generated resources is served
+	ManagedResourcesWebhookPath = "/validate-ragme-managed-resources"
+
+	// DefaultOperatorUsername is the identity of the operator deployed from config/manager
+	DefaultOperatorUsername = "system:serviceaccount:ragme-operator-system:ragme-operator-controller-manager"
+)
+
+// ManagedResourceGuard rejects manual updates of the operator-managed fields
+// of the Deployments and Services generated for a RAGme, which the operator
+// would otherwise silently revert on its next pass
+type ManagedResourceGuard struct {
+	// OperatorUsername is the user the operator's own updates are made as
+	OperatorUsername string
+
+	decoder *admission.Decoder
+}
+
+// SetupWebhookWithManager registers the guard with the webhook server of the manager
+func (g *ManagedResourceGuard) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if g.OperatorUsername == "" {
+		return fmt.Errorf("the operator username is required to let the operator update its resources")
+	}
+	g.decoder = admission.NewDecoder(mgr.GetScheme())
+	mgr.GetWebhookServer().Register(ManagedResourcesWebhookPath, &webhook.Admission{Handler: g})
+	return nil
+}
+
+// Handle admits an update of a Deployment or Service unless it changes a
+// managed field of a resource controlled by a RAGme
+func (g *ManagedResourceGuard) Handle(_ context.Context, req admission.Request) admission.Response {
+	if req.UserInfo.Username == g.OperatorUsername {
+		return admission.Allowed("")
+	}
+
+	var oldObj, newObj client.Object
+	switch req.Kind {
+	case metav1.GroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment")):
+		oldObj, newObj = &appsv1.Deployment{}, &appsv1.Deployment{}
+	case metav1.GroupVersionKind(corev1.SchemeGroupVersion.WithKind("Service")):
+		oldObj, newObj = &corev1.Service{}, &corev1.Service{}
+	default:
+		return admission.Allowed("")
+	}
+	if err := g.decoder.DecodeRaw(req.OldObject, oldObj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if err := g.decoder.Decode(req, newObj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	owner := metav1.GetControllerOf(oldObj)
+	if owner == nil || owner.Kind != "RAGme" || schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind).Group != ragmev1.GroupVersion.Group {
+		return admission.Allowed("")
+	}
+	if breakGlass(newObj) {
+		return admission.Allowed("").WithWarnings(fmt.Sprintf(
+			"%s is excluded from reconciliation until the %s annotation is removed", newObj.GetName(), BreakGlassAnnotation))
+	}
+
+	fields := managedFieldChanges(oldObj, newObj)
+	if len(fields) == 0 {
+		return admission.Allowed("")
+	}
+	return admission.Denied(fmt.Sprintf(
+		"%s is managed by RAGme %s, change its spec instead of %v, or set the %s=true annotation to edit it by hand",
+		newObj.GetName(), owner.Name, fields, BreakGlassAnnotation))
+}
+
+// managedFieldChanges returns the operator-managed fields an update changes.
+// The pod template annotations are left out so kubectl rollout restart keeps
+// working, scaling goes through the scale subresource and is not guarded.
+func managedFieldChanges(oldObj, newObj client.Object) []string {
+	switch oldObj := oldObj.(type) {
+	case *appsv1.Deployment:
+		oldSpec, newSpec := oldObj.Spec.DeepCopy(), newObj.(*appsv1.Deployment).Spec.DeepCopy()
+		oldSpec.Template.Annotations, newSpec.Template.Annotations = nil, nil
+		if !equality.Semantic.DeepEqual(oldSpec, newSpec) {
+			return []string{"spec"}
+		}
+	case *corev1.Service:
+		// The old Service stands for the desired state the operator applied
+		return serviceDrift(newObj.(*corev1.Service), oldObj)
+	}
+	return nil
+}
+
+// breakGlass reports whether a generated resource is released for manual edits
+func breakGlass(obj client.Object) bool {
+	return obj.GetAnnotations()[BreakGlassAnnotation] == "true"
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// updateRequest returns the admission request of an update of obj by user
+func updateRequest(t *testing.T, user string, oldObj, newObj client.Object, gvk metav1.GroupVersionKind) admission.Request {
+	t.Helper()
+	oldRaw, err := json.Marshal(oldObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newRaw, err := json.Marshal(newObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Kind:      gvk,
+		Operation: admissionv1.Update,
+		UserInfo:  authenticationv1.UserInfo{Username: user},
+		OldObject: runtime.RawExtension{Raw: oldRaw},
+		Object:    runtime.RawExtension{Raw: newRaw},
+	}}
+}
+
+func TestManagedResourceGuard(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(ragmev1.AddToScheme(scheme))
+	guard := &ManagedResourceGuard{OperatorUsername: DefaultOperatorUsername, decoder: admission.NewDecoder(scheme)}
+
+	ragme := harnessRAGme("guard")
+	ragme.UID = "guard-uid"
+	r := &RAGmeReconciler{Scheme: scheme}
+	deployment := r.createRAGmeServiceDeployment(ragme, "api")
+	service := r.createRAGmeService(ragme, "api")
+	for _, obj := range []client.Object{deployment, service} {
+		if err := ctrl.SetControllerReference(ragme, obj, scheme); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deploymentKind := metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	serviceKind := metav1.GroupVersionKind{Version: "v1", Kind: "Service"}
+
+	edited := deployment.DeepCopy()
+	edited.Spec.Template.Spec.Containers[0].Image = "ragme-api:hotfix"
+	restarted := deployment.DeepCopy()
+	restarted.Spec.Template.Annotations = map[string]string{"kubectl.kubernetes.io/restartedAt": "2024-06-07T12:00:00Z"}
+	released := edited.DeepCopy()
+	released.Annotations = map[string]string{BreakGlassAnnotation: "true"}
+	unowned := edited.DeepCopy()
+	unowned.OwnerReferences = nil
+	editedService := service.DeepCopy()
+	editedService.Spec.Type = corev1.ServiceTypeNodePort
+	labeled := service.DeepCopy()
+	labeled.Labels = mergeStringMaps(labeled.Labels, map[string]string{"team": "search"})
+
+	for _, tc := range []struct {
+		name    string
+		user    string
+		old     client.Object
+		new     client.Object
+		kind    metav1.GroupVersionKind
+		allowed bool
+	}{
+		{"image edited by hand", "alice", deployment, edited, deploymentKind, false},
+		{"image updated by the operator", DefaultOperatorUsername, deployment, edited, deploymentKind, true},
+		{"rollout restart", "alice", deployment, restarted, deploymentKind, true},
+		{"break glass", "alice", deployment, released, deploymentKind, true},
+		{"not generated", "alice", unowned, unowned, deploymentKind, true},
+		{"service type edited by hand", "alice", service, editedService, serviceKind, false},
+		{"label added", "alice", service, labeled, serviceKind, true},
+	} {
+		response := guard.Handle(context.Background(), updateRequest(t, tc.user, tc.old, tc.new, tc.kind))
+		if response.Allowed != tc.allowed {
+			t.Errorf("%s: allowed = %v (%v), want %v", tc.name, response.Allowed, response.Result, tc.allowed)
+		}
+	}
+}
+
+func TestBreakGlassSkipsUpdates(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("glass"))
+	h.reconcile(t, "glass")
+
+	deployment := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "glass-api"}, deployment); err != nil {
+		t.Fatal(err)
+	}
+	deployment.Annotations = mergeStringMaps(deployment.Annotations, map[string]string{BreakGlassAnnotation: "true"})
+	deployment.Spec.Template.Spec.Containers[0].Image = "ragme-api:hotfix"
+	if err := h.client.Update(h.ctx, deployment); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "glass")
+
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "glass-api"}, deployment); err != nil {
+		t.Fatal(err)
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "ragme-api:hotfix" {
+		t.Errorf("image = %s, want the hotfix kept while the break-glass annotation is set", image)
+	}
+}
@@ -0,0 +1,372 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	// transcriberComponent runs the agent in its media mode
+	transcriberComponent = "transcriber"
+
+	mediaModeDeployment = "deployment"
+	mediaModeJob        = "job"
+
+	defaultWhisperModel          = "base"
+	defaultTranscriberJobs int32 = 2
+
+	// transcriberMetricsPort serves the progress of the files being transcribed
+	transcriberMetricsPort = 9105
+	whisperModelDir        = "/app/models/whisper"
+	whisperModelVolume     = "whisper-models"
+
+	// mediaFileAnnotation records the file a transcription Job works on
+	mediaFileAnnotation = "ragme.io/media-file"
+
+	// maxMediaFileStatuses bounds the files listed in status
+	maxMediaFileStatuses = 20
+
+	mediaFilePendingMetric      = "ragme_media_file_pending"
+	mediaFileProgressMetric     = "ragme_media_file_progress"
+	mediaFilesTranscribedMetric = "ragme_media_files_transcribed_total"
+	mediaFilesFailedMetric      = "ragme_media_files_failed_total"
+
+	mediaPhasePending      = "Pending"
+	mediaPhaseTranscribing = "Transcribing"
+	mediaPhaseFailed       = "Failed"
+)
+
+// whisperLanguagePattern matches the ISO 639-1 codes Whisper is told the language with
+var whisperLanguagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// defaultMediaFileTypes are transcribed when none are configured
+var defaultMediaFileTypes = []string{"mp3", "wav", "m4a", "flac", "ogg", "mp4", "mov", "mkv", "webm"}
+
+// mediaFileTypes returns the extensions transcribed
+func mediaFileTypes(ragme *ragmev1.RAGme) []string {
+	if len(ragme.Spec.Processing.Media.FileTypes) > 0 {
+		return normalizeFileTypes(ragme.Spec.Processing.Media.FileTypes)
+	}
+	return defaultMediaFileTypes
+}
+
+// mediaMode returns how the transcriptions run
+func mediaMode(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.Processing.Media.Mode != "" {
+		return ragme.Spec.Processing.Media.Mode
+	}
+	return mediaModeDeployment
+}
+
+// whisperModel returns the Whisper model size of the transcriber
+func whisperModel(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.Processing.Media.Model != "" {
+		return ragme.Spec.Processing.Media.Model
+	}
+	return defaultWhisperModel
+}
+
+// transcriberWorkers returns the replicas of the transcriber
+func transcriberWorkers(ragme *ragmev1.RAGme) int32 {
+	if ragme.Spec.Processing.Media.Workers > 0 {
+		return ragme.Spec.Processing.Media.Workers
+	}
+	return 1
+}
+
+// transcriberJobs returns the transcription Jobs running at once in job mode
+func transcriberJobs(ragme *ragmev1.RAGme) int32 {
+	if ragme.Spec.Processing.Media.MaxParallelJobs > 0 {
+		return ragme.Spec.Processing.Media.MaxParallelJobs
+	}
+	return defaultTranscriberJobs
+}
+
+// applyMediaProcessing has the agent report the recordings waiting in the
+// watch directory, and configures the transcriber, its model cache and its
+// accelerators. The agent leaves the recordings to the transcriber, see
+// routedFileTypes.
+func applyMediaProcessing(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	media := ragme.Spec.Processing.Media
+	if !media.Enabled {
+		return
+	}
+	fileTypes := strings.Join(mediaFileTypes(ragme), ",")
+	switch serviceName {
+	case "agent":
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_AGENT_MEDIA_FILE_TYPES", Value: fileTypes,
+		})
+		return
+	case transcriberComponent:
+	default:
+		return
+	}
+
+	container := &podSpec.Containers[0]
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "RAGME_AGENT_MODE", Value: "media"},
+		corev1.EnvVar{Name: "RAGME_AGENT_FILE_TYPES", Value: fileTypes},
+		corev1.EnvVar{Name: "RAGME_WHISPER_MODEL", Value: whisperModel(ragme)},
+		corev1.EnvVar{Name: "RAGME_WHISPER_MODEL_DIR", Value: whisperModelDir},
+		corev1.EnvVar{Name: "RAGME_MEDIA_METRICS_PORT", Value: strconv.Itoa(transcriberMetricsPort)},
+	)
+	if media.Language != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "RAGME_WHISPER_LANGUAGE", Value: media.Language})
+	}
+	container.Ports = append(container.Ports, corev1.ContainerPort{
+		Name: "media-metrics", ContainerPort: transcriberMetricsPort,
+	})
+	// The model is downloaded on first use and kept for the life of the pod
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: whisperModelVolume, MountPath: whisperModelDir})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         whisperModelVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	applyAccelerator(podSpec, media.GPU, media.GPUResource, media.NodeSelector, media.Tolerations)
+}
+
+// reconcileMediaProcessing runs the transcriber Deployment in deployment
+// mode, or removes it. In job mode the transcriptions are dispatched by
+// checkMedia.
+func (r *RAGmeReconciler) reconcileMediaProcessing(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Processing.Media.Enabled || mediaMode(ragme) != mediaModeDeployment {
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ragme.Name, transcriberComponent),
+			Namespace: ragme.Namespace,
+		}}
+		if err := r.Delete(ctx, deployment); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	deployment := r.createRAGmeServiceDeployment(ragme, transcriberComponent)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+	found.Spec = deployment.Spec
+	found.Labels = mergeStringMaps(found.Labels, deployment.Labels)
+	found.Annotations = mergeStringMaps(found.Annotations, deployment.Annotations)
+	return r.updateOrRecreate(ctx, ragme, found, deployment)
+}
+
+// createTranscriptionJob returns the Job transcribing a single file in job
+// mode. It runs the pod of the transcriber Deployment without its sidecars,
+// which would keep the Job from completing.
+func (r *RAGmeReconciler) createTranscriptionJob(ragme *ragmev1.RAGme, file string) *batchv1.Job {
+	template := r.createRAGmeServiceDeployment(ragme, transcriberComponent).Spec.Template
+	template.Spec.Containers = template.Spec.Containers[:1]
+	template.Spec.RestartPolicy = corev1.RestartPolicyNever
+	template.Spec.Containers[0].Env = append(template.Spec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_MEDIA_FILE", Value: file,
+	})
+
+	sum := sha256.Sum256([]byte(file))
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-transcribe-%s", ragme.Name, hex.EncodeToString(sum[:])[:8]),
+			Namespace:   ragme.Namespace,
+			Labels:      componentLabels(ragme, transcriberComponent),
+			Annotations: map[string]string{mediaFileAnnotation: file},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{1}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template:                template,
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
+
+// checkMedia reads the recordings waiting in the watch directory from the
+// agent and the progress of their transcriptions from the transcribers, and
+// records them in status. In job mode it also starts a Job per waiting file,
+// up to maxParallelJobs at once.
+func (r *RAGmeReconciler) checkMedia(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Processing.Media.Enabled {
+		ragme.Status.Media = ragmev1.RAGmeMediaStatus{}
+		return nil
+	}
+	// The agent is stopped while the instance hibernates or is under maintenance
+	if maintenanceReplicas(ragme, "agent", 1) == 0 || ragme.Status.Hibernation.Hibernated {
+		return nil
+	}
+
+	agentSamples, err := r.scrapeAgentPod(ctx, ragme, agentMetricsPort,
+		mediaFilePendingMetric, mediaFilesTranscribedMetric, mediaFilesFailedMetric)
+	if err != nil {
+		return fmt.Errorf("pending recordings: %w", err)
+	}
+	progress, err := r.scrapeTranscribers(ctx, ragme)
+	if err != nil {
+		return fmt.Errorf("transcription progress: %w", err)
+	}
+
+	var jobs []batchv1.Job
+	if mediaMode(ragme) == mediaModeJob {
+		list := &batchv1.JobList{}
+		if err := r.List(ctx, list, client.InNamespace(ragme.Namespace),
+			client.MatchingLabels(componentLabels(ragme, transcriberComponent))); err != nil {
+			return err
+		}
+		jobs = list.Items
+		for _, file := range transcriptionsToStart(pendingMediaFiles(agentSamples), jobs, transcriberJobs(ragme)) {
+			job := r.createTranscriptionJob(ragme, file)
+			if err := r.setOwner(ragme, job); err != nil {
+				return err
+			}
+			if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			jobs = append(jobs, *job)
+		}
+	}
+
+	ragme.Status.Media = mediaStatus(agentSamples, progress, jobs)
+	return nil
+}
+
+// scrapeTranscribers reads the progress of the files being transcribed from
+// the running transcriber pods, of the Deployment or of the Jobs
+func (r *RAGmeReconciler) scrapeTranscribers(ctx context.Context, ragme *ragmev1.RAGme) ([]promSample, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace),
+		client.MatchingLabels(componentLabels(ragme, transcriberComponent))); err != nil {
+		return nil, err
+	}
+	var samples []promSample
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		podSamples, err := r.scrapePod(ctx, pod.Status.PodIP, transcriberMetricsPort, mediaFileProgressMetric)
+		if err != nil {
+			return nil, fmt.Errorf("pod %s: %w", pod.Name, err)
+		}
+		samples = append(samples, podSamples...)
+	}
+	return samples, nil
+}
+
+// pendingMediaFiles returns the recordings the agent reports waiting in the
+// watch directory, sorted by name
+func pendingMediaFiles(samples []promSample) []string {
+	var files []string
+	for _, sample := range samples {
+		if sample.Name == mediaFilePendingMetric && sample.Labels["file"] != "" && sample.Value > 0 {
+			files = append(files, sample.Labels["file"])
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// transcriptionsToStart returns the waiting files that get a Job now: the
+// files without a Job, while fewer than limit Jobs are running
+func transcriptionsToStart(pending []string, jobs []batchv1.Job, limit int32) []string {
+	started := map[string]bool{}
+	running := int32(0)
+	for i := range jobs {
+		started[jobs[i].Annotations[mediaFileAnnotation]] = true
+		if jobs[i].Status.Succeeded == 0 && !jobFailed(&jobs[i]) {
+			running++
+		}
+	}
+	var files []string
+	for _, file := range pending {
+		if running >= limit {
+			break
+		}
+		if !started[file] {
+			files = append(files, file)
+			running++
+		}
+	}
+	return files
+}
+
+// mediaStatus returns the transcription status: a waiting file is being
+// transcribed once a transcriber reports its progress or a Job runs for it
+func mediaStatus(agentSamples, progressSamples []promSample, jobs []batchv1.Job) ragmev1.RAGmeMediaStatus {
+	status := ragmev1.RAGmeMediaStatus{
+		Transcribed: int64(sumSamples(agentSamples, mediaFilesTranscribedMetric, nil)),
+		Failed:      int64(sumSamples(agentSamples, mediaFilesFailedMetric, nil)),
+	}
+
+	progress := map[string]int32{}
+	for _, sample := range progressSamples {
+		if sample.Name == mediaFileProgressMetric && sample.Labels["file"] != "" {
+			progress[sample.Labels["file"]] = int32(sample.Value)
+		}
+	}
+	jobsByFile := map[string]*batchv1.Job{}
+	for i := range jobs {
+		jobsByFile[jobs[i].Annotations[mediaFileAnnotation]] = &jobs[i]
+	}
+
+	var files []ragmev1.RAGmeMediaFileStatus
+	for _, name := range pendingMediaFiles(agentSamples) {
+		file := ragmev1.RAGmeMediaFileStatus{Name: name, Phase: mediaPhasePending}
+		if job := jobsByFile[name]; job != nil {
+			// A finished Job is kept in status only when it failed
+			if job.Status.Succeeded > 0 || jobFailed(job) {
+				continue
+			}
+			file.Phase = mediaPhaseTranscribing
+			file.Job = job.Name
+		}
+		if value, ok := progress[name]; ok {
+			file.Phase = mediaPhaseTranscribing
+			file.Progress = value
+		}
+		if file.Phase == mediaPhasePending {
+			status.Pending++
+		}
+		files = append(files, file)
+	}
+	for file, job := range jobsByFile {
+		if jobFailed(job) {
+			files = append(files, ragmev1.RAGmeMediaFileStatus{Name: file, Phase: mediaPhaseFailed, Job: job.Name})
+		}
+	}
+
+	// The running transcriptions come first, then the failed and waiting files
+	order := map[string]int{mediaPhaseTranscribing: 0, mediaPhaseFailed: 1, mediaPhasePending: 2}
+	sort.Slice(files, func(i, j int) bool {
+		if order[files[i].Phase] != order[files[j].Phase] {
+			return order[files[i].Phase] < order[files[j].Phase]
+		}
+		return files[i].Name < files[j].Name
+	})
+	if len(files) > maxMediaFileStatuses {
+		files = files[:maxMediaFileStatuses]
+	}
+	status.Files = files
+	return status
+}
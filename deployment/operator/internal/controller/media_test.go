@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestTranscriberDeployment(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Images = ragmev1.RAGmeImages{Registry: "localhost:5001", Tag: "latest"}
+	ragme.Spec.Processing.Images.Enabled = true
+	ragme.Spec.Processing.Media = ragmev1.RAGmeMediaProcessing{Enabled: true, Model: "small", Language: "en", Workers: 2, GPU: 1}
+
+	deployment := r.createRAGmeServiceDeployment(ragme, transcriberComponent)
+	if deployment.Name != "test-transcriber" || *deployment.Spec.Replicas != 2 {
+		t.Errorf("Deployment %s has %d replicas, want test-transcriber with 2", deployment.Name, *deployment.Spec.Replicas)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	for name, want := range map[string]string{
+		"RAGME_AGENT_MODE":       "media",
+		"RAGME_WHISPER_MODEL":    "small",
+		"RAGME_WHISPER_LANGUAGE": "en",
+		"RAGME_AGENT_FILE_TYPES": strings.Join(defaultMediaFileTypes, ","),
+	} {
+		if got := envValue(container.Env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if gpus := container.Resources.Limits[defaultGPUResource]; gpus.Value() != 1 {
+		t.Errorf("GPU limit = %s, want 1", gpus.String())
+	}
+
+	// The agent leaves both the images and the recordings to their workers
+	agent := r.createRAGmeServiceDeployment(ragme, "agent").Spec.Template.Spec.Containers[0]
+	skipped := envValue(agent.Env, "RAGME_AGENT_SKIP_FILE_TYPES")
+	if !strings.Contains(skipped, "png") || !strings.Contains(skipped, "mp3") {
+		t.Errorf("agent skipped types = %q, want the images and the recordings", skipped)
+	}
+	if envValue(agent.Env, "RAGME_AGENT_METRICS_PORT") == "" {
+		t.Errorf("agent env = %+v, want the metrics port reporting the pending recordings", agent.Env)
+	}
+
+	job := r.createTranscriptionJob(ragme, "meetings/standup.mp4")
+	podSpec := job.Spec.Template.Spec
+	if podSpec.RestartPolicy != corev1.RestartPolicyNever || len(podSpec.Containers) != 1 {
+		t.Errorf("Job pod = %+v, want a single container never restarted", podSpec)
+	}
+	if envValue(podSpec.Containers[0].Env, "RAGME_MEDIA_FILE") != "meetings/standup.mp4" || job.Annotations[mediaFileAnnotation] != "meetings/standup.mp4" {
+		t.Errorf("Job %s does not transcribe its file", job.Name)
+	}
+}
+
+func TestTranscriptionsToStart(t *testing.T) {
+	failed := batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{mediaFileAnnotation: "a.mp3"}},
+		Status:     batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}},
+	}
+	running := batchv1.Job{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{mediaFileAnnotation: "b.mp3"}}}
+
+	got := transcriptionsToStart([]string{"a.mp3", "b.mp3", "c.mp3", "d.mp3"}, []batchv1.Job{failed, running}, 2)
+	if len(got) != 1 || got[0] != "c.mp3" {
+		t.Errorf("transcriptionsToStart() = %v, want [c.mp3]", got)
+	}
+}
+
+func TestMediaStatus(t *testing.T) {
+	agent := []promSample{
+		{Name: mediaFilePendingMetric, Labels: map[string]string{"file": "b.wav"}, Value: 1},
+		{Name: mediaFilePendingMetric, Labels: map[string]string{"file": "a.mp4"}, Value: 1},
+		{Name: mediaFilesTranscribedMetric, Value: 12},
+		{Name: mediaFilesFailedMetric, Value: 1},
+	}
+	progress := []promSample{{Name: mediaFileProgressMetric, Labels: map[string]string{"file": "b.wav"}, Value: 40}}
+
+	status := mediaStatus(agent, progress, nil)
+	want := []ragmev1.RAGmeMediaFileStatus{
+		{Name: "b.wav", Phase: mediaPhaseTranscribing, Progress: 40},
+		{Name: "a.mp4", Phase: mediaPhasePending},
+	}
+	if status.Pending != 1 || status.Transcribed != 12 || status.Failed != 1 || len(status.Files) != 2 {
+		t.Fatalf("status = %+v", status)
+	}
+	for i := range want {
+		if status.Files[i] != want[i] {
+			t.Errorf("files[%d] = %+v, want %+v", i, status.Files[i], want[i])
+		}
+	}
+}
+
+func TestValidateSpecMedia(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.Media = ragmev1.RAGmeMediaProcessing{Enabled: true, Model: "huge", Language: "english", Mode: "cron"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an invalid model, language and mode")
+	}
+	ragme.Spec.Processing.Images = ragmev1.RAGmeImageProcessing{Enabled: true, FileTypes: []string{"gif"}}
+	ragme.Spec.Processing.Media = ragmev1.RAGmeMediaProcessing{Enabled: true, FileTypes: []string{"mp3", "gif"}}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a file type routed to both workers")
+	}
+	ragme.Spec.Processing.Media = ragmev1.RAGmeMediaProcessing{Enabled: true, Model: "large-v3", Language: "de", Mode: "job"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
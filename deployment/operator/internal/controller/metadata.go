@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// applyWorkloadAnnotations stamps the user-provided Deployment and pod
+// annotations on a generated workload, so cluster policies (Gatekeeper
+// exemptions, cost allocation, backup tooling) can key off them.
+func applyWorkloadAnnotations(ragme *ragmev1.RAGme, deployment *appsv1.Deployment) {
+	deployment.Annotations = mergeStringMaps(deployment.Annotations, ragme.Spec.DeploymentAnnotations)
+	applyPodAnnotations(ragme, &deployment.Spec.Template)
+}
+
+// applyPodAnnotations stamps the user-provided pod annotations on a pod
+// template, e.g. of a generated Job or CronJob
+func applyPodAnnotations(ragme *ragmev1.RAGme, template *corev1.PodTemplateSpec) {
+	template.Annotations = mergeStringMaps(template.Annotations, ragme.Spec.PodAnnotations)
+}
+
+// safeToEvictAnnotation tells the cluster autoscaler whether a pod may be evicted on scale-down
+const safeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+// defaultSafeToEvict lists the components the cluster autoscaler may evict. The
+// agent, its image and media workers and the stateful components are kept so ingestion
+// runs are not interrupted
+var defaultSafeToEvict = map[string]bool{
+	"api":             true,
+	"mcp":             true,
+	"frontend":        true,
+	"agent":           false,
+	"minio":           false,
+	"weaviate":        false,
+	"milvus":          false,
+	"image-processor": false,
+	"transcriber":     false,
+}
+
+// applyAutoscalerAnnotations stamps the cluster autoscaler safe-to-evict
+// annotation on the pod template of a component. The component setting wins
+// over the defaults applied when the cluster autoscaler integration is enabled.
+func applyAutoscalerAnnotations(ragme *ragmev1.RAGme, component string, template *corev1.PodTemplateSpec) {
+	safeToEvict, ok := defaultSafeToEvict[component]
+	ok = ok && ragme.Spec.ClusterAutoscaler.Enabled
+	if override := componentSpec(ragme, component).SafeToEvict; override != nil {
+		safeToEvict, ok = *override, true
+	}
+	if !ok {
+		return
+	}
+	template.Annotations = mergeStringMaps(template.Annotations, map[string]string{
+		safeToEvictAnnotation: strconv.FormatBool(safeToEvict),
+	})
+}
+
+// mergeStringMaps returns dst with all entries of src added, overriding
+// existing keys. dst is allocated when needed.
+func mergeStringMaps(dst, src map[string]string) map[string]string {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		dst[k] = v
+	}
+	return dst
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyAutoscalerAnnotations(t *testing.T) {
+	safe := true
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		component string
+		override  *bool
+		want      string
+	}{
+		{name: "disabled", component: "api"},
+		{name: "stateless component", enabled: true, component: "frontend", want: "true"},
+		{name: "stateful component", enabled: true, component: "weaviate", want: "false"},
+		{name: "agent", enabled: true, component: "agent", want: "false"},
+		{name: "override", enabled: true, component: "agent", override: &safe, want: "true"},
+		{name: "override when disabled", component: "minio", override: &safe, want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.ClusterAutoscaler.Enabled = tt.enabled
+			switch tt.component {
+			case "agent":
+				ragme.Spec.Components.Agent.SafeToEvict = tt.override
+			case "minio":
+				ragme.Spec.Components.MinIO.SafeToEvict = tt.override
+			}
+
+			template := &corev1.PodTemplateSpec{}
+			applyAutoscalerAnnotations(ragme, tt.component, template)
+			if got := template.Annotations[safeToEvictAnnotation]; got != tt.want {
+				t.Errorf("safe-to-evict = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobPodAnnotations(t *testing.T) {
+	ragme := harnessRAGme("annotated")
+	ragme.Spec.PodAnnotations = map[string]string{"admission.gatekeeper.sh/exempt": "true"}
+	tenant := &ragmev1.RAGmeTenant{ObjectMeta: metav1.ObjectMeta{Name: "engineering", Namespace: "bench"}}
+
+	templates := map[string]*corev1.PodTemplateSpec{
+		"ldap check":      &createLDAPCheckJob(ragme, "abc").Spec.Template,
+		"tenant quota":    &createBucketQuotaJob(ragme, tenant, "ragme-engineering", "", "abc").Spec.Template,
+		"seed data":       &createSeedDataJob(ragme, "abc").Spec.Template,
+		"shard rebalance": &createShardRebalanceJob(ragme, 1, 2).Spec.Template,
+		"version cleanup": &createVersionCleanupCronJob(ragme).Spec.JobTemplate.Spec.Template,
+		"evaluation":      &createEvaluationCronJob(ragme).Spec.JobTemplate.Spec.Template,
+	}
+	for name, template := range templates {
+		if template.Annotations["admission.gatekeeper.sh/exempt"] != "true" {
+			t.Errorf("%s pod annotations = %v, want spec.podAnnotations", name, template.Annotations)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultMetricsAgentImage = "prom/prometheus:v2.53.0"
+
+	metricsAgentConfigKey      = "prometheus.json"
+	metricsAgentConfigPath     = "/etc/prometheus"
+	metricsAgentAuthPath       = "/etc/remote-write"
+	metricsAgentMinIOTokenPath = "/etc/minio-prometheus"
+	metricsAgentHashAnnotation = "ragme.io/metrics-agent-hash"
+
+	defaultRemoteWriteScrapeInterval = "30s"
+)
+
+// scrapedServices are the services exposing a metrics endpoint, with their
+// service port
+var scrapedServices = []struct {
+	name string
+	port int32
+}{{"api", 8021}, {"mcp", 8022}, {"frontend", 8020}}
+
+// metricsAgentName returns the name of the ConfigMap and Deployment of the metrics agent
+func metricsAgentName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-metrics-agent", ragme.Name)
+}
+
+// renderMetricsAgentConfig returns the Prometheus agent configuration and its
+// hash. The services are scraped through their Services, on the admin port
+// when it is enabled.
+func renderMetricsAgentConfig(ragme *ragmev1.RAGme) (string, string, error) {
+	remoteWrite := ragme.Spec.Monitoring.RemoteWrite
+
+	externalLabels := map[string]string{
+		"namespace":      ragme.Namespace,
+		"ragme_instance": ragme.Name,
+	}
+	externalLabels = mergeStringMaps(externalLabels, remoteWrite.ExternalLabels)
+
+	var scrapeConfigs []interface{}
+	for _, service := range scrapedServices {
+		scrapeConfigs = append(scrapeConfigs, map[string]interface{}{
+			"job_name":     "ragme-" + service.name,
+			"metrics_path": "/metrics",
+			"static_configs": []interface{}{map[string]interface{}{
+				"targets": []string{operationalTarget(ragme, service.name, service.port)},
+				"labels":  map[string]string{"component": service.name},
+			}},
+		})
+	}
+	if metrics := ragme.Spec.Storage.MinIO.Metrics; ragme.Spec.Storage.MinIO.Enabled && metrics.Enabled {
+		minio := map[string]interface{}{
+			"job_name":     "ragme-minio",
+			"metrics_path": minioMetricsPath,
+			"static_configs": []interface{}{map[string]interface{}{
+				"targets": []string{fmt.Sprintf("%s-minio.%s.svc:9000", ragme.Name, ragme.Namespace)},
+				"labels":  map[string]string{"component": "minio"},
+			}},
+		}
+		if metrics.AuthType == "jwt" {
+			minio["authorization"] = map[string]interface{}{
+				"credentials_file": metricsAgentMinIOTokenPath + "/" + minioMetricsTokenKey,
+			}
+		}
+		scrapeConfigs = append(scrapeConfigs, minio)
+	}
+
+	endpoint := map[string]interface{}{"url": remoteWrite.URL}
+	if remoteWrite.AuthSecretRef != nil {
+		switch remoteWrite.AuthType {
+		case "basic":
+			endpoint["basic_auth"] = map[string]interface{}{
+				"username_file": metricsAgentAuthPath + "/username",
+				"password_file": metricsAgentAuthPath + "/password",
+			}
+		default:
+			endpoint["authorization"] = map[string]interface{}{
+				"credentials_file": metricsAgentAuthPath + "/token",
+			}
+		}
+	}
+
+	interval := remoteWrite.ScrapeInterval
+	if interval == "" {
+		interval = defaultRemoteWriteScrapeInterval
+	}
+	config := map[string]interface{}{
+		"global": map[string]interface{}{
+			"scrape_interval": interval,
+			"external_labels": externalLabels,
+		},
+		"scrape_configs": scrapeConfigs,
+		"remote_write":   []interface{}{endpoint},
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(data)
+	return string(data), hex.EncodeToString(sum[:])[:8], nil
+}
+
+// reconcileMetricsAgent renders the agent configuration and runs the
+// Prometheus agent shipping the metrics to the remote-write endpoint. Its
+// resources are removed when remote write is disabled.
+func (r *RAGmeReconciler) reconcileMetricsAgent(ctx context.Context, ragme *ragmev1.RAGme) error {
+	deployment := createMetricsAgentDeployment(ragme)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metricsAgentName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":       "ragme",
+				"component": "metrics-agent",
+				"instance":  ragme.Name,
+			},
+		},
+	}
+
+	if !ragme.Spec.Monitoring.RemoteWrite.Enabled {
+		for _, obj := range []client.Object{deployment, configMap} {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	config, _, err := renderMetricsAgentConfig(ragme)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{metricsAgentConfigKey: config}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if found.Data[metricsAgentConfigKey] != config {
+		found.Data = configMap.Data
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+	foundDeployment.Spec = deployment.Spec
+	foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+	foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+	return r.updateOrRecreate(ctx, ragme, foundDeployment, deployment)
+}
+
+// createMetricsAgentDeployment returns the Deployment of the Prometheus
+// agent. The agent does not reload its configuration, so the configuration
+// hash on the pod template rolls it when the targets change.
+func createMetricsAgentDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	remoteWrite := ragme.Spec.Monitoring.RemoteWrite
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "metrics-agent",
+		"instance":  ragme.Name,
+	}
+
+	image := remoteWrite.Image
+	if image == "" {
+		image = defaultMetricsAgentImage
+	}
+	annotations := map[string]string{}
+	if _, hash, err := renderMetricsAgentConfig(ragme); err == nil {
+		annotations[metricsAgentHashAnnotation] = hash
+	}
+	// Nothing is scraped while the services are scaled to zero
+	replicas := hibernationReplicas(ragme, 1)
+
+	container := corev1.Container{
+		Name:  "prometheus-agent",
+		Image: image,
+		Args: []string{
+			"--enable-feature=agent",
+			"--config.file=" + metricsAgentConfigPath + "/" + metricsAgentConfigKey,
+			"--storage.agent.path=/prometheus",
+		},
+		Ports: []corev1.ContainerPort{
+			{ContainerPort: 9090, Name: "http"},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/-/ready", Port: intstr.FromString("http")},
+			},
+			PeriodSeconds: 10,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: metricsAgentConfigPath, ReadOnly: true},
+			{Name: "wal", MountPath: "/prometheus"},
+		},
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: "config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: metricsAgentName(ragme)},
+				},
+			},
+		},
+		{Name: "wal", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	if remoteWrite.AuthSecretRef != nil {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name: "remote-write-auth", MountPath: metricsAgentAuthPath, ReadOnly: true,
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name:         "remote-write-auth",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: remoteWrite.AuthSecretRef.Name}},
+		})
+	}
+	if metrics := ragme.Spec.Storage.MinIO.Metrics; ragme.Spec.Storage.MinIO.Enabled && metrics.Enabled && metrics.AuthType == "jwt" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name: "minio-prometheus", MountPath: metricsAgentMinIOTokenPath, ReadOnly: true,
+		})
+		volumes = append(volumes, corev1.Volume{
+			Name: "minio-prometheus",
+			VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{
+				SecretName: fmt.Sprintf("%s-minio-prometheus", ragme.Name),
+			}},
+		})
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metricsAgentName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes:    volumes,
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
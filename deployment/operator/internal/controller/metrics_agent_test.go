@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func remoteWriteRAGme() *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Monitoring.RemoteWrite = ragmev1.RAGmeRemoteWrite{
+		Enabled:        true,
+		URL:            "https://thanos.example.com/api/v1/receive",
+		AuthType:       "basic",
+		AuthSecretRef:  &corev1.LocalObjectReference{Name: "thanos-auth"},
+		ExternalLabels: map[string]string{"cluster": "edge-1"},
+	}
+	ragme.Spec.Storage.MinIO.Enabled = true
+	ragme.Spec.Storage.MinIO.Metrics = ragmev1.RAGmeMinIOMetrics{Enabled: true, AuthType: "jwt"}
+	return ragme
+}
+
+func TestRenderMetricsAgentConfig(t *testing.T) {
+	ragme := remoteWriteRAGme()
+	ragme.Spec.AdminPort.Enabled = true
+
+	data, hash, err := renderMetricsAgentConfig(ragme)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := struct {
+		Global struct {
+			ScrapeInterval string            `json:"scrape_interval"`
+			ExternalLabels map[string]string `json:"external_labels"`
+		} `json:"global"`
+		ScrapeConfigs []struct {
+			JobName       string `json:"job_name"`
+			StaticConfigs []struct {
+				Targets []string `json:"targets"`
+			} `json:"static_configs"`
+			Authorization map[string]string `json:"authorization"`
+		} `json:"scrape_configs"`
+		RemoteWrite []struct {
+			URL       string            `json:"url"`
+			BasicAuth map[string]string `json:"basic_auth"`
+		} `json:"remote_write"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("config is not valid JSON: %v", err)
+	}
+
+	if config.Global.ScrapeInterval != "30s" || config.Global.ExternalLabels["cluster"] != "edge-1" ||
+		config.Global.ExternalLabels["ragme_instance"] != "test" {
+		t.Errorf("global = %+v", config.Global)
+	}
+	if len(config.ScrapeConfigs) != 4 {
+		t.Fatalf("scrape configs = %+v, want api, mcp, frontend and minio", config.ScrapeConfigs)
+	}
+	if target := config.ScrapeConfigs[0].StaticConfigs[0].Targets[0]; target != "test-api-admin.ragme.svc:9090" {
+		t.Errorf("api target = %s, want the admin Service", target)
+	}
+	if minio := config.ScrapeConfigs[3]; minio.Authorization["credentials_file"] != "/etc/minio-prometheus/token" {
+		t.Errorf("minio scrape config = %+v, want the bearer token file", minio)
+	}
+	if len(config.RemoteWrite) != 1 || config.RemoteWrite[0].BasicAuth["password_file"] != "/etc/remote-write/password" {
+		t.Errorf("remote_write = %+v, want basic auth from the mounted Secret", config.RemoteWrite)
+	}
+
+	ragme.Spec.Monitoring.RemoteWrite.ExternalLabels["cluster"] = "edge-2"
+	if _, changed, _ := renderMetricsAgentConfig(ragme); changed == hash {
+		t.Error("config hash did not change with the external labels")
+	}
+}
+
+func TestCreateMetricsAgentDeployment(t *testing.T) {
+	ragme := remoteWriteRAGme()
+	deployment := createMetricsAgentDeployment(ragme)
+
+	pod := deployment.Spec.Template.Spec
+	if args := pod.Containers[0].Args; args[0] != "--enable-feature=agent" {
+		t.Errorf("args = %v, want the agent mode", args)
+	}
+	secrets := map[string]bool{}
+	for _, volume := range pod.Volumes {
+		if volume.Secret != nil {
+			secrets[volume.Secret.SecretName] = true
+		}
+	}
+	if !secrets["thanos-auth"] || !secrets["test-minio-prometheus"] {
+		t.Errorf("secret volumes = %v, want the remote-write credentials and the MinIO token", secrets)
+	}
+	if deployment.Spec.Template.Annotations[metricsAgentHashAnnotation] == "" {
+		t.Error("config hash annotation missing")
+	}
+
+	ragme.Status.Hibernation.Hibernated = true
+	if replicas := *createMetricsAgentDeployment(ragme).Spec.Replicas; replicas != 0 {
+		t.Errorf("hibernated replicas = %d, want 0", replicas)
+	}
+}
+
+func TestValidateSpecRemoteWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*ragmev1.RAGme)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(*ragmev1.RAGme) {}},
+		{name: "no URL", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.RemoteWrite.URL = "" }, wantErr: true},
+		{name: "unknown auth", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.RemoteWrite.AuthType = "sigv4" }, wantErr: true},
+		{name: "auth without secret", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.RemoteWrite.AuthSecretRef = nil }, wantErr: true},
+		{name: "bad interval", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.RemoteWrite.ScrapeInterval = "often" }, wantErr: true},
+		{name: "no auth", mutate: func(r *ragmev1.RAGme) {
+			r.Spec.Monitoring.RemoteWrite.AuthType = ""
+			r.Spec.Monitoring.RemoteWrite.AuthSecretRef = nil
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := remoteWriteRAGme()
+			tt.mutate(ragme)
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
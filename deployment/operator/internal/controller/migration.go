@@ -0,0 +1,280 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch;update;patch
+
+// reclaimPolicyAnnotation keeps the reclaim policy of a volume while it is
+// switched to Retain to move it to the claim of a renamed instance
+const reclaimPolicyAnnotation = "ragme.io/reclaim-policy"
+
+// migrationInProgress reports whether the volumes of spec.previousInstanceName
+// are still being re-bound, along with a progress message
+func migrationInProgress(ragme *ragmev1.RAGme) (string, bool) {
+	migration := ragme.Status.Migration
+	if migration.From == "" || migration.Completed {
+		return "", false
+	}
+	return fmt.Sprintf("Re-binding the volumes of %s", migration.From), true
+}
+
+// previousVolumeClaims returns the names of the data volumes the previous
+// instance would have, the Weaviate shards up to the first missing one
+func (r *RAGmeReconciler) previousVolumeClaims(ctx context.Context, ragme *ragmev1.RAGme) ([]*corev1.PersistentVolumeClaim, int32, error) {
+	previous := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: ragme.Spec.PreviousInstanceName, Namespace: ragme.Namespace}}
+	names := []string{fmt.Sprintf("%s-shared-pvc", previous.Name), fmt.Sprintf("%s-minio-pvc", previous.Name)}
+
+	var claims []*corev1.PersistentVolumeClaim
+	for _, name := range names {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ragme.Namespace}, pvc)
+		if err == nil {
+			claims = append(claims, pvc)
+		} else if !errors.IsNotFound(err) {
+			return nil, 0, err
+		}
+	}
+
+	var shards int32
+	for ; ; shards++ {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, types.NamespacedName{Name: weaviateShardName(previous, shards) + "-pvc", Namespace: ragme.Namespace}, pvc)
+		if errors.IsNotFound(err) {
+			break
+		} else if err != nil {
+			return nil, 0, err
+		}
+		claims = append(claims, pvc)
+	}
+	return claims, shards, nil
+}
+
+// planMigration records the volumes of the previous instance to re-bind. An
+// instance that already has data volumes is never migrated.
+func (r *RAGmeReconciler) planMigration(ctx context.Context, ragme *ragmev1.RAGme) error {
+	previous := ragme.Spec.PreviousInstanceName
+	err := r.Get(ctx, types.NamespacedName{Name: previous, Namespace: ragme.Namespace}, &ragmev1.RAGme{})
+	if err == nil {
+		message := fmt.Sprintf("Instance %s still exists, delete it with cleanupPolicy Retain to hand its data over", previous)
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated, conditions.ReasonPreviousInstanceExists, message)
+		return fmt.Errorf("previous instance %s still exists", previous)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	err = r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-shared-pvc", ragme.Name), Namespace: ragme.Namespace}, &corev1.PersistentVolumeClaim{})
+	if err == nil {
+		ragme.Status.Migration = ragmev1.RAGmeMigrationStatus{From: previous, Completed: true}
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated,
+			conditions.ReasonMigrationSkipped, "The instance already has data volumes, nothing was re-bound")
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	claims, shards, err := r.previousVolumeClaims(ctx, ragme)
+	if err != nil {
+		return err
+	}
+	if len(claims) == 0 {
+		message := fmt.Sprintf("No volumes of %s found, it must be deleted with cleanupPolicy Retain", previous)
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated, conditions.ReasonPreviousVolumesNotFound, message)
+		return fmt.Errorf("no volumes of the previous instance %s found", previous)
+	}
+
+	migration := ragmev1.RAGmeMigrationStatus{From: previous}
+	for _, pvc := range claims {
+		if pvc.Spec.VolumeName == "" || pvc.Status.Phase != corev1.ClaimBound {
+			return fmt.Errorf("volume claim %s of the previous instance is not bound", pvc.Name)
+		}
+		migration.Volumes = append(migration.Volumes, ragmev1.RAGmeMigratedVolume{
+			Claim:      ragme.Name + strings.TrimPrefix(pvc.Name, previous),
+			VolumeName: pvc.Spec.VolumeName,
+		})
+	}
+	ragme.Status.Migration = migration
+	// Start with the layout of the previous instance, a different shard count is rebalanced afterwards
+	if shards > 1 {
+		ragme.Status.Sharding.Shards = shards
+	}
+	conditions.SetUnknown(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated,
+		conditions.ReasonMigrating, fmt.Sprintf("Re-binding %d volumes of %s", len(migration.Volumes), previous))
+	log.FromContext(ctx).Info("Migrating the data of the previous instance", "previous", previous, "volumes", migration.Volumes)
+	return nil
+}
+
+// reconcileMigration re-binds the volumes retained by spec.previousInstanceName
+// to the claims of the instance, and takes over its retained credentials.
+// The claims are created before the storage areas run, so they never
+// provision empty volumes in their place.
+func (r *RAGmeReconciler) reconcileMigration(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if ragme.Spec.PreviousInstanceName == "" || ragme.Status.Migration.Completed {
+		return nil
+	}
+	if ragme.Status.Migration.From == "" {
+		if err := r.planMigration(ctx, ragme); err != nil || ragme.Status.Migration.Completed {
+			return err
+		}
+	}
+
+	if err := r.adoptCredentials(ctx, ragme); err != nil {
+		return err
+	}
+
+	migration := &ragme.Status.Migration
+	pending := 0
+	for _, volume := range migration.Volumes {
+		bound, err := r.rebindVolume(ctx, ragme, volume)
+		if err != nil {
+			return fmt.Errorf("re-binding volume %s: %w", volume.VolumeName, err)
+		}
+		if !bound {
+			pending++
+		}
+	}
+	if pending > 0 {
+		conditions.SetUnknown(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated, conditions.ReasonMigrating,
+			fmt.Sprintf("Re-binding %d of %d volumes of %s", pending, len(migration.Volumes), migration.From))
+		return nil
+	}
+
+	migration.Completed = true
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeMigrated, conditions.ReasonMigrationSucceeded,
+		fmt.Sprintf("Re-bound %d volumes of %s", len(migration.Volumes), migration.From))
+	return nil
+}
+
+// rebindVolume moves a volume of the previous instance to a claim of the
+// instance and reports whether the claim is bound to it. The volume is kept
+// with the Retain reclaim policy while its previous claim is deleted, and
+// pre-bound to the new claim once it is released.
+func (r *RAGmeReconciler) rebindVolume(ctx context.Context, ragme *ragmev1.RAGme, volume ragmev1.RAGmeMigratedVolume) (bool, error) {
+	pv := &corev1.PersistentVolume{}
+	if err := r.Get(ctx, types.NamespacedName{Name: volume.VolumeName}, pv); err != nil {
+		return false, err
+	}
+
+	claim := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: volume.Claim, Namespace: ragme.Namespace}, claim)
+	if errors.IsNotFound(err) {
+		claim = &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: volume.Claim, Namespace: ragme.Namespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				AccessModes:      pv.Spec.AccessModes,
+				Resources:        corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: pv.Spec.Capacity[corev1.ResourceStorage]}},
+				StorageClassName: &pv.Spec.StorageClassName,
+				VolumeMode:       pv.Spec.VolumeMode,
+				VolumeName:       pv.Name,
+			},
+		}
+		if err := r.setOwner(ragme, claim); err != nil {
+			return false, err
+		}
+		if err := r.Create(ctx, claim); err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	}
+
+	if claim.Status.Phase == corev1.ClaimBound && claim.Spec.VolumeName == pv.Name {
+		if policy, ok := pv.Annotations[reclaimPolicyAnnotation]; ok {
+			pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(policy)
+			delete(pv.Annotations, reclaimPolicyAnnotation)
+			if err := r.Update(ctx, pv); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// Keep the data when the previous claim goes away
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+		pv.Annotations = mergeStringMaps(pv.Annotations, map[string]string{reclaimPolicyAnnotation: string(pv.Spec.PersistentVolumeReclaimPolicy)})
+		pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+		if err := r.Update(ctx, pv); err != nil {
+			return false, err
+		}
+	}
+
+	previous := &corev1.PersistentVolumeClaim{}
+	previousName := ragme.Spec.PreviousInstanceName + strings.TrimPrefix(volume.Claim, ragme.Name)
+	err = r.Get(ctx, types.NamespacedName{Name: previousName, Namespace: ragme.Namespace}, previous)
+	if err == nil {
+		if previous.DeletionTimestamp.IsZero() {
+			if err := r.Delete(ctx, previous); err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		// Wait for the previous claim to be gone
+		return false, nil
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	if ref := pv.Spec.ClaimRef; ref == nil || ref.Name != claim.Name || ref.Namespace != claim.Namespace || ref.UID != claim.UID {
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  claim.Namespace,
+			Name:       claim.Name,
+			UID:        claim.UID,
+		}
+		if err := r.Update(ctx, pv); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// adoptCredentials copies the storage user and API key Secrets retained by the
+// previous instance to the names of the instance, so the re-bound data stays
+// reachable with the same credentials
+func (r *RAGmeReconciler) adoptCredentials(ctx context.Context, ragme *ragmev1.RAGme) error {
+	previous := ragme.Spec.PreviousInstanceName
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": previous}); err != nil {
+		return err
+	}
+
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if !retainedCredentials(secret) || metav1.GetControllerOf(secret) != nil || !strings.HasPrefix(secret.Name, previous+"-") {
+			continue
+		}
+		adopted := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        ragme.Name + strings.TrimPrefix(secret.Name, previous),
+				Namespace:   ragme.Namespace,
+				Labels:      mergeStringMaps(mergeStringMaps(nil, secret.Labels), map[string]string{"instance": ragme.Name}),
+				Annotations: secret.Annotations,
+			},
+			Type: secret.Type,
+			Data: secret.Data,
+		}
+		if err := r.setOwner(ragme, adopted); err != nil {
+			return err
+		}
+		if err := r.Create(ctx, adopted); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		if err := r.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
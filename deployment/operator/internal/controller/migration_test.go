@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// retainedVolume returns a claim left by a deleted instance and its bound volume
+func retainedVolume(claim string) (*corev1.PersistentVolumeClaim, *corev1.PersistentVolume) {
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-" + claim},
+		Spec: corev1.PersistentVolumeSpec{
+			Capacity:                      corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+			AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			StorageClassName:              "standard",
+			ClaimRef:                      &corev1.ObjectReference{Kind: "PersistentVolumeClaim", Namespace: "bench", Name: claim, UID: "old-uid"},
+		},
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: claim, Namespace: "bench"},
+		Spec:       corev1.PersistentVolumeClaimSpec{VolumeName: pv.Name},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	}
+	return pvc, pv
+}
+
+func TestMigrationRebindsVolumes(t *testing.T) {
+	ragme := harnessRAGme("renamed")
+	ragme.Spec.PreviousInstanceName = "old"
+	ragme.Spec.Storage.MinIO.Credentials.PerService = true
+	h := newReconcilerHarness(t, ragme)
+
+	for _, claim := range []string{"old-shared-pvc", "old-minio-pvc", "old-weaviate-pvc"} {
+		pvc, pv := retainedVolume(claim)
+		for _, obj := range []client.Object{pv, pvc} {
+			if err := h.client.Create(h.ctx, obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "old-minio-api", Namespace: "bench", Labels: map[string]string{
+			"app": "ragme", "instance": "old", storageUserLabel: "true",
+		}},
+		Data: map[string][]byte{storageAccessKeyKey: []byte("old-api")},
+	}
+	if err := h.client.Create(h.ctx, credentials); err != nil {
+		t.Fatal(err)
+	}
+
+	h.reconcile(t, "renamed")
+	h.reconcile(t, "renamed")
+
+	for _, claim := range []string{"renamed-shared-pvc", "renamed-minio-pvc", "renamed-weaviate-pvc"} {
+		pvc := &corev1.PersistentVolumeClaim{}
+		if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: claim}, pvc); err != nil {
+			t.Fatalf("claim %s not created: %v", claim, err)
+		}
+		volume := "pv-old" + claim[len("renamed"):]
+		if pvc.Spec.VolumeName != volume {
+			t.Errorf("%s volumeName = %s, want %s", claim, pvc.Spec.VolumeName, volume)
+		}
+		pv := &corev1.PersistentVolume{}
+		if err := h.client.Get(h.ctx, client.ObjectKey{Name: volume}, pv); err != nil {
+			t.Fatal(err)
+		}
+		if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain || pv.Spec.ClaimRef.Name != claim {
+			t.Errorf("volume %s = %s bound to %s, want retained and pre-bound to %s", volume, pv.Spec.PersistentVolumeReclaimPolicy, pv.Spec.ClaimRef.Name, claim)
+		}
+		if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "old" + claim[len("renamed"):]}, &corev1.PersistentVolumeClaim{}); err == nil {
+			t.Errorf("previous claim of %s not deleted", claim)
+		}
+
+		// The volume controller binds the pre-bound claim
+		pvc.Status.Phase = corev1.ClaimBound
+		if err := h.client.Status().Update(h.ctx, pvc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	adopted := &corev1.Secret{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "renamed-minio-api"}, adopted); err != nil {
+		t.Fatalf("credentials not adopted: %v", err)
+	}
+	if string(adopted.Data[storageAccessKeyKey]) != "old-api" || !metav1.IsControlledBy(adopted, h.get(t, "renamed")) {
+		t.Errorf("adopted Secret = %+v, want the previous credentials owned by the instance", adopted)
+	}
+
+	h.reconcile(t, "renamed")
+	status := h.get(t, "renamed").Status
+	if !status.Migration.Completed || !conditions.IsTrue(status.Conditions, conditions.TypeMigrated) {
+		t.Errorf("migration = %+v, want completed", status.Migration)
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Name: "pv-old-shared-pvc"}, pv); err != nil {
+		t.Fatal(err)
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimDelete {
+		t.Errorf("reclaim policy = %s, want the original Delete restored", pv.Spec.PersistentVolumeReclaimPolicy)
+	}
+}
+
+func TestMigrationRequiresDeletedPreviousInstance(t *testing.T) {
+	ragme := harnessRAGme("renamed")
+	ragme.Spec.PreviousInstanceName = "old"
+	h := newReconcilerHarness(t, ragme, harnessRAGme("old"))
+
+	_, err := h.reconciler.Reconcile(h.ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "bench", Name: "renamed"}})
+	if err == nil {
+		t.Fatal("Reconcile() migrated the data of a live instance")
+	}
+	status := h.get(t, "renamed").Status
+	if status.Migration.From != "" {
+		t.Errorf("migration = %+v, want none planned", status.Migration)
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "renamed-shared-pvc"}, &corev1.PersistentVolumeClaim{}); err == nil {
+		t.Error("empty volume provisioned while the migration is blocked")
+	}
+}
@@ -0,0 +1,305 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultMilvusVersion     = "v2.4.15"
+	defaultMilvusStorageSize = "10Gi"
+	milvusImageRepo          = "milvusdb/milvus"
+	milvusPort               = 19530
+	milvusHealthPort         = 9091
+	milvusDataPath           = "/var/lib/milvus"
+	milvusEmbedEtcdKey       = "embedEtcd.yaml"
+)
+
+// milvusEmbedEtcdConfig configures the etcd embedded in the standalone Milvus,
+// as in the standalone_embed.sh script of Milvus
+const milvusEmbedEtcdConfig = `listen-client-urls: http://0.0.0.0:2379
+advertise-client-urls: http://0.0.0.0:2379
+quota-backend-bytes: 4294967296
+auto-compaction-mode: revision
+auto-compaction-retention: '1000'
+`
+
+// milvusInCluster reports whether the operator runs Milvus in the namespace
+func milvusInCluster(ragme *ragmev1.RAGme) bool {
+	milvus := ragme.Spec.VectorDB.Milvus
+	return ragme.Spec.VectorDB.Type == "milvus" && milvus.Enabled && milvus.URI == ""
+}
+
+// milvusName returns the name of the standalone Milvus resources
+func milvusName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-milvus", ragme.Name)
+}
+
+// milvusURI returns the URI of the configured cluster, or of the standalone Milvus
+func milvusURI(ragme *ragmev1.RAGme) string {
+	if uri := ragme.Spec.VectorDB.Milvus.URI; uri != "" || !milvusInCluster(ragme) {
+		return uri
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", milvusName(ragme), ragme.Namespace, milvusPort)
+}
+
+// milvusVersion returns the image tag of the standalone Milvus
+func milvusVersion(ragme *ragmev1.RAGme) string {
+	if version := ragme.Spec.VectorDB.Milvus.Version; version != "" {
+		return version
+	}
+	return defaultMilvusVersion
+}
+
+// milvusLabels returns the labels of the standalone Milvus
+func milvusLabels(ragme *ragmev1.RAGme) map[string]string {
+	return map[string]string{
+		"app":       "ragme",
+		"component": "milvus",
+		"instance":  ragme.Name,
+	}
+}
+
+// applyVectorDB points the api, mcp and agent at Milvus
+func applyVectorDB(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if ragme.Spec.VectorDB.Type != "milvus" || (serviceName != "api" && serviceName != "mcp" && serviceName != "agent") {
+		return
+	}
+	env := []corev1.EnvVar{{Name: "VECTOR_DB_TYPE", Value: "milvus"}}
+	if uri := milvusURI(ragme); uri != "" {
+		env = append(env, corev1.EnvVar{Name: "MILVUS_URI", Value: uri})
+	}
+	if token := ragme.Spec.VectorDB.Milvus.Token; token != "" {
+		env = append(env, corev1.EnvVar{Name: "MILVUS_TOKEN", Value: token})
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+}
+
+// reconcileMilvus reconciles the standalone Milvus: its embedded etcd
+// configuration, volume, Deployment and Service
+func (r *RAGmeReconciler) reconcileMilvus(ctx context.Context, ragme *ragmev1.RAGme) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      milvusName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    milvusLabels(ragme),
+		},
+		Data: map[string]string{milvusEmbedEtcdKey: milvusEmbedEtcdConfig},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+	foundConfigMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, foundConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if !reflect.DeepEqual(foundConfigMap.Data, configMap.Data) {
+		foundConfigMap.Data = configMap.Data
+		if err := r.Update(ctx, foundConfigMap); err != nil {
+			return err
+		}
+	}
+
+	size := ragme.Spec.VectorDB.Milvus.StorageSize
+	if size == "" {
+		size = defaultMilvusStorageSize
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      milvusName(ragme) + "-pvc",
+			Namespace: ragme.Namespace,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{
+				corev1.ReadWriteOnce,
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(size),
+				},
+			},
+			StorageClassName: className(ragme.Status.Classes.StorageClass),
+		},
+	}
+	if err := r.setOwner(ragme, pvc); err != nil {
+		return err
+	}
+
+	found := &corev1.PersistentVolumeClaim{}
+	err = r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, pvc); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	zones, err := r.volumeZones(ctx, ragme, found, placementZones(ragme, "milvus", 0))
+	if err != nil {
+		return err
+	}
+
+	deployment := createMilvusDeployment(ragme)
+	applyZonePlacement(zoneLabel(ragme), zones, &deployment.Spec.Template.Spec)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	service := createMilvusService(ragme)
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// createMilvusDeployment runs Milvus standalone with its embedded etcd and
+// local storage, all in the single container of the all-in-one image
+func createMilvusDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	labels := milvusLabels(ragme)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      milvusName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &[]int32{1}[0],
+			// Milvus owns its volume, the old pod must stop before the new one starts
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "milvus",
+							Image:   fmt.Sprintf("%s:%s", milvusImageRepo, milvusVersion(ragme)),
+							Command: []string{"milvus", "run", "standalone"},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: milvusPort, Name: "grpc"},
+								{ContainerPort: milvusHealthPort, Name: "health"},
+							},
+							Env: []corev1.EnvVar{
+								{Name: "ETCD_USE_EMBED", Value: "true"},
+								{Name: "ETCD_DATA_DIR", Value: milvusDataPath + "/etcd"},
+								{Name: "ETCD_CONFIG_PATH", Value: "/milvus/configs/" + milvusEmbedEtcdKey},
+								{Name: "COMMON_STORAGETYPE", Value: "local"},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "milvus-data", MountPath: milvusDataPath},
+								{Name: "milvus-config", MountPath: "/milvus/configs/" + milvusEmbedEtcdKey, SubPath: milvusEmbedEtcdKey},
+							},
+							LivenessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromInt(milvusHealthPort),
+									},
+								},
+								InitialDelaySeconds: 90,
+								PeriodSeconds:       30,
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromInt(milvusHealthPort),
+									},
+								},
+								InitialDelaySeconds: 30,
+								PeriodSeconds:       10,
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "milvus-data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: milvusName(ragme) + "-pvc",
+								},
+							},
+						},
+						{
+							Name: "milvus-config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: milvusName(ragme)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyAutoscalerAnnotations(ragme, "milvus", &deployment.Spec.Template)
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createMilvusService exposes the gRPC and REST API of the standalone Milvus
+func createMilvusService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := milvusLabels(ragme)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      milvusName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "grpc", Port: milvusPort, TargetPort: intstr.FromInt(milvusPort)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestMilvusStandalone(t *testing.T) {
+	ragme := harnessRAGme("vectors")
+	ragme.Spec.VectorDB = ragmev1.RAGmeVectorDB{
+		Type:   "milvus",
+		Milvus: ragmev1.RAGmeMilvusDB{Enabled: true, StorageSize: "20Gi"},
+	}
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "vectors")
+
+	deployment := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "vectors-milvus"}, deployment); err != nil {
+		t.Fatalf("Milvus not deployed: %v", err)
+	}
+	container := deployment.Spec.Template.Spec.Containers[0]
+	if container.Image != "milvusdb/milvus:"+defaultMilvusVersion || envValue(container.Env, "ETCD_USE_EMBED") != "true" {
+		t.Errorf("Milvus container = %s %v, want the standalone image with its embedded etcd", container.Image, container.Env)
+	}
+	for _, obj := range []client.Object{&corev1.Service{}, &corev1.ConfigMap{}} {
+		if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "vectors-milvus"}, obj); err != nil {
+			t.Errorf("Milvus %T: %v", obj, err)
+		}
+	}
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "vectors-milvus-pvc"}, pvc); err != nil {
+		t.Fatalf("Milvus volume not claimed: %v", err)
+	}
+	if size := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; size.String() != "20Gi" {
+		t.Errorf("Milvus volume size = %s, want 20Gi", size.String())
+	}
+
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "vectors-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	if uri := envValue(api.Spec.Template.Spec.Containers[0].Env, "MILVUS_URI"); uri != "http://vectors-milvus.bench.svc:19530" {
+		t.Errorf("MILVUS_URI = %q, want the standalone Milvus", uri)
+	}
+}
+
+func TestMilvusExternal(t *testing.T) {
+	ragme := harnessRAGme("remote")
+	ragme.Spec.VectorDB = ragmev1.RAGmeVectorDB{
+		Type:   "milvus",
+		Milvus: ragmev1.RAGmeMilvusDB{Enabled: true, URI: "http://milvus.vectors:19530", Token: "root:Milvus"},
+	}
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "remote")
+
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "remote-milvus"}, &appsv1.Deployment{}); err == nil {
+		t.Error("Milvus deployed although the spec points at a cluster")
+	}
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "remote-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	env := api.Spec.Template.Spec.Containers[0].Env
+	if envValue(env, "MILVUS_URI") != "http://milvus.vectors:19530" || envValue(env, "MILVUS_TOKEN") != "root:Milvus" {
+		t.Errorf("api env = %v, want the URI and token of the cluster", env)
+	}
+}
@@ -0,0 +1,393 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	storageAccessKeyKey      = "accessKey"
+	storageSecretKeyKey      = "secretKey"
+	storageUserLabel         = "ragme.io/storage-user"
+	storageRevisionKey       = "ragme.io/storage-credentials-revision"
+	storagePoliciesMountPath = "/policies"
+)
+
+// storageUser is a MinIO user the operator provisions for a service
+type storageUser struct {
+	Service  string
+	ReadOnly bool
+}
+
+// storageUsers are the per-service MinIO users. Backup jobs only read documents.
+var storageUsers = []storageUser{
+	{Service: "api"},
+	{Service: "agent", ReadOnly: true},
+	{Service: "backup", ReadOnly: true},
+}
+
+// externalStorageCredentials reports whether the credentials are brought in Secrets
+func externalStorageCredentials(ragme *ragmev1.RAGme) bool {
+	refs := ragme.Spec.Storage.MinIO.Credentials.SecretRefs
+	return refs.API != nil || refs.Agent != nil || refs.Backup != nil
+}
+
+// perServiceStorageUsers reports whether the operator provisions the MinIO users
+func perServiceStorageUsers(ragme *ragmev1.RAGme) bool {
+	return ragme.Spec.Storage.MinIO.Enabled && ragme.Spec.Storage.MinIO.Credentials.PerService && !externalStorageCredentials(ragme)
+}
+
+// storageSecretRef returns the Secret holding the credentials of a service, if any
+func storageSecretRef(ragme *ragmev1.RAGme, service string) *corev1.LocalObjectReference {
+	if externalStorageCredentials(ragme) {
+		refs := ragme.Spec.Storage.MinIO.Credentials.SecretRefs
+		switch service {
+		case "api":
+			return refs.API
+		case "agent":
+			return refs.Agent
+		case "backup":
+			return refs.Backup
+		}
+		return nil
+	}
+	if perServiceStorageUsers(ragme) {
+		return &corev1.LocalObjectReference{Name: fmt.Sprintf("%s-minio-%s", ragme.Name, service)}
+	}
+	return nil
+}
+
+// storageBuckets returns the buckets the service users are scoped to
+func storageBuckets(ragme *ragmev1.RAGme) []string {
+	if buckets := ragme.Spec.Storage.MinIO.Credentials.Buckets; len(buckets) > 0 {
+		return buckets
+	}
+	return []string{"ragme-*"}
+}
+
+// bucketPolicy renders the MinIO policy granting access to the buckets
+func bucketPolicy(buckets []string, readOnly bool) ([]byte, error) {
+	actions := []string{"s3:GetBucketLocation", "s3:ListBucket", "s3:GetObject"}
+	if !readOnly {
+		actions = append(actions, "s3:CreateBucket", "s3:PutObject", "s3:DeleteObject",
+			"s3:AbortMultipartUpload", "s3:ListMultipartUploadParts", "s3:ListBucketMultipartUploads")
+	}
+	resources := []string{}
+	for _, bucket := range buckets {
+		resources = append(resources, "arn:aws:s3:::"+bucket, "arn:aws:s3:::"+bucket+"/*")
+	}
+
+	return json.MarshalIndent(map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []map[string]interface{}{
+			{"Effect": "Allow", "Action": actions, "Resource": resources},
+		},
+	}, "", "  ")
+}
+
+// storagePolicyName returns the MinIO policy name of an access level
+func storagePolicyName(ragme *ragmev1.RAGme, readOnly bool) string {
+	if readOnly {
+		return fmt.Sprintf("%s-readonly", ragme.Name)
+	}
+	return fmt.Sprintf("%s-readwrite", ragme.Name)
+}
+
+// applyStorageCredentials passes the object storage credentials to the api and agent
+func applyStorageCredentials(ragme *ragmev1.RAGme, serviceName string, template *corev1.PodTemplateSpec) {
+	if serviceName != "api" && serviceName != "agent" {
+		return
+	}
+
+	container := &template.Spec.Containers[0]
+	if storage := newObjectStorageProvider(nil, ragme); storage.Name() != objectStorageMinIO {
+		// The services select their storage backend from STORAGE_TYPE and
+		// reach every other provider through the S3 API
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "STORAGE_TYPE", Value: "s3"},
+			corev1.EnvVar{Name: "S3_ENDPOINT", Value: storage.Endpoint(ragme)},
+			corev1.EnvVar{Name: "S3_REGION", Value: objectStorageRegion(ragme)},
+			corev1.EnvVar{Name: "S3_BUCKET_NAME", Value: objectStorageBucket(ragme)},
+		)
+		container.Env = append(container.Env, storage.Credentials(ragme, serviceName).env("S3_ACCESS_KEY", "S3_SECRET_KEY")...)
+		return
+	}
+
+	ref := storageSecretRef(ragme, serviceName)
+	if ref == nil {
+		return
+	}
+	container.Env = append(container.Env, secretCredentials(ref).env("MINIO_ACCESS_KEY", "MINIO_SECRET_KEY")...)
+
+	// Roll the pods once rotated credentials are active in MinIO
+	if revision := ragme.Status.Storage.CredentialsRevision; revision != "" && perServiceStorageUsers(ragme) {
+		template.Annotations = mergeStringMaps(template.Annotations, map[string]string{storageRevisionKey: revision})
+	}
+}
+
+// reconcileStorageCredentials generates and rotates the per-service MinIO
+// users and provisions them in MinIO with a Job. The revision of the active
+// credentials is recorded in status once the Job has succeeded.
+func (r *RAGmeReconciler) reconcileStorageCredentials(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !perServiceStorageUsers(ragme) {
+		ragme.Status.Storage.CredentialsRevision = ""
+		secrets := &corev1.SecretList{}
+		if err := r.List(ctx, secrets, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+			"instance":       ragme.Name,
+			storageUserLabel: "true",
+		}); err != nil {
+			return err
+		}
+		for i := range secrets.Items {
+			if err := r.Delete(ctx, &secrets.Items[i]); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	hash := sha256.New()
+	for _, user := range storageUsers {
+		secret, err := r.reconcileStorageUserSecret(ctx, ragme, user)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile %s storage user: %w", user.Service, err)
+		}
+		fmt.Fprintf(hash, "%s:%s:%s\n", user.Service, secret.Data[storageAccessKeyKey], secret.Data[storageSecretKeyKey])
+	}
+
+	policies := map[string]string{}
+	for _, readOnly := range []bool{false, true} {
+		policy, err := bucketPolicy(storageBuckets(ragme), readOnly)
+		if err != nil {
+			return err
+		}
+		policies[storagePolicyName(ragme, readOnly)+".json"] = string(policy)
+		hash.Write(policy)
+	}
+	revision := hex.EncodeToString(hash.Sum(nil))[:8]
+	if ragme.Status.Storage.CredentialsRevision == revision {
+		return nil
+	}
+
+	if err := r.reconcileStoragePolicies(ctx, ragme, policies); err != nil {
+		return err
+	}
+
+	job := createStorageUsersJob(ragme, revision)
+	if err := r.setOwner(ragme, job); err != nil {
+		return err
+	}
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, job)
+	} else if err != nil {
+		return err
+	}
+
+	switch {
+	case found.Status.Succeeded > 0:
+		ragme.Status.Storage.CredentialsRevision = revision
+	case jobFailed(found):
+		return fmt.Errorf("provisioning the MinIO users failed, see the logs of job %s", found.Name)
+	}
+	return nil
+}
+
+// reconcileStorageUserSecret creates the Secret holding the credentials of a
+// service user, rotating the secret key when its rotation period has elapsed
+func (r *RAGmeReconciler) reconcileStorageUserSecret(ctx context.Context, ragme *ragmev1.RAGme, user storageUser) (*corev1.Secret, error) {
+	name := fmt.Sprintf("%s-minio-%s", ragme.Name, user.Service)
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if errors.IsNotFound(err) {
+		secretKey, err := generateStorageSecretKey()
+		if err != nil {
+			return nil, err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ragme.Namespace,
+				Labels: map[string]string{
+					"app":            "ragme",
+					"instance":       ragme.Name,
+					storageUserLabel: "true",
+				},
+				Annotations: map[string]string{
+					apiKeyRotatedAtKey: r.now().UTC().Format(time.RFC3339),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				storageAccessKeyKey: []byte(fmt.Sprintf("%s-%s", ragme.Name, user.Service)),
+				storageSecretKeyKey: []byte(secretKey),
+			},
+		}
+		if err := r.setOwner(ragme, secret); err != nil {
+			return nil, err
+		}
+		return secret, r.Create(ctx, secret)
+	}
+
+	due, err := rotationDue(found, ragme.Spec.Storage.MinIO.Credentials.RotationPeriod)
+	if err != nil {
+		return nil, err
+	}
+	if !due {
+		return found, nil
+	}
+
+	secretKey, err := generateStorageSecretKey()
+	if err != nil {
+		return nil, err
+	}
+	if found.Annotations == nil {
+		found.Annotations = map[string]string{}
+	}
+	found.Annotations[apiKeyRotatedAtKey] = r.now().UTC().Format(time.RFC3339)
+	found.Data[storageSecretKeyKey] = []byte(secretKey)
+	return found, r.Update(ctx, found)
+}
+
+// generateStorageSecretKey returns a random MinIO secret key
+func generateStorageSecretKey() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// reconcileStoragePolicies renders the bucket policies mounted into the provisioning Job
+func (r *RAGmeReconciler) reconcileStoragePolicies(ctx context.Context, ragme *ragmev1.RAGme, policies map[string]string) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-minio-policies", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: policies,
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(found.Data, configMap.Data) {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// createStorageUsersJob returns the Job creating the bucket policies and the
+// per-service users in MinIO. Adding an existing user updates its secret key.
+func createStorageUsersJob(ragme *ragmev1.RAGme, revision string) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "minio-users",
+		"instance":  ragme.Name,
+	}
+
+	script := `set -e
+mc alias set ragme "http://${MINIO_ENDPOINT}" "${MINIO_ROOT_USER}" "${MINIO_ROOT_PASSWORD}"
+for policy in ` + storagePoliciesMountPath + `/*.json; do
+  mc admin policy create ragme "$(basename "${policy}" .json)" "${policy}"
+done
+provision() {
+  mc admin user add ragme "$1" "$2"
+  if ! mc admin user info ragme "$1" --json | grep -q "\"$3\""; then
+    mc admin policy attach ragme "$3" --user "$1"
+  fi
+}
+`
+	env := append([]corev1.EnvVar{
+		{Name: "MINIO_ENDPOINT", Value: fmt.Sprintf("%s-minio:9000", ragme.Name)},
+	}, minioRootCredentials(ragme).env("MINIO_ROOT_USER", "MINIO_ROOT_PASSWORD")...)
+	for _, user := range storageUsers {
+		prefix := strings.ToUpper(user.Service) + "_"
+		ref := corev1.LocalObjectReference{Name: fmt.Sprintf("%s-minio-%s", ragme.Name, user.Service)}
+		env = append(env,
+			corev1.EnvVar{Name: prefix + "ACCESS_KEY", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref, Key: storageAccessKeyKey},
+			}},
+			corev1.EnvVar{Name: prefix + "SECRET_KEY", ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref, Key: storageSecretKeyKey},
+			}},
+		)
+		script += fmt.Sprintf("provision \"${%sACCESS_KEY}\" \"${%sSECRET_KEY}\" %q\n",
+			prefix, prefix, storagePolicyName(ragme, user.ReadOnly))
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyOnFailure,
+		Containers: []corev1.Container{
+			{
+				Name:    "mc",
+				Image:   "minio/mc:latest",
+				Command: []string{"/bin/sh", "-c", script},
+				Env:     env,
+			},
+		},
+	}
+	mountVolume(&podSpec, corev1.Volume{
+		Name: "policies",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: fmt.Sprintf("%s-minio-policies", ragme.Name)},
+			},
+		},
+	}, storagePoliciesMountPath)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-minio-users-%s", ragme.Name, revision),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{6}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
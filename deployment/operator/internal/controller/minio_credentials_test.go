@@ -0,0 +1,91 @@
+package controller
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestBucketPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		readOnly   bool
+		wantWrites bool
+	}{
+		{name: "read-write", wantWrites: true},
+		{name: "read-only", readOnly: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := bucketPolicy([]string{"ragme-*"}, tt.readOnly)
+			if err != nil {
+				t.Fatalf("bucketPolicy() error = %v", err)
+			}
+			policy := struct {
+				Statement []struct {
+					Action   []string
+					Resource []string
+				}
+			}{}
+			if err := json.Unmarshal(data, &policy); err != nil {
+				t.Fatalf("invalid policy JSON: %v", err)
+			}
+			if len(policy.Statement) != 1 {
+				t.Fatalf("expected a single statement, got %d", len(policy.Statement))
+			}
+			statement := policy.Statement[0]
+			if want := []string{"arn:aws:s3:::ragme-*", "arn:aws:s3:::ragme-*/*"}; !reflect.DeepEqual(statement.Resource, want) {
+				t.Errorf("resources = %v, want %v", statement.Resource, want)
+			}
+			writes := false
+			for _, action := range statement.Action {
+				if action == "s3:PutObject" || action == "s3:DeleteObject" {
+					writes = true
+				}
+			}
+			if writes != tt.wantWrites {
+				t.Errorf("policy grants writes = %v, want %v", writes, tt.wantWrites)
+			}
+		})
+	}
+}
+
+func TestStorageSecretRef(t *testing.T) {
+	external := &corev1.LocalObjectReference{Name: "s3-agent"}
+	tests := []struct {
+		name        string
+		credentials ragmev1.RAGmeStorageCredentials
+		want        *corev1.LocalObjectReference
+	}{
+		{name: "shared root credentials"},
+		{
+			name:        "generated user",
+			credentials: ragmev1.RAGmeStorageCredentials{PerService: true},
+			want:        &corev1.LocalObjectReference{Name: "test-minio-agent"},
+		},
+		{
+			name: "secret refs replace generated users",
+			credentials: ragmev1.RAGmeStorageCredentials{PerService: true, SecretRefs: ragmev1.RAGmeStorageSecretRefs{
+				API: &corev1.LocalObjectReference{Name: "s3-api"}, Agent: external, Backup: &corev1.LocalObjectReference{Name: "s3-backup"},
+			}},
+			want: external,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Name = "test"
+			ragme.Spec.Storage.MinIO.Enabled = true
+			ragme.Spec.Storage.MinIO.Credentials = tt.credentials
+			if got := storageSecretRef(ragme, "agent"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("storageSecretRef() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
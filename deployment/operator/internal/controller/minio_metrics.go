@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	minioMetricsPath     = "/minio/v2/metrics/cluster"
+	minioMetricsTokenKey = "token"
+)
+
+// reconcileMinIOMetrics provisions the bearer token Secret and the optional
+// ServiceMonitor used to scrape MinIO metrics
+func (r *RAGmeReconciler) reconcileMinIOMetrics(ctx context.Context, ragme *ragmev1.RAGme) error {
+	metrics := ragme.Spec.Storage.MinIO.Metrics
+	if !metrics.Enabled {
+		return r.deleteServiceMonitor(ctx, ragme, fmt.Sprintf("%s-minio", ragme.Name))
+	}
+
+	if metrics.AuthType == "jwt" {
+		accessKey, secretKey, err := minioRootKeys(ctx, r.Client, ragme)
+		if err != nil {
+			return err
+		}
+		token, err := minioPrometheusToken(accessKey, secretKey)
+		if err != nil {
+			return err
+		}
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-minio-prometheus", ragme.Name),
+				Namespace: ragme.Namespace,
+				Labels: map[string]string{
+					"app":       "ragme",
+					"component": "minio",
+					"instance":  ragme.Name,
+				},
+			},
+			Type:       corev1.SecretTypeOpaque,
+			StringData: map[string]string{minioMetricsTokenKey: token},
+		}
+		if err := r.setOwner(ragme, secret); err != nil {
+			return err
+		}
+
+		found := &corev1.Secret{}
+		err = r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
+		if err != nil && errors.IsNotFound(err) {
+			if err := r.Create(ctx, secret); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+
+	// With annotation scraping the pods or Service carry prometheus.io annotations instead
+	if metrics.ServiceMonitor && !annotationScraping(ragme) {
+		return r.reconcileMinIOServiceMonitor(ctx, ragme)
+	}
+	return r.deleteServiceMonitor(ctx, ragme, fmt.Sprintf("%s-minio", ragme.Name))
+}
+
+// reconcileMinIOServiceMonitor creates a Prometheus Operator ServiceMonitor
+// for MinIO. It is skipped when the ServiceMonitor CRD is not installed.
+func (r *RAGmeReconciler) reconcileMinIOServiceMonitor(ctx context.Context, ragme *ragmev1.RAGme) error {
+	labels := map[string]interface{}{
+		"app":       "ragme",
+		"component": "minio",
+		"instance":  ragme.Name,
+	}
+	endpoint := map[string]interface{}{
+		"port": "api",
+		"path": minioMetricsPath,
+	}
+	if ragme.Spec.Storage.MinIO.Metrics.AuthType == "jwt" {
+		endpoint["bearerTokenSecret"] = map[string]interface{}{
+			"name": fmt.Sprintf("%s-minio-prometheus", ragme.Name),
+			"key":  minioMetricsTokenKey,
+		}
+	}
+
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetAPIVersion("monitoring.coreos.com/v1")
+	serviceMonitor.SetKind("ServiceMonitor")
+	serviceMonitor.SetName(fmt.Sprintf("%s-minio", ragme.Name))
+	serviceMonitor.SetNamespace(ragme.Namespace)
+	serviceMonitor.SetLabels(map[string]string{
+		"app":       "ragme",
+		"component": "minio",
+		"instance":  ragme.Name,
+	})
+	serviceMonitor.Object["spec"] = map[string]interface{}{
+		"selector":  map[string]interface{}{"matchLabels": labels},
+		"endpoints": []interface{}{endpoint},
+	}
+	return r.applyServiceMonitor(ctx, ragme, serviceMonitor)
+}
+
+// applyServiceMonitor creates or updates a Prometheus Operator ServiceMonitor.
+// It is skipped when the ServiceMonitor CRD is not installed.
+func (r *RAGmeReconciler) applyServiceMonitor(ctx context.Context, ragme *ragmev1.RAGme, serviceMonitor *unstructured.Unstructured) error {
+	if err := r.setOwner(ragme, serviceMonitor); err != nil {
+		return err
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(serviceMonitor.GroupVersionKind())
+	err := r.Get(ctx, types.NamespacedName{Name: serviceMonitor.GetName(), Namespace: serviceMonitor.GetNamespace()}, found)
+	if meta.IsNoMatchError(err) {
+		log.FromContext(ctx).Info("ServiceMonitor CRD not installed, skipping ServiceMonitor", "serviceMonitor", serviceMonitor.GetName())
+		return nil
+	}
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, serviceMonitor)
+	} else if err != nil {
+		return err
+	}
+
+	found.Object["spec"] = serviceMonitor.Object["spec"]
+	return r.Update(ctx, found)
+}
+
+// checkMinIOCapacity reads the MinIO cluster capacity metrics, records the
+// usage in status and flips the StorageAlmostFull condition when the usage
+// crosses the configured threshold
+func (r *RAGmeReconciler) checkMinIOCapacity(ctx context.Context, ragme *ragmev1.RAGme) error {
+	metrics := ragme.Spec.Storage.MinIO.Metrics
+	if !ragme.Spec.Storage.MinIO.Enabled || !metrics.Enabled {
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeStorageAlmostFull)
+		return nil
+	}
+
+	samples, err := r.fetchMinIOMetrics(ctx, ragme, minioMetricsPath,
+		"minio_cluster_capacity_usable_total_bytes", "minio_cluster_capacity_usable_free_bytes")
+	if err != nil {
+		return err
+	}
+	total := int64(sumSamples(samples, "minio_cluster_capacity_usable_total_bytes", nil))
+	free := int64(sumSamples(samples, "minio_cluster_capacity_usable_free_bytes", nil))
+	if total <= 0 {
+		return fmt.Errorf("MinIO did not report usable capacity")
+	}
+
+	usage := ragmev1.StorageUsageStatus{
+		UsedBytes:     total - free,
+		CapacityBytes: total,
+		UsedPercent:   int32((total - free) * 100 / total),
+	}
+	ragme.Status.Storage.MinIO = usage
+
+	message := fmt.Sprintf("MinIO usage is %d%% (threshold %d%%)", usage.UsedPercent, metrics.CapacityThresholdPercent)
+	if usage.UsedPercent >= metrics.CapacityThresholdPercent {
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeStorageAlmostFull, conditions.ReasonCapacityThresholdExceeded, message)
+	} else {
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeStorageAlmostFull, conditions.ReasonCapacityWithinThreshold, message)
+	}
+
+	return nil
+}
+
+// fetchMinIOMetrics scrapes the given MinIO metrics endpoint and returns the requested samples
+func (r *RAGmeReconciler) fetchMinIOMetrics(ctx context.Context, ragme *ragmev1.RAGme, path string, names ...string) ([]promSample, error) {
+	return scrapeMinIOMetrics(ctx, r.Client, defaultHTTPClient(r.HTTPClient), ragme, path, names...)
+}
+
+// scrapeMinIOMetrics scrapes a MinIO metrics endpoint of the instance,
+// authenticating with a bearer token signed with the root user when required
+func scrapeMinIOMetrics(ctx context.Context, reader client.Reader, httpClient *http.Client, ragme *ragmev1.RAGme, path string, names ...string) ([]promSample, error) {
+	url := fmt.Sprintf("http://%s-minio.%s.svc:9000%s", ragme.Name, ragme.Namespace, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	// jwt is the MinIO default when no auth type is set
+	if ragme.Spec.Storage.MinIO.Metrics.AuthType != "public" {
+		accessKey, secretKey, err := minioRootKeys(ctx, reader, ragme)
+		if err != nil {
+			return nil, err
+		}
+		token, err := minioPrometheusToken(accessKey, secretKey)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return parsePrometheusText(resp.Body, names...)
+}
+
+// minioPrometheusToken generates the bearer token MinIO expects on its
+// Prometheus endpoint, equivalent to `mc admin prometheus generate`
+func minioPrometheusToken(accessKey, secretKey string) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "HS512", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"exp": time.Now().AddDate(100, 0, 0).Unix(),
+		"sub": accessKey,
+		"iss": "prometheus",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	mac := hmac.New(sha512.New, []byte(secretKey))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	minioRootSecretLabel = "ragme.io/minio-root"
+	minioRootRevisionKey = "ragme.io/minio-root-revision"
+)
+
+// minioRootSecretName returns the name of the generated Secret of the root user
+func minioRootSecretName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-minio-root", ragme.Name)
+}
+
+// generatedMinIORootSecret reports whether the operator generates the Secret
+// of the root user, i.e. the spec references none
+func generatedMinIORootSecret(ragme *ragmev1.RAGme) bool {
+	minio := ragme.Spec.Storage.MinIO
+	return minio.ExistingSecretRef == nil && minio.SecretKeyRefs.AccessKey == nil && minio.SecretKeyRefs.SecretKey == nil
+}
+
+// minioRootCredentials selects the keys of the MinIO root user: the keys of
+// secretKeyRefs, the ones of existingSecretRef or the ones of the generated Secret
+func minioRootCredentials(ragme *ragmev1.RAGme) objectStorageCredentials {
+	minio := ragme.Spec.Storage.MinIO
+	switch {
+	case minio.SecretKeyRefs.AccessKey != nil || minio.SecretKeyRefs.SecretKey != nil:
+		return objectStorageCredentials{AccessKey: minio.SecretKeyRefs.AccessKey, SecretKey: minio.SecretKeyRefs.SecretKey}
+	case minio.ExistingSecretRef != nil:
+		return secretCredentials(minio.ExistingSecretRef)
+	default:
+		return secretCredentials(&corev1.LocalObjectReference{Name: minioRootSecretName(ragme)})
+	}
+}
+
+// minioRootSecretNames returns the Secrets holding the root user
+func minioRootSecretNames(ragme *ragmev1.RAGme) sets.Set[string] {
+	names := sets.New[string]()
+	credentials := minioRootCredentials(ragme)
+	for _, selector := range []*corev1.SecretKeySelector{credentials.AccessKey, credentials.SecretKey} {
+		if selector != nil {
+			names.Insert(selector.Name)
+		}
+	}
+	return names
+}
+
+// reconcileMinIORootSecret generates the Secret of the root user unless the
+// spec references one. The deprecated plaintext keys of the spec seed it and
+// keep precedence over its content, so they can still be changed in place.
+func (r *RAGmeReconciler) reconcileMinIORootSecret(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !generatedMinIORootSecret(ragme) {
+		return nil
+	}
+	minio := ragme.Spec.Storage.MinIO
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: minioRootSecretName(ragme), Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if errors.IsNotFound(err) {
+		accessKey, secretKey := minio.AccessKey, minio.SecretKey
+		if accessKey == "" {
+			accessKey = fmt.Sprintf("%s-root", ragme.Name)
+		}
+		if secretKey == "" {
+			if secretKey, err = generateStorageSecretKey(); err != nil {
+				return err
+			}
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      minioRootSecretName(ragme),
+				Namespace: ragme.Namespace,
+				Labels: map[string]string{
+					"app":                "ragme",
+					"component":          "minio",
+					"instance":           ragme.Name,
+					minioRootSecretLabel: "true",
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				storageAccessKeyKey: []byte(accessKey),
+				storageSecretKeyKey: []byte(secretKey),
+			},
+		}
+		if err := r.setOwner(ragme, secret); err != nil {
+			return err
+		}
+		return r.Create(ctx, secret)
+	}
+
+	changed := false
+	for key, value := range map[string]string{storageAccessKeyKey: minio.AccessKey, storageSecretKeyKey: minio.SecretKey} {
+		if value != "" && string(found.Data[key]) != value {
+			if found.Data == nil {
+				found.Data = map[string][]byte{}
+			}
+			found.Data[key] = []byte(value)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return r.Update(ctx, found)
+}
+
+// minioRootKeys reads the access and secret keys of the root user, for the
+// requests the operator signs itself
+func minioRootKeys(ctx context.Context, reader client.Reader, ragme *ragmev1.RAGme) (string, string, error) {
+	credentials := minioRootCredentials(ragme)
+	keys := make([]string, 2)
+	for i, selector := range []*corev1.SecretKeySelector{credentials.AccessKey, credentials.SecretKey} {
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, types.NamespacedName{Name: selector.Name, Namespace: ragme.Namespace}, secret); err != nil {
+			return "", "", fmt.Errorf("MinIO root credentials: %w", err)
+		}
+		value, ok := secret.Data[selector.Key]
+		if !ok {
+			return "", "", fmt.Errorf("MinIO root credentials: Secret %s has no %s key", selector.Name, selector.Key)
+		}
+		keys[i] = string(value)
+	}
+	return keys[0], keys[1], nil
+}
+
+// minioRootRevision hashes the root user, so MinIO restarts with changed keys
+func minioRootRevision(accessKey, secretKey string) string {
+	hash := sha256.Sum256([]byte(accessKey + "\n" + secretKey))
+	return hex.EncodeToString(hash[:])[:8]
+}
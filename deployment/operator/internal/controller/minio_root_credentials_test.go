@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// minioEnv returns the environment of the MinIO container of an instance
+func minioEnv(t *testing.T, h *reconcilerHarness, name string) (map[string]corev1.EnvVar, map[string]string) {
+	t.Helper()
+	deployment := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: name + "-minio"}, deployment); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	return env, deployment.Spec.Template.Annotations
+}
+
+func TestMinIORootSecretGenerated(t *testing.T) {
+	ragme := harnessRAGme("vault")
+	ragme.Spec.Storage.MinIO.AccessKey = ""
+	ragme.Spec.Storage.MinIO.SecretKey = ""
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "vault")
+
+	secret := &corev1.Secret{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "vault-minio-root"}, secret); err != nil {
+		t.Fatalf("root Secret not generated: %v", err)
+	}
+	if string(secret.Data[storageAccessKeyKey]) != "vault-root" || len(secret.Data[storageSecretKeyKey]) != 40 {
+		t.Errorf("root Secret data = %v, want a generated secret key", secret.Data)
+	}
+	if !metav1.IsControlledBy(secret, h.get(t, "vault")) || !retainedCredentials(secret) {
+		t.Errorf("root Secret %+v, want owned by the instance and retained with the volumes", secret.ObjectMeta)
+	}
+
+	env, _ := minioEnv(t, h, "vault")
+	for name, key := range map[string]string{"MINIO_ROOT_USER": storageAccessKeyKey, "MINIO_ROOT_PASSWORD": storageSecretKeyKey} {
+		e := env[name]
+		if e.Value != "" || e.ValueFrom == nil || e.ValueFrom.SecretKeyRef.Name != secret.Name || e.ValueFrom.SecretKeyRef.Key != key {
+			t.Errorf("%s = %+v, want a reference to %s of the root Secret", name, e, key)
+		}
+	}
+}
+
+func TestMinIORootSecretSeededFromSpec(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("legacy"))
+	h.reconcile(t, "legacy")
+
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: "bench", Name: "legacy-minio-root"}
+	if err := h.client.Get(h.ctx, key, secret); err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data[storageAccessKeyKey]) != "minioadmin" || string(secret.Data[storageSecretKeyKey]) != "minioadmin" {
+		t.Errorf("root Secret data = %v, want the plaintext keys of the spec", secret.Data)
+	}
+	_, annotations := minioEnv(t, h, "legacy")
+	revision := annotations[minioRootRevisionKey]
+
+	// A changed plaintext key still applies and restarts MinIO
+	current := h.get(t, "legacy")
+	current.Spec.Storage.MinIO.SecretKey = "rotated"
+	if err := h.client.Update(h.ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "legacy")
+	if err := h.client.Get(h.ctx, key, secret); err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data[storageSecretKeyKey]) != "rotated" {
+		t.Errorf("secret key = %s, want the changed key", secret.Data[storageSecretKeyKey])
+	}
+	if _, annotations := minioEnv(t, h, "legacy"); annotations[minioRootRevisionKey] == revision {
+		t.Error("MinIO root revision unchanged after the key changed")
+	}
+}
+
+func TestMinIORootSecretReferenced(t *testing.T) {
+	ragme := harnessRAGme("byo")
+	ragme.Spec.Storage.MinIO.AccessKey = ""
+	ragme.Spec.Storage.MinIO.SecretKey = ""
+	ragme.Spec.Storage.MinIO.SecretKeyRefs = ragmev1.RAGmeMinIOSecretKeyRefs{
+		AccessKey: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "vault-minio"}, Key: "user"},
+		SecretKey: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "vault-minio"}, Key: "password"},
+	}
+	h := newReconcilerHarness(t, ragme)
+
+	request := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "bench", Name: "byo"}}
+	if _, err := h.reconciler.Reconcile(h.ctx, request); err == nil {
+		t.Error("Reconcile() deployed MinIO without its root Secret")
+	}
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-minio", Namespace: "bench"},
+		Data:       map[string][]byte{"user": []byte("root"), "password": []byte("s3cr3t")},
+	}
+	if err := h.client.Create(h.ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "byo")
+
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "byo-minio-root"}, &corev1.Secret{}); err == nil {
+		t.Error("root Secret generated although the spec references one")
+	}
+	env, _ := minioEnv(t, h, "byo")
+	if ref := env["MINIO_ROOT_PASSWORD"].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "vault-minio" || ref.SecretKeyRef.Key != "password" {
+		t.Errorf("MINIO_ROOT_PASSWORD = %+v, want the password key of vault-minio", env["MINIO_ROOT_PASSWORD"])
+	}
+	if requests := h.reconciler.instancesForSecret(h.ctx, source); len(requests) != 1 || requests[0].Name != "byo" {
+		t.Errorf("instancesForSecret() = %v, want the instance using the root Secret", requests)
+	}
+}
+
+func TestValidateMinIORootCredentials(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		mutate func(minio *ragmev1.RAGmeMinIOStorage)
+		valid  bool
+	}{
+		{"plaintext", func(*ragmev1.RAGmeMinIOStorage) {}, true},
+		{"existing Secret", func(minio *ragmev1.RAGmeMinIOStorage) {
+			minio.AccessKey, minio.SecretKey = "", ""
+			minio.ExistingSecretRef = &corev1.LocalObjectReference{Name: "minio-root"}
+		}, true},
+		{"existing Secret and plaintext", func(minio *ragmev1.RAGmeMinIOStorage) {
+			minio.ExistingSecretRef = &corev1.LocalObjectReference{Name: "minio-root"}
+		}, false},
+		{"single key ref", func(minio *ragmev1.RAGmeMinIOStorage) {
+			minio.AccessKey, minio.SecretKey = "", ""
+			minio.SecretKeyRefs.SecretKey = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "minio"}, Key: "password"}
+		}, false},
+		{"key refs and existing Secret", func(minio *ragmev1.RAGmeMinIOStorage) {
+			minio.AccessKey, minio.SecretKey = "", ""
+			minio.ExistingSecretRef = &corev1.LocalObjectReference{Name: "minio-root"}
+			minio.SecretKeyRefs.AccessKey = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "minio"}, Key: "user"}
+			minio.SecretKeyRefs.SecretKey = &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "minio"}, Key: "password"}
+		}, false},
+	} {
+		ragme := harnessRAGme("validate")
+		tc.mutate(&ragme.Spec.Storage.MinIO)
+		if err := validateSpec(ragme); (err == nil) != tc.valid {
+			t.Errorf("%s: validateSpec() error = %v, want valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	monitoringModeAnnotations = "annotations"
+	scrapeAnnotationPrefix    = "prometheus.io/"
+)
+
+// annotationScraping reports whether metrics are discovered through
+// prometheus.io annotations instead of ServiceMonitors
+func annotationScraping(ragme *ragmev1.RAGme) bool {
+	return ragme.Spec.Monitoring.Mode == monitoringModeAnnotations
+}
+
+// scrapeAnnotations returns the annotations for an endpoint to be scraped by
+// the Prometheus kubernetes-pods or kubernetes-service-endpoints jobs
+func scrapeAnnotations(port int32, path string) map[string]string {
+	return map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   fmt.Sprint(port),
+		"prometheus.io/path":   path,
+	}
+}
+
+// podScrapeAnnotations returns the annotations of a scraped pod, or nil when
+// the Services are annotated instead
+func podScrapeAnnotations(ragme *ragmev1.RAGme, port int32, path string) map[string]string {
+	if annotationScraping(ragme) && ragme.Spec.Monitoring.AnnotationTarget == "services" {
+		return nil
+	}
+	return scrapeAnnotations(port, path)
+}
+
+// serviceScrapeAnnotations returns the annotations of a scraped Service, or
+// nil when the pods are annotated instead
+func serviceScrapeAnnotations(ragme *ragmev1.RAGme, port int32, path string) map[string]string {
+	if !annotationScraping(ragme) || ragme.Spec.Monitoring.AnnotationTarget != "services" {
+		return nil
+	}
+	return scrapeAnnotations(port, path)
+}
+
+// syncScrapeAnnotations replaces the prometheus.io annotations of an existing
+// Service with the desired ones and reports whether they changed
+func syncScrapeAnnotations(found, desired *corev1.Service) bool {
+	annotations := map[string]string{}
+	for key, value := range found.Annotations {
+		if !strings.HasPrefix(key, scrapeAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+	for key, value := range desired.Annotations {
+		if strings.HasPrefix(key, scrapeAnnotationPrefix) {
+			annotations[key] = value
+		}
+	}
+
+	changed := len(annotations) != len(found.Annotations)
+	for key, value := range annotations {
+		if current, ok := found.Annotations[key]; !ok || current != value {
+			changed = true
+		}
+	}
+	if changed {
+		found.Annotations = annotations
+	}
+	return changed
+}
+
+// deleteServiceMonitor removes a ServiceMonitor that is no longer wanted. A
+// missing ServiceMonitor CRD is not an error.
+func (r *RAGmeReconciler) deleteServiceMonitor(ctx context.Context, ragme *ragmev1.RAGme, name string) error {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetAPIVersion("monitoring.coreos.com/v1")
+	serviceMonitor.SetKind("ServiceMonitor")
+	serviceMonitor.SetName(name)
+	serviceMonitor.SetNamespace(ragme.Namespace)
+	if err := r.Delete(ctx, serviceMonitor); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return err
+	}
+	return nil
+}
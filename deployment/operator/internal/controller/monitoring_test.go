@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func annotationMonitoringRAGme(target string) *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Monitoring = ragmev1.RAGmeMonitoring{Mode: "annotations", AnnotationTarget: target}
+	ragme.Spec.AdminPort = ragmev1.RAGmeAdminPort{Enabled: true, ServiceMonitor: true}
+	ragme.Spec.Storage.MinIO.Metrics = ragmev1.RAGmeMinIOMetrics{Enabled: true, AuthType: "public", ServiceMonitor: true}
+	return ragme
+}
+
+func TestScrapeAnnotationsOnPods(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := annotationMonitoringRAGme("")
+
+	api := r.createRAGmeServiceDeployment(ragme, "api").Spec.Template
+	if api.Annotations["prometheus.io/scrape"] != "true" || api.Annotations["prometheus.io/port"] != "9090" ||
+		api.Annotations["prometheus.io/path"] != "/metrics" {
+		t.Errorf("api pod annotations = %v, want the admin port scraped", api.Annotations)
+	}
+	if annotations := createAdminService(ragme, "api").Annotations; len(annotations) != 0 {
+		t.Errorf("admin Service annotations = %v, want none when the pods are annotated", annotations)
+	}
+	if annotations := r.createMinIOService(ragme).Annotations; len(annotations) != 0 {
+		t.Errorf("MinIO Service annotations = %v, want none when the pods are annotated", annotations)
+	}
+
+	// ServiceMonitor mode leaves the services unannotated
+	ragme.Spec.Monitoring.Mode = ""
+	if annotations := r.createRAGmeServiceDeployment(ragme, "api").Spec.Template.Annotations; annotations["prometheus.io/scrape"] != "" {
+		t.Errorf("api pod annotations = %v, want none with ServiceMonitors", annotations)
+	}
+}
+
+func TestScrapeAnnotationsOnServices(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := annotationMonitoringRAGme("services")
+
+	if annotations := r.createRAGmeServiceDeployment(ragme, "api").Spec.Template.Annotations; annotations["prometheus.io/scrape"] != "" {
+		t.Errorf("api pod annotations = %v, want none when the Services are annotated", annotations)
+	}
+	if annotations := r.createMinIODeployment(ragme).Spec.Template.Annotations; annotations["prometheus.io/scrape"] != "" {
+		t.Errorf("MinIO pod annotations = %v, want none when the Services are annotated", annotations)
+	}
+	if annotations := createAdminService(ragme, "mcp").Annotations; annotations["prometheus.io/port"] != "9090" {
+		t.Errorf("admin Service annotations = %v, want the admin port scraped", annotations)
+	}
+	if annotations := r.createMinIOService(ragme).Annotations; annotations["prometheus.io/path"] != minioMetricsPath {
+		t.Errorf("MinIO Service annotations = %v, want the cluster metrics scraped", annotations)
+	}
+}
+
+func TestSyncScrapeAnnotations(t *testing.T) {
+	found := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		"prometheus.io/scrape": "true",
+		"prometheus.io/port":   "9000",
+		"example.com/owner":    "platform",
+	}}}
+	desired := &corev1.Service{}
+
+	if !syncScrapeAnnotations(found, desired) {
+		t.Fatal("syncScrapeAnnotations() = false, want the stale annotations removed")
+	}
+	if len(found.Annotations) != 1 || found.Annotations["example.com/owner"] != "platform" {
+		t.Errorf("annotations = %v, want only the foreign annotation kept", found.Annotations)
+	}
+	if syncScrapeAnnotations(found, desired) {
+		t.Error("syncScrapeAnnotations() = true on an unchanged Service")
+	}
+
+	desired.Annotations = scrapeAnnotations(9090, "/metrics")
+	if !syncScrapeAnnotations(found, desired) || len(found.Annotations) != 4 {
+		t.Errorf("annotations = %v, want the scrape annotations added", found.Annotations)
+	}
+}
+
+func TestValidateSpecMonitoring(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*ragmev1.RAGme)
+		wantErr bool
+	}{
+		{name: "valid", mutate: func(*ragmev1.RAGme) {}},
+		{name: "unknown mode", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.Mode = "podMonitor" }, wantErr: true},
+		{name: "unknown target", mutate: func(r *ragmev1.RAGme) { r.Spec.Monitoring.AnnotationTarget = "nodes" }, wantErr: true},
+		{name: "jwt MinIO metrics", mutate: func(r *ragmev1.RAGme) { r.Spec.Storage.MinIO.Metrics.AuthType = "jwt" }, wantErr: true},
+		{name: "jwt MinIO metrics with ServiceMonitors", mutate: func(r *ragmev1.RAGme) {
+			r.Spec.Monitoring.Mode = "serviceMonitor"
+			r.Spec.Storage.MinIO.Metrics.AuthType = "jwt"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := annotationMonitoringRAGme("")
+			tt.mutate(ragme)
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
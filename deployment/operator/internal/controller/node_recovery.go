@@ -0,0 +1,244 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=volumeattachments,verbs=get;list;watch;delete
+
+const (
+	defaultUnreachableAfter = 5 * time.Minute
+
+	// nodeRecoveryRequeue is the interval of the checks while a node is failing
+	nodeRecoveryRequeue = 30 * time.Second
+)
+
+// statefulComponents are the components holding ReadWriteOnce volumes
+var statefulComponents = map[string]bool{
+	"minio":          true,
+	"weaviate":       true,
+	"weaviate-shard": true,
+}
+
+// unreachableAfter returns how long a node must be not ready before it is considered lost
+func unreachableAfter(ragme *ragmev1.RAGme) time.Duration {
+	if d, err := time.ParseDuration(ragme.Spec.NodeRecovery.UnreachableAfter); err == nil && d > 0 {
+		return d
+	}
+	return defaultUnreachableAfter
+}
+
+// nodeNotReadySince returns when a node stopped being ready, and whether it
+// is not ready. A node that no longer exists has been gone forever.
+func nodeNotReadySince(node *corev1.Node) (time.Time, bool) {
+	if node == nil {
+		return time.Time{}, true
+	}
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			if condition.Status == corev1.ConditionTrue {
+				return time.Time{}, false
+			}
+			return condition.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// strandedWork is what a failed node holds of the instance
+type strandedWork struct {
+	since       time.Time
+	pods        []*corev1.Pod
+	attachments []*storagev1.VolumeAttachment
+}
+
+// describe lists the pods and volumes held by a node
+func (w *strandedWork) describe() string {
+	var names []string
+	for _, pod := range w.pods {
+		names = append(names, "pod "+pod.Name)
+	}
+	for _, attachment := range w.attachments {
+		names = append(names, "volume "+*attachment.Spec.Source.PersistentVolumeName)
+	}
+	return strings.Join(names, ", ")
+}
+
+// reconcileNodeRecovery finds the MinIO and Weaviate pods and volumes held by
+// a node that is not ready, and reports them in the NodeFailure condition.
+// Once the node has been lost for unreachableAfter and force is set, the pods
+// are force-deleted and the VolumeAttachments removed, so the replacement pods
+// can attach the volumes on another node.
+func (r *RAGmeReconciler) reconcileNodeRecovery(ctx context.Context, ragme *ragmev1.RAGme, now time.Time) error {
+	if !ragme.Spec.NodeRecovery.Enabled {
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeNodeFailure)
+		return nil
+	}
+
+	nodes := map[string]*strandedWork{}
+	failing := func(name string) (*strandedWork, error) {
+		if work, ok := nodes[name]; ok {
+			return work, nil
+		}
+		node := &corev1.Node{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, node); err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, err
+			}
+			node = nil
+		}
+		var work *strandedWork
+		if since, notReady := nodeNotReadySince(node); notReady {
+			work = &strandedWork{since: since}
+		}
+		nodes[name] = work
+		return work, nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": ragme.Name}); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !statefulComponents[pod.Labels["component"]] || pod.Spec.NodeName == "" {
+			continue
+		}
+		work, err := failing(pod.Spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if work != nil {
+			work.pods = append(work.pods, pod)
+		}
+	}
+
+	// The lost pods may be gone already while their volumes stay attached
+	volumes, err := r.statefulVolumes(ctx, ragme)
+	if err != nil {
+		return err
+	}
+	attachments := &storagev1.VolumeAttachmentList{}
+	if len(volumes) > 0 {
+		if err := r.List(ctx, attachments); err != nil {
+			return err
+		}
+	}
+	for i := range attachments.Items {
+		attachment := &attachments.Items[i]
+		if pv := attachment.Spec.Source.PersistentVolumeName; pv == nil || !volumes[*pv] {
+			continue
+		}
+		work, err := failing(attachment.Spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if work != nil {
+			work.attachments = append(work.attachments, attachment)
+		}
+	}
+
+	var waiting, lost []string
+	names := make([]string, 0, len(nodes))
+	for name, work := range nodes {
+		if work != nil && (len(work.pods) > 0 || len(work.attachments) > 0) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	grace := unreachableAfter(ragme)
+	for _, name := range names {
+		work := nodes[name]
+		if now.Before(work.since.Add(grace)) {
+			waiting = append(waiting, fmt.Sprintf("node %s holds %s", name, work.describe()))
+			continue
+		}
+		lost = append(lost, fmt.Sprintf("node %s holds %s", name, work.describe()))
+		if ragme.Spec.NodeRecovery.Force {
+			if err := r.releaseFailedNode(ctx, name, work); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch {
+	case len(lost) > 0 && ragme.Spec.NodeRecovery.Force:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeNodeFailure, conditions.ReasonPodsRescheduled,
+			"Released from unreachable nodes: "+strings.Join(lost, "; "))
+	case len(lost) > 0:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeNodeFailure, conditions.ReasonNodeUnreachable,
+			"Unreachable: "+strings.Join(lost, "; ")+". Set nodeRecovery.force to reschedule them")
+	case len(waiting) > 0:
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeNodeFailure, conditions.ReasonNodeNotReady,
+			fmt.Sprintf("Not ready: %s. Considered lost after %s", strings.Join(waiting, "; "), grace))
+	default:
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeNodeFailure, conditions.ReasonNodesReachable,
+			"MinIO and Weaviate run on ready nodes")
+	}
+	return nil
+}
+
+// statefulVolumes returns the names of the ReadWriteOnce volumes bound to the
+// PVCs of the instance
+func (r *RAGmeReconciler) statefulVolumes(ctx context.Context, ragme *ragmev1.RAGme) (map[string]bool, error) {
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs, client.InNamespace(ragme.Namespace)); err != nil {
+		return nil, err
+	}
+	volumes := map[string]bool{}
+	for i := range pvcs.Items {
+		pvc := &pvcs.Items[i]
+		// The volumes carry no labels, only the ownership of the instance
+		if len(withoutOwner(pvc.OwnerReferences, ragme)) == len(pvc.OwnerReferences) || pvc.Spec.VolumeName == "" {
+			continue
+		}
+		for _, mode := range pvc.Spec.AccessModes {
+			if mode == corev1.ReadWriteOnce || mode == corev1.ReadWriteOncePod {
+				volumes[pvc.Spec.VolumeName] = true
+			}
+		}
+	}
+	return volumes, nil
+}
+
+// releaseFailedNode detaches the volumes of the instance from a lost node and
+// force-deletes its pods there, which the kubelet can no longer confirm
+func (r *RAGmeReconciler) releaseFailedNode(ctx context.Context, node string, work *strandedWork) error {
+	logger := log.FromContext(ctx)
+	for _, attachment := range work.attachments {
+		if err := r.Delete(ctx, attachment); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Deleted the VolumeAttachment of an unreachable node", "node", node, "volumeAttachment", attachment.Name)
+	}
+	for _, pod := range work.pods {
+		if err := r.Delete(ctx, pod, client.GracePeriodSeconds(0)); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Info("Force-deleted a pod of an unreachable node", "node", node, "pod", pod.Name)
+	}
+	return nil
+}
+
+// nodeRecoveryResult shortens the requeue while a node is failing
+func nodeRecoveryResult(ragme *ragmev1.RAGme, result ctrl.Result) ctrl.Result {
+	if !conditions.IsTrue(ragme.Status.Conditions, conditions.TypeNodeFailure) {
+		return result
+	}
+	return requeueBefore(result, nodeRecoveryRequeue)
+}
@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestNodeNotReadySince(t *testing.T) {
+	lost := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	node := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionUnknown, LastTransitionTime: metav1.Time{Time: lost}},
+	}}}
+	if since, notReady := nodeNotReadySince(node); !notReady || !since.Equal(lost) {
+		t.Errorf("nodeNotReadySince() = %s, %v, want the transition of the Ready condition", since, notReady)
+	}
+
+	node.Status.Conditions[0].Status = corev1.ConditionTrue
+	if _, notReady := nodeNotReadySince(node); notReady {
+		t.Error("ready node reported as not ready")
+	}
+	if _, notReady := nodeNotReadySince(nil); !notReady {
+		t.Error("deleted node reported as ready")
+	}
+}
+
+func TestStrandedWorkDescribe(t *testing.T) {
+	volume := "pvc-1234"
+	work := &strandedWork{
+		pods:        []*corev1.Pod{{ObjectMeta: metav1.ObjectMeta{Name: "test-minio-5d8f"}}},
+		attachments: []*storagev1.VolumeAttachment{{Spec: storagev1.VolumeAttachmentSpec{Source: storagev1.VolumeAttachmentSource{PersistentVolumeName: &volume}}}},
+	}
+	if got := work.describe(); got != "pod test-minio-5d8f, volume pvc-1234" {
+		t.Errorf("describe() = %q", got)
+	}
+}
+
+func TestValidateSpecNodeRecovery(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.NodeRecovery = ragmev1.RAGmeNodeRecovery{Enabled: true, UnreachableAfter: "ten minutes"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an invalid unreachableAfter")
+	}
+	ragme.Spec.NodeRecovery.UnreachableAfter = "10m"
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+	if after := unreachableAfter(ragme); after != 10*time.Minute {
+		t.Errorf("unreachableAfter() = %s, want 10m", after)
+	}
+}
@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	noticeSeverityInfo     = "info"
+	noticeSeverityWarning  = "warning"
+	noticeSeverityCritical = "critical"
+
+	noticeSourceSpec        = "spec"
+	noticeSourceMaintenance = "maintenance"
+	noticeSourceUpgrade     = "upgrade"
+	noticeSourceRestore     = "restore"
+
+	noticesKey       = "notices.json"
+	noticesMountPath = "/app/config/notices"
+)
+
+// automaticNoticeMessages are shown to the end users while the operator
+// upgrades or restores the instance
+var automaticNoticeMessages = map[string]string{
+	noticeSourceUpgrade: "Search is being upgraded: answers may be slow or briefly unavailable.",
+	noticeSourceRestore: "Documents are being restored: some may be missing until the restore completes.",
+}
+
+// noticesConfigMapName returns the name of the ConfigMap holding the notices
+func noticesConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-notices", ragme.Name)
+}
+
+// activeNotices returns the notices shown at now: the spec notice within its
+// schedule, then the automatic notices of the operations in progress
+func activeNotices(ragme *ragmev1.RAGme, now time.Time) []ragmev1.RAGmeNoticeStatus {
+	var notices []ragmev1.RAGmeNoticeStatus
+	if notice := ragme.Spec.Frontend.Notice; notice != nil && noticeScheduled(notice.Schedule, now) {
+		severity := notice.Severity
+		if severity == "" {
+			severity = noticeSeverityInfo
+		}
+		notices = append(notices, ragmev1.RAGmeNoticeStatus{Source: noticeSourceSpec, Severity: severity, Message: notice.Message})
+	}
+	if !enabledOrDefault(ragme.Spec.Frontend.AutomaticNotices) {
+		return notices
+	}
+
+	if mode := ragme.Spec.MaintenanceMode; mode != "" {
+		message := ragme.Spec.MaintenanceMessage
+		if message == "" {
+			message = defaultMaintenanceMessages[mode]
+		}
+		severity := noticeSeverityWarning
+		if mode == maintenanceFull {
+			severity = noticeSeverityCritical
+		}
+		notices = append(notices, ragmev1.RAGmeNoticeStatus{Source: noticeSourceMaintenance, Severity: severity, Message: message})
+	}
+	if _, upgrading := weaviateUpgradeInProgress(ragme); upgrading {
+		notices = append(notices, ragmev1.RAGmeNoticeStatus{
+			Source: noticeSourceUpgrade, Severity: noticeSeverityWarning, Message: automaticNoticeMessages[noticeSourceUpgrade],
+		})
+	}
+	if _, restoring := restoreInProgress(ragme); restoring {
+		notices = append(notices, ragmev1.RAGmeNoticeStatus{
+			Source: noticeSourceRestore, Severity: noticeSeverityInfo, Message: automaticNoticeMessages[noticeSourceRestore],
+		})
+	}
+	return notices
+}
+
+// noticeScheduled reports whether now is within the schedule of a notice
+func noticeScheduled(schedule *ragmev1.RAGmeNoticeSchedule, now time.Time) bool {
+	if schedule == nil {
+		return true
+	}
+	if schedule.Start != nil && now.Before(schedule.Start.Time) {
+		return false
+	}
+	return schedule.End == nil || now.Before(schedule.End.Time)
+}
+
+// reconcileNotices records the active notices in status and renders them into
+// the ConfigMap mounted by the frontend, which reloads the file when the
+// kubelet refreshes the volume
+func (r *RAGmeReconciler) reconcileNotices(ctx context.Context, ragme *ragmev1.RAGme) error {
+	notices := activeNotices(ragme, r.now())
+	ragme.Status.Notices = notices
+
+	rendered := notices
+	if rendered == nil {
+		rendered = []ragmev1.RAGmeNoticeStatus{}
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"notices": rendered}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      noticesConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{noticesKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[noticesKey] != configMap.Data[noticesKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyNotices mounts the notices into the frontend pod
+func applyNotices(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "frontend" {
+		return
+	}
+	mountVolume(podSpec, corev1.Volume{
+		Name: "notices",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: noticesConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, noticesMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_NOTICES", Value: noticesMountPath + "/" + noticesKey,
+	})
+}
+
+// noticeResult shortens the requeue to the next start or end of the spec
+// notice so it is shown and removed on time
+func noticeResult(ragme *ragmev1.RAGme, result ctrl.Result, now time.Time) ctrl.Result {
+	notice := ragme.Spec.Frontend.Notice
+	if notice == nil || notice.Schedule == nil {
+		return result
+	}
+	for _, t := range []*metav1.Time{notice.Schedule.Start, notice.Schedule.End} {
+		if t != nil && t.After(now) {
+			return requeueBefore(result, t.Sub(now))
+		}
+	}
+	return result
+}
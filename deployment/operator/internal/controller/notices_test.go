@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestActiveNotices(t *testing.T) {
+	now := time.Date(2024, 6, 7, 12, 0, 0, 0, time.UTC)
+	ragme := &ragmev1.RAGme{}
+	if notices := activeNotices(ragme, now); len(notices) != 0 {
+		t.Errorf("activeNotices() = %+v, want none", notices)
+	}
+
+	ragme.Spec.Frontend.Notice = &ragmev1.RAGmeFrontendNotice{
+		Message:  "Downtime on Saturday",
+		Schedule: &ragmev1.RAGmeNoticeSchedule{Start: &metav1.Time{Time: now.Add(time.Hour)}},
+	}
+	if notices := activeNotices(ragme, now); len(notices) != 0 {
+		t.Errorf("activeNotices() = %+v, want none before the schedule", notices)
+	}
+	ragme.Spec.Frontend.Notice.Schedule.Start = &metav1.Time{Time: now.Add(-time.Hour)}
+	ragme.Spec.MaintenanceMode = maintenanceFull
+	ragme.Status.Weaviate.UpgradeTo = "1.25.0"
+	notices := activeNotices(ragme, now)
+	want := []ragmev1.RAGmeNoticeStatus{
+		{Source: noticeSourceSpec, Severity: noticeSeverityInfo, Message: "Downtime on Saturday"},
+		{Source: noticeSourceMaintenance, Severity: noticeSeverityCritical, Message: defaultMaintenanceMessages[maintenanceFull]},
+		{Source: noticeSourceUpgrade, Severity: noticeSeverityWarning, Message: automaticNoticeMessages[noticeSourceUpgrade]},
+	}
+	if len(notices) != len(want) {
+		t.Fatalf("activeNotices() = %+v, want %+v", notices, want)
+	}
+	for i := range want {
+		if notices[i] != want[i] {
+			t.Errorf("notices[%d] = %+v, want %+v", i, notices[i], want[i])
+		}
+	}
+
+	disabled := false
+	ragme.Spec.Frontend.AutomaticNotices = &disabled
+	if notices := activeNotices(ragme, now); len(notices) != 1 || notices[0].Source != noticeSourceSpec {
+		t.Errorf("activeNotices() = %+v, want only the spec notice", notices)
+	}
+}
+
+func TestNoticeResult(t *testing.T) {
+	now := time.Now()
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Frontend.Notice = &ragmev1.RAGmeFrontendNotice{
+		Message: "Downtime",
+		Schedule: &ragmev1.RAGmeNoticeSchedule{
+			Start: &metav1.Time{Time: now.Add(-time.Hour)},
+			End:   &metav1.Time{Time: now.Add(time.Minute)},
+		},
+	}
+	if result := noticeResult(ragme, ctrl.Result{RequeueAfter: 5 * time.Minute}, now); result.RequeueAfter != time.Minute {
+		t.Errorf("RequeueAfter = %v, want the end of the notice", result.RequeueAfter)
+	}
+}
+
+func TestValidateSpecNotice(t *testing.T) {
+	now := time.Now()
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Frontend.Notice = &ragmev1.RAGmeFrontendNotice{
+		Severity: "urgent",
+		Schedule: &ragmev1.RAGmeNoticeSchedule{Start: &metav1.Time{Time: now}, End: &metav1.Time{Time: now.Add(-time.Hour)}},
+	}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a notice without message, an unsupported severity and an inverted schedule")
+	}
+	ragme.Spec.Frontend.Notice = &ragmev1.RAGmeFrontendNotice{Message: "Downtime", Severity: noticeSeverityWarning}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// oauthProviders returns the OAuth provider configurations keyed by provider name
+func oauthProviders(ragme *ragmev1.RAGme) map[string]ragmev1.RAGmeOAuthProvider {
+	return map[string]ragmev1.RAGmeOAuthProvider{
+		"google": ragme.Spec.Authentication.OAuth.Google,
+		"github": ragme.Spec.Authentication.OAuth.GitHub,
+		"apple":  ragme.Spec.Authentication.OAuth.Apple,
+	}
+}
+
+// derivedRedirectURI returns the OAuth callback URL of the provider on the
+// public Ingress host, or "" when no Ingress host is configured. The /auth
+// prefix is served by the api on the public host.
+func derivedRedirectURI(ragme *ragmev1.RAGme, provider string) string {
+	ingress := ragme.Spec.ExternalAccess.Ingress
+	if ingress.Host == "" {
+		return ""
+	}
+	scheme := "http"
+	if ingress.TLSEnabled {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/auth/%s/callback", scheme, ingress.Host, provider)
+}
+
+// oauthRedirectURI returns the redirect URI injected for the provider. The
+// Ingress-derived URI wins over the spec value since it is the only one the
+// browser can reach.
+func oauthRedirectURI(ragme *ragmev1.RAGme, provider string) string {
+	if derived := derivedRedirectURI(ragme, provider); derived != "" {
+		return derived
+	}
+	return oauthProviders(ragme)[provider].RedirectURI
+}
+
+// checkOAuthRedirectURIs flags enabled providers whose spec redirect URI
+// conflicts with the one derived from the Ingress host
+func checkOAuthRedirectURIs(ragme *ragmev1.RAGme) {
+	var conflicts []string
+	for name, provider := range oauthProviders(ragme) {
+		derived := derivedRedirectURI(ragme, name)
+		if !provider.Enabled || derived == "" || provider.RedirectURI == "" {
+			continue
+		}
+		if strings.TrimSuffix(provider.RedirectURI, "/") != derived {
+			conflicts = append(conflicts, fmt.Sprintf("%s (spec %s, using %s)", name, provider.RedirectURI, derived))
+		}
+	}
+
+	if len(conflicts) == 0 {
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeOAuthRedirectURIConflict)
+		return
+	}
+	sort.Strings(conflicts)
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeOAuthRedirectURIConflict, conditions.ReasonRedirectURIOverridden,
+		"OAuth redirect URIs derived from the Ingress host override the spec: "+strings.Join(conflicts, ", "))
+}
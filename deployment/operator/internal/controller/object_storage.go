@@ -0,0 +1,556 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	objectStorageMinIO = "minio"
+	objectStorageS3    = "s3"
+	objectStorageGCS   = "gcs"
+	objectStorageAzure = "azure"
+
+	defaultObjectStorageBucket = "ragme-storage"
+	defaultObjectStorageRegion = "us-east-1"
+	gcsInteropEndpoint         = "https://storage.googleapis.com"
+	defaultS3GatewayImage      = "andrewgaul/s3proxy:latest"
+	s3GatewayPort              = 80
+)
+
+// errLifecycleUnsupported is returned by the providers whose buckets do not
+// accept S3 lifecycle rules, which are configured in the cloud console instead
+var errLifecycleUnsupported = errors.New("object expiration is not supported by this object storage")
+
+// objectStorageProvider encapsulates an object storage backend. The services,
+// the export Jobs and the bucket Jobs reach every backend through the S3 API
+// at Endpoint, with the keys returned by Credentials.
+type objectStorageProvider interface {
+	// Name identifies the provider in logs and errors
+	Name() string
+
+	// Endpoint returns the URL of the S3 API
+	Endpoint(ragme *ragmev1.RAGme) string
+
+	// Credentials returns the keys of a service: api, agent or backup
+	Credentials(ragme *ragmev1.RAGme, service string) objectStorageCredentials
+
+	// ProvisionBuckets creates the storage, its users and the document bucket
+	ProvisionBuckets(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// Lifecycle applies the expiration of the documents to the bucket
+	Lifecycle(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// Metrics observes the capacity of the storage
+	Metrics(ctx context.Context, ragme *ragmev1.RAGme) error
+}
+
+// objectStorageCredentials select the keys of a service in Secrets
+type objectStorageCredentials struct {
+	AccessKey *corev1.SecretKeySelector
+	SecretKey *corev1.SecretKeySelector
+}
+
+// secretCredentials returns the keys of a Secret holding accessKey and secretKey
+func secretCredentials(ref *corev1.LocalObjectReference) objectStorageCredentials {
+	if ref == nil {
+		return objectStorageCredentials{}
+	}
+	return objectStorageCredentials{
+		AccessKey: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageAccessKeyKey},
+		SecretKey: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageSecretKeyKey},
+	}
+}
+
+// env returns the variables holding the keys under the given names
+func (c objectStorageCredentials) env(accessKeyName, secretKeyName string) []corev1.EnvVar {
+	env := []corev1.EnvVar{}
+	for _, key := range []struct {
+		name     string
+		selector *corev1.SecretKeySelector
+	}{
+		{accessKeyName, c.AccessKey},
+		{secretKeyName, c.SecretKey},
+	} {
+		if key.selector == nil {
+			continue
+		}
+		env = append(env, corev1.EnvVar{
+			Name:      key.name,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: key.selector.DeepCopy()},
+		})
+	}
+	return env
+}
+
+// objectStorageProviderName returns the configured provider, minio by default
+func objectStorageProviderName(ragme *ragmev1.RAGme) string {
+	if provider := ragme.Spec.Storage.ObjectStorage.Provider; provider != "" {
+		return provider
+	}
+	return objectStorageMinIO
+}
+
+// objectStorageBucket returns the bucket holding the documents
+func objectStorageBucket(ragme *ragmev1.RAGme) string {
+	if bucket := ragme.Spec.Storage.ObjectStorage.Bucket; bucket != "" {
+		return bucket
+	}
+	return defaultObjectStorageBucket
+}
+
+// objectStorageRegion returns the region of the bucket
+func objectStorageRegion(ragme *ragmev1.RAGme) string {
+	if region := ragme.Spec.Storage.ObjectStorage.Region; region != "" {
+		return region
+	}
+	return defaultObjectStorageRegion
+}
+
+// newObjectStorageProvider returns the provider of the object storage
+// configured on the given RAGme instance. r may be nil when only the endpoint
+// and the credentials are read.
+func newObjectStorageProvider(r *RAGmeReconciler, ragme *ragmev1.RAGme) objectStorageProvider {
+	switch objectStorageProviderName(ragme) {
+	case objectStorageS3:
+		endpoint := ragme.Spec.Storage.ObjectStorage.Endpoint
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", objectStorageRegion(ragme))
+		}
+		return &s3ObjectStorage{r: r, name: objectStorageS3, endpoint: endpoint, lifecycle: true}
+	case objectStorageGCS:
+		// The XML API of Cloud Storage has its own lifecycle schema
+		return &s3ObjectStorage{r: r, name: objectStorageGCS, endpoint: gcsInteropEndpoint}
+	case objectStorageAzure:
+		return &azureObjectStorage{r: r}
+	default:
+		return &minioObjectStorage{r: r}
+	}
+}
+
+// objectStorageStep adapts a method of the object storage provider to a reconcile step
+func (r *RAGmeReconciler) objectStorageStep(run func(objectStorageProvider, context.Context, *ragmev1.RAGme) error) func(context.Context, *ragmev1.RAGme) error {
+	return func(ctx context.Context, ragme *ragmev1.RAGme) error {
+		return run(newObjectStorageProvider(r, ragme), ctx, ragme)
+	}
+}
+
+// minioObjectStorage is the MinIO of storage.minio, deployed in the namespace
+// when enabled
+type minioObjectStorage struct {
+	r *RAGmeReconciler
+}
+
+// Name returns the name of the provider
+func (p *minioObjectStorage) Name() string {
+	return objectStorageMinIO
+}
+
+// Endpoint returns the URL of the MinIO Service
+func (p *minioObjectStorage) Endpoint(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("http://%s-minio:9000", ragme.Name)
+}
+
+// Credentials returns the user of the service, or the root user when the
+// services share it
+func (p *minioObjectStorage) Credentials(ragme *ragmev1.RAGme, service string) objectStorageCredentials {
+	if ref := storageSecretRef(ragme, service); ref != nil {
+		return secretCredentials(ref)
+	}
+	return minioRootCredentials(ragme)
+}
+
+// ProvisionBuckets deploys MinIO and provisions the per-service users. The
+// services create the document bucket on startup.
+func (p *minioObjectStorage) ProvisionBuckets(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if err := p.r.deleteS3Gateway(ctx, ragme); err != nil {
+		return err
+	}
+	if err := p.r.reconcileMinIO(ctx, ragme); err != nil {
+		return fmt.Errorf("MinIO: %w", err)
+	}
+	if err := p.r.reconcileStorageCredentials(ctx, ragme); err != nil {
+		return fmt.Errorf("storage credentials: %w", err)
+	}
+	return nil
+}
+
+// Lifecycle imports the expiration rule into the bucket
+func (p *minioObjectStorage) Lifecycle(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.reconcileBucketLifecycle(ctx, ragme, p)
+}
+
+// Metrics raises the StorageAlmostFull condition from the MinIO capacity metrics
+func (p *minioObjectStorage) Metrics(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.checkMinIOCapacity(ctx, ragme)
+}
+
+// s3ObjectStorage is a cloud object storage speaking the S3 API: Amazon S3,
+// or Google Cloud Storage through its interoperability API
+type s3ObjectStorage struct {
+	r        *RAGmeReconciler
+	name     string
+	endpoint string
+	// lifecycle reports whether the bucket accepts S3 lifecycle rules
+	lifecycle bool
+}
+
+// Name returns the name of the provider
+func (p *s3ObjectStorage) Name() string {
+	return p.name
+}
+
+// Endpoint returns the URL of the S3 API
+func (p *s3ObjectStorage) Endpoint(*ragmev1.RAGme) string {
+	return p.endpoint
+}
+
+// Credentials returns the keys of objectStorage.credentialsSecretRef, shared by the services
+func (p *s3ObjectStorage) Credentials(ragme *ragmev1.RAGme, _ string) objectStorageCredentials {
+	return secretCredentials(ragme.Spec.Storage.ObjectStorage.CredentialsSecretRef)
+}
+
+// ProvisionBuckets creates the document bucket unless it exists
+func (p *s3ObjectStorage) ProvisionBuckets(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if err := p.r.deleteS3Gateway(ctx, ragme); err != nil {
+		return err
+	}
+	return p.r.reconcileBucket(ctx, ragme, p)
+}
+
+// Lifecycle imports the expiration rule into the bucket
+func (p *s3ObjectStorage) Lifecycle(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !p.lifecycle {
+		return unsupportedLifecycle(ragme)
+	}
+	return p.r.reconcileBucketLifecycle(ctx, ragme, p)
+}
+
+// Metrics clears the capacity condition, cloud buckets have no capacity limit
+func (p *s3ObjectStorage) Metrics(_ context.Context, ragme *ragmev1.RAGme) error {
+	conditions.Remove(&ragme.Status.Conditions, conditions.TypeStorageAlmostFull)
+	return nil
+}
+
+// azureObjectStorage is Azure Blob Storage, which has no S3 API: an S3Proxy
+// gateway run in the namespace translates the requests of the services
+type azureObjectStorage struct {
+	r *RAGmeReconciler
+}
+
+// Name returns the name of the provider
+func (p *azureObjectStorage) Name() string {
+	return objectStorageAzure
+}
+
+// Endpoint returns the URL of the S3 gateway
+func (p *azureObjectStorage) Endpoint(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("http://%s:%d", s3GatewayName(ragme), s3GatewayPort)
+}
+
+// Credentials returns the storage account name and key, which the gateway
+// also accepts as S3 keys
+func (p *azureObjectStorage) Credentials(ragme *ragmev1.RAGme, _ string) objectStorageCredentials {
+	return secretCredentials(ragme.Spec.Storage.ObjectStorage.CredentialsSecretRef)
+}
+
+// ProvisionBuckets deploys the S3 gateway and creates the document container
+func (p *azureObjectStorage) ProvisionBuckets(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if err := p.r.reconcileS3Gateway(ctx, ragme); err != nil {
+		return fmt.Errorf("S3 gateway: %w", err)
+	}
+	return p.r.reconcileBucket(ctx, ragme, p)
+}
+
+// Lifecycle is not supported, the gateway does not translate lifecycle rules
+func (p *azureObjectStorage) Lifecycle(_ context.Context, ragme *ragmev1.RAGme) error {
+	return unsupportedLifecycle(ragme)
+}
+
+// Metrics clears the capacity condition, storage accounts have no capacity limit
+func (p *azureObjectStorage) Metrics(_ context.Context, ragme *ragmev1.RAGme) error {
+	conditions.Remove(&ragme.Status.Conditions, conditions.TypeStorageAlmostFull)
+	return nil
+}
+
+// unsupportedLifecycle fails when an expiration is requested from a provider
+// without lifecycle rules
+func unsupportedLifecycle(ragme *ragmev1.RAGme) error {
+	if ragme.Spec.Storage.ObjectStorage.ExpirationDays > 0 {
+		return errLifecycleUnsupported
+	}
+	return nil
+}
+
+// bucketLifecycle renders the lifecycle configuration expiring the documents
+func bucketLifecycle(ragme *ragmev1.RAGme) (string, error) {
+	lifecycle, err := json.Marshal(map[string]interface{}{
+		"Rules": []map[string]interface{}{{
+			"ID":         "ragme-expiration",
+			"Status":     "Enabled",
+			"Filter":     map[string]string{"Prefix": ""},
+			"Expiration": map[string]int32{"Days": ragme.Spec.Storage.ObjectStorage.ExpirationDays},
+		}},
+	})
+	return string(lifecycle), err
+}
+
+// reconcileBucket runs the Job creating the document bucket
+func (r *RAGmeReconciler) reconcileBucket(ctx context.Context, ragme *ragmev1.RAGme, provider objectStorageProvider) error {
+	script := `mc mb --ignore-existing --region "${S3_REGION}" "storage/${BUCKET}"`
+	return r.reconcileBucketJob(ctx, ragme, createBucketJob(ragme, provider, "bucket", script, nil))
+}
+
+// reconcileBucketLifecycle runs the Job importing the expiration rule into the
+// bucket. Importing replaces the lifecycle configuration, so the Job can rerun.
+// Setting expirationDays back to 0 leaves the last rule in place.
+func (r *RAGmeReconciler) reconcileBucketLifecycle(ctx context.Context, ragme *ragmev1.RAGme, provider objectStorageProvider) error {
+	if ragme.Spec.Storage.ObjectStorage.ExpirationDays == 0 {
+		return nil
+	}
+	lifecycle, err := bucketLifecycle(ragme)
+	if err != nil {
+		return err
+	}
+	script := `echo "${LIFECYCLE}" | mc ilm import "storage/${BUCKET}"`
+	return r.reconcileBucketJob(ctx, ragme, createBucketJob(ragme, provider, "lifecycle", script,
+		[]corev1.EnvVar{{Name: "LIFECYCLE", Value: lifecycle}}))
+}
+
+// reconcileBucketJob creates the bucket Job unless it already ran
+func (r *RAGmeReconciler) reconcileBucketJob(ctx context.Context, ragme *ragmev1.RAGme, job *batchv1.Job) error {
+	if err := r.setOwner(ragme, job); err != nil {
+		return err
+	}
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+	if err != nil && apierrors.IsNotFound(err) {
+		return r.Create(ctx, job)
+	} else if err != nil {
+		return err
+	}
+	if jobFailed(found) {
+		return fmt.Errorf("configuring the bucket failed, see the logs of job %s", found.Name)
+	}
+	return nil
+}
+
+// createBucketJob returns a Job running an mc script against the bucket of
+// the provider. The Job is named after the hash of its inputs, so it only
+// reruns when they change.
+func createBucketJob(ragme *ragmev1.RAGme, provider objectStorageProvider, action, script string, env []corev1.EnvVar) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "object-storage",
+		"instance":  ragme.Name,
+	}
+
+	env = append([]corev1.EnvVar{
+		{Name: "S3_ENDPOINT", Value: provider.Endpoint(ragme)},
+		{Name: "S3_REGION", Value: objectStorageRegion(ragme)},
+		{Name: "BUCKET", Value: objectStorageBucket(ragme)},
+	}, env...)
+	credentials := provider.Credentials(ragme, "api")
+	env = append(env, credentials.env("S3_ACCESS_KEY", "S3_SECRET_KEY")...)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", script)
+	for _, e := range env {
+		fmt.Fprintf(hash, "%s=%s\n", e.Name, e.Value)
+	}
+	for _, selector := range []*corev1.SecretKeySelector{credentials.AccessKey, credentials.SecretKey} {
+		if selector != nil {
+			fmt.Fprintf(hash, "secret=%s/%s\n", selector.Name, selector.Key)
+		}
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s-%s", ragme.Name, action, hex.EncodeToString(hash.Sum(nil))[:8]),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{6}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name:  "mc",
+							Image: "minio/mc:latest",
+							Command: []string{"/bin/sh", "-c",
+								`set -e
+mc alias set storage "${S3_ENDPOINT}" "${S3_ACCESS_KEY}" "${S3_SECRET_KEY}"
+` + script},
+							Env: env,
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
+
+// s3GatewayName returns the name of the S3 gateway resources
+func s3GatewayName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-s3-gateway", ragme.Name)
+}
+
+// deleteS3Gateway removes the gateway once the provider is no longer azure
+func (r *RAGmeReconciler) deleteS3Gateway(ctx context.Context, ragme *ragmev1.RAGme) error {
+	meta := metav1.ObjectMeta{Name: s3GatewayName(ragme), Namespace: ragme.Namespace}
+	for _, obj := range []client.Object{&appsv1.Deployment{ObjectMeta: meta}, &corev1.Service{ObjectMeta: meta}} {
+		if err := r.Delete(ctx, obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileS3Gateway runs the S3Proxy gateway in front of Azure Blob Storage
+func (r *RAGmeReconciler) reconcileS3Gateway(ctx context.Context, ragme *ragmev1.RAGme) error {
+	deployment := createS3GatewayDeployment(ragme)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && apierrors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	service := createS3GatewayService(ragme)
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && apierrors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// createS3GatewayDeployment returns the Deployment of the S3 gateway. The
+// gateway accepts the storage account name and key as S3 keys.
+func createS3GatewayDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "s3-gateway",
+		"instance":  ragme.Name,
+	}
+
+	image := ragme.Spec.Storage.ObjectStorage.GatewayImage
+	if image == "" {
+		image = defaultS3GatewayImage
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "S3PROXY_ENDPOINT", Value: fmt.Sprintf("http://0.0.0.0:%d", s3GatewayPort)},
+		{Name: "S3PROXY_AUTHORIZATION", Value: "aws-v2-or-v4"},
+		{Name: "JCLOUDS_PROVIDER", Value: "azureblob"},
+	}
+	credentials := secretCredentials(ragme.Spec.Storage.ObjectStorage.CredentialsSecretRef)
+	env = append(env, credentials.env("S3PROXY_IDENTITY", "S3PROXY_CREDENTIAL")...)
+	env = append(env, credentials.env("JCLOUDS_IDENTITY", "JCLOUDS_CREDENTIAL")...)
+	env = append(env, corev1.EnvVar{Name: "JCLOUDS_ENDPOINT", Value: "https://$(JCLOUDS_IDENTITY).blob.core.windows.net"})
+
+	replicas := hibernationReplicas(ragme, 1)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s3GatewayName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "s3proxy",
+							Image: image,
+							Env:   env,
+							Ports: []corev1.ContainerPort{{Name: "s3", ContainerPort: s3GatewayPort}},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(s3GatewayPort)},
+								},
+								PeriodSeconds: 10,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+// createS3GatewayService returns the Service of the S3 gateway
+func createS3GatewayService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "s3-gateway",
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      s3GatewayName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "s3", Port: s3GatewayPort, TargetPort: intstr.FromInt(s3GatewayPort)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
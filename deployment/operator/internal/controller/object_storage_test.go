@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func cloudStorageRAGme(provider string) *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Storage.ObjectStorage = ragmev1.RAGmeObjectStorage{
+		Provider:             provider,
+		Region:               "eu-west-1",
+		Bucket:               "docs",
+		CredentialsSecretRef: &corev1.LocalObjectReference{Name: "storage"},
+	}
+	return ragme
+}
+
+func TestObjectStorageEndpoints(t *testing.T) {
+	for provider, want := range map[string]string{
+		objectStorageMinIO: "http://test-minio:9000",
+		objectStorageS3:    "https://s3.eu-west-1.amazonaws.com",
+		objectStorageGCS:   gcsInteropEndpoint,
+		objectStorageAzure: "http://test-s3-gateway:80",
+	} {
+		storage := newObjectStorageProvider(nil, cloudStorageRAGme(provider))
+		if storage.Name() != provider || storage.Endpoint(cloudStorageRAGme(provider)) != want {
+			t.Errorf("%s: Endpoint() = %s, want %s", provider, storage.Endpoint(cloudStorageRAGme(provider)), want)
+		}
+	}
+}
+
+func TestApplyStorageCredentialsCloud(t *testing.T) {
+	template := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}}
+	applyStorageCredentials(cloudStorageRAGme(objectStorageGCS), "api", template)
+
+	env := template.Spec.Containers[0].Env
+	for name, want := range map[string]string{
+		"STORAGE_TYPE":   "s3",
+		"S3_ENDPOINT":    gcsInteropEndpoint,
+		"S3_BUCKET_NAME": "docs",
+		"S3_REGION":      "eu-west-1",
+	} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, e := range env {
+		if e.Name == "S3_SECRET_KEY" && (e.ValueFrom == nil || e.ValueFrom.SecretKeyRef.Name != "storage") {
+			t.Errorf("S3_SECRET_KEY = %+v, want the credentials Secret", e)
+		}
+	}
+}
+
+func TestBucketLifecycleJob(t *testing.T) {
+	ragme := cloudStorageRAGme(objectStorageS3)
+	ragme.Spec.Storage.ObjectStorage.ExpirationDays = 30
+
+	lifecycle, err := bucketLifecycle(ragme)
+	if err != nil {
+		t.Fatalf("bucketLifecycle() error = %v", err)
+	}
+	rules := struct {
+		Rules []struct {
+			Expiration struct {
+				Days int32
+			}
+		}
+	}{}
+	if err := json.Unmarshal([]byte(lifecycle), &rules); err != nil || len(rules.Rules) != 1 || rules.Rules[0].Expiration.Days != 30 {
+		t.Errorf("lifecycle = %s, want one rule expiring after 30 days", lifecycle)
+	}
+
+	storage := newObjectStorageProvider(nil, ragme)
+	job := createBucketJob(ragme, storage, "lifecycle", `mc ilm import "storage/${BUCKET}"`, nil)
+	if !strings.HasPrefix(job.Name, "test-lifecycle-") {
+		t.Errorf("Job name = %s, want the lifecycle Job", job.Name)
+	}
+	if again := createBucketJob(ragme, storage, "lifecycle", `mc ilm import "storage/${BUCKET}"`, nil); again.Name != job.Name {
+		t.Errorf("Job name = %s, want the stable %s", again.Name, job.Name)
+	}
+	env := job.Spec.Template.Spec.Containers[0].Env
+	if envValue(env, "S3_ENDPOINT") != "https://s3.eu-west-1.amazonaws.com" || envValue(env, "BUCKET") != "docs" {
+		t.Errorf("env = %+v, want the endpoint and bucket of the provider", env)
+	}
+
+	if err := newObjectStorageProvider(nil, cloudStorageRAGme(objectStorageAzure)).Lifecycle(context.Background(), ragme); !errors.Is(err, errLifecycleUnsupported) {
+		t.Errorf("azure Lifecycle() error = %v, want %v", err, errLifecycleUnsupported)
+	}
+}
+
+func TestAzureObjectStorageProvisionsGateway(t *testing.T) {
+	ragme := harnessRAGme("azure")
+	ragme.Spec.Storage.MinIO.Enabled = false
+	ragme.Spec.Storage.ObjectStorage = ragmev1.RAGmeObjectStorage{
+		Provider:             objectStorageAzure,
+		CredentialsSecretRef: &corev1.LocalObjectReference{Name: "azure-storage"},
+	}
+	h := newReconcilerHarness(t, ragme)
+	h.reconcile(t, "azure")
+
+	gateway := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "azure-s3-gateway"}, gateway); err != nil {
+		t.Fatalf("S3 gateway not created: %v", err)
+	}
+	if env := gateway.Spec.Template.Spec.Containers[0].Env; envValue(env, "JCLOUDS_PROVIDER") != "azureblob" {
+		t.Errorf("env = %+v, want the azureblob backend", env)
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "azure-minio"}, &appsv1.Deployment{}); err == nil {
+		t.Error("MinIO was deployed with the azure object storage")
+	}
+
+	jobs := &batchv1.JobList{}
+	if err := h.client.List(h.ctx, jobs, client.InNamespace("bench"), client.MatchingLabels{"component": "object-storage"}); err != nil || len(jobs.Items) != 1 {
+		t.Fatalf("bucket Jobs = %d, %v, want one", len(jobs.Items), err)
+	}
+	if env := jobs.Items[0].Spec.Template.Spec.Containers[0].Env; envValue(env, "S3_ENDPOINT") != "http://azure-s3-gateway:80" {
+		t.Errorf("bucket Job env = %+v, want the gateway endpoint", env)
+	}
+}
+
+func TestValidateSpecObjectStorage(t *testing.T) {
+	ragme := cloudStorageRAGme(objectStorageS3)
+	ragme.Spec.Storage.ObjectStorage.ExpirationDays = 7
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+
+	ragme.Spec.Storage.MinIO.Enabled = true
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted MinIO alongside the s3 object storage")
+	}
+
+	ragme = cloudStorageRAGme(objectStorageGCS)
+	ragme.Spec.Storage.ObjectStorage.ExpirationDays = 7
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an expiration on gcs")
+	}
+
+	ragme = cloudStorageRAGme(objectStorageAzure)
+	ragme.Spec.Storage.ObjectStorage.CredentialsSecretRef = nil
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted azure without credentials")
+	}
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	ocrSourceDownload = "download"
+	ocrSourceImage    = "image"
+
+	defaultOCRLanguage    = "eng"
+	defaultOCRDataURL     = "https://github.com/tesseract-ocr/tessdata_fast/raw/main"
+	defaultOCRDataPath    = "/usr/share/tesseract-ocr/5/tessdata"
+	ocrDownloadImage      = "curlimages/curl:8.8.0"
+	ocrLanguageDataMount  = "/app/ocr/tessdata"
+	ocrLanguageDataVolume = "ocr-languages"
+)
+
+var (
+	// ocrLanguagePattern matches Tesseract language codes such as eng or chi_sim
+	ocrLanguagePattern = regexp.MustCompile(`^[a-z]{3}(_[a-z]+)?$`)
+	// localePattern matches BCP 47 tags such as de or de-CH
+	localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+)
+
+// ocrDownloadScript fetches the language packs into the shared volume, so
+// the services do not need network access to the pack repository themselves
+const ocrDownloadScript = `set -e
+for lang in $OCR_LANGUAGES; do
+  curl -fsSL -o "/tessdata/$lang.traineddata.tmp" "$OCR_DATA_URL/$lang.traineddata"
+  mv "/tessdata/$lang.traineddata.tmp" "/tessdata/$lang.traineddata"
+done`
+
+// ocrCopyScript copies the language packs out of an image bundling them
+const ocrCopyScript = `set -e
+for lang in $OCR_LANGUAGES; do
+  cp "$OCR_DATA_PATH/$lang.traineddata" /tessdata/
+done`
+
+// ocrConfig is the OCR stage of the processing pipeline
+type ocrConfig struct {
+	Languages   []string `json:"languages"`
+	Locale      string   `json:"locale,omitempty"`
+	DateOrder   string   `json:"dateOrder,omitempty"`
+	TessdataDir string   `json:"tessdataDir"`
+}
+
+// ocrLanguages returns the language packs of the OCR stage
+func ocrLanguages(ragme *ragmev1.RAGme) []string {
+	if len(ragme.Spec.Processing.OCR.Languages) > 0 {
+		return ragme.Spec.Processing.OCR.Languages
+	}
+	return []string{defaultOCRLanguage}
+}
+
+// renderOCRConfig returns the OCR stage of the processing configuration, or
+// nil when OCR is disabled
+func renderOCRConfig(ragme *ragmev1.RAGme) *ocrConfig {
+	ocr := ragme.Spec.Processing.OCR
+	if !ocr.Enabled {
+		return nil
+	}
+	return &ocrConfig{
+		Languages:   ocrLanguages(ragme),
+		Locale:      ocr.Locale,
+		DateOrder:   ocr.DateOrder,
+		TessdataDir: ocrLanguageDataMount,
+	}
+}
+
+// applyOCR provides the language packs to a processing service through an
+// emptyDir filled by an init container, downloading them or copying them out
+// of an image bundling them
+func applyOCR(ragme *ragmev1.RAGme, podSpec *corev1.PodSpec) {
+	ocr := ragme.Spec.Processing.OCR
+	if !ocr.Enabled {
+		return
+	}
+
+	env := []corev1.EnvVar{{Name: "OCR_LANGUAGES", Value: strings.Join(ocrLanguages(ragme), " ")}}
+	initContainer := corev1.Container{
+		Name:         "ocr-languages",
+		VolumeMounts: []corev1.VolumeMount{{Name: ocrLanguageDataVolume, MountPath: "/tessdata"}},
+	}
+	if ocr.LanguageData.Source == ocrSourceImage {
+		path := ocr.LanguageData.Path
+		if path == "" {
+			path = defaultOCRDataPath
+		}
+		initContainer.Image = ocr.LanguageData.Image
+		initContainer.Command = []string{"sh", "-c", ocrCopyScript}
+		env = append(env, corev1.EnvVar{Name: "OCR_DATA_PATH", Value: path})
+	} else {
+		url := ocr.LanguageData.URL
+		if url == "" {
+			url = defaultOCRDataURL
+		}
+		initContainer.Image = ocrDownloadImage
+		initContainer.Command = []string{"sh", "-c", ocrDownloadScript}
+		env = append(env, corev1.EnvVar{Name: "OCR_DATA_URL", Value: strings.TrimSuffix(url, "/")})
+	}
+	initContainer.Env = env
+	podSpec.InitContainers = append(podSpec.InitContainers, initContainer)
+
+	mountVolume(podSpec, corev1.Volume{
+		Name:         ocrLanguageDataVolume,
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}, ocrLanguageDataMount)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env,
+		corev1.EnvVar{Name: "TESSDATA_PREFIX", Value: ocrLanguageDataMount},
+		corev1.EnvVar{Name: "RAGME_OCR_LANGUAGES", Value: strings.Join(ocrLanguages(ragme), "+")},
+	)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyOCR(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	applyOCR(ragme, podSpec)
+	if len(podSpec.InitContainers) != 0 || len(podSpec.Volumes) != 0 {
+		t.Errorf("pod = %+v, want it untouched without OCR", podSpec)
+	}
+
+	ragme.Spec.Processing.OCR = ragmev1.RAGmeOCR{Enabled: true, Languages: []string{"eng", "deu"}}
+	applyOCR(ragme, podSpec)
+	if len(podSpec.InitContainers) != 1 || podSpec.InitContainers[0].Image != ocrDownloadImage {
+		t.Fatalf("init containers = %+v, want the download", podSpec.InitContainers)
+	}
+	download := podSpec.InitContainers[0]
+	if envValue(download.Env, "OCR_LANGUAGES") != "eng deu" || envValue(download.Env, "OCR_DATA_URL") != defaultOCRDataURL {
+		t.Errorf("download env = %+v, want the languages and the default URL", download.Env)
+	}
+	env := podSpec.Containers[0].Env
+	if envValue(env, "TESSDATA_PREFIX") != ocrLanguageDataMount || envValue(env, "RAGME_OCR_LANGUAGES") != "eng+deu" {
+		t.Errorf("agent env = %+v, want the language data and languages", env)
+	}
+
+	ragme.Spec.Processing.OCR.LanguageData = ragmev1.RAGmeOCRLanguageData{Source: "image", Image: "registry.example.com/tessdata:5"}
+	podSpec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "agent"}}}
+	applyOCR(ragme, podSpec)
+	if copier := podSpec.InitContainers[0]; copier.Image != "registry.example.com/tessdata:5" || envValue(copier.Env, "OCR_DATA_PATH") != defaultOCRDataPath {
+		t.Errorf("copy init container = %+v, want the bundled image", copier)
+	}
+}
+
+func TestRenderOCRConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.OCR = ragmev1.RAGmeOCR{Enabled: true, Locale: "de-CH", DateOrder: "DMY"}
+	config := renderProcessingConfig(ragme).OCR
+	if config == nil || config.Languages[0] != defaultOCRLanguage || config.Locale != "de-CH" || config.TessdataDir != ocrLanguageDataMount {
+		t.Errorf("ocr = %+v, want the default language and the locale", config)
+	}
+}
+
+func TestValidateSpecOCR(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.OCR = ragmev1.RAGmeOCR{
+		Enabled:      true,
+		Languages:    []string{"English"},
+		Locale:       "de_CH",
+		DateOrder:    "DDMM",
+		LanguageData: ragmev1.RAGmeOCRLanguageData{Source: "image"},
+	}
+	err := validateSpec(ragme)
+	if err == nil {
+		t.Fatal("validateSpec() accepted an invalid OCR configuration")
+	}
+	for _, field := range []string{"languages", "locale", "dateOrder", "languageData.image"} {
+		if !strings.Contains(err.Error(), "processing.ocr."+field) {
+			t.Errorf("validateSpec() error = %v, want %s reported", err, field)
+		}
+	}
+	ragme.Spec.Processing.OCR = ragmev1.RAGmeOCR{Enabled: true, Languages: []string{"eng", "chi_sim"}, Locale: "zh-Hans-CN"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,195 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/yaml"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// OperatorConfigKey is the key of the operator configuration in its ConfigMap
+const OperatorConfigKey = "config.yaml"
+
+// Features an operator configuration can forbid
+const (
+	featureAnonymousAccess   = "AnonymousAccess"
+	featureLoadBalancer      = "LoadBalancer"
+	featureIngressWithoutTLS = "IngressWithoutTLS"
+)
+
+// mandatoryLabelFields maps the labels an operator configuration can make
+// mandatory to the spec.metadata fields setting them
+var mandatoryLabelFields = map[string]string{
+	costCenterLabel:  "metadata.costCenter",
+	teamLabel:        "metadata.team",
+	environmentLabel: "metadata.environment",
+}
+
+// OperatorConfig holds the organization-wide settings of the operator. The
+// defaults are merged beneath the spec of every instance, which can still
+// override them, while the policies are enforced by the reconciler and the
+// validating webhook.
+type OperatorConfig struct {
+	// RegistryMirror is the default of spec.images.registry
+	RegistryMirror string `json:"registryMirror,omitempty"`
+
+	// StorageClass of the data volumes, used instead of the cluster default
+	StorageClass string `json:"storageClass,omitempty"`
+
+	// SharedVolumeStorageClass is the default of spec.storage.sharedVolume.storageClass
+	SharedVolumeStorageClass string `json:"sharedVolumeStorageClass,omitempty"`
+
+	// MandatoryLabels are the ownership labels every instance must set
+	// through spec.metadata, e.g. ragme.io/cost-center
+	MandatoryLabels []string `json:"mandatoryLabels,omitempty"`
+
+	// ForbiddenFeatures are rejected: AnonymousAccess, LoadBalancer or IngressWithoutTLS
+	ForbiddenFeatures []string `json:"forbiddenFeatures,omitempty"`
+
+	// Policy configures the deny-list of insecure configurations
+	Policy OperatorPolicy `json:"policy,omitempty"`
+}
+
+// OperatorPolicy configures the deny-list of insecure configurations
+type OperatorPolicy struct {
+	// Mode is Warn (default), Enforce or Disabled
+	Mode string `json:"mode,omitempty"`
+
+	// DisabledRules are not evaluated
+	DisabledRules []string `json:"disabledRules,omitempty"`
+}
+
+// ParseOperatorConfig parses and checks an operator configuration document
+func ParseOperatorConfig(data []byte) (*OperatorConfig, error) {
+	config := &OperatorConfig{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, label := range config.MandatoryLabels {
+		if _, ok := mandatoryLabelFields[label]; !ok {
+			errs = append(errs, fmt.Errorf("mandatoryLabels: unsupported label %q, use %s, %s or %s", label, costCenterLabel, teamLabel, environmentLabel))
+		}
+	}
+	for _, feature := range config.ForbiddenFeatures {
+		switch feature {
+		case featureAnonymousAccess, featureLoadBalancer, featureIngressWithoutTLS:
+		default:
+			errs = append(errs, fmt.Errorf("forbiddenFeatures: unsupported feature %q, use %s, %s or %s",
+				feature, featureAnonymousAccess, featureLoadBalancer, featureIngressWithoutTLS))
+		}
+	}
+	switch config.Policy.Mode {
+	case "", policyModeWarn, policyModeEnforce, policyModeDisabled:
+	default:
+		errs = append(errs, fmt.Errorf("policy.mode: unsupported mode %q, use %s, %s or %s",
+			config.Policy.Mode, policyModeWarn, policyModeEnforce, policyModeDisabled))
+	}
+	for _, name := range config.Policy.DisabledRules {
+		known := false
+		for _, rule := range policyRules {
+			known = known || rule.name == name
+		}
+		if !known {
+			errs = append(errs, fmt.Errorf("policy.disabledRules: unknown rule %q", name))
+		}
+	}
+	if err := utilerrors.NewAggregate(errs); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyDefaults fills the fields of the spec left empty with the configured defaults
+func (c *OperatorConfig) applyDefaults(ragme *ragmev1.RAGme) {
+	if ragme.Spec.Images.Registry == "" {
+		ragme.Spec.Images.Registry = c.RegistryMirror
+	}
+	if ragme.Spec.Storage.SharedVolume.StorageClass == "" {
+		ragme.Spec.Storage.SharedVolume.StorageClass = c.SharedVolumeStorageClass
+	}
+}
+
+// validate checks an instance against the policies of the configuration
+func (c *OperatorConfig) validate(ragme *ragmev1.RAGme) error {
+	var errs []error
+
+	labels := ownershipLabels(ragme)
+	for _, label := range c.MandatoryLabels {
+		if labels[label] == "" {
+			errs = append(errs, fmt.Errorf("%s: the %s label is mandatory in this cluster", mandatoryLabelFields[label], label))
+		}
+	}
+
+	ingress := ragme.Spec.ExternalAccess.Ingress
+	for _, feature := range c.ForbiddenFeatures {
+		switch {
+		case feature == featureAnonymousAccess && ragme.Spec.Authentication.Anonymous.Enabled:
+			errs = append(errs, fmt.Errorf("authentication.anonymous.enabled: anonymous access is forbidden in this cluster"))
+		case feature == featureLoadBalancer && ragme.Spec.ExternalAccess.Type == "LoadBalancer":
+			errs = append(errs, fmt.Errorf("externalAccess.type: LoadBalancer Services are forbidden in this cluster"))
+		case feature == featureIngressWithoutTLS && ingress.Enabled && !ingress.TLSEnabled:
+			errs = append(errs, fmt.Errorf("externalAccess.ingress.tlsEnabled: Ingresses without TLS are forbidden in this cluster"))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// OperatorConfigSource reads the operator configuration from a ConfigMap on
+// each use, so edits apply to the next reconciliation without a restart. A
+// nil source or a missing ConfigMap is an empty configuration.
+type OperatorConfigSource struct {
+	// Reader gets the ConfigMap
+	Reader client.Reader
+
+	// ConfigMap holding the configuration in its config.yaml key
+	ConfigMap types.NamespacedName
+}
+
+// Load returns the current operator configuration
+func (s *OperatorConfigSource) Load(ctx context.Context) (*OperatorConfig, error) {
+	if s == nil {
+		return &OperatorConfig{}, nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := s.Reader.Get(ctx, s.ConfigMap, configMap); errors.IsNotFound(err) {
+		return &OperatorConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	config, err := ParseOperatorConfig([]byte(configMap.Data[OperatorConfigKey]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid operator configuration %s: %w", s.ConfigMap, err)
+	}
+	return config, nil
+}
+
+// instancesForOperatorConfig maps a change of the operator configuration to
+// every instance, so new defaults and policies apply right away
+func (r *RAGmeReconciler) instancesForOperatorConfig(ctx context.Context, obj client.Object) []reconcile.Request {
+	if r.Config == nil || obj.GetName() != r.Config.ConfigMap.Name || obj.GetNamespace() != r.Config.ConfigMap.Namespace {
+		return nil
+	}
+	instances := &ragmev1.RAGmeList{}
+	if err := r.List(ctx, instances); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list the instances to apply the operator configuration to")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(instances.Items))
+	for _, ragme := range instances.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ragme.Name, Namespace: ragme.Namespace},
+		})
+	}
+	return requests
+}
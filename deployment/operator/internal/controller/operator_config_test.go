@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const testOperatorConfig = `
+registryMirror: mirror.example.com/ragme
+storageClass: fast-ssd
+sharedVolumeStorageClass: nfs
+mandatoryLabels: ["ragme.io/cost-center"]
+forbiddenFeatures: ["AnonymousAccess"]
+`
+
+// withOperatorConfig makes the harness reconciler read the given configuration
+func withOperatorConfig(t *testing.T, h *reconcilerHarness, data string) *OperatorConfigSource {
+	t.Helper()
+	key := types.NamespacedName{Namespace: "ragme-system", Name: "ragme-operator-config"}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Data:       map[string]string{OperatorConfigKey: data},
+	}
+	if err := h.client.Create(h.ctx, configMap); err != nil {
+		t.Fatal(err)
+	}
+	h.reconciler.Config = &OperatorConfigSource{Reader: h.client, ConfigMap: key}
+	return h.reconciler.Config
+}
+
+func TestOperatorConfigDefaults(t *testing.T) {
+	ragme := harnessRAGme("org")
+	ragme.Spec.Images.Registry = ""
+	ragme.Spec.Metadata.CostCenter = "cc-42"
+	h := newReconcilerHarness(t, ragme)
+	withOperatorConfig(t, h, testOperatorConfig)
+	h.reconcile(t, "org")
+
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "org-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	if image := api.Spec.Template.Spec.Containers[0].Image; !strings.HasPrefix(image, "mirror.example.com/ragme/ragme-api:") {
+		t.Errorf("image = %s, want the registry mirror", image)
+	}
+	classes := h.get(t, "org").Status.Classes
+	if classes.StorageClass != "fast-ssd" || classes.SharedVolumeStorageClass != "nfs" {
+		t.Errorf("classes = %+v, want the configured storage classes", classes)
+	}
+}
+
+func TestOperatorConfigSpecOverridesDefaults(t *testing.T) {
+	ragme := harnessRAGme("org")
+	ragme.Spec.Images.Registry = "registry.team.example.com"
+	ragme.Spec.Metadata.CostCenter = "cc-42"
+	h := newReconcilerHarness(t, ragme)
+	withOperatorConfig(t, h, testOperatorConfig)
+	h.reconcile(t, "org")
+
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "org-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	if image := api.Spec.Template.Spec.Containers[0].Image; !strings.HasPrefix(image, "registry.team.example.com/") {
+		t.Errorf("image = %s, want the registry of the spec", image)
+	}
+}
+
+func TestOperatorConfigPolicies(t *testing.T) {
+	ragme := harnessRAGme("org")
+	ragme.Spec.Authentication.Anonymous.Enabled = true
+	h := newReconcilerHarness(t, ragme)
+	config := withOperatorConfig(t, h, testOperatorConfig)
+	h.reconcile(t, "org")
+
+	got := h.get(t, "org")
+	condition := meta.FindStatusCondition(got.Status.Conditions, conditions.TypeSpecValid)
+	if condition == nil || condition.Status != metav1.ConditionFalse {
+		t.Fatalf("SpecValid = %+v, want False", condition)
+	}
+	for _, want := range []string{"metadata.costCenter", "authentication.anonymous.enabled"} {
+		if !strings.Contains(condition.Message, want) {
+			t.Errorf("SpecValid message %q does not report %s", condition.Message, want)
+		}
+	}
+
+	if _, err := (&RAGmeValidator{Config: config}).ValidateCreate(h.ctx, ragme); err == nil {
+		t.Error("webhook admitted an instance violating the cluster policies")
+	}
+	if _, err := (&RAGmeValidator{}).ValidateCreate(h.ctx, ragme); err != nil {
+		t.Errorf("webhook without configuration rejected the instance: %v", err)
+	}
+}
+
+func TestParseOperatorConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"empty", "", true},
+		{"full", testOperatorConfig, true},
+		{"unknown field", "registry: mirror.example.com", false},
+		{"unknown feature", `forbiddenFeatures: ["Telemetry"]`, false},
+		{"unknown label", `mandatoryLabels: ["owner"]`, false},
+	} {
+		if _, err := ParseOperatorConfig([]byte(tc.data)); (err == nil) != tc.valid {
+			t.Errorf("%s: ParseOperatorConfig() error = %v, want valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
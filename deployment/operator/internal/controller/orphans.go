@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// DefaultOrphanScanInterval is the interval between two sweeps of the orphan janitor
+const DefaultOrphanScanInterval = time.Hour
+
+// orphanKinds are the kinds the reconciler generates for an instance
+var orphanKinds = []schema.GroupVersionKind{
+	{Group: "apps", Version: "v1", Kind: "Deployment"},
+	{Version: "v1", Kind: "Service"},
+	{Version: "v1", Kind: "ConfigMap"},
+	{Version: "v1", Kind: "Secret"},
+	{Version: "v1", Kind: "PersistentVolumeClaim"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+	{Group: "batch", Version: "v1", Kind: "CronJob"},
+	{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"},
+}
+
+// orphanedResources reports the ragme-labeled resources of the last sweep
+// without a live instance
+var orphanedResources = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ragme_orphaned_resources",
+	Help: "Resources labeled for a RAGme instance that no longer exists, as of the last sweep",
+}, []string{"namespace", "kind"})
+
+func init() {
+	metrics.Registry.MustRegister(orphanedResources)
+}
+
+// orphan is a generated resource whose instance is gone
+type orphan struct {
+	Kind     string
+	Object   metav1.PartialObjectMetadata
+	Instance string
+}
+
+// OrphanJanitor sweeps the cluster for resources labeled for a RAGme instance
+// that does not exist anymore, e.g. after a failed deletion or a rename. The
+// orphans are logged and counted, and deleted when Delete is set. Retained
+// data volumes and credentials are orphans by design and are never deleted.
+type OrphanJanitor struct {
+	// Reader lists the resources, an uncached reader keeps every kind out of the cache
+	Reader client.Reader
+
+	// Client deletes the orphans
+	Client client.Client
+
+	// Interval between two sweeps. Defaults to DefaultOrphanScanInterval
+	Interval time.Duration
+
+	// Delete removes the orphans instead of only reporting them
+	Delete bool
+}
+
+// NeedLeaderElection keeps a single replica deleting orphans
+func (j *OrphanJanitor) NeedLeaderElection() bool {
+	return true
+}
+
+// Start sweeps periodically until the context is cancelled
+func (j *OrphanJanitor) Start(ctx context.Context) error {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = DefaultOrphanScanInterval
+	}
+	logger := log.FromContext(ctx).WithName("orphan-janitor")
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if _, err := j.Sweep(log.IntoContext(ctx, logger)); err != nil {
+			logger.Error(err, "Failed to sweep orphaned resources")
+		}
+	}, interval)
+	return nil
+}
+
+// Sweep finds the orphans, reports them and deletes them when requested
+func (j *OrphanJanitor) Sweep(ctx context.Context) ([]orphan, error) {
+	logger := log.FromContext(ctx)
+	orphans, err := j.findOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedResources.Reset()
+	for _, o := range orphans {
+		orphanedResources.WithLabelValues(o.Object.Namespace, o.Kind).Inc()
+		keysAndValues := []interface{}{"kind", o.Kind, "namespace", o.Object.Namespace, "name", o.Object.Name, "instance", o.Instance}
+		if !j.Delete || retainedOrphan(o) {
+			logger.Info("Found orphaned resource", keysAndValues...)
+			continue
+		}
+		obj := o.Object.DeepCopy()
+		if err := j.Client.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+			return orphans, err
+		}
+		logger.Info("Deleted orphaned resource", keysAndValues...)
+	}
+	return orphans, nil
+}
+
+// retainedOrphan reports whether an orphan was kept on purpose by
+// cleanupPolicy Retain: a data volume, or the credentials the migration of a
+// renamed instance adopts to reach its data
+func retainedOrphan(o orphan) bool {
+	switch o.Kind {
+	case "PersistentVolumeClaim":
+		return true
+	case "Secret":
+		return retainedCredentials(&corev1.Secret{ObjectMeta: o.Object.ObjectMeta})
+	}
+	return false
+}
+
+// findOrphans lists the ragme-labeled resources whose instance does not exist,
+// or exists as a new object with another UID than the controller reference.
+// Resources controlled by another kind, e.g. a data source, are left to their
+// own controller.
+func (j *OrphanJanitor) findOrphans(ctx context.Context) ([]orphan, error) {
+	// The UIDs of the live instances, nil for the missing ones
+	instances := map[types.NamespacedName]*types.UID{}
+	var orphans []orphan
+	for _, gvk := range orphanKinds {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := j.Reader.List(ctx, list, client.MatchingLabels{"app": "ragme"}, client.HasLabels{"instance"}); err != nil {
+			return nil, err
+		}
+
+		for _, item := range list.Items {
+			owner := metav1.GetControllerOf(&item)
+			if owner != nil && owner.Kind != "RAGme" {
+				continue
+			}
+			key := types.NamespacedName{Namespace: item.Namespace, Name: item.Labels["instance"]}
+			uid, seen := instances[key]
+			if !seen {
+				ragme := &ragmev1.RAGme{}
+				if err := j.Reader.Get(ctx, key, ragme); err == nil {
+					uid = &ragme.UID
+				} else if !errors.IsNotFound(err) {
+					return nil, err
+				}
+				instances[key] = uid
+			}
+			if uid != nil && (owner == nil || owner.UID == *uid) {
+				continue
+			}
+			item.SetGroupVersionKind(gvk)
+			orphans = append(orphans, orphan{Kind: gvk.Kind, Object: item, Instance: key.Name})
+		}
+	}
+	return orphans, nil
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestOrphanJanitorSweep(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("live"))
+	h.reconcile(t, "live")
+
+	labels := func(instance string) map[string]string {
+		return map[string]string{"app": "ragme", "instance": instance}
+	}
+	stale := metav1.OwnerReference{APIVersion: "ragme.io/v1", Kind: "RAGme", Name: "live", UID: types.UID("deleted-uid"), Controller: &[]bool{true}[0]}
+	for _, obj := range []client.Object{
+		// Left over from a renamed instance
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "renamed-api", Namespace: "bench", Labels: labels("renamed")},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: labels("renamed")},
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels("renamed")}},
+			},
+		},
+		// Owned by an earlier instance of the same name
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "live-old", Namespace: "bench", Labels: labels("live"), OwnerReferences: []metav1.OwnerReference{stale}}},
+		// A retained data volume
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "renamed-minio-pvc", Namespace: "bench", Labels: labels("renamed")}},
+	} {
+		if err := h.client.Create(h.ctx, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	janitor := &OrphanJanitor{Reader: h.client, Client: h.client}
+	orphans, err := janitor.Sweep(h.ctx)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	found := map[string]bool{}
+	for _, o := range orphans {
+		found[o.Kind+"/"+o.Object.Name] = true
+	}
+	want := []string{"Deployment/renamed-api", "ConfigMap/live-old", "PersistentVolumeClaim/renamed-minio-pvc"}
+	if len(orphans) != len(want) {
+		t.Errorf("orphans = %v, want %v", found, want)
+	}
+	for _, name := range want {
+		if !found[name] {
+			t.Errorf("orphan %s not reported, got %v", name, found)
+		}
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "renamed-api"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("orphan deleted without the Delete flag: %v", err)
+	}
+
+	janitor.Delete = true
+	if _, err := janitor.Sweep(h.ctx); err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "renamed-api"}, &appsv1.Deployment{}); err == nil {
+		t.Error("orphaned Deployment not deleted")
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "renamed-minio-pvc"}, &corev1.PersistentVolumeClaim{}); err != nil {
+		t.Errorf("retained volume deleted: %v", err)
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "live-api"}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("Deployment of the live instance deleted: %v", err)
+	}
+}
+
+func TestOrphanJanitorKeepsRetainedCredentials(t *testing.T) {
+	h := newReconcilerHarness(t)
+	labels := map[string]string{"app": "ragme", "instance": "renamed", minioRootSecretLabel: "true"}
+	// The MinIO root Secret released with the volumes by cleanupPolicy Retain
+	released := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "renamed-minio-root", Namespace: "bench", Labels: labels}}
+	if err := h.client.Create(h.ctx, released); err != nil {
+		t.Fatal(err)
+	}
+
+	janitor := &OrphanJanitor{Reader: h.client, Client: h.client, Delete: true}
+	orphans, err := janitor.Sweep(h.ctx)
+	if err != nil {
+		t.Fatalf("Sweep() error = %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].Kind != "Secret" {
+		t.Errorf("orphans = %+v, want the released Secret reported", orphans)
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKeyFromObject(released), &corev1.Secret{}); err != nil {
+		t.Errorf("retained MinIO root Secret deleted: %v", err)
+	}
+}
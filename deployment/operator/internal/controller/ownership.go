@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// Labels attributing the generated objects to the owner of an instance
+const (
+	costCenterLabel  = "ragme.io/cost-center"
+	teamLabel        = "ragme.io/team"
+	environmentLabel = "ragme.io/environment"
+)
+
+// instanceInfo exports the ownership of the instances with spec.metadata.infoMetric
+// set, so chargeback dashboards can join it with resource usage
+var instanceInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ragme_instance_info",
+	Help: "Ownership of a RAGme instance. The value is always 1",
+}, []string{"namespace", "name", "cost_center", "team", "environment"})
+
+func init() {
+	metrics.Registry.MustRegister(instanceInfo)
+}
+
+// ownershipLabels returns the labels of spec.metadata that are set
+func ownershipLabels(ragme *ragmev1.RAGme) map[string]string {
+	labels := map[string]string{}
+	for key, value := range map[string]string{
+		costCenterLabel:  ragme.Spec.Metadata.CostCenter,
+		teamLabel:        ragme.Spec.Metadata.Team,
+		environmentLabel: ragme.Spec.Metadata.Environment,
+	} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// withLabels returns a copy of labels with extra added. Generated objects often
+// share their label map with a selector, which must not change.
+func withLabels(labels, extra map[string]string) map[string]string {
+	if len(extra) == 0 {
+		return labels
+	}
+	return mergeStringMaps(mergeStringMaps(nil, labels), extra)
+}
+
+// setOwner makes the instance the controller of a generated object and stamps
+// the ownership labels on it
+func (r *RAGmeReconciler) setOwner(ragme *ragmev1.RAGme, obj client.Object) error {
+	if err := ctrl.SetControllerReference(ragme, obj, r.Scheme); err != nil {
+		return err
+	}
+	applyOwnershipLabels(ragme, obj)
+	return nil
+}
+
+// applyOwnershipLabels stamps the ownership labels on a generated object and
+// on the pods it creates
+func applyOwnershipLabels(ragme *ragmev1.RAGme, obj client.Object) {
+	labels := ownershipLabels(ragme)
+	obj.SetLabels(withLabels(obj.GetLabels(), labels))
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		workload.Spec.Template.Labels = withLabels(workload.Spec.Template.Labels, labels)
+	case *batchv1.Job:
+		workload.Spec.Template.Labels = withLabels(workload.Spec.Template.Labels, labels)
+	case *batchv1.CronJob:
+		workload.Spec.JobTemplate.Labels = withLabels(workload.Spec.JobTemplate.Labels, labels)
+		workload.Spec.JobTemplate.Spec.Template.Labels = withLabels(workload.Spec.JobTemplate.Spec.Template.Labels, labels)
+	}
+}
+
+// recordInstanceInfo publishes or withdraws the ragme_instance_info series of an instance
+func recordInstanceInfo(ragme *ragmev1.RAGme) {
+	forgetInstanceInfo(ragme.Namespace, ragme.Name)
+	if !ragme.Spec.Metadata.InfoMetric || ragme.DeletionTimestamp != nil {
+		return
+	}
+	instanceInfo.WithLabelValues(ragme.Namespace, ragme.Name, ragme.Spec.Metadata.CostCenter,
+		ragme.Spec.Metadata.Team, ragme.Spec.Metadata.Environment).Set(1)
+}
+
+// forgetInstanceInfo withdraws the ragme_instance_info series of an instance
+func forgetInstanceInfo(namespace, name string) {
+	instanceInfo.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyOwnershipLabels(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Metadata = ragmev1.RAGmeMetadata{CostCenter: "cc-42", Team: "search"}
+
+	selector := map[string]string{"app": "ragme", "component": "api"}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Labels: selector},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: selector},
+		},
+	}
+	deployment.Spec.Template.Labels = selector
+
+	applyOwnershipLabels(ragme, deployment)
+
+	for _, labels := range []map[string]string{deployment.Labels, deployment.Spec.Template.Labels} {
+		if labels[costCenterLabel] != "cc-42" || labels[teamLabel] != "search" {
+			t.Errorf("labels = %v, want the cost center and team", labels)
+		}
+		if _, ok := labels[environmentLabel]; ok {
+			t.Errorf("labels = %v, want no environment label", labels)
+		}
+	}
+	if len(deployment.Spec.Selector.MatchLabels) != 2 {
+		t.Errorf("selector = %v, want it unchanged", deployment.Spec.Selector.MatchLabels)
+	}
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// Modes of the policy guardrails
+const (
+	// policyModeWarn reports the violations without blocking the instance
+	policyModeWarn = "Warn"
+	// policyModeEnforce rejects the instance at admission and stops its reconciliation
+	policyModeEnforce = "Enforce"
+	// policyModeDisabled evaluates no rule
+	policyModeDisabled = "Disabled"
+)
+
+// Rules of the deny-list
+const (
+	ruleAnonymousVectorDBIngress = "AnonymousVectorDBIngress"
+	rulePlaintextSecrets         = "PlaintextSecrets"
+	ruleLatestTagInProduction    = "LatestTagInProduction"
+)
+
+// productionEnvironments are the spec.metadata.environment values of production instances
+var productionEnvironments = sets.New("production", "prod")
+
+// policyRule is a known-bad configuration. check returns a message per
+// offending field, and is given the spec as written, before the defaults.
+type policyRule struct {
+	name  string
+	check func(ragme *ragmev1.RAGme) []string
+}
+
+// policyRules is the deny-list of insecure configurations
+var policyRules = []policyRule{
+	{name: ruleAnonymousVectorDBIngress, check: func(ragme *ragmev1.RAGme) []string {
+		// Weaviate serves anonymous requests, the oauth2-proxy in front of the
+		// route is the only authentication, and it admits any account without email domains
+		auth := ragme.Spec.ExternalAccess.Ingress.Auth
+		if !ingressAuthEnabled(ragme) || len(auth.EmailDomains) > 0 {
+			return nil
+		}
+		for _, route := range auth.Routes {
+			if route.Name == "weaviate" {
+				return []string{fmt.Sprintf("externalAccess.ingress.auth.routes: the anonymous Weaviate is exposed on %s to any account, restrict emailDomains", route.Host)}
+			}
+		}
+		return nil
+	}},
+	{name: rulePlaintextSecrets, check: func(ragme *ragmev1.RAGme) []string {
+		var messages []string
+		for path, value := range map[string]string{
+			"storage.minio.secretKey":                  ragme.Spec.Storage.MinIO.SecretKey,
+			"authentication.session.secretKey":         ragme.Spec.Authentication.Session.SecretKey,
+			"authentication.oauth.google.clientSecret": ragme.Spec.Authentication.OAuth.Google.ClientSecret,
+			"authentication.oauth.github.clientSecret": ragme.Spec.Authentication.OAuth.GitHub.ClientSecret,
+			"authentication.oauth.apple.clientSecret":  ragme.Spec.Authentication.OAuth.Apple.ClientSecret,
+		} {
+			if value != "" {
+				messages = append(messages, fmt.Sprintf("%s: secrets must not be written in plaintext in the spec", path))
+			}
+		}
+		return messages
+	}},
+	{name: ruleLatestTagInProduction, check: func(ragme *ragmev1.RAGme) []string {
+		if !productionEnvironments.Has(ragme.Spec.Metadata.Environment) {
+			return nil
+		}
+		if tag := ragme.Spec.Images.Tag; tag == "" || tag == "latest" {
+			return []string{"images.tag: production instances must pin an image tag instead of latest"}
+		}
+		return nil
+	}},
+}
+
+// policyViolation is a field of an instance matching a rule of the deny-list
+type policyViolation struct {
+	Rule    string
+	Message string
+}
+
+// String formats the violation for admission responses and condition messages
+func (v policyViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// evaluatePolicies returns the violations of the rules enabled by the policy
+func evaluatePolicies(policy OperatorPolicy, ragme *ragmev1.RAGme) []policyViolation {
+	if policy.Mode == policyModeDisabled {
+		return nil
+	}
+	disabled := sets.New(policy.DisabledRules...)
+	var violations []policyViolation
+	for _, rule := range policyRules {
+		if disabled.Has(rule.name) {
+			continue
+		}
+		// Sorted, the checks may iterate over maps
+		for _, message := range sets.List(sets.New(rule.check(ragme)...)) {
+			violations = append(violations, policyViolation{Rule: rule.name, Message: message})
+		}
+	}
+	return violations
+}
+
+// setPolicyCondition records the violations in the PolicyViolation condition
+// and reports whether they block the instance
+func setPolicyCondition(ragme *ragmev1.RAGme, policy OperatorPolicy, violations []policyViolation) bool {
+	if len(violations) == 0 {
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypePolicyViolation,
+			conditions.ReasonPoliciesSatisfied, "The spec matches no rule of the deny-list")
+		return false
+	}
+	messages := make([]string, len(violations))
+	for i, violation := range violations {
+		messages[i] = violation.String()
+	}
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypePolicyViolation,
+		conditions.ReasonPolicyViolated, strings.Join(messages, "; "))
+	return policy.Mode == policyModeEnforce
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestEvaluatePolicies(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		policy OperatorPolicy
+		mutate func(ragme *ragmev1.RAGme)
+		want   []string
+	}{
+		{"compliant", OperatorPolicy{}, func(*ragmev1.RAGme) {}, nil},
+		{"weaviate exposed to any account", OperatorPolicy{}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.ExternalAccess.Ingress.Enabled = true
+			ragme.Spec.ExternalAccess.Ingress.Auth = ragmev1.RAGmeIngressAuth{
+				Enabled: true,
+				Routes:  []ragmev1.RAGmeProtectedRoute{{Name: "weaviate", Host: "weaviate.example.com"}},
+			}
+		}, []string{ruleAnonymousVectorDBIngress}},
+		{"weaviate restricted to a domain", OperatorPolicy{}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.ExternalAccess.Ingress.Enabled = true
+			ragme.Spec.ExternalAccess.Ingress.Auth = ragmev1.RAGmeIngressAuth{
+				Enabled:      true,
+				Routes:       []ragmev1.RAGmeProtectedRoute{{Name: "weaviate", Host: "weaviate.example.com"}},
+				EmailDomains: []string{"example.com"},
+			}
+		}, nil},
+		{"plaintext secrets", OperatorPolicy{}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.Storage.MinIO.SecretKey = "minio-secret"
+			ragme.Spec.Authentication.OAuth.GitHub.ClientSecret = "github-secret"
+		}, []string{rulePlaintextSecrets, rulePlaintextSecrets}},
+		{"latest in production", OperatorPolicy{}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.Metadata.Environment = "production"
+			ragme.Spec.Images.Tag = ""
+		}, []string{ruleLatestTagInProduction}},
+		{"latest in staging", OperatorPolicy{}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.Metadata.Environment = "staging"
+			ragme.Spec.Images.Tag = "latest"
+		}, nil},
+		{"rule disabled", OperatorPolicy{DisabledRules: []string{rulePlaintextSecrets}}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.Storage.MinIO.SecretKey = "minio-secret"
+		}, nil},
+		{"policy disabled", OperatorPolicy{Mode: policyModeDisabled}, func(ragme *ragmev1.RAGme) {
+			ragme.Spec.Storage.MinIO.SecretKey = "minio-secret"
+		}, nil},
+	} {
+		ragme := harnessRAGme("policy")
+		ragme.Spec.Storage.MinIO.SecretKey = ""
+		ragme.Spec.Images.Tag = "v1.4.2"
+		tc.mutate(ragme)
+
+		violations := evaluatePolicies(tc.policy, ragme)
+		if len(violations) != len(tc.want) {
+			t.Errorf("%s: violations = %v, want rules %v", tc.name, violations, tc.want)
+			continue
+		}
+		for i, violation := range violations {
+			if violation.Rule != tc.want[i] {
+				t.Errorf("%s: violation %d = %v, want rule %s", tc.name, i, violation, tc.want[i])
+			}
+		}
+	}
+}
+
+func TestPolicyModes(t *testing.T) {
+	for _, tc := range []struct {
+		mode    string
+		blocked bool
+	}{
+		{policyModeWarn, false},
+		{policyModeEnforce, true},
+	} {
+		ragme := harnessRAGme("policy")
+		ragme.Spec.Authentication.Session.SecretKey = "plaintext"
+		h := newReconcilerHarness(t, ragme)
+		config := withOperatorConfig(t, h, "policy:\n  mode: "+tc.mode+"\n")
+		h.reconcile(t, "policy")
+
+		status := h.get(t, "policy").Status
+		if !conditions.IsTrue(status.Conditions, conditions.TypePolicyViolation) {
+			t.Errorf("%s: PolicyViolation = %+v, want True", tc.mode, meta.FindStatusCondition(status.Conditions, conditions.TypePolicyViolation))
+		}
+		if stalled := conditions.IsTrue(status.Conditions, conditions.TypeStalled); stalled != tc.blocked {
+			t.Errorf("%s: Stalled = %v, want %v", tc.mode, stalled, tc.blocked)
+		}
+
+		warnings, err := (&RAGmeValidator{Config: config}).ValidateCreate(h.ctx, ragme)
+		if (err != nil) != tc.blocked {
+			t.Errorf("%s: ValidateCreate() error = %v, want rejected %v", tc.mode, err, tc.blocked)
+		}
+		if !tc.blocked && len(warnings) == 0 {
+			t.Errorf("%s: no admission warning, want the violations", tc.mode)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingressclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// defaultStorageClassAnnotation marks the default StorageClass of a cluster
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// rwxProvisioners are substrings of the provisioners known to support ReadWriteMany volumes
+var rwxProvisioners = []string{"nfs", "cephfs", "efs.csi.aws.com", "file.csi.azure.com", "filestore.csi.storage.gke.io", "glusterfs", "longhorn"}
+
+// PreflightResult is the outcome of a single preflight check
+type PreflightResult struct {
+	// Name of the check
+	Name string
+	// Passed reports whether the prerequisite is met
+	Passed bool
+	// Required checks block the deployment when they fail, the others only warn
+	Required bool
+	// Message describes the outcome and how to fix a failure
+	Message string
+}
+
+// String formats the result for the check command and condition messages
+func (p PreflightResult) String() string {
+	return fmt.Sprintf("%s: %s", p.Name, p.Message)
+}
+
+// RunPreflight checks the cluster prerequisites of the given instance. The
+// instance is defaulted first, so a RAGme read from a manifest can be checked
+// before it is created.
+func RunPreflight(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	ragme = ragme.DeepCopy()
+	(&RAGmeReconciler{}).setDefaults(ragme)
+
+	checks := []func(context.Context, client.Client, *ragmev1.RAGme) ([]PreflightResult, error){
+		checkSharedStorageClass,
+		checkIngressClass,
+		checkCertManager,
+		checkSnapshotCRDs,
+		checkNodeResources,
+	}
+
+	var results []PreflightResult
+	for _, check := range checks {
+		checked, err := check(ctx, c, ragme)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, checked...)
+	}
+	return results, nil
+}
+
+// preflightFailures returns the failed required checks
+func preflightFailures(results []PreflightResult) []PreflightResult {
+	var failures []PreflightResult
+	for _, result := range results {
+		if result.Required && !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// preflightRequired reports whether the prerequisites must be checked. They
+// are checked until they pass once, and never for an instance that has
+// already been deployed.
+func (r *RAGmeReconciler) preflightRequired(ragme *ragmev1.RAGme) bool {
+	if r.SkipPreflight {
+		return false
+	}
+	return !conditions.IsTrue(ragme.Status.Conditions, conditions.TypePreflightPassed) &&
+		!conditions.IsTrue(ragme.Status.Conditions, conditions.TypeReady)
+}
+
+// reconcilePreflight runs the preflight checks and records their outcome in
+// the PreflightPassed condition. A failed required check marks the instance
+// Degraded, since installing the missing prerequisite needs no spec change.
+func (r *RAGmeReconciler) reconcilePreflight(ctx context.Context, ragme *ragmev1.RAGme) error {
+	logger := log.FromContext(ctx)
+
+	results, err := RunPreflight(ctx, r.Client, ragme)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		if !result.Passed && !result.Required {
+			logger.Info("Preflight warning", "check", result.Name, "message", result.Message)
+		}
+	}
+
+	failures := preflightFailures(results)
+	if len(failures) == 0 {
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypePreflightPassed,
+			conditions.ReasonPreflightSucceeded, "All cluster prerequisites are met")
+		return nil
+	}
+
+	messages := make([]string, len(failures))
+	for i, failure := range failures {
+		messages[i] = failure.String()
+	}
+	message := strings.Join(messages, "; ")
+	conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypePreflightPassed, conditions.ReasonPreflightFailed, message)
+	conditions.MarkDegraded(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonPreflightFailed, message)
+	return nil
+}
+
+// checkSharedStorageClass verifies a StorageClass can provision the shared
+// watch-directory volume, which must be mountable by several pods
+func checkSharedStorageClass(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	classes := &storagev1.StorageClassList{}
+	if err := c.List(ctx, classes); err != nil {
+		return nil, err
+	}
+
+	name := ragme.Spec.Storage.SharedVolume.StorageClass
+	if name == "" {
+		name = selectStorageClass(classes.Items, true)
+	}
+	var class *storagev1.StorageClass
+	for i := range classes.Items {
+		if classes.Items[i].Name == name {
+			class = &classes.Items[i]
+		}
+	}
+
+	if class == nil {
+		message := "no StorageClass found, install a storage provisioner or set spec.storage.sharedVolume.storageClass"
+		if name != "" {
+			message = fmt.Sprintf("StorageClass %q does not exist", name)
+		}
+		return []PreflightResult{{Name: "SharedStorageClass", Required: true, Message: message}}, nil
+	}
+
+	results := []PreflightResult{{
+		Name: "SharedStorageClass", Passed: true, Required: true,
+		Message: fmt.Sprintf("shared volume uses StorageClass %q", class.Name),
+	}}
+	rwx := PreflightResult{
+		Name:    "ReadWriteMany",
+		Passed:  supportsRWX(class.Provisioner),
+		Message: fmt.Sprintf("provisioner %q supports ReadWriteMany", class.Provisioner),
+	}
+	if !rwx.Passed {
+		rwx.Message = fmt.Sprintf("provisioner %q of StorageClass %q is not known to support ReadWriteMany, the shared volume may stay Pending", class.Provisioner, class.Name)
+	}
+	return append(results, rwx), nil
+}
+
+// checkIngressClass verifies an ingress controller is installed when the
+// instance is exposed through an Ingress
+func checkIngressClass(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	if !ragme.Spec.ExternalAccess.Ingress.Enabled {
+		return nil, nil
+	}
+	classes := &networkingv1.IngressClassList{}
+	if err := c.List(ctx, classes); err != nil {
+		return nil, err
+	}
+
+	name := ragme.Spec.ExternalAccess.Ingress.IngressClassName
+	if name == "" {
+		name = selectIngressClass(classes.Items)
+	}
+	for _, class := range classes.Items {
+		if class.Name == name {
+			return []PreflightResult{{
+				Name: "IngressClass", Passed: true, Required: true,
+				Message: fmt.Sprintf("Ingresses use IngressClass %q", name),
+			}}, nil
+		}
+	}
+
+	message := "no IngressClass found, install an ingress controller such as ingress-nginx"
+	if name != "" {
+		message = fmt.Sprintf("IngressClass %q does not exist", name)
+	}
+	return []PreflightResult{{Name: "IngressClass", Required: true, Message: message}}, nil
+}
+
+// checkCertManager verifies cert-manager is installed when the Ingress
+// requests its certificate from it
+func checkCertManager(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	ingress := ragme.Spec.ExternalAccess.Ingress
+	usesCertManager := false
+	for key := range ingress.Annotations {
+		if strings.HasPrefix(key, "cert-manager.io/") {
+			usesCertManager = true
+		}
+	}
+	if !ingress.Enabled || !ingress.TLSEnabled || !usesCertManager {
+		return nil, nil
+	}
+
+	installed, err := kindInstalled(c, schema.GroupKind{Group: "cert-manager.io", Kind: "Certificate"})
+	if err != nil {
+		return nil, err
+	}
+	result := PreflightResult{Name: "CertManager", Passed: installed, Required: true, Message: "cert-manager is installed"}
+	if !installed {
+		result.Message = "the Ingress uses cert-manager annotations but cert-manager is not installed"
+	}
+	return []PreflightResult{result}, nil
+}
+
+// checkSnapshotCRDs reports whether volume snapshots are available for backups
+func checkSnapshotCRDs(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	installed, err := kindInstalled(c, schema.GroupKind{Group: "snapshot.storage.k8s.io", Kind: "VolumeSnapshot"})
+	if err != nil {
+		return nil, err
+	}
+	result := PreflightResult{Name: "VolumeSnapshots", Passed: installed, Message: "VolumeSnapshot CRDs are installed"}
+	if !installed {
+		result.Message = "VolumeSnapshot CRDs are not installed, volume snapshots cannot be used for backups"
+	}
+	return []PreflightResult{result}, nil
+}
+
+// checkNodeResources verifies the schedulable nodes can fit the requested
+// resources of all components
+func checkNodeResources(ctx context.Context, c client.Client, ragme *ragmev1.RAGme) ([]PreflightResult, error) {
+	nodes := &corev1.NodeList{}
+	if err := c.List(ctx, nodes); err != nil {
+		return nil, err
+	}
+
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.Quantity{},
+		corev1.ResourceMemory: resource.Quantity{},
+	}
+	for _, node := range nodes.Items {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		for name, total := range allocatable {
+			total.Add(node.Status.Allocatable[name])
+			allocatable[name] = total
+		}
+	}
+
+	requested := requestedResources(ragme)
+	var missing []string
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		want, have := requested[name], allocatable[name]
+		if want.Cmp(have) > 0 {
+			missing = append(missing, fmt.Sprintf("%s %s requested, %s allocatable", name, want.String(), have.String()))
+		}
+	}
+	if len(missing) > 0 {
+		return []PreflightResult{{
+			Name: "NodeResources", Required: true,
+			Message: fmt.Sprintf("schedulable nodes are too small (%s), add nodes or lower spec.replicas and spec.resources", strings.Join(missing, ", ")),
+		}}, nil
+	}
+	return []PreflightResult{{Name: "NodeResources", Passed: true, Required: true, Message: "schedulable nodes fit the requested resources"}}, nil
+}
+
+// requestedResources sums the CPU and memory requests of all deployed replicas
+func requestedResources(ragme *ragmev1.RAGme) corev1.ResourceList {
+	type componentRequests struct {
+		replicas  int32
+		resources ragmev1.RAGmeServiceResources
+	}
+	components := []componentRequests{
+		{ragme.Spec.Replicas.API, ragme.Spec.Resources.API},
+		{ragme.Spec.Replicas.MCP, ragme.Spec.Resources.MCP},
+		{ragme.Spec.Replicas.Agent, ragme.Spec.Resources.Agent},
+		{ragme.Spec.Replicas.Frontend, ragme.Spec.Resources.Frontend},
+	}
+	if ragme.Spec.Storage.MinIO.Enabled {
+		components = append(components, componentRequests{1, ragme.Spec.Resources.MinIO})
+	}
+	if ragme.Spec.VectorDB.Type == "weaviate" && ragme.Spec.VectorDB.Weaviate.Enabled {
+		components = append(components, componentRequests{targetShards(ragme), ragme.Spec.Resources.Weaviate})
+	}
+
+	requested := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.Quantity{},
+		corev1.ResourceMemory: resource.Quantity{},
+	}
+	for _, component := range components {
+		for name, value := range map[corev1.ResourceName]string{
+			corev1.ResourceCPU:    component.resources.Requests.CPU,
+			corev1.ResourceMemory: component.resources.Requests.Memory,
+		} {
+			// Invalid quantities are left to the API server to reject
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				continue
+			}
+			total := requested[name]
+			for i := int32(0); i < component.replicas; i++ {
+				total.Add(quantity)
+			}
+			requested[name] = total
+		}
+	}
+	return requested
+}
+
+// nodeReady reports whether the node is Ready
+func nodeReady(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// kindInstalled reports whether the API server serves the given kind
+func kindInstalled(c client.Client, kind schema.GroupKind) (bool, error) {
+	_, err := c.RESTMapper().RESTMapping(kind)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
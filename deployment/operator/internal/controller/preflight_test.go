@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRequestedResources(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Replicas = ragmev1.RAGmeReplicas{API: 2, MCP: 1, Agent: 1, Frontend: 3}
+	ragme.Spec.Resources.API.Requests = ragmev1.RAGmeResourceRequests{CPU: "500m", Memory: "1Gi"}
+	ragme.Spec.Resources.Frontend.Requests = ragmev1.RAGmeResourceRequests{CPU: "100m"}
+	ragme.Spec.Resources.MinIO.Requests = ragmev1.RAGmeResourceRequests{Memory: "512Mi"}
+	ragme.Spec.Resources.Weaviate.Requests = ragmev1.RAGmeResourceRequests{Memory: "2Gi"}
+	ragme.Spec.Storage.MinIO.Enabled = true
+	ragme.Spec.VectorDB = ragmev1.RAGmeVectorDB{
+		Type:     "weaviate",
+		Weaviate: ragmev1.RAGmeWeaviateDB{Enabled: true},
+		Sharding: ragmev1.RAGmeSharding{Shards: 2},
+	}
+
+	requested := requestedResources(ragme)
+	if cpu, want := requested[corev1.ResourceCPU], resource.MustParse("1300m"); cpu.Cmp(want) != 0 {
+		t.Errorf("requested cpu = %s, want %s", cpu.String(), want.String())
+	}
+	// 2x1Gi api, 512Mi minio, 2x2Gi weaviate shards
+	if memory, want := requested[corev1.ResourceMemory], resource.MustParse("6656Mi"); memory.Cmp(want) != 0 {
+		t.Errorf("requested memory = %s, want %s", memory.String(), want.String())
+	}
+}
+
+func TestPreflightFailures(t *testing.T) {
+	results := []PreflightResult{
+		{Name: "SharedStorageClass", Passed: true, Required: true},
+		{Name: "ReadWriteMany"},
+		{Name: "IngressClass", Required: true},
+	}
+	failures := preflightFailures(results)
+	if len(failures) != 1 || failures[0].Name != "IngressClass" {
+		t.Errorf("preflightFailures() = %v, want only IngressClass", failures)
+	}
+}
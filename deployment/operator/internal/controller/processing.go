@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	processingConfigKey       = "processing.json"
+	processingConfigMountPath = "/app/config/processing"
+
+	piiActionMask = "mask"
+	piiActionDrop = "drop"
+)
+
+// piiEntityTypePattern matches entity type names such as CREDIT_CARD
+var piiEntityTypePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// defaultPIIEntityTypes are detected when piiRedaction lists no entity types
+var defaultPIIEntityTypes = []string{"EMAIL", "PHONE", "CREDIT_CARD", "SSN"}
+
+var (
+	// fileExtensionPattern matches normalized file extensions such as pdf or tar.gz
+	fileExtensionPattern = regexp.MustCompile(`^[a-z0-9]+(\.[a-z0-9]+)*$`)
+	// mimeTypePattern matches MIME types such as application/pdf and wildcards such as text/*
+	mimeTypePattern = regexp.MustCompile(`^[a-z]+/([a-z0-9][a-z0-9.+-]*|\*)$`)
+)
+
+// fileTypeGroups are the named groups of file types security policies usually block
+var fileTypeGroups = map[string][]string{
+	"@executables": {
+		"exe", "dll", "msi", "com", "bat", "cmd", "ps1", "sh", "bin", "elf", "so", "dylib", "jar", "apk", "app",
+		"application/x-msdownload", "application/x-executable", "application/x-sharedlib",
+		"application/x-mach-binary", "application/java-archive", "application/vnd.android.package-archive",
+	},
+	"@archives": {
+		"zip", "tar", "gz", "tgz", "bz2", "xz", "7z", "rar", "iso",
+		"application/zip", "application/x-tar", "application/gzip", "application/x-bzip2",
+		"application/x-xz", "application/x-7z-compressed", "application/vnd.rar", "application/x-iso9660-image",
+	},
+}
+
+// processingConfig is the processing pipeline configuration rendered for the agent and api
+type processingConfig struct {
+	PIIRedaction *piiRedactionConfig `json:"piiRedaction,omitempty"`
+	FileTypes    *fileTypeConfig     `json:"fileTypes,omitempty"`
+	OCR          *ocrConfig          `json:"ocr,omitempty"`
+	Dedup        *dedupConfig        `json:"dedup,omitempty"`
+	Versioning   *versioningConfig   `json:"versioning,omitempty"`
+}
+
+// fileTypeConfig is the file type policy of the ingestion path, with the
+// extensions and MIME types apart and the groups expanded
+type fileTypeConfig struct {
+	AllowedExtensions []string `json:"allowedExtensions,omitempty"`
+	AllowedMIMETypes  []string `json:"allowedMimeTypes,omitempty"`
+	BlockedExtensions []string `json:"blockedExtensions,omitempty"`
+	BlockedMIMETypes  []string `json:"blockedMimeTypes,omitempty"`
+}
+
+// piiRedactionConfig is the PII redaction stage of the processing pipeline
+type piiRedactionConfig struct {
+	EntityTypes []string `json:"entityTypes"`
+	Action      string   `json:"action"`
+	ServiceURL  string   `json:"serviceURL,omitempty"`
+}
+
+// processingConfigMapName returns the name of the ConfigMap holding the processing configuration
+func processingConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-processing", ragme.Name)
+}
+
+// renderProcessingConfig returns the processing pipeline configuration of the instance
+func renderProcessingConfig(ragme *ragmev1.RAGme) processingConfig {
+	config := processingConfig{}
+	if pii := ragme.Spec.Processing.PIIRedaction; pii.Enabled {
+		config.PIIRedaction = &piiRedactionConfig{
+			EntityTypes: pii.EntityTypes,
+			Action:      pii.Action,
+			ServiceURL:  pii.Service.URL,
+		}
+		if len(config.PIIRedaction.EntityTypes) == 0 {
+			config.PIIRedaction.EntityTypes = defaultPIIEntityTypes
+		}
+	}
+	processing := ragme.Spec.Processing
+	if len(processing.AllowedTypes) > 0 || len(processing.BlockedTypes) > 0 {
+		config.FileTypes = &fileTypeConfig{}
+		config.FileTypes.AllowedExtensions, config.FileTypes.AllowedMIMETypes = splitFileTypes(processing.AllowedTypes)
+		config.FileTypes.BlockedExtensions, config.FileTypes.BlockedMIMETypes = splitFileTypes(processing.BlockedTypes)
+	}
+	config.OCR = renderOCRConfig(ragme)
+	config.Dedup = renderDedupConfig(ragme)
+	config.Versioning = renderVersioningConfig(ragme)
+	return config
+}
+
+// normalizeFileTypePattern lower-cases a file type pattern and strips the
+// leading dot of an extension
+func normalizeFileTypePattern(pattern string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pattern), "."))
+}
+
+// splitFileTypes expands the groups of the patterns and returns the
+// extensions and MIME types apart, without duplicates
+func splitFileTypes(patterns []string) (extensions, mimeTypes []string) {
+	seen := map[string]bool{}
+	add := func(pattern string) {
+		if seen[pattern] {
+			return
+		}
+		seen[pattern] = true
+		if strings.Contains(pattern, "/") {
+			mimeTypes = append(mimeTypes, pattern)
+		} else {
+			extensions = append(extensions, pattern)
+		}
+	}
+	for _, pattern := range patterns {
+		pattern = normalizeFileTypePattern(pattern)
+		if group, ok := fileTypeGroups[pattern]; ok {
+			for _, member := range group {
+				add(member)
+			}
+			continue
+		}
+		add(pattern)
+	}
+	return extensions, mimeTypes
+}
+
+// validFileTypePattern reports whether a pattern is a file extension, a MIME
+// type, a MIME type wildcard or a group
+func validFileTypePattern(pattern string) bool {
+	pattern = normalizeFileTypePattern(pattern)
+	if _, ok := fileTypeGroups[pattern]; ok {
+		return true
+	}
+	return fileExtensionPattern.MatchString(pattern) || mimeTypePattern.MatchString(pattern)
+}
+
+// reconcileProcessingConfig renders the processing pipeline configuration into
+// the ConfigMap mounted by the agent and api
+func (r *RAGmeReconciler) reconcileProcessingConfig(ctx context.Context, ragme *ragmev1.RAGme) error {
+	data, err := json.MarshalIndent(renderProcessingConfig(ragme), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      processingConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{processingConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[processingConfigKey] != configMap.Data[processingConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyProcessing mounts the processing configuration into the pods of the
+// ingestion path and wires the credentials of the external redaction service
+func applyProcessing(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName != "agent" && serviceName != "api" && serviceName != "mcp" && serviceName != imageProcessorComponent &&
+		serviceName != transcriberComponent {
+		return
+	}
+
+	mountVolume(podSpec, corev1.Volume{
+		Name: "processing",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: processingConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, processingConfigMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_PROCESSING_CONFIG", Value: processingConfigMountPath + "/" + processingConfigKey,
+	})
+	applyOCR(ragme, podSpec)
+
+	pii := ragme.Spec.Processing.PIIRedaction
+	if pii.Enabled && pii.Service.TokenSecretRef != nil {
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name:      "RAGME_PII_SERVICE_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: pii.Service.TokenSecretRef},
+		})
+	}
+}
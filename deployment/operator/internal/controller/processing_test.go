@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderProcessingConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if config := renderProcessingConfig(ragme); config.PIIRedaction != nil {
+		t.Errorf("piiRedaction = %v, want nil when disabled", config.PIIRedaction)
+	}
+
+	ragme.Spec.Processing.PIIRedaction = ragmev1.RAGmePIIRedaction{Enabled: true, Action: piiActionDrop}
+	config := renderProcessingConfig(ragme)
+	if config.PIIRedaction == nil {
+		t.Fatalf("piiRedaction = nil, want the redaction stage")
+	}
+	if !reflect.DeepEqual(config.PIIRedaction.EntityTypes, defaultPIIEntityTypes) {
+		t.Errorf("entityTypes = %v, want the defaults", config.PIIRedaction.EntityTypes)
+	}
+	if config.PIIRedaction.Action != piiActionDrop {
+		t.Errorf("action = %q, want %q", config.PIIRedaction.Action, piiActionDrop)
+	}
+}
+
+func TestRenderFileTypePolicy(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if config := renderProcessingConfig(ragme); config.FileTypes != nil {
+		t.Errorf("fileTypes = %+v, want nil without a policy", config.FileTypes)
+	}
+
+	ragme.Spec.Processing.AllowedTypes = []string{".PDF", "text/*", "application/pdf"}
+	ragme.Spec.Processing.BlockedTypes = []string{"@archives", "zip"}
+	config := renderProcessingConfig(ragme).FileTypes
+	if config == nil {
+		t.Fatal("fileTypes = nil, want the policy")
+	}
+	if !reflect.DeepEqual(config.AllowedExtensions, []string{"pdf"}) ||
+		!reflect.DeepEqual(config.AllowedMIMETypes, []string{"text/*", "application/pdf"}) {
+		t.Errorf("allowed = %v %v, want the extension and MIME types apart", config.AllowedExtensions, config.AllowedMIMETypes)
+	}
+	if len(config.BlockedExtensions) != len(fileTypeGroups["@archives"])-len(config.BlockedMIMETypes) {
+		t.Errorf("blocked extensions = %v, want the archives group once", config.BlockedExtensions)
+	}
+}
+
+func TestValidateSpecFileTypes(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.AllowedTypes = []string{"pdf", "application/*/x", "exe"}
+	ragme.Spec.Processing.BlockedTypes = []string{"@executables", "@unknown"}
+	err := validateSpec(ragme)
+	if err == nil {
+		t.Fatal("validateSpec() accepted invalid file type patterns")
+	}
+	for _, want := range []string{`"application/*/x"`, `"exe" is also blocked`, `"@unknown"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateSpec() error = %v, want %s reported", err, want)
+		}
+	}
+
+	ragme.Spec.Processing.AllowedTypes = []string{"pdf", "docx", "text/*"}
+	ragme.Spec.Processing.BlockedTypes = []string{"@executables", "@archives", "application/x-msdownload"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
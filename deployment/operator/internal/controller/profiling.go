@@ -0,0 +1,417 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	profilingBackendPyroscope = "pyroscope"
+	profilingBackendParca     = "parca"
+
+	defaultPyroscopeScraperImage = "grafana/alloy:v1.2.0"
+	defaultParcaScraperImage     = "ghcr.io/parca-dev/parca:v0.21.0"
+
+	defaultProfilingPort           = 6060
+	defaultProfilingScrapeInterval = "15s"
+	profilingPortName              = "profiling"
+
+	profilerConfigPath     = "/etc/profiler"
+	alloyConfigKey         = "config.alloy"
+	parcaConfigKey         = "parca.json"
+	profilerHashAnnotation = "ragme.io/profiler-hash"
+	profilerTokenEnv       = "PROFILING_TOKEN"
+)
+
+// profiledComponents lists the Python services serving profiling endpoints
+var profiledComponents = []string{"api", "mcp", "agent"}
+
+// profilerName returns the name of the ConfigMap and Deployment of the scraper
+func profilerName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-profiler", ragme.Name)
+}
+
+// profilingServiceName returns the name of the headless Service resolving to
+// the pods of a profiled component
+func profilingServiceName(ragme *ragmev1.RAGme, component string) string {
+	return fmt.Sprintf("%s-%s-profiling", ragme.Name, component)
+}
+
+// profilingBackend returns the configured backend, defaulting to Pyroscope
+func profilingBackend(ragme *ragmev1.RAGme) string {
+	if backend := ragme.Spec.Observability.Profiling.Backend; backend != "" {
+		return backend
+	}
+	return profilingBackendPyroscope
+}
+
+// profilingPort returns the port of the profiling endpoints of the services
+func profilingPort(ragme *ragmev1.RAGme) int32 {
+	if port := ragme.Spec.Observability.Profiling.Port; port != 0 {
+		return port
+	}
+	return defaultProfilingPort
+}
+
+// profilingScrapeInterval returns the scrape interval of the profiles
+func profilingScrapeInterval(ragme *ragmev1.RAGme) string {
+	if interval := ragme.Spec.Observability.Profiling.ScrapeInterval; interval != "" {
+		return interval
+	}
+	return defaultProfilingScrapeInterval
+}
+
+// consumesProfiling reports whether the component serves profiling endpoints
+func consumesProfiling(component string) bool {
+	for _, c := range profiledComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// applyProfiling serves the CPU and heap profiles of a service on the
+// profiling port. The endpoints follow the pprof layout, /debug/pprof/profile
+// and /debug/pprof/heap, and are only reachable through the headless Service.
+func applyProfiling(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if !ragme.Spec.Observability.Profiling.Enabled || !consumesProfiling(serviceName) {
+		return
+	}
+	container := &podSpec.Containers[0]
+	port := profilingPort(ragme)
+	container.Ports = append(container.Ports, corev1.ContainerPort{Name: profilingPortName, ContainerPort: port})
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "RAGME_PROFILING_ENABLED", Value: "true"},
+		corev1.EnvVar{Name: "RAGME_PROFILING_PORT", Value: fmt.Sprint(port)},
+	)
+}
+
+// profilingTargets returns the DNS name of the headless Service of each
+// profiled component, resolved by the scraper into the pod addresses so that
+// every replica is profiled
+func profilingTargets(ragme *ragmev1.RAGme) map[string]string {
+	targets := map[string]string{}
+	for _, component := range profiledComponents {
+		targets[component] = fmt.Sprintf("%s.%s.svc", profilingServiceName(ragme, component), ragme.Namespace)
+	}
+	return targets
+}
+
+// renderAlloyConfig returns the Grafana Alloy configuration scraping the
+// profiles into Pyroscope
+func renderAlloyConfig(ragme *ragmev1.RAGme) string {
+	profiling := ragme.Spec.Observability.Profiling
+	targets := profilingTargets(ragme)
+
+	var b strings.Builder
+	var outputs []string
+	for _, component := range profiledComponents {
+		fmt.Fprintf(&b, "discovery.dns %q {\n  names = [%q]\n  type  = \"A\"\n  port  = %d\n}\n\n", component, targets[component], profilingPort(ragme))
+		fmt.Fprintf(&b, "discovery.relabel %q {\n  targets = discovery.dns.%s.targets\n\n", component, component)
+		fmt.Fprintf(&b, "  rule {\n    target_label = \"service_name\"\n    replacement  = %q\n  }\n}\n\n", "ragme-"+component)
+		outputs = append(outputs, fmt.Sprintf("discovery.relabel.%s.output", component))
+	}
+
+	fmt.Fprintf(&b, "pyroscope.scrape \"ragme\" {\n  targets         = concat(%s)\n", strings.Join(outputs, ", "))
+	fmt.Fprintf(&b, "  forward_to      = [pyroscope.write.backend.receiver]\n  scrape_interval = %q\n\n", profilingScrapeInterval(ragme))
+	b.WriteString("  profiling_config {\n")
+	b.WriteString("    profile.process_cpu {\n      enabled = true\n      path    = \"/debug/pprof/profile\"\n      delta   = true\n    }\n")
+	b.WriteString("    profile.memory {\n      enabled = true\n      path    = \"/debug/pprof/heap\"\n    }\n")
+	for _, profile := range []string{"goroutine", "block", "mutex", "fgprof"} {
+		fmt.Fprintf(&b, "    profile.%s {\n      enabled = false\n    }\n", profile)
+	}
+	b.WriteString("  }\n}\n\n")
+
+	b.WriteString("pyroscope.write \"backend\" {\n  endpoint {\n")
+	fmt.Fprintf(&b, "    url = %q\n", strings.TrimSuffix(profiling.Endpoint, "/"))
+	if profiling.TokenSecretRef != nil {
+		fmt.Fprintf(&b, "    bearer_token = sys.env(%q)\n", profilerTokenEnv)
+	}
+	b.WriteString("  }\n\n")
+	fmt.Fprintf(&b, "  external_labels = {\n    namespace      = %q,\n    ragme_instance = %q,\n  }\n}\n", ragme.Namespace, ragme.Name)
+	return b.String()
+}
+
+// renderParcaConfig returns the scrape configuration of Parca in scraper-only
+// mode
+func renderParcaConfig(ragme *ragmev1.RAGme) (string, error) {
+	targets := profilingTargets(ragme)
+	var scrapeConfigs []interface{}
+	for _, component := range profiledComponents {
+		scrapeConfigs = append(scrapeConfigs, map[string]interface{}{
+			"job_name":        "ragme-" + component,
+			"scrape_interval": profilingScrapeInterval(ragme),
+			"dns_sd_configs": []interface{}{map[string]interface{}{
+				"names": []string{targets[component]},
+				"type":  "A",
+				"port":  profilingPort(ragme),
+			}},
+			"relabel_configs": []interface{}{
+				map[string]interface{}{"target_label": "component", "replacement": component},
+				map[string]interface{}{"target_label": "namespace", "replacement": ragme.Namespace},
+				map[string]interface{}{"target_label": "ragme_instance", "replacement": ragme.Name},
+			},
+			"profiling_config": map[string]interface{}{
+				"pprof_config": map[string]interface{}{
+					"process_cpu": map[string]interface{}{"enabled": true, "delta": true, "path": "/debug/pprof/profile"},
+					"memory":      map[string]interface{}{"enabled": true, "path": "/debug/pprof/heap"},
+					"goroutine":   map[string]interface{}{"enabled": false},
+					"block":       map[string]interface{}{"enabled": false},
+					"mutex":       map[string]interface{}{"enabled": false},
+				},
+			},
+		})
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{"scrape_configs": scrapeConfigs}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// renderProfilerConfig returns the configuration key and content of the
+// scraper of the configured backend, with its hash
+func renderProfilerConfig(ragme *ragmev1.RAGme) (string, string, string, error) {
+	key, config := alloyConfigKey, ""
+	if profilingBackend(ragme) == profilingBackendParca {
+		var err error
+		key = parcaConfigKey
+		if config, err = renderParcaConfig(ragme); err != nil {
+			return "", "", "", err
+		}
+	} else {
+		config = renderAlloyConfig(ragme)
+	}
+	sum := sha256.Sum256([]byte(config))
+	return key, config, hex.EncodeToString(sum[:])[:8], nil
+}
+
+// reconcileProfiling renders the scraper configuration and runs the scraper
+// with the headless Services of the profiled components. The resources are
+// removed when profiling is disabled.
+func (r *RAGmeReconciler) reconcileProfiling(ctx context.Context, ragme *ragmev1.RAGme) error {
+	deployment := createProfilerDeployment(ragme)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      profilerName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":       "ragme",
+				"component": "profiler",
+				"instance":  ragme.Name,
+			},
+		},
+	}
+	var services []*corev1.Service
+	for _, component := range profiledComponents {
+		services = append(services, createProfilingService(ragme, component))
+	}
+
+	if !ragme.Spec.Observability.Profiling.Enabled {
+		stale := []client.Object{deployment, configMap}
+		for _, service := range services {
+			stale = append(stale, service)
+		}
+		for _, obj := range stale {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, service := range services {
+		if err := r.setOwner(ragme, service); err != nil {
+			return err
+		}
+		found := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, found)
+		if err != nil && errors.IsNotFound(err) {
+			if err := r.Create(ctx, service); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if found.Spec.Ports[0].Port != service.Spec.Ports[0].Port {
+			found.Spec.Ports = service.Spec.Ports
+			if err := r.Update(ctx, found); err != nil {
+				return err
+			}
+		}
+	}
+
+	key, config, _, err := renderProfilerConfig(ragme)
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{key: config}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if len(found.Data) != 1 || found.Data[key] != config {
+		found.Data = configMap.Data
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+	foundDeployment.Spec = deployment.Spec
+	foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+	foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+	return r.updateOrRecreate(ctx, ragme, foundDeployment, deployment)
+}
+
+// createProfilingService returns the headless Service of a profiled
+// component. Being headless, its DNS name resolves to every ready pod.
+func createProfilingService(ragme *ragmev1.RAGme, component string) *corev1.Service {
+	selector := map[string]string{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}
+	port := profilingPort(ragme)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      profilingServiceName(ragme, component),
+			Namespace: ragme.Namespace,
+			Labels:    selector,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  selector,
+			Ports: []corev1.ServicePort{
+				{Name: profilingPortName, Port: port, TargetPort: intstr.FromString(profilingPortName)},
+			},
+		},
+	}
+}
+
+// createProfilerDeployment returns the Deployment of the scraper: Grafana
+// Alloy writing to Pyroscope, or Parca in scraper-only mode forwarding to the
+// Parca store. Neither reloads its configuration, so the configuration hash
+// on the pod template rolls the scraper.
+func createProfilerDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	profiling := ragme.Spec.Observability.Profiling
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "profiler",
+		"instance":  ragme.Name,
+	}
+
+	annotations := map[string]string{}
+	key, _, hash, err := renderProfilerConfig(ragme)
+	if err == nil {
+		annotations[profilerHashAnnotation] = hash
+	}
+	// Nothing is profiled while the services are scaled to zero
+	replicas := hibernationReplicas(ragme, 1)
+
+	container := corev1.Container{
+		Name: "profiler",
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "config", MountPath: profilerConfigPath, ReadOnly: true},
+		},
+	}
+	if ref := profiling.TokenSecretRef; ref != nil {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name:      profilerTokenEnv,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref.DeepCopy()},
+		})
+	}
+	if profilingBackend(ragme) == profilingBackendParca {
+		container.Image = defaultParcaScraperImage
+		container.Args = []string{
+			"--config-path=" + profilerConfigPath + "/" + key,
+			"--mode=scraper-only",
+			"--store-address=" + profiling.Endpoint,
+		}
+		if profiling.Insecure {
+			container.Args = append(container.Args, "--insecure")
+		}
+		if profiling.TokenSecretRef != nil {
+			container.Args = append(container.Args, fmt.Sprintf("--bearer-token=$(%s)", profilerTokenEnv))
+		}
+	} else {
+		container.Image = defaultPyroscopeScraperImage
+		container.Args = []string{
+			"run", profilerConfigPath + "/" + key,
+			"--storage.path=/tmp/alloy",
+			"--server.http.listen-addr=0.0.0.0:12345",
+		}
+	}
+	if profiling.Image != "" {
+		container.Image = profiling.Image
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      profilerName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{container},
+					Volumes: []corev1.Volume{{
+						Name: "config",
+						VolumeSource: corev1.VolumeSource{
+							ConfigMap: &corev1.ConfigMapVolumeSource{
+								LocalObjectReference: corev1.LocalObjectReference{Name: profilerName(ragme)},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
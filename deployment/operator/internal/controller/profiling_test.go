@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func profilingRAGme() *ragmev1.RAGme {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Observability.Profiling = ragmev1.RAGmeProfiling{
+		Enabled:  true,
+		Endpoint: "http://pyroscope.observability:4040/",
+		TokenSecretRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "profiling"}, Key: "token",
+		},
+	}
+	return ragme
+}
+
+func TestApplyProfiling(t *testing.T) {
+	ragme := profilingRAGme()
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}
+	applyProfiling(ragme, "api", podSpec)
+	container := podSpec.Containers[0]
+	if envValue(container.Env, "RAGME_PROFILING_ENABLED") != "true" || envValue(container.Env, "RAGME_PROFILING_PORT") != "6060" {
+		t.Errorf("env = %+v, want profiling on port 6060", container.Env)
+	}
+	if len(container.Ports) != 1 || container.Ports[0].Name != profilingPortName {
+		t.Errorf("ports = %+v, want the profiling port", container.Ports)
+	}
+
+	podSpec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "frontend"}}}
+	applyProfiling(ragme, "frontend", podSpec)
+	if len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("env = %+v, want none for the frontend", podSpec.Containers[0].Env)
+	}
+}
+
+func TestRenderAlloyConfig(t *testing.T) {
+	config := renderAlloyConfig(profilingRAGme())
+	for _, want := range []string{
+		`names = ["test-agent-profiling.ragme.svc"]`,
+		`replacement  = "ragme-mcp"`,
+		`concat(discovery.relabel.api.output, discovery.relabel.mcp.output, discovery.relabel.agent.output)`,
+		`path    = "/debug/pprof/heap"`,
+		`url = "http://pyroscope.observability:4040"`,
+		`bearer_token = sys.env("PROFILING_TOKEN")`,
+		`ragme_instance = "test"`,
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config does not contain %s:\n%s", want, config)
+		}
+	}
+}
+
+func TestCreateProfilerDeploymentParca(t *testing.T) {
+	ragme := profilingRAGme()
+	ragme.Spec.Observability.Profiling.Backend = profilingBackendParca
+	ragme.Spec.Observability.Profiling.Endpoint = "parca.observability:7070"
+	ragme.Spec.Observability.Profiling.Insecure = true
+
+	key, data, _, err := renderProfilerConfig(ragme)
+	if err != nil || key != parcaConfigKey {
+		t.Fatalf("renderProfilerConfig() = %s, %v", key, err)
+	}
+	config := struct {
+		ScrapeConfigs []struct {
+			JobName      string `json:"job_name"`
+			DNSSDConfigs []struct {
+				Names []string `json:"names"`
+				Port  int32    `json:"port"`
+			} `json:"dns_sd_configs"`
+		} `json:"scrape_configs"`
+	}{}
+	if err := json.Unmarshal([]byte(data), &config); err != nil {
+		t.Fatalf("config is not valid JSON: %v", err)
+	}
+	if len(config.ScrapeConfigs) != 3 || config.ScrapeConfigs[0].DNSSDConfigs[0].Names[0] != "test-api-profiling.ragme.svc" {
+		t.Errorf("scrape_configs = %+v, want the headless Services of the api, mcp and agent", config.ScrapeConfigs)
+	}
+
+	container := createProfilerDeployment(ragme).Spec.Template.Spec.Containers[0]
+	args := strings.Join(container.Args, " ")
+	for _, want := range []string{"--mode=scraper-only", "--store-address=parca.observability:7070", "--insecure", "--bearer-token=$(PROFILING_TOKEN)"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args = %s, want %s", args, want)
+		}
+	}
+	if container.Image != defaultParcaScraperImage {
+		t.Errorf("image = %s, want %s", container.Image, defaultParcaScraperImage)
+	}
+}
+
+func TestValidateSpecProfiling(t *testing.T) {
+	ragme := profilingRAGme()
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+
+	ragme.Spec.Observability.Profiling.Backend = profilingBackendParca
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a URL as the Parca gRPC address")
+	}
+	ragme.Spec.Observability.Profiling.Endpoint = "parca.observability:7070"
+	ragme.Spec.AdminPort.Enabled = true
+	ragme.Spec.AdminPort.Port = 6060
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted the admin port as the profiling port")
+	}
+}
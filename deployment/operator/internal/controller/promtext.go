@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// promSample is a single sample of a Prometheus text exposition
+type promSample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// parsePrometheusText parses the samples of the requested metrics from a
+// Prometheus text exposition. Malformed lines are skipped.
+func parsePrometheusText(r io.Reader, names ...string) ([]promSample, error) {
+	wanted := map[string]bool{}
+	for _, n := range names {
+		wanted[n] = true
+	}
+
+	var samples []promSample
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, rest := line, ""
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name, rest = line[:i], line[i:]
+		}
+		if !wanted[name] {
+			continue
+		}
+
+		labels := map[string]string{}
+		if strings.HasPrefix(rest, "{") {
+			end := strings.LastIndex(rest, "}")
+			if end < 0 {
+				continue
+			}
+			labels = parsePrometheusLabels(rest[1:end])
+			rest = rest[end+1:]
+		}
+
+		// The value is followed by an optional timestamp
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			continue
+		}
+		samples = append(samples, promSample{Name: name, Labels: labels, Value: value})
+	}
+	return samples, scanner.Err()
+}
+
+// parsePrometheusLabels parses a label set such as a="1",b="x\"y"
+func parsePrometheusLabels(s string) map[string]string {
+	labels := map[string]string{}
+	for len(s) > 0 {
+		eq := strings.Index(s, "=\"")
+		if eq < 0 {
+			break
+		}
+		key := strings.TrimSpace(strings.TrimLeft(s[:eq], ","))
+		s = s[eq+2:]
+
+		var value strings.Builder
+		i := 0
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					value.WriteByte('\n')
+				default:
+					value.WriteByte(s[i])
+				}
+				continue
+			}
+			value.WriteByte(s[i])
+		}
+		labels[key] = value.String()
+		if i >= len(s) {
+			break
+		}
+		s = s[i+1:]
+	}
+	return labels
+}
+
+// sumSamples sums the values of the samples named name whose labels contain all of the given labels
+func sumSamples(samples []promSample, name string, labels map[string]string) float64 {
+	var total float64
+	for _, sample := range samples {
+		if sample.Name != name {
+			continue
+		}
+		matches := true
+		for k, v := range labels {
+			if sample.Labels[k] != v {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			total += sample.Value
+		}
+	}
+	return total
+}
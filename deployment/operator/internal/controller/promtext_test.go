@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePrometheusText(t *testing.T) {
+	exposition := `# HELP minio_bucket_usage_total_bytes Total bucket size in bytes
+# TYPE minio_bucket_usage_total_bytes gauge
+minio_bucket_usage_total_bytes{bucket="ragme-eng",server="127.0.0.1:9000"} 2048
+minio_bucket_usage_total_bytes{bucket="ragme-ops",server="127.0.0.1:9000"} 512 1700000000000
+minio_bucket_usage_object_total{bucket="ragme-eng",desc="a \"quoted\", value"} 3
+minio_cluster_capacity_usable_total_bytes 1e+06
+unrelated_metric 42
+`
+	samples, err := parsePrometheusText(strings.NewReader(exposition),
+		"minio_bucket_usage_total_bytes", "minio_bucket_usage_object_total", "minio_cluster_capacity_usable_total_bytes")
+	if err != nil {
+		t.Fatalf("parsePrometheusText() error = %v", err)
+	}
+	if len(samples) != 4 {
+		t.Fatalf("expected 4 samples, got %d", len(samples))
+	}
+
+	if got := sumSamples(samples, "minio_bucket_usage_total_bytes", nil); got != 2560 {
+		t.Errorf("expected total bucket usage 2560, got %v", got)
+	}
+	if got := sumSamples(samples, "minio_bucket_usage_total_bytes", map[string]string{"bucket": "ragme-ops"}); got != 512 {
+		t.Errorf("expected ragme-ops usage 512, got %v", got)
+	}
+	if got := sumSamples(samples, "minio_bucket_usage_object_total", map[string]string{"bucket": "ragme-eng"}); got != 3 {
+		t.Errorf("expected 3 objects, got %v", got)
+	}
+	if got := samples[2].Labels["desc"]; got != `a "quoted", value` {
+		t.Errorf("unexpected escaped label value %q", got)
+	}
+	if got := sumSamples(samples, "minio_cluster_capacity_usable_total_bytes", nil); got != 1e6 {
+		t.Errorf("expected capacity 1e6, got %v", got)
+	}
+}
@@ -3,37 +3,77 @@ package controller
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
 )
 
 // RAGmeReconciler reconciles a RAGme object
 type RAGmeReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// HTTPClient is used to query component endpoints. Defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+
+	// Resync controls the periodic resync of reconciled resources
+	Resync ResyncConfig
+
+	// SkipPreflight disables the cluster prerequisite checks run before the first deploy
+	SkipPreflight bool
+
+	// Clock supplies the current time to the schedules, TTLs and rotations.
+	// Defaults to the wall clock; tests and benchmarks inject a fake clock
+	Clock clock.PassiveClock
+
+	// Config supplies the organization-wide defaults and policies. Nil applies none
+	Config *OperatorConfigSource
+
+	// Parallelism bounds the independent areas of an instance reconciled at
+	// the same time. Defaults to DefaultParallelism; 1 reconciles them in turn
+	Parallelism int
+}
+
+// now returns the current time of the reconciler clock
+func (r *RAGmeReconciler) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
 }
 
 // +kubebuilder:rbac:groups=ragme.io,resources=ragmes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ragme.io,resources=ragmes/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=ragme.io,resources=ragmes/finalizers,verbs=update
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmetenants,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -46,6 +86,9 @@ func (r *RAGmeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("RAGme resource not found. Ignoring since object must be deleted")
+			forgetInstanceInfo(req.Namespace, req.Name)
+			forgetSLO(req.Namespace, req.Name)
+			forgetDrift(req.Namespace, req.Name)
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "Failed to get RAGme")
@@ -54,52 +97,181 @@ func (r *RAGmeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	logger.Info("Reconciling RAGme", "name", ragme.Name, "namespace", ragme.Namespace)
 
-	// Set default values
+	// Apply the cleanup policy before the instance goes away
+	if !ragme.DeletionTimestamp.IsZero() {
+		return r.reconcileDelete(ctx, ragme)
+	}
+	if !controllerutil.ContainsFinalizer(ragme, ragmeFinalizer) {
+		patch := client.MergeFrom(ragme.DeepCopy())
+		controllerutil.AddFinalizer(ragme, ragmeFinalizer)
+		if err := r.Patch(ctx, ragme, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	// The status is only written when it changed since it was read
+	stored := ragme.DeepCopy()
+
+	// Set default values, the operator configuration beneath the built-in ones
+	config, err := r.Config.Load(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to load the operator configuration")
+		return ctrl.Result{}, err
+	}
+	// Check the deny-list on the spec as written, before the defaults
+	violations := evaluatePolicies(config.Policy, ragme)
+	// The defaulting webhook has already stored them, unless it is disabled
+	config.applyDefaults(ragme)
 	r.setDefaults(ragme)
+	setTransientDefaults(ragme)
+	recordInstanceInfo(ragme)
+
+	// Validate the spec and the cluster policies before touching any resources
+	if err := utilerrors.NewAggregate([]error{validateSpec(ragme), config.validate(ragme)}); err != nil {
+		logger.Error(err, "Invalid RAGme spec")
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSpecValid, conditions.ReasonValidationFailed, err.Error())
+		conditions.MarkStalled(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonValidationFailed, err.Error())
+		ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+		ragme.Status.ObservedGeneration = ragme.Generation
+		if err := r.patchStatus(ctx, ragme, stored); err != nil {
+			logger.Error(err, "Failed to update RAGme status")
+			return ctrl.Result{}, err
+		}
+		// Nothing to retry until the spec changes
+		return ctrl.Result{}, nil
+	}
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSpecValid, conditions.ReasonValidationSucceeded, "RAGme spec is valid")
+
+	// Stop at the insecure configurations the policy enforces, only report the others
+	if setPolicyCondition(ragme, config.Policy, violations) {
+		logger.Info("RAGme spec violates the enforced policies", "violations", len(violations))
+		conditions.MarkStalled(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonPolicyViolated, "The spec violates the enforced policies, see the PolicyViolation condition")
+		ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+		ragme.Status.ObservedGeneration = ragme.Generation
+		if err := r.patchStatus(ctx, ragme, stored); err != nil {
+			logger.Error(err, "Failed to update RAGme status")
+			return ctrl.Result{}, err
+		}
+		// Nothing to retry until the spec or the policy changes
+		return ctrl.Result{}, nil
+	} else if len(violations) > 0 {
+		logger.Info("RAGme spec violates the policies", "violations", len(violations))
+	}
 
-	// Update status to indicate reconciliation has started
-	ragme.Status.Phase = "Reconciling"
-	if err := r.Status().Update(ctx, ragme); err != nil {
-		logger.Error(err, "Failed to update RAGme status")
+	// Delete ephemeral instances once their TTL has elapsed
+	if expired, err := r.reconcileTTL(ctx, ragme, r.now()); err != nil || expired {
 		return ctrl.Result{}, err
 	}
 
-	// Reconcile storage components
-	if err := r.reconcileStorage(ctx, ragme); err != nil {
-		logger.Error(err, "Failed to reconcile storage")
-		return ctrl.Result{RequeueAfter: time.Minute}, err
+	// Publish the planned changes instead of applying them
+	if dryRunRequested(ragme) {
+		return r.reconcileDryRun(ctx, ragme, stored)
+	}
+	if err := r.cleanupDryRun(ctx, ragme); err != nil {
+		logger.Error(err, "Failed to clean up dry run results")
+		return ctrl.Result{}, err
 	}
 
-	// Reconcile MinIO
-	if err := r.reconcileMinIO(ctx, ragme); err != nil {
-		logger.Error(err, "Failed to reconcile MinIO")
-		return ctrl.Result{RequeueAfter: time.Minute}, err
+	// Check the cluster prerequisites before the first deploy
+	if r.preflightRequired(ragme) {
+		if err := r.reconcilePreflight(ctx, ragme); err != nil {
+			logger.Error(err, "Failed to run preflight checks")
+			return ctrl.Result{}, err
+		}
+		if !conditions.IsTrue(ragme.Status.Conditions, conditions.TypePreflightPassed) {
+			ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+			ragme.Status.ObservedGeneration = ragme.Generation
+			if err := r.patchStatus(ctx, ragme, stored); err != nil {
+				logger.Error(err, "Failed to update RAGme status")
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
 	}
 
-	// Reconcile vector database
-	if err := r.reconcileVectorDB(ctx, ragme); err != nil {
-		logger.Error(err, "Failed to reconcile vector database")
-		return ctrl.Result{RequeueAfter: time.Minute}, err
+	// Report the reconciliation of a new generation before applying it. The
+	// resyncs of an applied generation only write the status they change.
+	if ragme.Status.ObservedGeneration != ragme.Generation {
+		conditions.MarkReconciling(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonProgressing, "Applying the RAGme spec")
+		ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+		if err := r.patchStatus(ctx, ragme, stored); err != nil {
+			logger.Error(err, "Failed to update RAGme status")
+			return ctrl.Result{}, err
+		}
 	}
 
-	// Reconcile RAGme services
-	if err := r.reconcileRAGmeServices(ctx, ragme); err != nil {
-		logger.Error(err, "Failed to reconcile RAGme services")
+	if err := r.reconcileComponents(ctx, ragme); err != nil {
+		logger.Error(err, "Failed to reconcile RAGme components")
+		conditions.MarkDegraded(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonReconcileFailed, err.Error())
+		ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+		ragme.Status.ObservedGeneration = ragme.Generation
+		if statusErr := r.patchStatus(ctx, ragme, stored); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGme status")
+		}
 		return ctrl.Result{RequeueAfter: time.Minute}, err
 	}
 
+	conditions.Remove(&ragme.Status.Conditions, conditions.TypeBlockedByImmutableField)
+
+	// Hold Ready until a Weaviate upgrade has been verified and restored or migrated data is in place
+	message, pending := weaviateUpgradeInProgress(ragme)
+	if !pending {
+		message, pending = restoreInProgress(ragme)
+	}
+	if !pending {
+		message, pending = migrationInProgress(ragme)
+	}
+	if pending {
+		conditions.MarkReconciling(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonProgressing, message)
+		ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+		ragme.Status.ObservedGeneration = ragme.Generation
+		if err := r.patchStatus(ctx, ragme, stored); err != nil {
+			logger.Error(err, "Failed to update RAGme status")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+	}
+
 	// Update final status
-	ragme.Status.Phase = "Ready"
-	if err := r.Status().Update(ctx, ragme); err != nil {
+	conditions.MarkReady(&ragme.Status.Conditions, ragme.Generation, conditions.ReasonReconciled, "All RAGme components are reconciled")
+	ragme.Status.Phase = conditions.Phase(ragme.Status.Conditions)
+	ragme.Status.ObservedGeneration = ragme.Generation
+	if err := r.patchStatus(ctx, ragme, stored); err != nil {
 		logger.Error(err, "Failed to update final RAGme status")
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Successfully reconciled RAGme", "name", ragme.Name)
-	return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+	now := r.now()
+	result := nodeRecoveryResult(ragme, resilienceResult(ragme, sloResult(ragme, r.Resync.Result(ragme))))
+	return ttlResult(ragme, hibernationResult(ragme, noticeResult(ragme, result, now), now), now), nil
+}
+
+// reconcileComponents reconciles all resources owned by the instance, one
+// stage of areas after the other, the areas of a stage concurrently
+func (r *RAGmeReconciler) reconcileComponents(ctx context.Context, ragme *ragmev1.RAGme) error {
+	for _, stage := range subreconcilerStages(r.subreconcilers()) {
+		tasks := make([]reconcileTask, len(stage))
+		for i, sub := range stage {
+			tasks[i] = sub.Reconcile
+		}
+		if err := reconcileConcurrently(ctx, ragme, r.parallelism(), tasks...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setTransientDefaults sets the defaults only applied in memory, which the
+// defaulting webhook never writes to the stored spec: the shared session key
+// must not be persisted in plaintext
+func setTransientDefaults(ragme *ragmev1.RAGme) {
+	if ragme.Spec.Authentication.Session.SecretKey == "" {
+		ragme.Spec.Authentication.Session.SecretKey = "ragme-shared-session-secret-key-2025"
+	}
 }
 
-// setDefaults sets default values for RAGme spec
+// setDefaults sets default values for RAGme spec, persisted by the defaulting
+// webhook when it is enabled
 func (r *RAGmeReconciler) setDefaults(ragme *ragmev1.RAGme) {
 	if ragme.Spec.Version == "" {
 		ragme.Spec.Version = "latest"
@@ -133,18 +305,76 @@ func (r *RAGmeReconciler) setDefaults(ragme *ragmev1.RAGme) {
 		ragme.Spec.Storage.MinIO.StorageSize = "10Gi"
 	}
 
+	if ragme.Spec.Storage.MinIO.Metrics.AuthType == "" {
+		ragme.Spec.Storage.MinIO.Metrics.AuthType = "jwt"
+	}
+
+	if ragme.Spec.Storage.MinIO.Metrics.CapacityThresholdPercent == 0 {
+		ragme.Spec.Storage.MinIO.Metrics.CapacityThresholdPercent = 85
+	}
+	if ragme.Spec.LLM.Budget.AlertThresholdPercent == 0 {
+		ragme.Spec.LLM.Budget.AlertThresholdPercent = 80
+	}
+
 	if ragme.Spec.Storage.SharedVolume.Size == "" {
 		ragme.Spec.Storage.SharedVolume.Size = "5Gi"
 	}
 
+	if ragme.Spec.Storage.SharedVolume.Monitoring.Image == "" {
+		ragme.Spec.Storage.SharedVolume.Monitoring.Image = defaultVolumeMonitorImage
+	}
+
+	if ragme.Spec.Storage.SharedVolume.Monitoring.ThresholdPercent == 0 {
+		ragme.Spec.Storage.SharedVolume.Monitoring.ThresholdPercent = 85
+	}
+
+	if ragme.Spec.Storage.SharedVolume.Monitoring.ExpandStepPercent == 0 {
+		ragme.Spec.Storage.SharedVolume.Monitoring.ExpandStepPercent = 50
+	}
+
 	if ragme.Spec.VectorDB.Type == "" {
 		ragme.Spec.VectorDB.Type = "milvus"
 	}
 
-	// Set default authentication values
-	if ragme.Spec.Authentication.Session.SecretKey == "" {
-		ragme.Spec.Authentication.Session.SecretKey = "ragme-shared-session-secret-key-2025"
+	if ragme.Spec.VectorDB.Weaviate.Version == "" {
+		ragme.Spec.VectorDB.Weaviate.Version = defaultWeaviateVersion
+	}
+
+	if ragme.Spec.VectorDB.Weaviate.StorageSize == "" {
+		ragme.Spec.VectorDB.Weaviate.StorageSize = "10Gi"
+	}
+
+	if ragme.Spec.VectorDB.Sharding.RoutingKey == "" {
+		ragme.Spec.VectorDB.Sharding.RoutingKey = "document"
+	}
+
+	if ragme.Spec.Frontend.Streaming.IdleTimeoutSeconds == 0 {
+		ragme.Spec.Frontend.Streaming.IdleTimeoutSeconds = defaultStreamIdleTimeoutSeconds
+	}
+
+	if ragme.Spec.Security.Scanning.Engine == "" {
+		ragme.Spec.Security.Scanning.Engine = scanEngineClamAV
+	}
+	if ragme.Spec.Security.Scanning.Image == "" {
+		ragme.Spec.Security.Scanning.Image = defaultClamAVImage
+	}
+
+	if ragme.Spec.Processing.PIIRedaction.Action == "" {
+		ragme.Spec.Processing.PIIRedaction.Action = piiActionMask
+	}
+
+	if ragme.Spec.FeatureFlagsReload == "" {
+		ragme.Spec.FeatureFlagsReload = featureFlagsHotReload
 	}
+
+	if ragme.Spec.Authentication.Anonymous.Role == "" {
+		ragme.Spec.Authentication.Anonymous.Role = "read-only"
+	}
+	if ragme.Spec.Authorization.DefaultRole == "" {
+		ragme.Spec.Authorization.DefaultRole = roleViewer
+	}
+
+	// Set default authentication values
 	if ragme.Spec.Authentication.Session.MaxAgeSeconds == 0 {
 		ragme.Spec.Authentication.Session.MaxAgeSeconds = 86400 // 24 hours
 	}
@@ -173,11 +403,10 @@ func (r *RAGmeReconciler) reconcileStorage(ctx context.Context, ragme *ragmev1.R
 		},
 	}
 
-	if ragme.Spec.Storage.SharedVolume.StorageClass != "" {
-		pvc.Spec.StorageClassName = &ragme.Spec.Storage.SharedVolume.StorageClass
-	}
+	pvc.Spec.StorageClassName = className(ragme.Status.Classes.SharedVolumeStorageClass)
+	pvc.Spec.DataSource = restoreVolumeSource(ragme, pvc.Name)
 
-	if err := ctrl.SetControllerReference(ragme, pvc, r.Scheme); err != nil {
+	if err := r.setOwner(ragme, pvc); err != nil {
 		return err
 	}
 
@@ -198,6 +427,14 @@ func (r *RAGmeReconciler) reconcileMinIO(ctx context.Context, ragme *ragmev1.RAG
 		return nil
 	}
 
+	if err := r.reconcileMinIORootSecret(ctx, ragme); err != nil {
+		return err
+	}
+	accessKey, secretKey, err := minioRootKeys(ctx, r.Client, ragme)
+	if err != nil {
+		return err
+	}
+
 	// Create MinIO PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
@@ -213,24 +450,35 @@ func (r *RAGmeReconciler) reconcileMinIO(ctx context.Context, ragme *ragmev1.RAG
 					corev1.ResourceStorage: resource.MustParse(ragme.Spec.Storage.MinIO.StorageSize),
 				},
 			},
+			StorageClassName: className(ragme.Status.Classes.StorageClass),
 		},
 	}
 
-	if err := ctrl.SetControllerReference(ragme, pvc, r.Scheme); err != nil {
+	pvc.Spec.DataSource = restoreVolumeSource(ragme, pvc.Name)
+
+	if err := r.setOwner(ragme, pvc); err != nil {
 		return err
 	}
 
 	found := &corev1.PersistentVolumeClaim{}
-	err := r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, found)
+	err = r.Get(ctx, types.NamespacedName{Name: pvc.Name, Namespace: pvc.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		if err := r.Create(ctx, pvc); err != nil {
 			return err
 		}
 	}
 
+	zones, err := r.volumeZones(ctx, ragme, found, placementZones(ragme, "minio", 0))
+	if err != nil {
+		return err
+	}
+
 	// Create MinIO deployment
 	deployment := r.createMinIODeployment(ragme)
-	if err := ctrl.SetControllerReference(ragme, deployment, r.Scheme); err != nil {
+	applyZonePlacement(zoneLabel(ragme), zones, &deployment.Spec.Template.Spec)
+	deployment.Spec.Template.Annotations = mergeStringMaps(deployment.Spec.Template.Annotations,
+		map[string]string{minioRootRevisionKey: minioRootRevision(accessKey, secretKey)})
+	if err := r.setOwner(ragme, deployment); err != nil {
 		return err
 	}
 
@@ -243,14 +491,16 @@ func (r *RAGmeReconciler) reconcileMinIO(ctx context.Context, ragme *ragmev1.RAG
 	} else if err == nil {
 		// Update existing deployment
 		foundDeployment.Spec = deployment.Spec
-		if err := r.Update(ctx, foundDeployment); err != nil {
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
 			return err
 		}
 	}
 
 	// Create MinIO service
 	service := r.createMinIOService(ragme)
-	if err := ctrl.SetControllerReference(ragme, service, r.Scheme); err != nil {
+	if err := r.setOwner(ragme, service); err != nil {
 		return err
 	}
 
@@ -260,25 +510,49 @@ func (r *RAGmeReconciler) reconcileMinIO(ctx context.Context, ragme *ragmev1.RAG
 		if err := r.Create(ctx, service); err != nil {
 			return err
 		}
+	} else if err == nil && syncScrapeAnnotations(foundService, service) {
+		if err := r.Update(ctx, foundService); err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return r.reconcileMinIOMetrics(ctx, ragme)
 }
 
-// reconcileVectorDB reconciles vector database deployment
+// reconcileVectorDB drives the lifecycle of the configured vector database
+// through its provider
 func (r *RAGmeReconciler) reconcileVectorDB(ctx context.Context, ragme *ragmev1.RAGme) error {
-	if ragme.Spec.VectorDB.Type == "weaviate" && ragme.Spec.VectorDB.Weaviate.Enabled {
-		return r.reconcileWeaviate(ctx, ragme)
+	provider, err := r.newVectorDBProvider(ragme)
+	if err != nil {
+		return err
+	}
+	if err := provider.Migrate(ctx, ragme); err != nil {
+		return fmt.Errorf("%s: %w", provider.Name(), err)
+	}
+	if err := provider.Deploy(ctx, ragme); err != nil {
+		return fmt.Errorf("%s: %w", provider.Name(), err)
+	}
+	return provider.Bootstrap(ctx, ragme)
+}
+
+// checkVectorDB reports an unhealthy vector database
+func (r *RAGmeReconciler) checkVectorDB(ctx context.Context, ragme *ragmev1.RAGme) error {
+	provider, err := r.newVectorDBProvider(ragme)
+	if err != nil {
+		return err
+	}
+	if err := provider.HealthCheck(ctx, ragme); err != nil {
+		return fmt.Errorf("%s: %w", provider.Name(), err)
 	}
 	return nil
 }
 
-// reconcileWeaviate reconciles Weaviate deployment
-func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.RAGme) error {
+// reconcileWeaviate reconciles the Weaviate deployment of a shard
+func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.RAGme, shard int32) error {
 	// Create Weaviate PVC
 	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-weaviate-pvc", ragme.Name),
+			Name:      weaviateShardName(ragme, shard) + "-pvc",
 			Namespace: ragme.Namespace,
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
@@ -290,10 +564,13 @@ func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.
 					corev1.ResourceStorage: resource.MustParse(ragme.Spec.VectorDB.Weaviate.StorageSize),
 				},
 			},
+			StorageClassName: className(ragme.Status.Classes.StorageClass),
 		},
 	}
 
-	if err := ctrl.SetControllerReference(ragme, pvc, r.Scheme); err != nil {
+	pvc.Spec.DataSource = restoreVolumeSource(ragme, pvc.Name)
+
+	if err := r.setOwner(ragme, pvc); err != nil {
 		return err
 	}
 
@@ -305,9 +582,15 @@ func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.
 		}
 	}
 
+	zones, err := r.volumeZones(ctx, ragme, found, placementZones(ragme, "weaviate", shard))
+	if err != nil {
+		return err
+	}
+
 	// Create Weaviate deployment and service similar to MinIO
-	deployment := r.createWeaviateDeployment(ragme)
-	if err := ctrl.SetControllerReference(ragme, deployment, r.Scheme); err != nil {
+	deployment := r.createWeaviateDeployment(ragme, shard)
+	applyZonePlacement(zoneLabel(ragme), zones, &deployment.Spec.Template.Spec)
+	if err := r.setOwner(ragme, deployment); err != nil {
 		return err
 	}
 
@@ -319,14 +602,16 @@ func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.
 		}
 	} else if err == nil {
 		foundDeployment.Spec = deployment.Spec
-		if err := r.Update(ctx, foundDeployment); err != nil {
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
 			return err
 		}
 	}
 
 	// Create Weaviate service
-	service := r.createWeaviateService(ragme)
-	if err := ctrl.SetControllerReference(ragme, service, r.Scheme); err != nil {
+	service := r.createWeaviateService(ragme, shard)
+	if err := r.setOwner(ragme, service); err != nil {
 		return err
 	}
 
@@ -341,15 +626,36 @@ func (r *RAGmeReconciler) reconcileWeaviate(ctx context.Context, ragme *ragmev1.
 	return nil
 }
 
-// reconcileRAGmeServices reconciles the main RAGme application services
+// reconcileRAGmeServices reconciles the main RAGme application services, the
+// Deployments of the four services concurrently
 func (r *RAGmeReconciler) reconcileRAGmeServices(ctx context.Context, ragme *ragmev1.RAGme) error {
 	services := []string{"api", "mcp", "agent", "frontend"}
 
-	for _, serviceName := range services {
-		if err := r.reconcileRAGmeService(ctx, ragme, serviceName); err != nil {
-			return fmt.Errorf("failed to reconcile %s service: %w", serviceName, err)
+	tasks := make([]reconcileTask, len(services))
+	for i, serviceName := range services {
+		serviceName := serviceName
+		tasks[i] = func(ctx context.Context, ragme *ragmev1.RAGme) error {
+			if err := r.reconcileRAGmeService(ctx, ragme, serviceName); err != nil {
+				return fmt.Errorf("failed to reconcile %s service: %w", serviceName, err)
+			}
+			return nil
 		}
 	}
+	if err := reconcileConcurrently(ctx, ragme, r.parallelism(), tasks...); err != nil {
+		return err
+	}
+
+	if err := r.reconcileReadReplicas(ctx, ragme); err != nil {
+		return fmt.Errorf("failed to reconcile read replicas: %w", err)
+	}
+
+	if err := r.reconcileImageProcessing(ctx, ragme); err != nil {
+		return fmt.Errorf("failed to reconcile image processing: %w", err)
+	}
+
+	if err := r.reconcileMediaProcessing(ctx, ragme); err != nil {
+		return fmt.Errorf("failed to reconcile media processing: %w", err)
+	}
 
 	return nil
 }
@@ -357,7 +663,7 @@ func (r *RAGmeReconciler) reconcileRAGmeServices(ctx context.Context, ragme *rag
 // reconcileRAGmeService reconciles a single RAGme service
 func (r *RAGmeReconciler) reconcileRAGmeService(ctx context.Context, ragme *ragmev1.RAGme, serviceName string) error {
 	deployment := r.createRAGmeServiceDeployment(ragme, serviceName)
-	if err := ctrl.SetControllerReference(ragme, deployment, r.Scheme); err != nil {
+	if err := r.setOwner(ragme, deployment); err != nil {
 		return err
 	}
 
@@ -369,7 +675,9 @@ func (r *RAGmeReconciler) reconcileRAGmeService(ctx context.Context, ragme *ragm
 		}
 	} else if err == nil {
 		foundDeployment.Spec = deployment.Spec
-		if err := r.Update(ctx, foundDeployment); err != nil {
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
 			return err
 		}
 	}
@@ -377,7 +685,7 @@ func (r *RAGmeReconciler) reconcileRAGmeService(ctx context.Context, ragme *ragm
 	// Create service (except for agent which doesn't need a service)
 	if serviceName != "agent" {
 		service := r.createRAGmeService(ragme, serviceName)
-		if err := ctrl.SetControllerReference(ragme, service, r.Scheme); err != nil {
+		if err := r.setOwner(ragme, service); err != nil {
 			return err
 		}
 
@@ -427,10 +735,9 @@ func (r *RAGmeReconciler) createMinIODeployment(ragme *ragmev1.RAGme) *appsv1.De
 								{ContainerPort: 9000, Name: "api"},
 								{ContainerPort: 9001, Name: "console"},
 							},
-							Env: []corev1.EnvVar{
-								{Name: "MINIO_ROOT_USER", Value: ragme.Spec.Storage.MinIO.AccessKey},
-								{Name: "MINIO_ROOT_PASSWORD", Value: ragme.Spec.Storage.MinIO.SecretKey},
-							},
+							Env: append(minioRootCredentials(ragme).env("MINIO_ROOT_USER", "MINIO_ROOT_PASSWORD"),
+								corev1.EnvVar{Name: "MINIO_PROMETHEUS_AUTH_TYPE", Value: ragme.Spec.Storage.MinIO.Metrics.AuthType},
+							),
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "minio-data", MountPath: "/data"},
 							},
@@ -471,6 +778,17 @@ func (r *RAGmeReconciler) createMinIODeployment(ragme *ragmev1.RAGme) *appsv1.De
 		},
 	}
 
+	// Public metrics can be scraped through the standard Prometheus annotations
+	if ragme.Spec.Storage.MinIO.Metrics.Enabled && ragme.Spec.Storage.MinIO.Metrics.AuthType == "public" {
+		deployment.Spec.Template.Annotations = mergeStringMaps(deployment.Spec.Template.Annotations,
+			podScrapeAnnotations(ragme, 9000, minioMetricsPath))
+	}
+
+	applyTempStorage(&deployment.Spec.Template.Spec, componentSpec(ragme, "minio"))
+	applySidecars(&deployment.Spec.Template.Spec, componentSpec(ragme, "minio"))
+	applyAutoscalerAnnotations(ragme, "minio", &deployment.Spec.Template)
+	applyWorkloadAnnotations(ragme, deployment)
+
 	return deployment
 }
 
@@ -481,11 +799,17 @@ func (r *RAGmeReconciler) createMinIOService(ragme *ragmev1.RAGme) *corev1.Servi
 		"instance":  ragme.Name,
 	}
 
+	var annotations map[string]string
+	if ragme.Spec.Storage.MinIO.Metrics.Enabled && ragme.Spec.Storage.MinIO.Metrics.AuthType == "public" {
+		annotations = serviceScrapeAnnotations(ragme, 9000, minioMetricsPath)
+	}
+
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-minio", ragme.Name),
-			Namespace: ragme.Namespace,
-			Labels:    labels,
+			Name:        fmt.Sprintf("%s-minio", ragme.Name),
+			Namespace:   ragme.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
 		},
 		Spec: corev1.ServiceSpec{
 			Selector: labels,
@@ -498,21 +822,19 @@ func (r *RAGmeReconciler) createMinIOService(ragme *ragmev1.RAGme) *corev1.Servi
 	}
 }
 
-func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
-	labels := map[string]string{
-		"app":       "ragme",
-		"component": "weaviate",
-		"instance":  ragme.Name,
-	}
+func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme, shard int32) *appsv1.Deployment {
+	labels := weaviateLabels(ragme, shard)
 
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-weaviate", ragme.Name),
+			Name:      weaviateShardName(ragme, shard),
 			Namespace: ragme.Namespace,
 			Labels:    labels,
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: &[]int32{1}[0],
+			Replicas: &[]int32{weaviateReplicas(ragme)}[0],
+			// Weaviate owns its volume, the old pod must stop before the new one starts
+			Strategy: appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType},
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
@@ -524,7 +846,7 @@ func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme) *appsv1
 					Containers: []corev1.Container{
 						{
 							Name:  "weaviate",
-							Image: "cr.weaviate.io/semitechnologies/weaviate:1.25.0",
+							Image: fmt.Sprintf("%s:%s", weaviateImageRepo, weaviateVersion(ragme)),
 							Ports: []corev1.ContainerPort{
 								{ContainerPort: 8080, Name: "http"},
 							},
@@ -533,8 +855,9 @@ func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme) *appsv1
 								{Name: "AUTHENTICATION_ANONYMOUS_ACCESS_ENABLED", Value: "true"},
 								{Name: "PERSISTENCE_DATA_PATH", Value: "/var/lib/weaviate"},
 								{Name: "DEFAULT_VECTORIZER_MODULE", Value: "none"},
-								{Name: "ENABLE_MODULES", Value: "text2vec-openai,generative-openai"},
-								{Name: "CLUSTER_HOSTNAME", Value: "node1"},
+								{Name: "ENABLE_MODULES", Value: "text2vec-openai,generative-openai,backup-filesystem"},
+								{Name: "BACKUP_FILESYSTEM_PATH", Value: weaviateBackupPath},
+								{Name: "CLUSTER_HOSTNAME", Value: fmt.Sprintf("node%d", shard+1)},
 							},
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "weaviate-data", MountPath: "/var/lib/weaviate"},
@@ -546,7 +869,7 @@ func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme) *appsv1
 							Name: "weaviate-data",
 							VolumeSource: corev1.VolumeSource{
 								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-									ClaimName: fmt.Sprintf("%s-weaviate-pvc", ragme.Name),
+									ClaimName: weaviateShardName(ragme, shard) + "-pvc",
 								},
 							},
 						},
@@ -556,19 +879,20 @@ func (r *RAGmeReconciler) createWeaviateDeployment(ragme *ragmev1.RAGme) *appsv1
 		},
 	}
 
+	applyTempStorage(&deployment.Spec.Template.Spec, componentSpec(ragme, "weaviate"))
+	applySidecars(&deployment.Spec.Template.Spec, componentSpec(ragme, "weaviate"))
+	applyAutoscalerAnnotations(ragme, "weaviate", &deployment.Spec.Template)
+	applyWorkloadAnnotations(ragme, deployment)
+
 	return deployment
 }
 
-func (r *RAGmeReconciler) createWeaviateService(ragme *ragmev1.RAGme) *corev1.Service {
-	labels := map[string]string{
-		"app":       "ragme",
-		"component": "weaviate",
-		"instance":  ragme.Name,
-	}
+func (r *RAGmeReconciler) createWeaviateService(ragme *ragmev1.RAGme, shard int32) *corev1.Service {
+	labels := weaviateLabels(ragme, shard)
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s-weaviate", ragme.Name),
+			Name:      weaviateShardName(ragme, shard),
 			Namespace: ragme.Namespace,
 			Labels:    labels,
 		},
@@ -606,11 +930,19 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 		replicas = ragme.Spec.Replicas.Agent
 		port = 0 // No port for agent
 		image = fmt.Sprintf("%s/ragme-agent:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag)
+	case imageProcessorComponent:
+		replicas = imageWorkers(ragme)
+		image = fmt.Sprintf("%s/ragme-agent:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag)
+	case transcriberComponent:
+		replicas = transcriberWorkers(ragme)
+		image = fmt.Sprintf("%s/ragme-agent:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag)
 	case "frontend":
 		replicas = ragme.Spec.Replicas.Frontend
 		port = 8020
 		image = fmt.Sprintf("%s/ragme-frontend:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag)
 	}
+	replicas = maintenanceReplicas(ragme, serviceName, replicas)
+	replicas = hibernationReplicas(ragme, replicas)
 
 	envVars := []corev1.EnvVar{
 		{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
@@ -622,7 +954,7 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 		envVars = append(envVars, []corev1.EnvVar{
 			{Name: "GOOGLE_OAUTH_CLIENT_ID", Value: ragme.Spec.Authentication.OAuth.Google.ClientID},
 			{Name: "GOOGLE_OAUTH_CLIENT_SECRET", Value: ragme.Spec.Authentication.OAuth.Google.ClientSecret},
-			{Name: "GOOGLE_OAUTH_REDIRECT_URI", Value: ragme.Spec.Authentication.OAuth.Google.RedirectURI},
+			{Name: "GOOGLE_OAUTH_REDIRECT_URI", Value: oauthRedirectURI(ragme, "google")},
 		}...)
 	}
 
@@ -630,7 +962,7 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 		envVars = append(envVars, []corev1.EnvVar{
 			{Name: "GITHUB_OAUTH_CLIENT_ID", Value: ragme.Spec.Authentication.OAuth.GitHub.ClientID},
 			{Name: "GITHUB_OAUTH_CLIENT_SECRET", Value: ragme.Spec.Authentication.OAuth.GitHub.ClientSecret},
-			{Name: "GITHUB_OAUTH_REDIRECT_URI", Value: ragme.Spec.Authentication.OAuth.GitHub.RedirectURI},
+			{Name: "GITHUB_OAUTH_REDIRECT_URI", Value: oauthRedirectURI(ragme, "github")},
 		}...)
 	}
 
@@ -638,7 +970,7 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 		envVars = append(envVars, []corev1.EnvVar{
 			{Name: "APPLE_OAUTH_CLIENT_ID", Value: ragme.Spec.Authentication.OAuth.Apple.ClientID},
 			{Name: "APPLE_OAUTH_CLIENT_SECRET", Value: ragme.Spec.Authentication.OAuth.Apple.ClientSecret},
-			{Name: "APPLE_OAUTH_REDIRECT_URI", Value: ragme.Spec.Authentication.OAuth.Apple.RedirectURI},
+			{Name: "APPLE_OAUTH_REDIRECT_URI", Value: oauthRedirectURI(ragme, "apple")},
 		}...)
 	}
 
@@ -648,6 +980,7 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 			Name: "SESSION_SECRET_KEY", Value: ragme.Spec.Authentication.Session.SecretKey,
 		})
 	}
+	envVars = append(envVars, sessionStoreEnv(ragme)...)
 
 	container := corev1.Container{
 		Name:            serviceName,
@@ -727,6 +1060,59 @@ func (r *RAGmeReconciler) createRAGmeServiceDeployment(ragme *ragmev1.RAGme, ser
 		},
 	}
 
+	// The api enforces tenant quotas from the operator-rendered tenants config
+	if serviceName == "api" {
+		podSpec := &deployment.Spec.Template.Spec
+		mountVolume(podSpec, corev1.Volume{
+			Name: "tenants",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: fmt.Sprintf("%s-tenants", ragme.Name)},
+					Optional:             &[]bool{true}[0],
+				},
+			},
+		}, tenantsConfigMountPath)
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_TENANTS_CONFIG", Value: tenantsConfigMountPath + "/" + tenantsConfigKey,
+		})
+	}
+
+	applyServiceAuth(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyLDAP(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyAnonymousAccess(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyBootstrapAdmins(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyAuthorization(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyVolumeMonitor(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applySharding(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyStorageCredentials(ragme, serviceName, &deployment.Spec.Template)
+	applyFrontendAssets(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyStreaming(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyFeatureFlags(ragme, serviceName, &deployment.Spec.Template)
+	applyAgentProcessing(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyScanning(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyProcessing(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyLLM(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyVectorDB(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applySecretRefs(ragme, serviceName, &deployment.Spec.Template)
+	applyEmbeddingsCache(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyQueryCache(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyRetrieval(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyAdminPort(ragme, serviceName, &deployment.Spec.Template)
+	applyMaintenance(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyNotices(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyTracing(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyProfiling(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyReadReplicas(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyImageProcessing(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyMediaProcessing(ragme, serviceName, &deployment.Spec.Template.Spec)
+	applyEgressProxy(ragme, serviceName, &deployment.Spec.Template)
+	applyTermination(ragme, serviceName, &deployment.Spec.Template.Spec)
+
+	applyTempStorage(&deployment.Spec.Template.Spec, componentSpec(ragme, serviceName))
+	applySidecars(&deployment.Spec.Template.Spec, componentSpec(ragme, serviceName))
+	applyAutoscalerAnnotations(ragme, serviceName, &deployment.Spec.Template)
+	applyWorkloadAnnotations(ragme, deployment)
+
 	return deployment
 }
 
@@ -767,9 +1153,15 @@ func (r *RAGmeReconciler) createRAGmeService(ragme *ragmev1.RAGme, serviceName s
 func (r *RAGmeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&ragmev1.RAGme{}).
-		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.Deployment{}, builder.WithPredicates(workloadChanged)).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.PersistentVolumeClaim{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1.CronJob{}).
+		Owns(&networkingv1.Ingress{}).
+		Watches(&ragmev1.RAGmeTenant{}, handler.EnqueueRequestsFromMapFunc(r.instanceForTenant)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.instancesForSecret)).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.instancesForOperatorConfig)).
 		Complete(r)
 }
@@ -63,9 +63,11 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).ToNot(HaveOccurred())
 
+	// envtest has no nodes or StorageClasses to pass the preflight checks
 	err = (&RAGmeReconciler{
-		Client: k8sManager.GetClient(),
-		Scheme: k8sManager.GetScheme(),
+		Client:        k8sManager.GetClient(),
+		Scheme:        k8sManager.GetScheme(),
+		SkipPreflight: true,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -251,4 +253,26 @@ var _ = Describe("RAGme Controller", func() {
 			Expect(k8sClient.Delete(ctx, ragme)).Should(Succeed())
 		})
 	})
+
+	Context("When a RAGme spec breaks the CRD validation rules", func() {
+		It("Should be rejected by the API server", func() {
+			for name, mutate := range map[string]func(spec *ragmev1.RAGmeSpec){
+				"agents-without-coordination": func(spec *ragmev1.RAGmeSpec) { spec.Replicas.Agent = 2 },
+				"unparseable-storage-size":    func(spec *ragmev1.RAGmeSpec) { spec.Storage.MinIO.StorageSize = "10GB" },
+				"ingress-without-host":        func(spec *ragmev1.RAGmeSpec) { spec.ExternalAccess.Ingress.Enabled = true },
+			} {
+				By("Creating the " + name + " instance")
+				ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+				mutate(&ragme.Spec)
+				Expect(k8sClient.Create(ctx, ragme)).ShouldNot(Succeed())
+			}
+
+			By("Accepting coordinated agents")
+			ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "coordinated-agents", Namespace: "default"}}
+			ragme.Spec.Replicas.Agent = 2
+			ragme.Spec.Agent.Coordination.Enabled = true
+			Expect(k8sClient.Create(ctx, ragme)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, ragme)).Should(Succeed())
+		})
+	})
 })
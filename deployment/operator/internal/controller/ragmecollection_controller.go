@@ -0,0 +1,138 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const collectionFinalizer = "ragme.io/collection-finalizer"
+
+// RAGmeCollectionReconciler reconciles a RAGmeCollection object
+type RAGmeCollectionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// HTTPClient is used to talk to the vector database. Defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+
+	// Resync controls the periodic resync of reconciled resources
+	Resync ResyncConfig
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmecollections,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmecollections/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmecollections/finalizers,verbs=update
+
+// Reconcile creates or updates the collection in the vector database of the
+// referenced RAGme instance, and removes it when the resource is deleted.
+func (r *RAGmeCollectionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	collection := &ragmev1.RAGmeCollection{}
+	if err := r.Get(ctx, req.NamespacedName, collection); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeCollection resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeCollection")
+		return ctrl.Result{}, err
+	}
+
+	ragme := &ragmev1.RAGme{}
+	err := r.Get(ctx, types.NamespacedName{Name: collection.Spec.InstanceRef, Namespace: collection.Namespace}, ragme)
+	if err != nil && !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	instanceFound := err == nil
+
+	if !collection.DeletionTimestamp.IsZero() {
+		if controllerutil.ContainsFinalizer(collection, collectionFinalizer) {
+			// Without the instance there is no vector database left to clean up
+			if instanceFound {
+				if err := r.deleteCollection(ctx, ragme, collection); err != nil {
+					logger.Error(err, "Failed to delete collection from vector database")
+					return ctrl.Result{RequeueAfter: time.Minute}, err
+				}
+			}
+			controllerutil.RemoveFinalizer(collection, collectionFinalizer)
+			if err := r.Update(ctx, collection); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{}, nil
+	}
+
+	if !controllerutil.ContainsFinalizer(collection, collectionFinalizer) {
+		controllerutil.AddFinalizer(collection, collectionFinalizer)
+		if err := r.Update(ctx, collection); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if !instanceFound {
+		conditions.MarkReconciling(&collection.Status.Conditions, collection.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+collection.Spec.InstanceRef+" not found")
+		collection.Status.Phase = conditions.Phase(collection.Status.Conditions)
+		if err := r.Status().Update(ctx, collection); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	collection.Status.Backend = ragme.Spec.VectorDB.Type
+	vdb, err := newCollectionClient(defaultHTTPClient(r.HTTPClient), ragme)
+	if err == nil {
+		err = vdb.EnsureCollection(ctx, collection)
+	}
+	if err != nil {
+		logger.Error(err, "Failed to reconcile collection", "collection", collectionName(collection))
+		conditions.MarkDegraded(&collection.Status.Conditions, collection.Generation, conditions.ReasonSyncFailed, err.Error())
+		collection.Status.Phase = conditions.Phase(collection.Status.Conditions)
+		collection.Status.ObservedGeneration = collection.Generation
+		if statusErr := r.Status().Update(ctx, collection); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGmeCollection status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	collection.Status.ObservedGeneration = collection.Generation
+	conditions.MarkReady(&collection.Status.Conditions, collection.Generation, conditions.ReasonSynced,
+		"Collection is in sync with the vector database")
+	collection.Status.Phase = conditions.Phase(collection.Status.Conditions)
+	if err := r.Status().Update(ctx, collection); err != nil {
+		logger.Error(err, "Failed to update RAGmeCollection status")
+		return ctrl.Result{}, err
+	}
+
+	return r.Resync.Result(collection), nil
+}
+
+// deleteCollection removes the collection from the instance vector database
+func (r *RAGmeCollectionReconciler) deleteCollection(ctx context.Context, ragme *ragmev1.RAGme, collection *ragmev1.RAGmeCollection) error {
+	vdb, err := newCollectionClient(defaultHTTPClient(r.HTTPClient), ragme)
+	if err != nil {
+		// The vector database was reconfigured away, nothing to delete
+		log.FromContext(ctx).Info("Skipping collection cleanup", "reason", err.Error())
+		return nil
+	}
+	return vdb.DeleteCollection(ctx, collection)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeCollectionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeCollection{}).
+		Complete(r)
+}
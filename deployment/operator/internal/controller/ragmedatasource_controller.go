@@ -0,0 +1,596 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	dataSourceTypeCrawl = "crawl"
+
+	defaultDataSourceSchedule = "0 */6 * * *"
+
+	defaultCrawlMaxDepth          = 2
+	defaultCrawlMaxPages          = 1000
+	defaultCrawlRequestsPerSecond = 1.0
+	defaultCrawlUserAgent         = "RAGmeCrawler/1.0"
+
+	// dataSourceLabel records the data source a sync Job belongs to
+	dataSourceLabel = "datasource"
+
+	// syncSettingsHashAnnotation records the source settings a sync Job runs with
+	syncSettingsHashAnnotation = "ragme.io/sync-settings-hash"
+)
+
+// RAGmeDataSourceReconciler reconciles a RAGmeDataSource object
+type RAGmeDataSourceReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Resync controls the periodic resync of reconciled resources
+	Resync ResyncConfig
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmedatasources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmedatasources/status,verbs=get;update;patch
+
+// Reconcile runs the sync CronJob of the data source against the referenced
+// RAGme instance, and records the outcome of its last finished Job. The
+// syncs are paused while the instance hibernates or is under maintenance, and
+// connectors and mailboxes are not scheduled until their credentials are found.
+func (r *RAGmeDataSourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	source := &ragmev1.RAGmeDataSource{}
+	if err := r.Get(ctx, req.NamespacedName, source); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeDataSource resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeDataSource")
+		return ctrl.Result{}, err
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: source.Spec.InstanceRef, Namespace: source.Namespace}, ragme); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkReconciling(&source.Status.Conditions, source.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+source.Spec.InstanceRef+" not found")
+		source.Status.Phase = conditions.Phase(source.Status.Conditions)
+		if err := r.Status().Update(ctx, source); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if err := validateDataSource(source); err != nil {
+		conditions.MarkStalled(&source.Status.Conditions, source.Generation, conditions.ReasonValidationFailed, err.Error())
+		source.Status.Phase = conditions.Phase(source.Status.Conditions)
+		source.Status.ObservedGeneration = source.Generation
+		return ctrl.Result{}, r.Status().Update(ctx, source)
+	}
+
+	valid, err := r.checkSourceCredentials(ctx, source)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !valid {
+		conditions.MarkReconciling(&source.Status.Conditions, source.Generation, conditions.ReasonCredentialsMissing,
+			"Waiting for the credentials of the "+source.Spec.Type+" source")
+		source.Status.Phase = conditions.Phase(source.Status.Conditions)
+		source.Status.ObservedGeneration = source.Generation
+		if err := r.Status().Update(ctx, source); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if hash := syncSettingsHash(source); source.Status.Cursor != "" && source.Status.CursorSettingsHash != hash {
+		logger.Info("Source settings changed, the next sync starts over", "cursor", source.Status.Cursor)
+		source.Status.Cursor = ""
+		source.Status.CursorSettingsHash = ""
+	}
+
+	if err := r.reconcileSyncCronJob(ctx, ragme, source); err != nil {
+		logger.Error(err, "Failed to reconcile the sync CronJob")
+		conditions.MarkDegraded(&source.Status.Conditions, source.Generation, conditions.ReasonReconcileFailed, err.Error())
+		source.Status.Phase = conditions.Phase(source.Status.Conditions)
+		source.Status.ObservedGeneration = source.Generation
+		if statusErr := r.Status().Update(ctx, source); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGmeDataSource status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, err
+	}
+
+	if err := r.checkLastSync(ctx, source); err != nil {
+		// The stats are lost with the pod, the next sync reports them again
+		logger.Error(err, "Failed to read the outcome of the last sync")
+	}
+
+	source.Status.Phase = conditions.Phase(source.Status.Conditions)
+	source.Status.ObservedGeneration = source.Generation
+	if err := r.Status().Update(ctx, source); err != nil {
+		logger.Error(err, "Failed to update RAGmeDataSource status")
+		return ctrl.Result{}, err
+	}
+	return r.Resync.Result(source), nil
+}
+
+// validateDataSource checks the data source spec for settings the sync cannot run with
+func validateDataSource(source *ragmev1.RAGmeDataSource) error {
+	var errs []error
+	spec := source.Spec
+
+	if spec.Schedule != "" {
+		if _, err := parseCron(spec.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("schedule: %q: %w", spec.Schedule, err))
+		}
+	}
+	switch spec.Type {
+	case dataSourceTypeCrawl:
+		if spec.Crawl == nil {
+			errs = append(errs, fmt.Errorf("crawl: required with the %s type", dataSourceTypeCrawl))
+		}
+	case dataSourceTypeConfluence, dataSourceTypeSharePoint, dataSourceTypeGDrive:
+		errs = append(errs, validateConnector(spec.Type, spec.Connector)...)
+	case dataSourceTypeEmail:
+		errs = append(errs, validateEmail(spec.Email)...)
+	case dataSourceTypeGit:
+		errs = append(errs, validateGit(spec.Git)...)
+	default:
+		errs = append(errs, fmt.Errorf("type: unsupported type %q, use %s, %s, %s, %s, %s or %s", spec.Type, dataSourceTypeCrawl,
+			dataSourceTypeEmail, dataSourceTypeGit, dataSourceTypeConfluence, dataSourceTypeSharePoint, dataSourceTypeGDrive))
+	}
+	if spec.Crawl != nil && spec.Type != dataSourceTypeCrawl {
+		errs = append(errs, fmt.Errorf("crawl: only used with the %s type", dataSourceTypeCrawl))
+	}
+	if spec.Connector != nil && !isConnectorType(spec.Type) {
+		errs = append(errs, fmt.Errorf("connector: only used with the %s, %s and %s types",
+			dataSourceTypeConfluence, dataSourceTypeSharePoint, dataSourceTypeGDrive))
+	}
+	if spec.Email != nil && spec.Type != dataSourceTypeEmail {
+		errs = append(errs, fmt.Errorf("email: only used with the %s type", dataSourceTypeEmail))
+	}
+	if spec.Git != nil && spec.Type != dataSourceTypeGit {
+		errs = append(errs, fmt.Errorf("git: only used with the %s type", dataSourceTypeGit))
+	}
+
+	if crawl := spec.Crawl; crawl != nil {
+		if len(crawl.SeedURLs) == 0 {
+			errs = append(errs, fmt.Errorf("crawl.seedURLs: at least one URL is required"))
+		}
+		for i, seed := range crawl.SeedURLs {
+			if u, err := url.Parse(seed); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("crawl.seedURLs[%d]: %q must be an http(s) URL", i, seed))
+			}
+		}
+		if crawl.MaxDepth < 0 {
+			errs = append(errs, fmt.Errorf("crawl.maxDepth: %d must not be negative", crawl.MaxDepth))
+		}
+		if crawl.MaxPages < 0 {
+			errs = append(errs, fmt.Errorf("crawl.maxPages: %d must not be negative", crawl.MaxPages))
+		}
+		for i, pattern := range crawl.IncludePatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("crawl.includePatterns[%d]: %w", i, err))
+			}
+		}
+		for i, pattern := range crawl.ExcludePatterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("crawl.excludePatterns[%d]: %w", i, err))
+			}
+		}
+		if rate := crawl.RequestsPerSecond; rate != "" {
+			if parsed, err := strconv.ParseFloat(rate, 64); err != nil || parsed <= 0 {
+				errs = append(errs, fmt.Errorf("crawl.requestsPerSecond: %q must be a positive number", rate))
+			}
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// dataSourceCronJobName returns the name of the sync CronJob of a data source
+func dataSourceCronJobName(source *ragmev1.RAGmeDataSource) string {
+	return fmt.Sprintf("%s-%s", source.Name, source.Spec.Type)
+}
+
+// syncSettingsHash returns the hash of the settings selecting what a source
+// syncs. The schedule and the suspension do not change it.
+func syncSettingsHash(source *ragmev1.RAGmeDataSource) string {
+	settings, _ := json.Marshal([]interface{}{source.Spec.Type, source.Spec.Crawl, source.Spec.Connector,
+		source.Spec.Email, source.Spec.Git})
+	sum := sha256.Sum256(settings)
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// dataSourceLabels returns the labels of the sync CronJob and its Jobs
+func dataSourceLabels(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) map[string]string {
+	return map[string]string{
+		"app":           "ragme",
+		"component":     source.Spec.Type,
+		"instance":      ragme.Name,
+		dataSourceLabel: source.Name,
+	}
+}
+
+// crawlConfig is the configuration of a crawl, rendered into the crawler environment
+type crawlConfig struct {
+	SeedURLs          []string `json:"seedURLs"`
+	MaxDepth          int32    `json:"maxDepth"`
+	MaxPages          int32    `json:"maxPages"`
+	AllowedDomains    []string `json:"allowedDomains"`
+	IncludePatterns   []string `json:"includePatterns,omitempty"`
+	ExcludePatterns   []string `json:"excludePatterns,omitempty"`
+	RequestsPerSecond float64  `json:"requestsPerSecond"`
+	RespectRobotsTxt  bool     `json:"respectRobotsTxt"`
+	UserAgent         string   `json:"userAgent"`
+}
+
+// renderCrawlConfig returns the crawl configuration with its defaults applied.
+// Links are followed within the domains of the seed URLs unless domains are set.
+func renderCrawlConfig(crawl *ragmev1.RAGmeCrawlSource) crawlConfig {
+	config := crawlConfig{
+		SeedURLs:          crawl.SeedURLs,
+		MaxDepth:          defaultCrawlMaxDepth,
+		MaxPages:          defaultCrawlMaxPages,
+		AllowedDomains:    crawl.AllowedDomains,
+		IncludePatterns:   crawl.IncludePatterns,
+		ExcludePatterns:   crawl.ExcludePatterns,
+		RequestsPerSecond: defaultCrawlRequestsPerSecond,
+		RespectRobotsTxt:  crawl.RespectRobotsTxt == nil || *crawl.RespectRobotsTxt,
+		UserAgent:         defaultCrawlUserAgent,
+	}
+	if crawl.MaxDepth > 0 {
+		config.MaxDepth = crawl.MaxDepth
+	}
+	if crawl.MaxPages > 0 {
+		config.MaxPages = crawl.MaxPages
+	}
+	if rate, err := strconv.ParseFloat(crawl.RequestsPerSecond, 64); err == nil && rate > 0 {
+		config.RequestsPerSecond = rate
+	}
+	if crawl.UserAgent != "" {
+		config.UserAgent = crawl.UserAgent
+	}
+	if len(config.AllowedDomains) == 0 {
+		seen := map[string]bool{}
+		for _, seed := range crawl.SeedURLs {
+			if u, err := url.Parse(seed); err == nil && u.Hostname() != "" && !seen[u.Hostname()] {
+				seen[u.Hostname()] = true
+				config.AllowedDomains = append(config.AllowedDomains, u.Hostname())
+			}
+		}
+	}
+	return config
+}
+
+// crawlerContainer returns the crawler of a crawl source. It submits the
+// pages to the api and writes its stats as a JSON object to its termination message.
+func crawlerContainer(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) corev1.Container {
+	config, _ := json.Marshal(renderCrawlConfig(source.Spec.Crawl))
+	return corev1.Container{
+		Name:            "crawler",
+		Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+		ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+		Command:         []string{"python", "-m", "src.ragme.crawler"},
+		Env: []corev1.EnvVar{
+			{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+			{Name: "CRAWL_CONFIG", Value: string(config)},
+			{Name: "CRAWL_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+		},
+		TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+	}
+}
+
+// createSyncCronJob returns the CronJob syncing a data source: the crawler of
+// a crawl source, or the sync worker of a mailbox, a repository or a
+// connector. Syncs never overlap, so a site is not crawled, or a cursor
+// advanced, twice at once.
+func createSyncCronJob(ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) *batchv1.CronJob {
+	labels := dataSourceLabels(ragme, source)
+
+	schedule := source.Spec.Schedule
+	if schedule == "" {
+		schedule = defaultDataSourceSchedule
+	}
+	podSpec := corev1.PodSpec{RestartPolicy: corev1.RestartPolicyNever}
+	switch {
+	case isConnectorType(source.Spec.Type):
+		podSpec.Containers = []corev1.Container{connectorContainer(ragme, source)}
+	case source.Spec.Type == dataSourceTypeEmail:
+		podSpec.Containers = []corev1.Container{mailContainer(ragme, source)}
+	case source.Spec.Type == dataSourceTypeGit:
+		podSpec.Containers = []corev1.Container{gitContainer(ragme, source)}
+		podSpec.Volumes = gitVolumes()
+	default:
+		podSpec.Containers = []corev1.Container{crawlerContainer(ragme, source)}
+	}
+	if secretName, _ := sourceCredentials(source); secretName != "" {
+		podSpec.Volumes = append(podSpec.Volumes, credentialsVolumes(secretName)...)
+	}
+	// Nothing is ingested while the instance hibernates or is under maintenance
+	suspend := source.Spec.Suspend || ragme.Status.Hibernation.Hibernated || maintenanceReplicas(ragme, "agent", 1) == 0
+
+	succeeded, failed := jobHistoryLimits(ragme)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      dataSourceCronJobName(source),
+			Namespace: source.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			Suspend:                    &suspend,
+			SuccessfulJobsHistoryLimit: &[]int32{int32(succeeded)}[0],
+			FailedJobsHistoryLimit:     &[]int32{int32(failed)}[0],
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      labels,
+					Annotations: map[string]string{syncSettingsHashAnnotation: syncSettingsHash(source)},
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:            &[]int32{1}[0],
+					TTLSecondsAfterFinished: jobTTL(ragme),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: podSpec,
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &cronJob.Spec.JobTemplate.Spec.Template)
+	return cronJob
+}
+
+// reconcileSyncCronJob creates or updates the sync CronJob of the data source
+func (r *RAGmeDataSourceReconciler) reconcileSyncCronJob(ctx context.Context, ragme *ragmev1.RAGme, source *ragmev1.RAGmeDataSource) error {
+	cronJob := createSyncCronJob(ragme, source)
+	if err := ctrl.SetControllerReference(source, cronJob, r.Scheme); err != nil {
+		return err
+	}
+	applyOwnershipLabels(ragme, cronJob)
+
+	found := &batchv1.CronJob{}
+	err := r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		err = r.Create(ctx, cronJob)
+	} else if err == nil {
+		found.Spec = cronJob.Spec
+		found.Labels = mergeStringMaps(found.Labels, cronJob.Labels)
+		err = r.Update(ctx, found)
+	}
+	if err != nil {
+		return err
+	}
+	source.Status.CronJob = cronJob.Name
+	return nil
+}
+
+// checkLastSync records the outcome and the stats of the last finished sync
+// Job, and sets the Ready condition from it
+func (r *RAGmeDataSourceReconciler) checkLastSync(ctx context.Context, source *ragmev1.RAGmeDataSource) error {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(source.Namespace), client.MatchingLabels{
+		"app":           "ragme",
+		dataSourceLabel: source.Name,
+	}); err != nil {
+		return err
+	}
+	var last *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if job.Status.Succeeded == 0 && !jobFailed(job) {
+			continue
+		}
+		if last == nil || last.CreationTimestamp.Before(&job.CreationTimestamp) {
+			last = job
+		}
+	}
+
+	if last == nil {
+		if source.Status.LastJob == "" {
+			conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonReconciled,
+				fmt.Sprintf("CronJob %s is scheduled, no sync has finished yet", source.Status.CronJob))
+		}
+		return nil
+	}
+	if last.Name == source.Status.LastJob {
+		return nil
+	}
+
+	finishedAt := metav1.Now()
+	if last.Status.CompletionTime != nil {
+		finishedAt = *last.Status.CompletionTime
+	}
+	source.Status.LastJob = last.Name
+	source.Status.LastSyncTime = &finishedAt
+	if jobFailed(last) {
+		conditions.MarkDegraded(&source.Status.Conditions, source.Generation, conditions.ReasonSyncFailed,
+			fmt.Sprintf("Sync failed, see the logs of job %s", last.Name))
+		return nil
+	}
+
+	source.Status.LastSuccessfulSyncTime = &finishedAt
+	message, err := r.syncStats(ctx, last)
+	if err == nil {
+		err = recordSyncStats(source, last.Name, message)
+	}
+	if source.Status.Cursor != "" {
+		// A Job started before the settings changed records a stale hash, so
+		// its cursor is discarded on the next reconcile
+		source.Status.CursorSettingsHash = last.Annotations[syncSettingsHashAnnotation]
+	}
+	if err != nil {
+		conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonSynced,
+			fmt.Sprintf("Job %s synced the source", last.Name))
+		return fmt.Errorf("failed to read the stats of job %s: %w", last.Name, err)
+	}
+	return nil
+}
+
+// recordSyncStats records the stats of a successful sync in the status of the
+// data source, and the cursor a connector, a mailbox or a repository resumes
+// from on the next sync
+func recordSyncStats(source *ragmev1.RAGmeDataSource, job, message string) error {
+	if source.Spec.Type == dataSourceTypeGit {
+		stats, err := parseGitStats(message)
+		if err != nil {
+			return err
+		}
+		source.Status.Git = stats
+		if stats.Commit != "" {
+			source.Status.Cursor = stats.Commit
+		}
+		if stats.Errors > 0 {
+			conditions.MarkDegraded(&source.Status.Conditions, source.Generation, conditions.ReasonSyncFailed,
+				fmt.Sprintf("Job %s failed to ingest %d files of commit %s, see its logs", job, stats.Errors, stats.Commit))
+			return nil
+		}
+		conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonSynced,
+			fmt.Sprintf("Job %s synced commit %s, ingested %d and deleted %d files", job,
+				stats.Commit, stats.FilesIngested, stats.FilesDeleted))
+		return nil
+	}
+	if source.Spec.Type == dataSourceTypeEmail {
+		stats, cursor, err := parseEmailStats(message)
+		if err != nil {
+			return err
+		}
+		source.Status.Email = stats
+		if cursor != "" {
+			source.Status.Cursor = cursor
+		}
+		if stats.Errors > 0 {
+			conditions.MarkDegraded(&source.Status.Conditions, source.Generation, conditions.ReasonSyncFailed,
+				fmt.Sprintf("Job %s failed to ingest %d messages or attachments, see its logs", job, stats.Errors))
+			return nil
+		}
+		conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonSynced,
+			fmt.Sprintf("Job %s ingested %d bodies and %d attachments of %d messages", job,
+				stats.BodiesIngested, stats.AttachmentsIngested, stats.MessagesProcessed))
+		return nil
+	}
+	if isConnectorType(source.Spec.Type) {
+		stats, cursor, err := parseConnectorStats(message)
+		if err != nil {
+			return err
+		}
+		source.Status.Connector = stats
+		if cursor != "" {
+			source.Status.Cursor = cursor
+		}
+		if stats.Errors > 0 {
+			// Unlike a crawl hitting dead links, a document of the system
+			// failing to sync is missing from the instance until fixed
+			conditions.MarkDegraded(&source.Status.Conditions, source.Generation, conditions.ReasonSyncFailed,
+				fmt.Sprintf("Job %s failed to sync %d documents, see its logs", job, stats.Errors))
+			return nil
+		}
+		conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonSynced,
+			fmt.Sprintf("Job %s added %d, updated %d and deleted %d documents", job,
+				stats.DocumentsAdded, stats.DocumentsUpdated, stats.DocumentsDeleted))
+		return nil
+	}
+
+	stats, err := parseCrawlStats(message)
+	if err != nil {
+		return err
+	}
+	source.Status.Crawl = stats
+	conditions.MarkReady(&source.Status.Conditions, source.Generation, conditions.ReasonSynced,
+		fmt.Sprintf("Job %s ingested %d of %d pages fetched", job, stats.PagesIngested, stats.PagesFetched))
+	return nil
+}
+
+// syncStats reads the stats a sync Job wrote to the termination message of its container
+func (r *RAGmeDataSourceReconciler) syncStats(ctx context.Context, job *batchv1.Job) (string, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil {
+				return status.State.Terminated.Message, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no succeeded pod found")
+}
+
+// crawlReport is the JSON object of the stats the crawler writes when it finishes
+type crawlReport struct {
+	PagesFetched     int64   `json:"pagesFetched"`
+	PagesIngested    int64   `json:"pagesIngested"`
+	PagesSkipped     int64   `json:"pagesSkipped"`
+	RobotsDisallowed int64   `json:"robotsDisallowed"`
+	Errors           int64   `json:"errors"`
+	DurationSeconds  float64 `json:"durationSeconds"`
+}
+
+// parseCrawlStats parses the stats of a crawl
+func parseCrawlStats(message string) (ragmev1.RAGmeCrawlStats, error) {
+	report := crawlReport{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return ragmev1.RAGmeCrawlStats{}, fmt.Errorf("invalid crawl stats %q: %w", message, err)
+	}
+	return ragmev1.RAGmeCrawlStats{
+		PagesFetched:     report.PagesFetched,
+		PagesIngested:    report.PagesIngested,
+		PagesSkipped:     report.PagesSkipped,
+		RobotsDisallowed: report.RobotsDisallowed,
+		Errors:           report.Errors,
+		Duration:         (time.Duration(report.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+	}, nil
+}
+
+// dataSourceForJob maps a sync Job, created by the CronJob and so not owned
+// by the data source, to its data source
+func dataSourceForJob(_ context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[dataSourceLabel]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeDataSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeDataSource{}).
+		Owns(&batchv1.CronJob{}).
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(dataSourceForJob)).
+		Complete(r)
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func crawlDataSource() *ragmev1.RAGmeDataSource {
+	return &ragmev1.RAGmeDataSource{
+		ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: "ragme"},
+		Spec: ragmev1.RAGmeDataSourceSpec{
+			InstanceRef: "test",
+			Type:        dataSourceTypeCrawl,
+			Crawl: &ragmev1.RAGmeCrawlSource{
+				SeedURLs: []string{"https://docs.example.com/start", "https://blog.example.com/"},
+			},
+		},
+	}
+}
+
+func confluenceDataSource() *ragmev1.RAGmeDataSource {
+	source := crawlDataSource()
+	source.Spec.Type = dataSourceTypeConfluence
+	source.Spec.Crawl = nil
+	source.Spec.Connector = &ragmev1.RAGmeConnectorSource{
+		URL:                  "https://example.atlassian.net/wiki",
+		CredentialsSecretRef: corev1.LocalObjectReference{Name: "confluence-credentials"},
+		Spaces:               []string{"ENG"},
+	}
+	return source
+}
+
+func TestValidateDataSource(t *testing.T) {
+	source := crawlDataSource()
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+
+	source.Spec.Schedule = "every day"
+	source.Spec.Crawl.SeedURLs = []string{"ftp://example.com"}
+	source.Spec.Crawl.ExcludePatterns = []string{"("}
+	source.Spec.Crawl.RequestsPerSecond = "0"
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an invalid schedule, seed URL, pattern and rate")
+	}
+
+	source = crawlDataSource()
+	source.Spec.Crawl = nil
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted a crawl source without crawl settings")
+	}
+
+	source = confluenceDataSource()
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+	source.Spec.Connector.URL = "http://wiki"
+	source.Spec.Connector.Folders = []string{"Shared"}
+	source.Spec.Connector.CredentialsSecretRef.Name = ""
+	if err := validateDataSource(source); err == nil {
+		t.Error("validateDataSource() accepted an insecure URL, folders and no credentials")
+	}
+
+	source = confluenceDataSource()
+	source.Spec.Type = dataSourceTypeGDrive
+	source.Spec.Connector = &ragmev1.RAGmeConnectorSource{
+		CredentialsSecretRef: corev1.LocalObjectReference{Name: "drive"},
+		Folders:              []string{"1AbC"},
+	}
+	if err := validateDataSource(source); err != nil {
+		t.Errorf("validateDataSource() error = %v", err)
+	}
+}
+
+func TestRenderCrawlConfig(t *testing.T) {
+	config := renderCrawlConfig(crawlDataSource().Spec.Crawl)
+	if config.MaxDepth != defaultCrawlMaxDepth || config.MaxPages != defaultCrawlMaxPages ||
+		config.RequestsPerSecond != defaultCrawlRequestsPerSecond || !config.RespectRobotsTxt {
+		t.Errorf("config = %+v, want the polite defaults", config)
+	}
+	if len(config.AllowedDomains) != 2 || config.AllowedDomains[0] != "docs.example.com" {
+		t.Errorf("allowed domains = %v, want the domains of the seed URLs", config.AllowedDomains)
+	}
+
+	respect := false
+	config = renderCrawlConfig(&ragmev1.RAGmeCrawlSource{
+		SeedURLs:          []string{"https://example.com"},
+		AllowedDomains:    []string{"example.com", "cdn.example.com"},
+		RequestsPerSecond: "0.5",
+		RespectRobotsTxt:  &respect,
+	})
+	if config.RequestsPerSecond != 0.5 || config.RespectRobotsTxt || len(config.AllowedDomains) != 2 {
+		t.Errorf("config = %+v, want the configured politeness", config)
+	}
+}
+
+func TestCreateCrawlCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	source := crawlDataSource()
+
+	cronJob := createSyncCronJob(ragme, source)
+	if cronJob.Name != "docs-crawl" || cronJob.Spec.Schedule != defaultDataSourceSchedule || *cronJob.Spec.Suspend {
+		t.Errorf("CronJob %s %q suspended=%v, want docs-crawl on the default schedule", cronJob.Name, cronJob.Spec.Schedule, *cronJob.Spec.Suspend)
+	}
+	if labels := cronJob.Spec.JobTemplate.Labels; labels[dataSourceLabel] != "docs" || labels["instance"] != "test" {
+		t.Errorf("Job labels = %v, want the data source and the instance", labels)
+	}
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	config := crawlConfig{}
+	if err := json.Unmarshal([]byte(envValue(container.Env, "CRAWL_CONFIG")), &config); err != nil || len(config.SeedURLs) != 2 {
+		t.Errorf("CRAWL_CONFIG = %q, want the rendered crawl", envValue(container.Env, "CRAWL_CONFIG"))
+	}
+
+	ragme.Spec.MaintenanceMode = maintenanceReadOnly
+	if cronJob := createSyncCronJob(ragme, source); !*cronJob.Spec.Suspend {
+		t.Error("expected the crawls to be suspended during maintenance")
+	}
+}
+
+func TestCreateConnectorCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	source := confluenceDataSource()
+	source.Status.Cursor = "2026-10-01T08:00:00Z"
+
+	cronJob := createSyncCronJob(ragme, source)
+	if cronJob.Name != "docs-confluence" {
+		t.Errorf("CronJob %s, want docs-confluence", cronJob.Name)
+	}
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container := podSpec.Containers[0]
+	for name, want := range map[string]string{
+		"CONNECTOR_TYPE":   dataSourceTypeConfluence,
+		"CONNECTOR_SPACES": "ENG",
+		"CONNECTOR_CURSOR": "2026-10-01T08:00:00Z",
+	} {
+		if got := envValue(container.Env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if len(podSpec.Volumes) != 1 || podSpec.Volumes[0].Secret.SecretName != "confluence-credentials" {
+		t.Errorf("volumes = %+v, want the credentials Secret", podSpec.Volumes)
+	}
+}
+
+func TestMissingCredentialKeys(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{"clientId": []byte("id"), "clientSecret": {}}}
+	missing := missingCredentialKeys(connectorCredentialKeys[dataSourceTypeSharePoint], secret)
+	if len(missing) != 2 || missing[0] != "clientSecret" || missing[1] != "tenantId" {
+		t.Errorf("missingCredentialKeys() = %v, want [clientSecret tenantId]", missing)
+	}
+}
+
+func TestRecordSyncStats(t *testing.T) {
+	source := confluenceDataSource()
+	err := recordSyncStats(source, "docs-confluence-1", `{"documentsAdded":3,"documentsUpdated":5,"documentsDeleted":1,"durationSeconds":12,"cursor":"c2"}`)
+	if err != nil {
+		t.Fatalf("recordSyncStats() error = %v", err)
+	}
+	want := ragmev1.RAGmeConnectorStats{DocumentsAdded: 3, DocumentsUpdated: 5, DocumentsDeleted: 1, Duration: "12s"}
+	if source.Status.Connector != want || source.Status.Cursor != "c2" {
+		t.Errorf("status = %+v, want %+v at cursor c2", source.Status, want)
+	}
+	if phase := conditions.Phase(source.Status.Conditions); phase != conditions.PhaseReady {
+		t.Errorf("phase = %s, want Ready", phase)
+	}
+
+	if err := recordSyncStats(source, "docs-confluence-2", `{"errors":2}`); err != nil {
+		t.Fatalf("recordSyncStats() error = %v", err)
+	}
+	if source.Status.Cursor != "c2" || !conditions.IsTrue(source.Status.Conditions, conditions.TypeDegraded) {
+		t.Errorf("status = %+v, want the cursor kept and the source degraded", source.Status)
+	}
+}
+
+func TestParseCrawlStats(t *testing.T) {
+	stats, err := parseCrawlStats(`{"pagesFetched":12,"pagesIngested":10,"pagesSkipped":4,"robotsDisallowed":1,"errors":2,"durationSeconds":75.4}`)
+	if err != nil {
+		t.Fatalf("parseCrawlStats() error = %v", err)
+	}
+	want := ragmev1.RAGmeCrawlStats{PagesFetched: 12, PagesIngested: 10, PagesSkipped: 4, RobotsDisallowed: 1, Errors: 2, Duration: "1m15s"}
+	if stats != want {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if _, err := parseCrawlStats("crawl failed"); err == nil {
+		t.Error("parseCrawlStats() accepted a message that is not JSON")
+	}
+}
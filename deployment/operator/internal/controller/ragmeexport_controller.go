@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	exportComponent = "export"
+
+	exportFormatJSONL   = "jsonl"
+	exportFormatParquet = "parquet"
+
+	defaultExportBucket = "ragme-exports"
+
+	// exportLabel records the export a Job belongs to
+	exportLabel = "export"
+
+	// exportRequeue is the interval of the checks of a running export
+	exportRequeue = 30 * time.Second
+)
+
+// RAGmeExportReconciler reconciles a RAGmeExport object
+type RAGmeExportReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmeexports,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmeexports/status,verbs=get;update;patch
+
+// Reconcile runs the export Job of the current spec generation and records the
+// archive it wrote once it finished. An export runs once per generation, and
+// waits while the referenced instance hibernates since its api does the reads.
+func (r *RAGmeExportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	export := &ragmev1.RAGmeExport{}
+	if err := r.Get(ctx, req.NamespacedName, export); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeExport resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeExport")
+		return ctrl.Result{}, err
+	}
+	if export.Status.ObservedGeneration == export.Generation && export.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: export.Spec.InstanceRef, Namespace: export.Namespace}, ragme); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkReconciling(&export.Status.Conditions, export.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+export.Spec.InstanceRef+" not found")
+		export.Status.Phase = conditions.Phase(export.Status.Conditions)
+		if err := r.Status().Update(ctx, export); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if err := validateExport(export, ragme); err != nil {
+		conditions.MarkStalled(&export.Status.Conditions, export.Generation, conditions.ReasonValidationFailed, err.Error())
+		export.Status.Phase = conditions.Phase(export.Status.Conditions)
+		export.Status.ObservedGeneration = export.Generation
+		return ctrl.Result{}, r.Status().Update(ctx, export)
+	}
+
+	if ragme.Status.Hibernation.Hibernated && export.Status.Job == "" {
+		conditions.MarkReconciling(&export.Status.Conditions, export.Generation, conditions.ReasonWaitingForReady,
+			"Waiting for RAGme instance "+ragme.Name+" to wake up")
+		export.Status.Phase = conditions.Phase(export.Status.Conditions)
+		export.Status.ObservedGeneration = export.Generation
+		if err := r.Status().Update(ctx, export); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
+	done, err := r.runExport(ctx, ragme, export)
+	if err != nil {
+		logger.Error(err, "Failed to run export")
+		conditions.MarkDegraded(&export.Status.Conditions, export.Generation, conditions.ReasonReconcileFailed, err.Error())
+		export.Status.Phase = conditions.Phase(export.Status.Conditions)
+		export.Status.ObservedGeneration = export.Generation
+		if statusErr := r.Status().Update(ctx, export); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGmeExport status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, err
+	}
+
+	export.Status.Phase = conditions.Phase(export.Status.Conditions)
+	export.Status.ObservedGeneration = export.Generation
+	if err := r.Status().Update(ctx, export); err != nil {
+		logger.Error(err, "Failed to update RAGmeExport status")
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: exportRequeue}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// validateExport checks the export spec for settings the Job cannot run with
+func validateExport(export *ragmev1.RAGmeExport, ragme *ragmev1.RAGme) error {
+	var errs []error
+	spec := export.Spec
+	destination := spec.Destination
+
+	for i, collection := range spec.Collections {
+		if strings.TrimSpace(collection) == "" {
+			errs = append(errs, fmt.Errorf("collections[%d]: must not be empty", i))
+		}
+	}
+	switch spec.Format {
+	case "", exportFormatJSONL, exportFormatParquet:
+	default:
+		errs = append(errs, fmt.Errorf("format: unsupported format %q, use %s or %s", spec.Format, exportFormatJSONL, exportFormatParquet))
+	}
+
+	if destination.Endpoint != "" {
+		if u, err := url.Parse(destination.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("destination.endpoint: %q must be an http(s) URL", destination.Endpoint))
+		}
+		if destination.CredentialsSecretRef == nil || destination.CredentialsSecretRef.Name == "" {
+			errs = append(errs, fmt.Errorf("destination.credentialsSecretRef: required with an external endpoint"))
+		}
+	} else if objectStorageProviderName(ragme) == objectStorageMinIO && !ragme.Spec.Storage.MinIO.Enabled {
+		errs = append(errs, fmt.Errorf("destination.endpoint: required, RAGme instance %s has no object storage", ragme.Name))
+	}
+	if strings.HasPrefix(destination.Prefix, "/") {
+		errs = append(errs, fmt.Errorf("destination.prefix: %q must not start with /", destination.Prefix))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// exportJobName returns the name of the Job of the current generation
+func exportJobName(export *ragmev1.RAGmeExport) string {
+	return fmt.Sprintf("%s-%d", export.Name, export.Generation)
+}
+
+// exportLocation returns the bucket and the prefix of the archive
+func exportLocation(export *ragmev1.RAGmeExport) (string, string) {
+	bucket := export.Spec.Destination.Bucket
+	if bucket == "" {
+		bucket = defaultExportBucket
+	}
+	prefix := export.Spec.Destination.Prefix
+	if prefix == "" {
+		prefix = export.Name + "/"
+	}
+	return bucket, prefix
+}
+
+// runExport creates the Job of the current generation, then records the
+// archive once it finished. It reports whether the export is complete.
+func (r *RAGmeExportReconciler) runExport(ctx context.Context, ragme *ragmev1.RAGme, export *ragmev1.RAGmeExport) (bool, error) {
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: exportJobName(export), Namespace: export.Namespace}, job)
+	if err != nil && errors.IsNotFound(err) {
+		job = createExportJob(ragme, export)
+		if err := ctrl.SetControllerReference(export, job, r.Scheme); err != nil {
+			return false, err
+		}
+		applyOwnershipLabels(ragme, job)
+		if err := r.Create(ctx, job); err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	}
+
+	if export.Status.Job != job.Name {
+		startTime := job.CreationTimestamp
+		if startTime.IsZero() {
+			startTime = metav1.Now()
+		}
+		export.Status = ragmev1.RAGmeExportStatus{
+			Job:        job.Name,
+			StartTime:  &startTime,
+			Conditions: export.Status.Conditions,
+		}
+		conditions.MarkReconciling(&export.Status.Conditions, export.Generation, conditions.ReasonProgressing,
+			fmt.Sprintf("Export Job %s is running", job.Name))
+	}
+	if job.Status.Succeeded == 0 && !jobFailed(job) {
+		return false, nil
+	}
+
+	export.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+	if job.Status.CompletionTime != nil {
+		export.Status.CompletionTime = job.Status.CompletionTime
+	}
+	if jobFailed(job) {
+		conditions.MarkStalled(&export.Status.Conditions, export.Generation, conditions.ReasonExportFailed,
+			fmt.Sprintf("Export Job %s failed", job.Name))
+		return true, nil
+	}
+
+	message, err := jobTerminationMessage(ctx, r, job, exportComponent)
+	if err == nil {
+		err = recordExportStats(export, message)
+	}
+	if err != nil {
+		// The archive is written, only its statistics are lost with the pod
+		bucket, prefix := exportLocation(export)
+		export.Status.Archive = fmt.Sprintf("s3://%s/%s", bucket, prefix)
+		log.FromContext(ctx).Error(err, "Failed to read the statistics of the export", "job", job.Name)
+	}
+	conditions.MarkReady(&export.Status.Conditions, export.Generation, conditions.ReasonExportSucceeded,
+		fmt.Sprintf("Exported %d documents to %s", export.Status.Documents, export.Status.Archive))
+	return true, nil
+}
+
+// createExportJob returns the Job exporting the collections. The export asks
+// the api, which owns the vector database, for the documents, their metadata
+// and embeddings, writes one file per collection and a manifest under the
+// prefix, and writes its statistics as a JSON object to its termination
+// message. A retried export overwrites the files of the failed attempt.
+func createExportJob(ragme *ragmev1.RAGme, export *ragmev1.RAGmeExport) *batchv1.Job {
+	labels := mergeStringMaps(componentLabels(ragme, exportComponent), map[string]string{exportLabel: export.Name})
+
+	format := export.Spec.Format
+	if format == "" {
+		format = exportFormatJSONL
+	}
+	bucket, prefix := exportLocation(export)
+	env := []corev1.EnvVar{
+		{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+		{Name: "EXPORT_COLLECTIONS", Value: strings.Join(export.Spec.Collections, ",")},
+		{Name: "EXPORT_FORMAT", Value: format},
+		{Name: "EXPORT_INCLUDE_EMBEDDINGS", Value: strconv.FormatBool(enabledOrDefault(export.Spec.IncludeEmbeddings))},
+		{Name: "EXPORT_BUCKET", Value: bucket},
+		{Name: "EXPORT_PREFIX", Value: prefix},
+		{Name: "EXPORT_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+	}
+	env = append(env, exportStorageEnv(ragme, export)...)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      exportJobName(export),
+			Namespace: export.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{1}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:                     exportComponent,
+							Image:                    fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+							ImagePullPolicy:          corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+							Command:                  []string{"python", "-m", "src.ragme.export"},
+							Env:                      env,
+							TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
+
+// exportStorageEnv returns the endpoint and credentials of the destination.
+// The object storage of the instance is written with the credentials of the
+// api, which may write the ragme-* buckets.
+func exportStorageEnv(ragme *ragmev1.RAGme, export *ragmev1.RAGmeExport) []corev1.EnvVar {
+	destination := export.Spec.Destination
+	env := []corev1.EnvVar{
+		{Name: "EXPORT_ENDPOINT", Value: destination.Endpoint},
+		{Name: "EXPORT_REGION", Value: destination.Region},
+	}
+	credentials := secretCredentials(destination.CredentialsSecretRef)
+	if destination.Endpoint == "" {
+		storage := newObjectStorageProvider(nil, ragme)
+		env[0].Value = storage.Endpoint(ragme)
+		if storage.Name() != objectStorageMinIO && destination.Region == "" {
+			env[1].Value = objectStorageRegion(ragme)
+		}
+		credentials = storage.Credentials(ragme, "api")
+	}
+	return append(env, credentials.env("EXPORT_ACCESS_KEY", "EXPORT_SECRET_KEY")...)
+}
+
+// recordExportStats records the archive and the documents an export wrote
+func recordExportStats(export *ragmev1.RAGmeExport, message string) error {
+	report := struct {
+		Archive     string                            `json:"archive"`
+		Collections []ragmev1.RAGmeExportedCollection `json:"collections"`
+		Bytes       int64                             `json:"bytes"`
+	}{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return fmt.Errorf("invalid export stats %q: %w", message, err)
+	}
+	export.Status.Archive = report.Archive
+	export.Status.Collections = report.Collections
+	export.Status.SizeBytes = report.Bytes
+	export.Status.Documents = 0
+	for _, collection := range report.Collections {
+		export.Status.Documents += collection.Documents
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeExportReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeExport{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
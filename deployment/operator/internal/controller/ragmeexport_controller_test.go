@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func docsExport() *ragmev1.RAGmeExport {
+	return &ragmev1.RAGmeExport{
+		ObjectMeta: metav1.ObjectMeta{Name: "docs", Namespace: "ragme", Generation: 2},
+		Spec: ragmev1.RAGmeExportSpec{
+			InstanceRef: "test",
+			Collections: []string{"ragme-docs", "ragme-faq"},
+		},
+	}
+}
+
+func TestValidateExport(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	ragme.Spec.Storage.MinIO.Enabled = true
+
+	export := docsExport()
+	if err := validateExport(export, ragme); err != nil {
+		t.Errorf("validateExport() error = %v", err)
+	}
+
+	export.Spec.Format = "csv"
+	export.Spec.Destination = ragmev1.RAGmeExportDestination{Endpoint: "s3.amazonaws.com", Prefix: "/docs"}
+	if err := validateExport(export, ragme); err == nil {
+		t.Error("validateExport() accepted a format, an endpoint without scheme or credentials and an absolute prefix")
+	}
+
+	export = docsExport()
+	ragme.Spec.Storage.MinIO.Enabled = false
+	if err := validateExport(export, ragme); err == nil {
+		t.Error("validateExport() accepted the MinIO of an instance without MinIO")
+	}
+	export.Spec.Destination = ragmev1.RAGmeExportDestination{
+		Endpoint:             "https://s3.eu-west-1.amazonaws.com",
+		CredentialsSecretRef: &corev1.LocalObjectReference{Name: "archive"},
+	}
+	if err := validateExport(export, ragme); err != nil {
+		t.Errorf("validateExport() error = %v", err)
+	}
+}
+
+func TestCreateExportJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+
+	export := docsExport()
+	job := createExportJob(ragme, export)
+	if job.Name != "docs-2" || job.Labels[exportLabel] != "docs" || job.Labels["component"] != exportComponent {
+		t.Errorf("Job %s labels %v, want docs-2 labeled with the export", job.Name, job.Labels)
+	}
+	env := job.Spec.Template.Spec.Containers[0].Env
+	for name, want := range map[string]string{
+		"EXPORT_COLLECTIONS":        "ragme-docs,ragme-faq",
+		"EXPORT_FORMAT":             exportFormatJSONL,
+		"EXPORT_INCLUDE_EMBEDDINGS": "true",
+		"EXPORT_ENDPOINT":           "http://test-minio:9000",
+		"EXPORT_BUCKET":             defaultExportBucket,
+		"EXPORT_PREFIX":             "docs/",
+	} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	for _, e := range env {
+		if e.Name == "EXPORT_ACCESS_KEY" && (e.ValueFrom == nil || e.ValueFrom.SecretKeyRef.Name != "test-minio-root") {
+			t.Errorf("EXPORT_ACCESS_KEY = %+v, want a reference to the MinIO root Secret", e)
+		}
+	}
+
+	export.Spec.Destination = ragmev1.RAGmeExportDestination{
+		Endpoint:             "https://s3.eu-west-1.amazonaws.com",
+		CredentialsSecretRef: &corev1.LocalObjectReference{Name: "archive"},
+	}
+	env = createExportJob(ragme, export).Spec.Template.Spec.Containers[0].Env
+	for _, e := range env {
+		if e.Name == "EXPORT_SECRET_KEY" && (e.ValueFrom == nil || e.ValueFrom.SecretKeyRef.Name != "archive") {
+			t.Errorf("EXPORT_SECRET_KEY = %+v, want a reference to the archive Secret", e)
+		}
+	}
+}
+
+func TestRecordExportStats(t *testing.T) {
+	export := docsExport()
+	message := `{"archive":"s3://ragme-exports/docs/","collections":[{"name":"ragme-docs","documents":120},{"name":"ragme-faq","documents":30}],"bytes":5242880}`
+	if err := recordExportStats(export, message); err != nil {
+		t.Fatalf("recordExportStats() error = %v", err)
+	}
+	if export.Status.Documents != 150 || export.Status.SizeBytes != 5242880 || export.Status.Archive != "s3://ragme-exports/docs/" {
+		t.Errorf("status = %+v, want 150 documents in the archive", export.Status)
+	}
+	if err := recordExportStats(export, "done"); err == nil {
+		t.Error("recordExportStats() accepted a message that is not JSON")
+	}
+}
@@ -0,0 +1,698 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	loadTestToolK6     = "k6"
+	loadTestToolVegeta = "vegeta"
+
+	defaultK6Image     = "grafana/k6:latest"
+	defaultVegetaImage = "peterevans/vegeta:latest"
+
+	defaultLoadTestDuration       = time.Minute
+	defaultLoadTestLatencyP95     = 2 * time.Second
+	defaultLoadTestSuccessRatio   = 0.99
+	defaultAutoscalingUtilization = 70
+
+	loadTestScenariosDir = "/scenarios"
+	loadTestScriptKey    = "loadtest.js"
+	loadTestResultsKey   = "results.json"
+
+	// loadTestContainerPrefix prefixes the container running a scenario
+	loadTestContainerPrefix = "scenario-"
+
+	// loadTestRequeue is the interval of the checks of a running load test
+	loadTestRequeue = 30 * time.Second
+)
+
+// loadTestK6Script sends the query mix of the scenario at a constant rate and
+// writes a vegeta-like JSON report to the termination message
+const loadTestK6Script = `import http from 'k6/http';
+
+const queries = JSON.parse(open(` + "`" + loadTestScenariosDir + `/${__ENV.SCENARIO}.json` + "`" + `));
+const total = queries.reduce((sum, q) => sum + q.weight, 0);
+const rate = Number(__ENV.QPS);
+
+export const options = {
+  summaryTrendStats: ['med', 'p(95)', 'p(99)'],
+  scenarios: {
+    load: {
+      executor: 'constant-arrival-rate',
+      rate: rate,
+      timeUnit: '1s',
+      duration: __ENV.DURATION,
+      preAllocatedVUs: rate,
+      maxVUs: rate * 60,
+    },
+  },
+};
+
+function pick() {
+  let n = Math.random() * total;
+  for (const q of queries) {
+    n -= q.weight;
+    if (n < 0) {
+      return q.query;
+    }
+  }
+  return queries[queries.length - 1].query;
+}
+
+export default function () {
+  http.post(` + "`${__ENV.RAGME_API_URL}/query`" + `, JSON.stringify({ query: pick() }), {
+    headers: { 'Content-Type': 'application/json' },
+    timeout: '60s',
+  });
+}
+
+export function handleSummary(data) {
+  const m = data.metrics;
+  const failed = m.http_req_failed ? m.http_req_failed.values.rate : 1;
+  const requests = m.http_reqs ? m.http_reqs.values : { count: 0, rate: 0 };
+  const latency = m.http_req_duration ? m.http_req_duration.values : {};
+  const ns = (ms) => Math.round((ms || 0) * 1e6);
+  return {
+    '/dev/termination-log': JSON.stringify({
+      requests: requests.count,
+      throughput: requests.rate * (1 - failed),
+      success: 1 - failed,
+      latencies: { '50th': ns(latency.med), '95th': ns(latency['p(95)']), '99th': ns(latency['p(99)']) },
+    }),
+  };
+}
+`
+
+// loadTestVegetaScript sends the targets of the scenario at a constant rate
+// and writes the JSON report, without the error list that could overflow the
+// termination message, to the termination message
+const loadTestVegetaScript = `vegeta attack -format=json -targets="` + loadTestScenariosDir + `/${SCENARIO}.targets" ` +
+	`-rate="${QPS}" -duration="${DURATION}" -timeout=60s | ` +
+	`vegeta report -type=json | sed 's/,"errors":\[.*\]}$/}/' > /dev/termination-log`
+
+// RAGmeLoadTestReconciler reconciles a RAGmeLoadTest object
+type RAGmeLoadTestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmeloadtests,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmeloadtests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;update
+
+// Reconcile runs the load test Job of the current spec generation, collects
+// the reports of its scenarios and adjusts the HorizontalPodAutoscaler of the
+// api from the measured capacity. A load test runs once per generation.
+func (r *RAGmeLoadTestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	loadTest := &ragmev1.RAGmeLoadTest{}
+	if err := r.Get(ctx, req.NamespacedName, loadTest); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeLoadTest resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeLoadTest")
+		return ctrl.Result{}, err
+	}
+	if loadTest.Status.ObservedGeneration == loadTest.Generation && loadTest.Status.CompletionTime != nil {
+		return ctrl.Result{}, nil
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: loadTest.Spec.InstanceRef, Namespace: loadTest.Namespace}, ragme); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkReconciling(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+loadTest.Spec.InstanceRef+" not found")
+		loadTest.Status.Phase = conditions.Phase(loadTest.Status.Conditions)
+		if err := r.Status().Update(ctx, loadTest); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if err := validateLoadTest(loadTest); err != nil {
+		conditions.MarkStalled(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonValidationFailed, err.Error())
+		loadTest.Status.Phase = conditions.Phase(loadTest.Status.Conditions)
+		loadTest.Status.ObservedGeneration = loadTest.Generation
+		return ctrl.Result{}, r.Status().Update(ctx, loadTest)
+	}
+
+	done, err := r.runLoadTest(ctx, ragme, loadTest)
+	if err != nil {
+		logger.Error(err, "Failed to run load test")
+		conditions.MarkDegraded(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonReconcileFailed, err.Error())
+		loadTest.Status.Phase = conditions.Phase(loadTest.Status.Conditions)
+		loadTest.Status.ObservedGeneration = loadTest.Generation
+		if statusErr := r.Status().Update(ctx, loadTest); statusErr != nil {
+			logger.Error(statusErr, "Failed to update RAGmeLoadTest status")
+		}
+		return ctrl.Result{RequeueAfter: time.Minute}, err
+	}
+
+	loadTest.Status.Phase = conditions.Phase(loadTest.Status.Conditions)
+	loadTest.Status.ObservedGeneration = loadTest.Generation
+	if err := r.Status().Update(ctx, loadTest); err != nil {
+		logger.Error(err, "Failed to update RAGmeLoadTest status")
+		return ctrl.Result{}, err
+	}
+	if !done {
+		return ctrl.Result{RequeueAfter: loadTestRequeue}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// validateLoadTest checks the load test spec for settings the Job cannot run with
+func validateLoadTest(loadTest *ragmev1.RAGmeLoadTest) error {
+	var errs []error
+	spec := loadTest.Spec
+
+	switch spec.Tool {
+	case "", loadTestToolK6, loadTestToolVegeta:
+	default:
+		errs = append(errs, fmt.Errorf("tool: unsupported tool %q, use %s or %s", spec.Tool, loadTestToolK6, loadTestToolVegeta))
+	}
+	if len(spec.Scenarios) == 0 {
+		errs = append(errs, fmt.Errorf("scenarios: at least one scenario is required"))
+	}
+	names := map[string]bool{}
+	for i, scenario := range spec.Scenarios {
+		if msgs := validation.IsDNS1123Label(loadTestContainerPrefix + scenario.Name); scenario.Name == "" || len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("scenarios[%d].name: %q must be a short lower-case DNS label", i, scenario.Name))
+		} else if names[scenario.Name] {
+			errs = append(errs, fmt.Errorf("scenarios[%d].name: duplicate scenario %q", i, scenario.Name))
+		}
+		names[scenario.Name] = true
+		if scenario.QPS <= 0 {
+			errs = append(errs, fmt.Errorf("scenarios[%d].qps: must be positive", i))
+		}
+		if scenario.Duration != "" {
+			if d, err := time.ParseDuration(scenario.Duration); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("scenarios[%d].duration: %q is not a positive duration", i, scenario.Duration))
+			}
+		}
+		if len(scenario.Queries) == 0 {
+			errs = append(errs, fmt.Errorf("scenarios[%d].queries: at least one query is required", i))
+		}
+		for j, query := range scenario.Queries {
+			if query.Weight < 0 {
+				errs = append(errs, fmt.Errorf("scenarios[%d].queries[%d].weight: must not be negative", i, j))
+			}
+		}
+	}
+
+	if latency := spec.Thresholds.LatencyP95; latency != "" {
+		if d, err := time.ParseDuration(latency); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("thresholds.latencyP95: %q is not a positive duration", latency))
+		}
+	}
+	if ratio := spec.Thresholds.SuccessRatio; ratio != "" {
+		if parsed, err := strconv.ParseFloat(ratio, 64); err != nil || parsed < 0 || parsed > 1 {
+			errs = append(errs, fmt.Errorf("thresholds.successRatio: %q must be a number between 0 and 1", ratio))
+		}
+	}
+	if utilization := spec.Autoscaling.Utilization; utilization < 0 || utilization > 100 {
+		errs = append(errs, fmt.Errorf("autoscaling.utilization: %d must be between 1 and 100", utilization))
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// loadTestTool returns the effective tool of the load test
+func loadTestTool(loadTest *ragmev1.RAGmeLoadTest) string {
+	if loadTest.Spec.Tool != "" {
+		return loadTest.Spec.Tool
+	}
+	return loadTestToolK6
+}
+
+// loadTestJobName returns the name of the Job running the current generation
+func loadTestJobName(loadTest *ragmev1.RAGmeLoadTest) string {
+	return fmt.Sprintf("%s-%d", loadTest.Name, loadTest.Generation)
+}
+
+// runLoadTest creates the Job of the current generation, then collects its
+// results once it finished. It reports whether the load test is complete.
+func (r *RAGmeLoadTestReconciler) runLoadTest(ctx context.Context, ragme *ragmev1.RAGme, loadTest *ragmev1.RAGmeLoadTest) (bool, error) {
+	if err := r.reconcileLoadTestConfigMap(ctx, loadTest, loadTest.Name+"-scenarios", renderLoadTestScenarios(ragme, loadTest)); err != nil {
+		return false, err
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: loadTestJobName(loadTest), Namespace: loadTest.Namespace}, job)
+	if err != nil && errors.IsNotFound(err) {
+		job = createLoadTestJob(ragme, loadTest)
+		if err := ctrl.SetControllerReference(loadTest, job, r.Scheme); err != nil {
+			return false, err
+		}
+		applyOwnershipLabels(ragme, job)
+		if err := r.Create(ctx, job); err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	}
+
+	if loadTest.Status.Job != job.Name {
+		replicas, err := r.readyAPIReplicas(ctx, ragme)
+		if err != nil {
+			return false, err
+		}
+		startTime := job.CreationTimestamp
+		if startTime.IsZero() {
+			startTime = metav1.Now()
+		}
+		loadTest.Status = ragmev1.RAGmeLoadTestStatus{
+			Job:         job.Name,
+			StartTime:   &startTime,
+			APIReplicas: replicas,
+			Conditions:  loadTest.Status.Conditions,
+		}
+		conditions.Remove(&loadTest.Status.Conditions, conditions.TypeAutoscalingAdjusted)
+		conditions.MarkReconciling(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonProgressing,
+			fmt.Sprintf("Load test Job %s is running", job.Name))
+	}
+	if job.Status.Succeeded == 0 && !jobFailed(job) {
+		return false, nil
+	}
+
+	reports, err := r.loadTestReports(ctx, loadTest, job)
+	if err != nil {
+		return false, err
+	}
+	results, capacity := summarizeLoadTest(loadTest, reports)
+
+	data := map[string]string{}
+	for scenario, report := range reports {
+		data[scenario+".json"] = report
+	}
+	summary, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return false, err
+	}
+	data[loadTestResultsKey] = string(summary)
+	resultsConfigMap := loadTest.Name + "-results"
+	if err := r.reconcileLoadTestConfigMap(ctx, loadTest, resultsConfigMap, data); err != nil {
+		return false, err
+	}
+
+	loadTest.Status.Results = results
+	loadTest.Status.ResultsConfigMap = resultsConfigMap
+	loadTest.Status.CapacityQPS = ""
+	if capacity > 0 {
+		loadTest.Status.CapacityQPS = strconv.FormatFloat(capacity, 'f', 1, 64)
+	}
+	loadTest.Status.CompletionTime = &metav1.Time{Time: time.Now()}
+
+	if jobFailed(job) {
+		conditions.MarkStalled(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonLoadTestFailed,
+			fmt.Sprintf("Load test Job %s failed after %d of %d scenarios", job.Name, len(reports), len(loadTest.Spec.Scenarios)))
+		return true, nil
+	}
+	if err := r.adjustAutoscaler(ctx, ragme, loadTest, capacity); err != nil {
+		return false, err
+	}
+	conditions.MarkReady(&loadTest.Status.Conditions, loadTest.Generation, conditions.ReasonLoadTestSucceeded,
+		fmt.Sprintf("%d scenarios ran", len(results)))
+	return true, nil
+}
+
+// readyAPIReplicas returns the number of ready api replicas
+func (r *RAGmeLoadTestReconciler) readyAPIReplicas(ctx context.Context, ragme *ragmev1.RAGme) (int32, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: ragme.Name + "-api", Namespace: ragme.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return 0, nil
+	}
+	return deployment.Status.ReadyReplicas, err
+}
+
+// reconcileLoadTestConfigMap creates or updates a ConfigMap owned by the load test
+func (r *RAGmeLoadTestReconciler) reconcileLoadTestConfigMap(ctx context.Context, loadTest *ragmev1.RAGmeLoadTest, name string, data map[string]string) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: loadTest.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": loadTest.Spec.InstanceRef,
+				"loadtest": loadTest.Name,
+			},
+		},
+		Data: data,
+	}
+	if err := ctrl.SetControllerReference(loadTest, configMap, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(found.Data, configMap.Data) {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// loadTestQuery is a query of the mix rendered for k6
+type loadTestQuery struct {
+	Query  string `json:"query"`
+	Weight int32  `json:"weight"`
+}
+
+// vegetaTarget is a request of the targets rendered for vegeta
+type vegetaTarget struct {
+	Method string              `json:"method"`
+	URL    string              `json:"url"`
+	Header map[string][]string `json:"header"`
+	Body   []byte              `json:"body"`
+}
+
+// renderLoadTestScenarios returns the files of the scenarios: the script and
+// the weighted query mix of each scenario for k6, the targets of each scenario
+// for vegeta. Vegeta cycles through its targets, a query is repeated as many
+// times as its weight.
+func renderLoadTestScenarios(ragme *ragmev1.RAGme, loadTest *ragmev1.RAGmeLoadTest) map[string]string {
+	url := fmt.Sprintf("http://%s-api:8021/query", ragme.Name)
+	data := map[string]string{}
+	for _, scenario := range loadTest.Spec.Scenarios {
+		queries := make([]loadTestQuery, 0, len(scenario.Queries))
+		for _, query := range scenario.Queries {
+			weight := query.Weight
+			if weight == 0 {
+				weight = 1
+			}
+			queries = append(queries, loadTestQuery{Query: query.Query, Weight: weight})
+		}
+
+		if loadTestTool(loadTest) == loadTestToolVegeta {
+			var targets strings.Builder
+			for _, query := range queries {
+				body, _ := json.Marshal(map[string]string{"query": query.Query})
+				line, _ := json.Marshal(vegetaTarget{
+					Method: "POST",
+					URL:    url,
+					Header: map[string][]string{"Content-Type": {"application/json"}},
+					Body:   body,
+				})
+				for i := int32(0); i < query.Weight; i++ {
+					targets.Write(line)
+					targets.WriteByte('\n')
+				}
+			}
+			data[scenario.Name+".targets"] = targets.String()
+			continue
+		}
+		mix, _ := json.Marshal(queries)
+		data[scenario.Name+".json"] = string(mix)
+	}
+	if loadTestTool(loadTest) == loadTestToolK6 {
+		data[loadTestScriptKey] = loadTestK6Script
+	}
+	return data
+}
+
+// createLoadTestJob returns the Job running the scenarios one after the other.
+// Every scenario but the last runs in an init container, and each writes its
+// report to the termination message of its container.
+func createLoadTestJob(ragme *ragmev1.RAGme, loadTest *ragmev1.RAGmeLoadTest) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "loadtest",
+		"instance":  ragme.Name,
+		"loadtest":  loadTest.Name,
+	}
+
+	tool := loadTestTool(loadTest)
+	image := loadTest.Spec.Image
+	if image == "" {
+		image = defaultK6Image
+		if tool == loadTestToolVegeta {
+			image = defaultVegetaImage
+		}
+	}
+
+	containers := make([]corev1.Container, 0, len(loadTest.Spec.Scenarios))
+	for _, scenario := range loadTest.Spec.Scenarios {
+		container := corev1.Container{
+			Name:  loadTestContainerPrefix + scenario.Name,
+			Image: image,
+			Env: []corev1.EnvVar{
+				{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+				{Name: "SCENARIO", Value: scenario.Name},
+				{Name: "QPS", Value: strconv.Itoa(int(scenario.QPS))},
+				{Name: "DURATION", Value: durationOrDefault(scenario.Duration, defaultLoadTestDuration).String()},
+			},
+			VolumeMounts:             []corev1.VolumeMount{{Name: "scenarios", MountPath: loadTestScenariosDir}},
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		}
+		if tool == loadTestToolVegeta {
+			container.Command = []string{"sh", "-c", loadTestVegetaScript}
+		} else {
+			container.Args = []string{"run", "--quiet", loadTestScenariosDir + "/" + loadTestScriptKey}
+		}
+		containers = append(containers, container)
+	}
+	last := len(containers) - 1
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      loadTestJobName(loadTest),
+			Namespace: loadTest.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			// A retried load test would measure a different run, a failure is reported instead
+			BackoffLimit:            &[]int32{0}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					InitContainers: containers[:last],
+					Containers:     containers[last:],
+					Volumes: []corev1.Volume{
+						{
+							Name: "scenarios",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: loadTest.Name + "-scenarios"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
+
+// loadTestReports returns the reports the scenarios wrote to the termination
+// message of their container, by scenario. Scenarios that did not complete are
+// missing.
+func (r *RAGmeLoadTestReconciler) loadTestReports(ctx context.Context, loadTest *ragmev1.RAGmeLoadTest, job *batchv1.Job) (map[string]string, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return nil, err
+	}
+
+	reports := map[string]string{}
+	for _, pod := range pods.Items {
+		statuses := append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			terminated := status.State.Terminated
+			if terminated == nil || terminated.ExitCode != 0 || !strings.HasPrefix(status.Name, loadTestContainerPrefix) {
+				continue
+			}
+			reports[strings.TrimPrefix(status.Name, loadTestContainerPrefix)] = terminated.Message
+		}
+	}
+	return reports, nil
+}
+
+// loadTestReport is the vegeta JSON report, also rendered by the k6 script.
+// Latencies are in nanoseconds.
+type loadTestReport struct {
+	Requests   int64   `json:"requests"`
+	Throughput float64 `json:"throughput"`
+	Success    float64 `json:"success"`
+	Latencies  struct {
+		P50 time.Duration `json:"50th"`
+		P95 time.Duration `json:"95th"`
+		P99 time.Duration `json:"99th"`
+	} `json:"latencies"`
+}
+
+// loadTestThresholds returns the effective thresholds of a sustained scenario
+func loadTestThresholds(loadTest *ragmev1.RAGmeLoadTest) (time.Duration, float64) {
+	successRatio := defaultLoadTestSuccessRatio
+	if parsed, err := strconv.ParseFloat(loadTest.Spec.Thresholds.SuccessRatio, 64); err == nil {
+		successRatio = parsed
+	}
+	return durationOrDefault(loadTest.Spec.Thresholds.LatencyP95, defaultLoadTestLatencyP95), successRatio
+}
+
+// summarizeLoadTest returns the results of the scenarios in spec order and the
+// capacity, the highest throughput of a sustained scenario. A report that
+// cannot be parsed yields a result without measurements.
+func summarizeLoadTest(loadTest *ragmev1.RAGmeLoadTest, reports map[string]string) ([]ragmev1.RAGmeLoadTestResult, float64) {
+	latencyP95, successRatio := loadTestThresholds(loadTest)
+	results := []ragmev1.RAGmeLoadTestResult{}
+	capacity := 0.0
+	for _, scenario := range loadTest.Spec.Scenarios {
+		message, ok := reports[scenario.Name]
+		if !ok {
+			continue
+		}
+		result := ragmev1.RAGmeLoadTestResult{Scenario: scenario.Name, QPS: scenario.QPS}
+		report := loadTestReport{}
+		if err := json.Unmarshal([]byte(message), &report); err == nil {
+			result.Requests = report.Requests
+			result.Throughput = strconv.FormatFloat(report.Throughput, 'f', 2, 64)
+			result.SuccessRatio = strconv.FormatFloat(report.Success, 'f', 4, 64)
+			result.LatencyP50 = report.Latencies.P50.Round(time.Millisecond).String()
+			result.LatencyP95 = report.Latencies.P95.Round(time.Millisecond).String()
+			result.LatencyP99 = report.Latencies.P99.Round(time.Millisecond).String()
+			result.Sustained = report.Requests > 0 && report.Success >= successRatio && report.Latencies.P95 <= latencyP95
+			if result.Sustained && report.Throughput > capacity {
+				capacity = report.Throughput
+			}
+		}
+		results = append(results, result)
+	}
+	return results, capacity
+}
+
+// adjustAutoscaler applies the measured capacity to the HorizontalPodAutoscaler
+// of the api and sets the AutoscalingAdjusted condition
+func (r *RAGmeLoadTestReconciler) adjustAutoscaler(ctx context.Context, ragme *ragmev1.RAGme, loadTest *ragmev1.RAGmeLoadTest, capacity float64) error {
+	autoscaling := loadTest.Spec.Autoscaling
+	if !autoscaling.Enabled {
+		conditions.Remove(&loadTest.Status.Conditions, conditions.TypeAutoscalingAdjusted)
+		return nil
+	}
+	if capacity == 0 || loadTest.Status.APIReplicas == 0 {
+		conditions.SetFalse(&loadTest.Status.Conditions, loadTest.Generation, conditions.TypeAutoscalingAdjusted,
+			conditions.ReasonCapacityNotMeasured, "No scenario met the thresholds with ready api replicas")
+		return nil
+	}
+
+	name := autoscaling.HPAName
+	if name == "" {
+		name = ragme.Name + "-api"
+	}
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: loadTest.Namespace}, hpa); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		conditions.SetFalse(&loadTest.Status.Conditions, loadTest.Generation, conditions.TypeAutoscalingAdjusted,
+			conditions.ReasonHPANotFound, "HorizontalPodAutoscaler "+name+" not found")
+		return nil
+	}
+
+	adjustment := adjustHPA(hpa, autoscaling, capacity, loadTest.Status.APIReplicas)
+	if err := r.Update(ctx, hpa); err != nil {
+		return err
+	}
+	loadTest.Status.Autoscaling = adjustment
+	conditions.SetTrue(&loadTest.Status.Conditions, loadTest.Generation, conditions.TypeAutoscalingAdjusted,
+		conditions.ReasonHPAAdjusted, fmt.Sprintf("Capacity of %.1f QPS over %d api replicas applied to %s",
+			capacity, loadTest.Status.APIReplicas, name))
+	return nil
+}
+
+// adjustHPA sets the targets of hpa from the capacity of a replica at the
+// target utilization and returns the applied adjustment. maxReplicas is set
+// from the peak query rate and never drops below minReplicas.
+func adjustHPA(hpa *autoscalingv2.HorizontalPodAutoscaler, autoscaling ragmev1.RAGmeLoadTestAutoscaling, capacity float64, replicas int32) ragmev1.RAGmeLoadTestAutoscalingStatus {
+	utilization := autoscaling.Utilization
+	if utilization == 0 {
+		utilization = defaultAutoscalingUtilization
+	}
+	perReplica := capacity / float64(replicas) * float64(utilization) / 100
+	adjustment := ragmev1.RAGmeLoadTestAutoscalingStatus{HPA: hpa.Name}
+
+	if autoscaling.PeakQPS > 0 {
+		maxReplicas := int32(math.Ceil(float64(autoscaling.PeakQPS) / perReplica))
+		if minReplicas := hpa.Spec.MinReplicas; minReplicas != nil && maxReplicas < *minReplicas {
+			maxReplicas = *minReplicas
+		}
+		if maxReplicas < 1 {
+			maxReplicas = 1
+		}
+		hpa.Spec.MaxReplicas = maxReplicas
+		adjustment.MaxReplicas = maxReplicas
+	}
+
+	if autoscaling.MetricName != "" {
+		value := resource.NewMilliQuantity(int64(perReplica*1000), resource.DecimalSI)
+		target := autoscalingv2.MetricTarget{Type: autoscalingv2.AverageValueMetricType, AverageValue: value}
+		found := false
+		for i := range hpa.Spec.Metrics {
+			metric := &hpa.Spec.Metrics[i]
+			if metric.Type == autoscalingv2.PodsMetricSourceType && metric.Pods != nil && metric.Pods.Metric.Name == autoscaling.MetricName {
+				metric.Pods.Target = target
+				found = true
+			}
+		}
+		if !found {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.PodsMetricSourceType,
+				Pods: &autoscalingv2.PodsMetricSource{
+					Metric: autoscalingv2.MetricIdentifier{Name: autoscaling.MetricName},
+					Target: target,
+				},
+			})
+		}
+		adjustment.TargetAverageValue = value.String()
+	}
+	return adjustment
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeLoadTestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeLoadTest{}).
+		Owns(&batchv1.Job{}).
+		Owns(&corev1.ConfigMap{}).
+		Complete(r)
+}
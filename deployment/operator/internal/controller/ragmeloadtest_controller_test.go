@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func testLoadTest(tool string) *ragmev1.RAGmeLoadTest {
+	return &ragmev1.RAGmeLoadTest{
+		ObjectMeta: metav1.ObjectMeta{Name: "capacity", Namespace: "ragme", Generation: 2},
+		Spec: ragmev1.RAGmeLoadTestSpec{
+			InstanceRef: "test",
+			Tool:        tool,
+			Scenarios: []ragmev1.RAGmeLoadTestScenario{
+				{Name: "steady", QPS: 10, Queries: []ragmev1.RAGmeLoadTestQuery{{Query: "a", Weight: 2}, {Query: "b"}}},
+				{Name: "peak", QPS: 30, Duration: "5m", Queries: []ragmev1.RAGmeLoadTestQuery{{Query: "a"}}},
+			},
+		},
+	}
+}
+
+func TestCreateLoadTestJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	job := createLoadTestJob(ragme, testLoadTest(""))
+
+	if job.Name != "capacity-2" {
+		t.Errorf("job name = %q, want capacity-2", job.Name)
+	}
+	pod := job.Spec.Template.Spec
+	if len(pod.InitContainers) != 1 || pod.InitContainers[0].Name != "scenario-steady" {
+		t.Fatalf("init containers = %+v, want the steady scenario", pod.InitContainers)
+	}
+	if len(pod.Containers) != 1 || pod.Containers[0].Name != "scenario-peak" {
+		t.Fatalf("containers = %+v, want the peak scenario", pod.Containers)
+	}
+	peak := pod.Containers[0]
+	if peak.Image != defaultK6Image || peak.Args[len(peak.Args)-1] != "/scenarios/loadtest.js" {
+		t.Errorf("peak runs %s %v, want k6 with the rendered script", peak.Image, peak.Args)
+	}
+	if envValue(peak.Env, "QPS") != "30" || envValue(peak.Env, "DURATION") != "5m0s" {
+		t.Errorf("peak env = %v, want QPS 30 for 5m0s", peak.Env)
+	}
+	if envValue(pod.InitContainers[0].Env, "DURATION") != "1m0s" {
+		t.Errorf("steady duration = %q, want the 1m default", envValue(pod.InitContainers[0].Env, "DURATION"))
+	}
+	if *job.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %d, want 0", *job.Spec.BackoffLimit)
+	}
+
+	vegeta := createLoadTestJob(ragme, testLoadTest(loadTestToolVegeta)).Spec.Template.Spec.Containers[0]
+	if vegeta.Image != defaultVegetaImage || !strings.Contains(vegeta.Command[2], "vegeta attack") {
+		t.Errorf("vegeta container runs %s %v", vegeta.Image, vegeta.Command)
+	}
+}
+
+func TestRenderLoadTestScenarios(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+
+	data := renderLoadTestScenarios(ragme, testLoadTest(""))
+	if data[loadTestScriptKey] == "" {
+		t.Error("k6 script not rendered")
+	}
+	queries := []loadTestQuery{}
+	if err := json.Unmarshal([]byte(data["steady.json"]), &queries); err != nil {
+		t.Fatalf("steady query mix: %v", err)
+	}
+	if len(queries) != 2 || queries[0].Weight != 2 || queries[1].Weight != 1 {
+		t.Errorf("steady query mix = %+v, want weights 2 and 1", queries)
+	}
+
+	data = renderLoadTestScenarios(ragme, testLoadTest(loadTestToolVegeta))
+	lines := strings.Split(strings.TrimSpace(data["steady.targets"]), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("steady targets = %d lines, want a line per unit of weight", len(lines))
+	}
+	target := vegetaTarget{}
+	if err := json.Unmarshal([]byte(lines[2]), &target); err != nil {
+		t.Fatal(err)
+	}
+	if target.URL != "http://test-api:8021/query" || string(target.Body) != `{"query":"b"}` {
+		t.Errorf("target = %s %s", target.URL, target.Body)
+	}
+	if _, ok := data[loadTestScriptKey]; ok {
+		t.Error("k6 script rendered for vegeta")
+	}
+}
+
+func TestSummarizeLoadTest(t *testing.T) {
+	loadTest := testLoadTest("")
+	loadTest.Spec.Scenarios = append(loadTest.Spec.Scenarios, ragmev1.RAGmeLoadTestScenario{Name: "burst", QPS: 60})
+	reports := map[string]string{
+		"steady": `{"requests":600,"throughput":9.98,"success":1,"latencies":{"50th":400000000,"95th":900000000,"99th":1200000000}}`,
+		// Too slow to count as capacity
+		"peak":  `{"requests":9000,"throughput":29.5,"success":0.995,"latencies":{"50th":1500000000,"95th":2500000000,"99th":4000000000}}`,
+		"burst": `not a report`,
+	}
+
+	results, capacity := summarizeLoadTest(loadTest, reports)
+	if len(results) != 3 {
+		t.Fatalf("results = %+v, want 3", results)
+	}
+	if !results[0].Sustained || results[1].Sustained || results[2].Sustained {
+		t.Errorf("sustained = %v %v %v, want only steady", results[0].Sustained, results[1].Sustained, results[2].Sustained)
+	}
+	if results[0].LatencyP95 != "900ms" || results[0].Throughput != "9.98" {
+		t.Errorf("steady = %+v", results[0])
+	}
+	if capacity != 9.98 {
+		t.Errorf("capacity = %v, want 9.98", capacity)
+	}
+
+	loadTest.Spec.Thresholds.LatencyP95 = "3s"
+	if _, capacity := summarizeLoadTest(loadTest, reports); capacity != 29.5 {
+		t.Errorf("capacity with a 3s threshold = %v, want 29.5", capacity)
+	}
+}
+
+func TestAdjustHPA(t *testing.T) {
+	hpa := &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-api"},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			MinReplicas: &[]int32{2}[0],
+			MaxReplicas: 4,
+		},
+	}
+	autoscaling := ragmev1.RAGmeLoadTestAutoscaling{Enabled: true, PeakQPS: 100, MetricName: "ragme_api_queries_per_second"}
+
+	// 20 QPS over 2 replicas at 70% utilization: 7 QPS per replica
+	adjustment := adjustHPA(hpa, autoscaling, 20, 2)
+	if hpa.Spec.MaxReplicas != 15 || adjustment.MaxReplicas != 15 {
+		t.Errorf("maxReplicas = %d, want 15", hpa.Spec.MaxReplicas)
+	}
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Pods.Target.AverageValue.String() != "7" {
+		t.Fatalf("metrics = %+v, want a 7 per replica target", hpa.Spec.Metrics)
+	}
+
+	// The metric target is updated in place
+	adjustHPA(hpa, autoscaling, 40, 2)
+	if len(hpa.Spec.Metrics) != 1 || hpa.Spec.Metrics[0].Pods.Target.AverageValue.String() != "14" {
+		t.Errorf("metrics = %+v, want the target updated to 14", hpa.Spec.Metrics)
+	}
+
+	// maxReplicas never drops below minReplicas
+	autoscaling.PeakQPS = 1
+	if adjustHPA(hpa, autoscaling, 40, 2); hpa.Spec.MaxReplicas != 2 {
+		t.Errorf("maxReplicas = %d, want minReplicas 2", hpa.Spec.MaxReplicas)
+	}
+}
+
+func TestValidateLoadTest(t *testing.T) {
+	if err := validateLoadTest(testLoadTest("")); err != nil {
+		t.Errorf("validateLoadTest() = %v", err)
+	}
+
+	loadTest := testLoadTest("locust")
+	loadTest.Spec.Scenarios[1].Name = "steady"
+	loadTest.Spec.Scenarios[1].Duration = "soon"
+	loadTest.Spec.Thresholds.SuccessRatio = "99"
+	err := validateLoadTest(loadTest)
+	if err == nil {
+		t.Fatal("validateLoadTest() = nil, want errors")
+	}
+	for _, want := range []string{"tool", "duplicate scenario", "scenarios[1].duration", "thresholds.successRatio"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("validateLoadTest() = %v, want it to mention %s", err, want)
+		}
+	}
+}
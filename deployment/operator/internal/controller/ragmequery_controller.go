@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	queryMatchAll = "all"
+	queryMatchAny = "any"
+
+	defaultQuerySchedule = "*/5 * * * *"
+	defaultQueryTimeout  = 30 * time.Second
+
+	queryPassed = "Passed"
+	queryFailed = "Failed"
+
+	// maxQueryResponseSize bounds the answer read from the api
+	maxQueryResponseSize = 1 << 20
+)
+
+// RAGmeQueryReconciler reconciles a RAGmeQuery object
+type RAGmeQueryReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// HTTPClient is used to ask the queries. Defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+
+	// Resync controls the periodic resync of reconciled resources
+	Resync ResyncConfig
+}
+
+// queryRun is the outcome of asking a saved query once
+type queryRun struct {
+	Answer  string
+	Latency time.Duration
+	Err     error
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmequeries,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmequeries/status,verbs=get;update;patch
+
+// Reconcile asks the saved query to the api of the referenced RAGme instance
+// when its schedule is due, checks the answer against the expected keywords
+// and the latency limit, and records the outcome in status. A query is asked
+// right away after a spec change, and not while the instance hibernates.
+func (r *RAGmeQueryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	query := &ragmev1.RAGmeQuery{}
+	if err := r.Get(ctx, req.NamespacedName, query); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeQuery resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeQuery")
+		return ctrl.Result{}, err
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: query.Spec.InstanceRef, Namespace: query.Namespace}, ragme); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkReconciling(&query.Status.Conditions, query.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+query.Spec.InstanceRef+" not found")
+		query.Status.Phase = conditions.Phase(query.Status.Conditions)
+		if err := r.Status().Update(ctx, query); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	if err := validateQuery(query); err != nil {
+		conditions.MarkStalled(&query.Status.Conditions, query.Generation, conditions.ReasonValidationFailed, err.Error())
+		query.Status.Phase = conditions.Phase(query.Status.Conditions)
+		query.Status.ObservedGeneration = query.Generation
+		return ctrl.Result{}, r.Status().Update(ctx, query)
+	}
+	conditions.Remove(&query.Status.Conditions, conditions.TypeStalled)
+
+	schedule, _ := parseCron(querySchedule(query))
+	now := time.Now().UTC()
+	result := r.Resync.Result(query)
+	switch {
+	case query.Spec.Suspend:
+		query.Status.NextRunTime = nil
+	case ragme.Status.Hibernation.Hibernated:
+		// Answers of a hibernated instance say nothing about its content
+		query.Status.NextRunTime = nil
+	default:
+		if queryDue(query, schedule, now) {
+			timeout, maxLatency := queryTimeouts(query)
+			url := fmt.Sprintf("http://%s-api.%s.svc:8021/query", ragme.Name, ragme.Namespace)
+			run := askQuery(ctx, defaultHTTPClient(r.HTTPClient), url, query.Spec.Query, timeout)
+			recordQueryRun(query, run, maxLatency, now)
+			logger.Info("Asked saved query", "result", query.Status.Result, "latency", query.Status.Latency)
+		}
+		if next := schedule.next(now); !next.IsZero() {
+			query.Status.NextRunTime = &metav1.Time{Time: next}
+			result = requeueBefore(result, next.Sub(now))
+		}
+	}
+
+	query.Status.Phase = conditions.Phase(query.Status.Conditions)
+	query.Status.ObservedGeneration = query.Generation
+	if err := r.Status().Update(ctx, query); err != nil {
+		logger.Error(err, "Failed to update RAGmeQuery status")
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// validateQuery checks the query spec for settings the runs cannot use
+func validateQuery(query *ragmev1.RAGmeQuery) error {
+	var errs []error
+	spec := query.Spec
+
+	if strings.TrimSpace(spec.Query) == "" {
+		errs = append(errs, fmt.Errorf("query: required"))
+	}
+	for i, keyword := range spec.ExpectedKeywords {
+		if strings.TrimSpace(keyword) == "" {
+			errs = append(errs, fmt.Errorf("expectedKeywords[%d]: must not be empty", i))
+		}
+	}
+	switch spec.Match {
+	case "", queryMatchAll, queryMatchAny:
+	default:
+		errs = append(errs, fmt.Errorf("match: unsupported value %q, use %s or %s", spec.Match, queryMatchAll, queryMatchAny))
+	}
+	if spec.Schedule != "" {
+		if _, err := parseCron(spec.Schedule); err != nil {
+			errs = append(errs, fmt.Errorf("schedule: %q: %w", spec.Schedule, err))
+		}
+	}
+	if spec.Timeout != "" {
+		if d, err := time.ParseDuration(spec.Timeout); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("timeout: %q must be a positive duration", spec.Timeout))
+		}
+	}
+	if spec.MaxLatency != "" {
+		if d, err := time.ParseDuration(spec.MaxLatency); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("maxLatency: %q must be a positive duration", spec.MaxLatency))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// querySchedule returns the cron schedule of the runs
+func querySchedule(query *ragmev1.RAGmeQuery) string {
+	if query.Spec.Schedule != "" {
+		return query.Spec.Schedule
+	}
+	return defaultQuerySchedule
+}
+
+// queryTimeouts returns the timeout of a run and the latency above which it
+// fails, zero when unbounded
+func queryTimeouts(query *ragmev1.RAGmeQuery) (time.Duration, time.Duration) {
+	timeout := defaultQueryTimeout
+	if d, err := time.ParseDuration(query.Spec.Timeout); err == nil && d > 0 {
+		timeout = d
+	}
+	maxLatency, _ := time.ParseDuration(query.Spec.MaxLatency)
+	return timeout, maxLatency
+}
+
+// queryDue reports whether the query is to be asked at now: it never ran, its
+// spec changed since the last run, or the schedule fired since the last run
+func queryDue(query *ragmev1.RAGmeQuery, schedule *cronSchedule, now time.Time) bool {
+	last := query.Status.LastRunTime
+	if last == nil || query.Status.ObservedGeneration != query.Generation {
+		return true
+	}
+	return schedule.prev(now).After(last.Time)
+}
+
+// askQuery sends the query to the query endpoint of the api and returns its
+// answer, or the raw body when the response is not the expected JSON object
+func askQuery(ctx context.Context, httpClient *http.Client, url, query string, timeout time.Duration) queryRun {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return queryRun{Err: err}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return queryRun{Err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return queryRun{Latency: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxQueryResponseSize))
+	run := queryRun{Latency: time.Since(start), Err: err}
+	if err != nil {
+		return run
+	}
+	if resp.StatusCode >= 300 {
+		run.Err = fmt.Errorf("query returned %s", resp.Status)
+		return run
+	}
+
+	answer := struct {
+		Response string `json:"response"`
+	}{}
+	if err := json.Unmarshal(data, &answer); err == nil && answer.Response != "" {
+		run.Answer = answer.Response
+	} else {
+		run.Answer = string(data)
+	}
+	return run
+}
+
+// missingKeywords returns the expected keywords the answer lacks, none when
+// any keyword is enough and one is found
+func missingKeywords(spec ragmev1.RAGmeQuerySpec, answer string) []string {
+	answer = strings.ToLower(answer)
+	var missing []string
+	for _, keyword := range spec.ExpectedKeywords {
+		if strings.Contains(answer, strings.ToLower(keyword)) {
+			if spec.Match == queryMatchAny {
+				return nil
+			}
+			continue
+		}
+		missing = append(missing, keyword)
+	}
+	return missing
+}
+
+// recordQueryRun records the outcome of a run in status and sets the summary
+// conditions: Ready when it passed, Degraded when it failed
+func recordQueryRun(query *ragmev1.RAGmeQuery, run queryRun, maxLatency time.Duration, now time.Time) {
+	status := &query.Status
+	status.LastRunTime = &metav1.Time{Time: now}
+	status.Latency = run.Latency.Round(time.Millisecond).String()
+	status.MissingKeywords = nil
+	status.Runs++
+
+	var failure string
+	switch {
+	case run.Err != nil:
+		failure = run.Err.Error()
+	case strings.TrimSpace(run.Answer) == "":
+		failure = "The answer is empty"
+	default:
+		status.MissingKeywords = missingKeywords(query.Spec, run.Answer)
+		if len(status.MissingKeywords) > 0 {
+			failure = "The answer lacks " + strings.Join(status.MissingKeywords, ", ")
+		} else if maxLatency > 0 && run.Latency > maxLatency {
+			failure = fmt.Sprintf("The answer took %s, above %s", status.Latency, maxLatency)
+		}
+	}
+
+	if failure != "" {
+		status.Result = queryFailed
+		status.ConsecutiveFailures++
+		conditions.MarkDegraded(&status.Conditions, query.Generation, conditions.ReasonQueryFailed, failure)
+		return
+	}
+	status.Result = queryPassed
+	status.PassedRuns++
+	status.ConsecutiveFailures = 0
+	conditions.MarkReady(&status.Conditions, query.Generation, conditions.ReasonQueryPassed,
+		"The query answered in "+status.Latency)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeQueryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeQuery{}).
+		Complete(r)
+}
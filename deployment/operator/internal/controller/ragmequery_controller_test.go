@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func faqQuery() *ragmev1.RAGmeQuery {
+	return &ragmev1.RAGmeQuery{
+		ObjectMeta: metav1.ObjectMeta{Name: "what-is-ragme", Namespace: "ragme", Generation: 1},
+		Spec: ragmev1.RAGmeQuerySpec{
+			InstanceRef:      "test",
+			Query:            "What is RAGme?",
+			ExpectedKeywords: []string{"Retrieval", "agent"},
+		},
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	query := faqQuery()
+	if err := validateQuery(query); err != nil {
+		t.Errorf("validateQuery() error = %v", err)
+	}
+
+	query.Spec.Query = " "
+	query.Spec.Match = "most"
+	query.Spec.Schedule = "hourly"
+	query.Spec.Timeout = "-1s"
+	query.Spec.MaxLatency = "fast"
+	if err := validateQuery(query); err == nil {
+		t.Error("validateQuery() accepted an empty query, match, schedule, timeout and latency")
+	}
+}
+
+func TestQueryDue(t *testing.T) {
+	schedule, _ := parseCron(defaultQuerySchedule)
+	now := time.Date(2024, 5, 1, 10, 7, 0, 0, time.UTC)
+
+	query := faqQuery()
+	if !queryDue(query, schedule, now) {
+		t.Error("expected a query that never ran to be due")
+	}
+
+	query.Status.ObservedGeneration = 1
+	query.Status.LastRunTime = &metav1.Time{Time: now.Add(-time.Minute)}
+	if queryDue(query, schedule, now) {
+		t.Error("expected a query asked after the last schedule to wait")
+	}
+	query.Status.LastRunTime = &metav1.Time{Time: now.Add(-3 * time.Minute)}
+	if !queryDue(query, schedule, now) {
+		t.Error("expected a query asked before the last schedule to be due")
+	}
+
+	query.Status.LastRunTime = &metav1.Time{Time: now}
+	query.Generation = 2
+	if !queryDue(query, schedule, now) {
+		t.Error("expected a changed query to be due")
+	}
+}
+
+func TestAskQuery(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body := map[string]string{}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil || req.Method != http.MethodPost || body["query"] != "What is RAGme?" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"status":"success","response":"RAGme is a retrieval-augmented agent"}`))
+	}))
+	defer server.Close()
+
+	run := askQuery(context.Background(), server.Client(), server.URL+"/query", "What is RAGme?", time.Second)
+	if run.Err != nil || run.Answer != "RAGme is a retrieval-augmented agent" || run.Latency <= 0 {
+		t.Errorf("askQuery() = %+v, want the answer", run)
+	}
+
+	run = askQuery(context.Background(), server.Client(), server.URL+"/query", "", time.Second)
+	if run.Err == nil {
+		t.Error("askQuery() accepted an error response")
+	}
+}
+
+func TestMissingKeywords(t *testing.T) {
+	spec := faqQuery().Spec
+	if missing := missingKeywords(spec, "RAGme is a RETRIEVAL tool"); !reflect.DeepEqual(missing, []string{"agent"}) {
+		t.Errorf("missingKeywords() = %v, want [agent]", missing)
+	}
+	spec.Match = queryMatchAny
+	if missing := missingKeywords(spec, "RAGme is a RETRIEVAL tool"); missing != nil {
+		t.Errorf("missingKeywords() = %v, want none with any match", missing)
+	}
+	if missing := missingKeywords(spec, "RAGme is a tool"); len(missing) != 2 {
+		t.Errorf("missingKeywords() = %v, want both keywords", missing)
+	}
+}
+
+func TestRecordQueryRun(t *testing.T) {
+	query := faqQuery()
+	now := time.Now()
+
+	recordQueryRun(query, queryRun{Answer: "retrieval and agent", Latency: 1200 * time.Millisecond}, time.Second, now)
+	if query.Status.Result != queryFailed || query.Status.ConsecutiveFailures != 1 || query.Status.Latency != "1.2s" {
+		t.Errorf("status = %+v, want a failure above the latency limit", query.Status)
+	}
+	if !conditions.IsTrue(query.Status.Conditions, conditions.TypeDegraded) {
+		t.Error("expected the query to be degraded")
+	}
+
+	recordQueryRun(query, queryRun{Answer: "retrieval"}, 0, now)
+	if query.Status.Result != queryFailed || query.Status.ConsecutiveFailures != 2 || len(query.Status.MissingKeywords) != 1 {
+		t.Errorf("status = %+v, want a failure with a missing keyword", query.Status)
+	}
+
+	recordQueryRun(query, queryRun{Answer: "retrieval and agent", Latency: 300 * time.Millisecond}, time.Second, now)
+	if query.Status.Result != queryPassed || query.Status.ConsecutiveFailures != 0 || query.Status.Runs != 3 || query.Status.PassedRuns != 1 {
+		t.Errorf("status = %+v, want a pass", query.Status)
+	}
+	if !conditions.IsTrue(query.Status.Conditions, conditions.TypeReady) {
+		t.Error("expected the query to be ready")
+	}
+}
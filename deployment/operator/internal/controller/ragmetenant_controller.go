@@ -0,0 +1,254 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// RAGmeTenantReconciler reconciles a RAGmeTenant object
+type RAGmeTenantReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// HTTPClient is used to scrape MinIO usage metrics. Defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+
+	// Resync controls the periodic resync of reconciled resources
+	Resync ResyncConfig
+}
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmetenants,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmetenants/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile enforces the tenant storage quota on its MinIO bucket and reports
+// the tenant usage. The tenant configuration consumed by the api is rendered
+// by the RAGme controller.
+func (r *RAGmeTenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	tenant := &ragmev1.RAGmeTenant{}
+	if err := r.Get(ctx, req.NamespacedName, tenant); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("RAGmeTenant resource not found. Ignoring since object must be deleted")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get RAGmeTenant")
+		return ctrl.Result{}, err
+	}
+
+	ragme := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: tenant.Spec.InstanceRef, Namespace: tenant.Namespace}, ragme); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		conditions.MarkReconciling(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonInstanceNotFound,
+			"RAGme instance "+tenant.Spec.InstanceRef+" not found")
+		tenant.Status.Phase = conditions.Phase(tenant.Status.Conditions)
+		if err := r.Status().Update(ctx, tenant); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+	}
+
+	applied := true
+	if ragme.Spec.Storage.MinIO.Enabled {
+		var err error
+		if applied, err = r.reconcileBucketQuota(ctx, ragme, tenant); err != nil {
+			logger.Error(err, "Failed to reconcile tenant bucket quota")
+			conditions.MarkDegraded(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonReconcileFailed, err.Error())
+			tenant.Status.Phase = conditions.Phase(tenant.Status.Conditions)
+			tenant.Status.ObservedGeneration = tenant.Generation
+			if statusErr := r.Status().Update(ctx, tenant); statusErr != nil {
+				logger.Error(statusErr, "Failed to update RAGmeTenant status")
+			}
+			return ctrl.Result{RequeueAfter: time.Minute}, err
+		}
+
+		if ragme.Spec.Storage.MinIO.Metrics.Enabled {
+			if err := r.updateUsage(ctx, ragme, tenant); err != nil {
+				// Usage is informational, report it on the next pass
+				logger.Error(err, "Failed to collect tenant usage")
+			}
+		}
+	}
+
+	// Ready once the quota Job succeeded, its progress or failure is reported until then
+	if applied {
+		conditions.MarkReady(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonReconciled, "Tenant quotas are applied")
+	}
+	tenant.Status.Phase = conditions.Phase(tenant.Status.Conditions)
+	tenant.Status.ObservedGeneration = tenant.Generation
+	if err := r.Status().Update(ctx, tenant); err != nil {
+		logger.Error(err, "Failed to update RAGmeTenant status")
+		return ctrl.Result{}, err
+	}
+
+	return r.Resync.Result(tenant), nil
+}
+
+// tenantBucket returns the MinIO bucket of the tenant
+func tenantBucket(tenant *ragmev1.RAGmeTenant) string {
+	if tenant.Spec.Bucket != "" {
+		return tenant.Spec.Bucket
+	}
+	return fmt.Sprintf("ragme-%s", tenant.Name)
+}
+
+// reconcileBucketQuota runs a MinIO client Job creating the tenant bucket and
+// applying its storage quota, and reports whether the quota is applied. A new
+// Job is created whenever the quota changes. The Job outcome is reported in
+// the Ready condition: Reconciling while it runs, Degraded when it failed.
+func (r *RAGmeTenantReconciler) reconcileBucketQuota(ctx context.Context, ragme *ragmev1.RAGme, tenant *ragmev1.RAGmeTenant) (bool, error) {
+	bucket := tenantBucket(tenant)
+
+	quotaBytes := ""
+	if tenant.Spec.Quotas.MaxStorage != "" {
+		quantity, err := resource.ParseQuantity(tenant.Spec.Quotas.MaxStorage)
+		if err != nil {
+			return false, fmt.Errorf("invalid maxStorage %q: %w", tenant.Spec.Quotas.MaxStorage, err)
+		}
+		quotaBytes = fmt.Sprintf("%d", quantity.Value())
+	}
+
+	sum := sha256.Sum256([]byte(bucket + "/" + quotaBytes))
+	hash := hex.EncodeToString(sum[:])[:8]
+	if tenant.Status.AppliedQuota == hash {
+		return true, nil
+	}
+	job := createBucketQuotaJob(ragme, tenant, bucket, quotaBytes, hash)
+	if err := ctrl.SetControllerReference(tenant, job, r.Scheme); err != nil {
+		return false, err
+	}
+	applyOwnershipLabels(ragme, job)
+
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		conditions.MarkReconciling(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonApplyingQuota,
+			fmt.Sprintf("Applying the quota of bucket %s", bucket))
+		return false, r.Create(ctx, job)
+	} else if err != nil {
+		return false, err
+	}
+
+	switch {
+	case found.Status.Succeeded > 0:
+		tenant.Status.AppliedQuota = hash
+		return true, nil
+	case jobFailed(found):
+		conditions.MarkDegraded(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonQuotaFailed,
+			fmt.Sprintf("Applying the quota of bucket %s failed, see the logs of job %s", bucket, found.Name))
+	default:
+		conditions.MarkReconciling(&tenant.Status.Conditions, tenant.Generation, conditions.ReasonApplyingQuota,
+			fmt.Sprintf("Applying the quota of bucket %s", bucket))
+	}
+	return false, nil
+}
+
+// createBucketQuotaJob returns the Job creating the tenant bucket and setting its quota
+func createBucketQuotaJob(ragme *ragmev1.RAGme, tenant *ragmev1.RAGmeTenant, bucket, quotaBytes, hash string) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "tenant-quota",
+		"instance":  ragme.Name,
+		"tenant":    tenant.Name,
+	}
+
+	script := `mc alias set ragme "http://${MINIO_ENDPOINT}" "${MINIO_ACCESS_KEY}" "${MINIO_SECRET_KEY}" && ` +
+		`mc mb --ignore-existing "ragme/${BUCKET}" && ` +
+		`if [ -n "${QUOTA_BYTES}" ]; then mc quota set "ragme/${BUCKET}" --size "${QUOTA_BYTES}"; ` +
+		`else mc quota clear "ragme/${BUCKET}"; fi`
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-quota-%s", tenant.Name, hash),
+			Namespace: tenant.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{6}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name:    "mc",
+							Image:   "minio/mc:latest",
+							Command: []string{"/bin/sh", "-c", script},
+							Env: append(append([]corev1.EnvVar{
+								{Name: "MINIO_ENDPOINT", Value: fmt.Sprintf("%s-minio:9000", ragme.Name)},
+							}, minioRootCredentials(ragme).env("MINIO_ACCESS_KEY", "MINIO_SECRET_KEY")...),
+								corev1.EnvVar{Name: "BUCKET", Value: bucket},
+								corev1.EnvVar{Name: "QUOTA_BYTES", Value: quotaBytes},
+							),
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
+
+// updateUsage reads the tenant bucket usage from the MinIO bucket metrics and
+// flips the QuotaExceeded condition when a quota is crossed
+func (r *RAGmeTenantReconciler) updateUsage(ctx context.Context, ragme *ragmev1.RAGme, tenant *ragmev1.RAGmeTenant) error {
+	samples, err := scrapeMinIOMetrics(ctx, r.Client, defaultHTTPClient(r.HTTPClient), ragme, "/minio/v2/metrics/bucket",
+		"minio_bucket_usage_total_bytes", "minio_bucket_usage_object_total")
+	if err != nil {
+		return err
+	}
+
+	bucket := map[string]string{"bucket": tenantBucket(tenant)}
+	tenant.Status.Usage = ragmev1.RAGmeTenantUsage{
+		Documents:    int64(sumSamples(samples, "minio_bucket_usage_object_total", bucket)),
+		StorageBytes: int64(sumSamples(samples, "minio_bucket_usage_total_bytes", bucket)),
+	}
+
+	quotas := tenant.Spec.Quotas
+	status, reason, message := metav1.ConditionFalse, conditions.ReasonWithinQuota, "Tenant usage is within its quotas"
+	if quotas.MaxDocuments > 0 && tenant.Status.Usage.Documents >= quotas.MaxDocuments {
+		status, reason = metav1.ConditionTrue, conditions.ReasonDocumentQuotaExceeded
+		message = fmt.Sprintf("Tenant stores %d documents (quota %d)", tenant.Status.Usage.Documents, quotas.MaxDocuments)
+	} else if quotas.MaxStorage != "" {
+		if quantity, err := resource.ParseQuantity(quotas.MaxStorage); err == nil && tenant.Status.Usage.StorageBytes >= quantity.Value() {
+			status, reason = metav1.ConditionTrue, conditions.ReasonStorageQuotaExceeded
+			message = fmt.Sprintf("Tenant stores %d bytes (quota %s)", tenant.Status.Usage.StorageBytes, quotas.MaxStorage)
+		}
+	}
+	conditions.Set(&tenant.Status.Conditions, tenant.Generation, conditions.TypeQuotaExceeded, status, reason, message)
+
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RAGmeTenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&ragmev1.RAGmeTenant{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
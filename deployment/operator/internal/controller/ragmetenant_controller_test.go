@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestTenantReadyOnceQuotaApplied(t *testing.T) {
+	h := newReconcilerHarness(t)
+	tenant := &ragmev1.RAGmeTenant{
+		ObjectMeta: metav1.ObjectMeta{Name: "engineering", Namespace: "bench"},
+		Spec: ragmev1.RAGmeTenantSpec{
+			InstanceRef: "quota",
+			Quotas:      ragmev1.RAGmeTenantQuotas{MaxStorage: "20Gi"},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(h.reconciler.Scheme).
+		WithObjects(harnessRAGme("quota"), tenant).
+		WithStatusSubresource(&ragmev1.RAGmeTenant{}).
+		Build()
+	r := &RAGmeTenantReconciler{Client: c, Scheme: h.reconciler.Scheme}
+	key := types.NamespacedName{Namespace: "bench", Name: "engineering"}
+
+	reconcile := func() *ragmev1.RAGmeTenant {
+		t.Helper()
+		if _, err := r.Reconcile(h.ctx, ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		got := &ragmev1.RAGmeTenant{}
+		if err := c.Get(h.ctx, key, got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	quotaJob := func() *batchv1.Job {
+		t.Helper()
+		jobs := &batchv1.JobList{}
+		if err := c.List(h.ctx, jobs, client.MatchingLabels{"component": "tenant-quota"}); err != nil {
+			t.Fatal(err)
+		}
+		if len(jobs.Items) != 1 {
+			t.Fatalf("%d quota Jobs, want 1", len(jobs.Items))
+		}
+		return &jobs.Items[0]
+	}
+	setJobStatus := func(job *batchv1.Job, status batchv1.JobStatus) {
+		t.Helper()
+		job.Status = status
+		if err := c.Status().Update(h.ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := reconcile()
+	if conditions.IsTrue(got.Status.Conditions, conditions.TypeReady) || !conditions.IsTrue(got.Status.Conditions, conditions.TypeReconciling) {
+		t.Errorf("conditions = %+v, want Reconciling while the quota Job runs", got.Status.Conditions)
+	}
+
+	job := quotaJob()
+	setJobStatus(job, batchv1.JobStatus{Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}})
+	got = reconcile()
+	if degraded := meta.FindStatusCondition(got.Status.Conditions, conditions.TypeDegraded); degraded == nil || degraded.Reason != conditions.ReasonQuotaFailed {
+		t.Errorf("conditions = %+v, want Degraded by the failed quota Job", got.Status.Conditions)
+	}
+
+	setJobStatus(job, batchv1.JobStatus{Succeeded: 1})
+	got = reconcile()
+	if !conditions.IsTrue(got.Status.Conditions, conditions.TypeReady) || got.Status.AppliedQuota == "" {
+		t.Errorf("status = %+v, want Ready with the quota recorded", got.Status)
+	}
+
+	// A Job removed after its TTL is not run again
+	if err := c.Delete(h.ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	got = reconcile()
+	jobs := &batchv1.JobList{}
+	if err := c.List(h.ctx, jobs); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Items) != 0 || !conditions.IsTrue(got.Status.Conditions, conditions.TypeReady) {
+		t.Errorf("%d Jobs, status %+v, want the applied quota kept Ready without a new Job", len(jobs.Items), got.Status)
+	}
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultReadReplicas = 2
+
+	apiReadComponent = "api-read"
+)
+
+// readReplicas returns the replicas of the read path
+func readReplicas(ragme *ragmev1.RAGme) int32 {
+	if ragme.Spec.ReadReplicas.Replicas > 0 {
+		return ragme.Spec.ReadReplicas.Replicas
+	}
+	return defaultReadReplicas
+}
+
+// apiQueryService returns the api Service serving the read-only endpoints
+func apiQueryService(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.ReadReplicas.Enabled {
+		return apiReadComponent
+	}
+	return "api"
+}
+
+// applyReadReplicas flips the api into its write mode and points the other
+// services to the read path for their queries
+func applyReadReplicas(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if !ragme.Spec.ReadReplicas.Enabled {
+		return
+	}
+	env := corev1.EnvVar{Name: "RAGME_API_READ_URL", Value: fmt.Sprintf("http://%s-%s:8021", ragme.Name, apiReadComponent)}
+	if serviceName == "api" {
+		env = corev1.EnvVar{Name: "RAGME_API_MODE", Value: "write"}
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env)
+}
+
+// componentLabels returns the labels of a generated component
+func componentLabels(ragme *ragmev1.RAGme, component string) map[string]string {
+	return map[string]string{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}
+}
+
+// createReadAPIDeployment returns the Deployment of the read path: the api
+// in its read mode, under its own component label so its pods can be
+// selected apart from the ingestion path
+func (r *RAGmeReconciler) createReadAPIDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	deployment := r.createRAGmeServiceDeployment(ragme, "api")
+	deployment.Name = fmt.Sprintf("%s-%s", ragme.Name, apiReadComponent)
+
+	// The api labels are shared by the Deployment, its selector and its pods
+	labels := componentLabels(ragme, apiReadComponent)
+	deployment.Labels = mergeStringMaps(mergeStringMaps(nil, deployment.Labels), labels)
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	deployment.Spec.Template.Labels = mergeStringMaps(mergeStringMaps(nil, deployment.Spec.Template.Labels), labels)
+
+	replicas := hibernationReplicas(ragme, maintenanceReplicas(ragme, "api", readReplicas(ragme)))
+	deployment.Spec.Replicas = &replicas
+
+	env := deployment.Spec.Template.Spec.Containers[0].Env
+	for i := range env {
+		if env[i].Name == "RAGME_API_MODE" {
+			env[i].Value = "read"
+		}
+	}
+	return deployment
+}
+
+// createAPIPathService returns the Service of the read or write path of the api
+func createAPIPathService(ragme *ragmev1.RAGme, component string, selector map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", ragme.Name, component),
+			Namespace: ragme.Namespace,
+			Labels:    componentLabels(ragme, component),
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 8021, TargetPort: intstr.FromInt(8021)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
+
+// reconcileReadReplicas runs the read path of the api and the api-read and
+// api-write Services, or removes them when the read replicas are disabled.
+// The api Service keeps selecting the write path.
+func (r *RAGmeReconciler) reconcileReadReplicas(ctx context.Context, ragme *ragmev1.RAGme) error {
+	services := []*corev1.Service{
+		createAPIPathService(ragme, apiReadComponent, componentLabels(ragme, apiReadComponent)),
+		createAPIPathService(ragme, "api-write", componentLabels(ragme, "api")),
+	}
+	if !ragme.Spec.ReadReplicas.Enabled {
+		objects := []client.Object{
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("%s-%s", ragme.Name, apiReadComponent), Namespace: ragme.Namespace}},
+			services[0], services[1],
+		}
+		for _, obj := range objects {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	deployment := r.createReadAPIDeployment(ragme)
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	for _, service := range services {
+		if err := r.setOwner(ragme, service); err != nil {
+			return err
+		}
+		foundService := &corev1.Service{}
+		err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+		if err != nil && errors.IsNotFound(err) {
+			if err := r.Create(ctx, service); err != nil {
+				return err
+			}
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestReadAPIDeployment(t *testing.T) {
+	r := &RAGmeReconciler{}
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.ReadReplicas.Enabled = true
+
+	write := r.createRAGmeServiceDeployment(ragme, "api")
+	read := r.createReadAPIDeployment(ragme)
+	if read.Name != "test-api-read" || *read.Spec.Replicas != 2 {
+		t.Errorf("read Deployment %s has %d replicas, want test-api-read with 2", read.Name, *read.Spec.Replicas)
+	}
+	if component := read.Spec.Selector.MatchLabels["component"]; component != "api-read" || read.Spec.Template.Labels["component"] != "api-read" {
+		t.Errorf("read selector = %v, want the api-read component", read.Spec.Selector.MatchLabels)
+	}
+	if component := write.Spec.Selector.MatchLabels["component"]; component != "api" {
+		t.Errorf("write selector = %v, want the api component", write.Spec.Selector.MatchLabels)
+	}
+	if mode := envValue(write.Spec.Template.Spec.Containers[0].Env, "RAGME_API_MODE"); mode != "write" {
+		t.Errorf("write RAGME_API_MODE = %q", mode)
+	}
+	if mode := envValue(read.Spec.Template.Spec.Containers[0].Env, "RAGME_API_MODE"); mode != "read" {
+		t.Errorf("read RAGME_API_MODE = %q", mode)
+	}
+	frontend := r.createRAGmeServiceDeployment(ragme, "frontend")
+	if url := envValue(frontend.Spec.Template.Spec.Containers[0].Env, "RAGME_API_READ_URL"); url != "http://test-api-read:8021" {
+		t.Errorf("frontend RAGME_API_READ_URL = %q", url)
+	}
+
+	service := createAPIPathService(ragme, "api-write", componentLabels(ragme, "api"))
+	if service.Name != "test-api-write" || service.Spec.Selector["component"] != "api" {
+		t.Errorf("api-write Service %s selects %v, want the api pods", service.Name, service.Spec.Selector)
+	}
+}
+
+func TestPublicIngressReadPath(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.ExternalAccess.Ingress.Host = "ragme.example.com"
+	backend := func() string {
+		return createPublicIngress(ragme, "test-public").Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name
+	}
+	if name := backend(); name != "test-api" {
+		t.Errorf("query backend = %s, want the api", name)
+	}
+	ragme.Spec.ReadReplicas.Enabled = true
+	if name := backend(); name != "test-api-read" {
+		t.Errorf("query backend = %s, want the read path", name)
+	}
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// readinessComponents maps the component label of a generated workload to the
+// component it is reported under. The read path of the api and the additional
+// Weaviate shards count towards their component.
+var readinessComponents = map[string]string{
+	apiReadComponent: "api",
+	"weaviate-shard": "weaviate",
+}
+
+// componentReadiness is the readiness of the workloads of a component
+type componentReadiness struct {
+	replicas      int32
+	readyReplicas int32
+}
+
+// aggregateReadiness reports the readiness of the workloads of the instance
+// in its status. The Deployments and Services are listed from the manager
+// cache, which the Owns watches keep current, so every event of a workload
+// recomputes the summary without a Get per component.
+func (r *RAGmeReconciler) aggregateReadiness(ctx context.Context, ragme *ragmev1.RAGme) error {
+	selector := client.MatchingLabels{"app": "ragme", "instance": ragme.Name}
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(ragme.Namespace), selector); err != nil {
+		return err
+	}
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, client.InNamespace(ragme.Namespace), selector); err != nil {
+		return err
+	}
+
+	components := map[string]*componentReadiness{}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		name := readinessComponent(deployment.Labels["component"])
+		if name == "" || !metav1.IsControlledBy(deployment, ragme) {
+			continue
+		}
+		component := components[name]
+		if component == nil {
+			component = &componentReadiness{}
+			components[name] = component
+		}
+		component.replicas += deploymentReplicas(deployment)
+		component.readyReplicas += deployment.Status.ReadyReplicas
+	}
+
+	urls := map[string]string{}
+	for _, service := range services.Items {
+		// Only the Service of the component itself, not of its shards or read path
+		name := service.Labels["component"]
+		if readinessComponent(name) == name && len(service.Spec.Ports) > 0 {
+			urls[name] = fmt.Sprintf("http://%s.%s.svc:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port)
+		}
+	}
+
+	serviceStatus := ragmev1.RAGmeServiceStatus{}
+	readiness := ragmev1.RAGmeReadinessStatus{Components: int32(len(components))}
+	for name, component := range components {
+		ready := component.readyReplicas >= component.replicas
+		if ready {
+			readiness.ReadyComponents++
+		} else {
+			readiness.NotReady = append(readiness.NotReady, name)
+		}
+		if status := serviceComponentStatus(&serviceStatus, name); status != nil {
+			*status = ragmev1.ServiceComponentStatus{Ready: ready, Replicas: component.readyReplicas, URL: urls[name]}
+		}
+	}
+	sort.Strings(readiness.NotReady)
+	readiness.Summary = fmt.Sprintf("%d/%d", readiness.ReadyComponents, readiness.Components)
+
+	ragme.Status.Services = serviceStatus
+	ragme.Status.Readiness = readiness
+	return nil
+}
+
+// readinessComponent returns the component a workload label is reported under
+func readinessComponent(label string) string {
+	if component, ok := readinessComponents[label]; ok {
+		return component
+	}
+	return label
+}
+
+// serviceComponentStatus returns the service status of a component, or nil
+// for the components without one
+func serviceComponentStatus(services *ragmev1.RAGmeServiceStatus, component string) *ragmev1.ServiceComponentStatus {
+	switch component {
+	case "api":
+		return &services.API
+	case "mcp":
+		return &services.MCP
+	case "agent":
+		return &services.Agent
+	case "frontend":
+		return &services.Frontend
+	case "minio":
+		return &services.MinIO
+	case "weaviate":
+		return &services.Weaviate
+	}
+	return nil
+}
+
+// deploymentReplicas returns the desired replicas of a Deployment
+func deploymentReplicas(deployment *appsv1.Deployment) int32 {
+	if deployment.Spec.Replicas != nil {
+		return *deployment.Spec.Replicas
+	}
+	return 1
+}
+
+// workloadChanged filters the events of the owned Deployments down to the
+// ones a reconcile acts on: a changed spec or metadata, reverted as drift, or
+// changed replica counts, which change the readiness of the instance. The
+// status heartbeats of the Deployment controller are dropped.
+var workloadChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldDeployment, ok := e.ObjectOld.(*appsv1.Deployment)
+		newDeployment, ok2 := e.ObjectNew.(*appsv1.Deployment)
+		if !ok || !ok2 {
+			return true
+		}
+		if oldDeployment.Generation != newDeployment.Generation ||
+			!equality.Semantic.DeepEqual(oldDeployment.Labels, newDeployment.Labels) ||
+			!equality.Semantic.DeepEqual(oldDeployment.Annotations, newDeployment.Annotations) ||
+			!equality.Semantic.DeepEqual(oldDeployment.OwnerReferences, newDeployment.OwnerReferences) ||
+			!newDeployment.DeletionTimestamp.IsZero() {
+			return true
+		}
+		return oldDeployment.Status.Replicas != newDeployment.Status.Replicas ||
+			oldDeployment.Status.ReadyReplicas != newDeployment.Status.ReadyReplicas ||
+			oldDeployment.Status.AvailableReplicas != newDeployment.Status.AvailableReplicas ||
+			oldDeployment.Status.UpdatedReplicas != newDeployment.Status.UpdatedReplicas
+	},
+}
@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestAggregateReadiness(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("ready"))
+	h.reconcile(t, "ready")
+
+	got := h.get(t, "ready")
+	readiness := got.Status.Readiness
+	if readiness.Components == 0 || readiness.ReadyComponents != 0 || len(readiness.NotReady) != int(readiness.Components) {
+		t.Errorf("readiness = %+v, want no component ready before the pods", readiness)
+	}
+
+	// Every ready replica reported by a workload shows in the next pass
+	deployments := &appsv1.DeploymentList{}
+	if err := h.client.List(h.ctx, deployments, client.InNamespace("bench"), client.MatchingLabels{"instance": "ready"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		deployment.Status.ReadyReplicas = deploymentReplicas(deployment)
+		if deployment.Labels["component"] == "frontend" {
+			deployment.Status.ReadyReplicas = 0
+		}
+		if err := h.client.Status().Update(h.ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h.reconcile(t, "ready")
+
+	got = h.get(t, "ready")
+	readiness = got.Status.Readiness
+	if readiness.ReadyComponents != readiness.Components-1 || len(readiness.NotReady) != 1 || readiness.NotReady[0] != "frontend" {
+		t.Errorf("readiness = %+v, want all components but the frontend ready", readiness)
+	}
+	api := got.Status.Services.API
+	if !api.Ready || api.Replicas != got.Spec.Replicas.API || api.URL != "http://ready-api.bench.svc:8021" {
+		t.Errorf("api status = %+v, want its ready replicas and Service", api)
+	}
+	if got.Status.Services.Frontend.Ready {
+		t.Error("frontend reported ready without ready replicas")
+	}
+}
+
+func TestWorkloadChanged(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	h := newReconcilerHarness(t, harnessRAGme("watched"))
+	h.reconcile(t, "watched")
+	if err := h.client.Get(h.ctx, types.NamespacedName{Namespace: "bench", Name: "watched-api"}, deployment); err != nil {
+		t.Fatal(err)
+	}
+
+	heartbeat := deployment.DeepCopy()
+	heartbeat.Status.ObservedGeneration++
+	if workloadChanged.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: heartbeat}) {
+		t.Error("a status heartbeat triggered a reconcile")
+	}
+
+	ready := deployment.DeepCopy()
+	ready.Status.ReadyReplicas++
+	edited := deployment.DeepCopy()
+	edited.Generation++
+	for name, changed := range map[string]*appsv1.Deployment{"ready replicas": ready, "spec": edited} {
+		if !workloadChanged.Update(event.UpdateEvent{ObjectOld: deployment, ObjectNew: changed}) {
+			t.Errorf("a changed %s did not trigger a reconcile", name)
+		}
+	}
+}
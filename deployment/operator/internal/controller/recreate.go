@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// isImmutableFieldError reports whether the API server rejected an update
+// because it changes an immutable field
+func isImmutableFieldError(err error) bool {
+	if !errors.IsInvalid(err) {
+		return false
+	}
+	if status, ok := err.(errors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if strings.Contains(cause.Message, "field is immutable") {
+				return true
+			}
+		}
+	}
+	return strings.Contains(err.Error(), "field is immutable")
+}
+
+// updateOrRecreate updates found, which already carries the desired state.
+// When the update changes an immutable field, the resource is deleted and
+// desired is created in its place if spec.recreateOnImmutableChange is set,
+// otherwise the BlockedByImmutableField condition is raised. Resources
+// released for manual edits with the break-glass annotation are left alone.
+func (r *RAGmeReconciler) updateOrRecreate(ctx context.Context, ragme *ragmev1.RAGme, found, desired client.Object) error {
+	if breakGlass(found) {
+		log.FromContext(ctx).Info("Skipping the update of a resource released for manual edits", "resource", found.GetName())
+		return nil
+	}
+	err := r.Update(ctx, found)
+	if err == nil || !isImmutableFieldError(err) {
+		return err
+	}
+
+	kind := "resource"
+	if gvk, gvkErr := apiutil.GVKForObject(found, r.Scheme); gvkErr == nil {
+		kind = gvk.Kind
+	}
+	resource := fmt.Sprintf("%s %s", kind, found.GetName())
+
+	// Recreating a claim would lose its data
+	_, isPVC := found.(*corev1.PersistentVolumeClaim)
+	if !ragme.Spec.RecreateOnImmutableChange || isPVC {
+		message := fmt.Sprintf("%s cannot be updated in place: %v", resource, err)
+		if isPVC {
+			message += "; PersistentVolumeClaims are never recreated"
+		} else {
+			message += "; set spec.recreateOnImmutableChange to recreate it"
+		}
+		conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeBlockedByImmutableField,
+			conditions.ReasonImmutableFieldChanged, message)
+		return fmt.Errorf("%s is blocked by an immutable field change: %w", resource, err)
+	}
+
+	log.FromContext(ctx).Info("Recreating resource to apply an immutable field change", "resource", resource)
+	if err := r.Delete(ctx, found, client.PropagationPolicy("Background")); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	desired.SetResourceVersion("")
+	// The deletion may still be in progress, the next reconciliation retries the create
+	return r.Create(ctx, desired)
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestIsImmutableFieldError(t *testing.T) {
+	gk := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	immutable := errors.NewInvalid(gk, "ragme-api", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "selector"), nil, "field is immutable"),
+	})
+	otherInvalid := errors.NewInvalid(gk, "ragme-api", field.ErrorList{
+		field.Invalid(field.NewPath("spec", "replicas"), -1, "must be greater than or equal to 0"),
+	})
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "immutable selector", err: immutable, want: true},
+		{name: "other validation error", err: otherInvalid},
+		{name: "conflict", err: errors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "ragme-api", fmt.Errorf("stale"))},
+		{name: "plain error", err: fmt.Errorf("field is immutable")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isImmutableFieldError(tt.err); got != tt.want {
+				t.Errorf("isImmutableFieldError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=delete
+
+const (
+	defaultResilienceSchedule = "0 10 * * 2"
+	defaultRecoveryTimeout    = 5 * time.Minute
+
+	// resilienceStartWindow bounds how long after its schedule fired a
+	// verification may start, so a missed window is not caught up at any time
+	resilienceStartWindow = 30 * time.Minute
+
+	// resilienceCheckHistory is the number of completed verifications kept in status
+	resilienceCheckHistory = 10
+
+	// resilienceRequeue is the interval of the recovery checks
+	resilienceRequeue = 15 * time.Second
+
+	resiliencePassed = "Passed"
+	resilienceFailed = "Failed"
+)
+
+// resilienceComponents lists the stateless services a verification may
+// disrupt. The data stores are never disrupted
+var resilienceComponents = []string{"api", "mcp", "agent", "frontend"}
+
+// disruptableComponent reports whether component is one of the resilienceComponents
+func disruptableComponent(component string) bool {
+	for _, known := range resilienceComponents {
+		if component == known {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultResilienceComponents are the services whose loss users barely notice
+var defaultResilienceComponents = []string{"mcp", "frontend"}
+
+// verifiedComponents returns the components verified in turn
+func verifiedComponents(ragme *ragmev1.RAGme) []string {
+	if components := ragme.Spec.Resilience.Components; len(components) > 0 {
+		return components
+	}
+	return defaultResilienceComponents
+}
+
+// resilienceDue reports whether a verification window opened at most
+// resilienceStartWindow before now and has not been used yet
+func resilienceDue(ragme *ragmev1.RAGme, now time.Time) (bool, error) {
+	resilience := ragme.Spec.Resilience
+	location := time.UTC
+	if resilience.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(resilience.TimeZone); err != nil {
+			return false, fmt.Errorf("timeZone: %w", err)
+		}
+	}
+	expr := resilience.Schedule
+	if expr == "" {
+		expr = defaultResilienceSchedule
+	}
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return false, fmt.Errorf("schedule: %w", err)
+	}
+
+	fired := schedule.prev(now.In(location))
+	if fired.IsZero() || now.Sub(fired) > resilienceStartWindow {
+		return false, nil
+	}
+	checks := ragme.Status.Resilience.Checks
+	return len(checks) == 0 || checks[0].StartedAt.Time.Before(fired), nil
+}
+
+// nextVerifiedComponent returns the component following the last verified one
+func nextVerifiedComponent(ragme *ragmev1.RAGme) string {
+	components := verifiedComponents(ragme)
+	if checks := ragme.Status.Resilience.Checks; len(checks) > 0 {
+		for i, component := range components {
+			if component == checks[0].Component {
+				return components[(i+1)%len(components)]
+			}
+		}
+	}
+	return components[0]
+}
+
+// verifyResilience deletes a pod of a service when a verification window
+// opens, then checks that the service recovers within the recovery timeout.
+// Only a healthy instance is disrupted.
+func (r *RAGmeReconciler) verifyResilience(ctx context.Context, ragme *ragmev1.RAGme, now time.Time) error {
+	if !ragme.Spec.Resilience.Enabled {
+		ragme.Status.Resilience = ragmev1.RAGmeResilienceStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeSelfHealingVerified)
+		return nil
+	}
+	if ragme.Status.Resilience.Current != nil {
+		return r.checkRecovery(ctx, ragme, now)
+	}
+
+	if !conditions.IsTrue(ragme.Status.Conditions, conditions.TypeReady) ||
+		ragme.Status.Hibernation.Hibernated || ragme.Spec.MaintenanceMode != "" {
+		return nil
+	}
+	due, err := resilienceDue(ragme, now)
+	if err != nil || !due {
+		return err
+	}
+
+	component := nextVerifiedComponent(ragme)
+	deployment, pods, err := r.componentPods(ctx, ragme, component)
+	if err != nil {
+		return err
+	}
+	pod := verificationPod(deployment, pods)
+	if pod == nil {
+		log.FromContext(ctx).Info("Skipping self-healing verification of a component that is not fully available", "component", component)
+		return nil
+	}
+	if err := r.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	log.FromContext(ctx).Info("Deleted a pod to verify self-healing", "component", component, "pod", pod.Name)
+	ragme.Status.Resilience.Current = &ragmev1.RAGmeResilienceCheck{
+		Component: component,
+		Pod:       pod.Name,
+		StartedAt: metav1.Time{Time: now},
+	}
+	return nil
+}
+
+// checkRecovery completes the verification in progress once the component is
+// fully available again, or fails it after the recovery timeout
+func (r *RAGmeReconciler) checkRecovery(ctx context.Context, ragme *ragmev1.RAGme, now time.Time) error {
+	current := ragme.Status.Resilience.Current
+	timeout := durationOrDefault(ragme.Spec.Resilience.RecoveryTimeout, defaultRecoveryTimeout)
+
+	deployment, pods, err := r.componentPods(ctx, ragme, current.Component)
+	if err != nil {
+		return err
+	}
+	elapsed := now.Sub(current.StartedAt.Time)
+	switch {
+	case recovered(deployment, pods, current.StartedAt.Time):
+		finishResilienceCheck(ragme, resiliencePassed,
+			fmt.Sprintf("%s recovered in %s after losing pod %s", current.Component, elapsed.Round(time.Second), current.Pod), now)
+	case elapsed > timeout:
+		finishResilienceCheck(ragme, resilienceFailed,
+			fmt.Sprintf("%s did not recover within %s after losing pod %s", current.Component, timeout, current.Pod), now)
+	}
+	return nil
+}
+
+// componentPods returns the Deployment of a component and its pods
+func (r *RAGmeReconciler) componentPods(ctx context.Context, ragme *ragmev1.RAGme, component string) (*appsv1.Deployment, []corev1.Pod, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%s", ragme.Name, component), Namespace: ragme.Namespace}, deployment)
+	if err != nil {
+		return nil, nil, err
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":       "ragme",
+		"component": component,
+		"instance":  ragme.Name,
+	}); err != nil {
+		return nil, nil, err
+	}
+	return deployment, pods.Items, nil
+}
+
+// podReady reports whether a pod is running, ready and not terminating
+func podReady(pod *corev1.Pod) bool {
+	if !pod.DeletionTimestamp.IsZero() {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deploymentAvailable reports whether all the desired replicas of a Deployment are available
+func deploymentAvailable(deployment *appsv1.Deployment) bool {
+	desired := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return desired > 0 && deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas >= desired && deployment.Status.AvailableReplicas >= desired
+}
+
+// verificationPod returns the oldest ready pod of a fully available
+// component, or nil when the component is not healthy enough to be disrupted
+func verificationPod(deployment *appsv1.Deployment, pods []corev1.Pod) *corev1.Pod {
+	if !deploymentAvailable(deployment) {
+		return nil
+	}
+	var oldest *corev1.Pod
+	for i := range pods {
+		pod := &pods[i]
+		if podReady(pod) && (oldest == nil || pod.CreationTimestamp.Before(&oldest.CreationTimestamp)) {
+			oldest = pod
+		}
+	}
+	return oldest
+}
+
+// recovered reports whether a component is fully available again with a
+// ready pod created since the disruption
+func recovered(deployment *appsv1.Deployment, pods []corev1.Pod, since time.Time) bool {
+	if !deploymentAvailable(deployment) {
+		return false
+	}
+	for i := range pods {
+		if podReady(&pods[i]) && !pods[i].CreationTimestamp.Time.Before(since.Truncate(time.Second)) {
+			return true
+		}
+	}
+	return false
+}
+
+// finishResilienceCheck records the outcome of the verification in progress
+// and sets the SelfHealingVerified condition
+func finishResilienceCheck(ragme *ragmev1.RAGme, result, message string, now time.Time) {
+	status := &ragme.Status.Resilience
+	check := *status.Current
+	check.Result = result
+	check.Message = message
+	if result == resiliencePassed {
+		check.RecoveredAt = &metav1.Time{Time: now}
+	}
+	status.Current = nil
+	status.Checks = append([]ragmev1.RAGmeResilienceCheck{check}, status.Checks...)
+	if len(status.Checks) > resilienceCheckHistory {
+		status.Checks = status.Checks[:resilienceCheckHistory]
+	}
+
+	if result == resiliencePassed {
+		conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSelfHealingVerified,
+			metav1.ConditionTrue, conditions.ReasonRecoveredWithinTimeout, message)
+	} else {
+		conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSelfHealingVerified,
+			metav1.ConditionFalse, conditions.ReasonRecoveryTimedOut, message)
+	}
+}
+
+// resilienceResult shortens the requeue of result while a verification is in progress
+func resilienceResult(ragme *ragmev1.RAGme, result ctrl.Result) ctrl.Result {
+	if ragme.Status.Resilience.Current == nil {
+		return result
+	}
+	return requeueBefore(result, resilienceRequeue)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestResilienceDue(t *testing.T) {
+	// Tuesday 10:00 UTC opens the default window
+	window := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		now       time.Time
+		lastCheck time.Time
+		want      bool
+	}{
+		{name: "window just opened", now: window.Add(5 * time.Minute), want: true},
+		{name: "window missed", now: window.Add(2 * time.Hour), want: false},
+		{name: "window already used", now: window.Add(20 * time.Minute), lastCheck: window.Add(time.Minute), want: false},
+		{name: "previous window used", now: window.Add(5 * time.Minute), lastCheck: window.Add(-7 * 24 * time.Hour), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Resilience.Enabled = true
+			if !tt.lastCheck.IsZero() {
+				ragme.Status.Resilience.Checks = []ragmev1.RAGmeResilienceCheck{{Component: "mcp", StartedAt: metav1.Time{Time: tt.lastCheck}}}
+			}
+			got, err := resilienceDue(ragme, tt.now)
+			if err != nil || got != tt.want {
+				t.Errorf("resilienceDue() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextVerifiedComponent(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if got := nextVerifiedComponent(ragme); got != "mcp" {
+		t.Errorf("first component = %q, want mcp", got)
+	}
+	ragme.Status.Resilience.Checks = []ragmev1.RAGmeResilienceCheck{{Component: "mcp"}}
+	if got := nextVerifiedComponent(ragme); got != "frontend" {
+		t.Errorf("component after mcp = %q, want frontend", got)
+	}
+	ragme.Status.Resilience.Checks[0].Component = "frontend"
+	if got := nextVerifiedComponent(ragme); got != "mcp" {
+		t.Errorf("component after frontend = %q, want mcp", got)
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	disruptedAt := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	replicas := int32(2)
+	deployment := &appsv1.Deployment{
+		Spec:   appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	readyPod := func(name string, created time.Time) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: created}},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+			}},
+		}
+	}
+	old := readyPod("mcp-old", disruptedAt.Add(-time.Hour))
+	older := readyPod("mcp-older", disruptedAt.Add(-2*time.Hour))
+
+	if pod := verificationPod(deployment, []corev1.Pod{old, older}); pod == nil || pod.Name != "mcp-older" {
+		t.Errorf("verificationPod() = %v, want the oldest ready pod", pod)
+	}
+	if recovered(deployment, []corev1.Pod{old}, disruptedAt) {
+		t.Error("expected no recovery without a replacement pod")
+	}
+	if !recovered(deployment, []corev1.Pod{old, readyPod("mcp-new", disruptedAt.Add(30*time.Second))}, disruptedAt) {
+		t.Error("expected recovery once the replacement pod is ready")
+	}
+
+	deployment.Status.AvailableReplicas = 1
+	if pod := verificationPod(deployment, []corev1.Pod{old}); pod != nil {
+		t.Errorf("verificationPod() = %v, want nil for a degraded component", pod.Name)
+	}
+}
+
+func TestFinishResilienceCheck(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 5, 0, 0, time.UTC)
+	ragme := &ragmev1.RAGme{}
+	for i := 0; i < resilienceCheckHistory; i++ {
+		ragme.Status.Resilience.Checks = append(ragme.Status.Resilience.Checks, ragmev1.RAGmeResilienceCheck{Component: "mcp", Result: resiliencePassed})
+	}
+	ragme.Status.Resilience.Current = &ragmev1.RAGmeResilienceCheck{Component: "frontend", Pod: "frontend-1"}
+
+	finishResilienceCheck(ragme, resilienceFailed, "frontend did not recover", now)
+
+	status := ragme.Status.Resilience
+	if status.Current != nil || len(status.Checks) != resilienceCheckHistory || status.Checks[0].Component != "frontend" {
+		t.Errorf("status = %+v, want the failed check first and the history bounded", status)
+	}
+	condition := meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeSelfHealingVerified)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != conditions.ReasonRecoveryTimedOut {
+		t.Errorf("SelfHealingVerified = %+v, want False/%s", condition, conditions.ReasonRecoveryTimedOut)
+	}
+}
@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups=ragme.io,resources=ragmecollections,verbs=get;list;watch;create
+
+// restoreInProgress reports whether the data of spec.cloneFrom must be
+// restored before the instance is marked Ready, along with a progress message
+func restoreInProgress(ragme *ragmev1.RAGme) (string, bool) {
+	restore := ragme.Status.Restore
+	if restore.Source == "" || restore.Completed {
+		return "", false
+	}
+	return fmt.Sprintf("Restoring data from %s", restore.Source), true
+}
+
+// restoreVolumeSource returns the PVC of the source instance a new volume is
+// cloned from, or nil when the volume is not restored
+func restoreVolumeSource(ragme *ragmev1.RAGme, pvcName string) *corev1.TypedLocalObjectReference {
+	restore := ragme.Status.Restore
+	if restore.Source == "" || restore.Completed {
+		return nil
+	}
+	source := restore.Source + strings.TrimPrefix(pvcName, ragme.Name)
+	for _, volume := range restore.Volumes {
+		if volume == source {
+			return &corev1.TypedLocalObjectReference{Kind: "PersistentVolumeClaim", Name: source}
+		}
+	}
+	return nil
+}
+
+// prepareRestore plans the restore of a new instance from spec.cloneFrom:
+// it records the volumes of the source to clone and adopts its shard layout.
+// Instances whose volumes already exist are never overwritten.
+func (r *RAGmeReconciler) prepareRestore(ctx context.Context, ragme *ragmev1.RAGme) error {
+	clone := ragme.Spec.CloneFrom
+	if clone == nil || ragme.Status.Restore.Source != "" {
+		return nil
+	}
+
+	shared := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-shared-pvc", ragme.Name), Namespace: ragme.Namespace}, shared)
+	if err == nil && shared.Spec.DataSource == nil {
+		ragme.Status.Restore = ragmev1.RAGmeRestoreStatus{Source: clone.InstanceRef, Completed: true}
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored,
+			conditions.ReasonRestoreSkipped, "The instance already has data volumes, nothing was restored")
+		return nil
+	} else if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	source := &ragmev1.RAGme{}
+	if err := r.Get(ctx, types.NamespacedName{Name: clone.InstanceRef, Namespace: ragme.Namespace}, source); err != nil {
+		if errors.IsNotFound(err) {
+			conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored,
+				conditions.ReasonRestoreSourceNotFound, fmt.Sprintf("Source instance %s not found", clone.InstanceRef))
+			return fmt.Errorf("source instance %s not found", clone.InstanceRef)
+		}
+		return err
+	}
+
+	volumes := []string{fmt.Sprintf("%s-shared-pvc", source.Name)}
+	if source.Spec.Storage.MinIO.Enabled && ragme.Spec.Storage.MinIO.Enabled {
+		volumes = append(volumes, fmt.Sprintf("%s-minio-pvc", source.Name))
+	}
+	if source.Spec.VectorDB.Weaviate.Enabled && ragme.Spec.VectorDB.Weaviate.Enabled {
+		// Start with the layout of the source, a different shard count is rebalanced afterwards
+		for shard := int32(0); shard < activeShards(source); shard++ {
+			volumes = append(volumes, weaviateShardName(source, shard)+"-pvc")
+		}
+		ragme.Status.Sharding.Shards = source.Status.Sharding.Shards
+	}
+
+	ragme.Status.Restore = ragmev1.RAGmeRestoreStatus{Source: source.Name, Volumes: volumes}
+	conditions.SetUnknown(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored,
+		conditions.ReasonRestoring, fmt.Sprintf("Cloning %d volumes of %s", len(volumes), source.Name))
+	log.FromContext(ctx).Info("Restoring instance data", "source", source.Name, "volumes", volumes)
+	return nil
+}
+
+// reconcileRestore completes the restore once the cloned volumes are bound and
+// the vector database serves them, importing the collections of the source
+func (r *RAGmeReconciler) reconcileRestore(ctx context.Context, ragme *ragmev1.RAGme) error {
+	restore := &ragme.Status.Restore
+	if restore.Source == "" || restore.Completed {
+		return nil
+	}
+
+	for _, volume := range restore.Volumes {
+		pvc := &corev1.PersistentVolumeClaim{}
+		name := ragme.Name + strings.TrimPrefix(volume, restore.Source)
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ragme.Namespace}, pvc); err != nil {
+			return err
+		}
+		if pvc.Status.Phase != corev1.ClaimBound {
+			conditions.SetUnknown(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored,
+				conditions.ReasonRestoring, fmt.Sprintf("Waiting for volume %s to be cloned from %s", name, volume))
+			return nil
+		}
+	}
+
+	if ragme.Spec.VectorDB.Weaviate.Enabled {
+		ready, err := r.weaviateRolledOut(ctx, ragme)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			conditions.SetUnknown(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored,
+				conditions.ReasonRestoring, "Waiting for Weaviate to load the restored data")
+			return nil
+		}
+	}
+
+	imported, err := r.importCollections(ctx, ragme)
+	if err != nil {
+		return err
+	}
+
+	restore.Completed = true
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeRestored, conditions.ReasonRestoreSucceeded,
+		fmt.Sprintf("Restored %d volumes and imported %d collections from %s", len(restore.Volumes), imported, restore.Source))
+	return nil
+}
+
+// importCollections copies the RAGmeCollections of the source instance to the
+// restored instance. The copies keep the collection names, which the restored
+// vector database already holds.
+func (r *RAGmeReconciler) importCollections(ctx context.Context, ragme *ragmev1.RAGme) (int, error) {
+	collections := &ragmev1.RAGmeCollectionList{}
+	if err := r.List(ctx, collections, client.InNamespace(ragme.Namespace)); err != nil {
+		return 0, err
+	}
+
+	imported := 0
+	for i := range collections.Items {
+		source := &collections.Items[i]
+		if source.Spec.InstanceRef != ragme.Status.Restore.Source {
+			continue
+		}
+
+		collection := &ragmev1.RAGmeCollection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s", ragme.Name, source.Name),
+				Namespace: ragme.Namespace,
+				Labels:    withLabels(source.Labels, map[string]string{"instance": ragme.Name}),
+			},
+			Spec: *source.Spec.DeepCopy(),
+		}
+		collection.Spec.InstanceRef = ragme.Name
+		if collection.Spec.CollectionName == "" {
+			collection.Spec.CollectionName = source.Name
+		}
+		if err := r.setOwner(ragme, collection); err != nil {
+			return 0, err
+		}
+		if err := r.Create(ctx, collection); err != nil && !errors.IsAlreadyExists(err) {
+			return 0, err
+		}
+		imported++
+	}
+	return imported, nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRestoreVolumeSource(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "staging"}}
+	ragme.Status.Restore = ragmev1.RAGmeRestoreStatus{
+		Source:  "production",
+		Volumes: []string{"production-shared-pvc", "production-weaviate-pvc"},
+	}
+
+	tests := []struct {
+		pvc  string
+		want string
+	}{
+		{pvc: "staging-shared-pvc", want: "production-shared-pvc"},
+		{pvc: "staging-weaviate-pvc", want: "production-weaviate-pvc"},
+		// Shards added by a later rebalance start empty
+		{pvc: "staging-weaviate-1-pvc", want: ""},
+		{pvc: "staging-minio-pvc", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pvc, func(t *testing.T) {
+			got := restoreVolumeSource(ragme, tt.pvc)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("restoreVolumeSource() = %s, want nil", got.Name)
+			case tt.want != "" && (got == nil || got.Name != tt.want || got.Kind != "PersistentVolumeClaim"):
+				t.Errorf("restoreVolumeSource() = %v, want PersistentVolumeClaim %s", got, tt.want)
+			}
+		})
+	}
+
+	ragme.Status.Restore.Completed = true
+	if got := restoreVolumeSource(ragme, "staging-shared-pvc"); got != nil {
+		t.Errorf("restoreVolumeSource() = %s, want nil once the restore completed", got.Name)
+	}
+	if _, pending := restoreInProgress(ragme); pending {
+		t.Error("expected no restore in progress once completed")
+	}
+}
@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+const (
+	// ResyncPeriodAnnotation overrides the resync period of a single resource.
+	// It accepts a Go duration, "0" disables the periodic resync.
+	ResyncPeriodAnnotation = "ragme.io/resync-period"
+
+	// DefaultResyncPeriod is the resync period used when none is configured
+	DefaultResyncPeriod = 5 * time.Minute
+)
+
+// ResyncConfig controls the periodic resync used to detect and correct drift
+// that is not surfaced by watch events
+type ResyncConfig struct {
+	// Period between two reconciliations of an unchanged resource. Defaults to DefaultResyncPeriod
+	Period time.Duration
+
+	// Disabled relies purely on watch events, resources are never resynced periodically
+	Disabled bool
+}
+
+// Result returns the result of a successful reconciliation of obj, honoring
+// the resync period annotation of the resource
+func (c ResyncConfig) Result(obj metav1.Object) ctrl.Result {
+	period := c.Period
+	if period <= 0 {
+		period = DefaultResyncPeriod
+	}
+	if c.Disabled {
+		period = 0
+	}
+
+	if value, ok := obj.GetAnnotations()[ResyncPeriodAnnotation]; ok {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			period = d
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: period}
+}
+
+// requeueBefore shortens the requeue of result to after when it is sooner
+func requeueBefore(result ctrl.Result, after time.Duration) ctrl.Result {
+	if after <= 0 {
+		after = time.Second
+	}
+	if result.RequeueAfter == 0 || after < result.RequeueAfter {
+		result.RequeueAfter = after
+	}
+	return result
+}
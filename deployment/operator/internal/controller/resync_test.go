@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResyncConfigResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     ResyncConfig
+		annotation string
+		want       time.Duration
+	}{
+		{name: "default", want: DefaultResyncPeriod},
+		{name: "configured period", config: ResyncConfig{Period: time.Hour}, want: time.Hour},
+		{name: "disabled", config: ResyncConfig{Period: time.Hour, Disabled: true}, want: 0},
+		{name: "annotation overrides period", config: ResyncConfig{Period: time.Hour}, annotation: "30s", want: 30 * time.Second},
+		{name: "annotation overrides disabled", config: ResyncConfig{Disabled: true}, annotation: "10m", want: 10 * time.Minute},
+		{name: "annotation disables", annotation: "0", want: 0},
+		{name: "invalid annotation ignored", config: ResyncConfig{Period: time.Hour}, annotation: "daily", want: time.Hour},
+		{name: "negative annotation ignored", annotation: "-1m", want: DefaultResyncPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{}
+			if tt.annotation != "" {
+				obj.Annotations = map[string]string{ResyncPeriodAnnotation: tt.annotation}
+			}
+			if got := tt.config.Result(obj).RequeueAfter; got != tt.want {
+				t.Errorf("Result().RequeueAfter = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
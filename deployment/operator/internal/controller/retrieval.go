@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	retrievalConfigKey       = "retrieval.json"
+	retrievalConfigMountPath = "/app/config/retrieval"
+
+	defaultHybridAlpha = 0.75
+	defaultTopK        = 5
+)
+
+// retrievalPropertyPattern matches the property names a filter can apply to
+var retrievalPropertyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// retrievalConfig is the retrieval configuration rendered for the services
+type retrievalConfig struct {
+	HybridAlpha  float64           `json:"hybridAlpha"`
+	KeywordIndex bool              `json:"keywordIndex"`
+	TopK         int32             `json:"topK"`
+	Filters      map[string]string `json:"filters,omitempty"`
+}
+
+// keywordIndexEnabled reports whether the collections get a keyword index
+func keywordIndexEnabled(ragme *ragmev1.RAGme) bool {
+	return ragme.Spec.Retrieval.KeywordIndex == nil || *ragme.Spec.Retrieval.KeywordIndex
+}
+
+// retrievalConfigMapName returns the name of the ConfigMap holding the retrieval configuration
+func retrievalConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-retrieval", ragme.Name)
+}
+
+// renderRetrievalConfig returns the retrieval configuration of the instance.
+// Without a keyword index the search is vector only.
+func renderRetrievalConfig(ragme *ragmev1.RAGme) retrievalConfig {
+	retrieval := ragme.Spec.Retrieval
+	config := retrievalConfig{
+		HybridAlpha:  defaultHybridAlpha,
+		KeywordIndex: keywordIndexEnabled(ragme),
+		TopK:         retrieval.TopK,
+		Filters:      retrieval.Filters,
+	}
+	if alpha, err := strconv.ParseFloat(retrieval.HybridAlpha, 64); err == nil {
+		config.HybridAlpha = alpha
+	}
+	if !config.KeywordIndex {
+		config.HybridAlpha = 1
+	}
+	if config.TopK == 0 {
+		config.TopK = defaultTopK
+	}
+	return config
+}
+
+// reconcileRetrievalConfig renders the retrieval configuration into the
+// ConfigMap mounted by the api, mcp and agent
+func (r *RAGmeReconciler) reconcileRetrievalConfig(ctx context.Context, ragme *ragmev1.RAGme) error {
+	data, err := json.MarshalIndent(renderRetrievalConfig(ragme), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      retrievalConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{retrievalConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[retrievalConfigKey] != configMap.Data[retrievalConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// applyRetrieval mounts the retrieval configuration into the services. The api
+// and mcp query with it, the agent reads the keyword index setting when it
+// bootstraps the default collection at its first ingestion
+func applyRetrieval(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if serviceName == "frontend" {
+		return
+	}
+
+	mountVolume(podSpec, corev1.Volume{
+		Name: "retrieval",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: retrievalConfigMapName(ragme)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}, retrievalConfigMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_RETRIEVAL_CONFIG", Value: retrievalConfigMountPath + "/" + retrievalConfigKey,
+	})
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderRetrievalConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	config := renderRetrievalConfig(ragme)
+	if config.HybridAlpha != 0.75 || !config.KeywordIndex || config.TopK != 5 {
+		t.Errorf("config = %+v, want the hybrid defaults", config)
+	}
+
+	ragme.Spec.Retrieval = ragmev1.RAGmeRetrieval{
+		HybridAlpha: "0.3",
+		TopK:        12,
+		Filters:     map[string]string{"language": "en"},
+	}
+	config = renderRetrievalConfig(ragme)
+	if config.HybridAlpha != 0.3 || config.TopK != 12 || config.Filters["language"] != "en" {
+		t.Errorf("config = %+v, want the spec values", config)
+	}
+
+	ragme.Spec.Retrieval = ragmev1.RAGmeRetrieval{KeywordIndex: &[]bool{false}[0]}
+	if config := renderRetrievalConfig(ragme); config.KeywordIndex || config.HybridAlpha != 1 {
+		t.Errorf("config = %+v, want a vector only search without keyword index", config)
+	}
+}
+
+func TestValidateRetrieval(t *testing.T) {
+	tests := []struct {
+		name      string
+		retrieval ragmev1.RAGmeRetrieval
+		wantErr   string
+	}{
+		{name: "defaults"},
+		{name: "vector only", retrieval: ragmev1.RAGmeRetrieval{HybridAlpha: "1", KeywordIndex: &[]bool{false}[0]}},
+		{name: "alpha out of range", retrieval: ragmev1.RAGmeRetrieval{HybridAlpha: "1.5"}, wantErr: "retrieval.hybridAlpha"},
+		{name: "hybrid without keyword index", retrieval: ragmev1.RAGmeRetrieval{HybridAlpha: "0.5", KeywordIndex: &[]bool{false}[0]}, wantErr: "disables"},
+		{name: "negative topK", retrieval: ragmev1.RAGmeRetrieval{TopK: -1}, wantErr: "retrieval.topK"},
+		{name: "invalid filter property", retrieval: ragmev1.RAGmeRetrieval{Filters: map[string]string{"doc-type": "pdf"}}, wantErr: "retrieval.filters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Retrieval = tt.retrieval
+			err := validateSpec(ragme)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("validateSpec() = %v, want no error", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("validateSpec() = %v, want an error about %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyRetrieval(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Name = "ragme"
+	for service, want := range map[string]bool{"api": true, "mcp": true, "agent": true, "frontend": false} {
+		podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: service}}}
+		applyRetrieval(ragme, service, podSpec)
+		if got := envValue(podSpec.Containers[0].Env, "RAGME_RETRIEVAL_CONFIG") != ""; got != want {
+			t.Errorf("%s: RAGME_RETRIEVAL_CONFIG set = %v, want %v", service, got, want)
+		}
+	}
+}
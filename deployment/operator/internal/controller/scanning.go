@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	scanEngineClamAV  = "clamav"
+	scanEngineWebhook = "webhook"
+
+	defaultClamAVImage = "clamav/clamav:1.3"
+	scanMetricsPort    = 9103
+
+	// quarantineDir is the directory of the shared volume uploads land in
+	// before the scanner moves them into the watch directory
+	quarantineDir = ".quarantine"
+)
+
+// scannerScript moves the uploads of the quarantine directory into the watch
+// directory once scanned clean, and into the infected directory otherwise.
+// Files the scanner fails on are retried on the next pass. The counters are
+// published in the Prometheus text format.
+const scannerScript = `Q="$WATCH_DIR/` + quarantineDir + `"
+mkdir -p "$Q/incoming" "$Q/infected"
+if [ "$SCAN_ENGINE" = "` + scanEngineClamAV + `" ]; then freshclam --stdout || true; fi
+scan() {
+  if [ "$SCAN_ENGINE" = "` + scanEngineClamAV + `" ]; then
+    clamscan --no-summary "$1" >/dev/null 2>&1
+  else
+    wget -q -O /dev/null --post-file="$1" "$SCAN_WEBHOOK_URL" || return 1
+  fi
+}
+clean=0; infected=0
+while true; do
+  for f in "$Q"/incoming/*; do
+    [ -f "$f" ] || continue
+    scan "$f"
+    case $? in
+      0) mv "$f" "$WATCH_DIR/" && clean=$((clean+1)) ;;
+      1) mv "$f" "$Q/infected/" && infected=$((infected+1)) ;;
+    esac
+  done
+  pending=$(ls -1 "$Q/incoming" | wc -l)
+  printf "ragme_scan_clean_files_total %d\nragme_scan_infected_files_total %d\nragme_scan_pending_files %d\n" \
+    "$clean" "$infected" "$pending" > /metrics/metrics.tmp && mv /metrics/metrics.tmp /metrics/metrics
+  sleep 10
+done`
+
+// applyScanning routes the uploads of the api and mcp through the quarantine
+// directory and adds the scanner sidecars to the agent pod
+func applyScanning(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	scanning := ragme.Spec.Security.Scanning
+	if !scanning.Enabled {
+		return
+	}
+
+	quarantine := "/app/watch_directory/" + quarantineDir
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_QUARANTINE_DIR", Value: quarantine,
+	})
+	switch serviceName {
+	case "api", "mcp":
+		podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+			Name: "RAGME_UPLOAD_DIR", Value: quarantine + "/incoming",
+		})
+		return
+	case "agent":
+	default:
+		return
+	}
+
+	image := scanning.Image
+	if scanning.Engine == scanEngineWebhook {
+		image = ragme.Spec.Storage.SharedVolume.Monitoring.Image
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         "scan-metrics",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	})
+	podSpec.Containers = append(podSpec.Containers,
+		corev1.Container{
+			Name:    "scanner",
+			Image:   image,
+			Command: []string{"sh", "-c", scannerScript},
+			Env: []corev1.EnvVar{
+				{Name: "WATCH_DIR", Value: "/watch"},
+				{Name: "SCAN_ENGINE", Value: scanning.Engine},
+				{Name: "SCAN_WEBHOOK_URL", Value: scanning.WebhookURL},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "watch-directory", MountPath: "/watch"},
+				{Name: "scan-metrics", MountPath: "/metrics"},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("100m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("2Gi"),
+				},
+			},
+		},
+		corev1.Container{
+			Name:    "scan-metrics",
+			Image:   ragme.Spec.Storage.SharedVolume.Monitoring.Image,
+			Command: []string{"httpd", "-f", "-p", fmt.Sprint(scanMetricsPort), "-h", "/metrics"},
+			Ports: []corev1.ContainerPort{
+				{Name: "scan-metrics", ContainerPort: scanMetricsPort},
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: "scan-metrics", MountPath: "/metrics", ReadOnly: true},
+			},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("5m"),
+					corev1.ResourceMemory: resource.MustParse("8Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("50m"),
+					corev1.ResourceMemory: resource.MustParse("32Mi"),
+				},
+			},
+		},
+	)
+}
+
+// checkScanning reads the scanner counters from the agent pod and records them in status
+func (r *RAGmeReconciler) checkScanning(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Security.Scanning.Enabled {
+		ragme.Status.Scanning = ragmev1.RAGmeScanningStatus{}
+		return nil
+	}
+
+	samples, err := r.scrapeAgentPod(ctx, ragme, scanMetricsPort,
+		"ragme_scan_clean_files_total", "ragme_scan_infected_files_total", "ragme_scan_pending_files")
+	if err != nil {
+		return fmt.Errorf("scanning counters: %w", err)
+	}
+	status := ragmev1.RAGmeScanningStatus{
+		ScannedFiles:  int64(sumSamples(samples, "ragme_scan_clean_files_total", nil)),
+		InfectedFiles: int64(sumSamples(samples, "ragme_scan_infected_files_total", nil)),
+		PendingFiles:  int64(sumSamples(samples, "ragme_scan_pending_files", nil)),
+	}
+	if status.InfectedFiles > ragme.Status.Scanning.InfectedFiles {
+		log.FromContext(ctx).Info("Infected uploads quarantined", "infected", status.InfectedFiles)
+	}
+	ragme.Status.Scanning = status
+	return nil
+}
+
+// scrapeAgentPod reads the requested metrics from a port of the running agent
+// pod, which has no Service
+func (r *RAGmeReconciler) scrapeAgentPod(ctx context.Context, ragme *ragmev1.RAGme, port int32, names ...string) ([]promSample, error) {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":       "ragme",
+		"component": "agent",
+		"instance":  ragme.Name,
+	}); err != nil {
+		return nil, err
+	}
+
+	podIP := ""
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].Status.PodIP != "" {
+			podIP = pods.Items[i].Status.PodIP
+			break
+		}
+	}
+	if podIP == "" {
+		return nil, fmt.Errorf("no running agent pod to read the metrics from")
+	}
+	return r.scrapePod(ctx, podIP, port, names...)
+}
+
+// scrapePod reads the requested metrics from a port of a pod
+func (r *RAGmeReconciler) scrapePod(ctx context.Context, podIP string, port int32, names ...string) ([]promSample, error) {
+	return r.scrapeURL(ctx, fmt.Sprintf("http://%s:%d/metrics", podIP, port), names...)
+}
+
+// scrapeURL reads the requested metrics from a Prometheus endpoint
+func (r *RAGmeReconciler) scrapeURL(ctx context.Context, url string, names ...string) ([]promSample, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := defaultHTTPClient(r.HTTPClient).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return parsePrometheusText(resp.Body, names...)
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyScanning(t *testing.T) {
+	tests := []struct {
+		service        string
+		wantUploadDir  bool
+		wantContainers int
+	}{
+		{service: "api", wantUploadDir: true, wantContainers: 1},
+		{service: "mcp", wantUploadDir: true, wantContainers: 1},
+		{service: "agent", wantContainers: 3},
+		{service: "frontend", wantContainers: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Security.Scanning = ragmev1.RAGmeScanning{Enabled: true, Engine: scanEngineClamAV, Image: defaultClamAVImage}
+			podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: tt.service}}}
+
+			applyScanning(ragme, tt.service, podSpec)
+			if len(podSpec.Containers) != tt.wantContainers {
+				t.Errorf("got %d containers, want %d", len(podSpec.Containers), tt.wantContainers)
+			}
+			hasUploadDir := false
+			for _, env := range podSpec.Containers[0].Env {
+				if env.Name == "RAGME_UPLOAD_DIR" {
+					hasUploadDir = true
+				}
+			}
+			if hasUploadDir != tt.wantUploadDir {
+				t.Errorf("RAGME_UPLOAD_DIR set = %v, want %v", hasUploadDir, tt.wantUploadDir)
+			}
+		})
+	}
+}
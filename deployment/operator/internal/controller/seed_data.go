@@ -0,0 +1,230 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	seedGitImage  = "alpine/git:2.45.2"
+	seedS3Image   = "minio/mc:latest"
+	seedURLsImage = "curlimages/curl:8.8.0"
+)
+
+// seedGitScript copies the files of a repository into the seed directory
+const seedGitScript = `set -e
+if [ -n "$GIT_TOKEN" ]; then git config --global http.extraHeader "Authorization: Bearer $GIT_TOKEN"; fi
+git clone --depth 1 ${GIT_REF:+--branch "$GIT_REF"} "$GIT_URL" /tmp/repo
+mkdir -p "$SEED_DIR"
+find "/tmp/repo/$GIT_PATH" -type f -not -path '*/.git/*' -exec cp {} "$SEED_DIR/" \;`
+
+// seedS3Script copies the objects under a bucket prefix into the seed directory
+const seedS3Script = `set -e
+mc alias set seed "$S3_ENDPOINT" "$S3_ACCESS_KEY" "$S3_SECRET_KEY"
+mc cp --recursive "seed/$S3_BUCKET/$S3_PREFIX" /tmp/objects/
+mkdir -p "$SEED_DIR"
+find /tmp/objects -type f -exec cp {} "$SEED_DIR/" \;`
+
+// seedURLsScript submits the seed URLs to the api
+const seedURLsScript = `if [ -n "$SEED_URLS" ]; then
+  curl -sSf -X POST -H 'Content-Type: application/json' -d "$SEED_URLS" "$RAGME_API_URL/add-urls"
+fi`
+
+// seedDataConfigured reports whether the spec asks for any seed content
+func seedDataConfigured(seed ragmev1.RAGmeSeedData) bool {
+	return len(seed.URLs) > 0 || seed.Git != nil || seed.S3 != nil
+}
+
+// seedDataRevision returns a stable identifier of the seed content
+func seedDataRevision(seed ragmev1.RAGmeSeedData) string {
+	data, _ := json.Marshal(seed)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// reconcileSeedData runs the bootstrap Job ingesting spec.seedData once the
+// instance is ready. Each revision of the seed content is ingested once.
+func (r *RAGmeReconciler) reconcileSeedData(ctx context.Context, ragme *ragmev1.RAGme) error {
+	seed := ragme.Spec.SeedData
+	if !seedDataConfigured(seed) {
+		ragme.Status.SeedData = ragmev1.RAGmeSeedDataStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeDataSeeded)
+		return nil
+	}
+
+	revision := seedDataRevision(seed)
+	status := &ragme.Status.SeedData
+	if status.Revision == revision && status.Completed {
+		return nil
+	}
+
+	job := createSeedDataJob(ragme, revision)
+	if err := r.setOwner(ragme, job); err != nil {
+		return err
+	}
+
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		// The api must serve the URLs and the agent ingest the copied files
+		if !conditions.IsTrue(ragme.Status.Conditions, conditions.TypeReady) || ragme.Status.Hibernation.Hibernated {
+			setSeedDataCondition(ragme, metav1.ConditionUnknown, conditions.ReasonWaitingForReady,
+				"Seed data is ingested once the instance is ready")
+			return nil
+		}
+		*status = ragmev1.RAGmeSeedDataStatus{Revision: revision, Job: job.Name}
+		setSeedDataCondition(ragme, metav1.ConditionUnknown, conditions.ReasonSeeding, "Seed data is being ingested")
+		return r.Create(ctx, job)
+	} else if err != nil {
+		return err
+	}
+
+	*status = ragmev1.RAGmeSeedDataStatus{Revision: revision, Job: found.Name}
+	switch {
+	case found.Status.Succeeded > 0:
+		status.Completed = true
+		setSeedDataCondition(ragme, metav1.ConditionTrue, conditions.ReasonSeedSucceeded,
+			fmt.Sprintf("Seed data revision %s was ingested", revision))
+	case jobFailed(found):
+		setSeedDataCondition(ragme, metav1.ConditionFalse, conditions.ReasonSeedFailed,
+			fmt.Sprintf("Seed data ingestion failed, see the logs of job %s", found.Name))
+	default:
+		setSeedDataCondition(ragme, metav1.ConditionUnknown, conditions.ReasonSeeding, "Seed data is being ingested")
+	}
+	return nil
+}
+
+// setSeedDataCondition sets the DataSeeded condition
+func setSeedDataCondition(ragme *ragmev1.RAGme, status metav1.ConditionStatus, reason, message string) {
+	conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeDataSeeded, status, reason, message)
+}
+
+// createSeedDataJob returns the bootstrap Job of a seed content revision. The
+// repository and bucket files are copied into the watch directory, through the
+// quarantine when uploads are scanned, and the URLs are submitted to the api.
+func createSeedDataJob(ragme *ragmev1.RAGme, revision string) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "seed-data",
+		"instance":  ragme.Name,
+	}
+
+	seed := ragme.Spec.SeedData
+	seedDir := "/watch"
+	if ragme.Spec.Security.Scanning.Enabled {
+		seedDir = "/watch/" + quarantineDir + "/incoming"
+	}
+	mounts := []corev1.VolumeMount{{Name: "watch-directory", MountPath: "/watch"}}
+
+	var initContainers []corev1.Container
+	if git := seed.Git; git != nil {
+		env := []corev1.EnvVar{
+			{Name: "SEED_DIR", Value: seedDir},
+			{Name: "GIT_URL", Value: git.URL},
+			{Name: "GIT_REF", Value: git.Ref},
+			{Name: "GIT_PATH", Value: git.Path},
+		}
+		if git.TokenSecretRef != nil {
+			env = append(env, corev1.EnvVar{
+				Name:      "GIT_TOKEN",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: git.TokenSecretRef},
+			})
+		}
+		initContainers = append(initContainers, corev1.Container{
+			Name:         "seed-git",
+			Image:        seedGitImage,
+			Command:      []string{"sh", "-c", seedGitScript},
+			Env:          env,
+			VolumeMounts: mounts,
+		})
+	}
+	if s3 := seed.S3; s3 != nil {
+		env := []corev1.EnvVar{
+			{Name: "SEED_DIR", Value: seedDir},
+			{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+			{Name: "S3_BUCKET", Value: s3.Bucket},
+			{Name: "S3_PREFIX", Value: s3.Prefix},
+		}
+		if ref := s3.CredentialsSecretRef; ref != nil {
+			env = append(env,
+				corev1.EnvVar{Name: "S3_ACCESS_KEY", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageAccessKeyKey},
+				}},
+				corev1.EnvVar{Name: "S3_SECRET_KEY", ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: storageSecretKeyKey},
+				}},
+			)
+		}
+		initContainers = append(initContainers, corev1.Container{
+			Name:         "seed-s3",
+			Image:        seedS3Image,
+			Command:      []string{"sh", "-c", seedS3Script},
+			Env:          env,
+			VolumeMounts: mounts,
+		})
+	}
+
+	urls := ""
+	if len(seed.URLs) > 0 {
+		data, _ := json.Marshal(map[string][]string{"urls": seed.URLs})
+		urls = string(data)
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy:  corev1.RestartPolicyNever,
+		InitContainers: initContainers,
+		Containers: []corev1.Container{
+			{
+				Name:    "seed-urls",
+				Image:   seedURLsImage,
+				Command: []string{"sh", "-c", seedURLsScript},
+				Env: []corev1.EnvVar{
+					{Name: "SEED_URLS", Value: urls},
+					{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+				},
+			},
+		},
+		Volumes: []corev1.Volume{
+			{
+				Name: "watch-directory",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: fmt.Sprintf("%s-shared-pvc", ragme.Name),
+					},
+				},
+			},
+		},
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-seed-%s", ragme.Name, revision),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{3}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
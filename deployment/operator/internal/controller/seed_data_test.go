@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestCreateSeedDataJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "ragme"}}
+	ragme.Spec.SeedData = ragmev1.RAGmeSeedData{
+		URLs: []string{"https://example.com/handbook"},
+		Git:  &ragmev1.RAGmeSeedGit{URL: "https://github.com/example/docs.git"},
+		S3: &ragmev1.RAGmeSeedS3{
+			Endpoint:             "https://s3.amazonaws.com",
+			Bucket:               "onboarding",
+			CredentialsSecretRef: &corev1.LocalObjectReference{Name: "onboarding-s3"},
+		},
+	}
+
+	job := createSeedDataJob(ragme, seedDataRevision(ragme.Spec.SeedData))
+	podSpec := job.Spec.Template.Spec
+	if len(podSpec.InitContainers) != 2 {
+		t.Fatalf("expected the git and s3 init containers, got %d", len(podSpec.InitContainers))
+	}
+	if got := envValue(podSpec.InitContainers[0].Env, "SEED_DIR"); got != "/watch" {
+		t.Errorf("SEED_DIR = %q, want /watch", got)
+	}
+	if got := envValue(podSpec.Containers[0].Env, "SEED_URLS"); got != `{"urls":["https://example.com/handbook"]}` {
+		t.Errorf("SEED_URLS = %q", got)
+	}
+	if claim := podSpec.Volumes[0].PersistentVolumeClaim; claim == nil || claim.ClaimName != "demo-shared-pvc" {
+		t.Errorf("expected the shared PVC to be mounted, got %+v", podSpec.Volumes[0])
+	}
+
+	// Seed files go through the quarantine when uploads are scanned
+	ragme.Spec.Security.Scanning.Enabled = true
+	job = createSeedDataJob(ragme, "rev")
+	if got := envValue(job.Spec.Template.Spec.InitContainers[0].Env, "SEED_DIR"); got != "/watch/.quarantine/incoming" {
+		t.Errorf("SEED_DIR = %q, want the quarantine incoming directory", got)
+	}
+}
+
+func TestSeedDataRevision(t *testing.T) {
+	seed := ragmev1.RAGmeSeedData{URLs: []string{"https://example.com/a"}}
+	if seedDataRevision(seed) != seedDataRevision(*seed.DeepCopy()) {
+		t.Error("expected the revision to be stable")
+	}
+	changed := ragmev1.RAGmeSeedData{URLs: []string{"https://example.com/b"}}
+	if seedDataRevision(seed) == seedDataRevision(changed) {
+		t.Error("expected changed seed content to get a new revision")
+	}
+}
+
+func envValue(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
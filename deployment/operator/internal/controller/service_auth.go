@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	apiKeyKey              = "apiKey"
+	previousAPIKeyKey      = "previousApiKey"
+	apiKeyRotatedAtKey     = "ragme.io/rotated-at"
+	serviceAuthConfigKey   = "keys.json"
+	serviceAuthMountPath   = "/app/config/service-auth"
+	serviceAuthSecretLabel = "ragme.io/api-key"
+)
+
+// serviceAuthKeyConfig is the API key configuration rendered for the api and mcp.
+// Only key hashes are rendered, the keys themselves stay in the per-key Secrets.
+type serviceAuthKeyConfig struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes,omitempty"`
+	SHA256 []string `json:"sha256"`
+}
+
+// reconcileServiceAuth provisions and rotates the API key Secrets and renders
+// the key configuration consumed by the api and mcp
+func (r *RAGmeReconciler) reconcileServiceAuth(ctx context.Context, ragme *ragmev1.RAGme) error {
+	serviceAuth := ragme.Spec.Authentication.ServiceAuth
+	if !serviceAuth.Enabled {
+		return nil
+	}
+
+	wanted := map[string]bool{}
+	configs := []serviceAuthKeyConfig{}
+	for _, key := range serviceAuth.Keys {
+		secret, err := r.reconcileAPIKeySecret(ctx, ragme, key)
+		if err != nil {
+			return fmt.Errorf("failed to reconcile API key %s: %w", key.Name, err)
+		}
+		wanted[secret.Name] = true
+
+		config := serviceAuthKeyConfig{Name: key.Name, Scopes: key.Scopes}
+		for _, k := range []string{apiKeyKey, previousAPIKeyKey} {
+			if value := secret.Data[k]; len(value) > 0 {
+				sum := sha256.Sum256(value)
+				config.SHA256 = append(config.SHA256, hex.EncodeToString(sum[:]))
+			}
+		}
+		configs = append(configs, config)
+	}
+
+	// Revoke keys removed from the spec
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"instance":             ragme.Name,
+		serviceAuthSecretLabel: "true",
+	}); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		if !wanted[secrets.Items[i].Name] {
+			if err := r.Delete(ctx, &secrets.Items[i]); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	data, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+	config := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-service-auth", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{serviceAuthConfigKey: data},
+	}
+	if err := r.setOwner(ragme, config); err != nil {
+		return err
+	}
+
+	found := &corev1.Secret{}
+	err = r.Get(ctx, types.NamespacedName{Name: config.Name, Namespace: config.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, config)
+	} else if err != nil {
+		return err
+	}
+	if string(found.Data[serviceAuthConfigKey]) != string(data) {
+		found.Data = config.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// reconcileAPIKeySecret creates the Secret holding an API key, rotating the
+// key when its rotation period has elapsed
+func (r *RAGmeReconciler) reconcileAPIKeySecret(ctx context.Context, ragme *ragmev1.RAGme, key ragmev1.RAGmeAPIKey) (*corev1.Secret, error) {
+	name := fmt.Sprintf("%s-apikey-%s", ragme.Name, key.Name)
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	if errors.IsNotFound(err) {
+		value, err := generateAPIKey()
+		if err != nil {
+			return nil, err
+		}
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: ragme.Namespace,
+				Labels: map[string]string{
+					"app":                  "ragme",
+					"instance":             ragme.Name,
+					serviceAuthSecretLabel: "true",
+				},
+				Annotations: map[string]string{
+					apiKeyRotatedAtKey: r.now().UTC().Format(time.RFC3339),
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{apiKeyKey: []byte(value)},
+		}
+		if err := r.setOwner(ragme, secret); err != nil {
+			return nil, err
+		}
+		return secret, r.Create(ctx, secret)
+	}
+
+	due, err := rotationDue(found, key.RotationPeriod)
+	if err != nil {
+		return nil, err
+	}
+	if !due {
+		return found, nil
+	}
+
+	value, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	if found.Annotations == nil {
+		found.Annotations = map[string]string{}
+	}
+	found.Annotations[apiKeyRotatedAtKey] = r.now().UTC().Format(time.RFC3339)
+	found.Data = map[string][]byte{
+		apiKeyKey:         []byte(value),
+		previousAPIKeyKey: found.Data[apiKeyKey],
+	}
+	return found, r.Update(ctx, found)
+}
+
+// rotationDue reports whether the generated credentials in the Secret are older
+// than the rotation period. An empty period disables rotation.
+func rotationDue(secret *corev1.Secret, rotationPeriod string) (bool, error) {
+	if rotationPeriod == "" {
+		return false, nil
+	}
+	period, err := time.ParseDuration(rotationPeriod)
+	if err != nil {
+		return false, fmt.Errorf("invalid rotationPeriod %q: %w", rotationPeriod, err)
+	}
+	rotatedAt, err := time.Parse(time.RFC3339, secret.Annotations[apiKeyRotatedAtKey])
+	return err != nil || time.Since(rotatedAt) >= period, nil
+}
+
+// generateAPIKey returns a random API key
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "rgm_" + hex.EncodeToString(buf), nil
+}
+
+// applyServiceAuth configures the api and mcp pods to accept API keys and JWTs
+func applyServiceAuth(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	serviceAuth := ragme.Spec.Authentication.ServiceAuth
+	if !serviceAuth.Enabled || (serviceName != "api" && serviceName != "mcp") {
+		return
+	}
+
+	mountVolume(podSpec, corev1.Volume{
+		Name: "service-auth",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: fmt.Sprintf("%s-service-auth", ragme.Name)},
+		},
+	}, serviceAuthMountPath)
+
+	env := []corev1.EnvVar{
+		{Name: "RAGME_SERVICE_AUTH_ENABLED", Value: "true"},
+		{Name: "RAGME_SERVICE_AUTH_CONFIG", Value: serviceAuthMountPath + "/" + serviceAuthConfigKey},
+	}
+	if serviceAuth.JWT.Issuer != "" {
+		env = append(env, []corev1.EnvVar{
+			{Name: "RAGME_JWT_ISSUER", Value: serviceAuth.JWT.Issuer},
+			{Name: "RAGME_JWT_AUDIENCE", Value: serviceAuth.JWT.Audience},
+			{Name: "RAGME_JWT_JWKS_URI", Value: serviceAuth.JWT.JWKSURI},
+		}...)
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+}
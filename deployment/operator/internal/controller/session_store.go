@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const defaultRedisImage = "redis:7-alpine"
+
+// sessionStoreType returns the effective session store type
+func sessionStoreType(ragme *ragmev1.RAGme) string {
+	if t := ragme.Spec.Authentication.Session.Store.Type; t != "" {
+		return t
+	}
+	return "memory"
+}
+
+// managedRedis reports whether the operator runs the Redis session store
+func managedRedis(ragme *ragmev1.RAGme) bool {
+	redis := ragme.Spec.Authentication.Session.Store.Redis
+	return sessionStoreType(ragme) == "redis" && redis.URL == "" && redis.URLSecretRef == nil
+}
+
+// sessionStoreEnv returns the session store configuration passed to the services
+func sessionStoreEnv(ragme *ragmev1.RAGme) []corev1.EnvVar {
+	store := ragme.Spec.Authentication.Session.Store
+	env := []corev1.EnvVar{{Name: "SESSION_STORE", Value: sessionStoreType(ragme)}}
+
+	switch sessionStoreType(ragme) {
+	case "redis":
+		switch {
+		case store.Redis.URLSecretRef != nil:
+			env = append(env, corev1.EnvVar{
+				Name:      "SESSION_REDIS_URL",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: store.Redis.URLSecretRef},
+			})
+		case store.Redis.URL != "":
+			env = append(env, corev1.EnvVar{Name: "SESSION_REDIS_URL", Value: store.Redis.URL})
+		default:
+			env = append(env, corev1.EnvVar{
+				Name: "SESSION_REDIS_URL", Value: fmt.Sprintf("redis://%s-redis:6379/0", ragme.Name),
+			})
+		}
+	case "postgres":
+		if store.Postgres.URLSecretRef != nil {
+			env = append(env, corev1.EnvVar{
+				Name:      "SESSION_POSTGRES_URL",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: store.Postgres.URLSecretRef},
+			})
+		}
+	}
+	return env
+}
+
+// reconcileSessionStore runs the managed Redis when it backs the session
+// store, removes it otherwise, and warns when sessions are not shared
+// between api replicas
+func (r *RAGmeReconciler) reconcileSessionStore(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if sessionStoreType(ragme) == "memory" && ragme.Spec.Replicas.API > 1 {
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSessionStoreShared, conditions.ReasonInMemorySessionStore,
+			fmt.Sprintf("Sessions are kept in memory but the api runs %d replicas, users may be logged out between requests", ragme.Spec.Replicas.API))
+	} else {
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeSessionStoreShared)
+	}
+
+	deployment := r.createRedisDeployment(ragme)
+	service := r.createRedisService(ragme)
+
+	if !managedRedis(ragme) {
+		for _, obj := range []client.Object{deployment, service} {
+			if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := r.setOwner(ragme, deployment); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err == nil {
+		foundDeployment.Spec = deployment.Spec
+		foundDeployment.Labels = mergeStringMaps(foundDeployment.Labels, deployment.Labels)
+		foundDeployment.Annotations = mergeStringMaps(foundDeployment.Annotations, deployment.Annotations)
+		if err := r.updateOrRecreate(ctx, ragme, foundDeployment, deployment); err != nil {
+			return err
+		}
+	} else {
+		return err
+	}
+
+	if err := r.setOwner(ragme, service); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+func (r *RAGmeReconciler) createRedisDeployment(ragme *ragmev1.RAGme) *appsv1.Deployment {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "redis",
+		"instance":  ragme.Name,
+	}
+
+	image := ragme.Spec.Authentication.Session.Store.Redis.Image
+	if image == "" {
+		image = defaultRedisImage
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-redis", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &[]int32{1}[0],
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "redis",
+							Image: image,
+							// Sessions are short lived, persistence is not needed
+							Args: []string{"--save", "", "--appendonly", "no"},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 6379, Name: "redis"},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(6379)},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	applyWorkloadAnnotations(ragme, deployment)
+
+	return deployment
+}
+
+func (r *RAGmeReconciler) createRedisService(ragme *ragmev1.RAGme) *corev1.Service {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "redis",
+		"instance":  ragme.Name,
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-redis", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{Name: "redis", Port: 6379, TargetPort: intstr.FromInt(6379)},
+			},
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+}
@@ -0,0 +1,338 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	shardsConfigKey       = "shards.json"
+	shardsConfigMountPath = "/app/config/shards"
+)
+
+// shardConfig is the routing configuration rendered for the api and mcp services
+type shardConfig struct {
+	RoutingKey string `json:"routingKey"`
+	// Active is the number of shards serving reads and writes
+	Active int32 `json:"active"`
+	// Target is set while the index is rebalanced to a new shard count
+	Target int32         `json:"target,omitempty"`
+	Shards []shardTarget `json:"shards"`
+}
+
+// shardTarget locates a shard, either as a Weaviate URL or a Milvus collection suffix
+type shardTarget struct {
+	ID               int32  `json:"id"`
+	URL              string `json:"url,omitempty"`
+	CollectionSuffix string `json:"collectionSuffix,omitempty"`
+}
+
+// targetShards returns the shard count requested in the spec
+func targetShards(ragme *ragmev1.RAGme) int32 {
+	if shards := ragme.Spec.VectorDB.Sharding.Shards; shards > 1 {
+		return shards
+	}
+	return 1
+}
+
+// activeShards returns the shard count currently serving traffic
+func activeShards(ragme *ragmev1.RAGme) int32 {
+	if shards := ragme.Status.Sharding.Shards; shards > 1 {
+		return shards
+	}
+	return 1
+}
+
+// deployedShards returns the number of shards that must be running, which
+// covers both layouts while a rebalance is in progress
+func deployedShards(ragme *ragmev1.RAGme) int32 {
+	if target, active := targetShards(ragme), activeShards(ragme); target > active {
+		return target
+	}
+	return activeShards(ragme)
+}
+
+// shardingEnabled reports whether the index is or will be sharded
+func shardingEnabled(ragme *ragmev1.RAGme) bool {
+	return deployedShards(ragme) > 1
+}
+
+// weaviateShardName returns the name of the Weaviate resources of a shard.
+// Shard 0 keeps the unsharded name so existing data is preserved.
+func weaviateShardName(ragme *ragmev1.RAGme, shard int32) string {
+	if shard == 0 {
+		return fmt.Sprintf("%s-weaviate", ragme.Name)
+	}
+	return fmt.Sprintf("%s-weaviate-%d", ragme.Name, shard)
+}
+
+// weaviateLabels returns the labels of a Weaviate shard. Additional shards use
+// their own component so the selector of shard 0 does not match them.
+func weaviateLabels(ragme *ragmev1.RAGme, shard int32) map[string]string {
+	if shard == 0 {
+		return map[string]string{
+			"app":       "ragme",
+			"component": "weaviate",
+			"instance":  ragme.Name,
+		}
+	}
+	return map[string]string{
+		"app":       "ragme",
+		"component": "weaviate-shard",
+		"instance":  ragme.Name,
+		"shard":     fmt.Sprintf("%d", shard),
+	}
+}
+
+// buildShardConfig renders the routing configuration for the deployed shards
+func buildShardConfig(ragme *ragmev1.RAGme, active, target int32) shardConfig {
+	config := shardConfig{
+		RoutingKey: ragme.Spec.VectorDB.Sharding.RoutingKey,
+		Active:     active,
+	}
+	if target != active {
+		config.Target = target
+	}
+
+	shards := active
+	if target > active {
+		shards = target
+	}
+	for shard := int32(0); shard < shards; shard++ {
+		entry := shardTarget{ID: shard}
+		if ragme.Spec.VectorDB.Type == "weaviate" {
+			entry.URL = fmt.Sprintf("http://%s:8080", weaviateShardName(ragme, shard))
+		} else if shard > 0 {
+			entry.CollectionSuffix = fmt.Sprintf("_shard%d", shard)
+		}
+		config.Shards = append(config.Shards, entry)
+	}
+	return config
+}
+
+// applySharding mounts the shard routing configuration into the api and mcp pods
+func applySharding(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	if !shardingEnabled(ragme) || (serviceName != "api" && serviceName != "mcp") {
+		return
+	}
+
+	mountVolume(podSpec, shardsConfigVolume(ragme), shardsConfigMountPath)
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, corev1.EnvVar{
+		Name: "RAGME_SHARD_CONFIG", Value: shardsConfigMountPath + "/" + shardsConfigKey,
+	})
+}
+
+func shardsConfigVolume(ragme *ragmev1.RAGme) corev1.Volume {
+	return corev1.Volume{
+		Name: "shards",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: fmt.Sprintf("%s-shards", ragme.Name)},
+				Optional:             &[]bool{true}[0],
+			},
+		},
+	}
+}
+
+// reconcileSharding publishes the shard routing configuration and runs a
+// rebalance Job whenever the requested shard count differs from the active one.
+// Shards are only retired once their documents have been moved.
+func (r *RAGmeReconciler) reconcileSharding(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !shardingEnabled(ragme) {
+		if meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeShardsBalanced) == nil {
+			return nil
+		}
+		configMap := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-shards", ragme.Name),
+				Namespace: ragme.Namespace,
+			},
+		}
+		if err := r.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		ragme.Status.Sharding = ragmev1.RAGmeShardingStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeShardsBalanced)
+		return nil
+	}
+
+	active, target := activeShards(ragme), targetShards(ragme)
+	if err := r.reconcileShardConfig(ctx, ragme, active, target); err != nil {
+		return err
+	}
+
+	if active != target {
+		job := createShardRebalanceJob(ragme, active, target)
+		if err := r.setOwner(ragme, job); err != nil {
+			return err
+		}
+		ragme.Status.Sharding.RebalanceJob = job.Name
+
+		found := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, found)
+		if err != nil && errors.IsNotFound(err) {
+			setShardsCondition(ragme, metav1.ConditionUnknown, conditions.ReasonRebalancing,
+				fmt.Sprintf("Rebalancing from %d to %d shards", active, target))
+			return r.Create(ctx, job)
+		} else if err != nil {
+			return err
+		}
+
+		switch {
+		case found.Status.Succeeded > 0:
+			active = target
+			if err := r.reconcileShardConfig(ctx, ragme, active, target); err != nil {
+				return err
+			}
+		case jobFailed(found):
+			setShardsCondition(ragme, metav1.ConditionFalse, conditions.ReasonRebalanceFailed,
+				fmt.Sprintf("Rebalance to %d shards failed, see the logs of job %s", target, found.Name))
+			return nil
+		default:
+			setShardsCondition(ragme, metav1.ConditionUnknown, conditions.ReasonRebalancing,
+				fmt.Sprintf("Rebalancing from %d to %d shards", active, target))
+			return nil
+		}
+	}
+
+	ragme.Status.Sharding = ragmev1.RAGmeShardingStatus{Shards: active}
+	setShardsCondition(ragme, metav1.ConditionTrue, conditions.ReasonRebalanceSucceeded,
+		fmt.Sprintf("Index is balanced across %d shards", active))
+
+	return r.retireWeaviateShards(ctx, ragme, active)
+}
+
+// retireWeaviateShards removes the Weaviate shards numbered from keep onwards,
+// which are no longer part of the layout once a rebalance has completed
+func (r *RAGmeReconciler) retireWeaviateShards(ctx context.Context, ragme *ragmev1.RAGme, keep int32) error {
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":       "ragme",
+		"component": "weaviate-shard",
+		"instance":  ragme.Name,
+	}); err != nil {
+		return err
+	}
+
+	for i := range deployments.Items {
+		shard, err := strconv.ParseInt(deployments.Items[i].Labels["shard"], 10, 32)
+		if err != nil || int32(shard) < keep {
+			continue
+		}
+		name := weaviateShardName(ragme, int32(shard))
+		for _, obj := range []client.Object{
+			&deployments.Items[i],
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ragme.Namespace}},
+			&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name + "-pvc", Namespace: ragme.Namespace}},
+		} {
+			if err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileShardConfig renders the shard routing ConfigMap
+func (r *RAGmeReconciler) reconcileShardConfig(ctx context.Context, ragme *ragmev1.RAGme, active, target int32) error {
+	data, err := json.MarshalIndent(buildShardConfig(ragme, active, target), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-shards", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{shardsConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[shardsConfigKey] != configMap.Data[shardsConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// setShardsCondition sets the ShardsBalanced condition
+func setShardsCondition(ragme *ragmev1.RAGme, status metav1.ConditionStatus, reason, message string) {
+	conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeShardsBalanced, status, reason, message)
+}
+
+// createShardRebalanceJob builds the Job that moves documents between the
+// active and the target shard layout using the api image
+func createShardRebalanceJob(ragme *ragmev1.RAGme, from, to int32) *batchv1.Job {
+	labels := map[string]string{
+		"app":       "ragme",
+		"component": "shard-rebalance",
+		"instance":  ragme.Name,
+	}
+
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{
+				Name:            "rebalance",
+				Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+				ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+				Command: []string{"python", "-m", "src.ragme.vdbs.rebalance",
+					"--from", fmt.Sprintf("%d", from), "--to", fmt.Sprintf("%d", to)},
+				Env: []corev1.EnvVar{
+					{Name: "VECTOR_DB_TYPE", Value: ragme.Spec.VectorDB.Type},
+					{Name: "RAGME_SHARD_CONFIG", Value: shardsConfigMountPath + "/" + shardsConfigKey},
+				},
+			},
+		},
+	}
+	mountVolume(&podSpec, shardsConfigVolume(ragme), shardsConfigMountPath)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-shard-rebalance-%d-to-%d", ragme.Name, from, to),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &[]int32{2}[0],
+			TTLSecondsAfterFinished: jobTTL(ragme),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &job.Spec.Template)
+	return job
+}
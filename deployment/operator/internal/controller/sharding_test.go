@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestBuildShardConfig(t *testing.T) {
+	tests := []struct {
+		name           string
+		vectorDB       string
+		active, target int32
+		want           shardConfig
+	}{
+		{
+			name:     "weaviate balanced",
+			vectorDB: "weaviate",
+			active:   2, target: 2,
+			want: shardConfig{RoutingKey: "document", Active: 2, Shards: []shardTarget{
+				{ID: 0, URL: "http://test-weaviate:8080"},
+				{ID: 1, URL: "http://test-weaviate-1:8080"},
+			}},
+		},
+		{
+			name:     "weaviate scale up lists target shards",
+			vectorDB: "weaviate",
+			active:   1, target: 3,
+			want: shardConfig{RoutingKey: "document", Active: 1, Target: 3, Shards: []shardTarget{
+				{ID: 0, URL: "http://test-weaviate:8080"},
+				{ID: 1, URL: "http://test-weaviate-1:8080"},
+				{ID: 2, URL: "http://test-weaviate-2:8080"},
+			}},
+		},
+		{
+			name:     "milvus scale down keeps retiring shards",
+			vectorDB: "milvus",
+			active:   3, target: 2,
+			want: shardConfig{RoutingKey: "document", Active: 3, Target: 2, Shards: []shardTarget{
+				{ID: 0},
+				{ID: 1, CollectionSuffix: "_shard1"},
+				{ID: 2, CollectionSuffix: "_shard2"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Name = "test"
+			ragme.Spec.VectorDB.Type = tt.vectorDB
+			ragme.Spec.VectorDB.Sharding.RoutingKey = "document"
+			if got := buildShardConfig(ragme, tt.active, tt.target); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildShardConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeployedShards(t *testing.T) {
+	tests := []struct {
+		name           string
+		spec, observed int32
+		want           int32
+	}{
+		{name: "unsharded", want: 1},
+		{name: "initial sharding", spec: 3, want: 3},
+		{name: "scale up", spec: 4, observed: 2, want: 4},
+		{name: "scale down keeps old shards", spec: 2, observed: 4, want: 4},
+		{name: "disable keeps old shards", observed: 3, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.VectorDB.Sharding.Shards = tt.spec
+			ragme.Status.Sharding.Shards = tt.observed
+			if got := deployedShards(ragme); got != tt.want {
+				t.Errorf("deployedShards() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
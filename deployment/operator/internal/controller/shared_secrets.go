@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	// shareWithAnnotation lists the namespaces a Secret may be copied to,
+	// comma separated, or * for all of them
+	shareWithAnnotation = "ragme.io/share-with"
+
+	// copiedFromAnnotation records the source of a copied Secret as namespace/name
+	copiedFromAnnotation = "ragme.io/copied-from"
+
+	// sourceHashAnnotation records the content of the source a copy was made from
+	sourceHashAnnotation = "ragme.io/source-hash"
+
+	// copiedSecretLabel marks the copies so the unreferenced ones are pruned
+	copiedSecretLabel = "ragme.io/copied-secret"
+
+	// secretRefsRevisionAnnotation records the Secrets content a pod runs with
+	secretRefsRevisionAnnotation = "ragme.io/secrets-revision"
+)
+
+// secretRefNamespace returns the namespace of a referenced Secret
+func secretRefNamespace(ragme *ragmev1.RAGme, ref ragmev1.RAGmeSecretRef) string {
+	if ref.Namespace == "" {
+		return ragme.Namespace
+	}
+	return ref.Namespace
+}
+
+// secretRefName returns the name of the Secret the services read a reference
+// from: the Secret itself in the namespace of the instance, its copy otherwise
+func secretRefName(ragme *ragmev1.RAGme, ref ragmev1.RAGmeSecretRef) string {
+	namespace := secretRefNamespace(ragme, ref)
+	if namespace == ragme.Namespace {
+		return ref.Name
+	}
+	return fmt.Sprintf("%s-%s-%s", ragme.Name, namespace, ref.Name)
+}
+
+// sharedWith reports whether the share-with annotation of a Secret allows
+// copies into a namespace
+func sharedWith(secret *corev1.Secret, namespace string) bool {
+	for _, allowed := range strings.Split(secret.Annotations[shareWithAnnotation], ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == "*" || allowed == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// secretDataHash returns a stable hash of the content of a Secret
+func secretDataHash(secret *corev1.Secret) string {
+	keys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n", secret.Type)
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s=%x\n", key, secret.Data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// reconcileSecretRefs copies the referenced Secrets of other namespaces into
+// the namespace of the instance, updating a copy when the hash of its source
+// changes, and prunes the copies no longer referenced. The revision recorded
+// in status covers every referenced Secret, so a changed key rolls the services.
+func (r *RAGmeReconciler) reconcileSecretRefs(ctx context.Context, ragme *ragmev1.RAGme) error {
+	revision := sha256.New()
+	copies := map[string]bool{}
+	var copied []string
+	for _, ref := range ragme.Spec.SecretRefs {
+		namespace := secretRefNamespace(ragme, ref)
+		source := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, source); err != nil {
+			return fmt.Errorf("reading Secret %s/%s: %w", namespace, ref.Name, err)
+		}
+		sum := secretDataHash(source)
+		fmt.Fprintf(revision, "%s/%s:%s\n", namespace, ref.Name, sum)
+		if namespace == ragme.Namespace {
+			continue
+		}
+
+		if !sharedWith(source, ragme.Namespace) {
+			return fmt.Errorf("secret %s/%s is not shared with namespace %s, list it in its %s annotation", namespace, ref.Name, ragme.Namespace, shareWithAnnotation)
+		}
+		name := secretRefName(ragme, ref)
+		copies[name] = true
+		copied = append(copied, fmt.Sprintf("%s/%s", ragme.Namespace, name))
+		if err := r.copySecret(ctx, ragme, source, name, sum); err != nil {
+			return err
+		}
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, secrets, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"instance":        ragme.Name,
+		copiedSecretLabel: "true",
+	}); err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if copies[secret.Name] {
+			continue
+		}
+		if err := r.Delete(ctx, secret); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		log.FromContext(ctx).Info("Deleted unreferenced Secret copy", "secret", secret.Name)
+	}
+
+	ragme.Status.SecretRefs = ragmev1.RAGmeSecretRefsStatus{Copies: copied}
+	if len(ragme.Spec.SecretRefs) > 0 {
+		ragme.Status.SecretRefs.Revision = hex.EncodeToString(revision.Sum(nil))[:16]
+	}
+	return nil
+}
+
+// copySecret creates or refreshes the copy of a Secret of another namespace
+func (r *RAGmeReconciler) copySecret(ctx context.Context, ragme *ragmev1.RAGme, source *corev1.Secret, name, sum string) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":             "ragme",
+				"instance":        ragme.Name,
+				copiedSecretLabel: "true",
+			},
+			Annotations: map[string]string{
+				copiedFromAnnotation: fmt.Sprintf("%s/%s", source.Namespace, source.Name),
+				sourceHashAnnotation: sum,
+			},
+		},
+		Type: source.Type,
+		Data: source.Data,
+	}
+	if err := r.setOwner(ragme, secret); err != nil {
+		return err
+	}
+
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Copying Secret", "source", secret.Annotations[copiedFromAnnotation], "secret", secret.Name)
+		return r.Create(ctx, secret)
+	} else if err != nil {
+		return err
+	}
+
+	// Edits of the copy are reverted as well as changes of the source
+	if found.Annotations[sourceHashAnnotation] == sum && secretDataHash(found) == sum {
+		return nil
+	}
+	if found.Type != secret.Type {
+		// The type of a Secret is immutable
+		if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return r.Create(ctx, secret)
+	}
+	found.Labels = mergeStringMaps(found.Labels, secret.Labels)
+	found.Annotations = mergeStringMaps(found.Annotations, secret.Annotations)
+	found.Data = secret.Data
+	log.FromContext(ctx).Info("Updating Secret copy", "source", secret.Annotations[copiedFromAnnotation], "secret", secret.Name)
+	return r.Update(ctx, found)
+}
+
+// applySecretRefs exposes the keys of the referenced Secrets to the services
+// calling the language models. The revision on the pod template rolls them
+// when a key changes, environment variables are only read at startup.
+func applySecretRefs(ragme *ragmev1.RAGme, serviceName string, template *corev1.PodTemplateSpec) {
+	if serviceName != "api" && serviceName != "mcp" && serviceName != "agent" {
+		return
+	}
+	if len(ragme.Spec.SecretRefs) == 0 {
+		return
+	}
+
+	container := &template.Spec.Containers[0]
+	for _, ref := range ragme.Spec.SecretRefs {
+		container.EnvFrom = append(container.EnvFrom, corev1.EnvFromSource{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretRefName(ragme, ref)},
+			},
+		})
+	}
+	if revision := ragme.Status.SecretRefs.Revision; revision != "" {
+		template.Annotations = mergeStringMaps(template.Annotations, map[string]string{secretRefsRevisionAnnotation: revision})
+	}
+}
+
+// instancesForSecret maps a Secret event to the instances referencing it, or
+// to the instance owning the copy, so sources are synced and MinIO restarted
+// with a changed root user as soon as they change
+func (r *RAGmeReconciler) instancesForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetLabels()[copiedSecretLabel] == "true" {
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Name: obj.GetLabels()["instance"], Namespace: obj.GetNamespace()},
+		}}
+	}
+
+	instances := &ragmev1.RAGmeList{}
+	if err := r.List(ctx, instances); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list the instances referencing a Secret", "secret", obj.GetName())
+		return nil
+	}
+	var requests []reconcile.Request
+	for i := range instances.Items {
+		ragme := &instances.Items[i]
+		if ragme.Namespace == obj.GetNamespace() && ragme.Spec.Storage.MinIO.Enabled && minioRootSecretNames(ragme).Has(obj.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: ragme.Name, Namespace: ragme.Namespace},
+			})
+			continue
+		}
+		for _, ref := range ragme.Spec.SecretRefs {
+			if ref.Name == obj.GetName() && secretRefNamespace(ragme, ref) == obj.GetNamespace() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: ragme.Name, Namespace: ragme.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
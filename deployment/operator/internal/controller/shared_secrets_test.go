@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestSecretRefsCopyAcrossNamespaces(t *testing.T) {
+	ragme := harnessRAGme("team")
+	ragme.Spec.SecretRefs = []ragmev1.RAGmeSecretRef{{Name: "llm-keys", Namespace: "platform"}}
+	h := newReconcilerHarness(t, ragme)
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "llm-keys", Namespace: "platform", Annotations: map[string]string{shareWithAnnotation: "other, bench"}},
+		Data:       map[string][]byte{"OPENAI_API_KEY": []byte("sk-1")},
+	}
+	if err := h.client.Create(h.ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "team")
+
+	copied := &corev1.Secret{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "team-platform-llm-keys"}, copied); err != nil {
+		t.Fatalf("Secret not copied: %v", err)
+	}
+	if string(copied.Data["OPENAI_API_KEY"]) != "sk-1" || !metav1.IsControlledBy(copied, h.get(t, "team")) {
+		t.Errorf("copy = %+v, want the source data owned by the instance", copied)
+	}
+
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "team-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	envFrom := api.Spec.Template.Spec.Containers[0].EnvFrom
+	if len(envFrom) != 1 || envFrom[0].SecretRef == nil || envFrom[0].SecretRef.Name != copied.Name {
+		t.Errorf("envFrom = %+v, want the copy", envFrom)
+	}
+	revision := api.Spec.Template.Annotations[secretRefsRevisionAnnotation]
+	if revision == "" {
+		t.Fatal("no Secrets revision on the pod template")
+	}
+
+	// A rotated key is copied and rolls the services
+	source.Data["OPENAI_API_KEY"] = []byte("sk-2")
+	if err := h.client.Update(h.ctx, source); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "team")
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: copied.Name}, copied); err != nil {
+		t.Fatal(err)
+	}
+	if string(copied.Data["OPENAI_API_KEY"]) != "sk-2" {
+		t.Errorf("copy not updated: %s", copied.Data["OPENAI_API_KEY"])
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "team-api"}, api); err != nil {
+		t.Fatal(err)
+	}
+	if api.Spec.Template.Annotations[secretRefsRevisionAnnotation] == revision {
+		t.Error("Secrets revision unchanged after the source changed")
+	}
+
+	if requests := h.reconciler.instancesForSecret(h.ctx, source); len(requests) != 1 || requests[0].Name != "team" {
+		t.Errorf("instancesForSecret() = %v, want the referencing instance", requests)
+	}
+
+	// Copies no longer referenced are pruned
+	current := h.get(t, "team")
+	current.Spec.SecretRefs = nil
+	if err := h.client.Update(h.ctx, current); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "team")
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: copied.Name}, &corev1.Secret{}); err == nil {
+		t.Error("unreferenced copy not deleted")
+	}
+}
+
+func TestSecretRefsRequireShareWith(t *testing.T) {
+	ragme := harnessRAGme("team")
+	ragme.Spec.SecretRefs = []ragmev1.RAGmeSecretRef{{Name: "llm-keys", Namespace: "platform"}}
+	h := newReconcilerHarness(t, ragme)
+
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "llm-keys", Namespace: "platform", Annotations: map[string]string{shareWithAnnotation: "other"}},
+		Data:       map[string][]byte{"OPENAI_API_KEY": []byte("sk-1")},
+	}
+	if err := h.client.Create(h.ctx, source); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.reconciler.Reconcile(h.ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "bench", Name: "team"}}); err == nil {
+		t.Error("Reconcile() copied a Secret not shared with the namespace")
+	}
+	if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: "team-platform-llm-keys"}, &corev1.Secret{}); err == nil {
+		t.Error("Secret copied without the share-with annotation")
+	}
+}
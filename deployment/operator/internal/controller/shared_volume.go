@@ -0,0 +1,212 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+const (
+	defaultVolumeMonitorImage = "busybox:1.36"
+	volumeMonitorPort         = 9102
+	volumeMonitorMountPath    = "/watch"
+)
+
+// volumeMonitorScript publishes the watch-directory usage in the Prometheus
+// text format, so the same endpoint can also be scraped by Prometheus
+const volumeMonitorScript = `mkdir -p /tmp/www
+while true; do
+  df -P -k ` + volumeMonitorMountPath + ` | awk 'NR==2 {printf "ragme_shared_volume_capacity_bytes %.0f\nragme_shared_volume_used_bytes %.0f\n", $2*1024, $3*1024}' > /tmp/www/metrics.tmp && mv /tmp/www/metrics.tmp /tmp/www/metrics
+  sleep 30
+done &
+exec httpd -f -p 9102 -h /tmp/www`
+
+// applyVolumeMonitor adds the shared volume usage sidecar to the api pod
+func applyVolumeMonitor(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	monitoring := ragme.Spec.Storage.SharedVolume.Monitoring
+	if !monitoring.Enabled || serviceName != "api" {
+		return
+	}
+
+	podSpec.Containers = append(podSpec.Containers, corev1.Container{
+		Name:    "volume-monitor",
+		Image:   monitoring.Image,
+		Command: []string{"sh", "-c", volumeMonitorScript},
+		Ports: []corev1.ContainerPort{
+			{Name: "volume-metrics", ContainerPort: volumeMonitorPort},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "watch-directory", MountPath: volumeMonitorMountPath, ReadOnly: true},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("5m"),
+				corev1.ResourceMemory: resource.MustParse("8Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+		},
+	})
+}
+
+// checkSharedVolumeUsage reads the watch-directory usage from the monitor
+// sidecar, records it in status and expands the PVC when allowed
+func (r *RAGmeReconciler) checkSharedVolumeUsage(ctx context.Context, ragme *ragmev1.RAGme) error {
+	monitoring := ragme.Spec.Storage.SharedVolume.Monitoring
+	if !monitoring.Enabled {
+		ragme.Status.Storage.SharedVolume = ragmev1.StorageUsageStatus{}
+		conditions.Remove(&ragme.Status.Conditions, conditions.TypeSharedVolumeAlmostFull)
+		return nil
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), client.MatchingLabels{
+		"app":       "ragme",
+		"component": "api",
+		"instance":  ragme.Name,
+	}); err != nil {
+		return err
+	}
+
+	podIP := ""
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == corev1.PodRunning && pods.Items[i].Status.PodIP != "" {
+			podIP = pods.Items[i].Status.PodIP
+			break
+		}
+	}
+	if podIP == "" {
+		return fmt.Errorf("no running api pod to read the shared volume usage from")
+	}
+
+	url := fmt.Sprintf("http://%s:%d/metrics", podIP, volumeMonitorPort)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := defaultHTTPClient(r.HTTPClient).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("volume monitor returned status %d", resp.StatusCode)
+	}
+
+	samples, err := parsePrometheusText(resp.Body,
+		"ragme_shared_volume_capacity_bytes", "ragme_shared_volume_used_bytes")
+	if err != nil {
+		return err
+	}
+	capacity := int64(sumSamples(samples, "ragme_shared_volume_capacity_bytes", nil))
+	used := int64(sumSamples(samples, "ragme_shared_volume_used_bytes", nil))
+	if capacity <= 0 {
+		return fmt.Errorf("volume monitor did not report capacity")
+	}
+
+	usage := ragmev1.StorageUsageStatus{
+		UsedBytes:     used,
+		CapacityBytes: capacity,
+		UsedPercent:   int32(used * 100 / capacity),
+	}
+	ragme.Status.Storage.SharedVolume = usage
+
+	message := fmt.Sprintf("Shared volume usage is %d%% (threshold %d%%)", usage.UsedPercent, monitoring.ThresholdPercent)
+	if usage.UsedPercent < monitoring.ThresholdPercent {
+		conditions.SetFalse(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSharedVolumeAlmostFull, conditions.ReasonCapacityWithinThreshold, message)
+		return nil
+	}
+
+	reason := conditions.ReasonCapacityThresholdExceeded
+	if monitoring.AutoExpand {
+		state, size, err := r.expandSharedVolume(ctx, ragme)
+		if err != nil {
+			conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSharedVolumeAlmostFull, reason, message)
+			return err
+		}
+		switch state {
+		case volumeExpanded:
+			reason = conditions.ReasonVolumeExpanded
+			message = fmt.Sprintf("%s; expanding to %s", message, size)
+		case volumeAtMaxSize:
+			reason = conditions.ReasonVolumeAtMaxSize
+			message = fmt.Sprintf("%s; already at maxSize %s", message, size)
+		}
+	}
+	conditions.SetTrue(&ragme.Status.Conditions, ragme.Generation, conditions.TypeSharedVolumeAlmostFull, reason, message)
+
+	return nil
+}
+
+// volumeExpansion is the outcome of an automatic expansion attempt
+type volumeExpansion int
+
+const (
+	volumeExpansionPending volumeExpansion = iota
+	volumeExpanded
+	volumeAtMaxSize
+)
+
+// expandSharedVolume grows the shared PVC request by the configured step and
+// returns the outcome along with the requested size. Nothing is done while a
+// previous expansion is still in progress.
+func (r *RAGmeReconciler) expandSharedVolume(ctx context.Context, ragme *ragmev1.RAGme) (volumeExpansion, string, error) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-shared-pvc", ragme.Name), Namespace: ragme.Namespace}, pvc); err != nil {
+		return 0, "", err
+	}
+
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && capacity.Cmp(requested) < 0 {
+		return volumeExpansionPending, requested.String(), nil
+	}
+
+	monitoring := ragme.Spec.Storage.SharedVolume.Monitoring
+	next, err := nextVolumeSize(requested, monitoring.ExpandStepPercent, monitoring.MaxSize)
+	if err != nil {
+		return 0, "", err
+	}
+	if next.Cmp(requested) <= 0 {
+		return volumeAtMaxSize, requested.String(), nil
+	}
+
+	log.FromContext(ctx).Info("Expanding shared volume", "from", requested.String(), "to", next.String())
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = next
+	if err := r.Update(ctx, pvc); err != nil {
+		return 0, "", err
+	}
+	return volumeExpanded, next.String(), nil
+}
+
+// nextVolumeSize grows current by stepPercent, rounded up to a whole Gi and
+// capped at maxSize when set
+func nextVolumeSize(current resource.Quantity, stepPercent int32, maxSize string) (resource.Quantity, error) {
+	const gi = int64(1) << 30
+
+	bytes := current.Value() + current.Value()*int64(stepPercent)/100
+	next := *resource.NewQuantity((bytes+gi-1)/gi*gi, resource.BinarySI)
+
+	if maxSize != "" {
+		limit, err := resource.ParseQuantity(maxSize)
+		if err != nil {
+			return resource.Quantity{}, fmt.Errorf("invalid shared volume maxSize %q: %w", maxSize, err)
+		}
+		if next.Cmp(limit) > 0 {
+			return limit, nil
+		}
+	}
+	return next, nil
+}
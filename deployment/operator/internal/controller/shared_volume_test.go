@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNextVolumeSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		current     string
+		stepPercent int32
+		maxSize     string
+		want        string
+		wantErr     bool
+	}{
+		{name: "grows by step", current: "10Gi", stepPercent: 50, want: "15Gi"},
+		{name: "rounds up to whole Gi", current: "5Gi", stepPercent: 50, want: "8Gi"},
+		{name: "capped at max size", current: "10Gi", stepPercent: 50, maxSize: "12Gi", want: "12Gi"},
+		{name: "below max size", current: "10Gi", stepPercent: 20, maxSize: "20Gi", want: "12Gi"},
+		{name: "invalid max size", current: "10Gi", stepPercent: 50, maxSize: "lots", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextVolumeSize(resource.MustParse(tt.current), tt.stepPercent, tt.maxSize)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("nextVolumeSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if want := resource.MustParse(tt.want); got.Cmp(want) != 0 {
+				t.Errorf("nextVolumeSize() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultAvailabilityTarget = 99.5
+	defaultLatencyTarget      = 2 * time.Second
+	defaultProbeInterval      = time.Minute
+	defaultSLOWindow          = 24 * time.Hour
+)
+
+// Probe and SLO series of the instances with spec.slo enabled
+var (
+	probeDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ragme_probe_duration_seconds",
+		Help:    "Duration of the synthetic probes of a RAGme service",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"namespace", "name", "service"})
+	probeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ragme_probe_failures_total",
+		Help: "Synthetic probes of a RAGme service that failed or exceeded the latency target",
+	}, []string{"namespace", "name", "service"})
+	sloAvailability = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ragme_slo_availability_ratio",
+		Help: "Ratio of successful probes of a RAGme instance over the SLO window",
+	}, []string{"namespace", "name"})
+	sloBurnRate = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ragme_slo_burn_rate",
+		Help: "Pace the error budget of a RAGme instance is consumed at, 1 spending exactly the budget over the window",
+	}, []string{"namespace", "name"})
+	sloErrorBudgetRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ragme_slo_error_budget_remaining_ratio",
+		Help: "Ratio of the error budget of a RAGme instance left over the SLO window",
+	}, []string{"namespace", "name"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(probeDuration, probeFailures, sloAvailability, sloBurnRate, sloErrorBudgetRemaining)
+}
+
+// probeResult is the outcome of one synthetic probe
+type probeResult struct {
+	At       time.Time
+	Service  string
+	Duration time.Duration
+	OK       bool
+}
+
+// probeHistory keeps the probe results of each instance within its SLO window
+type probeHistory struct {
+	mu      sync.Mutex
+	results map[types.NamespacedName][]probeResult
+}
+
+// sloProbes is the probe history of the instances reconciled by this operator
+var sloProbes = &probeHistory{results: map[types.NamespacedName][]probeResult{}}
+
+// lastProbe returns when the instance was last probed
+func (h *probeHistory) lastProbe(key types.NamespacedName) time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	results := h.results[key]
+	if len(results) == 0 {
+		return time.Time{}
+	}
+	return results[len(results)-1].At
+}
+
+// record adds the results of a probe round, drops the results older than the
+// window and returns the remaining ones
+func (h *probeHistory) record(key types.NamespacedName, results []probeResult, window time.Duration, now time.Time) []probeResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	kept := []probeResult{}
+	for _, result := range append(h.results[key], results...) {
+		if now.Sub(result.At) <= window {
+			kept = append(kept, result)
+		}
+	}
+	h.results[key] = kept
+	return append([]probeResult(nil), kept...)
+}
+
+// forget drops the history of an instance
+func (h *probeHistory) forget(key types.NamespacedName) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.results, key)
+}
+
+// sloSettings returns the effective SLO settings of the instance
+func sloSettings(ragme *ragmev1.RAGme) (target float64, latency, interval, window time.Duration) {
+	slo := ragme.Spec.SLO
+	target = defaultAvailabilityTarget
+	if parsed, err := strconv.ParseFloat(slo.AvailabilityTarget, 64); err == nil {
+		target = parsed
+	}
+	return target,
+		durationOrDefault(slo.LatencyTarget, defaultLatencyTarget),
+		durationOrDefault(slo.ProbeInterval, defaultProbeInterval),
+		durationOrDefault(slo.Window, defaultSLOWindow)
+}
+
+// forgetSLO withdraws the probe history and the SLO series of an instance
+func forgetSLO(namespace, name string) {
+	sloProbes.forget(types.NamespacedName{Namespace: namespace, Name: name})
+	labels := prometheus.Labels{"namespace": namespace, "name": name}
+	for _, vec := range []*prometheus.MetricVec{
+		probeDuration.MetricVec, probeFailures.MetricVec,
+		sloAvailability.MetricVec, sloBurnRate.MetricVec, sloErrorBudgetRemaining.MetricVec,
+	} {
+		vec.DeletePartialMatch(labels)
+	}
+}
+
+// probeSLO probes the api and frontend once per probe interval and records the
+// availability over the window. Planned downtime, hibernation or full
+// maintenance, is not probed.
+func (r *RAGmeReconciler) probeSLO(ctx context.Context, ragme *ragmev1.RAGme) {
+	if !ragme.Spec.SLO.Enabled {
+		forgetSLO(ragme.Namespace, ragme.Name)
+		ragme.Status.SLO = ragmev1.RAGmeSLOStatus{}
+		return
+	}
+	if ragme.Status.Hibernation.Hibernated || ragme.Spec.MaintenanceMode == maintenanceFull {
+		return
+	}
+
+	key := types.NamespacedName{Namespace: ragme.Namespace, Name: ragme.Name}
+	_, latency, interval, window := sloSettings(ragme)
+	now := r.now()
+	if now.Sub(sloProbes.lastProbe(key)) < interval {
+		return
+	}
+
+	httpClient := defaultHTTPClient(r.HTTPClient)
+	results := []probeResult{
+		// Counting the documents reads the vector database through the api
+		probeService(ctx, httpClient, "api", fmt.Sprintf("http://%s-api.%s.svc:8021/count-documents", ragme.Name, ragme.Namespace), latency, now),
+		probeService(ctx, httpClient, "frontend", fmt.Sprintf("http://%s-frontend.%s.svc:8020/", ragme.Name, ragme.Namespace), latency, now),
+	}
+	for _, result := range results {
+		probeDuration.WithLabelValues(ragme.Namespace, ragme.Name, result.Service).Observe(result.Duration.Seconds())
+		if !result.OK {
+			probeFailures.WithLabelValues(ragme.Namespace, ragme.Name, result.Service).Inc()
+		}
+	}
+
+	setSLOStatus(ragme, sloProbes.record(key, results, window, now), now)
+}
+
+// probeService sends a synthetic request to a service. The probe fails on an
+// error, a non-2xx answer or an answer slower than the latency target
+func probeService(ctx context.Context, httpClient *http.Client, service, url string, latency time.Duration, now time.Time) probeResult {
+	result := probeResult{At: now, Service: service}
+	ctx, cancel := context.WithTimeout(ctx, 2*latency)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return result
+	}
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	result.Duration = time.Since(start)
+	if err != nil {
+		return result
+	}
+	resp.Body.Close()
+	result.OK = resp.StatusCode < 300 && result.Duration <= latency
+	return result
+}
+
+// setSLOStatus computes the availability, latency and error budget burn of the
+// probe results in the window and exports them
+func setSLOStatus(ragme *ragmev1.RAGme, results []probeResult, now time.Time) {
+	target, _, _, _ := sloSettings(ragme)
+	status := ragmev1.RAGmeSLOStatus{
+		Probes:        int32(len(results)),
+		LastProbeTime: &metav1.Time{Time: now},
+	}
+	if len(results) == 0 {
+		ragme.Status.SLO = status
+		return
+	}
+
+	succeeded := 0
+	durations := make([]time.Duration, 0, len(results))
+	for _, result := range results {
+		if result.OK {
+			succeeded++
+		}
+		durations = append(durations, result.Duration)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	availability := float64(succeeded) / float64(len(results))
+	burnRate := 0.0
+	if budget := 1 - target/100; budget > 0 {
+		burnRate = (1 - availability) / budget
+	}
+	remaining := 1 - burnRate
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	status.Availability = strconv.FormatFloat(availability*100, 'f', 2, 64)
+	status.LatencyP95 = durations[(len(durations)*95+99)/100-1].Round(time.Millisecond).String()
+	status.BurnRate = strconv.FormatFloat(burnRate, 'f', 2, 64)
+	status.ErrorBudgetRemaining = strconv.FormatFloat(remaining*100, 'f', 1, 64)
+	ragme.Status.SLO = status
+
+	sloAvailability.WithLabelValues(ragme.Namespace, ragme.Name).Set(availability)
+	sloBurnRate.WithLabelValues(ragme.Namespace, ragme.Name).Set(burnRate)
+	sloErrorBudgetRemaining.WithLabelValues(ragme.Namespace, ragme.Name).Set(remaining)
+}
+
+// sloResult shortens the requeue of result so the instance is probed on time
+func sloResult(ragme *ragmev1.RAGme, result ctrl.Result) ctrl.Result {
+	if !ragme.Spec.SLO.Enabled {
+		return result
+	}
+	_, _, interval, _ := sloSettings(ragme)
+	return requeueBefore(result, interval)
+}
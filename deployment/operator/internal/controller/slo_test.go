@@ -0,0 +1,72 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestSetSLOStatus(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ragme := &ragmev1.RAGme{}
+	ragme.Name = "slo-status"
+	ragme.Spec.SLO = ragmev1.RAGmeSLO{Enabled: true, AvailabilityTarget: "99"}
+
+	results := make([]probeResult, 0, 200)
+	for i := 0; i < 200; i++ {
+		results = append(results, probeResult{At: now, Service: "api", Duration: time.Duration(i+1) * time.Millisecond, OK: i != 0})
+	}
+	setSLOStatus(ragme, results, now)
+
+	status := ragme.Status.SLO
+	if status.Probes != 200 || status.Availability != "99.50" {
+		t.Errorf("probes = %d, availability = %s, want 200 and 99.50", status.Probes, status.Availability)
+	}
+	if status.BurnRate != "0.50" || status.ErrorBudgetRemaining != "50.0" {
+		t.Errorf("burn rate = %s, budget remaining = %s, want 0.50 and 50.0", status.BurnRate, status.ErrorBudgetRemaining)
+	}
+	if status.LatencyP95 != "190ms" {
+		t.Errorf("latencyP95 = %s, want 190ms", status.LatencyP95)
+	}
+}
+
+func TestProbeHistoryWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := &probeHistory{results: map[types.NamespacedName][]probeResult{}}
+	key := types.NamespacedName{Namespace: "default", Name: "ragme"}
+
+	history.record(key, []probeResult{{At: now.Add(-2 * time.Hour), OK: true}}, time.Hour, now.Add(-2*time.Hour))
+	kept := history.record(key, []probeResult{{At: now, OK: false}}, time.Hour, now)
+	if len(kept) != 1 || kept[0].OK {
+		t.Errorf("kept = %+v, want only the probe within the window", kept)
+	}
+	if got := history.lastProbe(key); !got.Equal(now) {
+		t.Errorf("lastProbe = %v, want %v", got, now)
+	}
+}
+
+func TestProbeService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/slow":
+			time.Sleep(50 * time.Millisecond)
+		case "/error":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tests := map[string]bool{"/": true, "/slow": false, "/error": false}
+	for path, want := range tests {
+		result := probeService(context.Background(), server.Client(), "api", server.URL+path, 20*time.Millisecond, time.Now())
+		if result.OK != want {
+			t.Errorf("probe %s OK = %v, want %v", path, result.OK, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// patchStatus writes the status of the instance when it differs from stored,
+// the instance as last read or written. The merge patch only carries the
+// status, so the in-memory spec keeps its defaults, and stored is advanced to
+// the written instance for the next patch of the pass.
+func (r *RAGmeReconciler) patchStatus(ctx context.Context, ragme, stored *ragmev1.RAGme) error {
+	if equality.Semantic.DeepEqual(stored.Status, ragme.Status) {
+		return nil
+	}
+	patched := stored.DeepCopy()
+	ragme.Status.DeepCopyInto(&patched.Status)
+	if err := r.Status().Patch(ctx, patched, client.MergeFrom(stored)); err != nil {
+		return err
+	}
+	// Take the status as stored, e.g. with its timestamps truncated to seconds
+	patched.Status.DeepCopyInto(&ragme.Status)
+	ragme.ResourceVersion = patched.ResourceVersion
+	*stored = *patched
+	return nil
+}
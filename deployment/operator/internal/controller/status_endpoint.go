@@ -0,0 +1,345 @@
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+// statusTokenKey is the key of the status endpoint token in its Secret
+const statusTokenKey = "token"
+
+// statusTokenSecretName returns the name of the Secret holding the status endpoint token
+func statusTokenSecretName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-status-token", ragme.Name)
+}
+
+// reconcileStatusToken creates the Secret holding the token required to read
+// the instance summary from the operator status server
+func (r *RAGmeReconciler) reconcileStatusToken(ctx context.Context, ragme *ragmev1.RAGme) error {
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: statusTokenSecretName(ragme), Namespace: ragme.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !ragme.Spec.StatusEndpoint.Enabled {
+		if err == nil {
+			return client.IgnoreNotFound(r.Delete(ctx, found))
+		}
+		return nil
+	}
+	if err == nil {
+		return nil
+	}
+
+	token, err := generateAPIKey()
+	if err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statusTokenSecretName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{statusTokenKey: []byte(token)},
+	}
+	if err := r.setOwner(ragme, secret); err != nil {
+		return err
+	}
+	return r.Create(ctx, secret)
+}
+
+// instanceSummary is the document served by the status server
+type instanceSummary struct {
+	Name        string              `json:"name"`
+	Namespace   string              `json:"namespace"`
+	Phase       string              `json:"phase"`
+	Ready       bool                `json:"ready"`
+	Version     string              `json:"version,omitempty"`
+	Components  []componentSummary  `json:"components"`
+	URLs        map[string]string   `json:"urls,omitempty"`
+	Collections []collectionSummary `json:"collections,omitempty"`
+	Conditions  []metav1.Condition  `json:"conditions,omitempty"`
+	GeneratedAt time.Time           `json:"generatedAt"`
+}
+
+// componentSummary reports the health of a generated Deployment
+type componentSummary struct {
+	Name          string `json:"name"`
+	Ready         bool   `json:"ready"`
+	Replicas      int32  `json:"replicas"`
+	ReadyReplicas int32  `json:"readyReplicas"`
+	Image         string `json:"image,omitempty"`
+}
+
+// collectionSummary reports a RAGmeCollection of the instance
+type collectionSummary struct {
+	Name    string `json:"name"`
+	Phase   string `json:"phase,omitempty"`
+	Objects *int64 `json:"objects,omitempty"`
+}
+
+// StatusServer serves the summary of the RAGme instances with
+// spec.statusEndpoint.enabled at /instances/<namespace>/<name>, and their
+// component graph at /instances/<namespace>/<name>/topology. Requests must
+// carry the token of the instance, as a bearer token or the token query parameter.
+type StatusServer struct {
+	Client client.Client
+
+	// Addr is the address the server binds to
+	Addr string
+
+	// HTTPClient is used to count the collection objects. Defaults to a client with a 30s timeout
+	HTTPClient *http.Client
+}
+
+// NeedLeaderElection lets every operator replica serve the summaries
+func (s *StatusServer) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves the summaries until the context is cancelled
+func (s *StatusServer) Start(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/instances/", s)
+	server := &http.Server{
+		Addr:              s.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(shutdownCtx)
+	case err := <-errCh:
+		return err
+	}
+}
+
+// ServeHTTP serves the summary of one instance as JSON, or as HTML when requested
+func (s *StatusServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(req.URL.Path, "/instances/"), "/"), "/")
+	topology := len(parts) == 3 && parts[2] == "topology"
+	if (len(parts) != 2 && !topology) || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	ctx := req.Context()
+	ragme := &ragmev1.RAGme{}
+	if err := s.Client.Get(ctx, types.NamespacedName{Namespace: parts[0], Name: parts[1]}, ragme); err != nil || !ragme.Spec.StatusEndpoint.Enabled {
+		http.NotFound(w, req)
+		return
+	}
+	if !s.authorized(ctx, ragme, req) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if topology {
+		s.serveTopology(w, req, ragme)
+		return
+	}
+
+	summary, err := s.summarize(ctx, ragme)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to summarize RAGme", "name", ragme.Name, "namespace", ragme.Namespace)
+		http.Error(w, "unable to summarize the instance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	if req.URL.Query().Get("format") == "html" || strings.Contains(req.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = summaryTemplate.Execute(w, summary)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(summary)
+}
+
+// serveTopology serves the component graph of one instance as JSON, or as DOT
+// with format=dot
+func (s *StatusServer) serveTopology(w http.ResponseWriter, req *http.Request, ragme *ragmev1.RAGme) {
+	ctx := req.Context()
+	deployments := &appsv1.DeploymentList{}
+	if err := s.Client.List(ctx, deployments, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": ragme.Name}); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list RAGme components", "name", ragme.Name, "namespace", ragme.Namespace)
+		http.Error(w, "unable to build the topology", http.StatusInternalServerError)
+		return
+	}
+	graph := buildTopology(ragme, deployments.Items)
+
+	w.Header().Set("Cache-Control", "no-store")
+	if req.URL.Query().Get("format") == "dot" {
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		_, _ = w.Write([]byte(renderTopologyDOT(graph)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(graph)
+}
+
+// authorized reports whether the request carries the status token of the instance
+func (s *StatusServer) authorized(ctx context.Context, ragme *ragmev1.RAGme, req *http.Request) bool {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		token = req.URL.Query().Get("token")
+	}
+	if token == "" {
+		return false
+	}
+
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, types.NamespacedName{Name: statusTokenSecretName(ragme), Namespace: ragme.Namespace}, secret); err != nil {
+		return false
+	}
+	expected := secret.Data[statusTokenKey]
+	return len(expected) > 0 && subtle.ConstantTimeCompare([]byte(token), expected) == 1
+}
+
+// summarize collects the component health, URLs and collections of the instance
+func (s *StatusServer) summarize(ctx context.Context, ragme *ragmev1.RAGme) (*instanceSummary, error) {
+	summary := &instanceSummary{
+		Name:        ragme.Name,
+		Namespace:   ragme.Namespace,
+		Phase:       ragme.Status.Phase,
+		Ready:       conditions.IsTrue(ragme.Status.Conditions, conditions.TypeReady),
+		Version:     ragme.Spec.Version,
+		URLs:        map[string]string{},
+		Conditions:  ragme.Status.Conditions,
+		GeneratedAt: time.Now().UTC(),
+	}
+	selector := client.MatchingLabels{"app": "ragme", "instance": ragme.Name}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := s.Client.List(ctx, deployments, client.InNamespace(ragme.Namespace), selector); err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		summary.Components = append(summary.Components, summarizeDeployment(&deployment))
+	}
+	sort.Slice(summary.Components, func(i, j int) bool {
+		return summary.Components[i].Name < summary.Components[j].Name
+	})
+
+	services := &corev1.ServiceList{}
+	if err := s.Client.List(ctx, services, client.InNamespace(ragme.Namespace), selector); err != nil {
+		return nil, err
+	}
+	for _, service := range services.Items {
+		if len(service.Spec.Ports) > 0 {
+			summary.URLs[service.Name] = fmt.Sprintf("http://%s.%s.svc:%d", service.Name, service.Namespace, service.Spec.Ports[0].Port)
+		}
+	}
+	if ingress := ragme.Spec.ExternalAccess.Ingress; ingress.Enabled && ingress.Host != "" {
+		scheme := "http"
+		if ingress.TLSEnabled {
+			scheme = "https"
+		}
+		summary.URLs["public"] = fmt.Sprintf("%s://%s", scheme, ingress.Host)
+	}
+
+	collections := &ragmev1.RAGmeCollectionList{}
+	if err := s.Client.List(ctx, collections, client.InNamespace(ragme.Namespace)); err != nil {
+		return nil, err
+	}
+	var vdb collectionClient
+	if ragme.Spec.StatusEndpoint.CollectionCounts {
+		vdb, _ = newCollectionClient(defaultHTTPClient(s.HTTPClient), ragme)
+	}
+	for i := range collections.Items {
+		collection := &collections.Items[i]
+		if collection.Spec.InstanceRef != ragme.Name {
+			continue
+		}
+		entry := collectionSummary{Name: collectionName(collection), Phase: collection.Status.Phase}
+		if vdb != nil {
+			// A vector database that cannot be reached only hides the count
+			if count, err := vdb.CountObjects(ctx, collection); err == nil {
+				entry.Objects = &count
+			}
+		}
+		summary.Collections = append(summary.Collections, entry)
+	}
+	return summary, nil
+}
+
+// summarizeDeployment reports the health of a generated Deployment
+func summarizeDeployment(deployment *appsv1.Deployment) componentSummary {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	component := componentSummary{
+		Name:          deployment.Labels["component"],
+		Replicas:      replicas,
+		ReadyReplicas: deployment.Status.ReadyReplicas,
+		Ready:         deployment.Status.ReadyReplicas >= replicas,
+	}
+	if component.Name == "" {
+		component.Name = deployment.Name
+	}
+	if containers := deployment.Spec.Template.Spec.Containers; len(containers) > 0 {
+		component.Image = containers[0].Image
+	}
+	return component
+}
+
+// summaryTemplate renders the summary for embedding into portals
+var summaryTemplate = template.Must(template.New("summary").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>RAGme {{.Namespace}}/{{.Name}}</title></head>
+<body>
+<h1>RAGme {{.Namespace}}/{{.Name}}</h1>
+<p>Phase: <strong>{{.Phase}}</strong>{{if .Version}} &middot; Version {{.Version}}{{end}}</p>
+<h2>Components</h2>
+<table>
+<tr><th>Component</th><th>Ready</th><th>Replicas</th><th>Image</th></tr>
+{{range .Components}}<tr><td>{{.Name}}</td><td>{{if .Ready}}yes{{else}}no{{end}}</td><td>{{.ReadyReplicas}}/{{.Replicas}}</td><td>{{.Image}}</td></tr>
+{{end}}</table>
+{{if .URLs}}<h2>URLs</h2>
+<ul>
+{{range $name, $url := .URLs}}<li>{{$name}}: <a href="{{$url}}">{{$url}}</a></li>
+{{end}}</ul>{{end}}
+{{if .Collections}}<h2>Collections</h2>
+<table>
+<tr><th>Collection</th><th>Phase</th><th>Objects</th></tr>
+{{range .Collections}}<tr><td>{{.Name}}</td><td>{{.Phase}}</td><td>{{if .Objects}}{{.Objects}}{{end}}</td></tr>
+{{end}}</table>{{end}}
+<p><small>Generated at {{.GeneratedAt.Format "2006-01-02T15:04:05Z07:00"}}</small></p>
+</body>
+</html>
+`))
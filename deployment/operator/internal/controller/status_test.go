@@ -0,0 +1,37 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+func TestStatusOnlyWrittenOnChange(t *testing.T) {
+	h := newReconcilerHarness(t, harnessRAGme("quiet"))
+	h.reconcile(t, "quiet")
+	h.reconcile(t, "quiet")
+
+	// A resync of the applied generation changes nothing
+	stored := h.get(t, "quiet")
+	h.reconcile(t, "quiet")
+	if got := h.get(t, "quiet"); got.ResourceVersion != stored.ResourceVersion {
+		t.Errorf("resourceVersion = %s after a resync, want %s unchanged", got.ResourceVersion, stored.ResourceVersion)
+	}
+
+	stored.Spec.Replicas.API = 3
+	if err := h.client.Update(h.ctx, stored); err != nil {
+		t.Fatal(err)
+	}
+	h.reconcile(t, "quiet")
+	got := h.get(t, "quiet")
+	ready := meta.FindStatusCondition(got.Status.Conditions, conditions.TypeReady)
+	if got.Status.ObservedGeneration != got.Generation || ready == nil || ready.ObservedGeneration != got.Generation {
+		t.Errorf("status %+v, want the new generation %d observed", got.Status, got.Generation)
+	}
+	// The defaults of the spec are never written back by the status patches
+	if authType := got.Spec.Storage.MinIO.Metrics.AuthType; authType != "" {
+		t.Errorf("metrics authType = %q, want the spec left as written", authType)
+	}
+}
@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// subreconciler reconciles one area of an instance, e.g. its object store or
+// its vector database. The RAGme reconciler runs the registered
+// subreconcilers in order, the concurrent ones alongside the areas before
+// them; an error stops the pass so that later areas never run against
+// missing dependencies.
+type subreconciler interface {
+	// Name identifies the area in logs
+	Name() string
+
+	// Concurrent reports whether the area runs alongside the previous one,
+	// which it does not depend on
+	Concurrent() bool
+
+	// Reconcile brings the area of the instance to its desired state
+	Reconcile(ctx context.Context, ragme *ragmev1.RAGme) error
+}
+
+// reconcileStep is one action of a subreconciler. Best-effort steps observe
+// the instance: their failures are logged and only delay the next observation.
+type reconcileStep struct {
+	// description completes "failed to ..." in errors and logs
+	description string
+	run         func(ctx context.Context, ragme *ragmev1.RAGme) error
+	bestEffort  bool
+}
+
+// stepReconciler is a subreconciler running its steps in order
+type stepReconciler struct {
+	name       string
+	steps      []reconcileStep
+	concurrent bool
+}
+
+// Name returns the name of the area
+func (s *stepReconciler) Name() string {
+	return s.name
+}
+
+// Concurrent reports whether the area runs alongside the previous one
+func (s *stepReconciler) Concurrent() bool {
+	return s.concurrent
+}
+
+// Reconcile runs the steps, stopping at the first failed step that is not best effort
+func (s *stepReconciler) Reconcile(ctx context.Context, ragme *ragmev1.RAGme) error {
+	logger := log.FromContext(ctx).WithValues("subreconciler", s.name)
+	for _, step := range s.steps {
+		if err := step.run(ctx, ragme); err != nil {
+			if step.bestEffort {
+				logger.Error(err, "Failed to "+step.description)
+				continue
+			}
+			return fmt.Errorf("failed to %s: %w", step.description, err)
+		}
+	}
+	return nil
+}
+
+// subreconcilerStages groups the areas into stages, each stage made of an
+// area and the concurrent areas following it
+func subreconcilerStages(subs []subreconciler) [][]subreconciler {
+	var stages [][]subreconciler
+	for _, sub := range subs {
+		if sub.Concurrent() && len(stages) > 0 {
+			stages[len(stages)-1] = append(stages[len(stages)-1], sub)
+			continue
+		}
+		stages = append(stages, []subreconciler{sub})
+	}
+	return stages
+}
+
+// infallible adapts a step that cannot fail
+func infallible(run func(ctx context.Context, ragme *ragmev1.RAGme)) func(context.Context, *ragmev1.RAGme) error {
+	return func(ctx context.Context, ragme *ragmev1.RAGme) error {
+		run(ctx, ragme)
+		return nil
+	}
+}
+
+// subreconcilers returns the registry of the areas of an instance in the
+// order they are reconciled. It is built from the receiver, so a copy of the
+// reconciler, e.g. the dry run with its recording client, runs its own steps.
+// A new backend plugs in by adding its steps to its area.
+func (r *RAGmeReconciler) subreconcilers() []subreconciler {
+	return []subreconciler{
+		&stepReconciler{name: "Planning", steps: []reconcileStep{
+			// Report a maintenance freeze and evaluate the hibernation schedule before scaling the services
+			{description: "evaluate maintenance", run: infallible(func(_ context.Context, ragme *ragmev1.RAGme) {
+				setMaintenanceCondition(ragme)
+			})},
+			{description: "evaluate hibernation", run: func(_ context.Context, ragme *ragmev1.RAGme) error {
+				return setHibernationStatus(ragme, r.now())
+			}},
+			// Select the classes of the generated Ingresses and volumes
+			{description: "reconcile classes", run: r.reconcileClasses},
+			// Check the requested zones can hold the data services
+			{description: "reconcile zone topology", run: r.reconcileZoneTopology},
+		}},
+		&stepReconciler{name: "Storage", steps: []reconcileStep{
+			// Plan the restore of a new instance before its volumes are created
+			{description: "prepare restore", run: r.prepareRestore},
+			// Re-bind the volumes of a renamed instance before new ones are provisioned
+			{description: "migrate the previous instance", run: r.reconcileMigration},
+			{description: "reconcile storage", run: r.reconcileStorage},
+			// Expand the shared volume when allowed
+			{description: "check shared volume usage", run: r.checkSharedVolumeUsage, bestEffort: true},
+		}},
+		&stepReconciler{name: "ObjectStore", steps: []reconcileStep{
+			// Deploy MinIO or the S3 gateway, the service users and the bucket
+			{description: "provision object storage", run: r.objectStorageStep(objectStorageProvider.ProvisionBuckets)},
+			{description: "apply the object lifecycle", run: r.objectStorageStep(objectStorageProvider.Lifecycle)},
+			{description: "check object storage capacity", run: r.objectStorageStep(objectStorageProvider.Metrics), bestEffort: true},
+		}},
+		// The object store, the vector database and the caches only need the volumes
+		&stepReconciler{name: "VectorDB", concurrent: true, steps: []reconcileStep{
+			{description: "reconcile vector database", run: r.reconcileVectorDB},
+		}},
+		&stepReconciler{name: "Caches", concurrent: true, steps: []reconcileStep{
+			{description: "reconcile session store", run: r.reconcileSessionStore},
+			{description: "reconcile embeddings cache", run: r.reconcileEmbeddingsCache},
+			{description: "reconcile query cache", run: r.reconcileQueryCache},
+		}},
+		&stepReconciler{name: "Networking", steps: []reconcileStep{
+			// Route the Ingress host to the frontend, api and mcp
+			{description: "reconcile Ingress", run: r.reconcileIngress},
+			// Expose the query-only endpoints for anonymous access
+			{description: "reconcile public Ingress", run: r.reconcilePublicIngress},
+			{description: "reconcile Ingress authentication", run: r.reconcileIngressAuth},
+			// Run the egress proxy the services reach the LLM providers through
+			{description: "reconcile egress proxy", run: r.reconcileEgressProxy},
+		}},
+		// The Ingresses and the credentials of the services are independent
+		&stepReconciler{name: "Access", concurrent: true, steps: []reconcileStep{
+			// Warn when OAuth redirect URIs are overridden by the Ingress host
+			{description: "check OAuth redirect URIs", run: infallible(func(_ context.Context, ragme *ragmev1.RAGme) {
+				checkOAuthRedirectURIs(ragme)
+			})},
+			{description: "reconcile LDAP connection test", run: r.reconcileLDAPCheck, bestEffort: true},
+			// Provision API keys for headless clients and the token of the status endpoint
+			{description: "reconcile service authentication", run: r.reconcileServiceAuth},
+			{description: "reconcile status endpoint token", run: r.reconcileStatusToken},
+			{description: "reconcile tenants configuration", run: r.reconcileTenantsConfig},
+			{description: "reconcile authorization configuration", run: r.reconcileAuthorizationConfig},
+		}},
+		&stepReconciler{name: "Configuration", steps: []reconcileStep{
+			// Copy the Secrets shared from other namespaces before the services read them
+			{description: "sync referenced Secrets", run: r.reconcileSecretRefs},
+			{description: "reconcile language model configuration", run: r.reconcileLLMConfig},
+			{description: "reconcile retrieval configuration", run: r.reconcileRetrievalConfig},
+			{description: "reconcile processing configuration", run: r.reconcileProcessingConfig},
+			{description: "reconcile feature flags", run: r.reconcileFeatureFlags},
+			{description: "reconcile frontend notices", run: r.reconcileNotices},
+		}},
+		&stepReconciler{name: "Services", steps: []reconcileStep{
+			{description: "reconcile RAGme services", run: r.reconcileRAGmeServices},
+			// Expose the operational endpoints on the admin Services
+			{description: "reconcile admin services", run: r.reconcileAdminServices},
+		}},
+		&stepReconciler{name: "Observability", steps: []reconcileStep{
+			{description: "reconcile metrics agent", run: r.reconcileMetricsAgent},
+			{description: "reconcile collector", run: r.reconcileCollector},
+			{description: "reconcile profiling", run: r.reconcileProfiling},
+			// Route the alerts of the namespace and mute them during upgrades
+			{description: "reconcile alert routing", run: r.reconcileAlertRouting},
+			{description: "mute upgrade alerts", run: infallible(r.reconcileUpgradeAlerting)},
+			// Publish the component graph and the catalog-info entities
+			{description: "reconcile topology", run: r.reconcileTopology},
+			{description: "reconcile catalog info", run: r.reconcileCatalogInfo},
+		}},
+		&stepReconciler{name: "Checks", steps: []reconcileStep{
+			// Report the readiness of the workloads as cached by the watches
+			{description: "aggregate readiness", run: r.aggregateReadiness},
+			// Let running pods pick up changed feature flags
+			{description: "trigger feature flags reload", run: r.reloadFeatureFlags, bestEffort: true},
+			{description: "check upload scanning", run: r.checkScanning, bestEffort: true},
+			{description: "check the agent queue", run: r.checkAgentQueue, bestEffort: true},
+			{description: "check the transcriptions", run: r.checkMedia, bestEffort: true},
+			{description: "check the de-duplication", run: r.checkDedup, bestEffort: true},
+			{description: "check the version cleanup", run: r.checkVersionCleanup, bestEffort: true},
+			{description: "check the token budget", run: r.checkBudget, bestEffort: true},
+			{description: "check the quality evaluation", run: r.checkEvaluation, bestEffort: true},
+			{description: "check the vector database", run: r.checkVectorDB, bestEffort: true},
+			// Revert or report the Services edited outside of the operator
+			{description: "audit configuration drift", run: r.auditDrift, bestEffort: true},
+			// Unreachable services count as failed probes
+			{description: "probe the SLO", run: infallible(r.probeSLO)},
+			{description: "verify self-healing", bestEffort: true, run: func(ctx context.Context, ragme *ragmev1.RAGme) error {
+				return r.verifyResilience(ctx, ragme, r.now())
+			}},
+			// Reschedule MinIO and Weaviate off failed nodes
+			{description: "recover from a node failure", bestEffort: true, run: func(ctx context.Context, ragme *ragmev1.RAGme) error {
+				return r.reconcileNodeRecovery(ctx, ragme, r.now())
+			}},
+		}},
+		&stepReconciler{name: "Data", steps: []reconcileStep{
+			// Import the restored collections once the volumes are in place
+			{description: "restore data", run: r.reconcileRestore},
+			// Ingest the seed data once the instance is ready
+			{description: "reconcile seed data", run: r.reconcileSeedData},
+			{description: "reconcile evaluation", run: r.reconcileEvaluation},
+			{description: "reconcile version cleanup", run: r.reconcileVersionCleanup},
+			// Remove finished Jobs beyond the history limits
+			{description: "prune finished Jobs", run: r.pruneJobs, bestEffort: true},
+		}},
+	}
+}
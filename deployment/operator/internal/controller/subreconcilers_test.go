@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestStepReconciler(t *testing.T) {
+	var ran []string
+	step := func(name string, err error) func(context.Context, *ragmev1.RAGme) error {
+		return func(context.Context, *ragmev1.RAGme) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	sub := &stepReconciler{name: "Test", steps: []reconcileStep{
+		{description: "observe", run: step("observe", errors.New("unreachable")), bestEffort: true},
+		{description: "apply", run: step("apply", errors.New("conflict"))},
+		{description: "publish", run: step("publish", nil)},
+	}}
+
+	err := sub.Reconcile(context.Background(), &ragmev1.RAGme{})
+	if err == nil || err.Error() != "failed to apply: conflict" {
+		t.Errorf("Reconcile() error = %v, want the failed step", err)
+	}
+	if len(ran) != 2 || ran[1] != "apply" {
+		t.Errorf("ran %v, want the best-effort failure skipped and the pass stopped at apply", ran)
+	}
+}
+
+func TestSubreconcilersOrder(t *testing.T) {
+	want := []string{"Planning", "Storage", "ObjectStore", "VectorDB", "Caches", "Networking", "Access", "Configuration", "Services", "Observability", "Checks", "Data"}
+	subs := (&RAGmeReconciler{}).subreconcilers()
+	if len(subs) != len(want) {
+		t.Fatalf("%d subreconcilers, want %d", len(subs), len(want))
+	}
+	for i, sub := range subs {
+		if sub.Name() != want[i] {
+			t.Errorf("subreconcilers[%d] = %s, want %s", i, sub.Name(), want[i])
+		}
+	}
+}
+
+func TestObjectStoreSubreconcilerIsolated(t *testing.T) {
+	ragme := harnessRAGme("isolated")
+	h := newReconcilerHarness(t, ragme)
+	ragme = h.get(t, "isolated")
+
+	var objectStore subreconciler
+	for _, sub := range h.reconciler.subreconcilers() {
+		if sub.Name() == "ObjectStore" {
+			objectStore = sub
+		}
+	}
+	if err := objectStore.Reconcile(h.ctx, ragme); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	minio := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, types.NamespacedName{Namespace: "bench", Name: "isolated-minio"}, minio); err != nil {
+		t.Errorf("MinIO Deployment not created: %v", err)
+	}
+	api := &appsv1.Deployment{}
+	if err := h.client.Get(h.ctx, types.NamespacedName{Namespace: "bench", Name: "isolated-api"}, api); err == nil {
+		t.Error("the api Deployment was created by the object store subreconciler")
+	}
+}
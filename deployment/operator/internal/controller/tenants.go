@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	tenantsConfigKey       = "tenants.json"
+	tenantsConfigMountPath = "/app/config/tenants"
+)
+
+// tenantConfig is the tenant configuration rendered for the api
+type tenantConfig struct {
+	Name             string `json:"name"`
+	DisplayName      string `json:"displayName,omitempty"`
+	Organization     string `json:"organization,omitempty"`
+	Bucket           string `json:"bucket"`
+	MaxDocuments     int64  `json:"maxDocuments,omitempty"`
+	MaxStorageBytes  int64  `json:"maxStorageBytes,omitempty"`
+	MaxQueriesPerDay int64  `json:"maxQueriesPerDay,omitempty"`
+}
+
+// reconcileTenantsConfig renders the tenants of the instance into the
+// ConfigMap mounted by the api, which enforces document and query quotas
+func (r *RAGmeReconciler) reconcileTenantsConfig(ctx context.Context, ragme *ragmev1.RAGme) error {
+	tenants := &ragmev1.RAGmeTenantList{}
+	if err := r.List(ctx, tenants, client.InNamespace(ragme.Namespace)); err != nil {
+		return err
+	}
+
+	configs := []tenantConfig{}
+	for i := range tenants.Items {
+		tenant := &tenants.Items[i]
+		if tenant.Spec.InstanceRef != ragme.Name || !tenant.DeletionTimestamp.IsZero() {
+			continue
+		}
+		config := tenantConfig{
+			Name:             tenant.Name,
+			DisplayName:      tenant.Spec.DisplayName,
+			Organization:     tenant.Spec.Organization,
+			Bucket:           tenantBucket(tenant),
+			MaxDocuments:     tenant.Spec.Quotas.MaxDocuments,
+			MaxQueriesPerDay: tenant.Spec.Quotas.MaxQueriesPerDay,
+		}
+		if tenant.Spec.Quotas.MaxStorage != "" {
+			if quantity, err := resource.ParseQuantity(tenant.Spec.Quotas.MaxStorage); err == nil {
+				config.MaxStorageBytes = quantity.Value()
+			}
+		}
+		configs = append(configs, config)
+	}
+	sort.Slice(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
+
+	data, err := json.MarshalIndent(configs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-tenants", ragme.Name),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":      "ragme",
+				"instance": ragme.Name,
+			},
+		},
+		Data: map[string]string{tenantsConfigKey: string(data)},
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+
+	if found.Data[tenantsConfigKey] != configMap.Data[tenantsConfigKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
+
+// instanceForTenant maps a RAGmeTenant event to its RAGme instance so the
+// tenants ConfigMap is re-rendered when tenants change
+func (r *RAGmeReconciler) instanceForTenant(ctx context.Context, obj client.Object) []reconcile.Request {
+	tenant, ok := obj.(*ragmev1.RAGmeTenant)
+	if !ok || tenant.Spec.InstanceRef == "" {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: tenant.Spec.InstanceRef, Namespace: tenant.Namespace},
+	}}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	defaultTerminationTimeoutSeconds = 120
+	// terminationPollInterval is how often the pods of a stage are checked
+	terminationPollInterval = 5 * time.Second
+)
+
+// defaultTerminationStages stops the entry points first, so no new work
+// arrives, then lets the agent drain its ingestion before the datastores it
+// writes to go away
+var defaultTerminationStages = []ragmev1.RAGmeTerminationStage{
+	{Components: []string{"frontend"}},
+	{Components: []string{"api", "api-read", "mcp"}},
+	{Components: []string{"agent", "image-processor", "transcriber"}},
+	{Components: []string{"weaviate", "weaviate-shard"}},
+	{Components: []string{"minio"}},
+}
+
+// terminationStages returns the stages of the ordered termination
+func terminationStages(ragme *ragmev1.RAGme) []ragmev1.RAGmeTerminationStage {
+	if len(ragme.Spec.Termination.Stages) > 0 {
+		return ragme.Spec.Termination.Stages
+	}
+	return defaultTerminationStages
+}
+
+// terminationTimeout returns how long to wait for the pods of a stage
+func terminationTimeout(ragme *ragmev1.RAGme, stage ragmev1.RAGmeTerminationStage) time.Duration {
+	seconds := stage.TimeoutSeconds
+	if seconds == 0 {
+		seconds = ragme.Spec.Termination.TimeoutSeconds
+	}
+	if seconds == 0 {
+		seconds = defaultTerminationTimeoutSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// applyTermination gives the pods of a component the timeout of its stage as
+// their termination grace period, so the agent can drain its ingestion instead
+// of being killed after the default 30s
+func applyTermination(ragme *ragmev1.RAGme, component string, podSpec *corev1.PodSpec) {
+	if !ragme.Spec.Termination.Ordered {
+		return
+	}
+	for _, stage := range terminationStages(ragme) {
+		for _, name := range stage.Components {
+			if name == component {
+				seconds := int64(terminationTimeout(ragme, stage).Seconds())
+				podSpec.TerminationGracePeriodSeconds = &seconds
+				return
+			}
+		}
+	}
+}
+
+// reconcileTermination deletes the Deployments of the instance stage by stage,
+// moving on once the pods of a stage are gone or its timeout elapsed. The
+// progress is kept in the status, which the caller persists. It reports
+// whether every stage is done; the remaining objects are left to the garbage
+// collector.
+func (r *RAGmeReconciler) reconcileTermination(ctx context.Context, ragme *ragmev1.RAGme, now time.Time) (bool, error) {
+	logger := log.FromContext(ctx)
+	stages := terminationStages(ragme)
+	status := &ragme.Status.Termination
+	selector := client.MatchingLabels{"app": "ragme", "instance": ragme.Name}
+
+	for int(status.Stage) < len(stages) {
+		stage := stages[status.Stage]
+		components := map[string]bool{}
+		for _, component := range stage.Components {
+			components[component] = true
+		}
+
+		if status.StageStartedAt == nil {
+			deployments := &appsv1.DeploymentList{}
+			if err := r.List(ctx, deployments, client.InNamespace(ragme.Namespace), selector); err != nil {
+				return false, err
+			}
+			for i := range deployments.Items {
+				deployment := &deployments.Items[i]
+				if !components[deployment.Labels["component"]] {
+					continue
+				}
+				if err := r.Delete(ctx, deployment); err != nil && !errors.IsNotFound(err) {
+					return false, err
+				}
+			}
+			logger.Info("Terminating components", "stage", status.Stage, "components", stage.Components)
+			status.StageStartedAt = &metav1.Time{Time: now}
+			return false, nil
+		}
+
+		pods := &corev1.PodList{}
+		if err := r.List(ctx, pods, client.InNamespace(ragme.Namespace), selector); err != nil {
+			return false, err
+		}
+		remaining := 0
+		for _, pod := range pods.Items {
+			if components[pod.Labels["component"]] {
+				remaining++
+			}
+		}
+		if remaining > 0 {
+			if now.Before(status.StageStartedAt.Add(terminationTimeout(ragme, stage))) {
+				return false, nil
+			}
+			logger.Info("Termination stage timed out, continuing", "stage", status.Stage, "components", stage.Components, "pods", remaining)
+		}
+		status.Stage++
+		status.StageStartedAt = nil
+	}
+	return true, nil
+}
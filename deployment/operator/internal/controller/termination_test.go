@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestTerminationStages(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	stages := terminationStages(ragme)
+	if len(stages) != 5 || stages[0].Components[0] != "frontend" || stages[4].Components[0] != "minio" {
+		t.Errorf("default stages = %+v, want frontend first and MinIO last", stages)
+	}
+	if timeout := terminationTimeout(ragme, stages[2]); timeout != 120*time.Second {
+		t.Errorf("default timeout = %s, want 2m", timeout)
+	}
+
+	ragme.Spec.Termination = ragmev1.RAGmeTermination{
+		Ordered:        true,
+		TimeoutSeconds: 30,
+		Stages: []ragmev1.RAGmeTerminationStage{
+			{Components: []string{"api"}},
+			{Components: []string{"agent"}, TimeoutSeconds: 600},
+		},
+	}
+	stages = terminationStages(ragme)
+	if len(stages) != 2 {
+		t.Fatalf("stages = %+v, want the configured stages", stages)
+	}
+	if timeout := terminationTimeout(ragme, stages[0]); timeout != 30*time.Second {
+		t.Errorf("timeout = %s, want the spec timeout", timeout)
+	}
+	if timeout := terminationTimeout(ragme, stages[1]); timeout != 10*time.Minute {
+		t.Errorf("agent timeout = %s, want the stage timeout", timeout)
+	}
+
+	podSpec := &corev1.PodSpec{}
+	applyTermination(ragme, "agent", podSpec)
+	if grace := podSpec.TerminationGracePeriodSeconds; grace == nil || *grace != 600 {
+		t.Errorf("agent grace period = %v, want the stage timeout", grace)
+	}
+	podSpec = &corev1.PodSpec{}
+	applyTermination(ragme, "frontend", podSpec)
+	if podSpec.TerminationGracePeriodSeconds != nil {
+		t.Error("grace period set on a component outside the stages")
+	}
+}
+
+func TestValidateSpecTermination(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Termination.Stages = []ragmev1.RAGmeTerminationStage{{TimeoutSeconds: -1}}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a stage without components and a negative timeout")
+	}
+	ragme.Spec.Termination.Stages[0] = ragmev1.RAGmeTerminationStage{Components: []string{"agent"}, TimeoutSeconds: 300}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
+
+func TestDeletionStuckThreshold(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if threshold := deletionStuckThreshold(ragme); threshold != 15*time.Minute {
+		t.Errorf("default threshold = %s, want 15m", threshold)
+	}
+	ragme.Spec.Termination.StuckThresholdSeconds = 60
+	if threshold := deletionStuckThreshold(ragme); threshold != time.Minute {
+		t.Errorf("threshold = %s, want the spec threshold", threshold)
+	}
+}
+
+func TestTerminatingObject(t *testing.T) {
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{
+		Name:       "test-minio-pvc",
+		Finalizers: []string{"kubernetes.io/pvc-protection"},
+	}}
+	if blocker := terminatingObject("PersistentVolumeClaim", pvc); blocker != "" {
+		t.Errorf("blocker = %q for an object that is not being deleted", blocker)
+	}
+	pvc.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	if blocker := terminatingObject("PersistentVolumeClaim", pvc); blocker != "PersistentVolumeClaim test-minio-pvc (finalizers kubernetes.io/pvc-protection)" {
+		t.Errorf("blocker = %q, want the PVC and its finalizer", blocker)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-agent-0", DeletionTimestamp: &metav1.Time{Time: time.Now()}}}
+	if blocker := terminatingObject("Pod", pod); blocker != "Pod test-agent-0 (terminating)" {
+		t.Errorf("blocker = %q, want the terminating pod", blocker)
+	}
+}
@@ -0,0 +1,258 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	topologyJSONKey = "topology.json"
+	topologyDOTKey  = "topology.dot"
+
+	nodeHealthy    = "Healthy"
+	nodeDegraded   = "Degraded"
+	nodeDown       = "Down"
+	nodeScaledDown = "ScaledDown"
+	nodeUnknown    = "Unknown"
+)
+
+// topologyGraph is the component graph of an instance
+type topologyGraph struct {
+	Instance  string         `json:"instance"`
+	Namespace string         `json:"namespace"`
+	Nodes     []topologyNode `json:"nodes"`
+	Edges     []topologyEdge `json:"edges"`
+}
+
+// topologyNode is a component of the instance, or an outside dependency
+type topologyNode struct {
+	ID string `json:"id"`
+	// Kind is Workload, Entrypoint, Volume or External
+	Kind          string `json:"kind"`
+	Health        string `json:"health"`
+	Replicas      int32  `json:"replicas,omitempty"`
+	ReadyReplicas int32  `json:"readyReplicas,omitempty"`
+}
+
+// topologyEdge is a data flow between two nodes. Its health is the health of
+// the dependency it reaches.
+type topologyEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Flow   string `json:"flow"`
+	Health string `json:"health"`
+}
+
+// topologyFlows are the data flows between the components, kept when both
+// ends are part of the instance
+var topologyFlows = []topologyEdge{
+	{From: "ingress", To: "oauth2-proxy", Flow: "auth"},
+	{From: "ingress", To: "frontend", Flow: "http"},
+	{From: "ingress", To: "api", Flow: "http"},
+	{From: "ingress", To: "api-read", Flow: "http"},
+	{From: "frontend", To: "api", Flow: "http"},
+	{From: "frontend", To: "api-read", Flow: "queries"},
+	{From: "agent", To: "watch-directory", Flow: "files"},
+	{From: "agent", To: "mcp", Flow: "documents"},
+	{From: "image-processor", To: "watch-directory", Flow: "files"},
+	{From: "image-processor", To: "mcp", Flow: "documents"},
+	{From: "image-processor", To: "egress-proxy", Flow: "llm"},
+	{From: "transcriber", To: "watch-directory", Flow: "files"},
+	{From: "transcriber", To: "mcp", Flow: "documents"},
+	{From: "mcp", To: "api", Flow: "documents"},
+	{From: "api", To: "weaviate", Flow: "vectors"},
+	{From: "api", To: "weaviate-shard", Flow: "vectors"},
+	{From: "api", To: "vectordb", Flow: "vectors"},
+	{From: "api-read", To: "weaviate", Flow: "vectors"},
+	{From: "api-read", To: "weaviate-shard", Flow: "vectors"},
+	{From: "api-read", To: "vectordb", Flow: "vectors"},
+	{From: "api-read", To: "query-cache", Flow: "cache"},
+	{From: "api", To: "minio", Flow: "objects"},
+	{From: "api", To: "redis", Flow: "sessions"},
+	{From: "api", To: "query-cache", Flow: "cache"},
+	{From: "api", To: "embeddings-cache", Flow: "cache"},
+	{From: "api", To: "egress-proxy", Flow: "llm"},
+	{From: "agent", To: "egress-proxy", Flow: "llm"},
+	{From: "egress-proxy", To: "llm", Flow: "llm"},
+	{From: "metrics-agent", To: "api", Flow: "metrics"},
+	{From: "metrics-agent", To: "mcp", Flow: "metrics"},
+	{From: "metrics-agent", To: "frontend", Flow: "metrics"},
+	{From: "api", To: "otel-collector", Flow: "traces"},
+	{From: "mcp", To: "otel-collector", Flow: "traces"},
+	{From: "agent", To: "otel-collector", Flow: "traces"},
+	{From: "frontend", To: "otel-collector", Flow: "traces"},
+	{From: "otel-collector", To: "api", Flow: "metrics"},
+	{From: "otel-collector", To: "mcp", Flow: "metrics"},
+	{From: "otel-collector", To: "frontend", Flow: "metrics"},
+	{From: "profiler", To: "api", Flow: "profiles"},
+	{From: "profiler", To: "mcp", Flow: "profiles"},
+	{From: "profiler", To: "agent", Flow: "profiles"},
+}
+
+// buildTopology returns the component graph of an instance from its spec and
+// the Deployments generated for it
+func buildTopology(ragme *ragmev1.RAGme, deployments []appsv1.Deployment) *topologyGraph {
+	nodes := map[string]*topologyNode{}
+	for i := range deployments {
+		deployment := &deployments[i]
+		id := deployment.Labels["component"]
+		if id == "" {
+			continue
+		}
+		node := nodes[id]
+		if node == nil {
+			node = &topologyNode{ID: id, Kind: "Workload"}
+			nodes[id] = node
+		}
+		// The shards of a component are aggregated into one node
+		if deployment.Spec.Replicas != nil {
+			node.Replicas += *deployment.Spec.Replicas
+		} else {
+			node.Replicas++
+		}
+		node.ReadyReplicas += deployment.Status.ReadyReplicas
+	}
+	for _, node := range nodes {
+		switch {
+		case node.Replicas == 0:
+			node.Health = nodeScaledDown
+		case node.ReadyReplicas >= node.Replicas:
+			node.Health = nodeHealthy
+		case node.ReadyReplicas > 0:
+			node.Health = nodeDegraded
+		default:
+			node.Health = nodeDown
+		}
+	}
+
+	if ingress := ragme.Spec.ExternalAccess.Ingress; ingress.Enabled {
+		nodes["ingress"] = &topologyNode{ID: "ingress", Kind: "Entrypoint", Health: nodeUnknown}
+	}
+	nodes["watch-directory"] = &topologyNode{ID: "watch-directory", Kind: "Volume", Health: nodeUnknown}
+	nodes["llm"] = &topologyNode{ID: "llm", Kind: "External", Health: nodeUnknown}
+	if nodes["weaviate"] == nil {
+		nodes["vectordb"] = &topologyNode{ID: "vectordb", Kind: "External", Health: nodeUnknown}
+	}
+
+	flows := topologyFlows
+	// Without a shared egress proxy the services reach the providers directly
+	if nodes["egress-proxy"] == nil {
+		flows = append(flows[:len(flows):len(flows)],
+			topologyEdge{From: "api", To: "llm", Flow: "llm"},
+			topologyEdge{From: "agent", To: "llm", Flow: "llm"},
+			topologyEdge{From: "image-processor", To: "llm", Flow: "llm"})
+	}
+
+	graph := &topologyGraph{Instance: ragme.Name, Namespace: ragme.Namespace, Nodes: []topologyNode{}, Edges: []topologyEdge{}}
+	for _, node := range nodes {
+		graph.Nodes = append(graph.Nodes, *node)
+	}
+	sort.Slice(graph.Nodes, func(i, j int) bool { return graph.Nodes[i].ID < graph.Nodes[j].ID })
+	for _, flow := range flows {
+		if nodes[flow.From] == nil || nodes[flow.To] == nil {
+			continue
+		}
+		flow.Health = nodes[flow.To].Health
+		graph.Edges = append(graph.Edges, flow)
+	}
+	return graph
+}
+
+// renderTopologyDOT renders the graph in the Graphviz DOT language, colouring
+// the nodes and edges by health
+func renderTopologyDOT(graph *topologyGraph) string {
+	colors := map[string]string{
+		nodeHealthy:    "green",
+		nodeDegraded:   "orange",
+		nodeDown:       "red",
+		nodeScaledDown: "grey",
+		nodeUnknown:    "black",
+	}
+	shapes := map[string]string{
+		"Workload":   "box",
+		"Entrypoint": "invhouse",
+		"Volume":     "cylinder",
+		"External":   "ellipse",
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %q {\n", graph.Namespace+"/"+graph.Instance)
+	b.WriteString("  rankdir=LR;\n")
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&b, "  %q [shape=%s, color=%s, tooltip=%q];\n", node.ID, shapes[node.Kind], colors[node.Health], node.Health)
+	}
+	for _, edge := range graph.Edges {
+		fmt.Fprintf(&b, "  %q -> %q [label=%q, color=%s];\n", edge.From, edge.To, edge.Flow, colors[edge.Health])
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// topologyConfigMapName returns the name of the ConfigMap publishing the graph
+func topologyConfigMapName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-topology", ragme.Name)
+}
+
+// reconcileTopology publishes the component graph in the topology ConfigMap,
+// deleting it when disabled
+func (r *RAGmeReconciler) reconcileTopology(ctx context.Context, ragme *ragmev1.RAGme) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      topologyConfigMapName(ragme),
+			Namespace: ragme.Namespace,
+			Labels: map[string]string{
+				"app":               "ragme",
+				"instance":          ragme.Name,
+				"ragme.io/topology": "true",
+			},
+		},
+	}
+	if !ragme.Spec.StatusEndpoint.TopologyConfigMap {
+		if err := r.Delete(ctx, configMap); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(ragme.Namespace), client.MatchingLabels{"app": "ragme", "instance": ragme.Name}); err != nil {
+		return err
+	}
+	graph := buildTopology(ragme, deployments.Items)
+	data, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return err
+	}
+	configMap.Data = map[string]string{
+		topologyJSONKey: string(data),
+		topologyDOTKey:  renderTopologyDOT(graph),
+	}
+	if err := r.setOwner(ragme, configMap); err != nil {
+		return err
+	}
+
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	if found.Data[topologyJSONKey] != configMap.Data[topologyJSONKey] || found.Data[topologyDOTKey] != configMap.Data[topologyDOTKey] {
+		found.Data = configMap.Data
+		return r.Update(ctx, found)
+	}
+	return nil
+}
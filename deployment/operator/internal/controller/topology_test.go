@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func topologyDeployment(name, component string, replicas, ready int32) appsv1.Deployment {
+	return appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"component": component}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: ready},
+	}
+}
+
+func TestBuildTopology(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.ExternalAccess.Ingress.Enabled = true
+	deployments := []appsv1.Deployment{
+		topologyDeployment("test-api", "api", 2, 1),
+		topologyDeployment("test-frontend", "frontend", 1, 1),
+		topologyDeployment("test-agent", "agent", 1, 0),
+		topologyDeployment("test-mcp", "mcp", 1, 1),
+		topologyDeployment("test-weaviate-shard-0", "weaviate-shard", 1, 1),
+		topologyDeployment("test-weaviate-shard-1", "weaviate-shard", 1, 0),
+	}
+
+	graph := buildTopology(ragme, deployments)
+	nodes := map[string]topologyNode{}
+	for _, node := range graph.Nodes {
+		nodes[node.ID] = node
+	}
+	if nodes["api"].Health != nodeDegraded || nodes["frontend"].Health != nodeHealthy || nodes["agent"].Health != nodeDown {
+		t.Errorf("nodes = %+v, want the health from the ready replicas", graph.Nodes)
+	}
+	if shards := nodes["weaviate-shard"]; shards.Replicas != 2 || shards.ReadyReplicas != 1 {
+		t.Errorf("weaviate-shard = %+v, want the shards aggregated", shards)
+	}
+	if nodes["vectordb"].Kind != "External" || nodes["ingress"].Kind != "Entrypoint" {
+		t.Errorf("nodes = %+v, want the external vector database and the Ingress", graph.Nodes)
+	}
+
+	edges := map[string]topologyEdge{}
+	for _, edge := range graph.Edges {
+		edges[edge.From+"->"+edge.To] = edge
+	}
+	if edge := edges["frontend->api"]; edge.Health != nodeDegraded {
+		t.Errorf("frontend->api = %+v, want the health of the api", edge)
+	}
+	for _, want := range []string{"ingress->frontend", "agent->mcp", "api->weaviate-shard", "api->llm"} {
+		if _, ok := edges[want]; !ok {
+			t.Errorf("edge %s missing from %+v", want, graph.Edges)
+		}
+	}
+	for _, unwanted := range []string{"ingress->oauth2-proxy", "api->minio", "api->egress-proxy"} {
+		if _, ok := edges[unwanted]; ok {
+			t.Errorf("edge %s to a component that is not deployed", unwanted)
+		}
+	}
+
+	// The providers are reached through the egress proxy when deployed
+	graph = buildTopology(ragme, append(deployments, topologyDeployment("test-egress-proxy", "egress-proxy", 0, 0)))
+	for _, edge := range graph.Edges {
+		if edge.From == "api" && edge.To == "llm" {
+			t.Error("api->llm kept with the egress proxy deployed")
+		}
+		if edge.To == "egress-proxy" && edge.Health != nodeScaledDown {
+			t.Errorf("edge %+v, want the scaled down egress proxy", edge)
+		}
+	}
+}
+
+func TestRenderTopologyDOT(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	dot := renderTopologyDOT(buildTopology(ragme, []appsv1.Deployment{
+		topologyDeployment("test-api", "api", 1, 1),
+	}))
+
+	for _, want := range []string{
+		`digraph "ragme/test" {`,
+		`"api" [shape=box, color=green, tooltip="Healthy"];`,
+		`"api" -> "vectordb" [label="vectors", color=black];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
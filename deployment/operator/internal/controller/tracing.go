@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	tracingProtocolGRPC = "grpc"
+	tracingProtocolHTTP = "http/protobuf"
+
+	defaultTracingSampleRate = "0.1"
+)
+
+// tracedComponents lists the components exporting spans
+var tracedComponents = []string{"api", "mcp", "agent", "frontend"}
+
+// defaultTracingPropagators keep the W3C trace context and baggage across services
+var defaultTracingPropagators = []string{"tracecontext", "baggage"}
+
+// supportedTracingPropagators are the propagators of the OpenTelemetry SDKs
+var supportedTracingPropagators = map[string]bool{
+	"tracecontext": true,
+	"baggage":      true,
+	"b3":           true,
+	"b3multi":      true,
+	"jaeger":       true,
+}
+
+// tracingResourceAttributes returns the resource attributes of the spans of a
+// component as sorted key=value pairs. The configured attributes override the
+// attributes set by the operator.
+func tracingResourceAttributes(ragme *ragmev1.RAGme) string {
+	attributes := map[string]string{
+		"service.namespace": ragme.Namespace,
+		"ragme.instance":    ragme.Name,
+	}
+	if environment := ragme.Spec.Metadata.Environment; environment != "" {
+		attributes["deployment.environment"] = environment
+	}
+	attributes = mergeStringMaps(attributes, ragme.Spec.Tracing.ResourceAttributes)
+
+	pairs := make([]string, 0, len(attributes))
+	for key, value := range attributes {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// applyTracing configures the OpenTelemetry SDK of a component through the
+// standard OTEL_* variables. The services propagate the trace context of the
+// incoming requests, so a chat request is traced across the frontend, the api
+// and the mcp, with the vector database calls as client spans.
+func applyTracing(ragme *ragmev1.RAGme, serviceName string, podSpec *corev1.PodSpec) {
+	tracing := ragme.Spec.Tracing
+	if !tracing.Enabled || !consumesTracing(serviceName) {
+		return
+	}
+
+	protocol := tracing.Protocol
+	if protocol == "" {
+		protocol = tracingProtocolGRPC
+	}
+	sampleRate := tracing.SampleRate
+	if sampleRate == "" {
+		sampleRate = defaultTracingSampleRate
+	}
+	propagators := tracing.Propagators
+	if len(propagators) == 0 {
+		propagators = defaultTracingPropagators
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "OTEL_SERVICE_NAME", Value: "ragme-" + serviceName},
+		{Name: "OTEL_RESOURCE_ATTRIBUTES", Value: tracingResourceAttributes(ragme)},
+		{Name: "OTEL_TRACES_EXPORTER", Value: "otlp"},
+		{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: tracingEndpoint(ragme, protocol)},
+		{Name: "OTEL_EXPORTER_OTLP_PROTOCOL", Value: protocol},
+		{Name: "OTEL_TRACES_SAMPLER", Value: "parentbased_traceidratio"},
+		{Name: "OTEL_TRACES_SAMPLER_ARG", Value: sampleRate},
+		{Name: "OTEL_PROPAGATORS", Value: strings.Join(propagators, ",")},
+	}
+	if ref := tracing.HeadersSecretRef; ref != nil {
+		env = append(env, corev1.EnvVar{
+			Name:      "OTEL_EXPORTER_OTLP_HEADERS",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: ref.DeepCopy()},
+		})
+	}
+	podSpec.Containers[0].Env = append(podSpec.Containers[0].Env, env...)
+}
+
+// tracingEndpoint returns the OTLP endpoint of the services, defaulting to
+// the in-cluster collector
+func tracingEndpoint(ragme *ragmev1.RAGme, protocol string) string {
+	if endpoint := ragme.Spec.Tracing.Endpoint; endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/")
+	}
+	if ragme.Spec.Observability.Collector.Enabled {
+		return collectorEndpoint(ragme, protocol)
+	}
+	return ""
+}
+
+// consumesTracing reports whether the component exports spans
+func consumesTracing(component string) bool {
+	for _, c := range tracedComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
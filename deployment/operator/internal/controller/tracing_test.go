@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestApplyTracing(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Metadata.Environment = "prod"
+	ragme.Spec.Tracing = ragmev1.RAGmeTracing{
+		Enabled:            true,
+		Endpoint:           "http://otel-collector.observability:4317/",
+		ResourceAttributes: map[string]string{"team": "search"},
+		HeadersSecretRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "tracing"}, Key: "otlp-headers",
+		},
+	}
+
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "api"}}}
+	applyTracing(ragme, "api", podSpec)
+	env := podSpec.Containers[0].Env
+	for name, want := range map[string]string{
+		"OTEL_SERVICE_NAME":           "ragme-api",
+		"OTEL_EXPORTER_OTLP_ENDPOINT": "http://otel-collector.observability:4317",
+		"OTEL_EXPORTER_OTLP_PROTOCOL": tracingProtocolGRPC,
+		"OTEL_TRACES_SAMPLER_ARG":     defaultTracingSampleRate,
+		"OTEL_PROPAGATORS":            "tracecontext,baggage",
+		"OTEL_RESOURCE_ATTRIBUTES":    "deployment.environment=prod,ragme.instance=test,service.namespace=ragme,team=search",
+	} {
+		if got := envValue(env, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if env[len(env)-1].Name != "OTEL_EXPORTER_OTLP_HEADERS" || env[len(env)-1].ValueFrom.SecretKeyRef.Key != "otlp-headers" {
+		t.Errorf("env = %+v, want the OTLP headers from the Secret", env[len(env)-1])
+	}
+
+	podSpec = &corev1.PodSpec{Containers: []corev1.Container{{Name: "minio"}}}
+	applyTracing(ragme, "minio", podSpec)
+	if len(podSpec.Containers[0].Env) != 0 {
+		t.Errorf("env = %+v, want none for a component without tracing", podSpec.Containers[0].Env)
+	}
+}
+
+func TestValidateSpecTracing(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Tracing = ragmev1.RAGmeTracing{
+		Enabled:            true,
+		Endpoint:           "otel-collector:4317",
+		Protocol:           "thrift",
+		SampleRate:         "10",
+		Propagators:        []string{"xray"},
+		ResourceAttributes: map[string]string{"team": "a,b"},
+	}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an invalid endpoint, protocol, sample rate, propagator and attribute")
+	}
+	ragme.Spec.Tracing = ragmev1.RAGmeTracing{Enabled: true, Endpoint: "https://otlp.example.com", Protocol: tracingProtocolHTTP, SampleRate: "1"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// instanceExpiry returns when the instance expires because of its TTL, or the
+// zero time when it has none
+func instanceExpiry(ragme *ragmev1.RAGme) time.Time {
+	if ragme.Spec.TTL == "" {
+		return time.Time{}
+	}
+	ttl, err := time.ParseDuration(ragme.Spec.TTL)
+	if err != nil {
+		return time.Time{}
+	}
+	return ragme.CreationTimestamp.Add(ttl)
+}
+
+// reconcileTTL records the expiry of the instance in status and deletes it
+// once expired. It reports whether the instance was deleted.
+func (r *RAGmeReconciler) reconcileTTL(ctx context.Context, ragme *ragmev1.RAGme, now time.Time) (bool, error) {
+	expiry := instanceExpiry(ragme)
+	if expiry.IsZero() {
+		ragme.Status.ExpiresAt = nil
+		return false, nil
+	}
+	ragme.Status.ExpiresAt = &metav1.Time{Time: expiry}
+	if now.Before(expiry) {
+		return false, nil
+	}
+
+	log.FromContext(ctx).Info("Deleting expired RAGme", "ttl", ragme.Spec.TTL, "expiredAt", expiry)
+	if err := r.Delete(ctx, ragme, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return true, nil
+}
+
+// ttlResult shortens the requeue of result so the instance is reconciled when it expires
+func ttlResult(ragme *ragmev1.RAGme, result ctrl.Result, now time.Time) ctrl.Result {
+	if ragme.Status.ExpiresAt == nil {
+		return result
+	}
+	return requeueBefore(result, ragme.Status.ExpiresAt.Sub(now))
+}
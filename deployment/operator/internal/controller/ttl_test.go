@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestInstanceExpiry(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.Time{Time: created}}}
+
+	if got := instanceExpiry(ragme); !got.IsZero() {
+		t.Errorf("instanceExpiry() = %s, want zero without a TTL", got)
+	}
+
+	ragme.Spec.TTL = "72h"
+	if got, want := instanceExpiry(ragme), created.Add(72*time.Hour); !got.Equal(want) {
+		t.Errorf("instanceExpiry() = %s, want %s", got, want)
+	}
+}
+
+func TestTTLResult(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	resync := ctrl.Result{RequeueAfter: 5 * time.Minute}
+	ragme := &ragmev1.RAGme{}
+
+	if got := ttlResult(ragme, resync, now); got != resync {
+		t.Errorf("ttlResult() = %v, want %v without a TTL", got, resync)
+	}
+
+	ragme.Status.ExpiresAt = &metav1.Time{Time: now.Add(time.Minute)}
+	if got := ttlResult(ragme, resync, now); got.RequeueAfter != time.Minute {
+		t.Errorf("ttlResult() requeues after %s, want 1m", got.RequeueAfter)
+	}
+
+	ragme.Status.ExpiresAt = &metav1.Time{Time: now.Add(time.Hour)}
+	if got := ttlResult(ragme, resync, now); got != resync {
+		t.Errorf("ttlResult() = %v, want %v when the resync comes first", got, resync)
+	}
+}
+
+func TestWithoutOwner(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{UID: types.UID("instance")}}
+	refs := []metav1.OwnerReference{
+		{Kind: "RAGme", UID: types.UID("instance")},
+		{Kind: "Backup", UID: types.UID("other")},
+	}
+
+	got := withoutOwner(refs, ragme)
+	if len(got) != 1 || got[0].UID != "other" {
+		t.Errorf("withoutOwner() = %v, want only the other owner", got)
+	}
+}
@@ -0,0 +1,1055 @@
+package controller
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// validateSpec checks the RAGme spec for configurations the operator cannot apply
+func validateSpec(ragme *ragmev1.RAGme) error {
+	var errs []error
+
+	for _, replicas := range []struct {
+		path  string
+		count int32
+	}{
+		{"replicas.api", ragme.Spec.Replicas.API},
+		{"replicas.mcp", ragme.Spec.Replicas.MCP},
+		{"replicas.agent", ragme.Spec.Replicas.Agent},
+		{"replicas.frontend", ragme.Spec.Replicas.Frontend},
+		{"readReplicas.replicas", ragme.Spec.ReadReplicas.Replicas},
+	} {
+		if replicas.count < 0 {
+			errs = append(errs, fmt.Errorf("%s: %d must not be negative", replicas.path, replicas.count))
+		}
+	}
+	// Without coordination, several agents would ingest every file of the watch directory
+	if ragme.Spec.Replicas.Agent > 1 && !ragme.Spec.Agent.Coordination.Enabled {
+		errs = append(errs, fmt.Errorf("replicas.agent: more than 1 agent requires agent.coordination.enabled"))
+	}
+	if ingress := ragme.Spec.ExternalAccess.Ingress; ingress.Enabled && ingress.Host == "" {
+		errs = append(errs, fmt.Errorf("externalAccess.ingress.host: required when the ingress is enabled"))
+	}
+
+	for _, component := range managedComponents {
+		names := map[string]bool{component: true}
+		for _, sidecar := range componentSpec(ragme, component).Sidecars {
+			if sidecar.Name == "" {
+				errs = append(errs, fmt.Errorf("components.%s.sidecars: container name is required", component))
+				continue
+			}
+			if names[sidecar.Name] {
+				errs = append(errs, fmt.Errorf("components.%s.sidecars: container name %q collides with another container", component, sidecar.Name))
+			}
+			names[sidecar.Name] = true
+		}
+
+		tempStorage := componentSpec(ragme, component).TempStorage
+		switch tempStorage.Medium {
+		case "", "Disk", "Memory":
+		default:
+			errs = append(errs, fmt.Errorf("components.%s.tempStorage.medium: unsupported medium %q, use Disk or Memory", component, tempStorage.Medium))
+		}
+		if tempStorage.SizeLimit != "" {
+			if _, err := resource.ParseQuantity(tempStorage.SizeLimit); err != nil {
+				errs = append(errs, fmt.Errorf("components.%s.tempStorage.sizeLimit: invalid quantity %q", component, tempStorage.SizeLimit))
+			}
+		}
+	}
+
+	keys := map[string]bool{}
+	for _, key := range ragme.Spec.Authentication.ServiceAuth.Keys {
+		if key.Name == "" {
+			errs = append(errs, fmt.Errorf("authentication.serviceAuth.keys: key name is required"))
+			continue
+		}
+		if keys[key.Name] {
+			errs = append(errs, fmt.Errorf("authentication.serviceAuth.keys: duplicate key name %q", key.Name))
+		}
+		keys[key.Name] = true
+		if key.RotationPeriod != "" {
+			if d, err := time.ParseDuration(key.RotationPeriod); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("authentication.serviceAuth.keys.%s: invalid rotationPeriod %q", key.Name, key.RotationPeriod))
+			}
+		}
+	}
+
+	if ldap := ragme.Spec.Authentication.LDAP; ldap.Enabled {
+		if u, err := url.Parse(ldap.URL); err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("authentication.ldap.url: %q must be an ldap:// or ldaps:// URL", ldap.URL))
+		}
+		if ldap.UserSearch.BaseDN == "" {
+			errs = append(errs, fmt.Errorf("authentication.ldap.userSearch.baseDN is required"))
+		}
+		if ldap.BindDN != "" && ldap.BindPasswordSecretRef == nil {
+			errs = append(errs, fmt.Errorf("authentication.ldap.bindPasswordSecretRef is required with bindDN"))
+		}
+	}
+
+	if anonymous := ragme.Spec.Authentication.Anonymous; anonymous.Enabled && anonymous.Role != "read-only" {
+		errs = append(errs, fmt.Errorf("authentication.anonymous.role: only read-only is supported, got %q", anonymous.Role))
+	}
+	for _, admin := range ragme.Spec.Authentication.BootstrapAdmins {
+		if !validBootstrapAdmin(admin) {
+			errs = append(errs, fmt.Errorf("authentication.bootstrapAdmins: %q must be an email or <provider>:<subject> with provider google, github or apple", admin))
+		}
+	}
+
+	authorization := ragme.Spec.Authorization
+	if authorization.DefaultRole != "" && !validApplicationRole(authorization.DefaultRole) {
+		errs = append(errs, fmt.Errorf("authorization.defaultRole: must be admin, editor or viewer, got %q", authorization.DefaultRole))
+	}
+	for i, mapping := range authorization.Roles {
+		if !validApplicationRole(mapping.Role) {
+			errs = append(errs, fmt.Errorf("authorization.roles[%d].role: must be admin, editor or viewer, got %q", i, mapping.Role))
+		}
+		if len(mapping.Groups) == 0 && len(mapping.EmailDomains) == 0 {
+			errs = append(errs, fmt.Errorf("authorization.roles[%d]: at least one group or email domain is required", i))
+		}
+		for _, group := range mapping.Groups {
+			if strings.TrimSpace(group) == "" || strings.Contains(group, ",") {
+				errs = append(errs, fmt.Errorf("authorization.roles[%d].groups: %q must be a non-empty group name without commas", i, group))
+			}
+		}
+		for _, domain := range mapping.EmailDomains {
+			if msgs := validation.IsDNS1123Subdomain(strings.ToLower(domain)); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("authorization.roles[%d].emailDomains: %q is not a valid domain", i, domain))
+			}
+		}
+	}
+
+	store := ragme.Spec.Authentication.Session.Store
+	switch store.Type {
+	case "memory":
+		// An explicit in-memory store cannot serve several api replicas
+		if ragme.Spec.Replicas.API > 1 {
+			errs = append(errs, fmt.Errorf("authentication.session.store: memory store cannot be used with %d api replicas, use redis or postgres", ragme.Spec.Replicas.API))
+		}
+	case "postgres":
+		if store.Postgres.URLSecretRef == nil {
+			errs = append(errs, fmt.Errorf("authentication.session.store.postgres.urlSecretRef is required"))
+		}
+	case "", "redis":
+	default:
+		errs = append(errs, fmt.Errorf("authentication.session.store.type: unsupported store %q", store.Type))
+	}
+
+	minio := ragme.Spec.Storage.MinIO
+	keyRefs := minio.SecretKeyRefs
+	if ref := minio.ExistingSecretRef; ref != nil && ref.Name == "" {
+		errs = append(errs, fmt.Errorf("storage.minio.existingSecretRef.name: required"))
+	}
+	if keyRefs.AccessKey != nil || keyRefs.SecretKey != nil {
+		for _, selector := range []struct {
+			path string
+			ref  *corev1.SecretKeySelector
+		}{{"accessKey", keyRefs.AccessKey}, {"secretKey", keyRefs.SecretKey}} {
+			if selector.ref == nil || selector.ref.Name == "" || selector.ref.Key == "" {
+				errs = append(errs, fmt.Errorf("storage.minio.secretKeyRefs.%s: name and key are required", selector.path))
+			}
+		}
+		if minio.ExistingSecretRef != nil {
+			errs = append(errs, fmt.Errorf("storage.minio.secretKeyRefs: mutually exclusive with existingSecretRef"))
+		}
+	}
+	if !generatedMinIORootSecret(ragme) && (minio.AccessKey != "" || minio.SecretKey != "") {
+		errs = append(errs, fmt.Errorf("storage.minio.accessKey, storage.minio.secretKey: mutually exclusive with the referenced root Secret"))
+	}
+
+	credentials := minio.Credentials
+	if refs := credentials.SecretRefs; externalStorageCredentials(ragme) && (refs.API == nil || refs.Agent == nil || refs.Backup == nil) {
+		errs = append(errs, fmt.Errorf("storage.minio.credentials.secretRefs: api, agent and backup must all be set"))
+	}
+	if credentials.RotationPeriod != "" {
+		if period, err := time.ParseDuration(credentials.RotationPeriod); err != nil || period <= 0 {
+			errs = append(errs, fmt.Errorf("storage.minio.credentials.rotationPeriod: invalid duration %q", credentials.RotationPeriod))
+		}
+	}
+
+	// The volume sizes are parsed when the PVCs are built, empty sizes are defaulted
+	for _, size := range []struct{ path, value string }{
+		{"storage.minio.storageSize", ragme.Spec.Storage.MinIO.StorageSize},
+		{"storage.sharedVolume.size", ragme.Spec.Storage.SharedVolume.Size},
+		{"vectorDB.weaviate.storageSize", ragme.Spec.VectorDB.Weaviate.StorageSize},
+	} {
+		if quantity, err := resource.ParseQuantity(size.value); size.value != "" && (err != nil || quantity.Sign() <= 0) {
+			errs = append(errs, fmt.Errorf("%s: %q is not a positive quantity", size.path, size.value))
+		}
+	}
+
+	objectStorage := ragme.Spec.Storage.ObjectStorage
+	switch provider := objectStorageProviderName(ragme); provider {
+	case objectStorageMinIO:
+		if objectStorage.ExpirationDays > 0 && !ragme.Spec.Storage.MinIO.Enabled {
+			errs = append(errs, fmt.Errorf("storage.objectStorage.expirationDays: requires storage.minio.enabled"))
+		}
+	case objectStorageS3, objectStorageGCS, objectStorageAzure:
+		if ragme.Spec.Storage.MinIO.Enabled {
+			errs = append(errs, fmt.Errorf("storage.minio.enabled: must be false with the %s object storage", provider))
+		}
+		if objectStorage.CredentialsSecretRef == nil || objectStorage.CredentialsSecretRef.Name == "" {
+			errs = append(errs, fmt.Errorf("storage.objectStorage.credentialsSecretRef: required with the %s object storage", provider))
+		}
+		if objectStorage.Endpoint != "" && provider != objectStorageS3 {
+			errs = append(errs, fmt.Errorf("storage.objectStorage.endpoint: only used by the s3 object storage"))
+		}
+		if objectStorage.ExpirationDays > 0 && provider != objectStorageS3 {
+			errs = append(errs, fmt.Errorf("storage.objectStorage.expirationDays: configure the lifecycle of %s buckets with the cloud provider", provider))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.objectStorage.provider: unsupported provider %q", provider))
+	}
+	if objectStorage.Endpoint != "" {
+		if u, err := url.Parse(objectStorage.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("storage.objectStorage.endpoint: %q must be an http(s) URL", objectStorage.Endpoint))
+		}
+	}
+	if objectStorage.ExpirationDays < 0 {
+		errs = append(errs, fmt.Errorf("storage.objectStorage.expirationDays: %d must not be negative", objectStorage.ExpirationDays))
+	}
+
+	if version := ragme.Spec.VectorDB.Weaviate.Version; version != "" {
+		if _, err := parseWeaviateVersion(version); err != nil {
+			errs = append(errs, fmt.Errorf("vectorDB.weaviate.version: %w", err))
+		}
+	}
+
+	sharding := ragme.Spec.VectorDB.Sharding
+	if sharding.Shards < 0 {
+		errs = append(errs, fmt.Errorf("vectorDB.sharding.shards: %d must not be negative", sharding.Shards))
+	}
+	switch sharding.RoutingKey {
+	case "", "document", "collection", "tenant":
+	default:
+		errs = append(errs, fmt.Errorf("vectorDB.sharding.routingKey: unsupported routing key %q", sharding.RoutingKey))
+	}
+
+	if monitoring := ragme.Spec.Storage.SharedVolume.Monitoring; monitoring.Enabled {
+		if monitoring.ThresholdPercent < 1 || monitoring.ThresholdPercent > 100 {
+			errs = append(errs, fmt.Errorf("storage.sharedVolume.monitoring.thresholdPercent: %d is not between 1 and 100", monitoring.ThresholdPercent))
+		}
+		if monitoring.ExpandStepPercent < 0 {
+			errs = append(errs, fmt.Errorf("storage.sharedVolume.monitoring.expandStepPercent: %d must be positive", monitoring.ExpandStepPercent))
+		}
+		if monitoring.MaxSize != "" {
+			if _, err := resource.ParseQuantity(monitoring.MaxSize); err != nil {
+				errs = append(errs, fmt.Errorf("storage.sharedVolume.monitoring.maxSize: invalid quantity %q", monitoring.MaxSize))
+			}
+		}
+	}
+
+	assets := ragme.Spec.Frontend.Assets
+	if assets.BaseURL != "" {
+		if u, err := url.Parse(assets.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("frontend.assets.baseURL: %q must be an http:// or https:// URL", assets.BaseURL))
+		}
+	}
+	for _, encoding := range assets.Compression {
+		if encoding != "gzip" && encoding != "brotli" {
+			errs = append(errs, fmt.Errorf("frontend.assets.compression: unsupported encoding %q", encoding))
+		}
+	}
+
+	streaming := ragme.Spec.Frontend.Streaming
+	if streaming.IdleTimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("frontend.streaming.idleTimeoutSeconds: %d must not be negative", streaming.IdleTimeoutSeconds))
+	}
+	if streaming.ProxyBufferSize != "" && !proxyBufferSizePattern.MatchString(streaming.ProxyBufferSize) {
+		errs = append(errs, fmt.Errorf("frontend.streaming.proxyBufferSize: invalid size %q", streaming.ProxyBufferSize))
+	}
+	if streaming.MaxMessageSize != "" {
+		if _, err := resource.ParseQuantity(streaming.MaxMessageSize); err != nil {
+			errs = append(errs, fmt.Errorf("frontend.streaming.maxMessageSize: invalid quantity %q", streaming.MaxMessageSize))
+		}
+	}
+
+	if notice := ragme.Spec.Frontend.Notice; notice != nil {
+		if strings.TrimSpace(notice.Message) == "" {
+			errs = append(errs, fmt.Errorf("frontend.notice.message: required"))
+		}
+		switch notice.Severity {
+		case "", noticeSeverityInfo, noticeSeverityWarning, noticeSeverityCritical:
+		default:
+			errs = append(errs, fmt.Errorf("frontend.notice.severity: unsupported severity %q, use %s, %s or %s",
+				notice.Severity, noticeSeverityInfo, noticeSeverityWarning, noticeSeverityCritical))
+		}
+		if schedule := notice.Schedule; schedule != nil && schedule.Start != nil && schedule.End != nil && !schedule.End.After(schedule.Start.Time) {
+			errs = append(errs, fmt.Errorf("frontend.notice.schedule.end: must be after the start"))
+		}
+	}
+
+	for field, value := range map[string]string{
+		"costCenter":  ragme.Spec.Metadata.CostCenter,
+		"team":        ragme.Spec.Metadata.Team,
+		"environment": ragme.Spec.Metadata.Environment,
+	} {
+		if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("metadata.%s: %q is not a valid label value: %s", field, value, strings.Join(msgs, "; ")))
+		}
+	}
+	if ragme.Spec.Metadata.Catalog.Enabled && catalogOwner(ragme) == "" {
+		errs = append(errs, fmt.Errorf("metadata.catalog.owner: required when metadata.team is not set"))
+	}
+
+	agent := ragme.Spec.Agent
+	if agent.Concurrency < 0 {
+		errs = append(errs, fmt.Errorf("agent.concurrency: %d must not be negative", agent.Concurrency))
+	}
+	if agent.BatchSize < 0 {
+		errs = append(errs, fmt.Errorf("agent.batchSize: %d must not be negative", agent.BatchSize))
+	}
+	if agent.PollInterval != "" {
+		interval, err := time.ParseDuration(agent.PollInterval)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agent.pollInterval: invalid duration %q", agent.PollInterval))
+		} else if interval < minAgentPollInterval {
+			errs = append(errs, fmt.Errorf("agent.pollInterval: %s is below %s", interval, minAgentPollInterval))
+		}
+	}
+	for _, fileType := range normalizeFileTypes(agent.FileTypes) {
+		if fileType == "" || strings.ContainsAny(fileType, "/,. ") {
+			errs = append(errs, fmt.Errorf("agent.fileTypes: %q is not a file extension", fileType))
+		}
+	}
+	for _, pattern := range agent.ExcludePatterns {
+		if _, err := filepath.Match(pattern, ""); err != nil || strings.Contains(pattern, ",") {
+			errs = append(errs, fmt.Errorf("agent.excludePatterns: invalid glob pattern %q", pattern))
+		}
+	}
+
+	backpressure := agent.Backpressure
+	if backpressure.MaxQueuedFiles < 0 {
+		errs = append(errs, fmt.Errorf("agent.backpressure.maxQueuedFiles: %d must not be negative", backpressure.MaxQueuedFiles))
+	}
+	if backpressure.MaxFileSize != "" {
+		if size, err := resource.ParseQuantity(backpressure.MaxFileSize); err != nil || size.Sign() <= 0 {
+			errs = append(errs, fmt.Errorf("agent.backpressure.maxFileSize: %q must be a positive size such as 500Mi", backpressure.MaxFileSize))
+		}
+	}
+	switch backpressure.Behavior {
+	case "", backpressureReject, backpressurePark:
+	default:
+		errs = append(errs, fmt.Errorf("agent.backpressure.behavior: unsupported behavior %q, use %s or %s", backpressure.Behavior, backpressureReject, backpressurePark))
+	}
+
+	scanning := ragme.Spec.Security.Scanning
+	switch scanning.Engine {
+	case "", scanEngineClamAV:
+	case scanEngineWebhook:
+		if u, err := url.Parse(scanning.WebhookURL); scanning.Enabled && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+			errs = append(errs, fmt.Errorf("security.scanning.webhookURL: %q must be an http(s) URL with the webhook engine", scanning.WebhookURL))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("security.scanning.engine: unsupported engine %q, use %s or %s", scanning.Engine, scanEngineClamAV, scanEngineWebhook))
+	}
+
+	pii := ragme.Spec.Processing.PIIRedaction
+	switch pii.Action {
+	case "", piiActionMask, piiActionDrop:
+	default:
+		errs = append(errs, fmt.Errorf("processing.piiRedaction.action: unsupported action %q, use %s or %s", pii.Action, piiActionMask, piiActionDrop))
+	}
+	for _, entityType := range pii.EntityTypes {
+		if !piiEntityTypePattern.MatchString(entityType) {
+			errs = append(errs, fmt.Errorf("processing.piiRedaction.entityTypes: %q must be an upper-case entity type such as EMAIL", entityType))
+		}
+	}
+	if pii.Service.URL != "" {
+		if u, err := url.Parse(pii.Service.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("processing.piiRedaction.service.url: %q must be an http(s) URL", pii.Service.URL))
+		}
+	} else if pii.Service.TokenSecretRef != nil {
+		errs = append(errs, fmt.Errorf("processing.piiRedaction.service.tokenSecretRef: requires service.url"))
+	}
+	blocked := map[string]bool{}
+	for _, pattern := range ragme.Spec.Processing.BlockedTypes {
+		if !validFileTypePattern(pattern) {
+			errs = append(errs, fmt.Errorf("processing.blockedTypes: %q is not a file extension, MIME type or group such as @executables", pattern))
+		}
+		blocked[normalizeFileTypePattern(pattern)] = true
+	}
+	if ocr := ragme.Spec.Processing.OCR; ocr.Enabled {
+		for _, language := range ocr.Languages {
+			if !ocrLanguagePattern.MatchString(language) {
+				errs = append(errs, fmt.Errorf("processing.ocr.languages: %q is not a Tesseract language code such as eng or chi_sim", language))
+			}
+		}
+		if ocr.Locale != "" && !localePattern.MatchString(ocr.Locale) {
+			errs = append(errs, fmt.Errorf("processing.ocr.locale: %q is not a BCP 47 locale such as de-CH", ocr.Locale))
+		}
+		switch ocr.DateOrder {
+		case "", "DMY", "MDY", "YMD":
+		default:
+			errs = append(errs, fmt.Errorf("processing.ocr.dateOrder: unsupported order %q, use DMY, MDY or YMD", ocr.DateOrder))
+		}
+		switch data := ocr.LanguageData; data.Source {
+		case "", ocrSourceDownload:
+			if u, err := url.Parse(data.URL); data.URL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+				errs = append(errs, fmt.Errorf("processing.ocr.languageData.url: %q must be an http(s) URL", data.URL))
+			}
+		case ocrSourceImage:
+			if data.Image == "" {
+				errs = append(errs, fmt.Errorf("processing.ocr.languageData.image: required with the %s source", ocrSourceImage))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("processing.ocr.languageData.source: unsupported source %q, use %s or %s", data.Source, ocrSourceDownload, ocrSourceImage))
+		}
+	}
+	if images := ragme.Spec.Processing.Images; images.Enabled {
+		for _, fileType := range normalizeFileTypes(images.FileTypes) {
+			if !fileExtensionPattern.MatchString(fileType) {
+				errs = append(errs, fmt.Errorf("processing.images.fileTypes: %q is not a file extension", fileType))
+			}
+		}
+		if images.GPU < 0 {
+			errs = append(errs, fmt.Errorf("processing.images.gpu: %d must not be negative", images.GPU))
+		}
+		if images.GPUResource != "" && len(validation.IsQualifiedName(images.GPUResource)) > 0 {
+			errs = append(errs, fmt.Errorf("processing.images.gpuResource: %q is not a resource name such as nvidia.com/gpu", images.GPUResource))
+		}
+	}
+	if media := ragme.Spec.Processing.Media; media.Enabled {
+		switch media.Model {
+		case "", "tiny", "base", "small", "medium", "large-v3":
+		default:
+			errs = append(errs, fmt.Errorf("processing.media.model: unsupported Whisper model %q, use tiny, base, small, medium or large-v3", media.Model))
+		}
+		if media.Language != "" && !whisperLanguagePattern.MatchString(media.Language) {
+			errs = append(errs, fmt.Errorf("processing.media.language: %q is not an ISO 639-1 code such as en", media.Language))
+		}
+		if media.Mode != "" && media.Mode != mediaModeDeployment && media.Mode != mediaModeJob {
+			errs = append(errs, fmt.Errorf("processing.media.mode: unsupported mode %q, use %s or %s", media.Mode, mediaModeDeployment, mediaModeJob))
+		}
+		imageTypes := map[string]bool{}
+		if ragme.Spec.Processing.Images.Enabled {
+			for _, fileType := range imageFileTypes(ragme) {
+				imageTypes[fileType] = true
+			}
+		}
+		for _, fileType := range normalizeFileTypes(media.FileTypes) {
+			if !fileExtensionPattern.MatchString(fileType) {
+				errs = append(errs, fmt.Errorf("processing.media.fileTypes: %q is not a file extension", fileType))
+			}
+		}
+		for _, fileType := range mediaFileTypes(ragme) {
+			if imageTypes[fileType] {
+				errs = append(errs, fmt.Errorf("processing.media.fileTypes: %q is also routed to the image workers", fileType))
+			}
+		}
+		if media.Workers < 0 {
+			errs = append(errs, fmt.Errorf("processing.media.workers: %d must not be negative", media.Workers))
+		}
+		if media.MaxParallelJobs < 0 {
+			errs = append(errs, fmt.Errorf("processing.media.maxParallelJobs: %d must not be negative", media.MaxParallelJobs))
+		}
+		if media.GPU < 0 {
+			errs = append(errs, fmt.Errorf("processing.media.gpu: %d must not be negative", media.GPU))
+		}
+		if media.GPUResource != "" && len(validation.IsQualifiedName(media.GPUResource)) > 0 {
+			errs = append(errs, fmt.Errorf("processing.media.gpuResource: %q is not a resource name such as nvidia.com/gpu", media.GPUResource))
+		}
+	}
+	if dedup := ragme.Spec.Processing.Dedup; dedup.Enabled {
+		switch dedup.Strategy {
+		case "", dedupStrategyHash:
+			if dedup.SimilarityThreshold != "" {
+				errs = append(errs, fmt.Errorf("processing.dedup.similarityThreshold: only used with the %s strategy", dedupStrategySemantic))
+			}
+		case dedupStrategySemantic:
+			if dedup.SimilarityThreshold != "" {
+				if threshold, err := strconv.ParseFloat(dedup.SimilarityThreshold, 64); err != nil || threshold <= 0 || threshold > 1 {
+					errs = append(errs, fmt.Errorf("processing.dedup.similarityThreshold: %q must be a number in (0, 1]", dedup.SimilarityThreshold))
+				}
+			}
+		default:
+			errs = append(errs, fmt.Errorf("processing.dedup.strategy: unsupported strategy %q, use %s or %s", dedup.Strategy, dedupStrategyHash, dedupStrategySemantic))
+		}
+	}
+	if versioning := ragme.Spec.Processing.Versioning; versioning.Enabled {
+		if versioning.KeepVersions < 0 {
+			errs = append(errs, fmt.Errorf("processing.versioning.keepVersions: %d must not be negative", versioning.KeepVersions))
+		}
+		if versioning.CleanupSchedule != "" {
+			if _, err := parseCron(versioning.CleanupSchedule); err != nil {
+				errs = append(errs, fmt.Errorf("processing.versioning.cleanupSchedule: %q: %w", versioning.CleanupSchedule, err))
+			}
+		}
+	}
+	blockedExtensions, blockedMIMETypes := splitFileTypes(ragme.Spec.Processing.BlockedTypes)
+	for _, blockedType := range append(blockedExtensions, blockedMIMETypes...) {
+		blocked[blockedType] = true
+	}
+	for _, pattern := range ragme.Spec.Processing.AllowedTypes {
+		if !validFileTypePattern(pattern) {
+			errs = append(errs, fmt.Errorf("processing.allowedTypes: %q is not a file extension, MIME type or group such as @archives", pattern))
+		} else if blocked[normalizeFileTypePattern(pattern)] {
+			errs = append(errs, fmt.Errorf("processing.allowedTypes: %q is also blocked", pattern))
+		}
+	}
+
+	budget := ragme.Spec.LLM.Budget
+	if budget.DailyTokens < 0 {
+		errs = append(errs, fmt.Errorf("llm.budget.dailyTokens: must not be negative"))
+	}
+	if budget.PerUserDailyTokens < 0 {
+		errs = append(errs, fmt.Errorf("llm.budget.perUserDailyTokens: must not be negative"))
+	}
+	if budget.AlertThresholdPercent < 0 || budget.AlertThresholdPercent > 100 {
+		errs = append(errs, fmt.Errorf("llm.budget.alertThresholdPercent: must be between 1 and 100, got %d", budget.AlertThresholdPercent))
+	}
+
+	for i, route := range ragme.Spec.LLM.Routing.Routes {
+		if msgs := validation.IsDNS1123Label(route.Provider); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("llm.routing.routes[%d].provider: %q must be a lower-case provider name such as openai", i, route.Provider))
+		}
+		if route.Model == "" {
+			errs = append(errs, fmt.Errorf("llm.routing.routes[%d].model is required", i))
+		}
+		if route.Timeout != "" {
+			if timeout, err := time.ParseDuration(route.Timeout); err != nil || timeout <= 0 {
+				errs = append(errs, fmt.Errorf("llm.routing.routes[%d].timeout: %q must be a positive duration such as 30s", i, route.Timeout))
+			}
+		}
+	}
+
+	secretRefs := map[string]bool{}
+	for i, ref := range ragme.Spec.SecretRefs {
+		if msgs := validation.IsDNS1123Subdomain(ref.Name); len(msgs) > 0 {
+			errs = append(errs, fmt.Errorf("secretRefs[%d].name: %q is not a valid Secret name", i, ref.Name))
+		}
+		if ref.Namespace != "" {
+			if msgs := validation.IsDNS1123Label(ref.Namespace); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("secretRefs[%d].namespace: %q is not a valid namespace", i, ref.Namespace))
+			}
+		}
+		key := secretRefNamespace(ragme, ref) + "/" + ref.Name
+		if secretRefs[key] {
+			errs = append(errs, fmt.Errorf("secretRefs[%d]: %s is referenced twice", i, key))
+		}
+		secretRefs[key] = true
+	}
+
+	if cache := ragme.Spec.Embeddings.Cache; cache.Enabled {
+		if cache.Backend != "" && cache.Backend != cacheBackendRedis {
+			errs = append(errs, fmt.Errorf("embeddings.cache.backend: unsupported backend %q, use %s", cache.Backend, cacheBackendRedis))
+		}
+		if cache.TTL != "" {
+			if ttl, err := time.ParseDuration(cache.TTL); err != nil || ttl <= 0 {
+				errs = append(errs, fmt.Errorf("embeddings.cache.ttl: %q must be a positive duration such as 720h", cache.TTL))
+			}
+		}
+		if cache.MaxSize != "" {
+			if size, err := resource.ParseQuantity(cache.MaxSize); err != nil || size.Sign() <= 0 {
+				errs = append(errs, fmt.Errorf("embeddings.cache.maxSize: %q must be a positive quantity such as 1Gi", cache.MaxSize))
+			}
+		}
+		if cache.Redis.URL != "" {
+			if u, err := url.Parse(cache.Redis.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+				errs = append(errs, fmt.Errorf("embeddings.cache.redis.url: %q must be a redis:// or rediss:// URL", cache.Redis.URL))
+			}
+		}
+	}
+
+	if cache := ragme.Spec.API.QueryCache; cache.Enabled {
+		switch cache.Backend {
+		case "", cacheBackendMemory, cacheBackendRedis:
+		default:
+			errs = append(errs, fmt.Errorf("api.queryCache.backend: unsupported backend %q, use %s or %s", cache.Backend, cacheBackendMemory, cacheBackendRedis))
+		}
+		if cache.TTL != "" {
+			if ttl, err := time.ParseDuration(cache.TTL); err != nil || ttl <= 0 {
+				errs = append(errs, fmt.Errorf("api.queryCache.ttl: %q must be a positive duration such as 30m", cache.TTL))
+			}
+		}
+		if cache.MaxEntries < 0 {
+			errs = append(errs, fmt.Errorf("api.queryCache.maxEntries: must not be negative"))
+		}
+		switch cache.KeyStrategy {
+		case "", queryCacheKeyExact, queryCacheKeyNormalized, queryCacheKeyPerUser:
+		default:
+			errs = append(errs, fmt.Errorf("api.queryCache.keyStrategy: unsupported strategy %q, use %s, %s or %s",
+				cache.KeyStrategy, queryCacheKeyExact, queryCacheKeyNormalized, queryCacheKeyPerUser))
+		}
+		if cache.Redis.URL != "" {
+			if u, err := url.Parse(cache.Redis.URL); err != nil || (u.Scheme != "redis" && u.Scheme != "rediss") {
+				errs = append(errs, fmt.Errorf("api.queryCache.redis.url: %q must be a redis:// or rediss:// URL", cache.Redis.URL))
+			}
+		}
+	}
+
+	retrieval := ragme.Spec.Retrieval
+	if retrieval.HybridAlpha != "" {
+		alpha, err := strconv.ParseFloat(retrieval.HybridAlpha, 64)
+		if err != nil || alpha < 0 || alpha > 1 {
+			errs = append(errs, fmt.Errorf("retrieval.hybridAlpha: %q must be a number between 0 and 1", retrieval.HybridAlpha))
+		} else if alpha < 1 && !keywordIndexEnabled(ragme) {
+			errs = append(errs, fmt.Errorf("retrieval.hybridAlpha: %q uses the keyword index, which retrieval.keywordIndex disables", retrieval.HybridAlpha))
+		}
+	}
+	if retrieval.TopK < 0 {
+		errs = append(errs, fmt.Errorf("retrieval.topK: must not be negative"))
+	}
+	for property := range retrieval.Filters {
+		if !retrievalPropertyPattern.MatchString(property) {
+			errs = append(errs, fmt.Errorf("retrieval.filters: %q is not a valid property name", property))
+		}
+	}
+
+	if evaluation := ragme.Spec.Evaluation; evaluation.Enabled {
+		if evaluation.Schedule != "" {
+			if _, err := parseCron(evaluation.Schedule); err != nil {
+				errs = append(errs, fmt.Errorf("evaluation.schedule: %q: %w", evaluation.Schedule, err))
+			}
+		}
+		dataset := evaluation.Dataset
+		switch {
+		case dataset.ConfigMapRef == nil && dataset.S3 == nil:
+			errs = append(errs, fmt.Errorf("evaluation.dataset: one of configMapRef or s3 is required"))
+		case dataset.ConfigMapRef != nil && dataset.S3 != nil:
+			errs = append(errs, fmt.Errorf("evaluation.dataset: configMapRef and s3 are mutually exclusive"))
+		case dataset.ConfigMapRef != nil && (dataset.ConfigMapRef.Name == "" || dataset.ConfigMapRef.Key == ""):
+			errs = append(errs, fmt.Errorf("evaluation.dataset.configMapRef: name and key are required"))
+		case dataset.S3 != nil && (dataset.S3.Endpoint == "" || dataset.S3.Bucket == "" || dataset.S3.Key == ""):
+			errs = append(errs, fmt.Errorf("evaluation.dataset.s3: endpoint, bucket and key are required"))
+		}
+		for metric, threshold := range evaluation.Thresholds {
+			if minimum, err := strconv.ParseFloat(threshold, 64); err != nil || minimum < 0 || minimum > 1 {
+				errs = append(errs, fmt.Errorf("evaluation.thresholds.%s: %q must be a score between 0 and 1", metric, threshold))
+			}
+		}
+	}
+
+	if slo := ragme.Spec.SLO; slo.Enabled {
+		if slo.AvailabilityTarget != "" {
+			if target, err := strconv.ParseFloat(slo.AvailabilityTarget, 64); err != nil || target <= 0 || target >= 100 {
+				errs = append(errs, fmt.Errorf("slo.availabilityTarget: %q must be a percentage below 100 such as 99.9", slo.AvailabilityTarget))
+			}
+		}
+		for field, value := range map[string]string{
+			"latencyTarget": slo.LatencyTarget,
+			"probeInterval": slo.ProbeInterval,
+			"window":        slo.Window,
+		} {
+			if value == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("slo.%s: %q must be a positive duration", field, value))
+			}
+		}
+		_, _, interval, window := sloSettings(ragme)
+		if interval >= window {
+			errs = append(errs, fmt.Errorf("slo.window: must be longer than the probe interval"))
+		}
+	}
+
+	if resilience := ragme.Spec.Resilience; resilience.Enabled {
+		if resilience.Schedule != "" {
+			if _, err := parseCron(resilience.Schedule); err != nil {
+				errs = append(errs, fmt.Errorf("resilience.schedule: %q: %w", resilience.Schedule, err))
+			}
+		}
+		if resilience.TimeZone != "" {
+			if _, err := time.LoadLocation(resilience.TimeZone); err != nil {
+				errs = append(errs, fmt.Errorf("resilience.timeZone: %q is not a known time zone", resilience.TimeZone))
+			}
+		}
+		for _, component := range resilience.Components {
+			if !disruptableComponent(component) {
+				errs = append(errs, fmt.Errorf("resilience.components: %q cannot be disrupted, use %s", component, strings.Join(resilienceComponents, ", ")))
+			}
+		}
+		if resilience.RecoveryTimeout != "" {
+			if timeout, err := time.ParseDuration(resilience.RecoveryTimeout); err != nil || timeout <= 0 {
+				errs = append(errs, fmt.Errorf("resilience.recoveryTimeout: %q must be a positive duration such as 3m", resilience.RecoveryTimeout))
+			}
+		}
+	}
+	if after := ragme.Spec.NodeRecovery.UnreachableAfter; after != "" {
+		if d, err := time.ParseDuration(after); err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("nodeRecovery.unreachableAfter: %q must be a positive duration such as 10m", after))
+		}
+	}
+
+	if topology := ragme.Spec.Topology; len(topology.Zones) > 0 || topology.Mode != "" {
+		if len(topology.Zones) == 0 {
+			errs = append(errs, fmt.Errorf("topology.zones: at least one zone is required"))
+		}
+		seen := map[string]bool{}
+		for _, zone := range topology.Zones {
+			if zone == "" || seen[zone] {
+				errs = append(errs, fmt.Errorf("topology.zones: %q is empty or listed twice", zone))
+			}
+			seen[zone] = true
+		}
+		if topology.Mode != "" && topology.Mode != "spread" && topology.Mode != "pin" {
+			errs = append(errs, fmt.Errorf("topology.mode: unsupported mode %q, use spread or pin", topology.Mode))
+		}
+	}
+
+	if auth := ragme.Spec.ExternalAccess.Ingress.Auth; auth.Enabled {
+		provider := ingressAuthProvider(ragme)
+		switch {
+		case provider == "":
+			errs = append(errs, fmt.Errorf("externalAccess.ingress.auth: requires an enabled google or github OAuth provider"))
+		case provider != "google" && provider != "github":
+			errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.provider: unsupported provider %q, use google or github", provider))
+		case !oauthProviders(ragme)[provider].Enabled:
+			errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.provider: OAuth provider %s is not enabled", provider))
+		}
+		if len(auth.Routes) == 0 {
+			errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.routes: at least one route is required"))
+		}
+		hosts := map[string]bool{}
+		for i, route := range auth.Routes {
+			if _, ok := protectedBackends[route.Name]; !ok {
+				errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.routes[%d].name: unsupported route %q, use minio-console, weaviate or api-docs", i, route.Name))
+			}
+			if route.Name == "minio-console" && !ragme.Spec.Storage.MinIO.Enabled {
+				errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.routes[%d]: minio-console requires storage.minio.enabled", i))
+			}
+			if msgs := validation.IsDNS1123Subdomain(route.Host); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.routes[%d].host: %q must be a domain name", i, route.Host))
+			} else if hosts[route.Host] || route.Host == ragme.Spec.ExternalAccess.Ingress.Host {
+				errs = append(errs, fmt.Errorf("externalAccess.ingress.auth.routes[%d].host: %q is already served", i, route.Host))
+			}
+			hosts[route.Host] = true
+		}
+	}
+
+	if port := ragme.Spec.AdminPort.Port; ragme.Spec.AdminPort.Enabled && port != 0 {
+		switch {
+		case port < 1 || port > 65535:
+			errs = append(errs, fmt.Errorf("adminPort.port: %d must be between 1 and 65535", port))
+		case port == 8020 || port == 8021 || port == 8022 ||
+			port == volumeMonitorPort || port == scanMetricsPort || port == egressProxyPort:
+			errs = append(errs, fmt.Errorf("adminPort.port: %d clashes with a port of the service pods", port))
+		}
+	}
+
+	monitoring := ragme.Spec.Monitoring
+	switch monitoring.Mode {
+	case "", "serviceMonitor", monitoringModeAnnotations:
+	default:
+		errs = append(errs, fmt.Errorf("monitoring.mode: unsupported mode %q, use serviceMonitor or annotations", monitoring.Mode))
+	}
+	switch monitoring.AnnotationTarget {
+	case "", "pods", "services":
+	default:
+		errs = append(errs, fmt.Errorf("monitoring.annotationTarget: unsupported target %q, use pods or services", monitoring.AnnotationTarget))
+	}
+	// Annotation-based scrape jobs cannot present the MinIO bearer token
+	if metrics := ragme.Spec.Storage.MinIO.Metrics; annotationScraping(ragme) && metrics.Enabled && metrics.ServiceMonitor && metrics.AuthType != "public" {
+		errs = append(errs, fmt.Errorf("storage.minio.metrics.authType: only public metrics can be scraped through annotations"))
+	}
+	if remoteWrite := monitoring.RemoteWrite; remoteWrite.Enabled {
+		if u, err := url.Parse(remoteWrite.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("monitoring.remoteWrite.url: %q must be an http:// or https:// URL", remoteWrite.URL))
+		}
+		switch remoteWrite.AuthType {
+		case "", "bearer", "basic":
+		default:
+			errs = append(errs, fmt.Errorf("monitoring.remoteWrite.authType: unsupported type %q, use bearer or basic", remoteWrite.AuthType))
+		}
+		if remoteWrite.AuthType != "" && remoteWrite.AuthSecretRef == nil {
+			errs = append(errs, fmt.Errorf("monitoring.remoteWrite.authSecretRef is required with authType"))
+		}
+		if remoteWrite.ScrapeInterval != "" {
+			if d, err := time.ParseDuration(remoteWrite.ScrapeInterval); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("monitoring.remoteWrite.scrapeInterval: invalid duration %q", remoteWrite.ScrapeInterval))
+			}
+		}
+	}
+	if routing := monitoring.AlertRouting; routing.Enabled {
+		if len(routing.Receivers) == 0 {
+			errs = append(errs, fmt.Errorf("monitoring.alertRouting.receivers: at least one receiver is required"))
+		}
+		receivers := map[string]bool{}
+		for i, receiver := range routing.Receivers {
+			switch {
+			case receiver.Name == "" || receiver.Name == nullReceiver:
+				errs = append(errs, fmt.Errorf("monitoring.alertRouting.receivers[%d].name: %q is not a valid receiver name", i, receiver.Name))
+			case receivers[receiver.Name]:
+				errs = append(errs, fmt.Errorf("monitoring.alertRouting.receivers[%d].name: duplicate receiver %q", i, receiver.Name))
+			}
+			receivers[receiver.Name] = true
+			configured := 0
+			for _, ref := range []*corev1.SecretKeySelector{receiver.WebhookURLSecretRef, receiver.SlackAPIURLSecretRef, receiver.PagerDutyRoutingKeySecretRef} {
+				if ref != nil {
+					configured++
+				}
+			}
+			if configured != 1 {
+				errs = append(errs, fmt.Errorf("monitoring.alertRouting.receivers[%d]: exactly one of webhookURLSecretRef, slackAPIURLSecretRef or pagerDutyRoutingKeySecretRef is required", i))
+			}
+		}
+		if routing.Receiver != "" && !receivers[routing.Receiver] {
+			errs = append(errs, fmt.Errorf("monitoring.alertRouting.receiver: unknown receiver %q", routing.Receiver))
+		}
+		for severity, receiver := range routing.SeverityReceivers {
+			if !receivers[receiver] {
+				errs = append(errs, fmt.Errorf("monitoring.alertRouting.severityReceivers.%s: unknown receiver %q", severity, receiver))
+			}
+		}
+		if (routing.InhibitDuringUpgrades || routing.SilenceUpgrades) && routing.AlertmanagerURL == "" {
+			errs = append(errs, fmt.Errorf("monitoring.alertRouting.alertmanagerURL is required to mute alerts during upgrades"))
+		} else if u, err := url.Parse(routing.AlertmanagerURL); routing.AlertmanagerURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "") {
+			errs = append(errs, fmt.Errorf("monitoring.alertRouting.alertmanagerURL: %q must be an http:// or https:// URL", routing.AlertmanagerURL))
+		}
+		if routing.SilenceDuration != "" {
+			if d, err := time.ParseDuration(routing.SilenceDuration); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("monitoring.alertRouting.silenceDuration: invalid duration %q", routing.SilenceDuration))
+			}
+		}
+	}
+
+	if tracing := ragme.Spec.Tracing; tracing.Enabled {
+		// Without an endpoint the services send their spans to the in-cluster collector
+		if tracing.Endpoint != "" || !ragme.Spec.Observability.Collector.Enabled {
+			if u, err := url.Parse(tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("tracing.endpoint: %q must be an http:// or https:// URL", tracing.Endpoint))
+			}
+		}
+		switch tracing.Protocol {
+		case "", tracingProtocolGRPC, tracingProtocolHTTP:
+		default:
+			errs = append(errs, fmt.Errorf("tracing.protocol: unsupported protocol %q, use %s or %s", tracing.Protocol, tracingProtocolGRPC, tracingProtocolHTTP))
+		}
+		if tracing.SampleRate != "" {
+			if rate, err := strconv.ParseFloat(tracing.SampleRate, 64); err != nil || rate < 0 || rate > 1 {
+				errs = append(errs, fmt.Errorf("tracing.sampleRate: %q must be between 0 and 1", tracing.SampleRate))
+			}
+		}
+		for i, propagator := range tracing.Propagators {
+			if !supportedTracingPropagators[propagator] {
+				errs = append(errs, fmt.Errorf("tracing.propagators[%d]: unsupported propagator %q", i, propagator))
+			}
+		}
+		for key, value := range tracing.ResourceAttributes {
+			if key == "" || strings.ContainsAny(key+value, ",=") {
+				errs = append(errs, fmt.Errorf("tracing.resourceAttributes: %q=%q must not be empty nor contain , or =", key, value))
+			}
+		}
+	}
+
+	if collector := ragme.Spec.Observability.Collector; collector.Enabled {
+		if len(collector.Exporters) == 0 {
+			errs = append(errs, fmt.Errorf("observability.collector.exporters: at least one exporter is required"))
+		}
+		if collector.Replicas < 0 {
+			errs = append(errs, fmt.Errorf("observability.collector.replicas: must not be negative"))
+		}
+		if collector.ScrapeInterval != "" {
+			if d, err := time.ParseDuration(collector.ScrapeInterval); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("observability.collector.scrapeInterval: invalid duration %q", collector.ScrapeInterval))
+			}
+		}
+		names := map[string]bool{}
+		for i, exporter := range collector.Exporters {
+			path := fmt.Sprintf("observability.collector.exporters[%d]", i)
+			if msgs := validation.IsDNS1123Label(exporter.Name); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("%s.name: %q %s", path, exporter.Name, strings.Join(msgs, ", ")))
+			} else if names[exporter.Name] {
+				errs = append(errs, fmt.Errorf("%s.name: duplicate exporter %q", path, exporter.Name))
+			}
+			names[exporter.Name] = true
+			if _, ok := collectorExporterSignals[exporter.Type]; !ok {
+				errs = append(errs, fmt.Errorf("%s.type: unsupported type %q, use one of %s", path, exporter.Type, collectorExporterTypes()))
+				continue
+			}
+			if exporter.Type != collectorExporterDebug && exporter.Endpoint == "" {
+				errs = append(errs, fmt.Errorf("%s.endpoint: required for the %s exporter", path, exporter.Type))
+			}
+			for _, signal := range exporter.Signals {
+				if !validCollectorSignal(exporter.Type, signal) {
+					errs = append(errs, fmt.Errorf("%s.signals: the %s exporter does not support %q", path, exporter.Type, signal))
+				}
+			}
+			for header, ref := range exporter.HeadersFrom {
+				if header == "" || ref.Name == "" || ref.Key == "" {
+					errs = append(errs, fmt.Errorf("%s.headersFrom: header %q requires a Secret name and key", path, header))
+				}
+			}
+		}
+	}
+
+	if profiling := ragme.Spec.Observability.Profiling; profiling.Enabled {
+		switch profilingBackend(ragme) {
+		case profilingBackendPyroscope:
+			if u, err := url.Parse(profiling.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Errorf("observability.profiling.endpoint: %q must be the http:// or https:// URL of Pyroscope", profiling.Endpoint))
+			}
+			if profiling.Insecure {
+				errs = append(errs, fmt.Errorf("observability.profiling.insecure: only applies to parca, use an http:// endpoint"))
+			}
+		case profilingBackendParca:
+			if _, _, err := net.SplitHostPort(profiling.Endpoint); err != nil || strings.Contains(profiling.Endpoint, "://") {
+				errs = append(errs, fmt.Errorf("observability.profiling.endpoint: %q must be the host:port of the Parca gRPC API", profiling.Endpoint))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("observability.profiling.backend: unsupported backend %q, use %s or %s", profiling.Backend, profilingBackendPyroscope, profilingBackendParca))
+		}
+		if port := profilingPort(ragme); port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("observability.profiling.port: %d is not a valid port", port))
+		} else if port == adminPort(ragme) {
+			errs = append(errs, fmt.Errorf("observability.profiling.port: %d is already the admin port", port))
+		}
+		if profiling.ScrapeInterval != "" {
+			if d, err := time.ParseDuration(profiling.ScrapeInterval); err != nil || d <= 0 {
+				errs = append(errs, fmt.Errorf("observability.profiling.scrapeInterval: invalid duration %q", profiling.ScrapeInterval))
+			}
+		}
+	}
+
+	if proxy := ragme.Spec.Egress.Proxy; proxy.Enabled {
+		switch proxy.Mode {
+		case "", egressProxyModeSidecar, egressProxyModeShared:
+		default:
+			errs = append(errs, fmt.Errorf("egress.proxy.mode: unsupported mode %q, use %s or %s", proxy.Mode, egressProxyModeSidecar, egressProxyModeShared))
+		}
+		names := map[string]bool{}
+		for i, upstream := range proxy.Upstreams {
+			if msgs := validation.IsDNS1123Label(upstream.Name); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("egress.proxy.upstreams[%d].name: %q must be a lower-case DNS label", i, upstream.Name))
+			} else if names[upstream.Name] {
+				errs = append(errs, fmt.Errorf("egress.proxy.upstreams[%d].name: duplicate upstream %q", i, upstream.Name))
+			}
+			names[upstream.Name] = true
+			if msgs := validation.IsDNS1123Subdomain(upstream.Host); len(msgs) > 0 {
+				errs = append(errs, fmt.Errorf("egress.proxy.upstreams[%d].host: %q must be a domain name", i, upstream.Host))
+			}
+			if upstream.Port < 0 || upstream.Port > 65535 {
+				errs = append(errs, fmt.Errorf("egress.proxy.upstreams[%d].port: %d is out of range", i, upstream.Port))
+			}
+			if upstream.BasePath != "" && !strings.HasPrefix(upstream.BasePath, "/") {
+				errs = append(errs, fmt.Errorf("egress.proxy.upstreams[%d].basePath: %q must start with /", i, upstream.BasePath))
+			}
+		}
+	}
+
+	switch ragme.Spec.MaintenanceMode {
+	case "", maintenanceReadOnly, maintenanceFull:
+	default:
+		errs = append(errs, fmt.Errorf("maintenanceMode: unsupported mode %q, use %s or %s", ragme.Spec.MaintenanceMode, maintenanceReadOnly, maintenanceFull))
+	}
+
+	hibernation := ragme.Spec.Hibernation
+	if hibernation.TimeZone != "" {
+		if _, err := time.LoadLocation(hibernation.TimeZone); err != nil {
+			errs = append(errs, fmt.Errorf("hibernation.timeZone: unknown time zone %q", hibernation.TimeZone))
+		}
+	}
+	for i, window := range hibernation.Windows {
+		if _, err := parseCron(window.Sleep); err != nil {
+			errs = append(errs, fmt.Errorf("hibernation.windows[%d].sleep: invalid schedule %q: %w", i, window.Sleep, err))
+		}
+		if _, err := parseCron(window.Wake); err != nil {
+			errs = append(errs, fmt.Errorf("hibernation.windows[%d].wake: invalid schedule %q: %w", i, window.Wake, err))
+		}
+	}
+
+	if clone := ragme.Spec.CloneFrom; clone != nil {
+		switch clone.InstanceRef {
+		case "":
+			errs = append(errs, fmt.Errorf("cloneFrom.instanceRef: required"))
+		case ragme.Name:
+			errs = append(errs, fmt.Errorf("cloneFrom.instanceRef: an instance cannot be cloned from itself"))
+		}
+	}
+	if previous := ragme.Spec.PreviousInstanceName; previous != "" {
+		switch {
+		case previous == ragme.Name:
+			errs = append(errs, fmt.Errorf("previousInstanceName: must differ from the name of the instance"))
+		case ragme.Spec.CloneFrom != nil:
+			errs = append(errs, fmt.Errorf("previousInstanceName: cannot be combined with cloneFrom"))
+		}
+	}
+
+	seed := ragme.Spec.SeedData
+	for _, seedURL := range seed.URLs {
+		if u, err := url.Parse(seedURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("seedData.urls: %q must be an http(s) URL", seedURL))
+		}
+	}
+	if git := seed.Git; git != nil {
+		if u, err := url.Parse(git.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("seedData.git.url: %q must be an http(s) URL", git.URL))
+		}
+		if strings.Contains(git.Path, "..") {
+			errs = append(errs, fmt.Errorf("seedData.git.path: %q must stay inside the repository", git.Path))
+		}
+	}
+	if s3 := seed.S3; s3 != nil {
+		if u, err := url.Parse(s3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("seedData.s3.endpoint: %q must be an http(s) URL", s3.Endpoint))
+		}
+		if s3.Bucket == "" {
+			errs = append(errs, fmt.Errorf("seedData.s3.bucket: required"))
+		}
+	}
+
+	if ragme.Spec.TTL != "" {
+		if ttl, err := time.ParseDuration(ragme.Spec.TTL); err != nil || ttl <= 0 {
+			errs = append(errs, fmt.Errorf("ttl: %q must be a positive duration such as 72h", ragme.Spec.TTL))
+		}
+	}
+	switch ragme.Spec.CleanupPolicy {
+	case "", cleanupPolicyDelete, cleanupPolicyRetain:
+	default:
+		errs = append(errs, fmt.Errorf("cleanupPolicy: unsupported policy %q, use %s or %s",
+			ragme.Spec.CleanupPolicy, cleanupPolicyDelete, cleanupPolicyRetain))
+	}
+	switch ragme.Spec.DriftPolicy {
+	case "", driftPolicyCorrect, driftPolicyWarn:
+	default:
+		errs = append(errs, fmt.Errorf("driftPolicy: unsupported policy %q, use %s or %s",
+			ragme.Spec.DriftPolicy, driftPolicyCorrect, driftPolicyWarn))
+	}
+	termination := ragme.Spec.Termination
+	if termination.TimeoutSeconds < 0 {
+		errs = append(errs, fmt.Errorf("termination.timeoutSeconds: %d must not be negative", termination.TimeoutSeconds))
+	}
+	if termination.StuckThresholdSeconds < 0 {
+		errs = append(errs, fmt.Errorf("termination.stuckThresholdSeconds: %d must not be negative", termination.StuckThresholdSeconds))
+	}
+	for i, stage := range termination.Stages {
+		if len(stage.Components) == 0 {
+			errs = append(errs, fmt.Errorf("termination.stages[%d].components: at least one component is required", i))
+		}
+		if stage.TimeoutSeconds < 0 {
+			errs = append(errs, fmt.Errorf("termination.stages[%d].timeoutSeconds: %d must not be negative", i, stage.TimeoutSeconds))
+		}
+	}
+
+	switch ragme.Spec.FeatureFlagsReload {
+	case "", featureFlagsHotReload, featureFlagsRollout:
+	default:
+		errs = append(errs, fmt.Errorf("featureFlagsReload: unsupported policy %q, use %s or %s",
+			ragme.Spec.FeatureFlagsReload, featureFlagsHotReload, featureFlagsRollout))
+	}
+
+	jobs := ragme.Spec.Jobs
+	if ttl := jobs.TTLSecondsAfterFinished; ttl != nil && *ttl < minJobTTLSeconds {
+		errs = append(errs, fmt.Errorf("jobs.ttlSecondsAfterFinished: %d is below %d, the operator must be able to observe finished Jobs", *ttl, minJobTTLSeconds))
+	}
+	if limit := jobs.SuccessfulJobsHistoryLimit; limit != nil && *limit < 0 {
+		errs = append(errs, fmt.Errorf("jobs.successfulJobsHistoryLimit: %d must not be negative", *limit))
+	}
+	if limit := jobs.FailedJobsHistoryLimit; limit != nil && *limit < 0 {
+		errs = append(errs, fmt.Errorf("jobs.failedJobsHistoryLimit: %d must not be negative", *limit))
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
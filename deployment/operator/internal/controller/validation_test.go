@@ -0,0 +1,225 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestValidateSpecSidecars(t *testing.T) {
+	tests := []struct {
+		name     string
+		sidecars []corev1.Container
+		wantErr  bool
+	}{
+		{name: "no sidecars"},
+		{name: "valid sidecar", sidecars: []corev1.Container{{Name: "cloud-sql-proxy"}}},
+		{name: "missing name", sidecars: []corev1.Container{{Image: "busybox"}}, wantErr: true},
+		{name: "collides with managed container", sidecars: []corev1.Container{{Name: "api"}}, wantErr: true},
+		{name: "duplicate sidecars", sidecars: []corev1.Container{{Name: "opa"}, {Name: "opa"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Components.API.Sidecars = tt.sidecars
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecServiceAuthKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []ragmev1.RAGmeAPIKey
+		wantErr bool
+	}{
+		{name: "no keys"},
+		{name: "valid keys", keys: []ragmev1.RAGmeAPIKey{{Name: "ci", RotationPeriod: "720h"}, {Name: "etl"}}},
+		{name: "missing name", keys: []ragmev1.RAGmeAPIKey{{Scopes: []string{"query"}}}, wantErr: true},
+		{name: "duplicate names", keys: []ragmev1.RAGmeAPIKey{{Name: "ci"}, {Name: "ci"}}, wantErr: true},
+		{name: "invalid rotation period", keys: []ragmev1.RAGmeAPIKey{{Name: "ci", RotationPeriod: "30d"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Authentication.ServiceAuth.Keys = tt.keys
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecLDAP(t *testing.T) {
+	password := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "ldap"}, Key: "password"}
+	tests := []struct {
+		name    string
+		ldap    ragmev1.RAGmeLDAPConfig
+		wantErr bool
+	}{
+		{name: "disabled", ldap: ragmev1.RAGmeLDAPConfig{URL: "not a url"}},
+		{
+			name: "valid",
+			ldap: ragmev1.RAGmeLDAPConfig{
+				Enabled: true, URL: "ldaps://ad.example.com:636", BindDN: "cn=ragme,dc=example,dc=com",
+				BindPasswordSecretRef: password, UserSearch: ragmev1.RAGmeLDAPSearch{BaseDN: "dc=example,dc=com"},
+			},
+		},
+		{
+			name:    "http url",
+			ldap:    ragmev1.RAGmeLDAPConfig{Enabled: true, URL: "https://ad.example.com", UserSearch: ragmev1.RAGmeLDAPSearch{BaseDN: "dc=example,dc=com"}},
+			wantErr: true,
+		},
+		{
+			name:    "missing user search base",
+			ldap:    ragmev1.RAGmeLDAPConfig{Enabled: true, URL: "ldap://ad.example.com"},
+			wantErr: true,
+		},
+		{
+			name: "bind DN without password",
+			ldap: ragmev1.RAGmeLDAPConfig{
+				Enabled: true, URL: "ldap://ad.example.com", BindDN: "cn=ragme,dc=example,dc=com",
+				UserSearch: ragmev1.RAGmeLDAPSearch{BaseDN: "dc=example,dc=com"},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Authentication.LDAP = tt.ldap
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecSessionStore(t *testing.T) {
+	url := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "pg"}, Key: "url"}
+	tests := []struct {
+		name     string
+		store    ragmev1.RAGmeSessionStore
+		replicas int32
+		wantErr  bool
+	}{
+		{name: "default store", replicas: 2},
+		{name: "memory single replica", store: ragmev1.RAGmeSessionStore{Type: "memory"}, replicas: 1},
+		{name: "memory multiple replicas", store: ragmev1.RAGmeSessionStore{Type: "memory"}, replicas: 2, wantErr: true},
+		{name: "managed redis", store: ragmev1.RAGmeSessionStore{Type: "redis"}, replicas: 3},
+		{name: "postgres", store: ragmev1.RAGmeSessionStore{Type: "postgres", Postgres: ragmev1.RAGmePostgresSessionStore{URLSecretRef: url}}, replicas: 3},
+		{name: "postgres without url", store: ragmev1.RAGmeSessionStore{Type: "postgres"}, replicas: 3, wantErr: true},
+		{name: "unknown store", store: ragmev1.RAGmeSessionStore{Type: "memcached"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Replicas.API = tt.replicas
+			ragme.Spec.Authentication.Session.Store = tt.store
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecTempStorage(t *testing.T) {
+	tests := []struct {
+		name        string
+		tempStorage ragmev1.RAGmeTempStorage
+		wantErr     bool
+	}{
+		{name: "not configured"},
+		{name: "disk with size limit", tempStorage: ragmev1.RAGmeTempStorage{SizeLimit: "8Gi", Medium: "Disk"}},
+		{name: "memory", tempStorage: ragmev1.RAGmeTempStorage{Medium: "Memory"}},
+		{name: "unsupported medium", tempStorage: ragmev1.RAGmeTempStorage{Medium: "HugePages"}, wantErr: true},
+		{name: "invalid size limit", tempStorage: ragmev1.RAGmeTempStorage{SizeLimit: "huge"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Components.MCP.TempStorage = tt.tempStorage
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecAgent(t *testing.T) {
+	tests := []struct {
+		name    string
+		agent   ragmev1.RAGmeAgent
+		wantErr bool
+	}{
+		{name: "not configured"},
+		{name: "tuned", agent: ragmev1.RAGmeAgent{Concurrency: 4, BatchSize: 64, PollInterval: "30s", FileTypes: []string{".PDF", "docx"}}},
+		{name: "negative concurrency", agent: ragmev1.RAGmeAgent{Concurrency: -1}, wantErr: true},
+		{name: "invalid poll interval", agent: ragmev1.RAGmeAgent{PollInterval: "often"}, wantErr: true},
+		{name: "poll interval too short", agent: ragmev1.RAGmeAgent{PollInterval: "10ms"}, wantErr: true},
+		{name: "mime type instead of extension", agent: ragmev1.RAGmeAgent{FileTypes: []string{"application/pdf"}}, wantErr: true},
+		{name: "invalid exclude pattern", agent: ragmev1.RAGmeAgent{ExcludePatterns: []string{"[bad"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Agent = tt.agent
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecAgentReplicas(t *testing.T) {
+	tests := []struct {
+		name         string
+		replicas     int32
+		coordination bool
+		wantErr      bool
+	}{
+		{name: "single agent", replicas: 1},
+		{name: "several agents", replicas: 3, wantErr: true},
+		{name: "several coordinated agents", replicas: 3, coordination: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ragme := &ragmev1.RAGme{}
+			ragme.Spec.Replicas.Agent = tt.replicas
+			ragme.Spec.Agent.Coordination.Enabled = tt.coordination
+			err := validateSpec(ragme)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateSpecIngressHost(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.ExternalAccess.Ingress.Enabled = true
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted an ingress without host")
+	}
+	ragme.Spec.ExternalAccess.Ingress.Host = "ragme.example.com"
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
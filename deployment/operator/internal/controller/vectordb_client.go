@@ -0,0 +1,386 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// collectionClient manages collections in a vector database
+type collectionClient interface {
+	// EnsureCollection creates the collection or reconciles its schema
+	EnsureCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error
+	// DeleteCollection removes the collection, ignoring collections that do not exist
+	DeleteCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error
+	// CountObjects returns the number of objects stored in the collection
+	CountObjects(ctx context.Context, collection *ragmev1.RAGmeCollection) (int64, error)
+}
+
+// newCollectionClient returns the collection client for the vector database
+// configured on the given RAGme instance
+func newCollectionClient(httpClient *http.Client, ragme *ragmev1.RAGme) (collectionClient, error) {
+	switch ragme.Spec.VectorDB.Type {
+	case "weaviate":
+		if !ragme.Spec.VectorDB.Weaviate.Enabled {
+			return nil, fmt.Errorf("weaviate is not enabled on RAGme %s", ragme.Name)
+		}
+		return &weaviateCollectionClient{
+			httpClient:   httpClient,
+			baseURL:      fmt.Sprintf("http://%s-weaviate.%s.svc:8080", ragme.Name, ragme.Namespace),
+			keywordIndex: keywordIndexEnabled(ragme),
+		}, nil
+	case "milvus":
+		if milvusURI(ragme) == "" {
+			return nil, fmt.Errorf("milvus URI is not configured on RAGme %s", ragme.Name)
+		}
+		return &milvusCollectionClient{
+			httpClient: httpClient,
+			baseURL:    strings.TrimSuffix(milvusURI(ragme), "/"),
+			token:      ragme.Spec.VectorDB.Milvus.Token,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector database type %q", ragme.Spec.VectorDB.Type)
+	}
+}
+
+// defaultHTTPClient returns c, or a client with a 30s timeout when c is nil
+func defaultHTTPClient(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: 30 * time.Second}
+}
+
+// collectionName returns the vector database name of the collection
+func collectionName(collection *ragmev1.RAGmeCollection) string {
+	if collection.Spec.CollectionName != "" {
+		return collection.Spec.CollectionName
+	}
+	return collection.Name
+}
+
+// doJSON sends a JSON request and decodes the JSON response into out (if not nil).
+// It returns the HTTP status code alongside any transport or decoding error.
+func doJSON(ctx context.Context, httpClient *http.Client, method, url, token string, body, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return resp.StatusCode, fmt.Errorf("%s %s: %s: %s", method, url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+			return resp.StatusCode, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// weaviateCollectionClient manages Weaviate classes through the REST schema API
+type weaviateCollectionClient struct {
+	httpClient *http.Client
+	baseURL    string
+	// keywordIndex builds the BM25 index of the text properties
+	keywordIndex bool
+}
+
+// weaviateClassName converts a collection name into a valid Weaviate class name
+func weaviateClassName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.'
+	})
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+// indexParamValue converts a string index parameter into a typed JSON value
+func indexParamValue(v string) interface{} {
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(v); err == nil {
+		return b
+	}
+	return v
+}
+
+func (c *weaviateCollectionClient) EnsureCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error {
+	className := weaviateClassName(collectionName(collection))
+	classURL := fmt.Sprintf("%s/v1/schema/%s", c.baseURL, className)
+
+	existing := map[string]interface{}{}
+	status, err := doJSON(ctx, c.httpClient, http.MethodGet, classURL, "", nil, &existing)
+	if err != nil && status != http.StatusNotFound {
+		return err
+	}
+
+	if status == http.StatusNotFound {
+		class := map[string]interface{}{
+			"class":       className,
+			"description": collection.Spec.Description,
+			"properties":  weaviateProperties(collection.Spec.Fields, c.keywordIndex),
+		}
+		if collection.Spec.Vectorizer != "" {
+			class["vectorizer"] = collection.Spec.Vectorizer
+		}
+		if len(collection.Spec.IndexParams) > 0 {
+			indexConfig := map[string]interface{}{}
+			for k, v := range collection.Spec.IndexParams {
+				indexConfig[k] = indexParamValue(v)
+			}
+			class["vectorIndexConfig"] = indexConfig
+		}
+		if collection.Spec.Tenant != "" {
+			class["multiTenancyConfig"] = map[string]interface{}{"enabled": true}
+		}
+		if _, err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/v1/schema", "", class, nil); err != nil {
+			return err
+		}
+	} else {
+		// Weaviate does not allow changing existing properties, only adding new ones
+		known := map[string]bool{}
+		if props, ok := existing["properties"].([]interface{}); ok {
+			for _, p := range props {
+				if prop, ok := p.(map[string]interface{}); ok {
+					if name, ok := prop["name"].(string); ok {
+						known[strings.ToLower(name)] = true
+					}
+				}
+			}
+		}
+		for _, prop := range weaviateProperties(collection.Spec.Fields, c.keywordIndex) {
+			if known[strings.ToLower(prop["name"].(string))] {
+				continue
+			}
+			if _, err := doJSON(ctx, c.httpClient, http.MethodPost, classURL+"/properties", "", prop, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	if collection.Spec.Tenant != "" {
+		tenants := []map[string]string{{"name": collection.Spec.Tenant}}
+		status, err := doJSON(ctx, c.httpClient, http.MethodPost, classURL+"/tenants", "", tenants, nil)
+		// Weaviate answers 422 when the tenant already exists
+		if err != nil && status != http.StatusUnprocessableEntity {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *weaviateCollectionClient) DeleteCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error {
+	classURL := fmt.Sprintf("%s/v1/schema/%s", c.baseURL, weaviateClassName(collectionName(collection)))
+	status, err := doJSON(ctx, c.httpClient, http.MethodDelete, classURL, "", nil, nil)
+	if err != nil && status != http.StatusNotFound {
+		return err
+	}
+	return nil
+}
+
+// weaviateProperties converts collection fields into Weaviate property definitions,
+// with the text properties indexed for keyword search when keywordIndex is set
+func weaviateProperties(fields []ragmev1.RAGmeCollectionField, keywordIndex bool) []map[string]interface{} {
+	props := make([]map[string]interface{}, 0, len(fields))
+	for _, f := range fields {
+		prop := map[string]interface{}{
+			"name":     f.Name,
+			"dataType": []string{f.DataType},
+		}
+		if f.Description != "" {
+			prop["description"] = f.Description
+		}
+		if f.DataType == "text" || f.DataType == "text[]" {
+			prop["indexSearchable"] = keywordIndex
+		}
+		props = append(props, prop)
+	}
+	return props
+}
+
+func (c *weaviateCollectionClient) CountObjects(ctx context.Context, collection *ragmev1.RAGmeCollection) (int64, error) {
+	className := weaviateClassName(collectionName(collection))
+	query := map[string]interface{}{
+		"query": fmt.Sprintf("{ Aggregate { %s { meta { count } } } }", className),
+	}
+	resp := struct {
+		Data struct {
+			Aggregate map[string][]struct {
+				Meta struct {
+					Count int64 `json:"count"`
+				} `json:"meta"`
+			} `json:"Aggregate"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if _, err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+"/v1/graphql", "", query, &resp); err != nil {
+		return 0, err
+	}
+	if len(resp.Errors) > 0 {
+		return 0, fmt.Errorf("weaviate aggregate of %s failed: %s", className, resp.Errors[0].Message)
+	}
+	var count int64
+	for _, result := range resp.Data.Aggregate[className] {
+		count += result.Meta.Count
+	}
+	return count, nil
+}
+
+// milvusCollectionClient manages Milvus collections through the v2 REST API
+type milvusCollectionClient struct {
+	httpClient *http.Client
+	baseURL    string
+	token      string
+}
+
+// milvusResponse is the common envelope of Milvus v2 REST responses
+type milvusResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (c *milvusCollectionClient) call(ctx context.Context, path string, body interface{}) (*milvusResponse, error) {
+	resp := &milvusResponse{}
+	if _, err := doJSON(ctx, c.httpClient, http.MethodPost, c.baseURL+path, c.token, body, resp); err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return resp, fmt.Errorf("milvus %s failed (code %d): %s", path, resp.Code, resp.Message)
+	}
+	return resp, nil
+}
+
+func (c *milvusCollectionClient) EnsureCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error {
+	name := collectionName(collection)
+
+	resp, err := c.call(ctx, "/v2/vectordb/collections/has", map[string]interface{}{"collectionName": name})
+	if err != nil {
+		return err
+	}
+	has := struct {
+		Has bool `json:"has"`
+	}{}
+	if err := json.Unmarshal(resp.Data, &has); err != nil {
+		return err
+	}
+
+	if !has.Has {
+		if collection.Spec.Dimension <= 0 {
+			return fmt.Errorf("dimension is required to create Milvus collection %s", name)
+		}
+		fields := []map[string]interface{}{
+			{"fieldName": "id", "dataType": "Int64", "isPrimary": true},
+			{"fieldName": "vector", "dataType": "FloatVector", "elementTypeParams": map[string]interface{}{"dim": collection.Spec.Dimension}},
+		}
+		for _, f := range collection.Spec.Fields {
+			field := map[string]interface{}{"fieldName": f.Name, "dataType": f.DataType}
+			if f.DataType == "VarChar" {
+				field["elementTypeParams"] = map[string]interface{}{"max_length": 65535}
+			}
+			fields = append(fields, field)
+		}
+		index := map[string]interface{}{"fieldName": "vector", "indexName": "vector_index"}
+		params := map[string]interface{}{}
+		for k, v := range collection.Spec.IndexParams {
+			switch k {
+			case "metricType", "indexType":
+				index[k] = v
+			default:
+				params[k] = indexParamValue(v)
+			}
+		}
+		if len(params) > 0 {
+			index["params"] = params
+		}
+		if _, ok := index["metricType"]; !ok {
+			index["metricType"] = "COSINE"
+		}
+
+		create := map[string]interface{}{
+			"collectionName": name,
+			"description":    collection.Spec.Description,
+			"schema":         map[string]interface{}{"fields": fields},
+			"indexParams":    []map[string]interface{}{index},
+		}
+		if _, err := c.call(ctx, "/v2/vectordb/collections/create", create); err != nil {
+			return err
+		}
+	}
+
+	if collection.Spec.Tenant != "" {
+		partition := map[string]interface{}{"collectionName": name, "partitionName": collection.Spec.Tenant}
+		resp, err := c.call(ctx, "/v2/vectordb/partitions/has", partition)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(resp.Data, &has); err != nil {
+			return err
+		}
+		if !has.Has {
+			if _, err := c.call(ctx, "/v2/vectordb/partitions/create", partition); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *milvusCollectionClient) DeleteCollection(ctx context.Context, collection *ragmev1.RAGmeCollection) error {
+	_, err := c.call(ctx, "/v2/vectordb/collections/drop", map[string]interface{}{"collectionName": collectionName(collection)})
+	return err
+}
+
+func (c *milvusCollectionClient) CountObjects(ctx context.Context, collection *ragmev1.RAGmeCollection) (int64, error) {
+	resp, err := c.call(ctx, "/v2/vectordb/collections/get_stats", map[string]interface{}{"collectionName": collectionName(collection)})
+	if err != nil {
+		return 0, err
+	}
+	stats := struct {
+		RowCount int64 `json:"rowCount"`
+	}{}
+	if err := json.Unmarshal(resp.Data, &stats); err != nil {
+		return 0, err
+	}
+	return stats.RowCount, nil
+}
@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestWeaviateClassName(t *testing.T) {
+	tests := map[string]string{
+		"docs":           "Docs",
+		"ragme-docs":     "RagmeDocs",
+		"team_docs.v2":   "TeamDocsV2",
+		"AlreadyPascal":  "AlreadyPascal",
+		"mixed-Case_one": "MixedCaseOne",
+	}
+	for in, want := range tests {
+		if got := weaviateClassName(in); got != want {
+			t.Errorf("weaviateClassName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWeaviateEnsureCollection(t *testing.T) {
+	var created map[string]interface{}
+	var addedProps []string
+	var tenants int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch {
+		case req.Method == http.MethodGet && req.URL.Path == "/v1/schema/RagmeDocs":
+			if created == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(created)
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/schema":
+			_ = json.NewDecoder(req.Body).Decode(&created)
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/schema/RagmeDocs/properties":
+			prop := map[string]interface{}{}
+			_ = json.NewDecoder(req.Body).Decode(&prop)
+			addedProps = append(addedProps, prop["name"].(string))
+		case req.Method == http.MethodPost && req.URL.Path == "/v1/schema/RagmeDocs/tenants":
+			tenants++
+			if tenants > 1 {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+			}
+		default:
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	vdb := &weaviateCollectionClient{httpClient: server.Client(), baseURL: server.URL, keywordIndex: true}
+	collection := &ragmev1.RAGmeCollection{
+		ObjectMeta: metav1.ObjectMeta{Name: "ragme-docs"},
+		Spec: ragmev1.RAGmeCollectionSpec{
+			InstanceRef: "ragme",
+			Fields:      []ragmev1.RAGmeCollectionField{{Name: "url", DataType: "text"}},
+			IndexParams: map[string]string{"efConstruction": "128"},
+			Tenant:      "engineering",
+		},
+	}
+
+	if err := vdb.EnsureCollection(context.Background(), collection); err != nil {
+		t.Fatalf("first EnsureCollection failed: %v", err)
+	}
+	if created["class"] != "RagmeDocs" {
+		t.Errorf("expected class RagmeDocs to be created, got %v", created["class"])
+	}
+	if created["vectorIndexConfig"].(map[string]interface{})["efConstruction"] != float64(128) {
+		t.Errorf("expected numeric index params, got %v", created["vectorIndexConfig"])
+	}
+	if prop := created["properties"].([]interface{})[0].(map[string]interface{}); prop["indexSearchable"] != true {
+		t.Errorf("expected the text property to be keyword indexed, got %v", prop)
+	}
+
+	collection.Spec.Fields = append(collection.Spec.Fields, ragmev1.RAGmeCollectionField{Name: "text", DataType: "text"})
+	if err := vdb.EnsureCollection(context.Background(), collection); err != nil {
+		t.Fatalf("second EnsureCollection failed: %v", err)
+	}
+	if len(addedProps) != 1 || addedProps[0] != "text" {
+		t.Errorf("expected only the new property to be added, got %v", addedProps)
+	}
+}
+
+func TestWeaviateCountObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/v1/graphql" {
+			t.Errorf("unexpected request %s %s", req.Method, req.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"Aggregate":{"RagmeDocs":[{"meta":{"count":42}}]}}}`))
+	}))
+	defer server.Close()
+
+	vdb := &weaviateCollectionClient{httpClient: server.Client(), baseURL: server.URL}
+	collection := &ragmev1.RAGmeCollection{ObjectMeta: metav1.ObjectMeta{Name: "ragme-docs"}}
+	count, err := vdb.CountObjects(context.Background(), collection)
+	if err != nil {
+		t.Fatalf("CountObjects failed: %v", err)
+	}
+	if count != 42 {
+		t.Errorf("CountObjects() = %d, want 42", count)
+	}
+}
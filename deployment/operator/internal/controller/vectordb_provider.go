@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// errBackupUnsupported is returned by the providers of backends the operator
+// cannot snapshot, which are backed up by their own tooling
+var errBackupUnsupported = errors.New("backups are not supported by this vector database")
+
+// vectorDBProvider encapsulates the lifecycle of a vector database backend.
+// reconcileVectorDB drives the provider selected by spec.vectorDB, so adding a
+// backend means implementing this interface and returning it from
+// newVectorDBProvider.
+type vectorDBProvider interface {
+	// Name identifies the backend in logs and errors
+	Name() string
+
+	// Migrate moves the backend to the version requested by the spec
+	Migrate(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// Deploy creates or updates the resources the operator runs for the backend
+	Deploy(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// Bootstrap prepares the deployed backend for the services, e.g. publishes
+	// the shard layout
+	Bootstrap(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// HealthCheck returns an error unless the backend serves requests
+	HealthCheck(ctx context.Context, ragme *ragmev1.RAGme) error
+
+	// Backup starts a snapshot of the backend under the given id
+	Backup(ctx context.Context, ragme *ragmev1.RAGme, id string) error
+}
+
+// newVectorDBProvider returns the provider of the vector database configured
+// on the given RAGme instance
+func (r *RAGmeReconciler) newVectorDBProvider(ragme *ragmev1.RAGme) (vectorDBProvider, error) {
+	switch ragme.Spec.VectorDB.Type {
+	case "weaviate":
+		if ragme.Spec.VectorDB.Weaviate.Enabled {
+			return &weaviateProvider{r: r}, nil
+		}
+		return &externalProvider{r: r}, nil
+	case "milvus":
+		return &milvusProvider{r: r}, nil
+	default:
+		return nil, fmt.Errorf("unsupported vector database type %q", ragme.Spec.VectorDB.Type)
+	}
+}
+
+// weaviateProvider runs Weaviate in the namespace, one Deployment per shard
+type weaviateProvider struct {
+	r *RAGmeReconciler
+}
+
+// Name returns the name of the backend
+func (p *weaviateProvider) Name() string {
+	return "weaviate"
+}
+
+// Migrate runs the checked upgrade of a changed Weaviate version
+func (p *weaviateProvider) Migrate(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.reconcileWeaviateVersion(ctx, ragme)
+}
+
+// Deploy reconciles the Weaviate shards. Shards being added or retired are
+// kept running until the rebalance completes.
+func (p *weaviateProvider) Deploy(ctx context.Context, ragme *ragmev1.RAGme) error {
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		if err := p.r.reconcileWeaviate(ctx, ragme, shard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Bootstrap publishes the shard layout and rebalances the index
+func (p *weaviateProvider) Bootstrap(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.reconcileSharding(ctx, ragme)
+}
+
+// HealthCheck queries the readiness endpoint of every shard
+func (p *weaviateProvider) HealthCheck(ctx context.Context, ragme *ragmev1.RAGme) error {
+	httpClient := defaultHTTPClient(p.r.HTTPClient)
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		if _, err := doJSON(ctx, httpClient, http.MethodGet, weaviateURL(ragme, shard)+"/v1/.well-known/ready", "", nil, nil); err != nil {
+			return fmt.Errorf("shard %d: %w", shard, err)
+		}
+	}
+	return nil
+}
+
+// Backup snapshots every shard to its filesystem backup backend
+func (p *weaviateProvider) Backup(ctx context.Context, ragme *ragmev1.RAGme, id string) error {
+	return p.r.startWeaviateBackups(ctx, ragme, id)
+}
+
+// milvusProvider runs a standalone Milvus in the namespace, or connects the
+// services to an external Milvus cluster deployed outside of the operator
+type milvusProvider struct {
+	r *RAGmeReconciler
+}
+
+// Name returns the name of the backend
+func (p *milvusProvider) Name() string {
+	return "milvus"
+}
+
+// Migrate does nothing, the standalone Milvus is upgraded by rolling its
+// image and an external cluster is managed on its own
+func (p *milvusProvider) Migrate(context.Context, *ragmev1.RAGme) error {
+	return nil
+}
+
+// Deploy reconciles the standalone Milvus, an external cluster needs nothing
+func (p *milvusProvider) Deploy(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !milvusInCluster(ragme) {
+		return nil
+	}
+	return p.r.reconcileMilvus(ctx, ragme)
+}
+
+// Bootstrap publishes the shard layout, one collection per shard
+func (p *milvusProvider) Bootstrap(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.reconcileSharding(ctx, ragme)
+}
+
+// HealthCheck lists the collections through the v2 REST API
+func (p *milvusProvider) HealthCheck(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if milvusURI(ragme) == "" {
+		return fmt.Errorf("milvus URI is not configured on RAGme %s", ragme.Name)
+	}
+	client := &milvusCollectionClient{
+		httpClient: defaultHTTPClient(p.r.HTTPClient),
+		baseURL:    strings.TrimSuffix(milvusURI(ragme), "/"),
+		token:      ragme.Spec.VectorDB.Milvus.Token,
+	}
+	_, err := client.call(ctx, "/v2/vectordb/collections/list", map[string]interface{}{})
+	return err
+}
+
+// Backup is not supported, Milvus clusters are backed up with milvus-backup
+func (p *milvusProvider) Backup(context.Context, *ragmev1.RAGme, string) error {
+	return errBackupUnsupported
+}
+
+// externalProvider stands for a Weaviate the services reach on their own,
+// e.g. Weaviate Cloud. The operator neither deploys nor observes it.
+type externalProvider struct {
+	r *RAGmeReconciler
+}
+
+// Name returns the name of the backend
+func (p *externalProvider) Name() string {
+	return "external"
+}
+
+// Migrate does nothing, the version is managed by the provider of the database
+func (p *externalProvider) Migrate(context.Context, *ragmev1.RAGme) error {
+	return nil
+}
+
+// Deploy does nothing, the database runs outside of the namespace
+func (p *externalProvider) Deploy(context.Context, *ragmev1.RAGme) error {
+	return nil
+}
+
+// Bootstrap publishes the shard layout
+func (p *externalProvider) Bootstrap(ctx context.Context, ragme *ragmev1.RAGme) error {
+	return p.r.reconcileSharding(ctx, ragme)
+}
+
+// HealthCheck succeeds, the endpoint of the database is only known to the services
+func (p *externalProvider) HealthCheck(context.Context, *ragmev1.RAGme) error {
+	return nil
+}
+
+// Backup is not supported, the provider of the database backs it up
+func (p *externalProvider) Backup(context.Context, *ragmev1.RAGme, string) error {
+	return errBackupUnsupported
+}
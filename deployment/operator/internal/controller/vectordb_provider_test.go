@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestNewVectorDBProvider(t *testing.T) {
+	r := &RAGmeReconciler{}
+	for _, tc := range []struct {
+		vectorDB ragmev1.RAGmeVectorDB
+		want     string
+	}{
+		{ragmev1.RAGmeVectorDB{Type: "weaviate", Weaviate: ragmev1.RAGmeWeaviateDB{Enabled: true}}, "weaviate"},
+		{ragmev1.RAGmeVectorDB{Type: "weaviate"}, "external"},
+		{ragmev1.RAGmeVectorDB{Type: "milvus"}, "milvus"},
+	} {
+		provider, err := r.newVectorDBProvider(&ragmev1.RAGme{Spec: ragmev1.RAGmeSpec{VectorDB: tc.vectorDB}})
+		if err != nil || provider.Name() != tc.want {
+			t.Errorf("newVectorDBProvider(%+v) = %v, %v, want %s", tc.vectorDB, provider, err, tc.want)
+		}
+	}
+
+	if _, err := r.newVectorDBProvider(&ragmev1.RAGme{Spec: ragmev1.RAGmeSpec{VectorDB: ragmev1.RAGmeVectorDB{Type: "qdrant"}}}); err == nil {
+		t.Error("newVectorDBProvider() accepted an unsupported type")
+	}
+}
+
+func TestMilvusProviderHealthCheck(t *testing.T) {
+	code := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v2/vectordb/collections/list" || req.Header.Get("Authorization") != "Bearer root:Milvus" {
+			http.NotFound(w, req)
+			return
+		}
+		fmt.Fprintf(w, `{"code":%d,"message":"not ready"}`, code)
+	}))
+	defer server.Close()
+
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+	ragme.Spec.VectorDB = ragmev1.RAGmeVectorDB{Type: "milvus", Milvus: ragmev1.RAGmeMilvusDB{URI: server.URL + "/", Token: "root:Milvus"}}
+	provider := &milvusProvider{r: &RAGmeReconciler{HTTPClient: server.Client()}}
+
+	if err := provider.HealthCheck(context.Background(), ragme); err != nil {
+		t.Errorf("HealthCheck() error = %v", err)
+	}
+	code = 1
+	if err := provider.HealthCheck(context.Background(), ragme); err == nil {
+		t.Error("HealthCheck() accepted a failed response")
+	}
+	if err := provider.Backup(context.Background(), ragme, "backup"); !errors.Is(err, errBackupUnsupported) {
+		t.Errorf("Backup() error = %v, want %v", err, errBackupUnsupported)
+	}
+}
+
+func TestVectorDBProviderDeploysShards(t *testing.T) {
+	ragme := harnessRAGme("shards")
+	ragme.Spec.VectorDB.Sharding.Shards = 2
+	h := newReconcilerHarness(t, ragme)
+	ragme = h.get(t, "shards")
+
+	provider, err := h.reconciler.newVectorDBProvider(ragme)
+	if err != nil {
+		t.Fatalf("newVectorDBProvider() error = %v", err)
+	}
+	if err := provider.Deploy(h.ctx, ragme); err != nil {
+		t.Fatalf("Deploy() error = %v", err)
+	}
+	for _, name := range []string{"shards-weaviate", "shards-weaviate-1"} {
+		if err := h.client.Get(h.ctx, client.ObjectKey{Namespace: "bench", Name: name}, &appsv1.Deployment{}); err != nil {
+			t.Errorf("Deployment %s not created: %v", name, err)
+		}
+	}
+	if err := provider.HealthCheck(h.ctx, ragme); err == nil {
+		t.Error("HealthCheck() succeeded against offline shards")
+	}
+}
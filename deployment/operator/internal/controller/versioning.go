@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+const (
+	versionCleanupComponent = "version-cleanup"
+
+	defaultKeepVersions           = 3
+	defaultVersionCleanupSchedule = "30 3 * * *"
+
+	versionCleanupSucceeded = "Succeeded"
+	versionCleanupFailed    = "Failed"
+)
+
+// versioningConfig is the versioning stage of the processing pipeline
+type versioningConfig struct {
+	KeepVersions        int32 `json:"keepVersions"`
+	SupersedeOnSamePath bool  `json:"supersedeOnSamePath"`
+}
+
+// keepVersions returns the number of versions kept per document
+func keepVersions(ragme *ragmev1.RAGme) int32 {
+	if ragme.Spec.Processing.Versioning.KeepVersions > 0 {
+		return ragme.Spec.Processing.Versioning.KeepVersions
+	}
+	return defaultKeepVersions
+}
+
+// renderVersioningConfig returns the versioning stage of the processing
+// configuration, or nil when versioning is disabled
+func renderVersioningConfig(ragme *ragmev1.RAGme) *versioningConfig {
+	versioning := ragme.Spec.Processing.Versioning
+	if !versioning.Enabled {
+		return nil
+	}
+	return &versioningConfig{
+		KeepVersions:        keepVersions(ragme),
+		SupersedeOnSamePath: versioning.SupersedeOnSamePath == nil || *versioning.SupersedeOnSamePath,
+	}
+}
+
+// versionCleanupCronJobName returns the name of the version cleanup CronJob
+func versionCleanupCronJobName(ragme *ragmev1.RAGme) string {
+	return fmt.Sprintf("%s-%s", ragme.Name, versionCleanupComponent)
+}
+
+// reconcileVersionCleanup runs the CronJob pruning the superseded versions,
+// and deletes it when versioning is disabled
+func (r *RAGmeReconciler) reconcileVersionCleanup(ctx context.Context, ragme *ragmev1.RAGme) error {
+	cronJob := createVersionCleanupCronJob(ragme)
+	if !ragme.Spec.Processing.Versioning.Enabled {
+		ragme.Status.Versioning = ragmev1.RAGmeVersioningStatus{}
+		if err := r.Delete(ctx, cronJob, client.PropagationPolicy("Background")); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := r.setOwner(ragme, cronJob); err != nil {
+		return err
+	}
+	found := &batchv1.CronJob{}
+	err := r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		return r.Create(ctx, cronJob)
+	} else if err != nil {
+		return err
+	}
+	found.Spec = cronJob.Spec
+	found.Labels = mergeStringMaps(found.Labels, cronJob.Labels)
+	return r.Update(ctx, found)
+}
+
+// createVersionCleanupCronJob returns the CronJob pruning the superseded
+// versions. The cleanup asks the api, which owns the vector database and the
+// object storage, to delete the vectors and objects of the versions beyond
+// the kept ones, and writes the counts as a JSON object to its termination
+// message. Nothing is pruned while the instance hibernates or is under
+// maintenance.
+func createVersionCleanupCronJob(ragme *ragmev1.RAGme) *batchv1.CronJob {
+	labels := componentLabels(ragme, versionCleanupComponent)
+
+	schedule := ragme.Spec.Processing.Versioning.CleanupSchedule
+	if schedule == "" {
+		schedule = defaultVersionCleanupSchedule
+	}
+	suspend := ragme.Status.Hibernation.Hibernated || maintenanceReplicas(ragme, "agent", 1) == 0
+
+	succeeded, failed := jobHistoryLimits(ragme)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      versionCleanupCronJobName(ragme),
+			Namespace: ragme.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			Suspend:                    &suspend,
+			SuccessfulJobsHistoryLimit: &[]int32{int32(succeeded)}[0],
+			FailedJobsHistoryLimit:     &[]int32{int32(failed)}[0],
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:            &[]int32{1}[0],
+					TTLSecondsAfterFinished: jobTTL(ragme),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:            versionCleanupComponent,
+									Image:           fmt.Sprintf("%s/ragme-api:%s", ragme.Spec.Images.Registry, ragme.Spec.Images.Tag),
+									ImagePullPolicy: corev1.PullPolicy(ragme.Spec.Images.PullPolicy),
+									Command:         []string{"python", "-m", "src.ragme.version_cleanup"},
+									Env: []corev1.EnvVar{
+										{Name: "RAGME_API_URL", Value: fmt.Sprintf("http://%s-api:8021", ragme.Name)},
+										{Name: "VERSIONING_KEEP_VERSIONS", Value: strconv.Itoa(int(keepVersions(ragme)))},
+										{Name: "CLEANUP_STATS_FILE", Value: corev1.TerminationMessagePathDefault},
+									},
+									TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	applyPodAnnotations(ragme, &cronJob.Spec.JobTemplate.Spec.Template)
+	return cronJob
+}
+
+// checkVersionCleanup records the outcome of the last finished cleanup Job in status
+func (r *RAGmeReconciler) checkVersionCleanup(ctx context.Context, ragme *ragmev1.RAGme) error {
+	if !ragme.Spec.Processing.Versioning.Enabled {
+		return nil
+	}
+
+	job, err := r.lastFinishedJob(ctx, ragme, versionCleanupComponent)
+	if err != nil || job == nil || job.Name == ragme.Status.Versioning.Job {
+		return err
+	}
+	completedAt := metav1.Now()
+	if job.Status.CompletionTime != nil {
+		completedAt = *job.Status.CompletionTime
+	}
+	status := ragmev1.RAGmeVersioningStatus{Job: job.Name, Result: versionCleanupFailed, CompletedAt: &completedAt}
+	if jobFailed(job) {
+		ragme.Status.Versioning = status
+		return nil
+	}
+
+	status.Result = versionCleanupSucceeded
+	message, err := jobTerminationMessage(ctx, r, job, versionCleanupComponent)
+	if err == nil {
+		status.VectorsPruned, status.ObjectsPruned, err = parseVersionCleanupStats(message)
+	}
+	// The counts are lost with the pod, the cleanup is still recorded
+	ragme.Status.Versioning = status
+	if err != nil {
+		return fmt.Errorf("failed to read the counts of job %s: %w", job.Name, err)
+	}
+	return nil
+}
+
+// parseVersionCleanupStats parses the vectors and objects a cleanup pruned
+func parseVersionCleanupStats(message string) (vectors, objects int64, err error) {
+	report := struct {
+		VectorsPruned int64 `json:"vectorsPruned"`
+		ObjectsPruned int64 `json:"objectsPruned"`
+	}{}
+	if err := json.Unmarshal([]byte(message), &report); err != nil {
+		return 0, 0, fmt.Errorf("invalid cleanup stats %q: %w", message, err)
+	}
+	return report.VectorsPruned, report.ObjectsPruned, nil
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestRenderVersioningConfig(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if config := renderProcessingConfig(ragme); config.Versioning != nil {
+		t.Errorf("versioning = %+v, want none while disabled", config.Versioning)
+	}
+
+	ragme.Spec.Processing.Versioning = ragmev1.RAGmeVersioning{Enabled: true}
+	if config := renderVersioningConfig(ragme); config.KeepVersions != defaultKeepVersions || !config.SupersedeOnSamePath {
+		t.Errorf("versioning = %+v, want the defaults", config)
+	}
+
+	supersede := false
+	ragme.Spec.Processing.Versioning = ragmev1.RAGmeVersioning{Enabled: true, KeepVersions: 1, SupersedeOnSamePath: &supersede}
+	if config := renderVersioningConfig(ragme); config.KeepVersions != 1 || config.SupersedeOnSamePath {
+		t.Errorf("versioning = %+v, want the configured policy", config)
+	}
+}
+
+func TestCreateVersionCleanupCronJob(t *testing.T) {
+	ragme := &ragmev1.RAGme{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ragme"}}
+	ragme.Spec.Processing.Versioning = ragmev1.RAGmeVersioning{Enabled: true, KeepVersions: 2}
+
+	cronJob := createVersionCleanupCronJob(ragme)
+	if cronJob.Name != "test-version-cleanup" || cronJob.Spec.Schedule != defaultVersionCleanupSchedule || *cronJob.Spec.Suspend {
+		t.Errorf("CronJob %s %q suspended=%v, want test-version-cleanup on the default schedule", cronJob.Name, cronJob.Spec.Schedule, *cronJob.Spec.Suspend)
+	}
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	if got := envValue(container.Env, "VERSIONING_KEEP_VERSIONS"); got != "2" {
+		t.Errorf("VERSIONING_KEEP_VERSIONS = %q, want 2", got)
+	}
+
+	ragme.Spec.MaintenanceMode = maintenanceReadOnly
+	if cronJob := createVersionCleanupCronJob(ragme); !*cronJob.Spec.Suspend {
+		t.Error("expected the cleanup to be suspended during maintenance")
+	}
+}
+
+func TestValidateSpecVersioning(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Processing.Versioning = ragmev1.RAGmeVersioning{Enabled: true, KeepVersions: -1, CleanupSchedule: "nightly"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a negative version count and an invalid schedule")
+	}
+	ragme.Spec.Processing.Versioning = ragmev1.RAGmeVersioning{Enabled: true, KeepVersions: 5, CleanupSchedule: "0 1 * * 0"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
+
+func TestParseVersionCleanupStats(t *testing.T) {
+	vectors, objects, err := parseVersionCleanupStats(`{"vectorsPruned":1834,"objectsPruned":57}`)
+	if err != nil || vectors != 1834 || objects != 57 {
+		t.Errorf("parseVersionCleanupStats() = %d, %d, %v, want 1834, 57", vectors, objects, err)
+	}
+	if _, _, err := parseVersionCleanupStats("done"); err == nil {
+		t.Error("parseVersionCleanupStats() accepted a message that is not JSON")
+	}
+}
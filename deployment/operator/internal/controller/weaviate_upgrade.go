@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+	"github.com/maximilien/ragme-io/operator/internal/conditions"
+)
+
+const (
+	defaultWeaviateVersion = "1.25.0"
+	weaviateImageRepo      = "cr.weaviate.io/semitechnologies/weaviate"
+	weaviateBackupBackend  = "filesystem"
+	weaviateBackupPath     = "/var/lib/weaviate/backups"
+
+	weaviatePhaseSnapshotting = "Snapshotting"
+	weaviatePhaseRolling      = "Rolling"
+	weaviatePhaseVerifying    = "Verifying"
+)
+
+// parseWeaviateVersion parses a major.minor.patch version
+func parseWeaviateVersion(version string) ([3]int, error) {
+	var parsed [3]int
+	parts := strings.Split(version, ".")
+	if len(parts) != 3 {
+		return parsed, fmt.Errorf("version %q is not of the form major.minor.patch", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, fmt.Errorf("version %q is not of the form major.minor.patch", version)
+		}
+		parsed[i] = n
+	}
+	return parsed, nil
+}
+
+// checkWeaviateUpgrade reports whether Weaviate supports upgrading in place
+// from one version to another. Weaviate migrates its on-disk format one minor
+// version at a time, so neither downgrades nor skipped major or minor
+// versions are allowed. Patch releases can be applied freely.
+func checkWeaviateUpgrade(from, to string) error {
+	f, err := parseWeaviateVersion(from)
+	if err != nil {
+		return err
+	}
+	t, err := parseWeaviateVersion(to)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case t[0] < f[0] || (t[0] == f[0] && t[1] < f[1]) || (t[0] == f[0] && t[1] == f[1] && t[2] < f[2]):
+		return fmt.Errorf("downgrading Weaviate from %s to %s is not supported", from, to)
+	case t[0] > f[0]+1:
+		return fmt.Errorf("upgrading Weaviate from %s to %s skips a major version", from, to)
+	case t[0] == f[0]+1 && t[1] != 0:
+		return fmt.Errorf("upgrading Weaviate from %s to %s must go through %d.0 first", from, to, t[0])
+	case t[0] == f[0] && t[1] > f[1]+1:
+		return fmt.Errorf("upgrading Weaviate from %s to %s skips a minor version, upgrade to %d.%d first", from, to, f[0], f[1]+1)
+	}
+	return nil
+}
+
+// weaviateVersion returns the version the Weaviate deployments run. The
+// previous version keeps running until the pre-upgrade snapshot is complete.
+func weaviateVersion(ragme *ragmev1.RAGme) string {
+	status := ragme.Status.Weaviate
+	switch {
+	case status.UpgradePhase == weaviatePhaseRolling || status.UpgradePhase == weaviatePhaseVerifying:
+		return status.UpgradeTo
+	case status.Version != "":
+		return status.Version
+	}
+	return ragme.Spec.VectorDB.Weaviate.Version
+}
+
+// weaviateURL returns the in-cluster URL of a Weaviate shard
+func weaviateURL(ragme *ragmev1.RAGme, shard int32) string {
+	return fmt.Sprintf("http://%s.%s.svc:8080", weaviateShardName(ragme, shard), ragme.Namespace)
+}
+
+// weaviateUpgradeInProgress reports whether a Weaviate upgrade must complete
+// before the instance is marked Ready, along with a progress message
+func weaviateUpgradeInProgress(ragme *ragmev1.RAGme) (string, bool) {
+	status := ragme.Status.Weaviate
+	if status.UpgradeTo == "" {
+		return "", false
+	}
+	return fmt.Sprintf("Upgrading Weaviate from %s to %s (%s)", status.Version, status.UpgradeTo, status.UpgradePhase), true
+}
+
+// reconcileWeaviateVersion drives a Weaviate version change: the upgrade is
+// checked against the supported upgrade paths, every shard is snapshotted,
+// the deployments are rolled and a verification query must succeed before the
+// new version is recorded.
+func (r *RAGmeReconciler) reconcileWeaviateVersion(ctx context.Context, ragme *ragmev1.RAGme) error {
+	desired := ragme.Spec.VectorDB.Weaviate.Version
+	status := &ragme.Status.Weaviate
+
+	if status.Version == "" {
+		version, err := r.deployedWeaviateVersion(ctx, ragme)
+		if err != nil {
+			return err
+		}
+		if version == "" {
+			// Fresh install, nothing to upgrade
+			version = desired
+		}
+		status.Version = version
+	}
+
+	if status.UpgradeTo == "" {
+		if desired == status.Version {
+			if c := meta.FindStatusCondition(ragme.Status.Conditions, conditions.TypeWeaviateUpgraded); c != nil && c.Reason == conditions.ReasonIncompatibleVersion {
+				conditions.Remove(&ragme.Status.Conditions, conditions.TypeWeaviateUpgraded)
+			}
+			return nil
+		}
+		if err := checkWeaviateUpgrade(status.Version, desired); err != nil {
+			setWeaviateCondition(ragme, metav1.ConditionFalse, conditions.ReasonIncompatibleVersion,
+				fmt.Sprintf("%v, keeping %s", err, status.Version))
+			return nil
+		}
+		backupID := fmt.Sprintf("pre-upgrade-%s-to-%s-%d",
+			strings.ReplaceAll(status.Version, ".", "-"), strings.ReplaceAll(desired, ".", "-"), r.now().Unix())
+		if err := r.startWeaviateBackups(ctx, ragme, backupID); err != nil {
+			return err
+		}
+		status.UpgradeTo = desired
+		status.UpgradePhase = weaviatePhaseSnapshotting
+		status.BackupID = backupID
+		setWeaviateCondition(ragme, metav1.ConditionUnknown, conditions.ReasonUpgrading,
+			fmt.Sprintf("Snapshotting Weaviate before upgrading to %s", desired))
+		return nil
+	}
+
+	switch status.UpgradePhase {
+	case weaviatePhaseSnapshotting:
+		if desired != status.UpgradeTo {
+			// The target changed before any pod was rolled, start over
+			*status = ragmev1.RAGmeWeaviateStatus{Version: status.Version}
+			return nil
+		}
+		done, err := r.weaviateBackupsDone(ctx, ragme)
+		if err != nil {
+			setWeaviateCondition(ragme, metav1.ConditionFalse, conditions.ReasonBackupFailed,
+				fmt.Sprintf("Snapshot %s failed, the upgrade will be retried: %v", status.BackupID, err))
+			*status = ragmev1.RAGmeWeaviateStatus{Version: status.Version}
+			return nil
+		}
+		if done {
+			status.UpgradePhase = weaviatePhaseRolling
+			setWeaviateCondition(ragme, metav1.ConditionUnknown, conditions.ReasonUpgrading,
+				fmt.Sprintf("Rolling Weaviate to %s, snapshot %s", status.UpgradeTo, status.BackupID))
+		}
+	case weaviatePhaseRolling:
+		rolled, err := r.weaviateRolledOut(ctx, ragme)
+		if err != nil {
+			return err
+		}
+		if rolled {
+			status.UpgradePhase = weaviatePhaseVerifying
+		}
+	case weaviatePhaseVerifying:
+		if err := r.verifyWeaviate(ctx, ragme, status.UpgradeTo); err != nil {
+			setWeaviateCondition(ragme, metav1.ConditionFalse, conditions.ReasonVerificationFailed,
+				fmt.Sprintf("Weaviate %s failed verification, snapshot %s can be restored: %v", status.UpgradeTo, status.BackupID, err))
+			return nil
+		}
+		log.FromContext(ctx).Info("Weaviate upgraded", "from", status.Version, "to", status.UpgradeTo)
+		setWeaviateCondition(ragme, metav1.ConditionTrue, conditions.ReasonUpgradeSucceeded,
+			fmt.Sprintf("Upgraded Weaviate from %s to %s, snapshot %s", status.Version, status.UpgradeTo, status.BackupID))
+		*status = ragmev1.RAGmeWeaviateStatus{Version: status.UpgradeTo, BackupID: status.BackupID}
+	}
+	return nil
+}
+
+// deployedWeaviateVersion returns the version of an existing Weaviate
+// deployment, or an empty string when Weaviate has not been deployed yet
+func (r *RAGmeReconciler) deployedWeaviateVersion(ctx context.Context, ragme *ragmev1.RAGme) (string, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: weaviateShardName(ragme, 0), Namespace: ragme.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == "weaviate" {
+			if i := strings.LastIndex(container.Image, ":"); i >= 0 {
+				return container.Image[i+1:], nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// startWeaviateBackups starts the pre-upgrade snapshot on every shard
+func (r *RAGmeReconciler) startWeaviateBackups(ctx context.Context, ragme *ragmev1.RAGme, backupID string) error {
+	httpClient := defaultHTTPClient(r.HTTPClient)
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		url := fmt.Sprintf("%s/v1/backups/%s", weaviateURL(ragme, shard), weaviateBackupBackend)
+		body := map[string]string{"id": backupID}
+		if _, err := doJSON(ctx, httpClient, http.MethodPost, url, "", body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// weaviateBackupsDone reports whether the snapshots of all shards completed
+func (r *RAGmeReconciler) weaviateBackupsDone(ctx context.Context, ragme *ragmev1.RAGme) (bool, error) {
+	httpClient := defaultHTTPClient(r.HTTPClient)
+	done := true
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		url := fmt.Sprintf("%s/v1/backups/%s/%s", weaviateURL(ragme, shard), weaviateBackupBackend, ragme.Status.Weaviate.BackupID)
+		backup := struct {
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		}{}
+		if _, err := doJSON(ctx, httpClient, http.MethodGet, url, "", nil, &backup); err != nil {
+			return false, err
+		}
+		switch backup.Status {
+		case "SUCCESS":
+		case "FAILED":
+			return false, fmt.Errorf("shard %d: %s", shard, backup.Error)
+		default:
+			done = false
+		}
+	}
+	return done, nil
+}
+
+// weaviateRolledOut reports whether every Weaviate deployment runs the upgraded pods
+func (r *RAGmeReconciler) weaviateRolledOut(ctx context.Context, ragme *ragmev1.RAGme) (bool, error) {
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Name: weaviateShardName(ragme, shard), Namespace: ragme.Namespace}, deployment); err != nil {
+			return false, err
+		}
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		if deployment.Status.ObservedGeneration < deployment.Generation ||
+			deployment.Status.UpdatedReplicas != replicas ||
+			deployment.Status.AvailableReplicas != replicas {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// verifyWeaviate checks that every shard reports the expected version and
+// serves object queries
+func (r *RAGmeReconciler) verifyWeaviate(ctx context.Context, ragme *ragmev1.RAGme, version string) error {
+	httpClient := defaultHTTPClient(r.HTTPClient)
+	for shard := int32(0); shard < deployedShards(ragme); shard++ {
+		baseURL := weaviateURL(ragme, shard)
+		meta := struct {
+			Version string `json:"version"`
+		}{}
+		if _, err := doJSON(ctx, httpClient, http.MethodGet, baseURL+"/v1/meta", "", nil, &meta); err != nil {
+			return err
+		}
+		if meta.Version != version {
+			return fmt.Errorf("shard %d reports version %s", shard, meta.Version)
+		}
+		if _, err := doJSON(ctx, httpClient, http.MethodGet, baseURL+"/v1/objects?limit=1", "", nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setWeaviateCondition sets the WeaviateUpgraded condition
+func setWeaviateCondition(ragme *ragmev1.RAGme, status metav1.ConditionStatus, reason, message string) {
+	conditions.Set(&ragme.Status.Conditions, ragme.Generation, conditions.TypeWeaviateUpgraded, status, reason, message)
+}
@@ -0,0 +1,30 @@
+package controller
+
+import "testing"
+
+func TestCheckWeaviateUpgrade(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to string
+		wantErr  bool
+	}{
+		{name: "patch release", from: "1.25.0", to: "1.25.4"},
+		{name: "next minor", from: "1.25.4", to: "1.26.0"},
+		{name: "next major", from: "1.26.3", to: "2.0.0"},
+		{name: "skipped minor", from: "1.24.0", to: "1.26.0", wantErr: true},
+		{name: "skipped major", from: "1.25.0", to: "3.0.0", wantErr: true},
+		{name: "major without x.0", from: "1.26.0", to: "2.1.0", wantErr: true},
+		{name: "minor downgrade", from: "1.25.0", to: "1.24.9", wantErr: true},
+		{name: "patch downgrade", from: "1.25.2", to: "1.25.1", wantErr: true},
+		{name: "malformed version", from: "1.25.0", to: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkWeaviateUpgrade(tt.from, tt.to)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkWeaviateUpgrade(%s, %s) error = %v, wantErr %v", tt.from, tt.to, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// +kubebuilder:webhook:path=/mutate-ragme-io-v1-ragme,mutating=true,failurePolicy=fail,sideEffects=None,groups=ragme.io,resources=ragmes,verbs=create;update,versions=v1,name=mragme.ragme.io,admissionReviewVersions=v1
+
+// RAGmeDefaulter writes the defaults of the operator configuration and the
+// built-in ones into the stored RAGme, so that the effective spec shows in
+// kubectl and GitOps tools. The reconciler still defaults the instances
+// admitted without the webhook in memory.
+type RAGmeDefaulter struct {
+	// Config supplies the organization-wide defaults
+	Config *OperatorConfigSource
+}
+
+// SetupWebhookWithManager registers the defaulting webhook with the manager
+func (d *RAGmeDefaulter) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ragmev1.RAGme{}).
+		WithDefaulter(d).
+		Complete()
+}
+
+// Default fills in the unset fields of a created or updated RAGme
+func (d *RAGmeDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	ragme, ok := obj.(*ragmev1.RAGme)
+	if !ok {
+		return fmt.Errorf("expected a RAGme, got %T", obj)
+	}
+	config, err := d.Config.Load(ctx)
+	if err != nil {
+		return err
+	}
+	config.applyDefaults(ragme)
+	(&RAGmeReconciler{}).setDefaults(ragme)
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-ragme-io-v1-ragme,mutating=false,failurePolicy=fail,sideEffects=None,groups=ragme.io,resources=ragmes,verbs=create;update,versions=v1,name=vragme.ragme.io,admissionReviewVersions=v1
+
+// RAGmeValidator rejects RAGme specs the operator cannot apply at admission,
+// instead of reporting them later through the SpecValid condition
+type RAGmeValidator struct {
+	// Config supplies the cluster policies enforced along with the spec checks
+	Config *OperatorConfigSource
+}
+
+// SetupWebhookWithManager registers the validating webhook with the manager
+func (v *RAGmeValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&ragmev1.RAGme{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates a new RAGme
+func (v *RAGmeValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, obj)
+}
+
+// ValidateUpdate validates the new version of an updated RAGme
+func (v *RAGmeValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return v.validate(ctx, newObj)
+}
+
+// ValidateDelete accepts every deletion
+func (v *RAGmeValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate checks the defaulted spec the way the reconciler does
+func (v *RAGmeValidator) validate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ragme, ok := obj.(*ragmev1.RAGme)
+	if !ok {
+		return nil, fmt.Errorf("expected a RAGme, got %T", obj)
+	}
+	config, err := v.Config.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var warnings admission.Warnings
+	var violated []error
+	for _, violation := range evaluatePolicies(config.Policy, ragme) {
+		if config.Policy.Mode == policyModeEnforce {
+			violated = append(violated, fmt.Errorf("%s", violation))
+		} else {
+			warnings = append(warnings, violation.String())
+		}
+	}
+
+	ragme = ragme.DeepCopy()
+	config.applyDefaults(ragme)
+	(&RAGmeReconciler{}).setDefaults(ragme)
+	setTransientDefaults(ragme)
+	return warnings, utilerrors.NewAggregate(append([]error{validateSpec(ragme), config.validate(ragme)}, violated...))
+}
@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+func TestRAGmeDefaulter(t *testing.T) {
+	ragme := harnessRAGme("defaults")
+	ragme.Spec.Images.Registry = ""
+	ragme.Spec.Replicas.API = 0
+	ragme.Spec.VectorDB.Weaviate.Version = ""
+	h := newReconcilerHarness(t)
+	config := withOperatorConfig(t, h, testOperatorConfig)
+
+	if err := (&RAGmeDefaulter{Config: config}).Default(h.ctx, ragme); err != nil {
+		t.Fatal(err)
+	}
+	if ragme.Spec.Images.Registry != "mirror.example.com/ragme" {
+		t.Errorf("registry = %q, want the mirror of the operator configuration", ragme.Spec.Images.Registry)
+	}
+	if ragme.Spec.Replicas.API != 2 || ragme.Spec.VectorDB.Weaviate.Version != defaultWeaviateVersion {
+		t.Errorf("api replicas %d, weaviate %q, want the built-in defaults", ragme.Spec.Replicas.API, ragme.Spec.VectorDB.Weaviate.Version)
+	}
+	if key := ragme.Spec.Authentication.Session.SecretKey; key != "" {
+		t.Errorf("session secret key = %q, want the shared key left out of the stored spec", key)
+	}
+
+	// Defaulting an already defaulted RAGme changes nothing
+	defaulted := ragme.DeepCopy()
+	if err := (&RAGmeDefaulter{Config: config}).Default(h.ctx, ragme); err != nil {
+		t.Fatal(err)
+	}
+	if !equality.Semantic.DeepEqual(ragme.Spec, defaulted.Spec) {
+		t.Error("defaulting a defaulted RAGme changed its spec")
+	}
+}
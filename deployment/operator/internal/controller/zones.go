@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+// +kubebuilder:rbac:groups="",resources=persistentvolumes,verbs=get;list;watch
+
+const (
+	defaultZoneLabel = "topology.kubernetes.io/zone"
+	// legacyZoneLabel is still set on the volumes of older provisioners
+	legacyZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// zoneLabel returns the node label holding the zone
+func zoneLabel(ragme *ragmev1.RAGme) string {
+	if ragme.Spec.Topology.ZoneLabel != "" {
+		return ragme.Spec.Topology.ZoneLabel
+	}
+	return defaultZoneLabel
+}
+
+// placementZones returns the zones a data service may run in, or nil when
+// the instance has no zones. In spread mode Weaviate shard i runs in zone
+// i mod n and MinIO in the last zone, so the placement of a shard does not
+// move when shards are added.
+func placementZones(ragme *ragmev1.RAGme, component string, shard int32) []string {
+	zones := ragme.Spec.Topology.Zones
+	if len(zones) == 0 || ragme.Spec.Topology.Mode == "pin" {
+		return zones
+	}
+	if component == "minio" {
+		return []string{zones[len(zones)-1]}
+	}
+	return []string{zones[int(shard)%len(zones)]}
+}
+
+// applyZonePlacement requires the pods to be scheduled on nodes in the zones
+func applyZonePlacement(label string, zones []string, podSpec *corev1.PodSpec) {
+	if len(zones) == 0 {
+		return
+	}
+	podSpec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{
+						Key:      label,
+						Operator: corev1.NodeSelectorOpIn,
+						Values:   zones,
+					}},
+				}},
+			},
+		},
+	}
+}
+
+// pvZone returns the zone a volume is bound to by its node affinity or its
+// legacy zone label, or "" when it can be attached in any zone
+func pvZone(pv *corev1.PersistentVolume, label string) string {
+	if affinity := pv.Spec.NodeAffinity; affinity != nil && affinity.Required != nil {
+		for _, term := range affinity.Required.NodeSelectorTerms {
+			for _, expression := range term.MatchExpressions {
+				if (expression.Key == label || expression.Key == legacyZoneLabel) &&
+					expression.Operator == corev1.NodeSelectorOpIn && len(expression.Values) == 1 {
+					return expression.Values[0]
+				}
+			}
+		}
+	}
+	return pv.Labels[legacyZoneLabel]
+}
+
+// volumeZones returns the zones of the pods of a data service. A volume that
+// was provisioned in another zone, before the topology was set or changed,
+// cannot follow its pods, so the pods follow the volume instead.
+func (r *RAGmeReconciler) volumeZones(ctx context.Context, ragme *ragmev1.RAGme, pvc *corev1.PersistentVolumeClaim, zones []string) ([]string, error) {
+	if len(zones) == 0 || pvc.Spec.VolumeName == "" {
+		return zones, nil
+	}
+	pv := &corev1.PersistentVolume{}
+	if err := r.Get(ctx, types.NamespacedName{Name: pvc.Spec.VolumeName}, pv); err != nil {
+		if errors.IsNotFound(err) {
+			return zones, nil
+		}
+		return nil, err
+	}
+	zone := pvZone(pv, zoneLabel(ragme))
+	if zone == "" {
+		return zones, nil
+	}
+	for _, z := range zones {
+		if z == zone {
+			return zones, nil
+		}
+	}
+	log.FromContext(ctx).Info("Volume is outside the requested zones, keeping its pods in its zone",
+		"pvc", pvc.Name, "zone", zone, "zones", zones)
+	return []string{zone}, nil
+}
+
+// checkStorageClassTopology reports whether a StorageClass can provision
+// volumes in the zones: either binding waits for the pod to be scheduled, or
+// its allowed topologies cover every zone
+func checkStorageClassTopology(class *storagev1.StorageClass, label string, zones []string) error {
+	if class.VolumeBindingMode != nil && *class.VolumeBindingMode == storagev1.VolumeBindingWaitForFirstConsumer {
+		return nil
+	}
+	if len(class.AllowedTopologies) == 0 {
+		return fmt.Errorf("StorageClass %s binds volumes immediately in any zone, use volumeBindingMode WaitForFirstConsumer or allowedTopologies", class.Name)
+	}
+	allowed := map[string]bool{}
+	for _, term := range class.AllowedTopologies {
+		for _, expression := range term.MatchLabelExpressions {
+			if expression.Key == label || expression.Key == legacyZoneLabel {
+				for _, value := range expression.Values {
+					allowed[value] = true
+				}
+			}
+		}
+	}
+	var missing []string
+	for _, zone := range zones {
+		if !allowed[zone] {
+			missing = append(missing, zone)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("StorageClass %s does not allow zones %s", class.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// reconcileZoneTopology checks that the requested zones have nodes and that
+// the StorageClass of the data services can provision volumes in them
+func (r *RAGmeReconciler) reconcileZoneTopology(ctx context.Context, ragme *ragmev1.RAGme) error {
+	zones := ragme.Spec.Topology.Zones
+	if len(zones) == 0 {
+		return nil
+	}
+	label := zoneLabel(ragme)
+
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return err
+	}
+	scheduled := map[string]bool{}
+	for _, node := range nodes.Items {
+		scheduled[node.Labels[label]] = true
+	}
+	var empty []string
+	for _, zone := range zones {
+		if !scheduled[zone] {
+			empty = append(empty, zone)
+		}
+	}
+	if len(empty) > 0 {
+		return fmt.Errorf("no node is labeled %s in zones %s", label, strings.Join(empty, ", "))
+	}
+
+	name := ragme.Status.Classes.StorageClass
+	if name == "" {
+		return nil
+	}
+	class := &storagev1.StorageClass{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, class); err != nil {
+		return err
+	}
+	return checkStorageClassTopology(class, label, zones)
+}
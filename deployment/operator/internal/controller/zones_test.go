@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ragmev1 "github.com/maximilien/ragme-io/operator/api/v1"
+)
+
+func TestPlacementZones(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	if zones := placementZones(ragme, "weaviate", 0); zones != nil {
+		t.Errorf("zones = %v without a topology, want none", zones)
+	}
+
+	ragme.Spec.Topology.Zones = []string{"a", "b", "c"}
+	for shard, want := range []string{"a", "b", "c", "a"} {
+		if zones := placementZones(ragme, "weaviate", int32(shard)); len(zones) != 1 || zones[0] != want {
+			t.Errorf("shard %d zones = %v, want %s", shard, zones, want)
+		}
+	}
+	if zones := placementZones(ragme, "minio", 0); len(zones) != 1 || zones[0] != "c" {
+		t.Errorf("MinIO zones = %v, want the last zone", zones)
+	}
+
+	ragme.Spec.Topology.Mode = "pin"
+	if zones := placementZones(ragme, "minio", 0); len(zones) != 3 {
+		t.Errorf("pinned zones = %v, want every zone", zones)
+	}
+
+	podSpec := &corev1.PodSpec{}
+	applyZonePlacement(zoneLabel(ragme), []string{"a"}, podSpec)
+	term := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0]
+	if expression := term.MatchExpressions[0]; expression.Key != defaultZoneLabel || expression.Values[0] != "a" {
+		t.Errorf("node affinity = %+v, want the zone label in a", expression)
+	}
+}
+
+func TestPVZone(t *testing.T) {
+	pv := &corev1.PersistentVolume{}
+	if zone := pvZone(pv, defaultZoneLabel); zone != "" {
+		t.Errorf("zone = %q for a volume without topology", zone)
+	}
+	pv.Labels = map[string]string{legacyZoneLabel: "us-east-1a"}
+	if zone := pvZone(pv, defaultZoneLabel); zone != "us-east-1a" {
+		t.Errorf("zone = %q, want the legacy label", zone)
+	}
+	pv.Spec.NodeAffinity = &corev1.VolumeNodeAffinity{Required: &corev1.NodeSelector{
+		NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key: defaultZoneLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"us-east-1b"},
+		}}}},
+	}}
+	if zone := pvZone(pv, defaultZoneLabel); zone != "us-east-1b" {
+		t.Errorf("zone = %q, want the node affinity", zone)
+	}
+}
+
+func TestCheckStorageClassTopology(t *testing.T) {
+	zones := []string{"a", "b"}
+	class := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "standard"}}
+	if err := checkStorageClassTopology(class, defaultZoneLabel, zones); err == nil {
+		t.Error("accepted a class binding immediately in any zone")
+	}
+
+	class.AllowedTopologies = []corev1.TopologySelectorTerm{{MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{{
+		Key: defaultZoneLabel, Values: []string{"a"},
+	}}}}
+	if err := checkStorageClassTopology(class, defaultZoneLabel, zones); err == nil {
+		t.Error("accepted a class not allowing zone b")
+	}
+	class.AllowedTopologies[0].MatchLabelExpressions[0].Values = zones
+	if err := checkStorageClassTopology(class, defaultZoneLabel, zones); err != nil {
+		t.Errorf("checkStorageClassTopology() error = %v", err)
+	}
+
+	waitForConsumer := storagev1.VolumeBindingWaitForFirstConsumer
+	class = &storagev1.StorageClass{VolumeBindingMode: &waitForConsumer}
+	if err := checkStorageClassTopology(class, defaultZoneLabel, zones); err != nil {
+		t.Errorf("checkStorageClassTopology() error = %v for WaitForFirstConsumer", err)
+	}
+}
+
+func TestValidateSpecTopology(t *testing.T) {
+	ragme := &ragmev1.RAGme{}
+	ragme.Spec.Topology = ragmev1.RAGmeTopology{Zones: []string{"a", "a"}, Mode: "random"}
+	if err := validateSpec(ragme); err == nil {
+		t.Error("validateSpec() accepted a duplicate zone and an unknown mode")
+	}
+	ragme.Spec.Topology = ragmev1.RAGmeTopology{Zones: []string{"a", "b"}, Mode: "spread"}
+	if err := validateSpec(ragme); err != nil {
+		t.Errorf("validateSpec() error = %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package health assesses the health of RAGme resources the way GitOps tools
+// do. Check mirrors the Argo CD Lua health check shipped in config/argocd, so
+// that tooling written in Go and Argo CD agree on the state of a resource.
+package health
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Status is the health status of a resource, using the Argo CD vocabulary
+type Status string
+
+const (
+	// Healthy: the resource is fully reconciled
+	Healthy Status = "Healthy"
+	// Progressing: the operator has not yet converged on the latest spec
+	Progressing Status = "Progressing"
+	// Degraded: the resource failed to reconcile or cannot progress
+	Degraded Status = "Degraded"
+)
+
+// Result is the outcome of a health check
+type Result struct {
+	Status  Status
+	Message string
+}
+
+// Check returns the health of a RAGme, RAGmeCollection or RAGmeTenant
+func Check(obj *unstructured.Unstructured) (Result, error) {
+	status := struct {
+		ObservedGeneration int64              `json:"observedGeneration,omitempty"`
+		Conditions         []metav1.Condition `json:"conditions,omitempty"`
+	}{}
+	if raw, ok := obj.Object["status"].(map[string]interface{}); ok {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &status); err != nil {
+			return Result{}, fmt.Errorf("failed to decode status of %s: %w", obj.GetName(), err)
+		}
+	}
+
+	condition := func(conditionType string) *metav1.Condition {
+		for i := range status.Conditions {
+			if status.Conditions[i].Type == conditionType {
+				return &status.Conditions[i]
+			}
+		}
+		return nil
+	}
+	isTrue := func(c *metav1.Condition) bool {
+		return c != nil && c.Status == metav1.ConditionTrue
+	}
+
+	if obj.GetGeneration() > status.ObservedGeneration {
+		return Result{Progressing, "Waiting for the operator to observe the latest spec"}, nil
+	}
+	if c := condition("Stalled"); isTrue(c) {
+		return Result{Degraded, c.Message}, nil
+	}
+	if c := condition("Degraded"); isTrue(c) {
+		return Result{Degraded, c.Message}, nil
+	}
+	if c := condition("Reconciling"); isTrue(c) {
+		return Result{Progressing, c.Message}, nil
+	}
+	if c := condition("Ready"); isTrue(c) {
+		return Result{Healthy, c.Message}, nil
+	}
+	return Result{Progressing, "Waiting for the Ready condition"}, nil
+}
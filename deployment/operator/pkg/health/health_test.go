@@ -0,0 +1,100 @@
+package health
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func ragme(generation int64, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "ragme.io/v1",
+		"kind":       "RAGme",
+		"metadata":   map[string]interface{}{"name": "ragme", "generation": generation},
+	}}
+	if status != nil {
+		obj.Object["status"] = status
+	}
+	return obj
+}
+
+func condition(conditionType, status, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":               conditionType,
+		"status":             status,
+		"reason":             "Test",
+		"message":            message,
+		"lastTransitionTime": "2025-01-01T00:00:00Z",
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  *unstructured.Unstructured
+		want Status
+	}{
+		{name: "no status", obj: ragme(1, nil), want: Progressing},
+		{
+			name: "new generation not observed",
+			obj: ragme(2, map[string]interface{}{
+				"observedGeneration": int64(1),
+				"conditions":         []interface{}{condition("Ready", "True", "done")},
+			}),
+			want: Progressing,
+		},
+		{
+			name: "ready",
+			obj: ragme(2, map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions":         []interface{}{condition("Ready", "True", "done")},
+			}),
+			want: Healthy,
+		},
+		{
+			name: "reconciling",
+			obj: ragme(2, map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions": []interface{}{
+					condition("Ready", "True", "done"),
+					condition("Reconciling", "True", "applying"),
+				},
+			}),
+			want: Progressing,
+		},
+		{
+			name: "degraded",
+			obj: ragme(2, map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions": []interface{}{
+					condition("Ready", "False", "boom"),
+					condition("Degraded", "True", "boom"),
+				},
+			}),
+			want: Degraded,
+		},
+		{
+			name: "stalled",
+			obj: ragme(3, map[string]interface{}{
+				"observedGeneration": int64(3),
+				"conditions": []interface{}{
+					condition("Ready", "False", "invalid"),
+					condition("Stalled", "True", "invalid"),
+				},
+			}),
+			want: Degraded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Check(tt.obj)
+			if err != nil {
+				t.Fatalf("Check() failed: %v", err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("Check() = %s (%s), want %s", got.Status, got.Message, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// The e2e suite runs against the cluster of the current kubeconfig, with the
+// CRDs installed and the operator running (make install deploy).
+func TestE2E(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "RAGme Operator E2E Suite")
+}
@@ -0,0 +1,113 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	"github.com/maximilien/ragme-io/operator/pkg/health"
+)
+
+// fieldOwner is the field manager Argo CD uses for server-side apply
+const fieldOwner = client.FieldOwner("argocd-controller")
+
+// ragmeManifest returns a RAGme manifest as a GitOps tool would apply it
+func ragmeManifest(namespace string, apiReplicas int64, sidecars []interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "ragme.io/v1",
+		"kind":       "RAGme",
+		"metadata": map[string]interface{}{
+			"name":      "gitops",
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"replicas": map[string]interface{}{"api": apiReplicas, "mcp": int64(1), "agent": int64(1), "frontend": int64(1)},
+			"authentication": map[string]interface{}{
+				"session": map[string]interface{}{"store": map[string]interface{}{"type": "redis"}},
+			},
+			"components": map[string]interface{}{
+				"api": map[string]interface{}{"sidecars": sidecars},
+			},
+		},
+	}}
+}
+
+var _ = Describe("GitOps sync and health", Ordered, func() {
+	var (
+		ctx       = context.Background()
+		k8sClient client.Client
+		namespace string
+	)
+
+	// healthOf returns the health of the applied RAGme as Argo CD would report it
+	healthOf := func() health.Status {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("ragme.io/v1")
+		obj.SetKind("RAGme")
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "gitops", Namespace: namespace}, obj); err != nil {
+			return ""
+		}
+		result, err := health.Check(obj)
+		Expect(err).NotTo(HaveOccurred())
+		GinkgoWriter.Printf("health: %s (%s)\n", result.Status, result.Message)
+		return result.Status
+	}
+
+	sync := func(obj *unstructured.Unstructured) {
+		Expect(k8sClient.Patch(ctx, obj, client.Apply, fieldOwner, client.ForceOwnership)).To(Succeed())
+	}
+
+	BeforeAll(func() {
+		cfg, err := config.GetConfig()
+		Expect(err).NotTo(HaveOccurred())
+		k8sClient, err = client.New(cfg, client.Options{})
+		Expect(err).NotTo(HaveOccurred())
+
+		namespace = fmt.Sprintf("ragme-e2e-%d", time.Now().Unix())
+		Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).To(Succeed())
+	})
+
+	AfterAll(func() {
+		if k8sClient != nil {
+			_ = k8sClient.Delete(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+		}
+	})
+
+	It("becomes healthy after the initial sync", func() {
+		sync(ragmeManifest(namespace, 2, nil))
+		Eventually(healthOf, 3*time.Minute, 2*time.Second).Should(Equal(health.Healthy))
+	})
+
+	It("reports progress until a spec change is observed, then becomes healthy again", func() {
+		sync(ragmeManifest(namespace, 3, nil))
+		Eventually(healthOf, 3*time.Minute, 2*time.Second).Should(Equal(health.Healthy))
+
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("ragme.io/v1")
+		obj.SetKind("RAGme")
+		Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "gitops", Namespace: namespace}, obj)).To(Succeed())
+		observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+		Expect(observed).To(Equal(obj.GetGeneration()))
+	})
+
+	It("reports an invalid spec as degraded", func() {
+		sync(ragmeManifest(namespace, 3, []interface{}{map[string]interface{}{"name": "api", "image": "busybox"}}))
+		Eventually(healthOf, time.Minute, 2*time.Second).Should(Equal(health.Degraded))
+	})
+
+	It("recovers once the spec is fixed", func() {
+		sync(ragmeManifest(namespace, 3, nil))
+		Eventually(healthOf, 3*time.Minute, 2*time.Second).Should(Equal(health.Healthy))
+	})
+})